@@ -44,6 +44,19 @@ func (p *EvaluationResult) AddPolicies(grantedPolicies []*pms.Policy, deniedPoli
 	}
 }
 
+// AddCombinedPolicies adds the applicable policies, the one deciding the result takes effect and the others are ignored
+func (p *EvaluationResult) AddCombinedPolicies(policies []*pms.Policy, takeEffect *pms.Policy) {
+	for _, metaPolicy := range policies {
+		var apiEvaluatedPolicy EvaluatedPolicy
+		if metaPolicy == takeEffect {
+			convertMetaPolicy2ApiEvaluatedPolicy(metaPolicy, &apiEvaluatedPolicy, Evaluation_TakeEffect, strconv.FormatBool(true))
+		} else {
+			convertMetaPolicy2ApiEvaluatedPolicy(metaPolicy, &apiEvaluatedPolicy, Evaluation_Ignored, "")
+		}
+		p.Policies = append(p.Policies, &apiEvaluatedPolicy)
+	}
+}
+
 // 	This function needs to be updated once the "Strategy" is removed from Policy
 func convertMetaPolicy2ApiEvaluatedPolicy(metaPolicy *pms.Policy, apiPolicy *EvaluatedPolicy, policyStatus string, evaluationResult string) {
 	if metaPolicy == nil || apiPolicy == nil {
@@ -57,6 +70,7 @@ func convertMetaPolicy2ApiEvaluatedPolicy(metaPolicy *pms.Policy, apiPolicy *Eva
 			Resource:           permission.Resource,
 			Actions:            permission.Actions,
 			ResourceExpression: permission.ResourceExpression,
+			ResourceGlob:       permission.ResourceGlob,
 		})
 	}
 
@@ -94,6 +108,7 @@ func convertMetaRolePolicy2ApiEvaluatedRolePolicy(metaRolePolicy *pms.RolePolicy
 	apiRolePolicy.Principals = metaRolePolicy.Principals
 	apiRolePolicy.Resources = metaRolePolicy.Resources
 	apiRolePolicy.ResourceExpressions = metaRolePolicy.ResourceExpressions
+	apiRolePolicy.ResourceGlobs = metaRolePolicy.ResourceGlobs
 
 	if len(metaRolePolicy.Condition) > 0 {
 		apiRolePolicy.Condition = &EvaluatedCondition{
@@ -11,6 +11,10 @@ type PolicyEvaluator interface {
 	// IsAllowed returns if the subject has been granted to a resource specified by a request context
 	IsAllowed(c RequestContext) (allowed bool, reason Reason, err error)
 
+	// IsAllowedBatch returns if the subject has been granted to each of the accesses, in the same order.
+	// An error is returned if the token of the subject fails to be asserted, errors of an access are in its decision.
+	IsAllowedBatch(subject *Subject, accesses []Access) ([]Decision, error)
+
 	// GetAllGrantedRoles returns the granted app roles in an application.
 	GetAllGrantedRoles(c RequestContext) ([]string, error)
 
@@ -22,6 +26,10 @@ type PolicyEvaluator interface {
 	Discover
 
 	Diagnose
+
+	AccessReviewer
+
+	PartialEvaluator
 }
 
 type Discover interface {
@@ -33,3 +41,14 @@ type Diagnose interface {
 	// returns all the policies related to a subject
 	Diagnose(c RequestContext) (*EvaluationResult, error)
 }
+
+type AccessReviewer interface {
+	// WhoCanAccess returns the principals which could be granted, or denied, an action on a resource
+	WhoCanAccess(serviceName, resource, action string) (*AccessReview, error)
+}
+
+type PartialEvaluator interface {
+	// PartialEvaluate returns the residual condition on the unknown attributes under which the subject is allowed
+	// the action on the resource, or on the resources matching a pattern like /books/*
+	PartialEvaluate(c RequestContext, unknowns []string) (*PartialEvaluation, error)
+}
@@ -0,0 +1,257 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package ads
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Operators of a residual condition
+const (
+	ResidualTrue  = "true"
+	ResidualFalse = "false"
+	ResidualAnd   = "and"
+	ResidualOr    = "or"
+	ResidualNot   = "not"
+
+	ResidualEQ    = "=="
+	ResidualNEQ   = "!="
+	ResidualGT    = ">"
+	ResidualGTE   = ">="
+	ResidualLT    = "<"
+	ResidualLTE   = "<="
+	ResidualRegex = "=~"
+	// ResidualNotRegex is true if the attribute doesn't match the regular expression
+	ResidualNotRegex = "!~"
+	// ResidualIn is true if the attribute is one of the values
+	ResidualIn = "in"
+)
+
+// Residual is the condition left after partial evaluation, which only depends on the unknown attributes.
+// It's true, false, the and, or, not of its operands, or the comparison of an unknown attribute with a value
+// or another unknown attribute.
+type Residual struct {
+	Op       string      `json:"op"`
+	Operands []*Residual `json:"operands,omitempty"`
+	// Attribute is the unknown attribute compared, nested fields are separated by dots
+	Attribute string `json:"attribute,omitempty"`
+	// Value is what the attribute is compared with, a list of values for the in operator
+	Value interface{} `json:"value,omitempty"`
+	// OtherAttribute is the unknown attribute the attribute is compared with instead of a value
+	OtherAttribute string `json:"otherAttribute,omitempty"`
+}
+
+// PartialEvaluation is the result of partial evaluation
+type PartialEvaluation struct {
+	// Residual is the condition the unknown attributes must meet to be allowed
+	Residual     *Residual `json:"residual"`
+	GrantedRoles []string  `json:"grantedRoles,omitempty"`
+}
+
+// SQL dialects, named after the drivers of the sql store
+const (
+	SQLDialectSQLite   = "sqlite3"
+	SQLDialectPostgres = "postgres"
+	SQLDialectMySQL    = "mysql"
+)
+
+// ToSQL translates a residual to a SQL WHERE clause and its arguments, attributes are columns and nested
+// fields are qualified columns. The placeholders, the quoting of columns and the regular expression operators
+// are of dialect, which is SQLDialectSQLite if it's empty.
+func (r *Residual) ToSQL(dialect string) (string, []interface{}, error) {
+	if len(dialect) == 0 {
+		dialect = SQLDialectSQLite
+	}
+	switch dialect {
+	case SQLDialectSQLite, SQLDialectPostgres, SQLDialectMySQL:
+	default:
+		return "", nil, fmt.Errorf("unsupported sql dialect %q, it should be %s, %s or %s", dialect, SQLDialectSQLite, SQLDialectPostgres, SQLDialectMySQL)
+	}
+	t := sqlTranslator{dialect: dialect}
+	where, err := t.translate(r)
+	if err != nil {
+		return "", nil, err
+	}
+	return where, t.args, nil
+}
+
+type sqlTranslator struct {
+	dialect string
+	args    []interface{}
+}
+
+var sqlComparators = map[string]string{
+	ResidualEQ:  "=",
+	ResidualNEQ: "<>",
+	ResidualGT:  ">",
+	ResidualGTE: ">=",
+	ResidualLT:  "<",
+	ResidualLTE: "<=",
+}
+
+func (t *sqlTranslator) translate(r *Residual) (string, error) {
+	switch r.Op {
+	case ResidualTrue:
+		return "1 = 1", nil
+	case ResidualFalse:
+		return "1 = 0", nil
+	case ResidualAnd, ResidualOr:
+		if len(r.Operands) == 0 {
+			return "", fmt.Errorf("no operand of %s", r.Op)
+		}
+		clauses := make([]string, 0, len(r.Operands))
+		for _, operand := range r.Operands {
+			clause, err := t.translate(operand)
+			if err != nil {
+				return "", err
+			}
+			clauses = append(clauses, "("+clause+")")
+		}
+		return strings.Join(clauses, " "+strings.ToUpper(r.Op)+" "), nil
+	case ResidualNot:
+		if len(r.Operands) != 1 {
+			return "", fmt.Errorf("%s should have one operand", r.Op)
+		}
+		clause, err := t.translate(r.Operands[0])
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + clause + ")", nil
+	}
+
+	column, err := t.column(r.Attribute)
+	if err != nil {
+		return "", err
+	}
+	if comparator, ok := sqlComparators[r.Op]; ok {
+		if len(r.OtherAttribute) != 0 {
+			other, err := t.column(r.OtherAttribute)
+			if err != nil {
+				return "", err
+			}
+			return column + " " + comparator + " " + other, nil
+		}
+		return column + " " + comparator + " " + t.arg(r.Value), nil
+	}
+	if len(r.OtherAttribute) != 0 {
+		return "", fmt.Errorf("unsupported comparison of attributes %s %s %s", r.Attribute, r.Op, r.OtherAttribute)
+	}
+	switch r.Op {
+	case ResidualRegex, ResidualNotRegex:
+		operator := "REGEXP"
+		if t.dialect == SQLDialectPostgres {
+			operator = "~"
+		}
+		if r.Op == ResidualNotRegex {
+			if t.dialect == SQLDialectPostgres {
+				operator = "!~"
+			} else {
+				operator = "NOT " + operator
+			}
+		}
+		return column + " " + operator + " " + t.arg(r.Value), nil
+	case ResidualIn:
+		values, ok := r.Value.([]interface{})
+		if !ok {
+			return "", fmt.Errorf("the value of %s should be a list, but got %v", r.Op, r.Value)
+		}
+		if len(values) == 0 {
+			return "1 = 0", nil
+		}
+		placeholders := make([]string, 0, len(values))
+		for _, value := range values {
+			placeholders = append(placeholders, t.arg(value))
+		}
+		return column + " IN (" + strings.Join(placeholders, ", ") + ")", nil
+	}
+	return "", fmt.Errorf("unsupported residual operator %q", r.Op)
+}
+
+func (t *sqlTranslator) arg(value interface{}) string {
+	t.args = append(t.args, value)
+	if t.dialect == SQLDialectPostgres {
+		return "$" + strconv.Itoa(len(t.args))
+	}
+	return "?"
+}
+
+// column quotes the parts of an attribute, the quotes are not allowed in attributes
+func (t *sqlTranslator) column(attribute string) (string, error) {
+	quote := `"`
+	if t.dialect == SQLDialectMySQL {
+		quote = "`"
+	}
+	parts := strings.Split(attribute, ".")
+	for i, part := range parts {
+		if len(part) == 0 || strings.Contains(part, quote) {
+			return "", fmt.Errorf("invalid column %q", attribute)
+		}
+		parts[i] = quote + part + quote
+	}
+	return strings.Join(parts, "."), nil
+}
+
+var mongoComparators = map[string]string{
+	ResidualEQ:  "$eq",
+	ResidualNEQ: "$ne",
+	ResidualGT:  "$gt",
+	ResidualGTE: "$gte",
+	ResidualLT:  "$lt",
+	ResidualLTE: "$lte",
+	ResidualIn:  "$in",
+}
+
+// ToMongo translates a residual to a MongoDB filter document, attributes are fields and nested fields are
+// dotted fields. Comparisons of two attributes are translated to $expr.
+func (r *Residual) ToMongo() (map[string]interface{}, error) {
+	switch r.Op {
+	case ResidualTrue:
+		return map[string]interface{}{}, nil
+	case ResidualFalse:
+		return map[string]interface{}{"$expr": false}, nil
+	case ResidualAnd, ResidualOr, ResidualNot:
+		if len(r.Operands) == 0 || (r.Op == ResidualNot && len(r.Operands) != 1) {
+			return nil, fmt.Errorf("invalid operands of %s", r.Op)
+		}
+		filters := make([]interface{}, 0, len(r.Operands))
+		for _, operand := range r.Operands {
+			filter, err := operand.ToMongo()
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, filter)
+		}
+		operator := map[string]string{ResidualAnd: "$and", ResidualOr: "$or", ResidualNot: "$nor"}[r.Op]
+		return map[string]interface{}{operator: filters}, nil
+	}
+
+	if len(r.Attribute) == 0 {
+		return nil, fmt.Errorf("no attribute in %s", r.Op)
+	}
+	if comparator, ok := mongoComparators[r.Op]; ok {
+		if len(r.OtherAttribute) != 0 {
+			if r.Op == ResidualIn {
+				return nil, fmt.Errorf("unsupported comparison of attributes %s %s %s", r.Attribute, r.Op, r.OtherAttribute)
+			}
+			return map[string]interface{}{
+				"$expr": map[string]interface{}{comparator: []interface{}{"$" + r.Attribute, "$" + r.OtherAttribute}},
+			}, nil
+		}
+		if _, ok := r.Value.([]interface{}); r.Op == ResidualIn && !ok {
+			return nil, fmt.Errorf("the value of %s should be a list, but got %v", r.Op, r.Value)
+		}
+		return map[string]interface{}{r.Attribute: map[string]interface{}{comparator: r.Value}}, nil
+	}
+	switch r.Op {
+	case ResidualRegex:
+		return map[string]interface{}{r.Attribute: map[string]interface{}{"$regex": r.Value}}, nil
+	case ResidualNotRegex:
+		return map[string]interface{}{
+			"$nor": []interface{}{map[string]interface{}{r.Attribute: map[string]interface{}{"$regex": r.Value}}},
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported residual operator %q", r.Op)
+}
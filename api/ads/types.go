@@ -30,14 +30,63 @@ type RequestContext struct {
 	Attributes  map[string]interface{} `json:"attributes,omitempty"`
 }
 
+// Access is a resource and action in a service to check for the subject shared by a batch
+type Access struct {
+	ServiceName string                 `json:"serviceName,omitempty"`
+	Resource    string                 `json:"resource,omitempty"`
+	Action      string                 `json:"action,omitempty"`
+	Attributes  map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// Decision is the result of checking an access in a batch
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Reason  Reason `json:"reason"`
+	Error   error  `json:"-"`
+}
+
+// AccessPath is a way through which the principals could be granted, or denied, an access by a policy
+type AccessPath struct {
+	// Principals get the access only if a subject has all of them
+	Principals []string `json:"principals"`
+	// Roles are the roles granted in turn by role policies, from the role in the policy to the role granted to the principals
+	Roles         []string `json:"roles,omitempty"`
+	RolePolicyIDs []string `json:"rolePolicyIDs,omitempty"`
+	PolicyID      string   `json:"policyID"`
+	PolicyName    string   `json:"policyName,omitempty"`
+	// Conditions of the policy and the role policies in the path, the path is taken only if all of them are true
+	Conditions []string `json:"conditions,omitempty"`
+}
+
+// RoleDenial is a deny role policy which stops the principals getting a role in a granted access path
+type RoleDenial struct {
+	Principals     []string `json:"principals"`
+	Role           string   `json:"role"`
+	RolePolicyID   string   `json:"rolePolicyID"`
+	RolePolicyName string   `json:"rolePolicyName,omitempty"`
+	Condition      string   `json:"condition,omitempty"`
+}
+
+// AccessReview lists who could perform an action on a resource
+type AccessReview struct {
+	ServiceName string        `json:"serviceName"`
+	Resource    string        `json:"resource"`
+	Action      string        `json:"action"`
+	Granted     []*AccessPath `json:"granted"`
+	Denied      []*AccessPath `json:"denied"`
+	DeniedRoles []*RoleDenial `json:"deniedRoles,omitempty"`
+}
+
 type EvaluationResult struct {
-	Allowed      bool                   `json:"allowed"`
-	Reason       Reason                 `json:"reason"`
-	RequestCtx   *RequestContext        `json:"requestContext,omitempty"`
-	Attributes   map[string]interface{} `json:"attributes,omitempty"`
-	GrantedRoles []string               `json:"grantedRoles,omitempty"`
-	RolePolicies []*EvaluatedRolePolicy `json:"rolePolicies,omitempty"`
-	Policies     []*EvaluatedPolicy     `json:"policies,omitempty"`
+	Allowed            bool                   `json:"allowed"`
+	Reason             Reason                 `json:"reason"`
+	RequestCtx         *RequestContext        `json:"requestContext,omitempty"`
+	Attributes         map[string]interface{} `json:"attributes,omitempty"`
+	GrantedRoles       []string               `json:"grantedRoles,omitempty"`
+	RolePolicies       []*EvaluatedRolePolicy `json:"rolePolicies,omitempty"`
+	Policies           []*EvaluatedPolicy     `json:"policies,omitempty"`
+	CombiningAlgorithm string                 `json:"combiningAlgorithm,omitempty"`
+	Explanation        string                 `json:"explanation,omitempty"`
 }
 
 type EvaluatedPolicy struct {
@@ -59,6 +108,7 @@ type EvaluatedRolePolicy struct {
 	Principals          []string            `json:"principals,omitempty"`
 	Resources           []string            `json:"resources,omitempty"`
 	ResourceExpressions []string            `json:"resourceExpression,omitempty"`
+	ResourceGlobs       []string            `json:"resourceGlobs,omitempty"`
 	Condition           *EvaluatedCondition `json:"condition,omitempty"`
 }
 
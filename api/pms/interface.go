@@ -11,6 +11,8 @@ type StoreManager interface {
 
 type FunctionManager interface {
 	CreateFunction(function *Function) (*Function, error)
+	// UpdateFunction replaces an existing function, a positive Revision is checked against the stored one
+	UpdateFunction(function *Function) (*Function, error)
 	DeleteFunction(funcName string) error
 	DeleteFunctions() error
 	GetFunction(funcName string) (*Function, error)
@@ -20,6 +22,9 @@ type FunctionManager interface {
 
 type ServiceManager interface {
 	CreateService(service *Service) error
+	// UpdateService updates the type and metadata of an existing service, policies and role policies are untouched.
+	// A positive Revision is checked against the stored one
+	UpdateService(service *Service) error
 	DeleteService(serviceName string) error
 	DeleteServices() error
 	GetService(serviceName string) (*Service, error)
@@ -31,22 +36,38 @@ type ServiceManager interface {
 
 type PolicyManager interface {
 	CreatePolicy(serviceName string, policy *Policy) (*Policy, error)
+	// UpdatePolicy replaces an existing policy, a positive Revision is checked against the stored one
+	UpdatePolicy(serviceName string, policy *Policy) (*Policy, error)
 	DeletePolicy(serviceName string, id string) error
 	DeletePolicies(serviceName string) error
 	GetPolicy(serviceName string, id string) (*Policy, error)
 	ListAllPolicies(serviceName string, filter string) ([]*Policy, error)
+	// ListPolicies lists a page of policies in a stable order, and the token to continue with, which is empty
+	// if there are no more policies
+	ListPolicies(serviceName string, opts *ListOptions) ([]*Policy, string, error)
 	GetPolicyCount(serviceName string) (int64, error)
 }
 
 type RolePolicyManager interface {
 	CreateRolePolicy(serviceName string, policy *RolePolicy) (*RolePolicy, error)
+	// UpdateRolePolicy replaces an existing role policy, a positive Revision is checked against the stored one
+	UpdateRolePolicy(serviceName string, policy *RolePolicy) (*RolePolicy, error)
 	DeleteRolePolicy(serviceName string, id string) error
 	DeleteRolePolicies(serviceName string) error
 	GetRolePolicy(serviceName string, id string) (*RolePolicy, error)
 	ListAllRolePolicies(serviceName string, filter string) ([]*RolePolicy, error)
+	// ListRolePolicies lists a page of role policies in a stable order, and the token to continue with, which is
+	// empty if there are no more role policies
+	ListRolePolicies(serviceName string, opts *ListOptions) ([]*RolePolicy, string, error)
 	GetRolePolicyCount(serviceName string) (int64, error)
 }
 
+type TransactionManager interface {
+	// ExecuteTransaction applies the operations in order, either all of them or none of them take effect.
+	// The returned operations carry the created or updated entities
+	ExecuteTransaction(ops []*Operation) ([]*Operation, error)
+}
+
 type PolicyStoreWatcher interface {
 	Watch() (StorageChangeChannel, error)
 	StopWatch()
@@ -58,6 +79,7 @@ type PolicyStoreManager interface {
 	PolicyManager
 	RolePolicyManager
 	FunctionManager
+	TransactionManager
 	PolicyStoreWatcher
 }
 
@@ -6,6 +6,7 @@ package pms
 type Permission struct {
 	Resource           string   `json:"resource,omitempty"`
 	ResourceExpression string   `json:"resourceExpression,omitempty"`
+	ResourceGlob       string   `json:"resourceGlob,omitempty"`
 	Actions            []string `json:"actions,omitempty"`
 }
 
@@ -18,6 +19,7 @@ type Function struct {
 	ResultCachable bool              `json:"resultCachable,omitempty" bson:"resultcachable,omitempty"` //false by default
 	ResultTTL      int64             `json:"resultTTL,omitempty" bson:"resultttl,omitempty"`           // TTL of function result in second
 	Metadata       map[string]string `json:"metadata,omitempty" bson:"metadata,omitempty"`
+	Revision       int64             `json:"revision,omitempty" bson:"revision,omitempty"`
 }
 
 type Policy struct {
@@ -27,7 +29,9 @@ type Policy struct {
 	Permissions []*Permission     `json:"permissions,omitempty" bson:"permissions,omitempty"`
 	Principals  [][]string        `json:"principals,omitempty" bson:"principals,omitempty"`
 	Condition   string            `json:"condition,omitempty" bson:"condition,omitempty"`
+	Priority    int               `json:"priority,omitempty" bson:"priority,omitempty"`
 	Metadata    map[string]string `json:"metadata,omitempty" bson:"metadata,omitempty"`
+	Revision    int64             `json:"revision,omitempty" bson:"revision,omitempty"`
 }
 
 const (
@@ -48,23 +52,131 @@ type RolePolicy struct {
 	Principals          []string          `json:"principals,omitempty" bson:"principals,omitempty"`
 	Resources           []string          `json:"resources,omitempty" bson:"resources,omitempty"`
 	ResourceExpressions []string          `json:"resourceExpressions,omitempty" bson:"resourceexpressions,omitempty"`
+	ResourceGlobs       []string          `json:"resourceGlobs,omitempty" bson:"resourceglobs,omitempty"`
 	Condition           string            `json:"condition,omitempty" bson:"condition,omitempty"`
 	Metadata            map[string]string `json:"metadata,omitempty" bson:"metadata,omitempty"`
+	Revision            int64             `json:"revision,omitempty" bson:"revision,omitempty"`
 }
 
 type Service struct {
-	Name         string            `json:"name" binding:"required"  bson:"_id"`
-	Type         string            `json:"type,omitempty" bson:"type,omitempty"`
-	Policies     []*Policy         `json:"policies,omitempty" bson:"policies,omitempty"`
-	RolePolicies []*RolePolicy     `json:"rolePolicies,omitempty" bson:"rolepolicies,omitempty"`
-	Metadata     map[string]string `json:"metadata,omitempty" bson:"metadata,omitempty"`
+	Name               string            `json:"name" binding:"required"  bson:"_id"`
+	Type               string            `json:"type,omitempty" bson:"type,omitempty"`
+	CombiningAlgorithm string            `json:"combiningAlgorithm,omitempty" bson:"combiningalgorithm,omitempty"`
+	DefaultEffect      string            `json:"defaultEffect,omitempty" bson:"defaulteffect,omitempty"`
+	Attributes         []*Attribute      `json:"attributes,omitempty" bson:"attributes,omitempty"`
+	Policies           []*Policy         `json:"policies,omitempty" bson:"policies,omitempty"`
+	RolePolicies       []*RolePolicy     `json:"rolePolicies,omitempty" bson:"rolepolicies,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty" bson:"metadata,omitempty"`
+	Revision           int64             `json:"revision,omitempty" bson:"revision,omitempty"`
 }
 
 const GlobalService = "global"
 
+// Attribute declares the type of an attribute the requests of a service could have. If a service declares any
+// attribute, the conditions of its policies and role policies could only reference the declared and the built-in
+// attributes, and are type checked when they are created or updated.
+type Attribute struct {
+	Name string `json:"name" bson:"name"`
+	Type string `json:"type" bson:"type"`
+	// List is true if the attribute is a list of values of Type
+	List bool `json:"list,omitempty" bson:"list,omitempty"`
+}
+
+// Types of attributes, which are the data types of the attributes in authorization requests
+const (
+	AttributeTypeString   = "string"
+	AttributeTypeNumeric  = "numeric"
+	AttributeTypeBool     = "bool"
+	AttributeTypeDatetime = "datetime"
+)
+
+// AttributeTypes are the supported types of attributes
+var AttributeTypes = []string{AttributeTypeString, AttributeTypeNumeric, AttributeTypeBool, AttributeTypeDatetime}
+
+// Policy combining algorithms of a service, which combine the effects of the policies applicable to a request.
+// DefaultEffect of a service is the decision when no policy applies, it is deny if not set.
+const (
+	// DenyOverrides denies the access if any deny policy applies
+	DenyOverrides = "deny-overrides"
+	// PermitOverrides grants the access if any grant policy applies
+	PermitOverrides = "permit-overrides"
+	// FirstApplicable takes the effect of the applicable policy with the highest priority, policies with
+	// the same priority are ordered by ID
+	FirstApplicable = "first-applicable"
+	// DenyUnlessPermit grants the access if any grant policy applies, and denies it otherwise, the default effect
+	// could only be deny
+	DenyUnlessPermit = "deny-unless-permit"
+	// PermitUnlessDeny denies the access if any deny policy applies, and grants it otherwise, the default effect
+	// could only be grant
+	PermitUnlessDeny = "permit-unless-deny"
+)
+
+// CombiningAlgorithms are the supported policy combining algorithms
+var CombiningAlgorithms = []string{DenyOverrides, PermitOverrides, FirstApplicable, DenyUnlessPermit, PermitUnlessDeny}
+
 type PolicyStore struct {
 	Functions []*Function `json:"functions,omitempty"`
 	Services  []*Service  `json:"services,omitempty"`
+	// Revision is the store level revision counter, it is only used by file store
+	Revision int64 `json:"revision,omitempty"`
+}
+
+// Operations in a transaction
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// Kinds of entities an operation could apply to
+const (
+	KindService    = "service"
+	KindPolicy     = "policy"
+	KindRolePolicy = "rolePolicy"
+	KindFunction   = "function"
+)
+
+// ListOptions are the options to list a page of policies or role policies. Filter is an expression of package
+// pkg/store/filter, e.g. "effect eq deny and principal eq user:Alice". At most Limit entities are listed if it is
+// positive, and the page continues from the previous one if Continue is the continue token returned with it.
+type ListOptions struct {
+	Filter   string `json:"filter,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+	Continue string `json:"continue,omitempty"`
+}
+
+// Operation is one step of a transaction. Create and update operations carry the entity of Kind,
+// delete operations identify the entity by ID, which is the name for services and functions.
+// ServiceName is required for policies and role policies. ChangedBy is who deletes the entity, which is kept in its history.
+// Revision is the expected revision of the entity to delete, which is not checked if it is 0.
+type Operation struct {
+	Op          string      `json:"op"`
+	Kind        string      `json:"kind"`
+	ServiceName string      `json:"serviceName,omitempty"`
+	ID          string      `json:"id,omitempty"`
+	Service     *Service    `json:"service,omitempty"`
+	Policy      *Policy     `json:"policy,omitempty"`
+	RolePolicy  *RolePolicy `json:"rolePolicy,omitempty"`
+	Function    *Function   `json:"function,omitempty"`
+	ChangedBy   string      `json:"changedBy,omitempty"`
+	Revision    int64       `json:"revision,omitempty"`
+}
+
+// HistoryRecord is one version of a service, policy, role policy or function, which is kept when the entity is changed.
+// Op is the change made this version, and the entity of Kind is empty if it is deleted.
+// A service is recorded without its policies and role policies, which have their own history.
+type HistoryRecord struct {
+	Kind        string      `json:"kind" bson:"kind"`
+	ServiceName string      `json:"serviceName,omitempty" bson:"serviceName,omitempty"`
+	ID          string      `json:"id" bson:"id"`
+	Version     int64       `json:"version" bson:"version"`
+	Op          string      `json:"op" bson:"op"`
+	ChangedBy   string      `json:"changedBy,omitempty" bson:"changedBy,omitempty"`
+	ChangedAt   string      `json:"changedAt,omitempty" bson:"changedAt,omitempty"`
+	Service     *Service    `json:"service,omitempty" bson:"service,omitempty"`
+	Policy      *Policy     `json:"policy,omitempty" bson:"policy,omitempty"`
+	RolePolicy  *RolePolicy `json:"rolePolicy,omitempty" bson:"rolePolicy,omitempty"`
+	Function    *Function   `json:"function,omitempty" bson:"function,omitempty"`
 }
 
 type PolicyAndRolePolicyCount struct {
@@ -86,6 +198,8 @@ const (
 	FUNCTION_ADD
 	SYNC_RELOAD
 	FULL_RELOAD
+	// BATCH carries the events of one transaction, which should be applied together
+	BATCH
 )
 
 type StoreChangeEvent struct {
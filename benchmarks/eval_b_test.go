@@ -186,6 +186,76 @@ func BenchmarkLargeExp(b *testing.B) {
 	runTest(b, rc, filePath, true)
 }
 
+// The cases below grant the policies to the same group, so every request has to match the resource against the
+// resource expressions of all the policies.
+
+func BenchmarkLargeSharedExp(b *testing.B) {
+	runSharedExpTest(b, 10000, func(w io.Writer, pno int) error {
+		_, err := fmt.Fprintf(w, "GRANT GROUP readers read expr:/books/type%d/[a-z]+/cover$\n", pno)
+		return err
+	}, "/books/type5000/fiction/cover")
+}
+
+func BenchmarkHugeSharedExp(b *testing.B) {
+	runSharedExpTest(b, 100000, func(w io.Writer, pno int) error {
+		_, err := fmt.Fprintf(w, "GRANT GROUP readers read expr:/books/type%d/[a-z]+/cover$\n", pno)
+		return err
+	}, "/books/type50000/fiction/cover")
+}
+
+func BenchmarkLargeSharedPrefixExp(b *testing.B) {
+	runSharedExpTest(b, 10000, func(w io.Writer, pno int) error {
+		_, err := fmt.Fprintf(w, "GRANT GROUP readers read expr:/books/type%d/.*\n", pno)
+		return err
+	}, "/books/type5000/fiction/cover")
+}
+
+func BenchmarkLargeSharedRoleExp(b *testing.B) {
+	filePath, err := writePolicyFile(b.Name(), 10000, 10, func(w io.Writer, pno int) error {
+		_, err := fmt.Fprintf(w, "GRANT ROLE role%d read expr:/books/type%d/.*\n", pno, pno)
+		return err
+	}, func(w io.Writer, pno, uc int) error {
+		_, err := fmt.Fprintf(w, "GRANT GROUP readers role%d ON expr:/books/type%d/[a-z]+/cover$\n", pno, pno)
+		return err
+	})
+	if err != nil {
+		b.Fatalf("unable to write to policy file because of error %s", err)
+	}
+	defer os.Remove(filePath)
+
+	runTest(b, sharedExpRequest("/books/type5000/fiction/cover"), filePath, true)
+}
+
+func runSharedExpTest(b *testing.B, pc int, pw func(w io.Writer, pno int) error, resource string) {
+	filePath, err := writePolicyFile(b.Name(), pc, 10, pw, simpleRolePolicyWriter)
+	if err != nil {
+		b.Fatalf("unable to write to policy file because of error %s", err)
+	}
+	defer os.Remove(filePath)
+
+	runTest(b, sharedExpRequest(resource), filePath, true)
+}
+
+func sharedExpRequest(resource string) ads.RequestContext {
+	return ads.RequestContext{
+		Subject: &ads.Subject{
+			Principals: []*ads.Principal{
+				{
+					Type: "user",
+					Name: "user5000-7",
+				},
+				{
+					Type: "group",
+					Name: "readers",
+				},
+			},
+		},
+		ServiceName: "bench",
+		Action:      "read",
+		Resource:    resource,
+	}
+}
+
 func BenchmarkLargeCond(b *testing.B) {
 	filePath, err := writePolicyFile(b.Name(), 10000, 10, func(w io.Writer, pno int) error {
 		_, err := fmt.Fprintf(w, "GRANT ROLE role%d read /books/book%d if att1 == \"val1\" && att2 == \"val2\"\n", pno, pno)
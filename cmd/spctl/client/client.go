@@ -15,10 +15,12 @@ import (
 	"strings"
 
 	"github.com/teramoby/speedle-plus/pkg/httputils"
+	"github.com/teramoby/speedle-plus/pkg/svcs"
 )
 
 type Client struct {
 	PMSEndpoint string
+	ADSEndpoint string
 	HTTPClient  *http.Client
 }
 
@@ -69,7 +71,7 @@ func (c *Client) Delete(paths []string, token string) error {
 	return c.delete(u, token)
 }
 
-func (c *Client) get(u *url.URL, paths []string, params url.Values, token string) ([]byte, error) {
+func (c *Client) get(u *url.URL, paths []string, params url.Values, token string) ([]byte, http.Header, error) {
 	if params != nil {
 		q := u.Query()
 		for name, val := range params {
@@ -80,7 +82,7 @@ func (c *Client) get(u *url.URL, paths []string, params url.Values, token string
 
 	req, err := http.NewRequest("GET", u.String(), nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	setAuthorizationHeader(req, token)
@@ -88,21 +90,21 @@ func (c *Client) get(u *url.URL, paths []string, params url.Values, token string
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
 		fmt.Printf("resp is : %v\n err is : %v\n", resp, err)
-		return nil, err
+		return nil, nil, err
 	}
 	switch resp.StatusCode {
 	case http.StatusNotFound:
-		return nil, fmt.Errorf("%s not found", strings.Join(paths, " "))
+		return nil, nil, fmt.Errorf("%s not found", strings.Join(paths, " "))
 	case http.StatusOK:
 		defer resp.Body.Close()
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		return body, nil
+		return body, resp.Header, nil
 	case http.StatusUnauthorized, http.StatusForbidden:
 		fmt.Println("Authentication or authorization failed. Please specify correct token using '--token' flag.")
-		return nil, errors.New(resp.Status)
+		return nil, nil, errors.New(resp.Status)
 	case http.StatusBadRequest:
 		defer resp.Body.Close()
 		body, err := ioutil.ReadAll(resp.Body)
@@ -110,12 +112,12 @@ func (c *Client) get(u *url.URL, paths []string, params url.Values, token string
 			var errorDetail httputils.ErrorResponse
 			err = json.Unmarshal(body, &errorDetail)
 			if err == nil {
-				return nil, errors.New(fmt.Sprintf("%s: %s", resp.Status, errorDetail.Error))
+				return nil, nil, errors.New(fmt.Sprintf("%s: %s", resp.Status, errorDetail.Error))
 			}
 		}
-		return nil, errors.New(resp.Status)
+		return nil, nil, errors.New(resp.Status)
 	default:
-		return nil, errors.New(resp.Status)
+		return nil, nil, errors.New(resp.Status)
 	}
 }
 func (c *Client) Get(paths []string, params url.Values, token string) ([]byte, error) {
@@ -123,11 +125,30 @@ func (c *Client) Get(paths []string, params url.Values, token string) ([]byte, e
 	if err != nil {
 		return nil, err
 	}
-	return c.get(u, paths, params, token)
+	body, _, err := c.get(u, paths, params, token)
+	return body, err
+}
+
+// GetPage gets a page of a list from PMS, and returns the body and the token to get the next page, which is empty
+// for the last page
+func (c *Client) GetPage(paths []string, params url.Values, token string) ([]byte, string, error) {
+	u, err := c.pmsURL(paths)
+	if err != nil {
+		return nil, "", err
+	}
+	body, header, err := c.get(u, paths, params, token)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, header.Get(svcs.ContinueTokenHeader), nil
 }
 
 func (c *Client) post(u *url.URL, paths []string, payload io.Reader, token string) (string, error) {
-	req, err := http.NewRequest("POST", u.String(), payload)
+	return c.send("POST", u, paths, payload, token)
+}
+
+func (c *Client) send(method string, u *url.URL, paths []string, payload io.Reader, token string) (string, error) {
+	req, err := http.NewRequest(method, u.String(), payload)
 	if err != nil {
 		return "", err
 	}
@@ -146,6 +167,8 @@ func (c *Client) post(u *url.URL, paths []string, payload io.Reader, token strin
 			return "", err
 		}
 		return string(body), nil
+	case http.StatusNotFound:
+		return "", fmt.Errorf("%s not found", strings.Join(paths, " "))
 	case http.StatusUnauthorized, http.StatusForbidden:
 		fmt.Println("Authentication or authorization failed. Please specify correct token using '--token' flag.")
 		return "", errors.New(resp.Status)
@@ -173,6 +196,41 @@ func (c *Client) Post(paths []string, payload io.Reader, token string) (string,
 	return c.post(u, paths, payload, token)
 }
 
+// PostADS posts to the paths of the authorization decision service
+func (c *Client) PostADS(paths []string, payload io.Reader, token string) (string, error) {
+	u, err := getURL(c.ADSEndpoint, paths)
+	if err != nil {
+		return "", err
+	}
+	return c.post(u, paths, payload, token)
+}
+
+// PostWithParams posts to the paths with query parameters, e.g. rollback?version=3
+func (c *Client) PostWithParams(paths []string, params url.Values, payload io.Reader, token string) (string, error) {
+	u, err := c.pmsURL(paths)
+	if err != nil {
+		return "", err
+	}
+	u.RawQuery = params.Encode()
+	return c.post(u, paths, payload, token)
+}
+
+func (c *Client) Put(paths []string, payload io.Reader, token string) (string, error) {
+	u, err := c.pmsURL(paths)
+	if err != nil {
+		return "", err
+	}
+	return c.send("PUT", u, paths, payload, token)
+}
+
+func (c *Client) Patch(paths []string, payload io.Reader, token string) (string, error) {
+	u, err := c.pmsURL(paths)
+	if err != nil {
+		return "", err
+	}
+	return c.send("PATCH", u, paths, payload, token)
+}
+
 func getURL(baseURL string, paths []string) (*url.URL, error) {
 	u, err := url.Parse(baseURL)
 	if err != nil {
@@ -0,0 +1,624 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/cmd/spctl/client"
+	"github.com/teramoby/speedle-plus/pkg/store"
+	"github.com/teramoby/speedle-plus/pkg/store/file"
+)
+
+var (
+	applyFileName string
+	dryRun        bool
+	prune         bool
+	outputFormat  string
+)
+
+var (
+	applyExample = `
+		# Converge the policy management service to the services and functions in a file
+		spctl apply -f policies.json
+
+		# Show what would be changed by the SPDL and json files in a directory, without changing anything
+		spctl apply -f ./policies --dry-run
+
+		# Show the changes in json format
+		spctl apply -f ./policies --dry-run --output=json
+
+		# Also delete the services, policies, role policies and functions which are not in the directory
+		spctl apply -f ./policies --prune`
+)
+
+// stateChange is a change to converge the policy management service to the desired state. The embedded
+// operation carries the desired entity, and Current is the entity in policy management service.
+type stateChange struct {
+	pms.Operation
+	Name    string      `json:"name"`
+	Current interface{} `json:"current,omitempty"`
+}
+
+func newApplyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply -f (FILE | DIRECTORY) [--dry-run] [--prune] [--output=text|json]",
+		Short: "Converge services | policies | role-policies | functions to the desired state in SPDL or json files",
+		Long: `Compare the desired state in SPDL files (*.spdl) or json files of policy store (*.json) with the policy management service,
+and create, update or delete entities to converge, all the changes are applied in one transaction.
+Policies and role policies are matched by name, the ones without name are matched by content.`,
+		Example: applyExample,
+		Run:     applyCommandFunc,
+	}
+
+	cmd.Flags().StringVarP(&applyFileName, "filename", "f", "", "file or directory that contains the desired state")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only show the changes, do not apply them")
+	cmd.Flags().BoolVar(&prune, "prune", false, "delete the entities which are not in the desired state")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "format of the changes, text or json")
+	return cmd
+}
+
+// readPolicyStoreFile reads services and functions from a SPDL or json file, the conditions in a SPDL file may call
+// the given functions besides the built-in ones
+func readPolicyStoreFile(fileName string, functions []string) (*pms.PolicyStore, error) {
+	if _, err := os.Stat(fileName); err != nil {
+		return nil, err
+	}
+	fileStore, err := store.NewStore(file.StoreType, map[string]interface{}{
+		file.FileLocationKey: fileName,
+		file.FunctionsKey:    functions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ps, err := fileStore.ReadPolicyStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", fileName, err)
+	}
+	return ps, nil
+}
+
+// loadDesiredState reads the desired state from a file, or all SPDL and json files in a directory. The json files are
+// read first, so the conditions in SPDL files may call the functions defined in them besides the given ones.
+func loadDesiredState(location string, functions []string) (*pms.PolicyStore, error) {
+	info, err := os.Stat(location)
+	if err != nil {
+		return nil, err
+	}
+	fileNames := []string{location}
+	if info.IsDir() {
+		fileNames = nil
+		files, err := ioutil.ReadDir(location)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if !f.IsDir() && (strings.HasSuffix(f.Name(), ".spdl") || strings.HasSuffix(f.Name(), ".json")) {
+				fileNames = append(fileNames, filepath.Join(location, f.Name()))
+			}
+		}
+		sort.SliceStable(fileNames, func(i, j int) bool {
+			return strings.HasSuffix(fileNames[i], ".json") && !strings.HasSuffix(fileNames[j], ".json")
+		})
+	}
+
+	functions = append([]string(nil), functions...)
+	var desired pms.PolicyStore
+	serviceFiles := make(map[string]string)
+	functionFiles := make(map[string]string)
+	for _, fileName := range fileNames {
+		ps, err := readPolicyStoreFile(fileName, functions)
+		if err != nil {
+			return nil, err
+		}
+		for _, service := range ps.Services {
+			if another, ok := serviceFiles[service.Name]; ok {
+				return nil, fmt.Errorf("service %q is defined in both %s and %s", service.Name, another, fileName)
+			}
+			serviceFiles[service.Name] = fileName
+			desired.Services = append(desired.Services, service)
+		}
+		for _, function := range ps.Functions {
+			if another, ok := functionFiles[function.Name]; ok {
+				return nil, fmt.Errorf("function %q is defined in both %s and %s", function.Name, another, fileName)
+			}
+			functionFiles[function.Name] = fileName
+			desired.Functions = append(desired.Functions, function)
+			functions = append(functions, function.Name)
+		}
+	}
+	return &desired, nil
+}
+
+// loadCurrentState gets all services with their policies and role policies, and all functions from policy management service
+func loadCurrentState(cli *client.Client) (*pms.PolicyStore, error) {
+	var current pms.PolicyStore
+	res, err := cli.Get([]string{"service"}, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	var services []*pms.Service
+	if err := json.Unmarshal(res, &services); err != nil {
+		return nil, err
+	}
+	for _, s := range services {
+		res, err := cli.Get([]string{"service", s.Name}, nil, "")
+		if err != nil {
+			return nil, err
+		}
+		var service pms.Service
+		if err := json.Unmarshal(res, &service); err != nil {
+			return nil, err
+		}
+		current.Services = append(current.Services, &service)
+	}
+	res, err = cli.Get([]string{"function"}, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(res, &current.Functions); err != nil {
+		return nil, err
+	}
+	return &current, nil
+}
+
+// functionNames returns the names of the functions
+func functionNames(functions []*pms.Function) []string {
+	names := make([]string, 0, len(functions))
+	for _, function := range functions {
+		names = append(names, function.Name)
+	}
+	return names
+}
+
+// normalizePolicy returns the definition of a policy, without the fields set by policy management service
+func normalizePolicy(policy *pms.Policy) *pms.Policy {
+	ret := *policy
+	ret.ID, ret.Metadata, ret.Revision = "", nil, 0
+	return &ret
+}
+
+func normalizeRolePolicy(rolePolicy *pms.RolePolicy) *pms.RolePolicy {
+	ret := *rolePolicy
+	ret.ID, ret.Metadata, ret.Revision = "", nil, 0
+	// SPDL gives empty resource expressions if there is no resource, which are omitted in json
+	if len(ret.Resources) == 0 {
+		ret.Resources = nil
+	}
+	if len(ret.ResourceExpressions) == 0 {
+		ret.ResourceExpressions = nil
+	}
+	if len(ret.ResourceGlobs) == 0 {
+		ret.ResourceGlobs = nil
+	}
+	return &ret
+}
+
+func normalizeFunction(function *pms.Function) *pms.Function {
+	ret := *function
+	ret.Metadata, ret.Revision = nil, 0
+	return &ret
+}
+
+func typeOfService(service *pms.Service) string {
+	if len(service.Type) == 0 {
+		return pms.TypeApplication
+	}
+	return service.Type
+}
+
+// serviceAttributes returns the service without its policies and role policies, with the default type
+func serviceAttributes(service *pms.Service) pms.Service {
+	return pms.Service{
+		Name:               service.Name,
+		Type:               typeOfService(service),
+		CombiningAlgorithm: service.CombiningAlgorithm,
+		DefaultEffect:      service.DefaultEffect,
+		Attributes:         service.Attributes,
+	}
+}
+
+// matchKey returns the name of a policy or role policy, or its content if it has no name
+func matchKey(name string, normalized interface{}) string {
+	if len(name) > 0 {
+		return name
+	}
+	content, _ := json.Marshal(normalized)
+	return "#" + string(content)
+}
+
+// displayName returns the name of a policy or role policy, or its ID if it has no name
+func displayName(name, id string) string {
+	if len(name) > 0 {
+		return name
+	}
+	return id
+}
+
+func diffPolicies(serviceName string, current, desired []*pms.Policy, prune bool) ([]*stateChange, error) {
+	var changes []*stateChange
+	currentPolicies := make(map[string]*pms.Policy)
+	for _, policy := range current {
+		key := matchKey(policy.Name, normalizePolicy(policy))
+		if _, ok := currentPolicies[key]; !ok {
+			currentPolicies[key] = policy
+		}
+	}
+	matched := make(map[string]bool)
+	for _, policy := range desired {
+		normalized := normalizePolicy(policy)
+		key := matchKey(policy.Name, normalized)
+		if matched[key] {
+			if len(policy.Name) == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("policy %q is defined more than once in service %q", policy.Name, serviceName)
+		}
+		matched[key] = true
+		existing, ok := currentPolicies[key]
+		if !ok {
+			changes = append(changes, &stateChange{
+				Operation: pms.Operation{Op: pms.OpCreate, Kind: pms.KindPolicy, ServiceName: serviceName, Policy: normalized},
+				Name:      policy.Name,
+			})
+			continue
+		}
+		if reflect.DeepEqual(normalizePolicy(existing), normalized) {
+			continue
+		}
+		updated := *normalized
+		updated.ID, updated.Revision = existing.ID, existing.Revision
+		changes = append(changes, &stateChange{
+			Operation: pms.Operation{Op: pms.OpUpdate, Kind: pms.KindPolicy, ServiceName: serviceName, ID: existing.ID, Policy: &updated},
+			Name:      policy.Name,
+			Current:   existing,
+		})
+	}
+	if prune {
+		for _, policy := range current {
+			key := matchKey(policy.Name, normalizePolicy(policy))
+			if matched[key] && currentPolicies[key] == policy {
+				continue
+			}
+			changes = append(changes, &stateChange{
+				Operation: pms.Operation{Op: pms.OpDelete, Kind: pms.KindPolicy, ServiceName: serviceName, ID: policy.ID},
+				Name:      displayName(policy.Name, policy.ID),
+				Current:   policy,
+			})
+		}
+	}
+	return changes, nil
+}
+
+func diffRolePolicies(serviceName string, current, desired []*pms.RolePolicy, prune bool) ([]*stateChange, error) {
+	var changes []*stateChange
+	currentRolePolicies := make(map[string]*pms.RolePolicy)
+	for _, rolePolicy := range current {
+		key := matchKey(rolePolicy.Name, normalizeRolePolicy(rolePolicy))
+		if _, ok := currentRolePolicies[key]; !ok {
+			currentRolePolicies[key] = rolePolicy
+		}
+	}
+	matched := make(map[string]bool)
+	for _, rolePolicy := range desired {
+		normalized := normalizeRolePolicy(rolePolicy)
+		key := matchKey(rolePolicy.Name, normalized)
+		if matched[key] {
+			if len(rolePolicy.Name) == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("role policy %q is defined more than once in service %q", rolePolicy.Name, serviceName)
+		}
+		matched[key] = true
+		existing, ok := currentRolePolicies[key]
+		if !ok {
+			changes = append(changes, &stateChange{
+				Operation: pms.Operation{Op: pms.OpCreate, Kind: pms.KindRolePolicy, ServiceName: serviceName, RolePolicy: normalized},
+				Name:      rolePolicy.Name,
+			})
+			continue
+		}
+		if reflect.DeepEqual(normalizeRolePolicy(existing), normalized) {
+			continue
+		}
+		updated := *normalized
+		updated.ID, updated.Revision = existing.ID, existing.Revision
+		changes = append(changes, &stateChange{
+			Operation: pms.Operation{Op: pms.OpUpdate, Kind: pms.KindRolePolicy, ServiceName: serviceName, ID: existing.ID, RolePolicy: &updated},
+			Name:      rolePolicy.Name,
+			Current:   existing,
+		})
+	}
+	if prune {
+		for _, rolePolicy := range current {
+			key := matchKey(rolePolicy.Name, normalizeRolePolicy(rolePolicy))
+			if matched[key] && currentRolePolicies[key] == rolePolicy {
+				continue
+			}
+			changes = append(changes, &stateChange{
+				Operation: pms.Operation{Op: pms.OpDelete, Kind: pms.KindRolePolicy, ServiceName: serviceName, ID: rolePolicy.ID},
+				Name:      displayName(rolePolicy.Name, rolePolicy.ID),
+				Current:   rolePolicy,
+			})
+		}
+	}
+	return changes, nil
+}
+
+/*
+diffState returns the changes to converge current state to the desired state:
+ 1. Functions and services which are not in current state are created, a service is created with its policies and role policies;
+ 2. Functions, services, policies and role policies which are different from the desired ones are updated;
+ 3. If prune is true, the ones which are not in the desired state are deleted;
+
+Creations and updates come before deletions.
+*/
+func diffState(current, desired *pms.PolicyStore, prune bool) ([]*stateChange, error) {
+	var changes, deletions []*stateChange
+
+	currentFunctions := make(map[string]*pms.Function)
+	for _, function := range current.Functions {
+		currentFunctions[function.Name] = function
+	}
+	desiredFunctions := make(map[string]bool)
+	for _, function := range desired.Functions {
+		if desiredFunctions[function.Name] {
+			return nil, fmt.Errorf("function %q is defined more than once", function.Name)
+		}
+		desiredFunctions[function.Name] = true
+		normalized := normalizeFunction(function)
+		existing, ok := currentFunctions[function.Name]
+		if !ok {
+			changes = append(changes, &stateChange{
+				Operation: pms.Operation{Op: pms.OpCreate, Kind: pms.KindFunction, Function: normalized},
+				Name:      function.Name,
+			})
+		} else if !reflect.DeepEqual(normalizeFunction(existing), normalized) {
+			normalized.Revision = existing.Revision
+			changes = append(changes, &stateChange{
+				Operation: pms.Operation{Op: pms.OpUpdate, Kind: pms.KindFunction, ID: function.Name, Function: normalized},
+				Name:      function.Name,
+				Current:   existing,
+			})
+		}
+	}
+	if prune {
+		for _, function := range current.Functions {
+			if !desiredFunctions[function.Name] {
+				deletions = append(deletions, &stateChange{
+					Operation: pms.Operation{Op: pms.OpDelete, Kind: pms.KindFunction, ID: function.Name},
+					Name:      function.Name,
+					Current:   function,
+				})
+			}
+		}
+	}
+
+	currentServices := make(map[string]*pms.Service)
+	for _, service := range current.Services {
+		currentServices[service.Name] = service
+	}
+	desiredServices := make(map[string]bool)
+	for _, service := range desired.Services {
+		if desiredServices[service.Name] {
+			return nil, fmt.Errorf("service %q is defined more than once", service.Name)
+		}
+		desiredServices[service.Name] = true
+		existing, ok := currentServices[service.Name]
+		if !ok {
+			policyChanges, err := diffPolicies(service.Name, nil, service.Policies, false)
+			if err != nil {
+				return nil, err
+			}
+			rolePolicyChanges, err := diffRolePolicies(service.Name, nil, service.RolePolicies, false)
+			if err != nil {
+				return nil, err
+			}
+			created := serviceAttributes(service)
+			for _, change := range policyChanges {
+				created.Policies = append(created.Policies, change.Policy)
+			}
+			for _, change := range rolePolicyChanges {
+				created.RolePolicies = append(created.RolePolicies, change.RolePolicy)
+			}
+			changes = append(changes, &stateChange{
+				Operation: pms.Operation{Op: pms.OpCreate, Kind: pms.KindService, Service: &created},
+				Name:      service.Name,
+			})
+			continue
+		}
+		desired, current := serviceAttributes(service), serviceAttributes(existing)
+		if desired.Type != current.Type || desired.CombiningAlgorithm != current.CombiningAlgorithm || desired.DefaultEffect != current.DefaultEffect ||
+			!reflect.DeepEqual(desired.Attributes, current.Attributes) {
+			desired.Revision = existing.Revision
+			changes = append(changes, &stateChange{
+				Operation: pms.Operation{Op: pms.OpUpdate, Kind: pms.KindService, ID: service.Name, Service: &desired},
+				Name:      service.Name,
+				Current: &pms.Service{Name: existing.Name, Type: existing.Type, CombiningAlgorithm: existing.CombiningAlgorithm,
+					DefaultEffect: existing.DefaultEffect, Attributes: existing.Attributes},
+			})
+		}
+		policyChanges, err := diffPolicies(service.Name, existing.Policies, service.Policies, prune)
+		if err != nil {
+			return nil, err
+		}
+		rolePolicyChanges, err := diffRolePolicies(service.Name, existing.RolePolicies, service.RolePolicies, prune)
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range append(policyChanges, rolePolicyChanges...) {
+			if change.Op == pms.OpDelete {
+				deletions = append(deletions, change)
+			} else {
+				changes = append(changes, change)
+			}
+		}
+	}
+	if prune {
+		for _, service := range current.Services {
+			if !desiredServices[service.Name] {
+				deletions = append(deletions, &stateChange{
+					Operation: pms.Operation{Op: pms.OpDelete, Kind: pms.KindService, ID: service.Name},
+					Name:      service.Name,
+					Current:   service,
+				})
+			}
+		}
+	}
+	return append(changes, deletions...), nil
+}
+
+// fieldDiffs returns the fields which are different between current and desired entities
+func fieldDiffs(current, desired interface{}) []string {
+	var currentFields, desiredFields map[string]interface{}
+	for _, pair := range []struct {
+		entity interface{}
+		fields *map[string]interface{}
+	}{{current, &currentFields}, {desired, &desiredFields}} {
+		data, _ := json.Marshal(pair.entity)
+		json.Unmarshal(data, pair.fields)
+		for _, key := range []string{"id", "metadata", "revision"} {
+			delete(*pair.fields, key)
+		}
+	}
+	keys := make(map[string]bool)
+	for key := range currentFields {
+		keys[key] = true
+	}
+	for key := range desiredFields {
+		keys[key] = true
+	}
+	var sortedKeys []string
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+	var diffs []string
+	for _, key := range sortedKeys {
+		if reflect.DeepEqual(currentFields[key], desiredFields[key]) {
+			continue
+		}
+		before, _ := json.Marshal(currentFields[key])
+		after, _ := json.Marshal(desiredFields[key])
+		diffs = append(diffs, fmt.Sprintf("%s: %s -> %s", key, before, after))
+	}
+	return diffs
+}
+
+// formatChanges prints the changes in a human readable way, "+" for creation, "~" for update
+// followed by the changed fields, and "-" for deletion, e.g.
+//
+//   - policy service1/policy1
+//     ~ function func1
+//     funcURL: "https://a.b.c/func1" -> "https://a.b.c/func2"
+//   - service service2
+func formatChanges(changes []*stateChange) string {
+	var buf bytes.Buffer
+	signs := map[string]string{pms.OpCreate: "+", pms.OpUpdate: "~", pms.OpDelete: "-"}
+	for _, change := range changes {
+		var desired interface{}
+		switch change.Kind {
+		case pms.KindService:
+			desired = change.Service
+		case pms.KindPolicy:
+			desired = change.Policy
+		case pms.KindRolePolicy:
+			desired = change.RolePolicy
+		case pms.KindFunction:
+			desired = change.Function
+		}
+		name := change.Name
+		if len(name) == 0 {
+			// a policy or role policy without name is shown by its content
+			content, _ := json.Marshal(desired)
+			name = string(content)
+		}
+		if len(change.ServiceName) > 0 {
+			name = change.ServiceName + "/" + name
+		}
+		fmt.Fprintf(&buf, "%s %s %s\n", signs[change.Op], change.Kind, name)
+		if change.Op != pms.OpUpdate {
+			continue
+		}
+		for _, diff := range fieldDiffs(change.Current, desired) {
+			fmt.Fprintf(&buf, "    %s\n", diff)
+		}
+	}
+	return buf.String()
+}
+
+func applyCommandFunc(cmd *cobra.Command, args []string) {
+	if len(applyFileName) == 0 || (outputFormat != "text" && outputFormat != "json") {
+		printHelpAndExit(cmd)
+	}
+
+	hc, err := httpClient()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	cli := &client.Client{
+		PMSEndpoint: globalFlags.PMSEndpoint,
+		HTTPClient:  hc,
+	}
+	current, err := loadCurrentState(cli)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	desired, err := loadDesiredState(applyFileName, functionNames(current.Functions))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	changes, err := diffState(current, desired, prune)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if outputFormat == "json" {
+		if changes == nil {
+			changes = []*stateChange{}
+		}
+		output, _ := json.MarshalIndent(changes, "", strings.Repeat(" ", 4))
+		fmt.Println(string(output))
+	} else if len(changes) == 0 {
+		fmt.Println("No changes.")
+	} else {
+		fmt.Print(formatChanges(changes))
+	}
+	if dryRun || len(changes) == 0 {
+		return
+	}
+
+	ops := make([]*pms.Operation, 0, len(changes))
+	for _, change := range changes {
+		op := change.Operation
+		ops = append(ops, &op)
+	}
+	payload, err := json.Marshal(ops)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if _, err := cli.Post([]string{"transaction"}, bytes.NewBuffer(payload), ""); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if outputFormat == "text" {
+		fmt.Printf("%d changes applied.\n", len(changes))
+	}
+}
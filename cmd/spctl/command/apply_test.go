@@ -0,0 +1,184 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+)
+
+func currentStateForApply() *pms.PolicyStore {
+	return &pms.PolicyStore{
+		Services: []*pms.Service{
+			{
+				Name: "service1", Type: pms.TypeApplication, Revision: 3,
+				Policies: []*pms.Policy{
+					{ID: "id1", Name: "p1", Effect: "grant", Principals: [][]string{{"user:Alice"}}, Revision: 2, Metadata: map[string]string{"createby": "Alice"}},
+					{ID: "id2", Name: "p2", Effect: "grant", Principals: [][]string{{"user:Bill"}}, Revision: 3},
+					{ID: "id3", Effect: "deny", Principals: [][]string{{"user:Carl"}}, Revision: 3},
+				},
+				RolePolicies: []*pms.RolePolicy{
+					{ID: "id4", Name: "rp1", Effect: "grant", Roles: []string{"role1"}, Principals: []string{"user:Alice"}, Revision: 3},
+				},
+			},
+			{Name: "service3", Type: pms.TypeApplication},
+		},
+		Functions: []*pms.Function{
+			{Name: "func1", FuncURL: "https://localhost:23456/func1", Revision: 1},
+		},
+	}
+}
+
+func desiredStateForApply() *pms.PolicyStore {
+	return &pms.PolicyStore{
+		Services: []*pms.Service{
+			{
+				Name: "service1",
+				Policies: []*pms.Policy{
+					{ID: "newID", Name: "p1", Effect: "deny", Principals: [][]string{{"user:Alice"}}},
+					{Name: "p4", Effect: "grant", Principals: [][]string{{"user:Dave"}}},
+					// matched by content since it has no name
+					{ID: "anotherID", Effect: "deny", Principals: [][]string{{"user:Carl"}}},
+				},
+				RolePolicies: []*pms.RolePolicy{
+					{Name: "rp1", Effect: "grant", Roles: []string{"role1"}, Principals: []string{"user:Alice"}},
+				},
+			},
+			{
+				Name: "service2", Type: pms.TypeK8SCluster,
+				Policies: []*pms.Policy{
+					{ID: "spdlID", Effect: "grant", Principals: [][]string{{"user:Eve"}}},
+				},
+			},
+		},
+		Functions: []*pms.Function{
+			{Name: "func1", FuncURL: "https://localhost:23456/func1"},
+			{Name: "func2", FuncURL: "https://localhost:23456/func2"},
+		},
+	}
+}
+
+func summarizeChanges(changes []*stateChange) []string {
+	var summary []string
+	for _, change := range changes {
+		name := change.Name
+		if len(change.ServiceName) > 0 {
+			name = change.ServiceName + "/" + name
+		}
+		summary = append(summary, change.Op+" "+change.Kind+" "+name)
+	}
+	return summary
+}
+
+func TestDiffState(t *testing.T) {
+	changes, err := diffState(currentStateForApply(), desiredStateForApply(), false)
+	if err != nil {
+		t.Fatal("fail to diff state:", err)
+	}
+	expected := []string{
+		"create function func2",
+		"update policy service1/p1",
+		"create policy service1/p4",
+		"create service service2",
+	}
+	if strings.Join(summarizeChanges(changes), ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected changes %v, but got %v", expected, summarizeChanges(changes))
+	}
+	update := changes[1]
+	if update.ID != "id1" || update.Policy.ID != "id1" || update.Policy.Revision != 2 || update.Policy.Effect != "deny" {
+		t.Fatal("policy should be updated with the ID and revision of the existing one:", update.Policy)
+	}
+	created := changes[3].Service
+	if created.Type != pms.TypeK8SCluster || len(created.Policies) != 1 || created.Policies[0].ID != "" {
+		t.Fatal("service should be created with its policies, without the IDs in the desired state:", created)
+	}
+
+	// the entities which are not in the desired state are deleted after the others are changed
+	changes, err = diffState(currentStateForApply(), desiredStateForApply(), true)
+	if err != nil {
+		t.Fatal("fail to diff state:", err)
+	}
+	expected = append(expected,
+		"delete policy service1/p2",
+		"delete service service3",
+	)
+	if strings.Join(summarizeChanges(changes), ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected changes %v, but got %v", expected, summarizeChanges(changes))
+	}
+
+	// no change if current state is the desired state
+	changes, err = diffState(currentStateForApply(), currentStateForApply(), true)
+	if err != nil || len(changes) != 0 {
+		t.Fatal("should not change anything:", summarizeChanges(changes), err)
+	}
+
+	desired := desiredStateForApply()
+	desired.Services[0].Policies = append(desired.Services[0].Policies, &pms.Policy{Name: "p1", Effect: "grant"})
+	if _, err := diffState(currentStateForApply(), desired, false); err == nil {
+		t.Fatal("should fail if a policy name is defined more than once")
+	}
+}
+
+func TestFormatChanges(t *testing.T) {
+	changes, err := diffState(currentStateForApply(), desiredStateForApply(), true)
+	if err != nil {
+		t.Fatal("fail to diff state:", err)
+	}
+	output := formatChanges(changes)
+	for _, expected := range []string{
+		"+ function func2\n",
+		"~ policy service1/p1\n    effect: \"grant\" -> \"deny\"\n",
+		"- service service3\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Fatalf("%q is not found in changes:\n%s", expected, output)
+		}
+	}
+}
+
+func TestLoadDesiredState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spctl-apply")
+	if err != nil {
+		t.Fatal("fail to create temp dir:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the condition calls the function defined in the json file
+	spdl := "[service.service1]\n[policy]\ngrant user Alice read books if func1(request_user)\n[rolepolicy]\ngrant user Bill role reader\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "service1.spdl"), []byte(spdl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	json := `{"services":[{"name":"service2","type":"k8s-cluster"}],"functions":[{"name":"func1","funcURL":"https://localhost:23456/func1"}]}`
+	if err := ioutil.WriteFile(filepath.Join(dir, "service2.json"), []byte(json), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("not a policy file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	desired, err := loadDesiredState(dir, nil)
+	if err != nil {
+		t.Fatal("fail to load desired state:", err)
+	}
+	if len(desired.Services) != 2 || len(desired.Functions) != 1 {
+		t.Fatal("services and functions in all files should be loaded:", desired)
+	}
+	// the json files are read first
+	if len(desired.Services[1].Policies) != 1 || len(desired.Services[1].RolePolicies) != 1 {
+		t.Fatal("policies and role policies in SPDL should be loaded:", desired.Services[1])
+	}
+
+	// a service can only be defined in one file
+	if err := ioutil.WriteFile(filepath.Join(dir, "service1.json"), []byte(`{"services":[{"name":"service1"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadDesiredState(dir, nil); err == nil {
+		t.Fatal("should fail if a service is defined in more than one file")
+	}
+}
@@ -0,0 +1,117 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/cmd/spctl/client"
+	"github.com/teramoby/speedle-plus/pkg/store"
+	"github.com/teramoby/speedle-plus/pkg/store/file"
+)
+
+var (
+	exportFileName string
+)
+
+var (
+	exportExample = `
+		# Export all services, policies, role policies and functions to a json file, which can be used by a file store
+		spctl export -f policies.json
+
+		# Export all services, policies and role policies to a SPDL file
+		spctl export -f policies.spdl`
+)
+
+func newExportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export -f FILE",
+		Short: "Export all services | policies | role-policies | functions to a SPDL or json file",
+		Long: `Export all services with their policies and role policies, and all functions in the policy management service to a file.
+A file named *.spdl is written in SPDL, which does not keep service types, functions, policy names and metadata,
+other files are written in json format of policy store, which keeps everything.`,
+		Example: exportExample,
+		Run:     exportCommandFunc,
+	}
+
+	cmd.Flags().StringVarP(&exportFileName, "filename", "f", "", "file to export to")
+	return cmd
+}
+
+// spdlLosses returns what in policy store is not kept when it is written in SPDL
+func spdlLosses(ps *pms.PolicyStore) []string {
+	var losses []string
+	if len(ps.Functions) > 0 {
+		losses = append(losses, fmt.Sprintf("%d functions", len(ps.Functions)))
+	}
+	for _, service := range ps.Services {
+		if typeOfService(service) != pms.TypeApplication {
+			losses = append(losses, fmt.Sprintf("type of service %q", service.Name))
+		}
+		for _, policy := range service.Policies {
+			if len(policy.Name) > 0 {
+				losses = append(losses, fmt.Sprintf("policy names in service %q", service.Name))
+				break
+			}
+		}
+		for _, rolePolicy := range service.RolePolicies {
+			if len(rolePolicy.Name) > 0 {
+				losses = append(losses, fmt.Sprintf("role policy names in service %q", service.Name))
+				break
+			}
+		}
+	}
+	return losses
+}
+
+// writePolicyStoreFile writes services and functions to a SPDL or json file
+func writePolicyStoreFile(fileName string, ps *pms.PolicyStore) error {
+	fileStore, err := store.NewStore(file.StoreType, map[string]interface{}{
+		file.FileLocationKey: fileName,
+	})
+	if err != nil {
+		return err
+	}
+	if err := fileStore.WritePolicyStore(ps); err != nil {
+		return fmt.Errorf("failed to write %s: %v", fileName, err)
+	}
+	return nil
+}
+
+func exportCommandFunc(cmd *cobra.Command, args []string) {
+	if len(exportFileName) == 0 {
+		printHelpAndExit(cmd)
+	}
+
+	hc, err := httpClient()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	cli := &client.Client{
+		PMSEndpoint: globalFlags.PMSEndpoint,
+		HTTPClient:  hc,
+	}
+	ps, err := loadCurrentState(cli)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if strings.HasSuffix(exportFileName, ".spdl") {
+		if losses := spdlLosses(ps); len(losses) > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: SPDL does not keep %s, export to a json file to keep them\n", strings.Join(losses, ", "))
+		}
+	}
+	if err := writePolicyStoreFile(exportFileName, ps); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%d services and %d functions exported to %s.\n", len(ps.Services), len(ps.Functions), exportFileName)
+}
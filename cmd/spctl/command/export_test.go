@@ -0,0 +1,60 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+)
+
+func TestExportAndImportFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spctl-export")
+	if err != nil {
+		t.Fatal("fail to create temp dir:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, fileName := range []string{"export.json", "export.spdl"} {
+		exported := desiredStateForApply()
+		for _, service := range exported.Services {
+			for _, policy := range service.Policies {
+				policy.Permissions = []*pms.Permission{{Resource: "books", Actions: []string{"read", "write"}}}
+			}
+		}
+		if fileName == "export.spdl" {
+			if losses := spdlLosses(exported); len(losses) != 4 {
+				t.Fatal("functions, service type and policy names should not be kept in SPDL:", losses)
+			}
+			exported.Functions = nil
+		}
+		location := filepath.Join(dir, fileName)
+		if err := writePolicyStoreFile(location, exported); err != nil {
+			t.Fatalf("fail to export to %s: %v", fileName, err)
+		}
+		imported, err := loadDesiredState(location, nil)
+		if err != nil {
+			t.Fatalf("fail to import from %s: %v", fileName, err)
+		}
+		// SPDL loses names, so the policies are matched by content
+		if fileName == "export.spdl" {
+			for _, service := range exported.Services {
+				for _, policy := range service.Policies {
+					policy.Name = ""
+				}
+				for _, rolePolicy := range service.RolePolicies {
+					rolePolicy.Name = ""
+				}
+			}
+			exported.Services[1].Type = ""
+		}
+		changes, err := diffState(exported, imported, true)
+		if err != nil || len(changes) != 0 {
+			t.Fatalf("%s should have the exported state, but got changes %v, %v", fileName, summarizeChanges(changes), err)
+		}
+	}
+}
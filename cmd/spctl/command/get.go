@@ -6,7 +6,9 @@ package command
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/teramoby/speedle-plus/cmd/spctl/client"
@@ -17,8 +19,11 @@ import (
 )
 
 var (
-	all         bool
-	serviceName string
+	all           bool
+	serviceName   string
+	filter        string
+	limit         int
+	continueToken string
 )
 
 var (
@@ -32,6 +37,13 @@ var (
 		# List all policies in service "foo"
 		spctl get policy --all --service-name=foo
 		
+		# List the grant policies of user "alice" in service "foo"
+		spctl get policy --all --service-name=foo --filter='effect eq grant and principal eq user:alice'
+
+		# List the policies in service "foo" by pages of 10, and continue with the token printed
+		spctl get policy --all --service-name=foo --limit=10
+		spctl get policy --all --service-name=foo --limit=10 --continue=TOKEN
+
 		# List the policy with id "1" in service "foo"
 		spctl get policy 1 --service-name=foo
 		
@@ -44,7 +56,7 @@ var (
 
 func newGetCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "get (service | policy | rolepolicy | function) (--all | NAME | ID) [--service-name=NAME]",
+		Use:     "get (service | policy | rolepolicy | function) (--all | NAME | ID) [--service-name=NAME] [--filter=FILTER] [--limit=N] [--continue=TOKEN]",
 		Short:   "Get one or many services | policies | role-policies",
 		Example: getExample,
 		Run:     getCommandFunc,
@@ -52,6 +64,9 @@ func newGetCommand() *cobra.Command {
 
 	cmd.Flags().BoolVarP(&all, "all", "a", false, "Get all elements")
 	cmd.Flags().StringVar(&serviceName, "service-name", "", "Service name")
+	cmd.Flags().StringVar(&filter, "filter", "", "Filter to get policies, role-policies or functions with --all, e.g. 'effect eq grant and principal eq user:alice'")
+	cmd.Flags().IntVar(&limit, "limit", 0, "Maximum number of policies or role-policies to get with --all, the token to get the next page is printed to stderr")
+	cmd.Flags().StringVar(&continueToken, "continue", "", "Token to get the next page of policies or role-policies")
 	return cmd
 }
 
@@ -110,7 +125,11 @@ func getCommandFunc(cmd *cobra.Command, args []string) {
 			kind = "role-policy"
 		}
 		if all {
-			res, err = cli.Get([]string{"service", serviceName, kind}, nil, "")
+			var nextToken string
+			res, nextToken, err = cli.GetPage([]string{"service", serviceName, kind}, listParams(), "")
+			if len(nextToken) != 0 {
+				fmt.Fprintf(os.Stderr, "More %s, continue with --continue=%s\n", kind, nextToken)
+			}
 
 			if err == nil {
 				var policies interface{}
@@ -149,7 +168,7 @@ func getCommandFunc(cmd *cobra.Command, args []string) {
 		}
 	case "function":
 		if all {
-			res, err = cli.Get([]string{"function"}, nil, "")
+			res, err = cli.Get([]string{"function"}, listParams(), "")
 			if err == nil {
 				functions := []pms.Function{}
 				if json.Unmarshal(res, &functions) == nil {
@@ -183,3 +202,18 @@ func getCommandFunc(cmd *cobra.Command, args []string) {
 	}
 	fmt.Println(string(output))
 }
+
+// listParams returns the query parameters to list policies, role-policies or functions
+func listParams() url.Values {
+	params := url.Values{}
+	if len(filter) != 0 {
+		params.Set("filter", filter)
+	}
+	if limit != 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	if len(continueToken) != 0 {
+		params.Set("continue", continueToken)
+	}
+	return params
+}
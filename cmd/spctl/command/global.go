@@ -20,6 +20,7 @@ import (
 
 var globalFlags struct {
 	PMSEndpoint        string
+	ADSEndpoint        string
 	Timeout            time.Duration
 	CertFile           string
 	KeyFile            string
@@ -0,0 +1,146 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/teramoby/speedle-plus/cmd/spctl/client"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	rollbackVersion int64
+)
+
+var (
+	historyExample = `
+		# List all versions of service "foo"
+		spctl history service foo
+
+		# List all versions of policy "p01" in service "foo"
+		spctl history policy p01 --service-name=foo
+
+		# List all versions of function "foo"
+		spctl history function foo`
+
+	rollbackExample = `
+		# Rollback service "foo" to version 3
+		spctl rollback service foo --version=3
+
+		# Rollback policy "p01" in service "foo" to version 5
+		spctl rollback policy p01 --service-name=foo --version=5
+
+		# Rollback role policy "rp01" in service "foo" to version 5
+		spctl rollback rolepolicy rp01 --service-name=foo --version=5`
+)
+
+func newHistoryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "history (service | policy | rolepolicy | function) (NAME | ID) [--service-name=NAME]",
+		Short:   "List all versions of a service | policy | role-policy | function",
+		Example: historyExample,
+		Run:     historyCommandFunc,
+	}
+
+	cmd.Flags().StringVar(&serviceName, "service-name", "", "Service name")
+	return cmd
+}
+
+func newRollbackCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rollback (service | policy | rolepolicy | function) (NAME | ID) --version=VERSION [--service-name=NAME]",
+		Short:   "Rollback a service | policy | role-policy | function to a version in its history",
+		Example: rollbackExample,
+		Run:     rollbackCommandFunc,
+	}
+
+	cmd.Flags().StringVar(&serviceName, "service-name", "", "Service name")
+	cmd.Flags().Int64Var(&rollbackVersion, "version", 0, "Version to rollback to")
+	return cmd
+}
+
+// historyPaths returns the path of the entity named in args, e.g. service/foo/policy/p01
+func historyPaths(cmd *cobra.Command, args []string) []string {
+	if len(args) != 2 {
+		printHelpAndExit(cmd)
+	}
+	switch strings.ToLower(args[0]) {
+	case "service":
+		return []string{"service", args[1]}
+	case "policy", "rolepolicy":
+		if serviceName == "" {
+			printHelpAndExit(cmd)
+		}
+		kind := "policy"
+		if "rolepolicy" == strings.ToLower(args[0]) {
+			kind = "role-policy"
+		}
+		return []string{"service", serviceName, kind, args[1]}
+	case "function":
+		return []string{"function", args[1]}
+	default:
+		printHelpAndExit(cmd)
+	}
+	return nil
+}
+
+func newClient() *client.Client {
+	hc, err := httpClient()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return &client.Client{
+		PMSEndpoint: globalFlags.PMSEndpoint,
+		HTTPClient:  hc,
+	}
+}
+
+func historyCommandFunc(cmd *cobra.Command, args []string) {
+	paths := append(historyPaths(cmd, args), "history")
+	res, err := newClient().Get(paths, nil, "")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	records := []pms.HistoryRecord{}
+	if err := json.Unmarshal(res, &records); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	output, _ := json.MarshalIndent(&records, "", strings.Repeat(" ", 4))
+	fmt.Println(string(output))
+}
+
+func rollbackCommandFunc(cmd *cobra.Command, args []string) {
+	paths := append(historyPaths(cmd, args), "rollback")
+	if rollbackVersion <= 0 {
+		printHelpAndExit(cmd)
+	}
+	params := url.Values{}
+	params.Set("version", strconv.FormatInt(rollbackVersion, 10))
+	res, err := newClient().PostWithParams(paths, params, nil, "")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	result := pms.Operation{}
+	if err := json.Unmarshal([]byte(res), &result); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	output, _ := json.MarshalIndent(&result, "", strings.Repeat(" ", 4))
+	fmt.Printf("%s rolled back to version %d.\n%s\n", strings.Join(args, " "), rollbackVersion, string(output))
+}
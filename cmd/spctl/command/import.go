@@ -0,0 +1,153 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/cmd/spctl/client"
+)
+
+const (
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+	conflictFail      = "fail"
+)
+
+var (
+	importFileName string
+	onConflict     string
+)
+
+var (
+	importExample = `
+		# Import all services, policies, role policies and functions in a file, fail if any of them is different from the existing one
+		spctl import -f policies.json
+
+		# Import the SPDL and json files in a directory, keep the existing entities which are different
+		spctl import -f ./policies --on-conflict=skip
+
+		# Import a file, replace the existing entities which are different
+		spctl import -f policies.spdl --on-conflict=overwrite
+
+		# Show what would be imported, without changing anything
+		spctl import -f policies.json --dry-run`
+)
+
+func newImportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import -f (FILE | DIRECTORY) [--on-conflict=skip|overwrite|fail] [--dry-run]",
+		Short: "Import services | policies | role-policies | functions from SPDL or json files",
+		Long: `Import services, policies, role policies and functions from SPDL files (*.spdl) or json files of policy store (*.json)
+to the policy management service, all of them are imported in one transaction.
+Policies and role policies are matched by name, the ones without name are matched by content. An imported entity conflicts
+with the existing one if they are different, conflicts are skipped, overwritten or fail the import as --on-conflict says.`,
+		Example: importExample,
+		Run:     importCommandFunc,
+	}
+
+	cmd.Flags().StringVarP(&importFileName, "filename", "f", "", "file or directory to import from")
+	cmd.Flags().StringVar(&onConflict, "on-conflict", conflictFail, "what to do if an imported entity is different from the existing one, skip, overwrite or fail")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only show the changes, do not import them")
+	return cmd
+}
+
+// planImport returns the changes to import services and functions, and the conflicts which are skipped.
+// Entities which are not in the imported policy store are kept.
+func planImport(current, imported *pms.PolicyStore, strategy string) ([]*stateChange, []*stateChange, error) {
+	changes, err := diffState(current, imported, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	var planned, conflicts []*stateChange
+	for _, change := range changes {
+		if change.Op == pms.OpUpdate {
+			conflicts = append(conflicts, change)
+			if strategy != conflictOverwrite {
+				continue
+			}
+		}
+		planned = append(planned, change)
+	}
+	switch strategy {
+	case conflictOverwrite:
+		return planned, nil, nil
+	case conflictSkip:
+		return planned, conflicts, nil
+	case conflictFail:
+		if len(conflicts) > 0 {
+			return nil, conflicts, fmt.Errorf("%d imported entities conflict with the existing ones", len(conflicts))
+		}
+		return planned, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown conflict strategy %q", strategy)
+	}
+}
+
+func importCommandFunc(cmd *cobra.Command, args []string) {
+	if len(importFileName) == 0 {
+		printHelpAndExit(cmd)
+	}
+
+	hc, err := httpClient()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	cli := &client.Client{
+		PMSEndpoint: globalFlags.PMSEndpoint,
+		HTTPClient:  hc,
+	}
+	current, err := loadCurrentState(cli)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	imported, err := loadDesiredState(importFileName, functionNames(current.Functions))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	changes, conflicts, err := planImport(current, imported, onConflict)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprint(os.Stderr, formatChanges(conflicts))
+		os.Exit(1)
+	}
+	if len(conflicts) > 0 {
+		fmt.Printf("Skipped %d conflicts:\n", len(conflicts))
+		fmt.Print(formatChanges(conflicts))
+	}
+	if len(changes) == 0 {
+		fmt.Println("Nothing to import.")
+		return
+	}
+	fmt.Print(formatChanges(changes))
+	if dryRun {
+		return
+	}
+
+	ops := make([]*pms.Operation, 0, len(changes))
+	for _, change := range changes {
+		op := change.Operation
+		ops = append(ops, &op)
+	}
+	payload, err := json.Marshal(ops)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if _, err := cli.Post([]string{"transaction"}, bytes.NewBuffer(payload), ""); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%d changes imported.\n", len(changes))
+}
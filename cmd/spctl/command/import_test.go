@@ -0,0 +1,49 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPlanImport(t *testing.T) {
+	created := []string{
+		"create function func2",
+		"create policy service1/p4",
+		"create service service2",
+	}
+
+	changes, conflicts, err := planImport(currentStateForApply(), desiredStateForApply(), conflictSkip)
+	if err != nil {
+		t.Fatal("fail to plan import:", err)
+	}
+	if strings.Join(summarizeChanges(changes), ",") != strings.Join(created, ",") {
+		t.Fatalf("expected changes %v, but got %v", created, summarizeChanges(changes))
+	}
+	if strings.Join(summarizeChanges(conflicts), ",") != "update policy service1/p1" {
+		t.Fatal("policy p1 should be skipped:", summarizeChanges(conflicts))
+	}
+
+	changes, conflicts, err = planImport(currentStateForApply(), desiredStateForApply(), conflictOverwrite)
+	if err != nil {
+		t.Fatal("fail to plan import:", err)
+	}
+	expected := []string{created[0], "update policy service1/p1", created[1], created[2]}
+	if strings.Join(summarizeChanges(changes), ",") != strings.Join(expected, ",") || len(conflicts) != 0 {
+		t.Fatalf("expected changes %v, but got %v", expected, summarizeChanges(changes))
+	}
+
+	if _, conflicts, err = planImport(currentStateForApply(), desiredStateForApply(), conflictFail); err == nil || len(conflicts) != 1 {
+		t.Fatal("import should fail because of the conflict of policy p1:", err)
+	}
+	// existing entities which are the same as the imported ones are not conflicts
+	if changes, _, err = planImport(currentStateForApply(), currentStateForApply(), conflictFail); err != nil || len(changes) != 0 {
+		t.Fatal("nothing should be imported:", summarizeChanges(changes), err)
+	}
+
+	if _, _, err = planImport(currentStateForApply(), desiredStateForApply(), "merge"); err == nil {
+		t.Fatal("unknown conflict strategy should fail")
+	}
+}
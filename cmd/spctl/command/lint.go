@@ -0,0 +1,120 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/cmd/spctl/client"
+	"github.com/teramoby/speedle-plus/pkg/analyzer"
+)
+
+var (
+	lintFileName   string
+	lintFunctions  []string
+	lintAttributes []string
+)
+
+var (
+	lintExample = `
+		# Analyze the services in policy management service
+		spctl lint
+
+		# Analyze the SPDL and json files in a directory, with the attributes the requests could have
+		spctl lint -f ./policies --attributes age,department
+
+		# Analyze a file whose conditions call a custom function defined elsewhere, and show the findings in json format
+		spctl lint -f policies.spdl --functions IsWeekday -o json`
+)
+
+func newLintCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lint [-f (FILE | DIRECTORY)] [--functions NAME,...] [--attributes NAME,...] [--output=text|json]",
+		Short: "Find the problems of the policies in SPDL or json files, or in policy management service",
+		Long: `Analyze the policies without evaluating any request. The errors are grant policies shadowed by deny policies or
+the other way around depending on the combining algorithm, role cycles, and conditions which are invalid, call
+undefined functions or reference undefined attributes. The attributes are only checked if --attributes is set. The
+warnings are identical or subsumed policies, and role policies granting roles which are never used. The command exits
+with 1 if any error is found.`,
+		Example: lintExample,
+		Run:     lintCommandFunc,
+	}
+
+	cmd.Flags().StringVarP(&lintFileName, "filename", "f", "", "file or directory to analyze, the services in policy management service are analyzed if it's not set")
+	cmd.Flags().StringSliceVar(&lintFunctions, "functions", nil, "custom functions defined besides the ones being analyzed")
+	cmd.Flags().StringSliceVar(&lintAttributes, "attributes", nil, "attributes of requests besides the built-in ones")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "format of the findings, text or json")
+	return cmd
+}
+
+// formatFindings prints a finding per line as "severity service kind: message", e.g.
+//
+//	error   library  shadowed: policy p1 never takes effect, policy p2 overrides it for every request it applies to
+func formatFindings(findings []*analyzer.Finding) string {
+	var buf bytes.Buffer
+	errorCount := 0
+	for _, finding := range findings {
+		if finding.Severity == analyzer.SeverityError {
+			errorCount++
+		}
+		fmt.Fprintf(&buf, "%-7s %s  %s: %s\n", finding.Severity, finding.ServiceName, finding.Kind, finding.Message)
+	}
+	fmt.Fprintf(&buf, "%d errors, %d warnings.\n", errorCount, len(findings)-errorCount)
+	return buf.String()
+}
+
+func lintCommandFunc(cmd *cobra.Command, args []string) {
+	if outputFormat != "text" && outputFormat != "json" {
+		printHelpAndExit(cmd)
+	}
+
+	var ps *pms.PolicyStore
+	var err error
+	if len(lintFileName) > 0 {
+		ps, err = loadDesiredState(lintFileName, lintFunctions)
+	} else {
+		var hc *http.Client
+		if hc, err = httpClient(); err == nil {
+			ps, err = loadCurrentState(&client.Client{
+				PMSEndpoint: globalFlags.PMSEndpoint,
+				HTTPClient:  hc,
+			})
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	opts := analyzer.Options{Functions: lintFunctions}
+	if cmd.Flags().Changed("attributes") {
+		opts.Attributes = lintAttributes
+		if opts.Attributes == nil {
+			opts.Attributes = []string{}
+		}
+	}
+	findings := analyzer.Analyze(ps, &opts)
+	if outputFormat == "json" {
+		if findings == nil {
+			findings = []*analyzer.Finding{}
+		}
+		output, _ := json.MarshalIndent(findings, "", strings.Repeat(" ", 4))
+		fmt.Println(string(output))
+	} else {
+		fmt.Print(formatFindings(findings))
+	}
+	for _, finding := range findings {
+		if finding.Severity == analyzer.SeverityError {
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package command
+
+import (
+	"testing"
+
+	"github.com/teramoby/speedle-plus/pkg/analyzer"
+)
+
+func TestFormatFindings(t *testing.T) {
+	findings := []*analyzer.Finding{
+		{Kind: analyzer.KindShadowed, Severity: analyzer.SeverityError, ServiceName: "library", Policies: []string{"p1", "p2"},
+			Message: "policy p1 never takes effect, policy p2 overrides it for every request it applies to"},
+		{Kind: analyzer.KindUnusedRole, Severity: analyzer.SeverityWarning, ServiceName: "library", RolePolicies: []string{"rp1"},
+			Message: "role policy rp1 grants roles writer which no policy or role policy uses"},
+	}
+	expected := `error   library  shadowed: policy p1 never takes effect, policy p2 overrides it for every request it applies to
+warning library  unused-role: role policy rp1 grants roles writer which no policy or role policy uses
+1 errors, 1 warnings.
+`
+	if output := formatFindings(findings); output != expected {
+		t.Fatalf("expected output:\n%s\nbut got:\n%s", expected, output)
+	}
+}
@@ -31,6 +31,7 @@ func printHelpAndExit(cmd *cobra.Command) {
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&globalFlags.PMSEndpoint, "pms-endpoint", flags.DefaultPolicyManagmentConnectEndpoint, "speedle policy managemnet service endpoint")
+	rootCmd.PersistentFlags().StringVar(&globalFlags.ADSEndpoint, "ads-endpoint", flags.DefaultAuthzCheckConnectEndpoint, "speedle authorization decision service endpoint")
 	rootCmd.PersistentFlags().DurationVar(&globalFlags.Timeout, "timeout", 5000000000, "timeout for running command")
 	rootCmd.PersistentFlags().StringVar(&globalFlags.CertFile, "cert", "", "identify secure client using this TLS certificate file")
 	rootCmd.PersistentFlags().StringVar(&globalFlags.KeyFile, "key", "", "identify secure client using this TLS key file")
@@ -46,8 +47,18 @@ func init() {
 		newGetCommand(),
 		newDeleteCommand(),
 		newCreateCommand(),
+		newUpdateCommand(),
 		newConfigCommand(),
 		newDiscoverCommand(),
+		newHistoryCommand(),
+		newRollbackCommand(),
+		newApplyCommand(),
+		newExportCommand(),
+		newImportCommand(),
+		newWhoCanAccessCommand(),
+		newSimulateCommand(),
+		newTestCommand(),
+		newLintCommand(),
 		newVersionCommand(),
 	)
 }
@@ -0,0 +1,192 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/cmd/spctl/client"
+	"github.com/teramoby/speedle-plus/pkg/eval"
+	"github.com/teramoby/speedle-plus/pkg/subjectutils"
+	"github.com/teramoby/speedle-plus/pkg/svcs/pmsrest"
+)
+
+var (
+	simulateFileName     string
+	simulateRequestsFile string
+)
+
+var (
+	simulateExample = `
+		# Show whose access would be changed by the SPDL and json files in a directory, replaying the recorded discover requests
+		spctl simulate -f ./policies
+
+		# Replay the requests in a file, which is a json array of request contexts like the output of "spctl discover request"
+		spctl simulate -f policies.spdl --requests requests.json
+
+		# Also simulate deleting the services, policies and role policies which are not in the file, and show the report in json format
+		spctl simulate -f policies.json --prune -o json`
+)
+
+func newSimulateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "simulate -f (FILE | DIRECTORY) [--requests FILE] [--prune] [--output=text|json]",
+		Short: "Show the requests whose decisions or granted roles would be changed by applying SPDL or json files",
+		Long: `Compute the changes to converge the policy management service to the desired state like apply, and replay requests
+through the live policies and the policies with the changes applied, nothing is changed. The requests whose decisions
+or granted roles differ are listed with the responsible policies and role policies.`,
+		Example: simulateExample,
+		Run:     simulateCommandFunc,
+	}
+
+	cmd.Flags().StringVarP(&simulateFileName, "filename", "f", "", "file or directory that contains the desired state")
+	cmd.Flags().StringVar(&simulateRequestsFile, "requests", "", "file of the requests to replay, the recorded discover requests are replayed if it's not set")
+	cmd.Flags().BoolVar(&prune, "prune", false, "also simulate deleting the entities which are not in the desired state")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "format of the report, text or json")
+	return cmd
+}
+
+// readRequestsFile reads a json array of request contexts, or the response of the discover request API
+func readRequestsFile(fileName string) ([]*ads.RequestContext, error) {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var requests []*ads.RequestContext
+	if err := json.Unmarshal(content, &requests); err == nil {
+		return requests, nil
+	}
+	var discovered pmsrest.GetDiscoverRequestsResponse
+	if err := json.Unmarshal(content, &discovered); err != nil {
+		return nil, fmt.Errorf("failed to read requests from %s: %v", fileName, err)
+	}
+	return discovered.Requests, nil
+}
+
+func formatSubject(subject *ads.Subject) string {
+	if subject == nil || len(subject.Principals) == 0 {
+		return "anonymous"
+	}
+	var principals []string
+	for _, principal := range subject.Principals {
+		principals = append(principals, subjectutils.EncodePrincipal(principal))
+	}
+	return strings.Join(principals, " & ")
+}
+
+func formatDecision(decision *eval.SimulatedDecision) string {
+	if len(decision.Error) > 0 {
+		return "error (" + decision.Error + ")"
+	}
+	if decision.Allowed {
+		return "allowed"
+	}
+	return "denied"
+}
+
+// formatSimulationReport prints a difference as "principals action resource in service: live -> proposed", e.g.
+//
+//	user:alice read /books in service library: allowed -> denied
+//	    responsible policies: policy1
+func formatSimulationReport(report *eval.SimulationReport) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d of %d requests changed.\n", report.Changed, report.Requests)
+	for _, diff := range report.Differences {
+		request := diff.Request
+		fmt.Fprintf(&buf, "%s %s %s in service %s: %s -> %s\n", formatSubject(request.Subject), request.Action, request.Resource,
+			request.ServiceName, formatDecision(diff.Live), formatDecision(diff.Proposed))
+		if !reflect.DeepEqual(diff.Live.GrantedRoles, diff.Proposed.GrantedRoles) {
+			fmt.Fprintf(&buf, "    granted roles: [%s] -> [%s]\n", strings.Join(diff.Live.GrantedRoles, ", "), strings.Join(diff.Proposed.GrantedRoles, ", "))
+		}
+		if len(diff.ResponsiblePolicies) > 0 {
+			fmt.Fprintf(&buf, "    responsible policies: %s\n", strings.Join(diff.ResponsiblePolicies, ", "))
+		}
+		if len(diff.ResponsibleRolePolicies) > 0 {
+			fmt.Fprintf(&buf, "    responsible role policies: %s\n", strings.Join(diff.ResponsibleRolePolicies, ", "))
+		}
+	}
+	return buf.String()
+}
+
+func simulateCommandFunc(cmd *cobra.Command, args []string) {
+	if len(simulateFileName) == 0 || (outputFormat != "text" && outputFormat != "json") {
+		printHelpAndExit(cmd)
+	}
+
+	var err error
+	var requests []*ads.RequestContext
+	if len(simulateRequestsFile) > 0 {
+		if requests, err = readRequestsFile(simulateRequestsFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if len(requests) == 0 {
+			fmt.Fprintf(os.Stderr, "no request found in %s\n", simulateRequestsFile)
+			os.Exit(1)
+		}
+	}
+
+	hc, err := httpClient()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	cli := &client.Client{
+		PMSEndpoint: globalFlags.PMSEndpoint,
+		HTTPClient:  hc,
+	}
+	current, err := loadCurrentState(cli)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	desired, err := loadDesiredState(simulateFileName, functionNames(current.Functions))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	changes, err := diffState(current, desired, prune)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	ops := make([]*pms.Operation, 0, len(changes))
+	for _, change := range changes {
+		op := change.Operation
+		ops = append(ops, &op)
+	}
+	payload, err := json.Marshal(&pmsrest.SimulationRequest{Operations: ops, Requests: requests})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	res, err := cli.Post([]string{"simulate"}, bytes.NewBuffer(payload), "")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var report eval.SimulationReport
+	if err := json.Unmarshal([]byte(res), &report); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if outputFormat == "json" {
+		output, _ := json.MarshalIndent(&report, "", strings.Repeat(" ", 4))
+		fmt.Println(string(output))
+		return
+	}
+	fmt.Print(formatSimulationReport(&report))
+}
@@ -0,0 +1,47 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package command
+
+import (
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/pkg/eval"
+)
+
+func TestFormatSimulationReport(t *testing.T) {
+	report := &eval.SimulationReport{
+		Requests: 3,
+		Changed:  2,
+		Differences: []*eval.SimulationDifference{
+			{
+				Request: &ads.RequestContext{
+					Subject:     &ads.Subject{Principals: []*ads.Principal{{Type: "user", Name: "alice"}}},
+					ServiceName: "library",
+					Resource:    "books",
+					Action:      "read",
+				},
+				Live:                &eval.SimulatedDecision{Allowed: true, Policies: []string{"id1"}},
+				Proposed:            &eval.SimulatedDecision{},
+				ResponsiblePolicies: []string{"id1"},
+			},
+			{
+				Request:                 &ads.RequestContext{ServiceName: "library", Resource: "books", Action: "write"},
+				Live:                    &eval.SimulatedDecision{GrantedRoles: []string{"editor"}},
+				Proposed:                &eval.SimulatedDecision{Error: "service not found"},
+				ResponsibleRolePolicies: []string{"rp1"},
+			},
+		},
+	}
+	expected := `2 of 3 requests changed.
+user:alice read books in service library: allowed -> denied
+    responsible policies: id1
+anonymous write books in service library: denied -> error (service not found)
+    granted roles: [editor] -> []
+    responsible role policies: rp1
+`
+	if output := formatSimulationReport(report); output != expected {
+		t.Fatalf("expected output:\n%s\nbut got:\n%s", expected, output)
+	}
+}
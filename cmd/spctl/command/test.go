@@ -0,0 +1,376 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/eval"
+)
+
+var (
+	testPoliciesFile string
+	testCasesFile    string
+	testJUnitFile    string
+)
+
+var (
+	testExample = `
+		# Run the test cases in cases.yaml against the policies in policies.spdl
+		spctl test --policies policies.spdl --cases cases.yaml
+
+		# Also write the results in JUnit XML format for CI
+		spctl test --policies policies.json --cases cases.json --junit report.xml
+
+		# A file of test cases in YAML, or the same structure in JSON
+		cases:
+		- name: alice reads books
+		  subject:
+		    principals:
+		    - type: user
+		      name: alice
+		  serviceName: library
+		  resource: /books
+		  action: read
+		  attributes:
+		    age: 20
+		  expect:
+		    allowed: true
+		    reason: GRANT_POLICY_FOUND
+		    grantedRoles: [reader]`
+)
+
+// policyTestExpectation is the expected decision of a test case. Reason and GrantedRoles are checked only if they
+// are specified, an empty list of granted roles means no role is granted.
+type policyTestExpectation struct {
+	Allowed      bool     `json:"allowed"`
+	Reason       string   `json:"reason,omitempty"`
+	GrantedRoles []string `json:"grantedRoles,omitempty"`
+}
+
+// policyTestCase is a request context with the expected decision
+type policyTestCase struct {
+	Name string `json:"name,omitempty"`
+	ads.RequestContext
+	Expect policyTestExpectation `json:"expect"`
+}
+
+type policyTestCases struct {
+	Cases []*policyTestCase `json:"cases"`
+}
+
+// policyTestResult is the result of a test case, Failures is empty if the case passes
+type policyTestResult struct {
+	Case     *policyTestCase
+	Failures []string
+	Trace    *ads.EvaluationResult
+	Duration time.Duration
+}
+
+// policyCoverage lists the policies and role policies which are not exercised by any test case, as
+// "service/name (id)" or "service/id (content)", a policy is exercised if it is evaluated in the Diagnose trace of a test case.
+type policyCoverage struct {
+	Policies                int
+	RolePolicies            int
+	UnexercisedPolicies     []string
+	UnexercisedRolePolicies []string
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Content string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name         `xml:"testsuite"`
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Time      string           `xml:"time,attr"`
+	TestCases []*junitTestCase `xml:"testcase"`
+}
+
+func newTestCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test --policies (SPDL | JSON) --cases FILE [--junit FILE]",
+		Short: "Test policies against the expected decisions in test cases, without any running service",
+		Long: `Load the policies into an in-process evaluator, and check the decision of every test case. A failed case is printed
+with the Diagnose trace of its request. The policies and role policies which are not exercised by any case are reported
+as policy coverage. The command exits with 1 if any case fails.`,
+		Example: testExample,
+		Run:     testCommandFunc,
+	}
+
+	cmd.Flags().StringVar(&testPoliciesFile, "policies", "", "SPDL or json file of the policies to test")
+	cmd.Flags().StringVar(&testCasesFile, "cases", "", "YAML or json file of the test cases")
+	cmd.Flags().StringVar(&testJUnitFile, "junit", "", "file to write the results in JUnit XML format")
+	return cmd
+}
+
+// readTestCases reads the test cases in YAML or json, either a list of cases or an object with the cases
+func readTestCases(fileName string) ([]*policyTestCase, error) {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var cases []*policyTestCase
+	if err := yaml.Unmarshal(content, &cases); err != nil {
+		var casesObject policyTestCases
+		if err := yaml.Unmarshal(content, &casesObject); err != nil {
+			return nil, fmt.Errorf("failed to read test cases from %s: %v", fileName, err)
+		}
+		cases = casesObject.Cases
+	}
+	for i, c := range cases {
+		if c == nil {
+			return nil, fmt.Errorf("test case %d in %s is empty", i, fileName)
+		}
+		if len(c.Name) == 0 {
+			c.Name = fmt.Sprintf("case %d", i+1)
+		}
+	}
+	return cases, nil
+}
+
+func runPolicyTestCase(evaluator ads.PolicyEvaluator, c *policyTestCase) *policyTestResult {
+	start := time.Now()
+	result := policyTestResult{Case: c}
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+
+	trace, err := evaluator.Diagnose(c.RequestContext)
+	result.Trace = trace
+	if err != nil {
+		result.Failures = append(result.Failures, fmt.Sprintf("error in evaluation: %v", err))
+		return &result
+	}
+	if trace.Allowed != c.Expect.Allowed {
+		result.Failures = append(result.Failures, fmt.Sprintf("expected allowed %t, but got %t (%s)", c.Expect.Allowed, trace.Allowed, trace.Reason))
+	}
+	if len(c.Expect.Reason) > 0 && c.Expect.Reason != trace.Reason.String() {
+		result.Failures = append(result.Failures, fmt.Sprintf("expected reason %s, but got %s", c.Expect.Reason, trace.Reason))
+	}
+	if c.Expect.GrantedRoles != nil {
+		// the trace has no granted role if the service has no policy, so the roles are resolved separately
+		roles, err := evaluator.GetAllGrantedRoles(c.RequestContext)
+		if err != nil {
+			result.Failures = append(result.Failures, fmt.Sprintf("error in resolving roles: %v", err))
+			return &result
+		}
+		expected := append([]string{}, c.Expect.GrantedRoles...)
+		roles = append([]string{}, roles...)
+		sort.Strings(expected)
+		sort.Strings(roles)
+		if !reflect.DeepEqual(expected, roles) {
+			result.Failures = append(result.Failures, fmt.Sprintf("expected granted roles [%s], but got [%s]", strings.Join(expected, ", "), strings.Join(roles, ", ")))
+		}
+	}
+	return &result
+}
+
+// policyDisplayName identifies a policy by its name, or by its content if it has no name like a policy in SPDL
+func policyDisplayName(serviceName, name, id, content string) string {
+	if len(name) == 0 {
+		return fmt.Sprintf("%s/%s (%s)", serviceName, id, content)
+	}
+	return fmt.Sprintf("%s/%s (%s)", serviceName, name, id)
+}
+
+func describePolicy(policy *pms.Policy) string {
+	var principals, permissions []string
+	for _, and := range policy.Principals {
+		principals = append(principals, strings.Join(and, " && "))
+	}
+	for _, permission := range policy.Permissions {
+		resource := permission.Resource
+		if len(permission.ResourceExpression) > 0 {
+			resource = "expr:" + permission.ResourceExpression
+		} else if len(permission.ResourceGlob) > 0 {
+			resource = "glob:" + permission.ResourceGlob
+		}
+		permissions = append(permissions, strings.Join(permission.Actions, ",")+" "+resource)
+	}
+	return fmt.Sprintf("%s %s %s", policy.Effect, strings.Join(principals, " || "), strings.Join(permissions, "; "))
+}
+
+func describeRolePolicy(rolePolicy *pms.RolePolicy) string {
+	return fmt.Sprintf("%s %s %s", rolePolicy.Effect, strings.Join(rolePolicy.Principals, ","), strings.Join(rolePolicy.Roles, ","))
+}
+
+// computePolicyCoverage finds the policies and role policies which aren't in the trace of any result
+func computePolicyCoverage(store *eval.RuntimePolicyStore, results []*policyTestResult) *policyCoverage {
+	exercisedPolicies := make(map[string]bool)
+	exercisedRolePolicies := make(map[string]bool)
+	for _, result := range results {
+		if result.Trace == nil {
+			continue
+		}
+		for _, policy := range result.Trace.Policies {
+			exercisedPolicies[result.Case.ServiceName+"/"+policy.ID] = true
+		}
+		for _, rolePolicy := range result.Trace.RolePolicies {
+			exercisedRolePolicies[result.Case.ServiceName+"/"+rolePolicy.ID] = true
+		}
+	}
+
+	coverage := policyCoverage{}
+	for serviceName, service := range store.RuntimeServices {
+		for id, policy := range service.PoliciesCache.PolicyMap {
+			coverage.Policies++
+			if !exercisedPolicies[serviceName+"/"+id] && !exercisedGlobally(exercisedPolicies, serviceName, id) {
+				coverage.UnexercisedPolicies = append(coverage.UnexercisedPolicies, policyDisplayName(serviceName, policy.Name, id, describePolicy(policy)))
+			}
+		}
+		for id, rolePolicy := range service.RolePoliciesCache.PolicyMap {
+			coverage.RolePolicies++
+			if !exercisedRolePolicies[serviceName+"/"+id] && !exercisedGlobally(exercisedRolePolicies, serviceName, id) {
+				coverage.UnexercisedRolePolicies = append(coverage.UnexercisedRolePolicies, policyDisplayName(serviceName, rolePolicy.Name, id, describeRolePolicy(rolePolicy)))
+			}
+		}
+	}
+	sort.Strings(coverage.UnexercisedPolicies)
+	sort.Strings(coverage.UnexercisedRolePolicies)
+	return &coverage
+}
+
+// exercisedGlobally checks if a policy of the global service is evaluated for a request to any service
+func exercisedGlobally(exercised map[string]bool, serviceName, id string) bool {
+	if serviceName != pms.GlobalService {
+		return false
+	}
+	for key := range exercised {
+		if strings.HasSuffix(key, "/"+id) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatPolicyTestResults prints PASS or FAIL of every case, the failures and the Diagnose traces of the failed
+// cases, and the policy coverage
+func formatPolicyTestResults(results []*policyTestResult, coverage *policyCoverage) string {
+	var buf bytes.Buffer
+	failed := 0
+	for _, result := range results {
+		if len(result.Failures) == 0 {
+			fmt.Fprintf(&buf, "PASS  %s\n", result.Case.Name)
+			continue
+		}
+		failed++
+		fmt.Fprintf(&buf, "FAIL  %s\n", result.Case.Name)
+		for _, failure := range result.Failures {
+			fmt.Fprintf(&buf, "    %s\n", failure)
+		}
+		if result.Trace != nil {
+			trace, _ := json.MarshalIndent(result.Trace, "    ", strings.Repeat(" ", 4))
+			fmt.Fprintf(&buf, "    diagnose: %s\n", trace)
+		}
+	}
+	fmt.Fprintf(&buf, "%d passed, %d failed.\n", len(results)-failed, failed)
+	fmt.Fprintf(&buf, "Policy coverage: %d/%d policies, %d/%d role policies exercised.\n",
+		coverage.Policies-len(coverage.UnexercisedPolicies), coverage.Policies,
+		coverage.RolePolicies-len(coverage.UnexercisedRolePolicies), coverage.RolePolicies)
+	for _, policy := range coverage.UnexercisedPolicies {
+		fmt.Fprintf(&buf, "    policy %s is never exercised\n", policy)
+	}
+	for _, rolePolicy := range coverage.UnexercisedRolePolicies {
+		fmt.Fprintf(&buf, "    role policy %s is never exercised\n", rolePolicy)
+	}
+	return buf.String()
+}
+
+func junitReport(results []*policyTestResult) ([]byte, error) {
+	suite := junitTestSuite{Name: testCasesFile, Tests: len(results)}
+	var total time.Duration
+	for _, result := range results {
+		total += result.Duration
+		testCase := &junitTestCase{
+			Name:      result.Case.Name,
+			ClassName: result.Case.ServiceName,
+			Time:      fmt.Sprintf("%.3f", result.Duration.Seconds()),
+		}
+		if len(result.Failures) > 0 {
+			suite.Failures++
+			trace, _ := json.MarshalIndent(result.Trace, "", strings.Repeat(" ", 4))
+			testCase.Failure = &junitFailure{
+				Message: strings.Join(result.Failures, "; "),
+				Content: string(trace),
+			}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	suite.Time = fmt.Sprintf("%.3f", total.Seconds())
+	output, err := xml.MarshalIndent(&suite, "", strings.Repeat(" ", 4))
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), output...), nil
+}
+
+func testCommandFunc(cmd *cobra.Command, args []string) {
+	if len(testPoliciesFile) == 0 || len(testCasesFile) == 0 {
+		printHelpAndExit(cmd)
+	}
+
+	cases, err := readTestCases(testCasesFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if _, err := os.Stat(testPoliciesFile); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	evaluator, err := eval.NewFromFile(testPoliciesFile, false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load policies from %s: %v\n", testPoliciesFile, err)
+		os.Exit(1)
+	}
+
+	var results []*policyTestResult
+	failed := false
+	for _, c := range cases {
+		result := runPolicyTestCase(evaluator, c)
+		failed = failed || len(result.Failures) > 0
+		results = append(results, result)
+	}
+	coverage := computePolicyCoverage(evaluator.(*eval.PolicyEvalImpl).RuntimePolicyStore, results)
+	fmt.Print(formatPolicyTestResults(results, coverage))
+
+	if len(testJUnitFile) > 0 {
+		report, err := junitReport(results)
+		if err == nil {
+			err = ioutil.WriteFile(testJUnitFile, report, 0644)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,117 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package command
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/teramoby/speedle-plus/pkg/eval"
+)
+
+const testPolicies = `{
+	"services": [{
+		"name": "library",
+		"type": "application",
+		"policies": [
+			{"id": "p1", "name": "readers", "effect": "grant", "principals": [["role:reader"]], "permissions": [{"resource": "/books", "actions": ["read"]}]},
+			{"id": "p2", "name": "writers", "effect": "grant", "principals": [["user:bob"]], "permissions": [{"resource": "/books", "actions": ["write"]}]}
+		],
+		"rolePolicies": [
+			{"id": "rp1", "name": "alice", "effect": "grant", "roles": ["reader"], "principals": ["user:alice"]}
+		]
+	}]
+}`
+
+const testCases = `
+cases:
+- name: alice reads books
+  subject:
+    principals:
+    - type: user
+      name: alice
+  serviceName: library
+  resource: /books
+  action: read
+  expect:
+    allowed: true
+    reason: GRANT_POLICY_FOUND
+    grantedRoles: [reader]
+- subject:
+    principals:
+    - type: user
+      name: alice
+  serviceName: library
+  resource: /books
+  action: write
+  expect:
+    allowed: true
+`
+
+func TestPolicyTestCases(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spctltest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	policiesFile := filepath.Join(dir, "policies.json")
+	casesFile := filepath.Join(dir, "cases.yaml")
+	if err := ioutil.WriteFile(policiesFile, []byte(testPolicies), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(casesFile, []byte(testCases), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases, err := readTestCases(casesFile)
+	if err != nil {
+		t.Fatal("fail to read test cases:", err)
+	}
+	if len(cases) != 2 || cases[1].Name != "case 2" || cases[0].Subject.Principals[0].Name != "alice" {
+		t.Fatalf("unexpected test cases %+v", cases)
+	}
+	evaluator, err := eval.NewFromFile(policiesFile, false)
+	if err != nil {
+		t.Fatal("fail to create evaluator:", err)
+	}
+	var results []*policyTestResult
+	for _, c := range cases {
+		results = append(results, runPolicyTestCase(evaluator, c))
+	}
+	if len(results[0].Failures) != 0 {
+		t.Errorf("expected case 1 to pass, but got %v", results[0].Failures)
+	}
+	if len(results[1].Failures) != 1 || !strings.Contains(results[1].Failures[0], "expected allowed true, but got false") {
+		t.Errorf("expected case 2 to fail, but got %v", results[1].Failures)
+	}
+
+	coverage := computePolicyCoverage(evaluator.(*eval.PolicyEvalImpl).RuntimePolicyStore, results)
+	if coverage.Policies != 2 || coverage.RolePolicies != 1 || len(coverage.UnexercisedRolePolicies) != 0 ||
+		len(coverage.UnexercisedPolicies) != 1 || coverage.UnexercisedPolicies[0] != "library/writers (p2)" {
+		t.Errorf("unexpected coverage %+v", coverage)
+	}
+	output := formatPolicyTestResults(results, coverage)
+	for _, expected := range []string{"PASS  alice reads books\n", "FAIL  case 2\n", "    diagnose: {", "1 passed, 1 failed.\n",
+		"Policy coverage: 1/2 policies, 1/1 role policies exercised.\n", "    policy library/writers (p2) is never exercised\n"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in output:\n%s", expected, output)
+		}
+	}
+
+	report, err := junitReport(results)
+	if err != nil {
+		t.Fatal("fail to create JUnit report:", err)
+	}
+	var suite junitTestSuite
+	if err := xml.Unmarshal(report, &suite); err != nil {
+		t.Fatal("fail to parse JUnit report:", err)
+	}
+	if suite.Tests != 2 || suite.Failures != 1 || suite.TestCases[0].Failure != nil || suite.TestCases[1].Failure == nil {
+		t.Errorf("unexpected JUnit report:\n%s", report)
+	}
+}
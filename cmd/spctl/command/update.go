@@ -0,0 +1,162 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/cmd/spctl/client"
+	"github.com/teramoby/speedle-plus/pkg/pdl"
+)
+
+var (
+	updateExample = `
+		# Update the type of service "service1" to "k8s"
+		spctl update service service1 --service-type=k8s
+
+		# Replace policy "p01" in service "service1" using pdl
+		spctl update policy p01 --pdl-command "grant group Administrators list,watch,get expr:c1/default/core/pods/*" --service-name=service1
+
+		# Replace policy "p01" in service "service1" using the data in policy.json
+		spctl update policy p01 --json-file ./policy.json --service-name=service1
+
+		# Replace role policy "rp01" in service "service1" using pdl
+		spctl update rolepolicy rp01 --pdl-command "grant user User1 Role1 on res1" --service-name=service1
+
+		# Update the url and cache settings of function "foo"
+		spctl update function foo --func-url=https://a.b.c:3456/funcs/foo --cachable=true --cache-ttl=3600
+
+		# Replace function "foo" using function definition json file
+		spctl update function foo --json-file=function.json`
+)
+
+func newUpdateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "update (service | policy | rolepolicy | function) (NAME | ID) [--json-file JSON_FILENAME] [--pdl-command COMMMAND] [--service-type=TYPE] [--service-name=NAME]",
+		Short:   "Update a service | policy | role-policy | function",
+		Example: updateExample,
+		Run:     updateCommandFunc,
+	}
+
+	cmd.Flags().StringVarP(&serviceType, "service-type", "t", pms.TypeApplication, "service type, e.g. k8s")
+	cmd.Flags().StringVarP(&serviceName, "service-name", "s", "", "service name")
+	cmd.Flags().StringVarP(&command, "pdl-command", "c", "", "policy definition language command")
+	cmd.Flags().StringVarP(&jsonFileName, "json-file", "f", "", "file that contains policy/role policy/service/function definition in json format")
+	cmd.Flags().StringVarP(&funcURL, "func-url", "", "", "URL for the function")
+	cmd.Flags().BoolVarP(&funcResultCachable, "cachable", "", false, "whether the function result is cachable")
+	cmd.Flags().Int64VarP(&funcResultTTL, "cache-ttl", "", 0, "How many seconds could the function result be kept in cache, 0 means the result could be kept in cache forever")
+	return cmd
+}
+
+func updateCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 2 || args[1] == "" {
+		printHelpAndExit(cmd)
+	}
+
+	hc, err := httpClient()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	cli := &client.Client{
+		PMSEndpoint: globalFlags.PMSEndpoint,
+		HTTPClient:  hc,
+	}
+	var res string
+	var buf []byte
+
+	switch strings.ToLower(args[0]) {
+	case "service":
+		if jsonFileName != "" {
+			buf, err = ioutil.ReadFile(jsonFileName)
+		} else {
+			if serviceType == "" {
+				printHelpAndExit(cmd)
+			}
+			buf, err = json.Marshal(pms.Service{Name: args[1], Type: serviceType})
+		}
+		if err == nil {
+			res, err = cli.Put([]string{"service", args[1]}, bytes.NewBuffer(buf), "")
+		}
+
+	case "policy", "rolepolicy":
+		if serviceName == "" {
+			printHelpAndExit(cmd)
+		}
+		kind := "policy"
+		if "rolepolicy" == strings.ToLower(args[0]) {
+			kind = "role-policy"
+		}
+		if command != "" {
+			// pdl does not carry the policy name, so keep the name of the existing one
+			var existing struct {
+				Name string `json:"name"`
+			}
+			var body []byte
+			if body, err = cli.Get([]string{"service", serviceName, kind, args[1]}, nil, ""); err == nil {
+				err = json.Unmarshal(body, &existing)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if kind == "policy" {
+				var policy *pms.Policy
+				if policy, _, err = pdl.ParsePolicy(command, existing.Name); err == nil {
+					policy.ID = args[1]
+					buf, err = json.Marshal(policy)
+				}
+			} else {
+				var rolePolicy *pms.RolePolicy
+				if rolePolicy, _, err = pdl.ParseRolePolicy(command, existing.Name); err == nil {
+					rolePolicy.ID = args[1]
+					buf, err = json.Marshal(rolePolicy)
+				}
+			}
+		} else {
+			if jsonFileName == "" {
+				printHelpAndExit(cmd)
+			}
+			buf, err = ioutil.ReadFile(jsonFileName)
+		}
+		if err == nil {
+			res, err = cli.Put([]string{"service", serviceName, kind, args[1]}, bytes.NewBuffer(buf), "")
+		}
+
+	case "function":
+		if jsonFileName != "" {
+			buf, err = ioutil.ReadFile(jsonFileName)
+		} else {
+			function := pms.Function{
+				Name:           args[1],
+				FuncURL:        funcURL,
+				ResultCachable: funcResultCachable,
+				ResultTTL:      funcResultTTL,
+			}
+			buf, err = json.Marshal(function)
+		}
+		if err == nil {
+			res, err = cli.Put([]string{"function", args[1]}, bytes.NewBuffer(buf), "")
+		}
+
+	default:
+		printHelpAndExit(cmd)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s updated\n%s\n", args[0], res)
+}
@@ -0,0 +1,134 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/cmd/spctl/client"
+)
+
+var (
+	whoCanAccessExample = `
+		# List who could read resource "books" in service "library"
+		spctl who-can-access books read --service-name=library
+
+		# List who could read resource "books" in service "library" in json format
+		spctl who-can-access books read --service-name=library -o json`
+)
+
+func newWhoCanAccessCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "who-can-access RESOURCE ACTION --service-name=NAME [-o text|json]",
+		Short:   "List the principals which could be granted or denied an action on a resource",
+		Example: whoCanAccessExample,
+		Run:     whoCanAccessCommandFunc,
+	}
+
+	cmd.Flags().StringVar(&serviceName, "service-name", "", "Service name")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "output format, text or json")
+	return cmd
+}
+
+func whoCanAccessCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 2 || len(serviceName) == 0 {
+		printHelpAndExit(cmd)
+	}
+	if outputFormat != "text" && outputFormat != "json" {
+		fmt.Fprintf(os.Stderr, "invalid output format %q, should be text or json\n", outputFormat)
+		os.Exit(1)
+	}
+
+	hc, err := httpClient()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	cli := &client.Client{
+		ADSEndpoint: globalFlags.ADSEndpoint,
+		HTTPClient:  hc,
+	}
+	payload, err := json.Marshal(ads.Access{
+		ServiceName: serviceName,
+		Resource:    args[0],
+		Action:      args[1],
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	res, err := cli.PostADS([]string{"who-can-access"}, bytes.NewBuffer(payload), "")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var review ads.AccessReview
+	if err := json.Unmarshal([]byte(res), &review); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if outputFormat == "json" {
+		output, _ := json.MarshalIndent(&review, "", strings.Repeat(" ", 4))
+		fmt.Println(string(output))
+		return
+	}
+	fmt.Print(formatAccessReview(&review))
+}
+
+// formatAccessPath formats a path as "principals [via roles] by policy [if conditions]", roles are listed
+// from the one granted to the principals to the one in the policy
+func formatAccessPath(path *ads.AccessPath) string {
+	line := strings.Join(path.Principals, " & ")
+	if len(path.Roles) > 0 {
+		var roles []string
+		for i := len(path.Roles) - 1; i >= 0; i-- {
+			roles = append(roles, path.Roles[i])
+		}
+		line += " via role " + strings.Join(roles, " -> ")
+	}
+	policy := path.PolicyID
+	if len(path.PolicyName) > 0 {
+		policy = path.PolicyName + " (" + path.PolicyID + ")"
+	}
+	line += " by policy " + policy
+	if len(path.Conditions) > 0 {
+		line += " if " + strings.Join(path.Conditions, " && ")
+	}
+	return line
+}
+
+func formatAccessReview(review *ads.AccessReview) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s in service %s\n", review.Action, review.Resource, review.ServiceName)
+	fmt.Fprintln(&buf, "granted:")
+	for _, path := range review.Granted {
+		fmt.Fprintln(&buf, "    "+formatAccessPath(path))
+	}
+	fmt.Fprintln(&buf, "denied:")
+	for _, path := range review.Denied {
+		fmt.Fprintln(&buf, "    "+formatAccessPath(path))
+	}
+	if len(review.DeniedRoles) > 0 {
+		fmt.Fprintln(&buf, "denied roles:")
+		for _, denial := range review.DeniedRoles {
+			rolePolicy := denial.RolePolicyID
+			if len(denial.RolePolicyName) > 0 {
+				rolePolicy = denial.RolePolicyName + " (" + denial.RolePolicyID + ")"
+			}
+			line := fmt.Sprintf("    role %s to %s by role policy %s", denial.Role, strings.Join(denial.Principals, ", "), rolePolicy)
+			if len(denial.Condition) > 0 {
+				line += " if " + denial.Condition
+			}
+			fmt.Fprintln(&buf, line)
+		}
+	}
+	return buf.String()
+}
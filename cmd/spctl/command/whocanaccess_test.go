@@ -0,0 +1,40 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package command
+
+import (
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/ads"
+)
+
+func TestFormatAccessReview(t *testing.T) {
+	review := &ads.AccessReview{
+		ServiceName: "library",
+		Resource:    "books",
+		Action:      "read",
+		Granted: []*ads.AccessPath{
+			{Principals: []string{"user:alice"}, PolicyID: "id1", PolicyName: "p1"},
+			{Principals: []string{"group:staff", "entity:/bin/app"}, Roles: []string{"reader", "member"}, RolePolicyIDs: []string{"rp1", "rp2"}, PolicyID: "id1", PolicyName: "p1", Conditions: []string{"hour < 18", "level > 3"}},
+		},
+		Denied: []*ads.AccessPath{
+			{Principals: []string{"role:banned"}, PolicyID: "id2"},
+		},
+		DeniedRoles: []*ads.RoleDenial{
+			{Principals: []string{"group:interns"}, Role: "reader", RolePolicyID: "rp3", Condition: "level < 2"},
+		},
+	}
+	expected := `read books in service library
+granted:
+    user:alice by policy p1 (id1)
+    group:staff & entity:/bin/app via role member -> reader by policy p1 (id1) if hour < 18 && level > 3
+denied:
+    role:banned by policy id2
+denied roles:
+    role reader to group:interns by role policy rp3 if level < 2
+`
+	if output := formatAccessReview(review); output != expected {
+		t.Fatalf("expected output:\n%s\nbut got:\n%s", expected, output)
+	}
+}
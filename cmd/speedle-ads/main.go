@@ -70,17 +70,20 @@ func main() {
 		log.Error("No any audit log configurations for authorization service.\n")
 	}
 
-	evaluator, err := newEvaluator(conf)
+	evaluators, err := newEvaluators(conf)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if len(conf.Tenants) != 0 {
+		log.Infof("Serving tenants %v besides the default tenant.", conf.Tenants)
+	}
 
-	httpServer, err := newHTTPServer(&params, evaluator)
+	httpServer, err := newHTTPServer(&params, evaluators)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	grpcServer, err := newGRPCServer(evaluator)
+	grpcServer, err := newGRPCServer(evaluators)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -125,8 +128,9 @@ func main() {
 	}
 }
 
-func newEvaluator(conf *cfg.Config) (eval.InternalEvaluator, error) {
-	evaluator, err := eval.NewFromConfig(conf)
+// newEvaluators creates the evaluators of the tenants, which assert the tokens by the same asserter
+func newEvaluators(conf *cfg.Config) (map[string]eval.InternalEvaluator, error) {
+	evaluators, err := eval.NewTenantEvaluators(conf)
 	if err != nil {
 		return nil, err
 	}
@@ -155,20 +159,25 @@ func newEvaluator(conf *cfg.Config) (eval.InternalEvaluator, error) {
 			}
 			return nil
 		}
-		evaluator.SetAsserterFunc(f)
+		for _, evaluator := range evaluators {
+			evaluator.SetAsserterFunc(f)
+		}
 	}
 
-	return evaluator, nil
+	return evaluators, nil
 }
 
-func newGRPCServer(evaluator eval.InternalEvaluator) (*grpc.Server, error) {
+func newGRPCServer(evaluators map[string]eval.InternalEvaluator) (*grpc.Server, error) {
 
-	serviceImpl, err := adsgrpc.NewGRPCService(evaluator)
+	serviceImpl, err := adsgrpc.NewGRPCService(evaluators[store.DefaultTenant])
 	if err != nil {
 		return nil, err
 	}
 
-	server := grpc.NewServer()
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(adsgrpc.UnaryTenantInterceptor(evaluators)),
+		grpc.StreamInterceptor(adsgrpc.StreamTenantInterceptor(evaluators)),
+	)
 	pb.RegisterEvaluatorServer(server, serviceImpl)
 	// Register reflection service on gRPC server.
 	reflection.Register(server)
@@ -186,8 +195,8 @@ func listenGRPCServer(server *grpc.Server) error {
 	return nil
 }
 
-func newHTTPServer(params *flags.Parameters, evaluator eval.InternalEvaluator) (*http.Server, error) {
-	routers, err := adsrest.NewRouter(evaluator)
+func newHTTPServer(params *flags.Parameters, evaluators map[string]eval.InternalEvaluator) (*http.Server, error) {
+	routers, err := adsrest.NewTenantRouter(evaluators)
 	if err != nil {
 		return nil, err
 	}
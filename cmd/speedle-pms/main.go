@@ -10,14 +10,18 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 
 	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/assertion"
+	"github.com/teramoby/speedle-plus/pkg/cfg"
 	"github.com/teramoby/speedle-plus/pkg/cmd/flags"
 	"github.com/teramoby/speedle-plus/pkg/errors"
 	"github.com/teramoby/speedle-plus/pkg/logging"
 	"github.com/teramoby/speedle-plus/pkg/store"
 	"github.com/teramoby/speedle-plus/pkg/svcs/pmsgrpc"
 	"github.com/teramoby/speedle-plus/pkg/svcs/pmsgrpc/pb"
+	"github.com/teramoby/speedle-plus/pkg/svcs/pmsimpl"
 	"github.com/teramoby/speedle-plus/pkg/svcs/pmsrest"
 
 	log "github.com/sirupsen/logrus"
@@ -67,17 +71,26 @@ func main() {
 		log.Error("No any audit log configurations for Policy_mgmt.")
 	}
 
-	ps, err := store.NewStore(conf.StoreConfig.StoreType, conf.StoreConfig.StoreProps)
+	if pmsimpl.LintPolicies, _ = strconv.ParseBool(params.LintPolicies.Value); pmsimpl.LintPolicies {
+		log.Info("Services, policies and role policies with errors found by the policy analyzer are rejected.")
+	}
+
+	tenants, err := pmsimpl.NewTenants(conf, func(ps pms.PolicyStoreManager) (*pmsimpl.Authorizer, error) {
+		return newAuthorizer(&params, conf, ps)
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
+	if len(conf.Tenants) != 0 {
+		log.Infof("Serving tenants %v besides the default tenant.", conf.Tenants)
+	}
 
-	httpServer, err := newHTTPServer(&params, ps)
+	httpServer, err := newHTTPServer(&params, tenants)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	grpcServer, err := newGRPCServer(ps)
+	grpcServer, err := newGRPCServer(tenants)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -122,9 +135,29 @@ func main() {
 	}
 }
 
-func newGRPCServer(ps pms.PolicyStoreManager) (*grpc.Server, error) {
-	server := grpc.NewServer()
-	pb.RegisterPolicyManagerServer(server, pmsgrpc.NewServiceImpl(ps))
+// newAuthorizer creates the authorizer protecting the management calls if enable-authz is set
+func newAuthorizer(params *flags.Parameters, conf *cfg.Config, ps pms.PolicyStoreManager) (*pmsimpl.Authorizer, error) {
+	enableAuthz, _ := strconv.ParseBool(params.EnableAuthz.Value)
+	if !enableAuthz {
+		return nil, nil
+	}
+
+	log.Info("Loading asserters.")
+	as, errLoadAsserter := assertion.NewAsserter(conf.AsserterWebhookConfig, nil)
+	if errLoadAsserter != nil {
+		log.Warningf("load asserter error: %v, only client certificates are accepted", errLoadAsserter)
+	}
+
+	// the admin policies are changed through this service, so they are always watched
+	authzConf := *conf
+	authzConf.EnableWatch = true
+	log.Infof("Management calls are authorized against the policies of service %s.", pmsimpl.AdminService)
+	return pmsimpl.NewAuthorizer(&authzConf, ps, as)
+}
+
+func newGRPCServer(tenants map[string]*pmsimpl.Tenant) (*grpc.Server, error) {
+	server := grpc.NewServer(grpc.UnaryInterceptor(pmsgrpc.UnaryTenantInterceptor(tenants)))
+	pb.RegisterPolicyManagerServer(server, pmsgrpc.NewServiceImpl(tenants[store.DefaultTenant].PolicyStore))
 	reflection.Register(server)
 	return server, nil
 }
@@ -141,8 +174,8 @@ func listenGRPCServer(server *grpc.Server) error {
 	return nil
 }
 
-func newHTTPServer(params *flags.Parameters, ps pms.PolicyStoreManager) (*http.Server, error) {
-	routers, err := pmsrest.NewRouter(ps)
+func newHTTPServer(params *flags.Parameters, tenants map[string]*pmsimpl.Tenant) (*http.Server, error) {
+	routers, err := pmsrest.NewTenantRouter(tenants)
 	if err != nil {
 		log.Error("Fail to create handler...")
 		return nil, err
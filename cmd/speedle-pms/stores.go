@@ -7,7 +7,9 @@
 package main
 
 import (
+	_ "github.com/teramoby/speedle-plus/pkg/store/bolt"
 	_ "github.com/teramoby/speedle-plus/pkg/store/etcd"
 	_ "github.com/teramoby/speedle-plus/pkg/store/file"
 	_ "github.com/teramoby/speedle-plus/pkg/store/mongodb"
+	_ "github.com/teramoby/speedle-plus/pkg/store/sql"
 )
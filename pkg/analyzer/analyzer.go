@@ -0,0 +1,153 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+// Package analyzer finds the problems of policies without evaluating any request, like grant policies which never
+// take effect, duplicate policies, roles which are granted but never used, role cycles and invalid conditions.
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+)
+
+// Kinds of findings
+const (
+	// KindShadowed is a policy which never takes effect, because a policy with the opposite effect overrides it
+	KindShadowed = "shadowed"
+	// KindDuplicate is a policy identical to another policy
+	KindDuplicate = "duplicate"
+	// KindSubsumed is a policy which applies to a subset of the requests of another policy with the same effect
+	KindSubsumed = "subsumed"
+	// KindUnusedRole is a role policy granting roles which no policy or role policy uses
+	KindUnusedRole = "unused-role"
+	// KindRoleCycle is a set of roles granted to each other by role policies
+	KindRoleCycle = "role-cycle"
+	// KindInvalidCondition is a condition which can't be compiled
+	KindInvalidCondition = "invalid-condition"
+	// KindUndefinedFunction is a condition calling functions which are neither built-in nor in the policy store
+	KindUndefinedFunction = "undefined-function"
+	// KindUndefinedAttribute is a condition referencing attributes which are neither built-in nor known
+	KindUndefinedAttribute = "undefined-attribute"
+)
+
+type Severity string
+
+const (
+	// SeverityError is a problem that the policy or role policy doesn't work as written
+	SeverityError Severity = "error"
+	// SeverityWarning is a policy or role policy which is likely unnecessary
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is a problem found in a service. The first one of Policies or RolePolicies is the policy or role policy
+// the finding is about, the others are the ones related, e.g. the policy shadowing it.
+type Finding struct {
+	Kind         string   `json:"kind"`
+	Severity     Severity `json:"severity"`
+	ServiceName  string   `json:"serviceName"`
+	Policies     []string `json:"policies,omitempty"`
+	RolePolicies []string `json:"rolePolicies,omitempty"`
+	Message      string   `json:"message"`
+}
+
+// Options are the definitions the policies are analyzed against
+type Options struct {
+	// Functions are the names of the custom functions besides the ones in the policy store
+	Functions []string
+	// Attributes are the names of the attributes in requests besides the built-in ones, the attributes in
+	// conditions aren't checked if it's nil
+	Attributes []string
+}
+
+// Analyze finds the problems of the services in the policy store, the findings are sorted by service, severity
+// and kind
+func Analyze(ps *pms.PolicyStore, opts *Options) []*Finding {
+	if opts == nil {
+		opts = &Options{}
+	}
+	conditions := newConditionChecker(ps.Functions, opts)
+
+	// roles used by any service could be granted by the global service
+	globalUsedRoles := make(map[string]bool)
+	for _, service := range ps.Services {
+		for role := range usedRoles(service) {
+			globalUsedRoles[role] = true
+		}
+	}
+
+	var findings []*Finding
+	for _, service := range ps.Services {
+		findings = append(findings, analyzeService(service, conditions, globalUsedRoles)...)
+	}
+	sortFindings(findings)
+	return findings
+}
+
+// AnalyzeService finds the problems of a service, functions are the custom functions in the policy store
+func AnalyzeService(service *pms.Service, functions []*pms.Function, opts *Options) []*Finding {
+	if opts == nil {
+		opts = &Options{}
+	}
+	findings := analyzeService(service, newConditionChecker(functions, opts), usedRoles(service))
+	sortFindings(findings)
+	return findings
+}
+
+func analyzeService(service *pms.Service, conditions *conditionChecker, globalUsedRoles map[string]bool) []*Finding {
+	var findings []*Finding
+	findings = append(findings, findShadowedPolicies(service)...)
+	findings = append(findings, findRedundantPolicies(service)...)
+	if service.Name == pms.GlobalService {
+		findings = append(findings, findUnusedRoles(service, globalUsedRoles)...)
+	} else {
+		findings = append(findings, findUnusedRoles(service, usedRoles(service))...)
+	}
+	findings = append(findings, findRoleCycles(service)...)
+	for _, policy := range service.Policies {
+		for _, finding := range conditions.check(policy.Condition) {
+			finding.ServiceName = service.Name
+			finding.Policies = []string{policy.ID}
+			finding.Message = fmt.Sprintf("%s: %s", policyRef(policy), finding.Message)
+			findings = append(findings, finding)
+		}
+	}
+	for _, rolePolicy := range service.RolePolicies {
+		for _, finding := range conditions.check(rolePolicy.Condition) {
+			finding.ServiceName = service.Name
+			finding.RolePolicies = []string{rolePolicy.ID}
+			finding.Message = fmt.Sprintf("%s: %s", rolePolicyRef(rolePolicy), finding.Message)
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// sortFindings sorts the findings by service, errors before warnings, then by kind, the order of the findings
+// of the same kind is kept
+func sortFindings(findings []*Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].ServiceName != findings[j].ServiceName {
+			return findings[i].ServiceName < findings[j].ServiceName
+		}
+		if findings[i].Severity != findings[j].Severity {
+			return findings[i].Severity == SeverityError
+		}
+		return findings[i].Kind < findings[j].Kind
+	})
+}
+
+func policyRef(policy *pms.Policy) string {
+	if len(policy.Name) == 0 {
+		return fmt.Sprintf("policy %s", policy.ID)
+	}
+	return fmt.Sprintf("policy %q (%s)", policy.Name, policy.ID)
+}
+
+func rolePolicyRef(rolePolicy *pms.RolePolicy) string {
+	if len(rolePolicy.Name) == 0 {
+		return fmt.Sprintf("role policy %s", rolePolicy.ID)
+	}
+	return fmt.Sprintf("role policy %q (%s)", rolePolicy.Name, rolePolicy.ID)
+}
@@ -0,0 +1,162 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+)
+
+func findingsOfKind(findings []*Finding, kind string) []*Finding {
+	var result []*Finding
+	for _, finding := range findings {
+		if finding.Kind == kind {
+			result = append(result, finding)
+		}
+	}
+	return result
+}
+
+func TestShadowedPolicies(t *testing.T) {
+	service := &pms.Service{
+		Name: "library",
+		Policies: []*pms.Policy{
+			{ID: "g1", Effect: pms.Grant, Principals: [][]string{{"user:alice", "group:staff"}},
+				Permissions: []*pms.Permission{{Resource: "/books/1", Actions: []string{"read"}}}},
+			{ID: "g2", Effect: pms.Grant, Principals: [][]string{{"user:alice"}, {"user:bob"}},
+				Permissions: []*pms.Permission{{Resource: "/books/1", Actions: []string{"read"}}}},
+			{ID: "g3", Effect: pms.Grant, Principals: [][]string{{"user:alice"}},
+				Permissions: []*pms.Permission{{ResourceExpression: "/books/.*", Actions: []string{"read"}}}},
+			{ID: "g4", Effect: pms.Grant, Principals: [][]string{{"user:alice"}}, Condition: "request_hour < 8",
+				Permissions: []*pms.Permission{{Resource: "/books/1"}}},
+			{ID: "d1", Effect: pms.Deny, Principals: [][]string{{"user:alice"}},
+				Permissions: []*pms.Permission{{ResourceExpression: "^/books/[0-9]+$", Actions: []string{"read", "write"}}}},
+		},
+	}
+
+	// g1 is shadowed, g2 still grants bob, d1 doesn't cover the expression of g3 or all the actions of g4
+	findings := findingsOfKind(AnalyzeService(service, nil, nil), KindShadowed)
+	if len(findings) != 1 || !reflect.DeepEqual(findings[0].Policies, []string{"g1", "d1"}) || findings[0].Severity != SeverityError {
+		t.Fatalf("unexpected findings %+v", findings)
+	}
+
+	// the denies are shadowed by grants with permit-overrides
+	service.CombiningAlgorithm = pms.PermitOverrides
+	service.Policies = append(service.Policies, &pms.Policy{ID: "d2", Effect: pms.Deny, Principals: [][]string{{"user:alice"}},
+		Permissions: []*pms.Permission{{Resource: "/books/2", Actions: []string{"read"}}}})
+	findings = findingsOfKind(AnalyzeService(service, nil, nil), KindShadowed)
+	if len(findings) != 1 || !reflect.DeepEqual(findings[0].Policies, []string{"d2", "g3"}) {
+		t.Fatalf("unexpected findings %+v", findings)
+	}
+
+	// with first-applicable, the policies applying first shadow the ones with the opposite effect
+	service.CombiningAlgorithm = pms.FirstApplicable
+	service.Policies[2].Priority = 10
+	findings = findingsOfKind(AnalyzeService(service, nil, nil), KindShadowed)
+	if len(findings) != 2 || !reflect.DeepEqual(findings[0].Policies, []string{"d2", "g3"}) ||
+		!reflect.DeepEqual(findings[1].Policies, []string{"g1", "d1"}) {
+		t.Fatalf("unexpected findings %+v", findings)
+	}
+}
+
+func TestRedundantPolicies(t *testing.T) {
+	service := &pms.Service{
+		Name: "library",
+		Policies: []*pms.Policy{
+			{ID: "p1", Effect: pms.Grant, Principals: [][]string{{"user:alice"}},
+				Permissions: []*pms.Permission{{Resource: "/books", Actions: []string{"read", "write"}}}},
+			{ID: "p2", Effect: pms.Grant, Principals: [][]string{{"user:alice"}},
+				Permissions: []*pms.Permission{{Resource: "/books", Actions: []string{"write", "read"}}}},
+			{ID: "p3", Effect: pms.Grant, Principals: [][]string{{"user:alice"}},
+				Permissions: []*pms.Permission{{Resource: "/books", Actions: []string{"read"}}}},
+			{ID: "p4", Effect: pms.Grant, Principals: [][]string{{"user:alice"}}, Condition: "request_hour < 8",
+				Permissions: []*pms.Permission{{Resource: "/books", Actions: []string{"read", "delete"}}}},
+		},
+	}
+	findings := AnalyzeService(service, nil, nil)
+	duplicates := findingsOfKind(findings, KindDuplicate)
+	subsumed := findingsOfKind(findings, KindSubsumed)
+	if len(duplicates) != 1 || !reflect.DeepEqual(duplicates[0].Policies, []string{"p2", "p1"}) {
+		t.Errorf("unexpected duplicates %+v", duplicates)
+	}
+	if len(subsumed) != 1 || !reflect.DeepEqual(subsumed[0].Policies, []string{"p3", "p1"}) || subsumed[0].Severity != SeverityWarning {
+		t.Errorf("unexpected subsumed policies %+v", subsumed)
+	}
+}
+
+func TestRoles(t *testing.T) {
+	ps := &pms.PolicyStore{
+		Services: []*pms.Service{
+			{
+				Name: "library",
+				Policies: []*pms.Policy{
+					{ID: "p1", Effect: pms.Grant, Principals: [][]string{{"role:reader"}}},
+				},
+				RolePolicies: []*pms.RolePolicy{
+					{ID: "rp1", Effect: pms.Grant, Roles: []string{"reader"}, Principals: []string{"user:alice"}},
+					{ID: "rp2", Effect: pms.Grant, Roles: []string{"writer", "editor"}, Principals: []string{"user:bob"}},
+					{ID: "rp3", Effect: pms.Grant, Roles: []string{"reader"}, Principals: []string{"role:editor"}},
+					{ID: "rp4", Effect: pms.Grant, Roles: []string{"editor"}, Principals: []string{"role:reader"}},
+					{ID: "rp5", Effect: pms.Grant, Roles: []string{"admin"}, Principals: []string{"role:admin"}},
+				},
+			},
+			{
+				Name: pms.GlobalService,
+				RolePolicies: []*pms.RolePolicy{
+					{ID: "rp6", Effect: pms.Grant, Roles: []string{"reader", "auditor"}, Principals: []string{"user:carol"}},
+				},
+			},
+		},
+	}
+	findings := Analyze(ps, nil)
+
+	unused := findingsOfKind(findings, KindUnusedRole)
+	if len(unused) != 2 || unused[0].ServiceName != pms.GlobalService || unused[0].RolePolicies[0] != "rp6" ||
+		unused[1].RolePolicies[0] != "rp2" || unused[1].Message != "role policy rp2 grants roles writer which no policy or role policy uses" {
+		t.Errorf("unexpected unused roles %+v", unused)
+	}
+
+	cycles := findingsOfKind(findings, KindRoleCycle)
+	if len(cycles) != 2 || !reflect.DeepEqual(cycles[0].RolePolicies, []string{"rp5"}) ||
+		!reflect.DeepEqual(cycles[1].RolePolicies, []string{"rp3", "rp4"}) ||
+		cycles[1].Message != "roles editor, reader are granted to each other in a cycle by role policies rp3, rp4" {
+		t.Errorf("unexpected role cycles %+v", cycles)
+	}
+}
+
+func TestConditions(t *testing.T) {
+	service := &pms.Service{
+		Name: "library",
+		Policies: []*pms.Policy{
+			{ID: "p1", Effect: pms.Grant, Condition: "Max(age, 18) > 20 && IsMember(request_user, 'staff')"},
+			{ID: "p2", Effect: pms.Grant, Condition: "Foo(1) && Bar(level)"},
+			{ID: "p3", Effect: pms.Grant, Condition: "age >"},
+		},
+		RolePolicies: []*pms.RolePolicy{
+			{ID: "rp1", Effect: pms.Grant, Roles: []string{"adult"}, Condition: "agee > 18"},
+		},
+	}
+	findings := AnalyzeService(service, []*pms.Function{{Name: "IsMember"}}, &Options{Attributes: []string{"age", "level"}})
+
+	undefinedFunctions := findingsOfKind(findings, KindUndefinedFunction)
+	if len(undefinedFunctions) != 1 || undefinedFunctions[0].Policies[0] != "p2" ||
+		undefinedFunctions[0].Message != `policy p2: condition "Foo(1) && Bar(level)" calls undefined functions Foo, Bar` {
+		t.Errorf("unexpected undefined functions %+v", undefinedFunctions)
+	}
+	invalid := findingsOfKind(findings, KindInvalidCondition)
+	if len(invalid) != 1 || invalid[0].Policies[0] != "p3" {
+		t.Errorf("unexpected invalid conditions %+v", invalid)
+	}
+	undefinedAttributes := findingsOfKind(findings, KindUndefinedAttribute)
+	if len(undefinedAttributes) != 1 || undefinedAttributes[0].RolePolicies[0] != "rp1" {
+		t.Errorf("unexpected undefined attributes %+v", undefinedAttributes)
+	}
+
+	// the attributes aren't checked without the known attributes
+	if findings := findingsOfKind(AnalyzeService(service, []*pms.Function{{Name: "IsMember"}}, nil), KindUndefinedAttribute); len(findings) != 0 {
+		t.Errorf("unexpected undefined attributes %+v", findings)
+	}
+}
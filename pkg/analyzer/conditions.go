@@ -0,0 +1,124 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/teramoby/speedle-plus/3rdparty/github.com/Knetic/govaluate"
+	"github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/eval"
+)
+
+// undefinedFunctionError is the prefix of the error of govaluate for a function which isn't defined
+const undefinedFunctionError = "Undefined function "
+
+var builtinAttributes = []string{
+	ads.BuiltIn_Attr_RequestUser,
+	ads.BuiltIn_Attr_RequestGroups,
+	ads.BuiltIn_Attr_RequestResource,
+	ads.BuiltIn_Attr_RequestAction,
+	ads.BuiltIn_Attr_RequestEntity,
+	ads.BuiltIn_Attr_RequestTime,
+	ads.BuiltIn_Attr_RequestYear,
+	ads.BuiltIn_Attr_RequestMonth,
+	ads.BuiltIn_Attr_RequestDay,
+	ads.BuiltIn_Attr_RequestHour,
+	ads.BuiltIn_Attr_RequestWeekday,
+}
+
+// stubFunction stands for the functions when conditions are compiled
+func stubFunction(args ...interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+// conditionChecker compiles conditions with the built-in and custom functions, the functions are never called
+type conditionChecker struct {
+	functions  map[string]govaluate.ExpressionFunction
+	attributes map[string]bool
+}
+
+func newConditionChecker(functions []*pms.Function, opts *Options) *conditionChecker {
+	checker := conditionChecker{functions: make(map[string]govaluate.ExpressionFunction)}
+	for _, name := range eval.BuiltinFunctionNames() {
+		checker.functions[name] = stubFunction
+	}
+	for _, function := range functions {
+		checker.functions[function.Name] = stubFunction
+	}
+	for _, name := range opts.Functions {
+		checker.functions[name] = stubFunction
+	}
+	if opts.Attributes != nil {
+		checker.attributes = make(map[string]bool)
+		for _, name := range append(builtinAttributes, opts.Attributes...) {
+			checker.attributes[name] = true
+		}
+	}
+	return &checker
+}
+
+// check returns the problems of a condition, the service and the policy of the findings are filled by the caller
+func (c *conditionChecker) check(condition string) []*Finding {
+	if len(condition) == 0 {
+		return nil
+	}
+
+	// every undefined function is found by compiling the condition again with the ones found so far
+	functions := c.functions
+	var undefined []string
+	var expression *govaluate.EvaluableExpression
+	for {
+		var err error
+		expression, err = govaluate.NewEvaluableExpressionWithFunctions(condition, functions)
+		if err == nil {
+			break
+		}
+		name := strings.TrimPrefix(err.Error(), undefinedFunctionError)
+		if name == err.Error() || contains(undefined, name) {
+			return []*Finding{{
+				Kind:     KindInvalidCondition,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("condition %q is invalid: %v", condition, err),
+			}}
+		}
+		if len(undefined) == 0 {
+			functions = make(map[string]govaluate.ExpressionFunction)
+			for name, function := range c.functions {
+				functions[name] = function
+			}
+		}
+		undefined = append(undefined, name)
+		functions[name] = stubFunction
+	}
+
+	var findings []*Finding
+	if len(undefined) > 0 {
+		findings = append(findings, &Finding{
+			Kind:     KindUndefinedFunction,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("condition %q calls undefined functions %s", condition, strings.Join(undefined, ", ")),
+		})
+	}
+	if c.attributes != nil {
+		var unknown []string
+		for _, name := range expression.Vars() {
+			if !c.attributes[name] && !contains(unknown, name) {
+				unknown = append(unknown, name)
+			}
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			findings = append(findings, &Finding{
+				Kind:     KindUndefinedAttribute,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("condition %q references undefined attributes %s", condition, strings.Join(unknown, ", ")),
+			})
+		}
+	}
+	return findings
+}
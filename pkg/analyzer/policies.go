@@ -0,0 +1,244 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/glob"
+)
+
+// covers checks if the broad policy applies to every request the narrow policy applies to. It's conservative, a
+// condition only covers the same condition, and resource expressions and globs only cover the same expressions and
+// globs, while resource names are matched against them.
+func covers(broad, narrow *pms.Policy) bool {
+	if len(broad.Condition) > 0 && broad.Condition != narrow.Condition {
+		return false
+	}
+	return principalsCover(broad.Principals, narrow.Principals) && permissionsCover(broad.Permissions, narrow.Permissions)
+}
+
+// principalsCover checks the principals, which are an OR list of AND lists, an empty list means any subject.
+// Every AND list of the narrow policy should contain an AND list of the broad policy.
+func principalsCover(broad, narrow [][]string) bool {
+	if len(broad) == 0 {
+		return true
+	}
+	if len(narrow) == 0 {
+		return false
+	}
+	for _, narrowAnd := range narrow {
+		covered := false
+		for _, broadAnd := range broad {
+			if isSubset(broadAnd, narrowAnd) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+func isSubset(sub, set []string) bool {
+	for _, s := range sub {
+		if !contains(set, s) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// permissionsCover checks that every action on the resources of the narrow permissions is allowed by one of the
+// broad permissions, empty permissions mean any action on any resource
+func permissionsCover(broad, narrow []*pms.Permission) bool {
+	if len(broad) == 0 {
+		return true
+	}
+	if len(narrow) == 0 {
+		return false
+	}
+	for _, narrowPerm := range narrow {
+		actions := narrowPerm.Actions
+		if len(actions) == 0 {
+			// any action, which is only covered by any action
+			actions = []string{""}
+		}
+		for _, action := range actions {
+			covered := false
+			for _, broadPerm := range broad {
+				if actionCovered(broadPerm, action) && resourceCovered(broadPerm, narrowPerm) {
+					covered = true
+					break
+				}
+			}
+			if !covered {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func actionCovered(perm *pms.Permission, action string) bool {
+	return len(perm.Actions) == 0 || (len(action) > 0 && contains(perm.Actions, action))
+}
+
+func anyResource(perm *pms.Permission) bool {
+	return len(perm.Resource) == 0 && len(perm.ResourceExpression) == 0 && len(perm.ResourceGlob) == 0
+}
+
+func resourceCovered(broad, narrow *pms.Permission) bool {
+	if anyResource(broad) {
+		return true
+	}
+	if anyResource(narrow) {
+		return false
+	}
+	if len(narrow.Resource) > 0 {
+		covered := broad.Resource == narrow.Resource
+		if !covered && len(broad.ResourceExpression) > 0 {
+			matched, err := regexp.MatchString(broad.ResourceExpression, narrow.Resource)
+			covered = err == nil && matched
+		}
+		if !covered && len(broad.ResourceGlob) > 0 {
+			covered = glob.Match(broad.ResourceGlob, narrow.Resource)
+		}
+		if !covered {
+			return false
+		}
+	}
+	if len(narrow.ResourceExpression) > 0 && broad.ResourceExpression != narrow.ResourceExpression {
+		return false
+	}
+	if len(narrow.ResourceGlob) > 0 && broad.ResourceGlob != narrow.ResourceGlob {
+		return false
+	}
+	return true
+}
+
+// findShadowedPolicies finds the policies overridden by a policy with the opposite effect for every request they
+// apply to, according to the combining algorithm of the service
+func findShadowedPolicies(service *pms.Service) []*Finding {
+	var findings []*Finding
+	shadowed := func(policy, by *pms.Policy, reason string) {
+		findings = append(findings, &Finding{
+			Kind:        KindShadowed,
+			Severity:    SeverityError,
+			ServiceName: service.Name,
+			Policies:    []string{policy.ID, by.ID},
+			Message:     fmt.Sprintf("%s never takes effect, %s %s", policyRef(policy), policyRef(by), reason),
+		})
+	}
+
+	var grants, denies []*pms.Policy
+	for _, policy := range service.Policies {
+		if policy.Effect == pms.Deny {
+			denies = append(denies, policy)
+		} else {
+			grants = append(grants, policy)
+		}
+	}
+
+	switch service.CombiningAlgorithm {
+	case pms.PermitOverrides, pms.DenyUnlessPermit:
+		for _, deny := range denies {
+			for _, grant := range grants {
+				if covers(grant, deny) {
+					shadowed(deny, grant, "overrides it for every request it applies to")
+					break
+				}
+			}
+		}
+	case pms.FirstApplicable:
+		policies := append([]*pms.Policy{}, service.Policies...)
+		sortByPriority(policies)
+		for i, policy := range policies {
+			for _, prior := range policies[:i] {
+				if prior.Effect != policy.Effect && covers(prior, policy) {
+					shadowed(policy, prior, "applies first to every request it applies to")
+					break
+				}
+			}
+		}
+	default:
+		for _, grant := range grants {
+			for _, deny := range denies {
+				if covers(deny, grant) {
+					shadowed(grant, deny, "overrides it for every request it applies to")
+					break
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// sortByPriority sorts the policies in the order of the first-applicable combining algorithm
+func sortByPriority(policies []*pms.Policy) {
+	sort.SliceStable(policies, func(i, j int) bool {
+		if policies[i].Priority != policies[j].Priority {
+			return policies[i].Priority > policies[j].Priority
+		}
+		return policies[i].ID < policies[j].ID
+	})
+}
+
+// findRedundantPolicies finds the policies identical to or subsumed by another policy with the same effect
+func findRedundantPolicies(service *pms.Service) []*Finding {
+	var findings []*Finding
+	redundant := make(map[string]bool)
+	for i, policy := range service.Policies {
+		for _, other := range service.Policies[i+1:] {
+			if policy.Effect != other.Effect || redundant[policy.ID] || redundant[other.ID] {
+				continue
+			}
+			policyCovers, otherCovers := covers(policy, other), covers(other, policy)
+			switch {
+			case policyCovers && otherCovers:
+				redundant[other.ID] = true
+				findings = append(findings, &Finding{
+					Kind:        KindDuplicate,
+					Severity:    SeverityWarning,
+					ServiceName: service.Name,
+					Policies:    []string{other.ID, policy.ID},
+					Message:     fmt.Sprintf("%s is identical to %s", policyRef(other), policyRef(policy)),
+				})
+			case policyCovers:
+				redundant[other.ID] = true
+				findings = append(findings, &Finding{
+					Kind:        KindSubsumed,
+					Severity:    SeverityWarning,
+					ServiceName: service.Name,
+					Policies:    []string{other.ID, policy.ID},
+					Message:     fmt.Sprintf("%s is subsumed by %s", policyRef(other), policyRef(policy)),
+				})
+			case otherCovers:
+				redundant[policy.ID] = true
+				findings = append(findings, &Finding{
+					Kind:        KindSubsumed,
+					Severity:    SeverityWarning,
+					ServiceName: service.Name,
+					Policies:    []string{policy.ID, other.ID},
+					Message:     fmt.Sprintf("%s is subsumed by %s", policyRef(policy), policyRef(other)),
+				})
+			}
+		}
+	}
+	return findings
+}
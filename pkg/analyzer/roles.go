@@ -0,0 +1,189 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/subjectutils"
+)
+
+// roleOf returns the role name if the principal is a role
+func roleOf(principal string) (string, bool) {
+	decoded, err := subjectutils.DecodePrincipal(principal)
+	if err != nil || decoded.Type != ads.PRINCIPAL_TYPE_ROLE {
+		return "", false
+	}
+	return decoded.Name, true
+}
+
+// usedRoles returns the roles which are principals of the policies or role policies of the service
+func usedRoles(service *pms.Service) map[string]bool {
+	roles := make(map[string]bool)
+	for _, policy := range service.Policies {
+		for _, and := range policy.Principals {
+			for _, principal := range and {
+				if role, ok := roleOf(principal); ok {
+					roles[role] = true
+				}
+			}
+		}
+	}
+	for _, rolePolicy := range service.RolePolicies {
+		for _, principal := range rolePolicy.Principals {
+			if role, ok := roleOf(principal); ok {
+				roles[role] = true
+			}
+		}
+	}
+	return roles
+}
+
+// findUnusedRoles finds the role policies granting roles which aren't used. The roles granted by the role
+// policies of the global service could be used by any service.
+func findUnusedRoles(service *pms.Service, used map[string]bool) []*Finding {
+	var findings []*Finding
+	for _, rolePolicy := range service.RolePolicies {
+		if rolePolicy.Effect == pms.Deny {
+			continue
+		}
+		var unused []string
+		for _, role := range rolePolicy.Roles {
+			if !used[role] {
+				unused = append(unused, role)
+			}
+		}
+		if len(unused) == 0 {
+			continue
+		}
+		findings = append(findings, &Finding{
+			Kind:         KindUnusedRole,
+			Severity:     SeverityWarning,
+			ServiceName:  service.Name,
+			RolePolicies: []string{rolePolicy.ID},
+			Message:      fmt.Sprintf("%s grants roles %s which no policy or role policy uses", rolePolicyRef(rolePolicy), strings.Join(unused, ", ")),
+		})
+	}
+	return findings
+}
+
+type roleEdge struct {
+	to           string
+	rolePolicyID string
+}
+
+// findRoleCycles finds the roles granted to each other by the grant role policies of the service, a role policy
+// granting role B to role A is an edge from A to B. Every strongly connected component with a cycle is a finding.
+func findRoleCycles(service *pms.Service) []*Finding {
+	graph := make(map[string][]roleEdge)
+	for _, rolePolicy := range service.RolePolicies {
+		if rolePolicy.Effect == pms.Deny {
+			continue
+		}
+		for _, principal := range rolePolicy.Principals {
+			from, ok := roleOf(principal)
+			if !ok {
+				continue
+			}
+			for _, to := range rolePolicy.Roles {
+				graph[from] = append(graph[from], roleEdge{to: to, rolePolicyID: rolePolicy.ID})
+			}
+		}
+	}
+
+	var findings []*Finding
+	for _, component := range stronglyConnectedComponents(graph) {
+		inComponent := make(map[string]bool)
+		for _, role := range component {
+			inComponent[role] = true
+		}
+		var rolePolicies []string
+		for _, role := range component {
+			for _, edge := range graph[role] {
+				if inComponent[edge.to] && !contains(rolePolicies, edge.rolePolicyID) {
+					rolePolicies = append(rolePolicies, edge.rolePolicyID)
+				}
+			}
+		}
+		// a single role is a cycle only if it's granted to itself
+		if len(rolePolicies) == 0 {
+			continue
+		}
+		sort.Strings(rolePolicies)
+		findings = append(findings, &Finding{
+			Kind:         KindRoleCycle,
+			Severity:     SeverityError,
+			ServiceName:  service.Name,
+			RolePolicies: rolePolicies,
+			Message: fmt.Sprintf("roles %s are granted to each other in a cycle by role policies %s",
+				strings.Join(component, ", "), strings.Join(rolePolicies, ", ")),
+		})
+	}
+	return findings
+}
+
+// stronglyConnectedComponents returns the strongly connected components of the graph with Tarjan's algorithm,
+// the roles in a component and the components are sorted
+func stronglyConnectedComponents(graph map[string][]roleEdge) [][]string {
+	var nodes []string
+	for node := range graph {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	index := 0
+	indexes := make(map[string]int)
+	lowLinks := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+
+	var connect func(node string)
+	connect = func(node string) {
+		indexes[node] = index
+		lowLinks[node] = index
+		index++
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, edge := range graph[node] {
+			if _, visited := indexes[edge.to]; !visited {
+				connect(edge.to)
+				if lowLinks[edge.to] < lowLinks[node] {
+					lowLinks[node] = lowLinks[edge.to]
+				}
+			} else if onStack[edge.to] && indexes[edge.to] < lowLinks[node] {
+				lowLinks[node] = indexes[edge.to]
+			}
+		}
+
+		if lowLinks[node] == indexes[node] {
+			var component []string
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == node {
+					break
+				}
+			}
+			sort.Strings(component)
+			components = append(components, component)
+		}
+	}
+	for _, node := range nodes {
+		if _, visited := indexes[node]; !visited {
+			connect(node)
+		}
+	}
+	sort.Slice(components, func(i, j int) bool {
+		return components[i][0] < components[j][0]
+	})
+	return components
+}
@@ -25,6 +25,7 @@ type ServerConfig struct {
 	Endpoint        string `json:"endpoint,omitempty"`
 	Insecure        string `json:"insecure,omitempty"`
 	EnableAuthz     string `json:"enableAuthz,omitempty"`
+	LintPolicies    string `json:"lintPolicies,omitempty"`
 	KeyPath         string `json:"keyPath,omitempty"`
 	CertPath        string `json:"certPath,omitempty"`
 	ClientCertPath  string `json:"clientCertPath,omitempty"`
@@ -39,8 +40,22 @@ type Config struct {
 	ServerConfig          *ServerConfig             `json:"serverConfig,omitempty"`
 	LogConfig             *logging.LogConfig        `json:"logConfig,omitempty"`
 	AuditLogConfig        *logging.LogConfig        `json:"auditLogConfig,omitempty"`
+	// Tenants are served besides the default tenant, each of them has its own services and functions
+	Tenants []string `json:"tenants,omitempty"`
+	// DecisionCacheConfig enables the decision cache of the evaluator
+	DecisionCacheConfig *DecisionCacheConfig `json:"decisionCacheConfig,omitempty"`
 }
 
+// DecisionCacheConfig bounds the decision cache, which is disabled if Size isn't positive
+type DecisionCacheConfig struct {
+	// Size is the maximum number of decisions cached, the least recently used ones are evicted
+	Size int `json:"size"`
+	// TTL is the seconds a decision is cached, DefaultDecisionCacheTTL is used if it isn't positive
+	TTL int64 `json:"ttl,omitempty"`
+}
+
+const DefaultDecisionCacheTTL = 60
+
 func ReadConfig(configFileLocation string) (*Config, error) {
 	var config Config
 	raw, err := ioutil.ReadFile(configFileLocation)
@@ -16,6 +16,7 @@ import (
 	"github.com/teramoby/speedle-plus/pkg/cfg"
 	"github.com/teramoby/speedle-plus/pkg/errors"
 	"github.com/teramoby/speedle-plus/pkg/logging"
+	"github.com/teramoby/speedle-plus/pkg/store"
 
 	"strconv"
 
@@ -35,13 +36,18 @@ type Parameters struct {
 	Endpoint        StrParamDetail
 	Insecure        StrParamDetail
 	EnableAuthz     StrParamDetail
+	LintPolicies    StrParamDetail
 	KeyPath         StrParamDetail
 	CertPath        StrParamDetail
 	ClientCertPath  StrParamDetail
 	ForceClientCert StrParamDetail
+	Tenants         StrParamDetail
 	/////////Store config////////////////
 	StoreType         StrParamDetail
 	StoreWatchEnabled StrParamDetail
+	/////////Evaluator config////////////
+	DecisionCacheSize StrParamDetail
+	DecisionCacheTTL  StrParamDetail
 
 	////////Log config/////////////////////
 	LogConf      LogParameters // normal log configuration
@@ -84,6 +90,9 @@ const (
 	DefaultInsecure                       = true
 	DefaultEnableAuthz                    = false
 
+	// DefaultAuthzCheckConnectEndpoint is the default value of authorization decision service endpoint for command line tool spctl.
+	DefaultAuthzCheckConnectEndpoint = "http://127.0.0.1:6734/authz-check/v1/"
+
 	DefaultStoreType = cfg.StorageTypeFile //file
 
 	DefaultStoreWatchEnabled = true
@@ -183,6 +192,8 @@ func (k *Parameters) ParseFlags(defaultEndpoint string, printVersionInfoFun func
 	params = append(params, &k.Insecure)
 	k.EnableAuthz = StrParamDetail{Name: "enable-authz", DefaultValue: strconv.FormatBool(DefaultEnableAuthz), Usage: "Server config: Enable authorization check."}
 	params = append(params, &k.EnableAuthz)
+	k.LintPolicies = StrParamDetail{Name: "lint-policies", DefaultValue: strconv.FormatBool(false), Usage: "Server config: Reject the services, policies and role policies with errors found by the policy analyzer."}
+	params = append(params, &k.LintPolicies)
 	k.CertPath = StrParamDetail{Name: "cert", Usage: "Server config: Server certifice file path."}
 	params = append(params, &k.CertPath)
 	k.KeyPath = StrParamDetail{Name: "key", Usage: "Server config: Server key file path."}
@@ -191,11 +202,17 @@ func (k *Parameters) ParseFlags(defaultEndpoint string, printVersionInfoFun func
 	params = append(params, &k.ClientCertPath)
 	k.ForceClientCert = StrParamDetail{Name: "force-client-cert", ShortName: "f", Usage: "Server config: Force Client certification."}
 	params = append(params, &k.ForceClientCert)
+	k.Tenants = StrParamDetail{Name: "tenants", Usage: "Server config: Comma separated tenants served besides the default tenant."}
+	params = append(params, &k.Tenants)
 
 	k.StoreType = StrParamDetail{Name: "store-type", DefaultValue: DefaultStoreType, Usage: "Store config: Policy store type, etcd or file."}
 	params = append(params, &k.StoreType)
 	k.StoreWatchEnabled = StrParamDetail{Name: "enable-watch", DefaultValue: strconv.FormatBool(DefaultStoreWatchEnabled), Usage: "Evaluator config: Whether enable watch store changes."}
 	params = append(params, &k.StoreWatchEnabled)
+	k.DecisionCacheSize = StrParamDetail{Name: "decision-cache-size", Usage: "Evaluator config: Maximum number of cached decisions, the decision cache is disabled if it isn't positive."}
+	params = append(params, &k.DecisionCacheSize)
+	k.DecisionCacheTTL = StrParamDetail{Name: "decision-cache-ttl", Usage: "Evaluator config: Seconds a decision is cached, default is " + strconv.Itoa(cfg.DefaultDecisionCacheTTL) + "."}
+	params = append(params, &k.DecisionCacheTTL)
 
 	// Log configurations
 	k.LogConf.LogLevel = StrParamDetail{Name: "log-level", Usage: "Log config: log level, available levels are panic, fatal, error, warn, info and debug."}
@@ -302,6 +319,10 @@ func (k *Parameters) ParseFlags(defaultEndpoint string, printVersionInfoFun func
 					if conf != nil && conf.ServerConfig != nil && len(conf.ServerConfig.EnableAuthz) != 0 {
 						f.Value.Set(conf.ServerConfig.EnableAuthz)
 					}
+				case k.LintPolicies.Name:
+					if conf != nil && conf.ServerConfig != nil && len(conf.ServerConfig.LintPolicies) != 0 {
+						f.Value.Set(conf.ServerConfig.LintPolicies)
+					}
 				case k.KeyPath.Name:
 					if conf != nil && conf.ServerConfig != nil && len(conf.ServerConfig.KeyPath) != 0 {
 						f.Value.Set(conf.ServerConfig.KeyPath)
@@ -318,6 +339,10 @@ func (k *Parameters) ParseFlags(defaultEndpoint string, printVersionInfoFun func
 					if conf != nil && conf.ServerConfig != nil && len(conf.ServerConfig.ClientCertPath) != 0 {
 						f.Value.Set(conf.ServerConfig.ClientCertPath)
 					}
+				case k.Tenants.Name:
+					if conf != nil && len(conf.Tenants) != 0 {
+						f.Value.Set(strings.Join(conf.Tenants, ","))
+					}
 				case k.StoreType.Name:
 					if conf != nil && conf.StoreConfig != nil && len(conf.StoreConfig.StoreType) != 0 {
 						f.Value.Set(conf.StoreConfig.StoreType)
@@ -326,6 +351,14 @@ func (k *Parameters) ParseFlags(defaultEndpoint string, printVersionInfoFun func
 					if conf != nil {
 						f.Value.Set(strconv.FormatBool(conf.EnableWatch))
 					}
+				case k.DecisionCacheSize.Name:
+					if conf != nil && conf.DecisionCacheConfig != nil {
+						f.Value.Set(strconv.Itoa(conf.DecisionCacheConfig.Size))
+					}
+				case k.DecisionCacheTTL.Name:
+					if conf != nil && conf.DecisionCacheConfig != nil && conf.DecisionCacheConfig.TTL != 0 {
+						f.Value.Set(strconv.FormatInt(conf.DecisionCacheConfig.TTL, 10))
+					}
 				// Log configurations
 				case k.LogConf.LogLevel.Name:
 					if conf != nil && conf.LogConfig != nil {
@@ -470,6 +503,33 @@ func (k *Parameters) ValidateFlags() {
 		}
 	}
 
+	if len(k.LintPolicies.Value) != 0 {
+		if _, err := strconv.ParseBool(k.LintPolicies.Value); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid value for 'lint-policies' parameter: %s", k.LintPolicies.Value)
+			k.usage()
+		}
+	}
+
+	for _, tenant := range k.tenants() {
+		if err := store.ValidateTenant(tenant); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid value for 'tenants' parameter: %v", err)
+			k.usage()
+		}
+	}
+
+	if len(k.DecisionCacheSize.Value) != 0 {
+		if _, err := strconv.Atoi(k.DecisionCacheSize.Value); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid value for 'decision-cache-size' parameter: %s", k.DecisionCacheSize.Value)
+			k.usage()
+		}
+	}
+	if len(k.DecisionCacheTTL.Value) != 0 {
+		if _, err := strconv.ParseInt(k.DecisionCacheTTL.Value, 10, 64); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid value for 'decision-cache-ttl' parameter: %s", k.DecisionCacheTTL.Value)
+			k.usage()
+		}
+	}
+
 	if !insecure {
 		if k.CertPath.Value == "" || k.KeyPath.Value == "" {
 			fmt.Fprintln(os.Stderr, "In secure mode, "+k.KeyPath.Name+", "+k.CertPath.Name+" should be passed.")
@@ -490,6 +550,17 @@ func (k *Parameters) ValidateFlags() {
 	}
 }
 
+// tenants returns the tenants in the comma separated tenants parameter
+func (k *Parameters) tenants() []string {
+	var tenants []string
+	for _, tenant := range strings.Split(k.Tenants.Value, ",") {
+		if tenant = strings.TrimSpace(tenant); len(tenant) != 0 {
+			tenants = append(tenants, tenant)
+		}
+	}
+	return tenants
+}
+
 func (k *Parameters) Param2Config(storeParamsMap map[string]string) (*cfg.Config, error) {
 
 	conf := cfg.Config{}
@@ -510,6 +581,13 @@ func (k *Parameters) Param2Config(storeParamsMap map[string]string) (*cfg.Config
 	watchEnabled, _ := strconv.ParseBool(k.StoreWatchEnabled.Value)
 	conf.EnableWatch = watchEnabled
 
+	conf.Tenants = k.tenants()
+
+	if size, _ := strconv.Atoi(k.DecisionCacheSize.Value); size > 0 {
+		ttl, _ := strconv.ParseInt(k.DecisionCacheTTL.Value, 10, 64)
+		conf.DecisionCacheConfig = &cfg.DecisionCacheConfig{Size: size, TTL: ttl}
+	}
+
 	// Log Configuration
 	if len(k.LogConf.LogLevel.Value) != 0 ||
 		len(k.LogConf.LogFormatter.Value) != 0 ||
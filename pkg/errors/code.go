@@ -11,10 +11,12 @@ const UnknownError ErrorCode = "SPDL-0000"
 
 // For common components
 const (
-	ConfigError    ErrorCode = "SPDL-0001"
-	ServerError    ErrorCode = "SPDL-0002"
-	LoggingError   ErrorCode = "SPDL-0003"
-	InvalidRequest ErrorCode = "SPDL-0004"
+	ConfigError      ErrorCode = "SPDL-0001"
+	ServerError      ErrorCode = "SPDL-0002"
+	LoggingError     ErrorCode = "SPDL-0003"
+	InvalidRequest   ErrorCode = "SPDL-0004"
+	Unauthenticated  ErrorCode = "SPDL-0005"
+	PermissionDenied ErrorCode = "SPDL-0006"
 )
 
 // For policy management errors
@@ -24,6 +26,7 @@ const (
 	EntityAlreadyExists ErrorCode = "SPDL-1003"
 	ExceedLimit         ErrorCode = "SPDL-1004"
 	SerializationError  ErrorCode = "SPDL-1005"
+	RevisionConflict    ErrorCode = "SPDL-1006"
 )
 
 // For evaluator errors
@@ -8,7 +8,9 @@ import (
 	"strings"
 
 	radix "github.com/armon/go-radix"
+	log "github.com/sirupsen/logrus"
 	"github.com/teramoby/speedle-plus/3rdparty/github.com/Knetic/govaluate"
+	"github.com/teramoby/speedle-plus/pkg/glob"
 )
 
 // Patterns used to match resource expression.
@@ -18,38 +20,41 @@ var /*const*/ Suffix_Pattern = regexp.MustCompile(`^\^?\.\*[\w/]+\$?$`)
 var /*const*/ All_Pattern = regexp.MustCompile(`^\^?\.\*\$?$`)
 
 type ResourceToPolicyMap struct {
-	//{resource:{policyID: bool}}
-	ResourceToPolicies           map[string]map[string]bool
-	PrefixResourceExpressionTree *radix.Tree
+	//ResourceTree indexes the literal resources and the prefixes of the hierarchical resource expressions, e.g.
+	//"/books/.*", so the policies of a resource and of its ancestors are found in one walk of the tree.
+	//The values are *resourceEntry.
+	ResourceTree                 *radix.Tree
 	SuffixResourceExpressionTree *radix.Tree
-	//{resourceExpression:{policyID: bool}}
-	//This map contains the resource expressions not match prefix, suffix and all patterns.
-	//That mean the incoming resource in isAllowed need match these resource expressions one by one.
-	ResourceExpressionToPolicies map[string]map[string]bool
+	//This set contains the resource expressions not match prefix, suffix and all patterns.
+	//They are matched by one automaton combining all of them.
+	ResourceExpressions *expressionSet
 	//resources/resExpressions could be empty, which means any resource
 	//Also use this map to store the ".*" resourceexpression policy. which also
 	//means any resource.
 	NilResourceToPolicies map[string]bool
 }
 
+// resourceEntry is a node of ResourceTree
+type resourceEntry struct {
+	//{policyID: bool} of the policies of the resource
+	resourcePolicies map[string]bool
+	//{policyID: bool} of the policies of the resource expressions prefixed by the resource
+	prefixPolicies map[string]bool
+}
+
 func (p *ResourceToPolicyMap) isEmpty() bool {
 	if p.NilResourceToPolicies != nil &&
 		len(p.NilResourceToPolicies) > 0 {
 		return false
 	}
 
-	if p.ResourceExpressionToPolicies != nil &&
-		len(p.ResourceExpressionToPolicies) > 0 {
-		return false
-	}
-
-	if p.ResourceToPolicies != nil &&
-		len(p.ResourceToPolicies) > 0 {
+	if p.ResourceExpressions != nil &&
+		p.ResourceExpressions.len() > 0 {
 		return false
 	}
 
-	if p.PrefixResourceExpressionTree != nil &&
-		p.PrefixResourceExpressionTree.Len() > 0 {
+	if p.ResourceTree != nil &&
+		p.ResourceTree.Len() > 0 {
 		return false
 	}
 
@@ -61,6 +66,127 @@ func (p *ResourceToPolicyMap) isEmpty() bool {
 	return true
 }
 
+func (p *ResourceToPolicyMap) addNilResource(policyID string) {
+	if p.NilResourceToPolicies == nil {
+		p.NilResourceToPolicies = make(map[string]bool)
+	}
+	p.NilResourceToPolicies[policyID] = true
+}
+
+func (p *ResourceToPolicyMap) deleteNilResource(policyID string) {
+	if p.NilResourceToPolicies != nil {
+		delete(p.NilResourceToPolicies, policyID)
+	}
+}
+
+// resourceEntry gets the entry of a resource or a prefix in ResourceTree, which is created if create is true
+func (p *ResourceToPolicyMap) resourceEntry(resource string, create bool) *resourceEntry {
+	if p.ResourceTree == nil {
+		if !create {
+			return nil
+		}
+		p.ResourceTree = radix.New()
+	}
+	if value, exist := p.ResourceTree.Get(resource); exist {
+		return value.(*resourceEntry)
+	}
+	if !create {
+		return nil
+	}
+	entry := &resourceEntry{}
+	p.ResourceTree.Insert(resource, entry)
+	return entry
+}
+
+func (p *ResourceToPolicyMap) addResource(resource string, policyID string) {
+	entry := p.resourceEntry(resource, true)
+	if entry.resourcePolicies == nil {
+		entry.resourcePolicies = make(map[string]bool)
+	}
+	entry.resourcePolicies[policyID] = true
+}
+
+func (p *ResourceToPolicyMap) deleteResource(resource string, policyID string) {
+	if entry := p.resourceEntry(resource, false); entry != nil {
+		delete(entry.resourcePolicies, policyID)
+		p.deleteEmptyEntry(resource, entry)
+	}
+}
+
+func (p *ResourceToPolicyMap) deleteEmptyEntry(resource string, entry *resourceEntry) {
+	if len(entry.resourcePolicies) == 0 && len(entry.prefixPolicies) == 0 {
+		p.ResourceTree.Delete(resource)
+	}
+}
+
+// matchPolicies calls fn with the IDs of the policies applicable to the resource
+func (p *ResourceToPolicyMap) matchPolicies(resource string, fn func(policyID string)) {
+	for id := range p.NilResourceToPolicies {
+		fn(id)
+	}
+
+	if p.ResourceTree != nil {
+		p.ResourceTree.WalkPath(resource, func(s string, v interface{}) bool {
+			entry := v.(*resourceEntry)
+			for id := range entry.prefixPolicies {
+				fn(id)
+			}
+			if s == resource {
+				for id := range entry.resourcePolicies {
+					fn(id)
+				}
+			}
+			return false
+		})
+	}
+
+	if p.SuffixResourceExpressionTree != nil {
+		p.SuffixResourceExpressionTree.WalkPath(ReverseString(resource), func(s string, v interface{}) bool {
+			for id := range v.(map[string]bool) {
+				fn(id)
+			}
+			return false
+		})
+	}
+
+	if p.ResourceExpressions != nil {
+		p.ResourceExpressions.match(resource, fn)
+	}
+}
+
+// allPolicies calls fn with the IDs of all the policies
+func (p *ResourceToPolicyMap) allPolicies(fn func(policyID string)) {
+	for id := range p.NilResourceToPolicies {
+		fn(id)
+	}
+
+	if p.ResourceTree != nil {
+		p.ResourceTree.Walk(func(s string, v interface{}) bool {
+			entry := v.(*resourceEntry)
+			for id := range entry.resourcePolicies {
+				fn(id)
+			}
+			for id := range entry.prefixPolicies {
+				fn(id)
+			}
+			return false
+		})
+	}
+
+	if p.SuffixResourceExpressionTree != nil {
+		p.SuffixResourceExpressionTree.Walk(func(s string, v interface{}) bool {
+			for id := range v.(map[string]bool) {
+				fn(id)
+			}
+			return false
+		})
+	}
+
+	if p.ResourceExpressions != nil {
+		p.ResourceExpressions.all(fn)
+	}
+}
+
 type BasePolicyCacheData struct {
 	/*
 		In current cache, we don't distinguish andPrincipals and orPrincipals.
@@ -72,6 +198,16 @@ type BasePolicyCacheData struct {
 	//No principal defined in policy, mean match any principal
 	NilPrincipalToPolicies *ResourceToPolicyMap
 	Conditions             map[string]*govaluate.EvaluableExpression
+	//{resourceExpression:compiledExpression}, the resource expressions are compiled once when the policies are added
+	Expressions map[string]*compiledExpression
+	//{resourceGlob:compiledExpression}
+	Globs map[string]*compiledExpression
+}
+
+// compiledExpression is a compiled resource expression, and the number of its occurrences in the policies
+type compiledExpression struct {
+	re   *regexp.Regexp
+	refs int
 }
 
 func (p *BasePolicyCacheData) isEmpty() bool {
@@ -92,6 +228,68 @@ func (p *BasePolicyCacheData) clearConditions() {
 	p.Conditions = make(map[string]*govaluate.EvaluableExpression)
 }
 
+func (p *BasePolicyCacheData) addExpression(expression string) {
+	if p.Expressions == nil {
+		p.Expressions = make(map[string]*compiledExpression)
+	}
+	addCompiled(p.Expressions, expression, regexp.Compile)
+}
+
+func (p *BasePolicyCacheData) deleteExpression(expression string) {
+	deleteCompiled(p.Expressions, expression)
+}
+
+func (p *BasePolicyCacheData) addGlob(pattern string) {
+	if p.Globs == nil {
+		p.Globs = make(map[string]*compiledExpression)
+	}
+	addCompiled(p.Globs, pattern, glob.Compile)
+}
+
+func (p *BasePolicyCacheData) deleteGlob(pattern string) {
+	deleteCompiled(p.Globs, pattern)
+}
+
+func addCompiled(cache map[string]*compiledExpression, expression string, compile func(string) (*regexp.Regexp, error)) {
+	if compiled, exist := cache[expression]; exist {
+		compiled.refs++
+		return
+	}
+	re, err := compile(expression)
+	if err != nil {
+		log.Errorf("Meet error when compile the resource expression %q. err: %s", expression, err)
+	}
+	cache[expression] = &compiledExpression{re: re, refs: 1}
+}
+
+func deleteCompiled(cache map[string]*compiledExpression, expression string) {
+	if compiled, exist := cache[expression]; exist {
+		compiled.refs--
+		if compiled.refs <= 0 {
+			delete(cache, expression)
+		}
+	}
+}
+
+// matchExpression checks if the resource matches the resource expression, which is compiled when the policies are
+// added. An invalid expression matches nothing.
+func (p *BasePolicyCacheData) matchExpression(expression, resource string) bool {
+	if compiled, exist := p.Expressions[expression]; exist {
+		return compiled.re != nil && compiled.re.MatchString(resource)
+	}
+	matched, err := regexp.MatchString(expression, resource)
+	return err == nil && matched
+}
+
+// matchGlob checks if the resource matches the resource glob, which is compiled when the policies are added.
+// An invalid glob matches nothing.
+func (p *BasePolicyCacheData) matchGlob(pattern, resource string) bool {
+	if compiled, exist := p.Globs[pattern]; exist {
+		return compiled.re != nil && compiled.re.MatchString(resource)
+	}
+	return glob.Match(pattern, resource)
+}
+
 func ReverseString(s string) string {
 	bytes := []byte(s)
 	for i, j := 0, len(bytes)-1; i < j; i, j = i+1, j-1 {
@@ -102,64 +300,52 @@ func ReverseString(s string) string {
 
 func AddPolicyToResourceExpressionCache(resourceToPolicyMap *ResourceToPolicyMap, resourceExpression string, policyID string) {
 	if Prefix_Pattern.MatchString(resourceExpression) {
-
-		resourceExpression := trimResourceExpressionSuffix(resourceExpression)
-
-		if resourceToPolicyMap.PrefixResourceExpressionTree == nil {
-			resourceToPolicyMap.PrefixResourceExpressionTree = radix.New()
-			policyIDSet := make(map[string]bool)
-			policyIDSet[policyID] = true
-			resourceToPolicyMap.PrefixResourceExpressionTree.Insert(resourceExpression, policyIDSet)
-		} else {
-			if value, exist := resourceToPolicyMap.PrefixResourceExpressionTree.Get(resourceExpression); exist {
-				policyIDSet := value.(map[string]bool)
-				policyIDSet[policyID] = true
-			} else {
-				policyIDSet := make(map[string]bool)
-				policyIDSet[policyID] = true
-				resourceToPolicyMap.PrefixResourceExpressionTree.Insert(resourceExpression, policyIDSet)
-			}
+		entry := resourceToPolicyMap.resourceEntry(trimResourceExpressionSuffix(resourceExpression), true)
+		if entry.prefixPolicies == nil {
+			entry.prefixPolicies = make(map[string]bool)
 		}
+		entry.prefixPolicies[policyID] = true
 	} else if Suffix_Pattern.MatchString(resourceExpression) {
 		resourceExpression := ReverseString(trimResourceExpressionPrefix(resourceExpression))
 
 		if resourceToPolicyMap.SuffixResourceExpressionTree == nil {
 			resourceToPolicyMap.SuffixResourceExpressionTree = radix.New()
+		}
+		if value, exist := resourceToPolicyMap.SuffixResourceExpressionTree.Get(resourceExpression); exist {
+			policyIDSet := value.(map[string]bool)
+			policyIDSet[policyID] = true
+		} else {
 			policyIDSet := make(map[string]bool)
 			policyIDSet[policyID] = true
 			resourceToPolicyMap.SuffixResourceExpressionTree.Insert(resourceExpression, policyIDSet)
-		} else {
-			if value, exist := resourceToPolicyMap.SuffixResourceExpressionTree.Get(resourceExpression); exist {
-				policyIDSet := value.(map[string]bool)
-				policyIDSet[policyID] = true
-			} else {
-				policyIDSet := make(map[string]bool)
-				policyIDSet[policyID] = true
-				resourceToPolicyMap.SuffixResourceExpressionTree.Insert(resourceExpression, policyIDSet)
-			}
 		}
 	} else if All_Pattern.MatchString(resourceExpression) {
-		if resourceToPolicyMap.NilResourceToPolicies == nil {
-			resourceToPolicyMap.NilResourceToPolicies = make(map[string]bool)
-		}
-		resourceToPolicyMap.NilResourceToPolicies[policyID] = true
+		resourceToPolicyMap.addNilResource(policyID)
 	} else {
 		//No perfix and no suffix and no all pattern matched
-		if resourceToPolicyMap.ResourceExpressionToPolicies == nil {
-			resourceToPolicyMap.ResourceExpressionToPolicies = make(map[string]map[string]bool)
-			policyIDSet := make(map[string]bool)
-			policyIDSet[policyID] = true
-			resourceToPolicyMap.ResourceExpressionToPolicies[resourceExpression] = policyIDSet
-		} else {
-			if policyIDSet, exist := resourceToPolicyMap.ResourceExpressionToPolicies[resourceExpression]; exist {
-				policyIDSet[policyID] = true
-			} else {
-				policyIDSet := make(map[string]bool)
-				policyIDSet[policyID] = true
-				resourceToPolicyMap.ResourceExpressionToPolicies[resourceExpression] = policyIDSet
-			}
+		if resourceToPolicyMap.ResourceExpressions == nil {
+			resourceToPolicyMap.ResourceExpressions = newExpressionSet()
 		}
+		resourceToPolicyMap.ResourceExpressions.add(resourceExpression, policyID)
+	}
+}
+
+// AddPolicyToResourceGlobCache indexes the policy by the resource glob, which is converted to a resource expression
+func AddPolicyToResourceGlobCache(resourceToPolicyMap *ResourceToPolicyMap, resourceGlob string, policyID string) {
+	resourceExpression, err := glob.Regexp(resourceGlob)
+	if err != nil {
+		log.Errorf("Meet error when convert the resource glob %q. err: %s", resourceGlob, err)
+		return
+	}
+	AddPolicyToResourceExpressionCache(resourceToPolicyMap, resourceExpression, policyID)
+}
+
+func DeletePolicyFromResourceGlobCache(resourceToPolicyMap *ResourceToPolicyMap, resourceGlob string, policyID string) {
+	resourceExpression, err := glob.Regexp(resourceGlob)
+	if err != nil {
+		return
 	}
+	DeletePolicyFromResourceExpressionCache(resourceToPolicyMap, resourceExpression, policyID)
 }
 
 func trimResourceExpressionSuffix(resourceExpression string) string {
@@ -186,17 +372,10 @@ func trimResourceExpressionPrefix(resourceExpression string) string {
 
 func DeletePolicyFromResourceExpressionCache(resourceToPolicyMap *ResourceToPolicyMap, resourceExpression string, policyID string) {
 	if Prefix_Pattern.MatchString(resourceExpression) {
-		if resourceToPolicyMap.PrefixResourceExpressionTree == nil {
-			return
-		}
-
 		resourceExpression := trimResourceExpressionSuffix(resourceExpression)
-		if value, exist := resourceToPolicyMap.PrefixResourceExpressionTree.Get(resourceExpression); exist {
-			policyIDSet := value.(map[string]bool)
-			delete(policyIDSet, policyID)
-			if len(policyIDSet) == 0 {
-				resourceToPolicyMap.PrefixResourceExpressionTree.Delete(resourceExpression)
-			}
+		if entry := resourceToPolicyMap.resourceEntry(resourceExpression, false); entry != nil {
+			delete(entry.prefixPolicies, policyID)
+			resourceToPolicyMap.deleteEmptyEntry(resourceExpression, entry)
 		}
 	} else if Suffix_Pattern.MatchString(resourceExpression) {
 		if resourceToPolicyMap.SuffixResourceExpressionTree == nil {
@@ -212,20 +391,11 @@ func DeletePolicyFromResourceExpressionCache(resourceToPolicyMap *ResourceToPoli
 			}
 		}
 	} else if All_Pattern.MatchString(resourceExpression) {
-		if resourceToPolicyMap.NilResourceToPolicies != nil {
-			delete(resourceToPolicyMap.NilResourceToPolicies, policyID)
-		}
+		resourceToPolicyMap.deleteNilResource(policyID)
 	} else {
 		//No perfix and no suffix and no all pattern matched
-		if resourceToPolicyMap.ResourceExpressionToPolicies == nil {
-			return
-		}
-
-		if policyIDSet, exist := resourceToPolicyMap.ResourceExpressionToPolicies[resourceExpression]; exist {
-			delete(policyIDSet, policyID)
-			if len(policyIDSet) == 0 {
-				delete(resourceToPolicyMap.ResourceExpressionToPolicies, resourceExpression)
-			}
+		if resourceToPolicyMap.ResourceExpressions != nil {
+			resourceToPolicyMap.ResourceExpressions.delete(resourceExpression, policyID)
 		}
 	}
 }
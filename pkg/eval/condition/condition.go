@@ -0,0 +1,183 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+// Package condition checks the conditions of policies and role policies before they are stored, so that a condition
+// with a typo is rejected instead of never matching at runtime. It doesn't depend on the policy evaluator, which
+// compiles the conditions again with the custom functions it could call.
+package condition
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/teramoby/speedle-plus/3rdparty/github.com/Knetic/govaluate"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/eval/function"
+)
+
+// errors of govaluate, which are rewritten with the positions of the problems
+const (
+	undefinedFunctionError = "Undefined function "
+	transitionError        = "Cannot transition token types from "
+	closingTransitionError = " to CLAUSE_CLOSE ["
+	unexpectedEndError     = "Unexpected end of expression"
+)
+
+// Error is a problem of a condition, Column is the position of the problem counted in characters from 1, it is 0
+// if the position is unknown
+type Error struct {
+	Column  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Column <= 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%s at column %d", e.Message, e.Column)
+}
+
+// stubFunction stands for the custom functions, which are never called when conditions are checked
+func stubFunction(args ...interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+// Check compiles a condition with the built-in functions and the custom functions of the names. The attributes the
+// condition references are type checked if the schema isn't empty, and the ones not in the schema or built in are
+// rejected. The error is an *Error if the condition is invalid.
+func Check(condition string, functions []string, schema []*pms.Attribute) error {
+	if len(condition) == 0 {
+		return nil
+	}
+	funcs := make(map[string]govaluate.ExpressionFunction, len(function.Builtins)+len(functions))
+	for name, builtin := range function.Builtins {
+		funcs[name] = builtin
+	}
+	for _, name := range functions {
+		funcs[name] = stubFunction
+	}
+
+	runes := []rune(condition)
+	spans := scan(runes)
+	expression, err := govaluate.NewEvaluableExpressionWithFunctions(condition, funcs)
+	if err != nil {
+		return locate(runes, spans, funcs, err)
+	}
+	tokens := expression.Tokens()
+	if len(tokens) != len(spans) {
+		// the positions are unknown if the condition isn't split in the same way
+		spans = nil
+	}
+	if err := checkOperands(tokens, spans); err != nil {
+		return err
+	}
+	if len(schema) == 0 {
+		return nil
+	}
+	return checkTypes(runes, tokens, spans, schema)
+}
+
+// checkOperands rejects the operators and parenthesis followed by a closing parenthesis, e.g. "(age >)", which are
+// accepted by govaluate but fail at runtime
+func checkOperands(tokens []govaluate.ExpressionToken, spans []span) error {
+	for i := 1; i < len(tokens); i++ {
+		if tokens[i].Kind != govaluate.CLAUSE_CLOSE {
+			continue
+		}
+		switch tokens[i-1].Kind {
+		case govaluate.CLAUSE:
+			if i > 1 && tokens[i-2].Kind == govaluate.FUNCTION {
+				continue
+			}
+		case govaluate.PREFIX, govaluate.MODIFIER, govaluate.COMPARATOR, govaluate.LOGICALOP:
+		default:
+			continue
+		}
+		if spans == nil {
+			return &Error{Message: "unexpected )"}
+		}
+		return &Error{Column: spans[i].start + 1, Message: "unexpected )"}
+	}
+	return nil
+}
+
+// CheckSchema checks the attribute declarations of a service
+func CheckSchema(schema []*pms.Attribute) error {
+	names := make(map[string]bool, len(schema))
+	for _, attribute := range schema {
+		if attribute == nil || len(attribute.Name) == 0 {
+			return fmt.Errorf("attribute name is empty")
+		}
+		if names[attribute.Name] {
+			return fmt.Errorf("attribute %s is declared more than once", attribute.Name)
+		}
+		names[attribute.Name] = true
+		if !isAttributeType(attribute.Type) {
+			return fmt.Errorf("unknown type %q of attribute %s, it should be one of %v", attribute.Type, attribute.Name, pms.AttributeTypes)
+		}
+	}
+	return nil
+}
+
+func isAttributeType(attributeType string) bool {
+	for _, supported := range pms.AttributeTypes {
+		if attributeType == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// locate finds the position of the error of compiling a condition, by compiling the prefixes of the condition ending
+// at each token. The parenthesis open in a prefix are closed, so the first prefix failing for a reason other than
+// ending early has the problem at its last token.
+func locate(runes []rune, spans []span, functions map[string]govaluate.ExpressionFunction, compileErr error) error {
+	var open []int
+	for k, sp := range spans {
+		text := string(runes[sp.start:sp.end])
+		switch text {
+		case "(":
+			open = append(open, sp.start)
+		case ")":
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			} else {
+				return &Error{Column: sp.start + 1, Message: "unbalanced parenthesis"}
+			}
+		}
+
+		prefix := string(runes[:sp.end]) + strings.Repeat(")", len(open))
+		_, err := govaluate.NewEvaluableExpressionWithFunctions(prefix, functions)
+		if err == nil {
+			continue
+		}
+		message := strings.TrimSpace(err.Error())
+		switch {
+		case message == unexpectedEndError:
+			continue
+		case len(open) > 0 && text != ")" && strings.HasPrefix(message, transitionError) &&
+			strings.Contains(message, closingTransitionError):
+			// the prefix can't be followed by the closing parenthesis added to it
+			continue
+		case strings.HasPrefix(message, undefinedFunctionError) && k > 0:
+			return &Error{Column: spans[k-1].start + 1, Message: "undefined function " + strings.TrimPrefix(message, undefinedFunctionError)}
+		case strings.HasPrefix(message, transitionError):
+			return &Error{Column: sp.start + 1, Message: fmt.Sprintf("unexpected %s", text)}
+		default:
+			return &Error{Column: sp.start + 1, Message: message}
+		}
+	}
+
+	// the condition is complete if closing the open parenthesis makes it valid
+	if len(open) > 0 {
+		closed := string(runes) + strings.Repeat(")", len(open))
+		if expression, err := govaluate.NewEvaluableExpressionWithFunctions(closed, functions); err == nil &&
+			checkOperands(expression.Tokens(), nil) == nil {
+			return &Error{Column: open[len(open)-1] + 1, Message: "unbalanced parenthesis"}
+		}
+	}
+	if strings.TrimSpace(compileErr.Error()) == unexpectedEndError || len(open) > 0 {
+		return &Error{Column: len(runes) + 1, Message: "unexpected end of condition"}
+	}
+	return &Error{Message: strings.TrimSpace(compileErr.Error())}
+}
@@ -0,0 +1,90 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package condition
+
+import (
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+)
+
+func TestCheckSyntax(t *testing.T) {
+	tests := []struct {
+		condition string
+		err       string
+	}{
+		{"", ""},
+		{"age > 18 && Max(age, 20) < 30 && IsWeekday(request_time)", ""},
+		{"Foo(1) && age > 1", "undefined function Foo at column 1"},
+		{"(age > 1 && Bar(age))", "undefined function Bar at column 13"},
+		{"age >", "unexpected end of condition at column 6"},
+		{"(age > 1 &&", "unexpected end of condition at column 12"},
+		{"age 1", "unexpected 1 at column 5"},
+		{"(age > 1 && dept dept) || vip", "unexpected dept at column 18"},
+		{"age > 1)", "unbalanced parenthesis at column 8"},
+		{"((age > 1) && (age < 3)", "unbalanced parenthesis at column 1"},
+		{"(age >) && age > 1", "unexpected ) at column 7"},
+		{"name == 'abc", "Unclosed string literal at column 9"},
+		{"名字 == 'x' && 1 2", "unexpected 2 at column 16"},
+	}
+	for _, test := range tests {
+		err := Check(test.condition, []string{"IsWeekday"}, nil)
+		if (err == nil && len(test.err) > 0) || (err != nil && err.Error() != test.err) {
+			t.Errorf("condition %q: expected error %q, but got %v", test.condition, test.err, err)
+		}
+	}
+}
+
+func TestCheckTypes(t *testing.T) {
+	schema := []*pms.Attribute{
+		{Name: "age", Type: pms.AttributeTypeNumeric},
+		{Name: "dept", Type: pms.AttributeTypeString},
+		{Name: "tags", Type: pms.AttributeTypeString, List: true},
+		{Name: "vip", Type: pms.AttributeTypeBool},
+		{Name: "since", Type: pms.AttributeTypeDatetime},
+	}
+	tests := []struct {
+		condition string
+		err       string
+	}{
+		{"age > 18 && dept == 'sales' && vip", ""},
+		{"'a' IN tags && dept IN ('a', 'b') && request_user IN request_groups", ""},
+		{"since > '2018-01-01' && since < request_time && request_hour < 8", ""},
+		{"Max(age, 1) > 'x' && dept + 1 == 'x1' && age == (1)", ""},
+		{"agee > 1", "undefined attribute agee at column 1"},
+		{"age > 'x'", "> can't be applied to age (numeric) and 'x' (string) at column 5"},
+		{"dept >= 18", ">= can't be applied to dept (string) and 18 (numeric) at column 6"},
+		{"tags == 'a'", "== can't be applied to tags (list of string) and 'a' (string) at column 6"},
+		{"age IN ('a', 'b')", "in can't be applied to age (numeric) and ('a', 'b') (list of string) at column 5"},
+		{"dept in dept", "in can't be applied to dept (string) and dept (string) at column 6"},
+		{"vip && age", "&& can't be applied to age (numeric) at column 5"},
+		{"!age", "! can't be applied to age (numeric) at column 1"},
+		{"since > 'yesterday'", "> can't be applied to since (datetime) and 'yesterday' (string) at column 7"},
+		{"age =~ 'a.*'", "=~ can't be applied to age (numeric) and 'a.*' (string) at column 5"},
+		{"dept * 2 > 1", "* can't be applied to dept (string) and 2 (numeric) at column 6"},
+	}
+	for _, test := range tests {
+		err := Check(test.condition, nil, schema)
+		if (err == nil && len(test.err) > 0) || (err != nil && err.Error() != test.err) {
+			t.Errorf("condition %q: expected error %q, but got %v", test.condition, test.err, err)
+		}
+	}
+
+	// the attributes aren't checked without schema
+	if err := Check("agee > 'x'", nil, nil); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	if err := CheckSchema([]*pms.Attribute{{Name: "age", Type: pms.AttributeTypeNumeric}, {Name: "tags", Type: pms.AttributeTypeString, List: true}}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := CheckSchema([]*pms.Attribute{{Name: "age", Type: "int"}}); err == nil {
+		t.Error("expected the unknown type to be rejected")
+	}
+	if err := CheckSchema([]*pms.Attribute{{Name: "age", Type: pms.AttributeTypeNumeric}, {Name: "age", Type: pms.AttributeTypeString}}); err == nil {
+		t.Error("expected the duplicated attribute to be rejected")
+	}
+}
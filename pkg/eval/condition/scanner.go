@@ -0,0 +1,104 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package condition
+
+import (
+	"unicode"
+)
+
+// span is the position of a token in a condition, start and end are the offsets of runes
+type span struct {
+	start int
+	end   int
+}
+
+// scan splits a condition into the tokens the same way as govaluate, which doesn't keep the positions of its tokens.
+// A string literal or an escaped variable which isn't closed ends with the condition.
+func scan(runes []rune) []span {
+	var spans []span
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		if unicode.IsSpace(c) {
+			i++
+			continue
+		}
+
+		start := i
+		switch {
+		case isNumeric(c):
+			if c == '0' && i+2 < len(runes) && runes[i+1] == 'x' {
+				i = readWhile(runes, i+2, isHexDigit)
+			} else {
+				i = readWhile(runes, i, isNumeric)
+			}
+		case c == ',' || c == '(' || c == ')':
+			i++
+		case c == '[':
+			i = readEnclosed(runes, i+1, func(r rune) bool { return r == ']' })
+		case unicode.IsLetter(c):
+			i = readWhile(runes, i, isVariableName)
+		case isQuote(c):
+			i = readEnclosed(runes, i+1, isQuote)
+		default:
+			i = readWhile(runes, i, isSymbol)
+		}
+		spans = append(spans, span{start: start, end: i})
+	}
+	return spans
+}
+
+// readWhile returns the end of a token starting at i, which is broken by a space or a rune not satisfying the
+// condition, a backslash escapes the next rune
+func readWhile(runes []rune, i int, condition func(rune) bool) int {
+	for i < len(runes) {
+		if runes[i] == '\\' {
+			i += 2
+			continue
+		}
+		if unicode.IsSpace(runes[i]) || !condition(runes[i]) {
+			break
+		}
+		i++
+	}
+	if i > len(runes) {
+		i = len(runes)
+	}
+	return i
+}
+
+// readEnclosed returns the end of a token after its closing rune, or the end of the condition if it isn't closed
+func readEnclosed(runes []rune, i int, isClosing func(rune) bool) int {
+	for i < len(runes) {
+		if runes[i] == '\\' {
+			i += 2
+			continue
+		}
+		if isClosing(runes[i]) {
+			return i + 1
+		}
+		i++
+	}
+	return len(runes)
+}
+
+func isNumeric(r rune) bool {
+	return unicode.IsDigit(r) || r == '.'
+}
+
+func isHexDigit(r rune) bool {
+	r = unicode.ToLower(r)
+	return unicode.IsDigit(r) || r == 'a' || r == 'b' || r == 'c' || r == 'd' || r == 'e' || r == 'f'
+}
+
+func isVariableName(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.'
+}
+
+func isQuote(r rune) bool {
+	return r == '\'' || r == '"'
+}
+
+func isSymbol(r rune) bool {
+	return !(unicode.IsDigit(r) || unicode.IsLetter(r) || r == '(' || r == ')' || r == '[' || r == ']' || isQuote(r))
+}
@@ -0,0 +1,287 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package condition
+
+import (
+	"fmt"
+
+	"github.com/teramoby/speedle-plus/3rdparty/github.com/Knetic/govaluate"
+	"github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+)
+
+// builtinAttributes are the types of the attributes every request has
+var builtinAttributes = map[string]*pms.Attribute{
+	ads.BuiltIn_Attr_RequestUser:     {Type: pms.AttributeTypeString},
+	ads.BuiltIn_Attr_RequestGroups:   {Type: pms.AttributeTypeString, List: true},
+	ads.BuiltIn_Attr_RequestResource: {Type: pms.AttributeTypeString},
+	ads.BuiltIn_Attr_RequestAction:   {Type: pms.AttributeTypeString},
+	ads.BuiltIn_Attr_RequestEntity:   {Type: pms.AttributeTypeString},
+	ads.BuiltIn_Attr_RequestTime:     {Type: pms.AttributeTypeDatetime},
+	ads.BuiltIn_Attr_RequestYear:     {Type: pms.AttributeTypeNumeric},
+	ads.BuiltIn_Attr_RequestMonth:    {Type: pms.AttributeTypeNumeric},
+	ads.BuiltIn_Attr_RequestDay:      {Type: pms.AttributeTypeNumeric},
+	ads.BuiltIn_Attr_RequestHour:     {Type: pms.AttributeTypeNumeric},
+	ads.BuiltIn_Attr_RequestWeekday:  {Type: pms.AttributeTypeString},
+}
+
+// operand is an attribute or a literal which is an operand of an operator, the type of an operand is only known if
+// it's a single token
+type operand struct {
+	text      string
+	valueType string
+	list      bool
+}
+
+func (o *operand) String() string {
+	if o.list {
+		return fmt.Sprintf("%s (list of %s)", o.text, o.valueType)
+	}
+	return fmt.Sprintf("%s (%s)", o.text, o.valueType)
+}
+
+// family is the kind of values in govaluate, datetime values are compared as numbers
+func (o *operand) family() string {
+	if o.valueType == pms.AttributeTypeDatetime {
+		return pms.AttributeTypeNumeric
+	}
+	return o.valueType
+}
+
+func (o *operand) is(valueType string) bool {
+	return !o.list && o.family() == valueType
+}
+
+// typeChecker checks the operators whose operands are single tokens, the other operators are left to the evaluation
+type typeChecker struct {
+	runes  []rune
+	tokens []govaluate.ExpressionToken
+	spans  []span
+	schema map[string]*pms.Attribute
+}
+
+func checkTypes(runes []rune, tokens []govaluate.ExpressionToken, spans []span, schema []*pms.Attribute) error {
+	c := typeChecker{runes: runes, tokens: tokens, spans: spans, schema: make(map[string]*pms.Attribute, len(schema))}
+	for _, attribute := range schema {
+		c.schema[attribute.Name] = attribute
+	}
+
+	for i, token := range tokens {
+		var message string
+		switch token.Kind {
+		case govaluate.VARIABLE:
+			if name := token.Value.(string); c.attribute(name) == nil {
+				message = "undefined attribute " + name
+			}
+		case govaluate.PREFIX:
+			message = c.checkPrefix(token.Value.(string), c.operand(i+1))
+		case govaluate.COMPARATOR, govaluate.LOGICALOP, govaluate.MODIFIER, govaluate.TERNARY:
+			message = c.checkOperator(token.Value.(string), c.leftOperand(i), c.rightOperand(i))
+		}
+		if len(message) > 0 {
+			return &Error{Column: c.column(i), Message: message}
+		}
+	}
+	return nil
+}
+
+func (c *typeChecker) attribute(name string) *pms.Attribute {
+	if attribute, ok := c.schema[name]; ok {
+		return attribute
+	}
+	return builtinAttributes[name]
+}
+
+func (c *typeChecker) column(i int) int {
+	if c.spans == nil {
+		return 0
+	}
+	return c.spans[i].start + 1
+}
+
+func (c *typeChecker) text(i int) string {
+	if c.spans == nil {
+		return fmt.Sprintf("%v", c.tokens[i].Value)
+	}
+	return string(c.runes[c.spans[i].start:c.spans[i].end])
+}
+
+// operand returns the type of the token at i if it's an attribute or a literal
+func (c *typeChecker) operand(i int) *operand {
+	if i < 0 || i >= len(c.tokens) {
+		return nil
+	}
+	result := operand{text: c.text(i)}
+	switch c.tokens[i].Kind {
+	case govaluate.NUMERIC:
+		result.valueType = pms.AttributeTypeNumeric
+	case govaluate.STRING, govaluate.PATTERN:
+		result.valueType = pms.AttributeTypeString
+	case govaluate.TIME:
+		result.valueType = pms.AttributeTypeDatetime
+	case govaluate.BOOLEAN:
+		result.valueType = pms.AttributeTypeBool
+	case govaluate.VARIABLE:
+		attribute := c.attribute(c.tokens[i].Value.(string))
+		if attribute == nil {
+			return nil
+		}
+		result.valueType = attribute.Type
+		result.list = attribute.List
+	default:
+		return nil
+	}
+	return &result
+}
+
+// precedence returns how tight an operator binds its operands, or -1 if the token isn't an operator
+func (c *typeChecker) precedence(i int) int {
+	token := c.tokens[i]
+	switch token.Kind {
+	case govaluate.PREFIX:
+		return 9
+	case govaluate.MODIFIER:
+		switch token.Value.(string) {
+		case "**":
+			return 8
+		case "*", "/", "%":
+			return 7
+		case "+", "-":
+			return 6
+		case "<<", ">>":
+			return 5
+		}
+		return 4
+	case govaluate.COMPARATOR:
+		return 3
+	case govaluate.LOGICALOP:
+		if token.Value.(string) == "&&" {
+			return 2
+		}
+		return 1
+	case govaluate.TERNARY:
+		return 0
+	}
+	return -1
+}
+
+// bounds returns true if the token at i doesn't take the operand next to it from the operator of the precedence
+func (c *typeChecker) bounds(i int, precedence int) bool {
+	if i < 0 || i >= len(c.tokens) {
+		return true
+	}
+	switch c.tokens[i].Kind {
+	case govaluate.CLAUSE, govaluate.CLAUSE_CLOSE, govaluate.SEPARATOR:
+		return true
+	}
+	other := c.precedence(i)
+	return other >= 0 && other < precedence
+}
+
+func (c *typeChecker) leftOperand(i int) *operand {
+	if !c.bounds(i-2, c.precedence(i)) {
+		return nil
+	}
+	return c.operand(i - 1)
+}
+
+func (c *typeChecker) rightOperand(i int) *operand {
+	if c.tokens[i].Kind == govaluate.COMPARATOR && c.tokens[i].Value.(string) == "in" &&
+		i+1 < len(c.tokens) && c.tokens[i+1].Kind == govaluate.CLAUSE {
+		return c.listOperand(i + 1)
+	}
+	if !c.bounds(i+2, c.precedence(i)) {
+		return nil
+	}
+	return c.operand(i + 1)
+}
+
+// listOperand returns the type of a parenthesized list of literals of the same type starting at i, e.g. ('a', 'b')
+func (c *typeChecker) listOperand(i int) *operand {
+	if c.spans == nil {
+		return nil
+	}
+	var result *operand
+	for j := i + 1; j < len(c.tokens); j += 2 {
+		item := c.operand(j)
+		if item == nil || item.list || (result != nil && item.family() != result.family()) {
+			return nil
+		}
+		if result == nil {
+			result = &operand{valueType: item.valueType, list: true}
+		}
+		if j+1 >= len(c.tokens) {
+			return nil
+		}
+		switch c.tokens[j+1].Kind {
+		case govaluate.SEPARATOR:
+			continue
+		case govaluate.CLAUSE_CLOSE:
+			result.text = string(c.runes[c.spans[i].start:c.spans[j+1].end])
+			return result
+		}
+		return nil
+	}
+	return nil
+}
+
+func (c *typeChecker) checkPrefix(op string, value *operand) string {
+	if value == nil {
+		return ""
+	}
+	switch op {
+	case "!":
+		if !value.is(pms.AttributeTypeBool) {
+			return fmt.Sprintf("%s can't be applied to %s", op, value)
+		}
+	default:
+		if !value.is(pms.AttributeTypeNumeric) {
+			return fmt.Sprintf("%s can't be applied to %s", op, value)
+		}
+	}
+	return ""
+}
+
+func (c *typeChecker) checkOperator(op string, left, right *operand) string {
+	var valid bool
+	switch op {
+	case "&&", "||":
+		// either operand is checked if the other one isn't known
+		for _, value := range []*operand{left, right} {
+			if value != nil && !value.is(pms.AttributeTypeBool) {
+				return fmt.Sprintf("%s can't be applied to %s", op, value)
+			}
+		}
+		return ""
+	case "?":
+		if left != nil && !left.is(pms.AttributeTypeBool) {
+			return fmt.Sprintf("%s can't be applied to %s", op, left)
+		}
+		return ""
+	case ":", "??":
+		return ""
+	}
+
+	if left == nil || right == nil {
+		return ""
+	}
+	switch op {
+	case "==", "!=":
+		valid = !left.list && !right.list && left.family() == right.family()
+	case ">", ">=", "<", "<=":
+		valid = !left.list && !right.list && left.family() == right.family() && left.family() != pms.AttributeTypeBool
+	case "=~", "!~":
+		valid = left.is(pms.AttributeTypeString) && right.is(pms.AttributeTypeString)
+	case "in":
+		valid = !left.list && right.list && left.family() == right.family()
+	case "+":
+		valid = !left.list && !right.list && left.family() != pms.AttributeTypeBool && right.family() != pms.AttributeTypeBool
+	default:
+		valid = left.is(pms.AttributeTypeNumeric) && right.is(pms.AttributeTypeNumeric)
+	}
+	if valid {
+		return ""
+	}
+	return fmt.Sprintf("%s can't be applied to %s and %s", op, left, right)
+}
@@ -0,0 +1,339 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package eval
+
+import (
+	"container/list"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/teramoby/speedle-plus/3rdparty/github.com/Knetic/govaluate"
+	adsapi "github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/cfg"
+)
+
+// timeAttributes are the built-in attributes which change between requests, decisions of the conditions
+// using them are never cached
+var timeAttributes = map[string]bool{
+	adsapi.BuiltIn_Attr_RequestTime:    true,
+	adsapi.BuiltIn_Attr_RequestYear:    true,
+	adsapi.BuiltIn_Attr_RequestMonth:   true,
+	adsapi.BuiltIn_Attr_RequestDay:     true,
+	adsapi.BuiltIn_Attr_RequestWeekday: true,
+	adsapi.BuiltIn_Attr_RequestHour:    true,
+}
+
+// DecisionCacheStats is the statistics of the decision cache
+type DecisionCacheStats struct {
+	Enabled bool  `json:"enabled"`
+	Size    int   `json:"size"`
+	TTL     int64 `json:"ttl"`
+	Entries int   `json:"entries"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	// Bypasses counts the decisions not cached because they depend on time attributes or non-cachable functions
+	Bypasses int64 `json:"bypasses"`
+	// Evictions counts the decisions evicted because the cache is full or they expired
+	Evictions int64 `json:"evictions"`
+	// Invalidations counts the decisions dropped because the policies of their services changed
+	Invalidations int64 `json:"invalidations"`
+}
+
+type cachedDecision struct {
+	service string
+	key     string
+	allowed bool
+	reason  adsapi.Reason
+	expires time.Time
+}
+
+// serviceCacheInfo is what the decision cache knows about a service since its last change
+type serviceCacheInfo struct {
+	generation int64
+	// analyzed is true once vars and bypass are computed from the conditions of the service
+	analyzed bool
+	// vars are the sorted attributes referred by the conditions, their values are part of the key
+	vars []string
+	// bypass is true if any condition depends on time attributes or non-cachable functions
+	bypass bool
+	// entries are the cached decisions of the service, so they could be dropped when it changes
+	entries map[string]*list.Element
+}
+
+// decisionCache is a LRU cache of decisions, the decisions of a service are dropped once the service,
+// the global service or the functions change. A nil decisionCache caches nothing.
+type decisionCache struct {
+	sync.Mutex
+	size     int
+	ttl      time.Duration
+	lru      *list.List
+	services map[string]*serviceCacheInfo
+	// generation is increased on every invalidation, a decision evaluated before it isn't cached
+	generation int64
+	stats      DecisionCacheStats
+}
+
+func newDecisionCache(conf *cfg.DecisionCacheConfig) *decisionCache {
+	if conf == nil || conf.Size <= 0 {
+		return nil
+	}
+	ttl := conf.TTL
+	if ttl <= 0 {
+		ttl = cfg.DefaultDecisionCacheTTL
+	}
+	return &decisionCache{
+		size:     conf.Size,
+		ttl:      time.Duration(ttl) * time.Second,
+		lru:      list.New(),
+		services: make(map[string]*serviceCacheInfo),
+		stats:    DecisionCacheStats{Enabled: true, Size: conf.Size, TTL: ttl},
+	}
+}
+
+func (c *decisionCache) serviceInfo(service string) *serviceCacheInfo {
+	info, ok := c.services[service]
+	if !ok {
+		info = &serviceCacheInfo{generation: c.generation, entries: make(map[string]*list.Element)}
+		c.services[service] = info
+	}
+	return info
+}
+
+// getServiceInfo returns a copy of the analysis of a service, and the generation it's valid for
+func (c *decisionCache) getServiceInfo(service string) (analyzed bool, vars []string, bypass bool, generation int64) {
+	c.Lock()
+	defer c.Unlock()
+	info := c.serviceInfo(service)
+	return info.analyzed, info.vars, info.bypass, info.generation
+}
+
+// setServiceInfo saves the analysis of a service if it hasn't changed since generation
+func (c *decisionCache) setServiceInfo(service string, vars []string, bypass bool, generation int64) {
+	c.Lock()
+	defer c.Unlock()
+	info := c.serviceInfo(service)
+	if info.generation == generation {
+		info.analyzed, info.vars, info.bypass = true, vars, bypass
+	}
+}
+
+func (c *decisionCache) bypass() {
+	c.Lock()
+	defer c.Unlock()
+	c.stats.Bypasses++
+}
+
+func (c *decisionCache) get(service, key string) (*cachedDecision, bool) {
+	c.Lock()
+	defer c.Unlock()
+	elem, ok := c.serviceInfo(service).entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	decision := elem.Value.(*cachedDecision)
+	if time.Now().After(decision.expires) {
+		c.remove(elem)
+		c.stats.Evictions++
+		c.stats.Misses++
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	c.stats.Hits++
+	return decision, true
+}
+
+// put caches a decision evaluated since generation, it's dropped if the service changed meanwhile
+func (c *decisionCache) put(service, key string, generation int64, allowed bool, reason adsapi.Reason) {
+	c.Lock()
+	defer c.Unlock()
+	info := c.serviceInfo(service)
+	if info.generation != generation {
+		return
+	}
+	if elem, ok := info.entries[key]; ok {
+		c.remove(elem)
+	}
+	for c.lru.Len() >= c.size {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+	info.entries[key] = c.lru.PushFront(&cachedDecision{
+		service: service,
+		key:     key,
+		allowed: allowed,
+		reason:  reason,
+		expires: time.Now().Add(c.ttl),
+	})
+}
+
+func (c *decisionCache) remove(elem *list.Element) {
+	decision := c.lru.Remove(elem).(*cachedDecision)
+	if info, ok := c.services[decision.service]; ok {
+		delete(info.entries, decision.key)
+	}
+}
+
+// invalidate drops the decisions of the services at once, all the decisions are dropped if the global service is
+// one of them
+func (c *decisionCache) invalidate(services ...string) {
+	if c == nil {
+		return
+	}
+	for _, service := range services {
+		if service == pms.GlobalService {
+			c.invalidateAll()
+			return
+		}
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.generation++
+	for _, service := range services {
+		if info, ok := c.services[service]; ok {
+			for _, elem := range info.entries {
+				c.lru.Remove(elem)
+			}
+			c.stats.Invalidations += int64(len(info.entries))
+		}
+		c.services[service] = &serviceCacheInfo{generation: c.generation, entries: make(map[string]*list.Element)}
+	}
+}
+
+// invalidateAll drops all the decisions, e.g. when the functions change or the policy store is reloaded
+func (c *decisionCache) invalidateAll() {
+	if c == nil {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.generation++
+	c.stats.Invalidations += int64(c.lru.Len())
+	c.lru.Init()
+	c.services = make(map[string]*serviceCacheInfo)
+}
+
+func (c *decisionCache) getStats() DecisionCacheStats {
+	if c == nil {
+		return DecisionCacheStats{}
+	}
+	c.Lock()
+	defer c.Unlock()
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	return stats
+}
+
+// DecisionCacheStats returns the statistics of the decision cache
+func (p *PolicyEvalImpl) DecisionCacheStats() DecisionCacheStats {
+	return p.decisionCache.getStats()
+}
+
+// cachedIsAllowed evaluates a populated context with the decision cache, the runtime policy store and
+// the service should be read locked
+func (p *PolicyEvalImpl) cachedIsAllowed(newCtx *internalRequestContext) (bool, adsapi.Reason, error) {
+	if p.decisionCache == nil {
+		return p.evaluate(newCtx, nil)
+	}
+	serviceName := newCtx.Service.Name
+	analyzed, vars, bypass, generation := p.decisionCache.getServiceInfo(serviceName)
+	if !analyzed {
+		vars, bypass = p.analyzeConditions(newCtx)
+		p.decisionCache.setServiceInfo(serviceName, vars, bypass, generation)
+	}
+	if bypass {
+		p.decisionCache.bypass()
+		return p.evaluate(newCtx, nil)
+	}
+
+	key := decisionKey(newCtx, vars)
+	if decision, ok := p.decisionCache.get(serviceName, key); ok {
+		return decision.allowed, decision.reason, nil
+	}
+	allowed, reason, err := p.evaluate(newCtx, nil)
+	if err == nil {
+		p.decisionCache.put(serviceName, key, generation, allowed, reason)
+	}
+	return allowed, reason, err
+}
+
+// decisionKey returns the key of a request, which is made of the sorted principals, the resource, the action
+// and the values of the attributes referred by the conditions
+func decisionKey(newCtx *internalRequestContext, vars []string) string {
+	principals := append([]string{}, newCtx.Subject.Principals...)
+	sort.Strings(principals)
+
+	var key strings.Builder
+	fmt.Fprintf(&key, "%q\x00%q\x00%q", principals, newCtx.Resource, newCtx.Action)
+	for _, name := range vars {
+		if value, ok := newCtx.Attributes[name]; ok {
+			fmt.Fprintf(&key, "\x00%s=%T:%#v", name, value, value)
+		} else {
+			fmt.Fprintf(&key, "\x00%s", name)
+		}
+	}
+	return key.String()
+}
+
+// analyzeConditions returns the attributes referred by the conditions of the policies and role policies
+// of the service, and the role policies of the global service, and whether the decisions must bypass the cache
+func (p *PolicyEvalImpl) analyzeConditions(newCtx *internalRequestContext) ([]string, bool) {
+	var conditions []string
+	for _, policy := range newCtx.Service.PoliciesCache.PolicyMap {
+		conditions = append(conditions, policy.Condition)
+	}
+	for _, rolePolicy := range newCtx.Service.RolePoliciesCache.PolicyMap {
+		conditions = append(conditions, rolePolicy.Condition)
+	}
+	if newCtx.GlobalService != nil {
+		newCtx.GlobalService.RLock()
+		for _, rolePolicy := range newCtx.GlobalService.RolePoliciesCache.PolicyMap {
+			conditions = append(conditions, rolePolicy.Condition)
+		}
+		newCtx.GlobalService.RUnlock()
+	}
+
+	varSet := make(map[string]bool)
+	for _, condition := range conditions {
+		if len(condition) == 0 {
+			continue
+		}
+		if p.RuntimePolicyStore.callsUncachableFunction(condition) {
+			return nil, true
+		}
+		exp, err := govaluate.NewEvaluableExpressionWithFunctions(condition, p.RuntimePolicyStore.Functions)
+		if err != nil {
+			// the evaluation fails or recompiles the condition, don't cache it
+			return nil, true
+		}
+		for _, name := range exp.Vars() {
+			if timeAttributes[name] {
+				return nil, true
+			}
+			varSet[name] = true
+		}
+	}
+
+	vars := make([]string, 0, len(varSet))
+	for name := range varSet {
+		vars = append(vars, name)
+	}
+	sort.Strings(vars)
+	return vars, false
+}
+
+// callsUncachableFunction checks whether a condition calls a custom function whose result isn't cachable
+func (rtps *RuntimePolicyStore) callsUncachableFunction(condition string) bool {
+	for name := range rtps.UncachableFunctions {
+		if matched, _ := regexp.MatchString(`\b`+regexp.QuoteMeta(name)+`\s*\(`, condition); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package eval
+
+import (
+	"testing"
+	"time"
+
+	adsapi "github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/cfg"
+)
+
+func TestDecisionCache(t *testing.T) {
+	preparePolicyDataInStore([]byte(`{"services": [
+		{"name": "adult", "policies": [
+			{"id": "p1", "effect": "grant", "permissions": [{"resource": "/doc", "actions": ["get"]}], "principals": [["user:alice"]], "condition": "age > 18"}
+		]},
+		{"name": "plain", "policies": [
+			{"id": "p1", "effect": "grant", "permissions": [{"resource": "/doc", "actions": ["get"]}], "principals": [["user:alice"]]}
+		]},
+		{"name": "office", "policies": [
+			{"id": "p1", "effect": "grant", "permissions": [{"resource": "/doc", "actions": ["get"]}], "principals": [["user:alice"]], "condition": "request_hour >= 0"}
+		]}
+	]}`), t)
+
+	decisionConf := *conf
+	decisionConf.EnableWatch = false
+	decisionConf.DecisionCacheConfig = &cfg.DecisionCacheConfig{Size: 3}
+	evaluator, err := NewWithStore(&decisionConf, testPS)
+	if err != nil {
+		t.Fatalf("Unable to initialize evaluator due to error [%v].", err)
+	}
+	p := evaluator.(*PolicyEvalImpl)
+
+	check := func(user, service string, age float64, allowed bool) {
+		t.Helper()
+		got, _, err := evaluator.IsAllowed(adsapi.RequestContext{
+			Subject:     &adsapi.Subject{Principals: []*adsapi.Principal{{Type: adsapi.PRINCIPAL_TYPE_USER, Name: user}}},
+			ServiceName: service,
+			Resource:    "/doc",
+			Action:      "get",
+			Attributes:  map[string]interface{}{"age": age},
+		})
+		if err != nil {
+			t.Fatalf("Unexcepted error happened [%v].", err)
+		}
+		if got != allowed {
+			t.Errorf("%s in %s with age %v: expected %v, but got %v", user, service, age, allowed, got)
+		}
+	}
+	checkStats := func(hits, misses, bypasses, evictions, invalidations int64, entries int) {
+		t.Helper()
+		stats := evaluator.DecisionCacheStats()
+		if stats.Hits != hits || stats.Misses != misses || stats.Bypasses != bypasses ||
+			stats.Evictions != evictions || stats.Invalidations != invalidations || stats.Entries != entries {
+			t.Errorf("unexpected stats %+v", stats)
+		}
+	}
+
+	check("alice", "adult", 20, true)
+	check("alice", "adult", 20, true)
+	// the attribute in the condition is part of the key
+	check("alice", "adult", 10, false)
+	check("alice", "plain", 10, true)
+	checkStats(1, 3, 0, 0, 0, 3)
+
+	// decisions depending on time attributes aren't cached
+	check("alice", "office", 0, true)
+	check("alice", "office", 0, true)
+	checkStats(1, 3, 2, 0, 0, 3)
+
+	// only the decisions of the changed service are dropped
+	p.AddPolicyInRuntimeCache("adult", &pms.Policy{
+		ID:          "p2",
+		Effect:      pms.Grant,
+		Permissions: []*pms.Permission{{Resource: "/doc", Actions: []string{"get"}}},
+		Principals:  [][]string{{"user:bob"}},
+	})
+	checkStats(1, 3, 2, 0, 2, 1)
+	check("alice", "plain", 10, true)
+	check("bob", "adult", 10, true)
+	checkStats(2, 4, 2, 0, 2, 2)
+
+	// the least recently used decision is evicted once the cache is full
+	check("alice", "adult", 20, true)
+	check("alice", "adult", 30, true)
+	checkStats(2, 6, 2, 1, 2, 3)
+	check("alice", "plain", 10, true)
+	checkStats(2, 7, 2, 2, 2, 3)
+
+	// expired decisions are evicted
+	p.decisionCache.ttl = time.Millisecond
+	check("bob", "adult", 20, true)
+	time.Sleep(5 * time.Millisecond)
+	check("bob", "adult", 20, true)
+	checkStats(2, 9, 2, 4, 2, 3)
+
+	// a global service change drops all the decisions
+	p.AddServiceInRuntimeCache(&pms.Service{Name: pms.GlobalService})
+	checkStats(2, 9, 2, 4, 5, 0)
+}
@@ -10,7 +10,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/teramoby/speedle-plus/3rdparty/github.com/Knetic/govaluate"
 	adsapi "github.com/teramoby/speedle-plus/api/ads"
 	"github.com/teramoby/speedle-plus/pkg/errors"
 	"github.com/teramoby/speedle-plus/pkg/eval/function"
@@ -21,13 +20,16 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-var builtinFunctions = map[string]govaluate.ExpressionFunction{
-	"Sqrt":     function.Sqrt,
-	"Max":      function.Max,
-	"Min":      function.Min,
-	"Sum":      function.Sum,
-	"Avg":      function.Avg,
-	"IsSubSet": function.IsSubSet,
+var builtinFunctions = function.Builtins
+
+// BuiltinFunctionNames returns the names of the built-in functions which could be used in conditions
+func BuiltinFunctionNames() []string {
+	names := make([]string, 0, len(builtinFunctions))
+	for name := range builtinFunctions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 type TokenAsserter interface {
@@ -40,6 +42,8 @@ type TokenAsserter interface {
 type InternalEvaluator interface {
 	adsapi.PolicyEvaluator
 	TokenAsserter
+	// DecisionCacheStats returns the statistics of the decision cache
+	DecisionCacheStats() DecisionCacheStats
 }
 
 type internalRequestContext struct {
@@ -64,11 +68,15 @@ type PolicyEvalImpl struct {
 	RuntimePolicyStore *RuntimePolicyStore //This is runtime policy store
 	Store              pms.PolicyStoreManagerADS
 	AsserterFunc       func(ctx *adsapi.RequestContext) error
+	// IsolatedServices are evaluated without the role policies of the global service
+	IsolatedServices map[string]bool
+	decisionCache    *decisionCache
 }
 
 func (p *PolicyEvalImpl) deleteService(serviceName string) {
 	// Delete application
 	p.RuntimePolicyStore.deleteService(serviceName)
+	p.decisionCache.invalidate(serviceName)
 }
 
 func (p *PolicyEvalImpl) fullReloadRuntimeCache() {
@@ -78,6 +86,7 @@ func (p *PolicyEvalImpl) fullReloadRuntimeCache() {
 		return
 	}
 	p.RuntimePolicyStore.reloadPolicyStore(ps)
+	p.decisionCache.invalidateAll()
 }
 
 func (p *PolicyEvalImpl) Refresh() error {
@@ -87,29 +96,36 @@ func (p *PolicyEvalImpl) Refresh() error {
 
 func (p *PolicyEvalImpl) AddServiceInRuntimeCache(service *pms.Service) {
 	p.RuntimePolicyStore.addService(service)
+	p.decisionCache.invalidate(service.Name)
 }
 
 func (p *PolicyEvalImpl) AddPolicyInRuntimeCache(serviceName string, policy *pms.Policy) {
 	p.RuntimePolicyStore.addPolicy(serviceName, policy)
+	p.decisionCache.invalidate(serviceName)
 }
 
 func (p *PolicyEvalImpl) AddRolePolicyInRuntimeCache(serviceName string, rolepolicy *pms.RolePolicy) {
 	p.RuntimePolicyStore.addRolePolicy(serviceName, rolepolicy)
+	p.decisionCache.invalidate(serviceName)
 }
 
 func (p *PolicyEvalImpl) DeletePolicyInRuntimeCache(serviceName string, policyID string) {
 	p.RuntimePolicyStore.deletePolicy(serviceName, policyID)
+	p.decisionCache.invalidate(serviceName)
 }
 func (p *PolicyEvalImpl) DeleteRolePolicyInRuntimeCache(serviceName string, rolePolicyID string) {
 	p.RuntimePolicyStore.deleteRolePolicy(serviceName, rolePolicyID)
+	p.decisionCache.invalidate(serviceName)
 }
 
 func (p *PolicyEvalImpl) DeleteFunctionInRuntimeCache(funcName string) {
 	p.RuntimePolicyStore.deleteFunction(funcName)
+	p.decisionCache.invalidateAll()
 }
 
 func (p *PolicyEvalImpl) AddFunctionInRuntimeCache(cf *pms.Function) {
 	p.RuntimePolicyStore.addFunction(cf)
+	p.decisionCache.invalidateAll()
 }
 
 func (p *PolicyEvalImpl) CleanExpiredFunctionResult() {
@@ -148,37 +164,23 @@ func (p *PolicyEvalImpl) populateContext(ctx *adsapi.RequestContext) (*internalR
 		return nil, err
 	}
 
-	var globalService *RuntimeService
-	if ctx.ServiceName != pms.GlobalService {
-		globalService, _ = p.getService(pms.GlobalService)
-	}
-
-	newCtx := internalRequestContext{
-		Resource:      ctx.Resource,
-		Action:        ctx.Action,
-		Service:       service,
-		GlobalService: globalService,
-		Attributes:    make(map[string]interface{}),
-	}
-
-	now := time.Now()
-	newCtx.Attributes[adsapi.BuiltIn_Attr_RequestTime] = now.Unix()
-	year, month, day := now.Date()
-	newCtx.Attributes[adsapi.BuiltIn_Attr_RequestYear] = year
-	newCtx.Attributes[adsapi.BuiltIn_Attr_RequestMonth] = int(month)
-	newCtx.Attributes[adsapi.BuiltIn_Attr_RequestDay] = day
-	newCtx.Attributes[adsapi.BuiltIn_Attr_RequestWeekday] = now.Weekday().String()
-	newCtx.Attributes[adsapi.BuiltIn_Attr_RequestHour] = now.Hour()
+	subject, subjectAttributes := populateSubject(ctx.Subject)
+	return p.newInternalContext(ctx.ServiceName, service, subject, subjectAttributes, ctx.Resource, ctx.Action, ctx.Attributes), nil
+}
 
-	newCtx.Subject = &subject{
+// populateSubject converts an asserted subject to the principals used in evaluation, and the built-in
+// attributes of the subject
+func populateSubject(reqSubject *adsapi.Subject) (*subject, map[string]interface{}) {
+	attributes := make(map[string]interface{})
+	newSubject := &subject{
 		Users:    []string{},
 		Groups:   []string{},
 		Entities: []string{},
 	}
-	if ctx.Subject != nil {
+	if reqSubject != nil {
 		groups := []interface{}{}
 		var user, entity interface{}
-		for _, principal := range ctx.Subject.Principals {
+		for _, principal := range reqSubject.Principals {
 			encodedPrincipal := subjectutils.EncodePrincipal(principal)
 			principalWithoutIDD := ""
 			if len(principal.IDD) != 0 {
@@ -189,25 +191,25 @@ func (p *PolicyEvalImpl) populateContext(ctx *adsapi.RequestContext) (*internalR
 			}
 			switch principal.Type {
 			case adsapi.PRINCIPAL_TYPE_USER:
-				newCtx.Subject.Users = append(newCtx.Subject.Users, encodedPrincipal)
+				newSubject.Users = append(newSubject.Users, encodedPrincipal)
 				if len(principalWithoutIDD) != 0 {
-					newCtx.Subject.Users = append(newCtx.Subject.Users, principalWithoutIDD)
+					newSubject.Users = append(newSubject.Users, principalWithoutIDD)
 				}
 				if user == nil {
 					user = principal.Name
 				}
 				break
 			case adsapi.PRINCIPAL_TYPE_GROUP:
-				newCtx.Subject.Groups = append(newCtx.Subject.Groups, encodedPrincipal)
+				newSubject.Groups = append(newSubject.Groups, encodedPrincipal)
 				groups = append(groups, principal.Name)
 				if len(principalWithoutIDD) != 0 {
-					newCtx.Subject.Groups = append(newCtx.Subject.Groups, principalWithoutIDD)
+					newSubject.Groups = append(newSubject.Groups, principalWithoutIDD)
 				}
 				break
 			case adsapi.PRINCIPAL_TYPE_ENTITY:
-				newCtx.Subject.Entities = append(newCtx.Subject.Entities, encodedPrincipal)
+				newSubject.Entities = append(newSubject.Entities, encodedPrincipal)
 				if len(principalWithoutIDD) != 0 {
-					newCtx.Subject.Entities = append(newCtx.Subject.Entities, principalWithoutIDD)
+					newSubject.Entities = append(newSubject.Entities, principalWithoutIDD)
 				}
 				if entity == nil {
 					entity = principal.Name
@@ -216,22 +218,61 @@ func (p *PolicyEvalImpl) populateContext(ctx *adsapi.RequestContext) (*internalR
 			}
 		}
 		if user != nil {
-			newCtx.Attributes[adsapi.BuiltIn_Attr_RequestUser] = user
+			attributes[adsapi.BuiltIn_Attr_RequestUser] = user
 		}
-		newCtx.Attributes[adsapi.BuiltIn_Attr_RequestGroups] = groups
+		attributes[adsapi.BuiltIn_Attr_RequestGroups] = groups
 		if entity != nil {
-			newCtx.Attributes[adsapi.BuiltIn_Attr_RequestEntity] = entity
+			attributes[adsapi.BuiltIn_Attr_RequestEntity] = entity
 		}
 	}
-	newCtx.Attributes[adsapi.BuiltIn_Attr_RequestResource] = ctx.Resource
-	newCtx.Attributes[adsapi.BuiltIn_Attr_RequestAction] = ctx.Action
-	for key, value := range ctx.Attributes {
-		newCtx.Attributes[key] = value
+
+	updateSubjectWithBuiltInRoles(newSubject)
+
+	return newSubject, attributes
+}
+
+// clone copies the subject, so the roles granted in a service are not added to the shared one
+func (s *subject) clone() *subject {
+	ret := *s
+	ret.Principals = append([]string{}, s.Principals...)
+	return &ret
+}
+
+func (p *PolicyEvalImpl) newInternalContext(serviceName string, service *RuntimeService, subject *subject, subjectAttributes map[string]interface{},
+	resource, action string, attributes map[string]interface{}) *internalRequestContext {
+	var globalService *RuntimeService
+	if serviceName != pms.GlobalService && !p.IsolatedServices[serviceName] {
+		globalService, _ = p.getService(pms.GlobalService)
+	}
+
+	newCtx := internalRequestContext{
+		Subject:       subject,
+		Resource:      resource,
+		Action:        action,
+		Service:       service,
+		GlobalService: globalService,
+		Attributes:    make(map[string]interface{}),
 	}
 
-	updateSubjectWithBuiltInRoles(newCtx.Subject)
+	now := time.Now()
+	newCtx.Attributes[adsapi.BuiltIn_Attr_RequestTime] = now.Unix()
+	year, month, day := now.Date()
+	newCtx.Attributes[adsapi.BuiltIn_Attr_RequestYear] = year
+	newCtx.Attributes[adsapi.BuiltIn_Attr_RequestMonth] = int(month)
+	newCtx.Attributes[adsapi.BuiltIn_Attr_RequestDay] = day
+	newCtx.Attributes[adsapi.BuiltIn_Attr_RequestWeekday] = now.Weekday().String()
+	newCtx.Attributes[adsapi.BuiltIn_Attr_RequestHour] = now.Hour()
+
+	for key, value := range subjectAttributes {
+		newCtx.Attributes[key] = value
+	}
+	newCtx.Attributes[adsapi.BuiltIn_Attr_RequestResource] = resource
+	newCtx.Attributes[adsapi.BuiltIn_Attr_RequestAction] = action
+	for key, value := range attributes {
+		newCtx.Attributes[key] = value
+	}
 
-	return &newCtx, nil
+	return &newCtx
 }
 
 func (p *PolicyEvalImpl) IsAllowed(ctx adsapi.RequestContext) (bool, adsapi.Reason, error) {
@@ -246,10 +287,50 @@ func (p *PolicyEvalImpl) InternalIsAllowed(ctx *adsapi.RequestContext, evaluatio
 	if err != nil {
 		return false, adsapi.SERVICE_NOT_FOUND, err
 	}
+	return p.isAllowed(newCtx, evaluationResult)
+}
+
+// IsAllowedBatch asserts the token of the subject and populates it once, then checks all the accesses with
+// the runtime policy store read locked once.
+func (p *PolicyEvalImpl) IsAllowedBatch(reqSubject *adsapi.Subject, accesses []adsapi.Access) ([]adsapi.Decision, error) {
+	p.RuntimePolicyStore.RLock()
+	defer p.RuntimePolicyStore.RUnlock()
+
+	// Assert identity token
+	if err := p.AssertToken(&adsapi.RequestContext{Subject: reqSubject}); err != nil {
+		return nil, err
+	}
+	subject, subjectAttributes := populateSubject(reqSubject)
+
+	decisions := make([]adsapi.Decision, len(accesses))
+	for i, access := range accesses {
+		service, err := p.getService(access.ServiceName)
+		if err != nil {
+			decisions[i] = adsapi.Decision{Reason: adsapi.SERVICE_NOT_FOUND, Error: err}
+			continue
+		}
+		newCtx := p.newInternalContext(access.ServiceName, service, subject.clone(), subjectAttributes, access.Resource, access.Action, access.Attributes)
+		decisions[i].Allowed, decisions[i].Reason, decisions[i].Error = p.isAllowed(newCtx, nil)
+	}
+	return decisions, nil
+}
+
+// isAllowed evaluates a populated context, the runtime policy store should be read locked
+func (p *PolicyEvalImpl) isAllowed(newCtx *internalRequestContext, evaluationResult *adsapi.EvaluationResult) (bool, adsapi.Reason, error) {
 	newCtx.Service.RLock()
 	defer newCtx.Service.RUnlock()
+	// diagnoses need the evaluated policies, so they aren't cached
+	if evaluationResult != nil {
+		return p.evaluate(newCtx, evaluationResult)
+	}
+	return p.cachedIsAllowed(newCtx)
+}
+
+// evaluate evaluates a populated context, the runtime policy store and the service should be read locked
+func (p *PolicyEvalImpl) evaluate(newCtx *internalRequestContext, evaluationResult *adsapi.EvaluationResult) (bool, adsapi.Reason, error) {
 	if newCtx.Service.PoliciesCache.isEmpty() {
-		return false, adsapi.NO_APPLICABLE_POLICIES, nil
+		allowed, reason := combinePolicies(newCtx.Service, nil, nil, evaluationResult)
+		return allowed, reason, nil
 	}
 
 	if evaluationResult != nil {
@@ -265,7 +346,7 @@ func (p *PolicyEvalImpl) InternalIsAllowed(ctx *adsapi.RequestContext, evaluatio
 		return false, adsapi.ERROR_IN_EVALUATION, err
 	}
 
-	allowed, reason := denyOverwriteCombiner(grantedPolicies, deniedPolicies, newCtx, evaluationResult)
+	allowed, reason := combinePolicies(newCtx.Service, grantedPolicies, deniedPolicies, evaluationResult)
 	return allowed, reason, nil
 }
 
@@ -289,6 +370,11 @@ func (p *PolicyEvalImpl) Diagnose(ctx adsapi.RequestContext) (*adsapi.Evaluation
 }
 
 func (p *PolicyEvalImpl) GetAllGrantedRoles(ctx adsapi.RequestContext) ([]string, error) {
+	return p.getAllGrantedRoles(ctx, nil)
+}
+
+// getAllGrantedRoles returns the granted roles, and adds the evaluated role policies to evaluationResult if it isn't nil
+func (p *PolicyEvalImpl) getAllGrantedRoles(ctx adsapi.RequestContext, evaluationResult *adsapi.EvaluationResult) ([]string, error) {
 	p.RuntimePolicyStore.RLock()
 	defer p.RuntimePolicyStore.RUnlock()
 	newCtx, err := p.populateContext(&ctx)
@@ -298,11 +384,12 @@ func (p *PolicyEvalImpl) GetAllGrantedRoles(ctx adsapi.RequestContext) ([]string
 	newCtx.Service.RLock()
 	defer newCtx.Service.RUnlock()
 
-	ret, err := p.getGrantedRolesFromService(newCtx, nil)
+	ret, err := p.getGrantedRolesFromService(newCtx, evaluationResult)
 	return ret, err
 }
 
-//Limitations: This function only calculate granted permissions with resource, will not calculate granted permissions with resource expression.
+//Limitations: This function only calculate granted permissions with resource or resource glob, will not calculate granted permissions with resource expression.
+//A granted resource glob is removed only if a denied permission covers the whole glob, e.g. denying /reports/secret doesn't change /reports/**.
 func (p *PolicyEvalImpl) GetAllGrantedPermissions(ctx adsapi.RequestContext) ([]pms.Permission, error) {
 	p.RuntimePolicyStore.RLock()
 	defer p.RuntimePolicyStore.RUnlock()
@@ -339,6 +426,12 @@ func (p *PolicyEvalImpl) GetAllGrantedPermissions(ctx adsapi.RequestContext) ([]
 					Actions:  permission.Actions,
 				})
 			}
+			if len(permission.ResourceGlob) != 0 {
+				grantedPermissionList = append(grantedPermissionList, pms.Permission{
+					ResourceGlob: permission.ResourceGlob,
+					Actions:      permission.Actions,
+				})
+			}
 		}
 	}
 	for _, policy := range deniedPolicies {
@@ -351,6 +444,7 @@ func (p *PolicyEvalImpl) GetAllGrantedPermissions(ctx adsapi.RequestContext) ([]
 				Resource:           permission.Resource,
 				Actions:            permission.Actions,
 				ResourceExpression: permission.ResourceExpression,
+				ResourceGlob:       permission.ResourceGlob,
 			})
 		}
 	}
@@ -412,7 +506,7 @@ func (p *PolicyEvalImpl) getDirectRolePolicesInService(principals []string,
 		}
 
 		// No principal defined. that means the roles are granted to any user
-		if (policy.Principals == nil || len(policy.Principals) == 0 || matchRolePolicyPrincipals(principals, policy.Principals)) && matchResource(resource, policy.Resources, policy.ResourceExpressions) {
+		if (policy.Principals == nil || len(policy.Principals) == 0 || matchRolePolicyPrincipals(principals, policy.Principals)) && service.RolePoliciesCache.matchResource(resource, policy.Resources, policy.ResourceExpressions, policy.ResourceGlobs) {
 			// Evaluate conditions
 			condition, ok := service.RolePoliciesCache.Conditions[policy.ID]
 			// If no conditions defined, the condition evaluation result is true
@@ -871,7 +965,7 @@ func (p *PolicyEvalImpl) getPolicyList(ctx *internalRequestContext, matchResourc
 		// No principal defined. that means the resource actions are granted to any user
 		if policy.Principals == nil || len(policy.Principals) == 0 || matchPrincipals(principals, policy.Principals) {
 			// Check the resource and action
-			if !matchResource || (matchResource && matchResourceAction(policy, ctx)) {
+			if !matchResource || (matchResource && ctx.Service.PoliciesCache.matchResourceAction(policy, ctx)) {
 				// Evaluate conditions
 				condition, ok := ctx.Service.PoliciesCache.Conditions[policy.ID]
 				// If no conditions defined, the condition evaluation result is true
@@ -1080,57 +1174,80 @@ func (p *PolicyEvalImpl) getCustFunctionSetInCache() ([]string, error) {
 
 func (p *PolicyEvalImpl) updateRuntimeCacheWithStoreChange(updateChan pms.StorageChangeChannel) {
 	for e := range updateChan {
-		switch e.Type {
-		case pms.SERVICE_ADD: ///Event content: StoreUpdateData{ParentID:serviceName, Data:*service}
-			serviceGot := e.Content.(*pms.Service)
-			p.AddServiceInRuntimeCache(serviceGot)
-		case pms.SERVICE_DELETE: //Event content:[]StoreUpdateData{ParentID:serviceName, Data:servieName}
-			services := e.Content.([]string)
-			for _, s := range services {
-				p.deleteService(s)
-			}
-		case pms.POLICY_ADD: //Event content :[]StoreUpdateData{ParentID:serviceName, Data:*policy}
-			data := e.Content.([]pms.StoreUpdateData)
-			for _, s := range data {
-				policy := s.Data.(*pms.Policy)
-				p.AddPolicyInRuntimeCache(s.ServiceName, policy)
-			}
-		case pms.POLICY_DELETE: // Event content:[]StoreUpdateData{ParentID:serviceName, Data:*pms.Policy}
-			data := e.Content.([]pms.StoreUpdateData)
-			for _, s := range data {
-				policy := s.Data.(*pms.Policy)
-				p.DeletePolicyInRuntimeCache(s.ServiceName, policy.ID)
-			}
-		case pms.ROLEPOLICY_ADD: //Event content :[]StoreUpdateData{ParentID:serviceName, Data:*rolepolicy}
-			data := e.Content.([]pms.StoreUpdateData)
-			for _, s := range data {
-				rolepolicy := s.Data.(*pms.RolePolicy)
-				p.AddRolePolicyInRuntimeCache(s.ServiceName, rolepolicy)
-			}
-		case pms.ROLEPOLICY_DELETE: //Event content:[]StoreUpdateData{ParentID:serviceName, Data:*pms.RolePolicy}
-			data := e.Content.([]pms.StoreUpdateData)
-			for _, s := range data {
-				rolePolicy := s.Data.(*pms.RolePolicy)
-				p.DeleteRolePolicyInRuntimeCache(s.ServiceName, rolePolicy.ID)
-			}
-		case pms.SYNC_RELOAD:
-			data := e.Content.([]interface{})
-			err := p.syncRuntimeCache(data)
-			if err != nil {
-				log.Error("failed to reload cache data. ", err)
-			}
-		case pms.FUNCTION_ADD:
-			f := e.Content.(*pms.Function)
-			p.AddFunctionInRuntimeCache(f)
-		case pms.FUNCTION_DELETE:
-			fs := e.Content.([]string)
-			for _, f := range fs {
-				p.DeleteFunctionInRuntimeCache(f)
-			}
-		case pms.FULL_RELOAD:
+		p.applyStoreChange(e)
+	}
+}
+
+func (p *PolicyEvalImpl) applyStoreChange(e pms.StoreChangeEvent) {
+	switch e.Type {
+	case pms.SERVICE_ADD: ///Event content: StoreUpdateData{ParentID:serviceName, Data:*service}
+		serviceGot := e.Content.(*pms.Service)
+		p.AddServiceInRuntimeCache(serviceGot)
+	case pms.SERVICE_DELETE: //Event content:[]StoreUpdateData{ParentID:serviceName, Data:servieName}
+		services := e.Content.([]string)
+		for _, s := range services {
+			p.deleteService(s)
+		}
+	case pms.POLICY_ADD: //Event content :[]StoreUpdateData{ParentID:serviceName, Data:*policy}
+		data := e.Content.([]pms.StoreUpdateData)
+		for _, s := range data {
+			policy := s.Data.(*pms.Policy)
+			p.AddPolicyInRuntimeCache(s.ServiceName, policy)
+		}
+	case pms.POLICY_DELETE: // Event content:[]StoreUpdateData{ParentID:serviceName, Data:*pms.Policy}
+		data := e.Content.([]pms.StoreUpdateData)
+		for _, s := range data {
+			policy := s.Data.(*pms.Policy)
+			p.DeletePolicyInRuntimeCache(s.ServiceName, policy.ID)
+		}
+	case pms.ROLEPOLICY_ADD: //Event content :[]StoreUpdateData{ParentID:serviceName, Data:*rolepolicy}
+		data := e.Content.([]pms.StoreUpdateData)
+		for _, s := range data {
+			rolepolicy := s.Data.(*pms.RolePolicy)
+			p.AddRolePolicyInRuntimeCache(s.ServiceName, rolepolicy)
+		}
+	case pms.ROLEPOLICY_DELETE: //Event content:[]StoreUpdateData{ParentID:serviceName, Data:*pms.RolePolicy}
+		data := e.Content.([]pms.StoreUpdateData)
+		for _, s := range data {
+			rolePolicy := s.Data.(*pms.RolePolicy)
+			p.DeleteRolePolicyInRuntimeCache(s.ServiceName, rolePolicy.ID)
+		}
+	case pms.SYNC_RELOAD:
+		data := e.Content.([]interface{})
+		err := p.syncRuntimeCache(data)
+		if err != nil {
+			log.Error("failed to reload cache data. ", err)
+		}
+	case pms.FUNCTION_ADD:
+		f := e.Content.(*pms.Function)
+		p.AddFunctionInRuntimeCache(f)
+	case pms.FUNCTION_DELETE:
+		fs := e.Content.([]string)
+		for _, f := range fs {
+			p.DeleteFunctionInRuntimeCache(f)
+		}
+	case pms.FULL_RELOAD:
+		p.fullReloadRuntimeCache()
+	case pms.BATCH: //Event content:[]StoreChangeEvent of one transaction
+		p.applyBatch(e.Content.([]pms.StoreChangeEvent))
+	}
+}
+
+// applyBatch applies the events of one transaction at once, the decisions are invalidated after all of them are
+// applied. A reload in the batch reloads everything instead.
+func (p *PolicyEvalImpl) applyBatch(events []pms.StoreChangeEvent) {
+	for _, event := range events {
+		if event.Type == pms.FULL_RELOAD || event.Type == pms.SYNC_RELOAD {
 			p.fullReloadRuntimeCache()
+			return
 		}
 	}
+	services, all := p.RuntimePolicyStore.applyEvents(events)
+	if all {
+		p.decisionCache.invalidateAll()
+	} else if len(services) > 0 {
+		p.decisionCache.invalidate(services...)
+	}
 }
 
 func (p *PolicyEvalImpl) cleanExpiredFunctionResultPeriodically() {
@@ -0,0 +1,139 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package eval
+
+import (
+	"errors"
+	"testing"
+
+	adsapi "github.com/teramoby/speedle-plus/api/ads"
+)
+
+func TestIsAllowedBatch(t *testing.T) {
+	const appStream = `
+	{
+		"services": [
+		{
+			"name": "erp",
+			"policies": [
+			{
+				"id": "id1",
+				"name": "policy1",
+				"effect": "grant",
+				"permissions": [{"resource": "/node1", "actions": ["get"]}],
+				"principals": [["role:reader"]]
+			},
+			{
+				"id": "id2",
+				"name": "policy2",
+				"effect": "deny",
+				"permissions": [{"resource": "/node1", "actions": ["delete"]}],
+				"principals": [["user:bill"]]
+			},
+			{
+				"id": "id3",
+				"name": "policy3",
+				"effect": "grant",
+				"permissions": [{"resource": "/node2", "actions": ["get"]}],
+				"principals": [["user:bill"]],
+				"condition": "level > 3"
+			}
+			],
+			"rolePolicies": [
+			{
+				"id": "id4",
+				"name": "rolePolicy1",
+				"effect": "grant",
+				"roles": ["reader"],
+				"principals": ["user:bill"]
+			}
+			]
+		},
+		{
+			"name": "crm",
+			"policies": [
+			{
+				"id": "id5",
+				"name": "policy1",
+				"effect": "grant",
+				"permissions": [{"resource": "/node1", "actions": ["get"]}],
+				"principals": [["role:reader"]]
+			}
+			]
+		}
+		]
+	}
+	`
+	preparePolicyDataInStore([]byte(appStream), t)
+
+	evaluator, err := NewWithStore(conf, testPS)
+	if err != nil {
+		t.Fatalf("Unable to initialize evaluator due to error [%v].", err)
+	}
+	assertions := 0
+	evaluator.SetAsserterFunc(func(ctx *adsapi.RequestContext) error {
+		assertions++
+		if ctx.Subject.Token != "bill-token" {
+			return errors.New("invalid token")
+		}
+		ctx.Subject.Principals = append(ctx.Subject.Principals, &adsapi.Principal{Type: adsapi.PRINCIPAL_TYPE_USER, Name: "bill"})
+		return nil
+	})
+
+	accesses := []adsapi.Access{
+		{ServiceName: "erp", Resource: "/node1", Action: "get"},
+		{ServiceName: "erp", Resource: "/node1", Action: "delete"},
+		{ServiceName: "erp", Resource: "/node2", Action: "get", Attributes: map[string]interface{}{"level": float64(5)}},
+		{ServiceName: "erp", Resource: "/node2", Action: "get", Attributes: map[string]interface{}{"level": float64(1)}},
+		// role reader is granted in erp only
+		{ServiceName: "crm", Resource: "/node1", Action: "get"},
+		{ServiceName: "dummy", Resource: "/node1", Action: "get"},
+	}
+	expected := []struct {
+		allowed bool
+		reason  adsapi.Reason
+	}{
+		{true, adsapi.GRANT_POLICY_FOUND},
+		{false, adsapi.DENY_POLICY_FOUND},
+		{true, adsapi.GRANT_POLICY_FOUND},
+		{false, adsapi.NO_APPLICABLE_POLICIES},
+		{false, adsapi.NO_APPLICABLE_POLICIES},
+		{false, adsapi.SERVICE_NOT_FOUND},
+	}
+
+	subject := adsapi.Subject{TokenType: "test", Token: "bill-token"}
+	decisions, err := evaluator.IsAllowedBatch(&subject, accesses)
+	if err != nil {
+		t.Fatalf("Unexcepted error happened [%v].", err)
+	}
+	if assertions != 1 {
+		t.Fatalf("token should be asserted once, but asserted %d times", assertions)
+	}
+	if len(decisions) != len(accesses) {
+		t.Fatalf("expected %d decisions, but got %d", len(accesses), len(decisions))
+	}
+	for i, decision := range decisions {
+		if decision.Allowed != expected[i].allowed || decision.Reason != expected[i].reason {
+			t.Errorf("access %v: expected %v %v, but got %v %v", accesses[i], expected[i].allowed, expected[i].reason, decision.Allowed, decision.Reason)
+		}
+		if (decision.Error != nil) != (expected[i].reason == adsapi.SERVICE_NOT_FOUND) {
+			t.Errorf("access %v: unexpected error %v", accesses[i], decision.Error)
+		}
+		// the result should be the same as checking the access alone
+		allowed, reason, _ := evaluator.IsAllowed(adsapi.RequestContext{
+			Subject:     &adsapi.Subject{Principals: []*adsapi.Principal{{Type: adsapi.PRINCIPAL_TYPE_USER, Name: "bill"}}},
+			ServiceName: accesses[i].ServiceName,
+			Resource:    accesses[i].Resource,
+			Action:      accesses[i].Action,
+			Attributes:  accesses[i].Attributes,
+		})
+		if allowed != decision.Allowed || reason != decision.Reason {
+			t.Errorf("access %v: batch decision %v %v is different from %v %v", accesses[i], decision.Allowed, decision.Reason, allowed, reason)
+		}
+	}
+
+	if _, err := evaluator.IsAllowedBatch(&adsapi.Subject{TokenType: "test", Token: "invalid"}, accesses); err == nil {
+		t.Fatal("batch should fail if token fails to be asserted")
+	}
+}
@@ -0,0 +1,109 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package eval
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	adsapi "github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+)
+
+func TestCombiningAlgorithms(t *testing.T) {
+	const policies = `[
+		{"id": "id1", "effect": "grant", "permissions": [{"resource": "/doc", "actions": ["read"]}], "principals": [["user:alice"]], "priority": 10},
+		{"id": "id2", "effect": "deny", "permissions": [{"resource": "/doc", "actions": ["read"]}], "principals": [["user:alice"]], "priority": 1},
+		{"id": "id3", "effect": "deny", "permissions": [{"resource": "/doc", "actions": ["read"]}], "principals": [["user:bob"]]}
+	]`
+	services := []struct {
+		name      string
+		algorithm string
+		effect    string
+	}{
+		{"default", "", ""},
+		{"deny-overrides", pms.DenyOverrides, pms.Grant},
+		{"permit-overrides", pms.PermitOverrides, ""},
+		{"first-applicable", pms.FirstApplicable, ""},
+		{"deny-unless-permit", pms.DenyUnlessPermit, ""},
+		{"permit-unless-deny", pms.PermitUnlessDeny, ""},
+	}
+	var serviceDefs []string
+	for _, service := range services {
+		serviceDefs = append(serviceDefs, fmt.Sprintf(`{"name": %q, "combiningAlgorithm": %q, "defaultEffect": %q, "policies": %s}`,
+			service.name, service.algorithm, service.effect, policies))
+	}
+	serviceDefs = append(serviceDefs, `{"name": "empty", "combiningAlgorithm": "permit-unless-deny"}`)
+	preparePolicyDataInStore([]byte(`{"services": [`+strings.Join(serviceDefs, ",")+`]}`), t)
+
+	evaluator, err := NewWithStore(conf, testPS)
+	if err != nil {
+		t.Fatalf("Unable to initialize evaluator due to error [%v].", err)
+	}
+
+	type decision struct {
+		allowed bool
+		reason  adsapi.Reason
+	}
+	expected := map[string][]decision{
+		// decisions of alice, bob and carol
+		"default":            {{false, adsapi.DENY_POLICY_FOUND}, {false, adsapi.DENY_POLICY_FOUND}, {false, adsapi.NO_APPLICABLE_POLICIES}},
+		"deny-overrides":     {{false, adsapi.DENY_POLICY_FOUND}, {false, adsapi.DENY_POLICY_FOUND}, {true, adsapi.NO_APPLICABLE_POLICIES}},
+		"permit-overrides":   {{true, adsapi.GRANT_POLICY_FOUND}, {false, adsapi.DENY_POLICY_FOUND}, {false, adsapi.NO_APPLICABLE_POLICIES}},
+		"first-applicable":   {{true, adsapi.GRANT_POLICY_FOUND}, {false, adsapi.DENY_POLICY_FOUND}, {false, adsapi.NO_APPLICABLE_POLICIES}},
+		"deny-unless-permit": {{true, adsapi.GRANT_POLICY_FOUND}, {false, adsapi.DENY_POLICY_FOUND}, {false, adsapi.NO_APPLICABLE_POLICIES}},
+		"permit-unless-deny": {{false, adsapi.DENY_POLICY_FOUND}, {false, adsapi.DENY_POLICY_FOUND}, {true, adsapi.NO_APPLICABLE_POLICIES}},
+		"empty":              {{true, adsapi.NO_APPLICABLE_POLICIES}, {true, adsapi.NO_APPLICABLE_POLICIES}, {true, adsapi.NO_APPLICABLE_POLICIES}},
+	}
+	for serviceName, decisions := range expected {
+		for i, user := range []string{"alice", "bob", "carol"} {
+			allowed, reason, err := evaluator.IsAllowed(adsapi.RequestContext{
+				Subject:     &adsapi.Subject{Principals: []*adsapi.Principal{{Type: adsapi.PRINCIPAL_TYPE_USER, Name: user}}},
+				ServiceName: serviceName,
+				Resource:    "/doc",
+				Action:      "read",
+			})
+			if err != nil {
+				t.Fatalf("Unexcepted error happened [%v].", err)
+			}
+			if allowed != decisions[i].allowed || reason != decisions[i].reason {
+				t.Errorf("service %s, user %s: expected %v %v, but got %v %v", serviceName, user, decisions[i].allowed, decisions[i].reason, allowed, reason)
+			}
+		}
+	}
+
+	result, err := evaluator.Diagnose(adsapi.RequestContext{
+		Subject:     &adsapi.Subject{Principals: []*adsapi.Principal{{Type: adsapi.PRINCIPAL_TYPE_USER, Name: "alice"}}},
+		ServiceName: "first-applicable",
+		Resource:    "/doc",
+		Action:      "read",
+	})
+	if err != nil {
+		t.Fatalf("Unexcepted error happened [%v].", err)
+	}
+	if result.CombiningAlgorithm != pms.FirstApplicable || !strings.Contains(result.Explanation, `grant policy "id1" with priority 10 takes effect`) {
+		t.Errorf("unexpected combining algorithm %q or explanation %q", result.CombiningAlgorithm, result.Explanation)
+	}
+	statuses := make(map[string]string)
+	for _, policy := range result.Policies {
+		statuses[policy.ID] = policy.Status
+	}
+	if statuses["id1"] != adsapi.Evaluation_TakeEffect || statuses["id2"] != adsapi.Evaluation_Ignored {
+		t.Errorf("id1 should take effect and id2 should be ignored, but got %v", statuses)
+	}
+
+	result, err = evaluator.Diagnose(adsapi.RequestContext{
+		Subject:     &adsapi.Subject{Principals: []*adsapi.Principal{{Type: adsapi.PRINCIPAL_TYPE_USER, Name: "carol"}}},
+		ServiceName: "default",
+		Resource:    "/doc",
+		Action:      "read",
+	})
+	if err != nil {
+		t.Fatalf("Unexcepted error happened [%v].", err)
+	}
+	if result.CombiningAlgorithm != pms.DenyOverrides || !strings.Contains(result.Explanation, "the default effect deny is used") {
+		t.Errorf("unexpected combining algorithm %q or explanation %q", result.CombiningAlgorithm, result.Explanation)
+	}
+}
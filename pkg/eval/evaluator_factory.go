@@ -5,6 +5,7 @@ package eval
 
 import (
 	"github.com/teramoby/speedle-plus/pkg/cfg"
+	"github.com/teramoby/speedle-plus/pkg/errors"
 	"github.com/teramoby/speedle-plus/pkg/store"
 
 	adsapi "github.com/teramoby/speedle-plus/api/ads"
@@ -48,6 +49,28 @@ func NewFromConfig(conf *cfg.Config) (InternalEvaluator, error) {
 	return NewWithStore(conf, s)
 }
 
+// NewTenantEvaluators creates the evaluators of the default tenant and the tenants in conf, and returns them by tenant.
+// Every evaluator has its own runtime policy store loaded from the policy store of its tenant.
+func NewTenantEvaluators(conf *cfg.Config) (map[string]InternalEvaluator, error) {
+	evaluators := make(map[string]InternalEvaluator)
+	// the default tenant is opened first, so the tenants could share its embedded store, e.g. the embedded etcd
+	for _, tenant := range append([]string{store.DefaultTenant}, conf.Tenants...) {
+		if _, ok := evaluators[tenant]; ok {
+			return nil, errors.Errorf(errors.ConfigError, "duplicate tenant %q", tenant)
+		}
+		s, err := store.NewTenantStore(conf.StoreConfig.StoreType, conf.StoreConfig.StoreProps, tenant)
+		if err != nil {
+			return nil, err
+		}
+		evaluator, err := NewWithStore(conf, s)
+		if err != nil {
+			return nil, err
+		}
+		evaluators[tenant] = evaluator
+	}
+	return evaluators, nil
+}
+
 // NewWithStore creates a policy evaluator with policy store
 func NewWithStore(conf *cfg.Config, s pms.PolicyStoreManagerADS) (InternalEvaluator, error) {
 	ps, err := s.ReadPolicyStore()
@@ -70,6 +93,7 @@ func NewWithStore(conf *cfg.Config, s pms.PolicyStoreManagerADS) (InternalEvalua
 	p := &PolicyEvalImpl{
 		RuntimePolicyStore: runtimePolicyStore,
 		Store:              s,
+		decisionCache:      newDecisionCache(conf.DecisionCacheConfig),
 	}
 
 	// start a goroutine watching to the channel for update events and
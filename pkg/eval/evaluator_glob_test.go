@@ -0,0 +1,113 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package eval
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	adsapi "github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+)
+
+func TestResourceGlobs(t *testing.T) {
+	preparePolicyDataInStore([]byte(`{"services": [{
+		"name": "reports",
+		"policies": [
+			{"id": "p1", "effect": "grant", "permissions": [{"resourceGlob": "/reports/*", "actions": ["get"]}], "principals": [["user:alice"]]},
+			{"id": "p2", "effect": "grant", "permissions": [{"resourceGlob": "/reports/**", "actions": ["list"]}], "principals": [["user:alice"]]},
+			{"id": "p3", "effect": "deny", "permissions": [{"resourceGlob": "/reports/{secret,private}", "actions": ["get"]}], "principals": [["user:alice"]]},
+			{"id": "p4", "effect": "grant", "permissions": [{"resourceGlob": "/audit/**", "actions": ["read"]}], "principals": [["role:auditor"]]},
+			{"id": "p5", "effect": "grant", "permissions": [{"resourceGlob": "/docs/2019/*", "actions": ["get", "list"]}], "principals": [["user:carol"]]},
+			{"id": "p6", "effect": "deny", "permissions": [{"resourceGlob": "/docs/**", "actions": ["get"]}], "principals": [["user:carol"]]}
+		],
+		"rolePolicies": [
+			{"id": "rp1", "effect": "grant", "roles": ["auditor"], "principals": ["user:bob"], "resourceGlobs": ["/audit/**/logs"]}
+		]
+	}]}`), t)
+
+	evaluator, err := NewWithStore(conf, testPS)
+	if err != nil {
+		t.Fatalf("Unable to initialize evaluator due to error [%v].", err)
+	}
+	subjectOf := func(user string) *adsapi.Subject {
+		return &adsapi.Subject{Principals: []*adsapi.Principal{{Type: adsapi.PRINCIPAL_TYPE_USER, Name: user}}}
+	}
+
+	testCases := []struct {
+		user     string
+		action   string
+		resource string
+		allowed  bool
+	}{
+		{"alice", "get", "/reports/2019", true},
+		{"alice", "get", "/reports/2019/q1", false},
+		{"alice", "get", "/reports", false},
+		{"alice", "list", "/reports", true},
+		{"alice", "list", "/reports/2019/q1", true},
+		{"alice", "list", "/reportsx", false},
+		{"alice", "get", "/reports/secret", false},
+		{"bob", "read", "/audit/logs", true},
+		{"bob", "read", "/audit/2019/q1/logs", true},
+		{"bob", "read", "/audit/2019", false},
+		{"carol", "list", "/docs/2019/q1", true},
+		{"carol", "get", "/docs/2019/q1", false},
+	}
+	for _, tc := range testCases {
+		allowed, _, err := evaluator.IsAllowed(adsapi.RequestContext{
+			Subject:     subjectOf(tc.user),
+			ServiceName: "reports",
+			Resource:    tc.resource,
+			Action:      tc.action,
+		})
+		if err != nil {
+			t.Fatalf("Unexcepted error happened [%v].", err)
+		}
+		if allowed != tc.allowed {
+			t.Errorf("%s %s %s: expected %v, but got %v", tc.user, tc.action, tc.resource, tc.allowed, allowed)
+		}
+	}
+
+	expected := map[string][]pms.Permission{
+		"alice": {{ResourceGlob: "/reports/*", Actions: []string{"get"}}, {ResourceGlob: "/reports/**", Actions: []string{"list"}}},
+		// the denied glob covers the granted one
+		"carol": {{ResourceGlob: "/docs/2019/*", Actions: []string{"list"}}},
+	}
+	for user, want := range expected {
+		got, err := evaluator.GetAllGrantedPermissions(adsapi.RequestContext{Subject: subjectOf(user), ServiceName: "reports"})
+		if err != nil {
+			t.Fatalf("Unexcepted error happened [%v].", err)
+		}
+		sort.Slice(got, func(i, j int) bool { return got[i].ResourceGlob < got[j].ResourceGlob })
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("user %s: expected permissions %v, but got %v", user, want, got)
+		}
+	}
+}
+
+func TestResourceGlobCache(t *testing.T) {
+	cache := NewPolicyCacheData()
+	policy := &pms.Policy{
+		ID:          "p1",
+		Effect:      pms.Grant,
+		Permissions: []*pms.Permission{{ResourceGlob: "/reports/{2019,2020}/*", Actions: []string{"get"}}},
+		Principals:  [][]string{{"user:alice"}},
+	}
+	cache.AddPolicyToCache(policy, nil)
+	if policies := cache.GetRelatedPolicyMap([]string{"user:alice"}, "/reports/2019/q1", true); len(policies) != 1 {
+		t.Errorf("expected policy p1, but got %v", policies)
+	}
+	if policies := cache.GetRelatedPolicyMap([]string{"user:alice"}, "/reports/2021/q1", true); len(policies) != 0 {
+		t.Errorf("expected no policies, but got %v", policies)
+	}
+	if !cache.matchGlob("/reports/{2019,2020}/*", "/reports/2020/q2") {
+		t.Errorf("/reports/2020/q2 should match the glob")
+	}
+
+	cache.DeletePolicyFromCache("p1")
+	if !cache.isEmpty() || len(cache.Globs) != 0 {
+		t.Errorf("the cache should be empty, but got %d globs", len(cache.Globs))
+	}
+}
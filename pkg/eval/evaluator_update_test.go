@@ -0,0 +1,150 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package eval
+
+import (
+	"testing"
+	"time"
+
+	adsapi "github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+)
+
+func TestUpdatePolicyInRuntimeCache(t *testing.T) {
+	preparePolicyDataInStore([]byte(`{"services": [
+		{"name": "library", "policies": [
+			{"id": "p1", "effect": "grant", "permissions": [{"resourceExpression": "/books/.*", "actions": ["read"]}], "principals": [["user:alice"]], "condition": "age > 18"}
+		], "rolePolicies": [
+			{"id": "rp1", "effect": "grant", "roles": ["reader"], "principals": ["user:alice"], "resources": ["/magazines"], "condition": "age > 18"}
+		]}
+	]}`), t)
+
+	updateConf := *conf
+	updateConf.EnableWatch = false
+	evaluator, err := NewWithStore(&updateConf, testPS)
+	if err != nil {
+		t.Fatalf("Unable to initialize evaluator due to error [%v].", err)
+	}
+	p := evaluator.(*PolicyEvalImpl)
+
+	check := func(user, resource string, age float64, allowed bool) {
+		t.Helper()
+		got, _, err := evaluator.IsAllowed(adsapi.RequestContext{
+			Subject:     &adsapi.Subject{Principals: []*adsapi.Principal{{Type: adsapi.PRINCIPAL_TYPE_USER, Name: user}}},
+			ServiceName: "library",
+			Resource:    resource,
+			Action:      "read",
+			Attributes:  map[string]interface{}{"age": age},
+		})
+		if err != nil {
+			t.Fatalf("Unexcepted error happened [%v].", err)
+		}
+		if got != allowed {
+			t.Errorf("%s reading %s with age %v: expected %v, but got %v", user, resource, age, allowed, got)
+		}
+	}
+	check("alice", "/books/1", 20, true)
+	check("bob", "/papers/1", 10, false)
+
+	// the principal, the resource and the condition are all replaced
+	p.AddPolicyInRuntimeCache("library", &pms.Policy{
+		ID:          "p1",
+		Effect:      pms.Grant,
+		Permissions: []*pms.Permission{{ResourceExpression: "/papers/.*", Actions: []string{"read"}}},
+		Principals:  [][]string{{"user:bob"}},
+	})
+	check("alice", "/books/1", 20, false)
+	check("bob", "/books/1", 20, false)
+	check("bob", "/papers/1", 10, true)
+
+	cache := p.RuntimePolicyStore.RuntimeServices["library"].PoliciesCache
+	if _, ok := cache.PrincipalToPolicies["user:alice"]; ok {
+		t.Error("the index of the old principal should be removed")
+	}
+	if _, ok := cache.Conditions["p1"]; ok {
+		t.Error("the old condition should be removed")
+	}
+	if _, ok := cache.Expressions["/books/.*"]; ok {
+		t.Error("the old resource expression should be removed")
+	}
+
+	// role policies are replaced in the same way
+	p.AddRolePolicyInRuntimeCache("library", &pms.RolePolicy{
+		ID:         "rp1",
+		Effect:     pms.Grant,
+		Roles:      []string{"reader"},
+		Principals: []string{"user:bob"},
+		Resources:  []string{"/papers"},
+	})
+	roleCache := p.RuntimePolicyStore.RuntimeServices["library"].RolePoliciesCache
+	if _, ok := roleCache.PrincipalToPolicies["user:alice"]; ok {
+		t.Error("the index of the old principal of the role policy should be removed")
+	}
+	if _, ok := roleCache.Conditions["rp1"]; ok {
+		t.Error("the old condition of the role policy should be removed")
+	}
+	if _, ok := roleCache.PrincipalToPolicies["user:bob"]; !ok {
+		t.Error("the new role policy should be indexed by its principal")
+	}
+}
+
+func TestApplyBatchInRuntimeCache(t *testing.T) {
+	preparePolicyDataInStore([]byte(`{"services": [
+		{"name": "library", "policies": [
+			{"id": "p1", "effect": "grant", "permissions": [{"resource": "/books", "actions": ["read"]}], "principals": [["user:alice"]]}
+		]}
+	]}`), t)
+
+	batchConf := *conf
+	batchConf.EnableWatch = false
+	evaluator, err := NewWithStore(&batchConf, testPS)
+	if err != nil {
+		t.Fatalf("Unable to initialize evaluator due to error [%v].", err)
+	}
+	p := evaluator.(*PolicyEvalImpl)
+
+	batch := pms.StoreChangeEvent{Type: pms.BATCH, Content: []pms.StoreChangeEvent{
+		{Type: pms.POLICY_DELETE, Content: []pms.StoreUpdateData{{ServiceName: "library", Data: &pms.Policy{ID: "p1"}}}},
+		{Type: pms.POLICY_ADD, Content: []pms.StoreUpdateData{{ServiceName: "library", Data: &pms.Policy{
+			ID:          "p2",
+			Effect:      pms.Grant,
+			Permissions: []*pms.Permission{{Resource: "/books", Actions: []string{"read"}}},
+			Principals:  [][]string{{"user:bob"}},
+		}}}},
+	}}
+
+	// the batch waits for the requests being evaluated, and none of its events is applied before the others
+	p.RuntimePolicyStore.RLock()
+	done := make(chan struct{})
+	go func() {
+		p.applyStoreChange(batch)
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("the batch should not be applied while a request is being evaluated")
+	case <-time.After(100 * time.Millisecond):
+	}
+	policies := p.RuntimePolicyStore.RuntimeServices["library"].PoliciesCache.PolicyMap
+	if policies["p1"] == nil || policies["p2"] != nil {
+		t.Errorf("no event of the batch should be applied, but got %v", policies)
+	}
+	p.RuntimePolicyStore.RUnlock()
+	<-done
+
+	for user, expected := range map[string]bool{"alice": false, "bob": true} {
+		allowed, _, err := evaluator.IsAllowed(adsapi.RequestContext{
+			Subject:     &adsapi.Subject{Principals: []*adsapi.Principal{{Type: adsapi.PRINCIPAL_TYPE_USER, Name: user}}},
+			ServiceName: "library",
+			Resource:    "/books",
+			Action:      "read",
+		})
+		if err != nil {
+			t.Fatalf("Unexcepted error happened [%v].", err)
+		}
+		if allowed != expected {
+			t.Errorf("%s: expected %v, but got %v", user, expected, allowed)
+		}
+	}
+}
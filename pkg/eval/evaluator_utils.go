@@ -4,18 +4,23 @@
 package eval
 
 import (
+	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/teramoby/speedle-plus/3rdparty/github.com/Knetic/govaluate"
 	adsapi "github.com/teramoby/speedle-plus/api/ads"
 	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/glob"
 	log "github.com/sirupsen/logrus"
 )
 
-func matchResource(requestRes string, resources, resExpressions []string) bool {
-	//in role policy, resources/resExpressions could be empty, which means any resource
-	if (resources == nil || len(resources) == 0) && (resExpressions == nil || len(resExpressions) == 0) {
+// matchResource checks if the resource matches the resources, resource expressions or resource globs of a role policy
+// in the cache
+func (p *BasePolicyCacheData) matchResource(requestRes string, resources, resExpressions, resGlobs []string) bool {
+	//in role policy, resources/resExpressions/resGlobs could be empty, which means any resource
+	if (resources == nil || len(resources) == 0) && (resExpressions == nil || len(resExpressions) == 0) && len(resGlobs) == 0 {
 		return true
 	}
 	for _, res := range resources {
@@ -24,16 +29,20 @@ func matchResource(requestRes string, resources, resExpressions []string) bool {
 		}
 	}
 	for _, resExp := range resExpressions {
-		matched, err := regexp.MatchString(resExp, requestRes)
-		if err == nil && matched {
+		if p.matchExpression(resExp, requestRes) {
+			return true
+		}
+	}
+	for _, resGlob := range resGlobs {
+		if p.matchGlob(resGlob, requestRes) {
 			return true
 		}
 	}
 	return false
 }
 
-// Returns if policy is matched
-func matchResourceAction(policy *pms.Policy, ctx *internalRequestContext) bool {
+// Returns if policy in the cache is matched
+func (p *BasePolicyCacheData) matchResourceAction(policy *pms.Policy, ctx *internalRequestContext) bool {
 	//we interpret nil or empty resource/permission/action/principal etc as ANY resource/permission/action/principal
 	if policy.Permissions == nil || len(policy.Permissions) == 0 { //any permissions
 		return true
@@ -41,11 +50,15 @@ func matchResourceAction(policy *pms.Policy, ctx *internalRequestContext) bool {
 	for _, perm := range policy.Permissions {
 		resExpMatch := false
 		if len(perm.ResourceExpression) != 0 {
-			resExpMatch, _ = regexp.MatchString(perm.ResourceExpression, ctx.Resource)
-			//TODO log error
+			resExpMatch = p.matchExpression(perm.ResourceExpression, ctx.Resource)
+		}
+		resGlobMatch := false
+		if len(perm.ResourceGlob) != 0 {
+			resGlobMatch = p.matchGlob(perm.ResourceGlob, ctx.Resource)
 		}
 		resNameMatch := perm.Resource == ctx.Resource
-		if (len(perm.Resource) == 0 && len(perm.ResourceExpression) == 0) || resExpMatch || resNameMatch {
+		if (len(perm.Resource) == 0 && len(perm.ResourceExpression) == 0 && len(perm.ResourceGlob) == 0) ||
+			resExpMatch || resGlobMatch || resNameMatch {
 			if perm.Actions == nil || len(perm.Actions) == 0 { //any action
 				return true
 			}
@@ -60,32 +73,92 @@ func matchResourceAction(policy *pms.Policy, ctx *internalRequestContext) bool {
 	return false
 }
 
-func denyOverwriteCombiner(grantedPolicies []*pms.Policy, deniedPolicies []*pms.Policy,
-	context *internalRequestContext, evaluationResult *adsapi.EvaluationResult) (bool, adsapi.Reason) {
-
-	if evaluationResult != nil {
-		evaluationResult.AddPolicies(grantedPolicies, deniedPolicies)
+// combiningAlgorithm returns the combining algorithm of the service, deny-overrides if not set
+func combiningAlgorithm(service *RuntimeService) string {
+	if len(service.CombiningAlgorithm) == 0 {
+		return pms.DenyOverrides
 	}
+	return service.CombiningAlgorithm
+}
 
-	// Evaluate denied policies first
-	if len(deniedPolicies) > 0 {
-		// If the number of matched denied policies is bigger than 0, then return false directly
-		return false, adsapi.DENY_POLICY_FOUND
+// defaultEffect returns the effect of the service when no policy applies
+func defaultEffect(service *RuntimeService) string {
+	switch service.CombiningAlgorithm {
+	case pms.DenyUnlessPermit:
+		return pms.Deny
+	case pms.PermitUnlessDeny:
+		return pms.Grant
+	}
+	if service.DefaultEffect == pms.Grant {
+		return pms.Grant
 	}
+	return pms.Deny
+}
+
+// sortPoliciesByPriority sorts the policies by priority in descending order, and by ID for the same priority
+func sortPoliciesByPriority(policies []*pms.Policy) {
+	sort.SliceStable(policies, func(i, j int) bool {
+		if policies[i].Priority != policies[j].Priority {
+			return policies[i].Priority > policies[j].Priority
+		}
+		return policies[i].ID < policies[j].ID
+	})
+}
+
+// combinePolicies decides the result with the combining algorithm of the service from the applicable policies.
+// If evaluationResult is not nil, the applicable policies and the explanation of the decision are added to it.
+func combinePolicies(service *RuntimeService, grantedPolicies []*pms.Policy, deniedPolicies []*pms.Policy,
+	evaluationResult *adsapi.EvaluationResult) (bool, adsapi.Reason) {
 
-	if len(grantedPolicies) == 0 {
-		// No granted policy defined, return false directly
-		// No need to check deny policies
-		return false, adsapi.NO_APPLICABLE_POLICIES
+	algorithm := combiningAlgorithm(service)
+	var policies []*pms.Policy
+	var explanation string
+	switch algorithm {
+	case pms.PermitOverrides, pms.DenyUnlessPermit:
+		// Evaluate granted policies first
+		policies = append(append(policies, grantedPolicies...), deniedPolicies...)
+		if len(grantedPolicies) > 0 {
+			explanation = "grant policies override deny policies"
+		} else {
+			explanation = "no grant policy applies"
+		}
+	case pms.FirstApplicable:
+		policies = append(append(policies, grantedPolicies...), deniedPolicies...)
+		sortPoliciesByPriority(policies)
+		explanation = fmt.Sprintf("it is the first one of %d applicable policies in priority order", len(policies))
+	default:
+		// Evaluate denied policies first
+		policies = append(append(policies, deniedPolicies...), grantedPolicies...)
+		if len(deniedPolicies) > 0 {
+			explanation = "deny policies override grant policies"
+		} else {
+			explanation = "no deny policy applies"
+		}
 	}
 
-	if len(grantedPolicies) > 0 {
-		// If the number of matched granted policies is bigger than 0, then return true directly
-		return true, adsapi.GRANT_POLICY_FOUND
+	var takeEffect *pms.Policy
+	if len(policies) > 0 {
+		takeEffect = policies[0]
+	}
+	if evaluationResult != nil {
+		evaluationResult.AddCombinedPolicies(policies, takeEffect)
+		evaluationResult.CombiningAlgorithm = algorithm
+		if takeEffect == nil {
+			evaluationResult.Explanation = fmt.Sprintf("%s: no policy applies, the default effect %s is used", algorithm, defaultEffect(service))
+		} else {
+			evaluationResult.Explanation = fmt.Sprintf("%s: %s policy %q with priority %d takes effect, %s",
+				algorithm, takeEffect.Effect, takeEffect.ID, takeEffect.Priority, explanation)
+		}
 	}
 
-	//should not go here
-	return false, adsapi.REASON_NOT_AVAILABLE
+	if takeEffect == nil {
+		// No applicable policy, the default effect is used
+		return defaultEffect(service) == pms.Grant, adsapi.NO_APPLICABLE_POLICIES
+	}
+	if takeEffect.Effect == pms.Deny {
+		return false, adsapi.DENY_POLICY_FOUND
+	}
+	return true, adsapi.GRANT_POLICY_FOUND
 }
 
 func updateSubjectWithBuiltInRoles(s *subject) {
@@ -123,21 +196,13 @@ func calculatePermissions(grantedPermissions, deniedPermissions []pms.Permission
 	var finalPermissions []pms.Permission
 	for _, permission := range grantedPermissions {
 		grantPermission := pms.Permission{
-			Resource: permission.Resource,
-			Actions:  permission.Actions,
+			Resource:     permission.Resource,
+			ResourceGlob: permission.ResourceGlob,
+			Actions:      permission.Actions,
 		}
 		isDenied := false
 		for _, deniedPermission := range deniedPermissions {
-			expMatched := false
-			if len(deniedPermission.ResourceExpression) > 0 {
-				matched, err := regexp.MatchString(deniedPermission.ResourceExpression, grantPermission.Resource)
-				if err != nil || matched {
-					//TODO: log err
-					expMatched = true
-				}
-			}
-
-			if (len(deniedPermission.Resource) == 0 && len(deniedPermission.ResourceExpression) == 0) || expMatched || strings.Compare(deniedPermission.Resource, grantPermission.Resource) == 0 {
+			if deniedResourceMatched(deniedPermission, grantPermission) {
 				//if resource match, then remove denied actions
 				var actions []string
 				for _, grantedAction := range grantPermission.Actions {
@@ -157,8 +222,9 @@ func calculatePermissions(grantedPermissions, deniedPermissions []pms.Permission
 					break
 				} else {
 					grantPermission = pms.Permission{
-						Resource: grantPermission.Resource,
-						Actions:  actions,
+						Resource:     grantPermission.Resource,
+						ResourceGlob: grantPermission.ResourceGlob,
+						Actions:      actions,
 					}
 				}
 			}
@@ -171,6 +237,38 @@ func calculatePermissions(grantedPermissions, deniedPermissions []pms.Permission
 
 }
 
+// deniedResourceMatched checks if the denied permission covers the resource, or the resource glob, of the granted
+// permission. A granted glob is covered if the denied resource expression or glob matches the glob itself, e.g.
+// /reports/** covers /reports/2019/*.
+func deniedResourceMatched(deniedPermission, grantPermission pms.Permission) bool {
+	if len(deniedPermission.Resource) == 0 && len(deniedPermission.ResourceExpression) == 0 && len(deniedPermission.ResourceGlob) == 0 {
+		return true
+	}
+	resource := grantPermission.Resource
+	if len(grantPermission.ResourceGlob) > 0 {
+		if deniedPermission.ResourceGlob == grantPermission.ResourceGlob {
+			return true
+		}
+		resource = grantPermission.ResourceGlob
+	} else if strings.Compare(deniedPermission.Resource, resource) == 0 {
+		return true
+	}
+	if len(deniedPermission.ResourceExpression) > 0 {
+		matched, err := regexp.MatchString(deniedPermission.ResourceExpression, resource)
+		if err != nil || matched {
+			//TODO: log err
+			return true
+		}
+	}
+	if len(deniedPermission.ResourceGlob) > 0 {
+		re, err := glob.Compile(deniedPermission.ResourceGlob)
+		if err != nil || re.MatchString(resource) {
+			return true
+		}
+	}
+	return false
+}
+
 func evaluateCondition(condition *govaluate.EvaluableExpression, attributes map[string]interface{}) (bool, error) {
 	res, err := condition.Evaluate(attributes)
 	if err != nil || res != true {
@@ -7,11 +7,22 @@ import (
 	"math"
 	"reflect"
 
+	"github.com/teramoby/speedle-plus/3rdparty/github.com/Knetic/govaluate"
 	"github.com/teramoby/speedle-plus/pkg/errors"
 )
 
 // Add all built-in functions in this file
 
+// Builtins are the built-in functions which could be used in conditions, by name
+var Builtins = map[string]govaluate.ExpressionFunction{
+	"Sqrt":     Sqrt,
+	"Max":      Max,
+	"Min":      Min,
+	"Sum":      Sum,
+	"Avg":      Avg,
+	"IsSubSet": IsSubSet,
+}
+
 func Sqrt(args ...interface{}) (interface{}, error) {
 	err := errors.New(errors.BuiltInFuncError, "Usage: Sqrt(x)")
 	if len(args) != 1 {
@@ -0,0 +1,680 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package eval
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/teramoby/speedle-plus/3rdparty/github.com/Knetic/govaluate"
+	adsapi "github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/glob"
+)
+
+// Kinds of the nodes of a condition
+const (
+	nodeValue = iota
+	nodeAttribute
+	nodeUnary
+	nodeBinary
+	nodeCall
+	nodeArray
+)
+
+// conditionNode is a node of the syntax tree of a condition parsed from its govaluate tokens. Subtrees without
+// unknown attributes are evaluated by govaluate from their tokens, so only the operators combining unknown
+// attributes are interpreted here.
+type conditionNode struct {
+	kind     int
+	op       string
+	operands []*conditionNode
+	// tokens are the tokens the node is parsed from
+	tokens []govaluate.ExpressionToken
+	// attribute is the name of an attribute node, nested fields are separated by dots
+	attribute string
+	// root is the attribute an attribute node refers to, e.g. a of a.b
+	root string
+}
+
+// attributes returns the attributes referred by the node and its operands
+func (n *conditionNode) attributes() []string {
+	var attributes []string
+	if n.kind == nodeAttribute {
+		attributes = append(attributes, n.root)
+	}
+	for _, operand := range n.operands {
+		attributes = append(attributes, operand.attributes()...)
+	}
+	return attributes
+}
+
+type conditionParser struct {
+	tokens []govaluate.ExpressionToken
+	pos    int
+}
+
+// parseCondition parses the tokens of a compiled condition with the precedence of govaluate
+func parseCondition(exp *govaluate.EvaluableExpression) (*conditionNode, error) {
+	p := conditionParser{tokens: exp.Tokens()}
+	node, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unexpected token %v", p.tokens[p.pos].Value)
+	}
+	return node, nil
+}
+
+// binaryLevels are the binary operators from the lowest precedence to the highest
+var binaryLevels = []struct {
+	kind      govaluate.TokenKind
+	operators map[string]bool
+}{
+	{govaluate.TERNARY, map[string]bool{"?": true, ":": true, "??": true}},
+	{govaluate.LOGICALOP, map[string]bool{"||": true}},
+	{govaluate.LOGICALOP, map[string]bool{"&&": true}},
+	{govaluate.COMPARATOR, map[string]bool{"==": true, "!=": true, ">": true, ">=": true, "<": true, "<=": true, "=~": true, "!~": true, "in": true}},
+	{govaluate.MODIFIER, map[string]bool{"&": true, "|": true, "^": true}},
+	{govaluate.MODIFIER, map[string]bool{">>": true, "<<": true}},
+	{govaluate.MODIFIER, map[string]bool{"+": true, "-": true}},
+	{govaluate.MODIFIER, map[string]bool{"*": true, "/": true, "%": true}},
+	{govaluate.MODIFIER, map[string]bool{"**": true}},
+}
+
+func (p *conditionParser) peek() (govaluate.ExpressionToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return govaluate.ExpressionToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *conditionParser) node(kind int, op string, start int, operands ...*conditionNode) *conditionNode {
+	return &conditionNode{kind: kind, op: op, operands: operands, tokens: p.tokens[start:p.pos]}
+}
+
+func (p *conditionParser) parseBinary(level int) (*conditionNode, error) {
+	if level == len(binaryLevels) {
+		return p.parsePrefix()
+	}
+	start := p.pos
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		token, ok := p.peek()
+		if !ok || token.Kind != binaryLevels[level].kind {
+			return left, nil
+		}
+		op := strings.ToLower(fmt.Sprint(token.Value))
+		if !binaryLevels[level].operators[op] {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = p.node(nodeBinary, op, start, left, right)
+	}
+}
+
+func (p *conditionParser) parsePrefix() (*conditionNode, error) {
+	start := p.pos
+	token, ok := p.peek()
+	if ok && token.Kind == govaluate.PREFIX {
+		p.pos++
+		operand, err := p.parsePrefix()
+		if err != nil {
+			return nil, err
+		}
+		return p.node(nodeUnary, fmt.Sprint(token.Value), start, operand), nil
+	}
+	return p.parseValue()
+}
+
+func (p *conditionParser) parseValue() (*conditionNode, error) {
+	start := p.pos
+	token, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of condition")
+	}
+	p.pos++
+	switch token.Kind {
+	case govaluate.NUMERIC, govaluate.STRING, govaluate.BOOLEAN, govaluate.PATTERN, govaluate.TIME:
+		return p.node(nodeValue, "", start), nil
+	case govaluate.VARIABLE:
+		node := p.node(nodeAttribute, "", start)
+		node.attribute = token.Value.(string)
+		node.root = node.attribute
+		return node, nil
+	case govaluate.ACCESSOR:
+		fields := token.Value.([]string)
+		node := p.node(nodeAttribute, "", start)
+		node.attribute = strings.Join(fields, ".")
+		node.root = fields[0]
+		return node, nil
+	case govaluate.FUNCTION:
+		if next, ok := p.peek(); !ok || next.Kind != govaluate.CLAUSE {
+			return nil, fmt.Errorf("no arguments of function")
+		}
+		p.pos++
+		args, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return p.node(nodeCall, "", start, args...), nil
+	case govaluate.CLAUSE:
+		items, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		if len(items) == 1 {
+			return items[0], nil
+		}
+		return p.node(nodeArray, "", start, items...), nil
+	}
+	return nil, fmt.Errorf("unexpected token %v", token.Value)
+}
+
+// parseList parses the comma separated items after a opening parenthesis until the closing one
+func (p *conditionParser) parseList() ([]*conditionNode, error) {
+	var items []*conditionNode
+	if token, ok := p.peek(); ok && token.Kind == govaluate.CLAUSE_CLOSE {
+		p.pos++
+		return items, nil
+	}
+	for {
+		// the separator has the lowest precedence, so the items are parsed from the ternary level
+		item, err := p.parseBinary(0)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		token, ok := p.peek()
+		if !ok {
+			return nil, fmt.Errorf("unbalanced parenthesis")
+		}
+		p.pos++
+		switch token.Kind {
+		case govaluate.SEPARATOR:
+			continue
+		case govaluate.CLAUSE_CLOSE:
+			return items, nil
+		}
+		return nil, fmt.Errorf("unexpected token %v", token.Value)
+	}
+}
+
+// partialValue is a node after partial evaluation, which is a known value, an unknown attribute, or a residual
+type partialValue struct {
+	known     bool
+	value     interface{}
+	attribute string
+	residual  *adsapi.Residual
+	// failed is true if the evaluation of a known part failed, the whole condition is false then like in IsAllowed
+	failed bool
+}
+
+// partialEvaluator evaluates conditions with known attributes, and leaves residuals of the unknown ones
+type partialEvaluator struct {
+	attributes map[string]interface{}
+	unknowns   map[string]bool
+}
+
+// residualOf partially evaluates a compiled condition, a nil condition is true
+func (e *partialEvaluator) residualOf(condition *govaluate.EvaluableExpression) (*adsapi.Residual, error) {
+	if condition == nil {
+		return residualBool(true), nil
+	}
+	node, err := parseCondition(condition)
+	if err != nil {
+		return nil, err
+	}
+	value, err := e.evaluate(node)
+	if err != nil {
+		return nil, err
+	}
+	if value.failed {
+		return residualBool(false), nil
+	}
+	if value.known {
+		// a condition is true only if its result is true, like in IsAllowed
+		return residualBool(value.value == true), nil
+	}
+	return e.boolResidual(value)
+}
+
+func (e *partialEvaluator) hasUnknown(node *conditionNode) bool {
+	for _, attribute := range node.attributes() {
+		if e.unknowns[attribute] {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *partialEvaluator) evaluate(node *conditionNode) (partialValue, error) {
+	if !e.hasUnknown(node) {
+		if node.kind == nodeValue {
+			return partialValue{known: true, value: node.tokens[0].Value}, nil
+		}
+		exp, err := govaluate.NewEvaluableExpressionFromTokens(node.tokens)
+		if err != nil {
+			return partialValue{failed: true}, nil
+		}
+		value, err := exp.Evaluate(e.attributes)
+		if err != nil {
+			return partialValue{failed: true}, nil
+		}
+		return partialValue{known: true, value: value}, nil
+	}
+
+	switch node.kind {
+	case nodeAttribute:
+		return partialValue{attribute: node.attribute}, nil
+	case nodeUnary:
+		if node.op != "!" {
+			break
+		}
+		operand, err := e.evaluate(node.operands[0])
+		if err != nil || operand.failed {
+			return operand, err
+		}
+		residual, err := e.boolResidual(operand)
+		if err != nil {
+			return partialValue{}, err
+		}
+		return partialValue{residual: residualNot(residual)}, nil
+	case nodeBinary:
+		switch node.op {
+		case "&&", "||":
+			return e.evaluateLogical(node)
+		case "==", "!=", ">", ">=", "<", "<=", "=~", "!~", "in":
+			return e.evaluateComparison(node)
+		}
+	}
+	return partialValue{}, fmt.Errorf("unsupported use of unknown attributes in %q", tokensString(node.tokens))
+}
+
+func (e *partialEvaluator) evaluateLogical(node *conditionNode) (partialValue, error) {
+	left, err := e.evaluate(node.operands[0])
+	if err != nil || left.failed {
+		return left, err
+	}
+	// the right operand isn't evaluated if the left one decides the result, like in govaluate
+	if left.known && left.value == (node.op == "||") {
+		return left, nil
+	}
+	right, err := e.evaluate(node.operands[1])
+	if err != nil || right.failed {
+		return right, err
+	}
+	leftResidual, err := e.boolResidual(left)
+	if err != nil {
+		return partialValue{}, err
+	}
+	rightResidual, err := e.boolResidual(right)
+	if err != nil {
+		return partialValue{}, err
+	}
+	if node.op == "&&" {
+		return partialValue{residual: residualAnd(leftResidual, rightResidual)}, nil
+	}
+	return partialValue{residual: residualOr(leftResidual, rightResidual)}, nil
+}
+
+// flippedComparators are the comparators with the operands swapped
+var flippedComparators = map[string]string{
+	"==": "==",
+	"!=": "!=",
+	">":  "<",
+	">=": "<=",
+	"<":  ">",
+	"<=": ">=",
+}
+
+func (e *partialEvaluator) evaluateComparison(node *conditionNode) (partialValue, error) {
+	left, err := e.evaluate(node.operands[0])
+	if err != nil || left.failed {
+		return left, err
+	}
+	right, err := e.evaluate(node.operands[1])
+	if err != nil || right.failed {
+		return right, err
+	}
+
+	op := node.op
+	if len(left.attribute) == 0 && len(right.attribute) != 0 {
+		flipped, ok := flippedComparators[op]
+		if !ok || !left.known {
+			return partialValue{}, fmt.Errorf("unsupported use of unknown attributes in %q", tokensString(node.tokens))
+		}
+		op, left, right = flipped, right, left
+	}
+	if len(left.attribute) == 0 {
+		return partialValue{}, fmt.Errorf("unsupported use of unknown attributes in %q", tokensString(node.tokens))
+	}
+
+	residual := &adsapi.Residual{Op: op, Attribute: left.attribute}
+	switch {
+	case len(right.attribute) != 0:
+		if _, ok := flippedComparators[op]; !ok {
+			return partialValue{}, fmt.Errorf("unsupported comparison of unknown attributes in %q", tokensString(node.tokens))
+		}
+		residual.OtherAttribute = right.attribute
+	case right.known:
+		switch op {
+		case "=~", "!~":
+			switch pattern := right.value.(type) {
+			case *regexp.Regexp:
+				residual.Value = pattern.String()
+			case string:
+				if _, err := regexp.Compile(pattern); err != nil {
+					return partialValue{failed: true}, nil
+				}
+				residual.Value = pattern
+			default:
+				return partialValue{failed: true}, nil
+			}
+		case "in":
+			values, ok := right.value.([]interface{})
+			if !ok {
+				return partialValue{failed: true}, nil
+			}
+			residual.Value = values
+		default:
+			residual.Value = right.value
+		}
+	default:
+		return partialValue{}, fmt.Errorf("unsupported use of unknown attributes in %q", tokensString(node.tokens))
+	}
+	return partialValue{residual: residual}, nil
+}
+
+// boolResidual returns the residual of a boolean, an unknown attribute used as a boolean is compared with true
+func (e *partialEvaluator) boolResidual(value partialValue) (*adsapi.Residual, error) {
+	switch {
+	case value.residual != nil:
+		return value.residual, nil
+	case len(value.attribute) != 0:
+		return &adsapi.Residual{Op: adsapi.ResidualEQ, Attribute: value.attribute, Value: true}, nil
+	}
+	b, ok := value.value.(bool)
+	if !ok {
+		return nil, fmt.Errorf("%v is used as a boolean", value.value)
+	}
+	return residualBool(b), nil
+}
+
+func tokensString(tokens []govaluate.ExpressionToken) string {
+	values := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		values = append(values, fmt.Sprint(token.Value))
+	}
+	return strings.Join(values, " ")
+}
+
+func residualBool(b bool) *adsapi.Residual {
+	if b {
+		return &adsapi.Residual{Op: adsapi.ResidualTrue}
+	}
+	return &adsapi.Residual{Op: adsapi.ResidualFalse}
+}
+
+func residualNot(r *adsapi.Residual) *adsapi.Residual {
+	switch r.Op {
+	case adsapi.ResidualTrue:
+		return residualBool(false)
+	case adsapi.ResidualFalse:
+		return residualBool(true)
+	case adsapi.ResidualNot:
+		return r.Operands[0]
+	}
+	return &adsapi.Residual{Op: adsapi.ResidualNot, Operands: []*adsapi.Residual{r}}
+}
+
+func residualAnd(a, b *adsapi.Residual) *adsapi.Residual {
+	return residualJunction(adsapi.ResidualAnd, adsapi.ResidualTrue, adsapi.ResidualFalse, a, b)
+}
+
+func residualOr(a, b *adsapi.Residual) *adsapi.Residual {
+	return residualJunction(adsapi.ResidualOr, adsapi.ResidualFalse, adsapi.ResidualTrue, a, b)
+}
+
+// residualJunction returns the and, or or of two residuals, the identity operands are dropped, the absorbing
+// ones decide the result, and the nested junctions of the same operator are flattened
+func residualJunction(op, identity, absorbing string, a, b *adsapi.Residual) *adsapi.Residual {
+	switch {
+	case a.Op == absorbing || b.Op == absorbing:
+		return &adsapi.Residual{Op: absorbing}
+	case a.Op == identity:
+		return b
+	case b.Op == identity:
+		return a
+	}
+	junction := &adsapi.Residual{Op: op}
+	for _, r := range []*adsapi.Residual{a, b} {
+		if r.Op == op {
+			junction.Operands = append(junction.Operands, r.Operands...)
+		} else {
+			junction.Operands = append(junction.Operands, r)
+		}
+	}
+	return junction
+}
+
+// PartialEvaluate resolves the roles of the subject and matches the policies like IsAllowed, but the attributes
+// in unknowns are not known, e.g. the columns of the rows to filter. It returns the residual condition on the
+// unknown attributes under which the applicable policies allow the action, combined with the combining algorithm
+// of the service. The resource is matched like in IsAllowed, unless request_resource is one of the unknowns. The
+// resource is unknown then, and the resource of the context is a glob pattern of the resources to filter, or empty
+// for any resource. The permissions of the policies become residuals on request_resource, and the pattern is
+// required by the result. The role policies applicable to the subject must not depend on the unknown attributes,
+// or on the resource if it is unknown.
+func (p *PolicyEvalImpl) PartialEvaluate(ctx adsapi.RequestContext, unknowns []string) (*adsapi.PartialEvaluation, error) {
+	p.RuntimePolicyStore.RLock()
+	defer p.RuntimePolicyStore.RUnlock()
+	newCtx, err := p.populateContext(&ctx)
+	if err != nil {
+		return nil, err
+	}
+	unknownMap := make(map[string]bool)
+	for _, unknown := range unknowns {
+		unknownMap[unknown] = true
+		delete(newCtx.Attributes, unknown)
+	}
+	patternResidual := residualBool(true)
+	resourceUnknown := unknownMap[adsapi.BuiltIn_Attr_RequestResource]
+	if resourceUnknown {
+		if len(newCtx.Resource) != 0 {
+			expression, err := glob.Regexp(newCtx.Resource)
+			if err != nil {
+				return nil, err
+			}
+			patternResidual = &adsapi.Residual{Op: adsapi.ResidualRegex, Attribute: adsapi.BuiltIn_Attr_RequestResource, Value: expression}
+		}
+		// the role policies restricted to resources are rejected by checkRolePolicyConditions, so no role
+		// depends on the pattern
+		newCtx.Resource = ""
+	}
+
+	newCtx.Service.RLock()
+	defer newCtx.Service.RUnlock()
+	roles, err := p.getGrantedRolesFromService(newCtx, nil)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(roles)
+	for _, role := range roles {
+		newCtx.Subject.Principals = append(newCtx.Subject.Principals, convertRoleToPrincipal(role))
+	}
+	if err := p.checkRolePolicyConditions(newCtx, unknownMap); err != nil {
+		return nil, err
+	}
+
+	evaluator := partialEvaluator{attributes: newCtx.Attributes, unknowns: unknownMap}
+	var policies []*pms.Policy
+	residuals := make(map[string]*adsapi.Residual)
+	principals := newCtx.Subject.Principals
+	for _, policy := range newCtx.Service.GetRelatedPolicyMap(principals, newCtx.Resource, !resourceUnknown) {
+		if len(policy.Principals) != 0 && !matchPrincipals(principals, policy.Principals) {
+			continue
+		}
+		resourceResidual := residualBool(true)
+		if resourceUnknown {
+			resourceResidual = resourceResidualOf(policy, newCtx.Action)
+		} else if !newCtx.Service.PoliciesCache.matchResourceAction(policy, newCtx) {
+			resourceResidual = residualBool(false)
+		}
+		if resourceResidual.Op == adsapi.ResidualFalse {
+			continue
+		}
+		condition, err := compileCondition(policy.Condition, p.RuntimePolicyStore.Functions)
+		if err != nil {
+			return nil, err
+		}
+		residual, err := evaluator.residualOf(condition)
+		if err != nil {
+			return nil, errors.Wrapf(err, errors.InvalidRequest, "unable to partially evaluate the condition of policy %q", policy.ID)
+		}
+		residual = residualAnd(resourceResidual, residual)
+		if residual.Op == adsapi.ResidualFalse {
+			continue
+		}
+		policies = append(policies, policy)
+		residuals[policy.ID] = residual
+	}
+
+	return &adsapi.PartialEvaluation{
+		Residual:     residualAnd(patternResidual, combineResiduals(newCtx.Service, policies, residuals)),
+		GrantedRoles: roles,
+	}, nil
+}
+
+// resourceResidualOf returns the residual on request_resource under which a permission of the policy allows the
+// action, the resource expressions and globs are matched as regular expressions like in IsAllowed
+func resourceResidualOf(policy *pms.Policy, action string) *adsapi.Residual {
+	if len(policy.Permissions) == 0 {
+		return residualBool(true)
+	}
+	result := residualBool(false)
+	for _, perm := range policy.Permissions {
+		actionMatch := len(perm.Actions) == 0
+		for _, act := range perm.Actions {
+			actionMatch = actionMatch || act == action
+		}
+		if !actionMatch {
+			continue
+		}
+		if len(perm.Resource) == 0 && len(perm.ResourceExpression) == 0 && len(perm.ResourceGlob) == 0 {
+			return residualBool(true)
+		}
+		if len(perm.Resource) != 0 {
+			result = residualOr(result, &adsapi.Residual{Op: adsapi.ResidualEQ, Attribute: adsapi.BuiltIn_Attr_RequestResource, Value: perm.Resource})
+		}
+		// an invalid expression or glob matches nothing
+		if _, err := regexp.Compile(perm.ResourceExpression); len(perm.ResourceExpression) != 0 && err == nil {
+			result = residualOr(result, &adsapi.Residual{Op: adsapi.ResidualRegex, Attribute: adsapi.BuiltIn_Attr_RequestResource, Value: perm.ResourceExpression})
+		}
+		if expression, err := glob.Regexp(perm.ResourceGlob); len(perm.ResourceGlob) != 0 && err == nil {
+			result = residualOr(result, &adsapi.Residual{Op: adsapi.ResidualRegex, Attribute: adsapi.BuiltIn_Attr_RequestResource, Value: expression})
+		}
+	}
+	return result
+}
+
+// combineResiduals combines the residuals of the policies in the order combinePolicies takes them, the first
+// policy whose residual holds takes effect, and the default effect is used if none holds
+func combineResiduals(service *RuntimeService, policies []*pms.Policy, residuals map[string]*adsapi.Residual) *adsapi.Residual {
+	switch combiningAlgorithm(service) {
+	case pms.FirstApplicable:
+		sortPoliciesByPriority(policies)
+	default:
+		grantFirst := combiningAlgorithm(service) == pms.PermitOverrides || combiningAlgorithm(service) == pms.DenyUnlessPermit
+		sort.SliceStable(policies, func(i, j int) bool {
+			if policies[i].Effect != policies[j].Effect {
+				return (policies[i].Effect == pms.Grant) == grantFirst
+			}
+			return policies[i].ID < policies[j].ID
+		})
+	}
+
+	result := residualBool(defaultEffect(service) == pms.Grant)
+	for i := len(policies) - 1; i >= 0; i-- {
+		residual := residuals[policies[i].ID]
+		if policies[i].Effect == pms.Deny {
+			result = residualAnd(residualNot(residual), result)
+		} else {
+			result = residualOr(residual, result)
+		}
+	}
+	return result
+}
+
+// checkRolePolicyConditions returns an error if the condition of a role policy applicable to the subject refers to
+// the unknown attributes, or the role policy is restricted to resources and the resource is unknown, the roles
+// granted or denied by it would depend on them
+func (p *PolicyEvalImpl) checkRolePolicyConditions(ctx *internalRequestContext, unknowns map[string]bool) error {
+	if len(unknowns) == 0 {
+		return nil
+	}
+	principals := make(map[string]bool)
+	for _, principal := range ctx.Subject.Principals {
+		principals[principal] = true
+	}
+	if ctx.GlobalService != nil {
+		ctx.GlobalService.RLock()
+		defer ctx.GlobalService.RUnlock()
+	}
+	resourceUnknown := unknowns[adsapi.BuiltIn_Attr_RequestResource]
+	for _, service := range []*RuntimeService{ctx.Service, ctx.GlobalService} {
+		if service == nil {
+			continue
+		}
+		for _, rolePolicy := range service.RolePoliciesCache.PolicyMap {
+			restricted := len(rolePolicy.Resources) != 0 || len(rolePolicy.ResourceExpressions) != 0 || len(rolePolicy.ResourceGlobs) != 0
+			if resourceUnknown {
+				if !restricted && len(rolePolicy.Condition) == 0 {
+					continue
+				}
+			} else if len(rolePolicy.Condition) == 0 ||
+				!service.RolePoliciesCache.matchResource(ctx.Resource, rolePolicy.Resources, rolePolicy.ResourceExpressions, rolePolicy.ResourceGlobs) {
+				continue
+			}
+			applicable := len(rolePolicy.Principals) == 0
+			for _, principal := range rolePolicy.Principals {
+				applicable = applicable || principals[principal]
+			}
+			if !applicable {
+				continue
+			}
+			if resourceUnknown && restricted {
+				return errors.Errorf(errors.InvalidRequest, "the role policy %q is restricted to resources, so it depends on the unknown resource", rolePolicy.ID)
+			}
+			if len(rolePolicy.Condition) == 0 {
+				continue
+			}
+			condition, err := compileCondition(rolePolicy.Condition, p.RuntimePolicyStore.Functions)
+			if err != nil {
+				return err
+			}
+			node, err := parseCondition(condition)
+			if err != nil {
+				return errors.Wrapf(err, errors.InvalidRequest, "unable to parse the condition of role policy %q", rolePolicy.ID)
+			}
+			for _, attribute := range node.attributes() {
+				if unknowns[attribute] {
+					return errors.Errorf(errors.InvalidRequest, "the condition of role policy %q depends on the unknown attribute %q", rolePolicy.ID, attribute)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package eval
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	adsapi "github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+)
+
+func TestPartialEvaluate(t *testing.T) {
+	preparePolicyDataInStore([]byte(`{"services": [
+		{"name": "books",
+		 "policies": [
+			{"id": "p1", "effect": "grant", "permissions": [{"resource": "/books", "actions": ["read"]}], "principals": [["user:alice"]], "condition": "owner == request_user"},
+			{"id": "p2", "effect": "grant", "permissions": [{"resource": "/books", "actions": ["read"]}], "principals": [["role:editor"]], "condition": "region in ('us', 'eu') && published"},
+			{"id": "p3", "effect": "deny", "permissions": [{"resource": "/books", "actions": ["read"]}], "principals": [["user:alice"]], "condition": "classified == true"},
+			{"id": "p4", "effect": "grant", "permissions": [{"resource": "/books", "actions": ["read"]}], "principals": [["user:alice"]], "condition": "age > 18"}
+		 ],
+		 "rolePolicies": [
+			{"id": "rp1", "effect": "grant", "roles": ["editor"], "principals": ["user:alice"]},
+			{"id": "rp2", "effect": "grant", "roles": ["editor"], "principals": ["user:carol"], "condition": "region == 'us'"}
+		 ]},
+		{"name": "docs",
+		 "policies": [
+			{"id": "p1", "effect": "grant", "permissions": [{"resourceGlob": "/docs/**", "actions": ["read"]}], "principals": [["user:alice"]], "condition": "18 < level || owner == creator"},
+			{"id": "p2", "effect": "grant", "permissions": [{"resource": "/docs/*", "actions": ["read"]}], "principals": [["user:alice"]], "condition": "owner + 'x' == 'ax'"}
+		 ]},
+		{"name": "library",
+		 "policies": [
+			{"id": "p1", "effect": "grant", "permissions": [{"resource": "/library/a", "actions": ["read"]}], "principals": [["user:alice"]]},
+			{"id": "p2", "effect": "grant", "permissions": [{"resourceGlob": "/library/{x,y}/*", "actions": ["read"]}], "principals": [["user:alice"]], "condition": "owner == request_user"},
+			{"id": "p3", "effect": "deny", "permissions": [{"resourceExpression": "^/library/secret", "actions": ["read"]}], "principals": [["user:alice"]]},
+			{"id": "p4", "effect": "grant", "permissions": [{"resource": "/library/b", "actions": ["write"]}], "principals": [["user:alice"]]}
+		 ],
+		 "rolePolicies": [
+			{"id": "rp1", "effect": "grant", "roles": ["reader"], "principals": ["user:dave"], "resources": ["/library/a"]}
+		 ]}
+	]}`), t)
+
+	evaluator, err := NewWithStore(conf, testPS)
+	if err != nil {
+		t.Fatalf("Unable to initialize evaluator due to error [%v].", err)
+	}
+	requestOf := func(user, service, resource string) adsapi.RequestContext {
+		return adsapi.RequestContext{
+			Subject:     &adsapi.Subject{Principals: []*adsapi.Principal{{Type: adsapi.PRINCIPAL_TYPE_USER, Name: user}}},
+			ServiceName: service,
+			Resource:    resource,
+			Action:      "read",
+			Attributes:  map[string]interface{}{"age": float64(10)},
+		}
+	}
+	unknowns := []string{"owner", "region", "published", "classified"}
+
+	result, err := evaluator.PartialEvaluate(requestOf("alice", "books", "/books"), unknowns)
+	if err != nil {
+		t.Fatalf("Unexcepted error happened [%v].", err)
+	}
+	if !reflect.DeepEqual(result.GrantedRoles, []string{"editor"}) {
+		t.Errorf("expected granted roles [editor], but got %v", result.GrantedRoles)
+	}
+	where, args, err := result.Residual.ToSQL(adsapi.SQLDialectPostgres)
+	if err != nil {
+		t.Fatalf("Unexcepted error happened [%v].", err)
+	}
+	expectedWhere := `(NOT ("classified" = $1)) AND (("owner" = $2) OR (("region" IN ($3, $4)) AND ("published" = $5)))`
+	if where != expectedWhere {
+		t.Errorf("expected where clause %s, but got %s", expectedWhere, where)
+	}
+	if expectedArgs := []interface{}{true, "alice", "us", "eu", true}; !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected arguments %v, but got %v", expectedArgs, args)
+	}
+	filter, err := result.Residual.ToMongo()
+	if err != nil {
+		t.Fatalf("Unexcepted error happened [%v].", err)
+	}
+	filterJSON, _ := json.Marshal(filter)
+	expectedFilter := `{"$and":[{"$nor":[{"classified":{"$eq":true}}]},{"$or":[{"owner":{"$eq":"alice"}},{"$and":[{"region":{"$in":["us","eu"]}},{"published":{"$eq":true}}]}]}]}`
+	if string(filterJSON) != expectedFilter {
+		t.Errorf("expected filter %s, but got %s", expectedFilter, filterJSON)
+	}
+
+	// a known attribute decides the condition of p4
+	older := requestOf("alice", "books", "/books")
+	older.Attributes["age"] = float64(20)
+	result, err = evaluator.PartialEvaluate(older, unknowns)
+	if err != nil {
+		t.Fatalf("Unexcepted error happened [%v].", err)
+	}
+	if where, _, _ := result.Residual.ToSQL(""); where != `NOT ("classified" = ?)` {
+		t.Errorf("unexpected where clause %s", where)
+	}
+
+	// no policy applies to bob
+	result, err = evaluator.PartialEvaluate(requestOf("bob", "books", "/books"), unknowns)
+	if err != nil {
+		t.Fatalf("Unexcepted error happened [%v].", err)
+	}
+	if result.Residual.Op != adsapi.ResidualFalse {
+		t.Errorf("expected false, but got %+v", result.Residual)
+	}
+
+	// the role of carol depends on an unknown attribute
+	if _, err = evaluator.PartialEvaluate(requestOf("carol", "books", "/books"), unknowns); errors.Code(err) != errors.InvalidRequest {
+		t.Errorf("expected an invalid request error, but got %v", err)
+	}
+
+	// the comparisons are flipped to put the unknown attributes on the left
+	result, err = evaluator.PartialEvaluate(requestOf("alice", "docs", "/docs/*"), []string{"level", "owner", "creator"})
+	if errors.Code(err) != errors.InvalidRequest {
+		t.Errorf("expected an invalid request error of unsupported condition, but got %v", err)
+	}
+	result, err = evaluator.PartialEvaluate(requestOf("alice", "docs", "/docs/2019/*"), []string{"level", "owner", "creator"})
+	if err != nil {
+		t.Fatalf("Unexcepted error happened [%v].", err)
+	}
+	where, _, err = result.Residual.ToSQL(adsapi.SQLDialectMySQL)
+	if err != nil {
+		t.Fatalf("Unexcepted error happened [%v].", err)
+	}
+	if expected := "(`level` > ?) OR (`owner` = `creator`)"; where != expected {
+		t.Errorf("expected where clause %s, but got %s", expected, where)
+	}
+	filter, _ = result.Residual.ToMongo()
+	filterJSON, _ = json.Marshal(filter)
+	if expected := `{"$or":[{"level":{"$gt":18}},{"$expr":{"$eq":["$owner","$creator"]}}]}`; string(filterJSON) != expected {
+		t.Errorf("expected filter %s, but got %s", expected, filterJSON)
+	}
+
+	// the resource is unknown, and the resource of the context is a pattern of the resources to filter
+	unknowns = []string{adsapi.BuiltIn_Attr_RequestResource, "owner"}
+	result, err = evaluator.PartialEvaluate(requestOf("alice", "library", "/library/**"), unknowns)
+	if err != nil {
+		t.Fatalf("Unexcepted error happened [%v].", err)
+	}
+	where, args, err = result.Residual.ToSQL(adsapi.SQLDialectPostgres)
+	if err != nil {
+		t.Fatalf("Unexcepted error happened [%v].", err)
+	}
+	expectedWhere = `("request_resource" ~ $1) AND (NOT ("request_resource" ~ $2)) AND (("request_resource" = $3) OR (("request_resource" ~ $4) AND ("owner" = $5)))`
+	if where != expectedWhere {
+		t.Errorf("expected where clause %s, but got %s", expectedWhere, where)
+	}
+	if expectedArgs := []interface{}{"^/library(?:/.*)?$", "^/library/secret", "/library/a", "^/library/(?:x|y)/[^/]*$", "alice"}; !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected arguments %v, but got %v", expectedArgs, args)
+	}
+
+	// an empty pattern is any resource
+	result, err = evaluator.PartialEvaluate(requestOf("alice", "library", ""), unknowns)
+	if err != nil {
+		t.Fatalf("Unexcepted error happened [%v].", err)
+	}
+	if where, _, _ = result.Residual.ToSQL(""); where != `(NOT ("request_resource" REGEXP ?)) AND (("request_resource" = ?) OR (("request_resource" REGEXP ?) AND ("owner" = ?)))` {
+		t.Errorf("unexpected where clause %s", where)
+	}
+
+	// the role of dave depends on the resource
+	if _, err = evaluator.PartialEvaluate(requestOf("dave", "library", "/library/**"), unknowns); errors.Code(err) != errors.InvalidRequest {
+		t.Errorf("expected an invalid request error, but got %v", err)
+	}
+	if _, err = evaluator.PartialEvaluate(requestOf("alice", "library", "/library/{a"), unknowns); errors.Code(err) != errors.InvalidRequest {
+		t.Errorf("expected an invalid request error of invalid pattern, but got %v", err)
+	}
+}
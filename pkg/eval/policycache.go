@@ -4,10 +4,6 @@
 package eval
 
 import (
-	"regexp"
-
-	log "github.com/sirupsen/logrus"
-
 	"github.com/teramoby/speedle-plus/3rdparty/github.com/Knetic/govaluate"
 	"github.com/teramoby/speedle-plus/api/pms"
 )
@@ -23,6 +19,8 @@ func NewPolicyCacheData() (p *PolicyCacheData) {
 			PrincipalToPolicies:    make(map[string]*ResourceToPolicyMap),
 			NilPrincipalToPolicies: &ResourceToPolicyMap{},
 			Conditions:             make(map[string]*govaluate.EvaluableExpression),
+			Expressions:            make(map[string]*compiledExpression),
+			Globs:                  make(map[string]*compiledExpression),
 		},
 		PolicyMap: make(map[string]*pms.Policy),
 	}
@@ -36,12 +34,24 @@ func nilPrincipalPolicy(policy *pms.Policy) (result bool) {
 	return false
 }
 
+// AddPolicyToCache adds a policy to the cache, the existing one with the same ID is replaced
 func (p *PolicyCacheData) AddPolicyToCache(policy *pms.Policy, condition *govaluate.EvaluableExpression) {
+	// Remove the existing one first, so none of its index entries, condition or expressions is left behind
+	p.DeletePolicyFromCache(policy.ID)
+
 	//First add to PolicyMap
 	p.PolicyMap[policy.ID] = policy
 	if condition != nil {
 		p.Conditions[policy.ID] = condition
 	}
+	for _, permission := range policy.Permissions {
+		if permission.ResourceExpression != "" {
+			p.addExpression(permission.ResourceExpression)
+		}
+		if permission.ResourceGlob != "" {
+			p.addGlob(permission.ResourceGlob)
+		}
+	}
 
 	//No principal defined. that means the permissions are granted to any principal
 	if nilPrincipalPolicy(policy) {
@@ -75,42 +85,27 @@ func (p *PolicyCacheData) addPolicyToResourceToPolicyMap(resourceToPolicyMap *Re
 
 	//Nil permissions
 	if policy.Permissions == nil || len(policy.Permissions) == 0 {
-		if resourceToPolicyMap.NilResourceToPolicies == nil {
-			resourceToPolicyMap.NilResourceToPolicies = make(map[string]bool)
-		}
-		resourceToPolicyMap.NilResourceToPolicies[policy.ID] = true
+		resourceToPolicyMap.addNilResource(policy.ID)
 		return
 	}
 
 	for _, permission := range policy.Permissions {
 
-		if permission.Resource == "" && permission.ResourceExpression == "" {
-			if resourceToPolicyMap.NilResourceToPolicies == nil {
-				resourceToPolicyMap.NilResourceToPolicies = make(map[string]bool)
-			}
-			resourceToPolicyMap.NilResourceToPolicies[policy.ID] = true
+		if permission.Resource == "" && permission.ResourceExpression == "" && permission.ResourceGlob == "" {
+			resourceToPolicyMap.addNilResource(policy.ID)
 		}
 
 		if permission.Resource != "" {
-			if resourceToPolicyMap.ResourceToPolicies == nil {
-				resourceToPolicyMap.ResourceToPolicies = make(map[string]map[string]bool)
-				policyIDSet := make(map[string]bool)
-				policyIDSet[policy.ID] = true
-				resourceToPolicyMap.ResourceToPolicies[permission.Resource] = policyIDSet
-			} else {
-				if policyIDSet, exist := resourceToPolicyMap.ResourceToPolicies[permission.Resource]; exist {
-					policyIDSet[policy.ID] = true
-				} else {
-					policyIDSet := make(map[string]bool)
-					policyIDSet[policy.ID] = true
-					resourceToPolicyMap.ResourceToPolicies[permission.Resource] = policyIDSet
-				}
-			}
+			resourceToPolicyMap.addResource(permission.Resource, policy.ID)
 		}
 
 		if permission.ResourceExpression != "" {
 			AddPolicyToResourceExpressionCache(resourceToPolicyMap, permission.ResourceExpression, policy.ID)
 		}
+
+		if permission.ResourceGlob != "" {
+			AddPolicyToResourceGlobCache(resourceToPolicyMap, permission.ResourceGlob, policy.ID)
+		}
 	}
 }
 
@@ -123,8 +118,15 @@ func (p *PolicyCacheData) DeletePolicyFromCache(policyID string) {
 		return
 	}
 	delete(p.PolicyMap, policyID)
-	if len(policy.Condition) > 0 { //remove related condition cache
-		delete(p.Conditions, policyID)
+	// remove related condition cache, the condition may have been compiled after the policy was added
+	delete(p.Conditions, policyID)
+	for _, permission := range policy.Permissions {
+		if permission.ResourceExpression != "" {
+			p.deleteExpression(permission.ResourceExpression)
+		}
+		if permission.ResourceGlob != "" {
+			p.deleteGlob(permission.ResourceGlob)
+		}
 	}
 
 	if nilPrincipalPolicy(policy) {
@@ -151,32 +153,27 @@ func (p *PolicyCacheData) deletePolicyFromResourceToPolicyMap(resourceToPolicyMa
 
 	//Nil permissions policy
 	if policy.Permissions == nil || len(policy.Permissions) == 0 {
-		if resourceToPolicyMap.NilResourceToPolicies != nil {
-			delete(resourceToPolicyMap.NilResourceToPolicies, policy.ID)
-		}
+		resourceToPolicyMap.deleteNilResource(policy.ID)
 		return
 	}
 
 	for _, permission := range policy.Permissions {
 
-		if permission.Resource == "" && permission.ResourceExpression == "" &&
-			resourceToPolicyMap.NilResourceToPolicies != nil {
-			delete(resourceToPolicyMap.NilResourceToPolicies, policy.ID)
+		if permission.Resource == "" && permission.ResourceExpression == "" && permission.ResourceGlob == "" {
+			resourceToPolicyMap.deleteNilResource(policy.ID)
 		}
 
-		if permission.Resource != "" &&
-			resourceToPolicyMap.ResourceToPolicies != nil {
-			if policyIDSet, exist := resourceToPolicyMap.ResourceToPolicies[permission.Resource]; exist {
-				delete(policyIDSet, policy.ID)
-				if len(policyIDSet) == 0 {
-					delete(resourceToPolicyMap.ResourceToPolicies, permission.Resource)
-				}
-			}
+		if permission.Resource != "" {
+			resourceToPolicyMap.deleteResource(permission.Resource, policy.ID)
 		}
 
 		if permission.ResourceExpression != "" {
 			DeletePolicyFromResourceExpressionCache(resourceToPolicyMap, permission.ResourceExpression, policy.ID)
 		}
+
+		if permission.ResourceGlob != "" {
+			DeletePolicyFromResourceGlobCache(resourceToPolicyMap, permission.ResourceGlob, policy.ID)
+		}
 	}
 }
 
@@ -202,103 +199,14 @@ func (p *PolicyCacheData) GetRelatedPolicyMap(subjectPrincipals []string, resour
 }
 
 func (p *PolicyCacheData) getPolicyFromResourceToPolicyMap(resourceToPolicyMap *ResourceToPolicyMap, resultPolicyMap map[string]*pms.Policy, resource string, matchResource bool) {
-
-	//First add nil resource policies
-	if resourceToPolicyMap.NilResourceToPolicies != nil {
-		for id := range resourceToPolicyMap.NilResourceToPolicies {
-			resultPolicyMap[id] = p.PolicyMap[id]
-		}
+	addPolicy := func(id string) {
+		resultPolicyMap[id] = p.PolicyMap[id]
 	}
 
 	if matchResource {
-		//Check resource policy map
-		if resourceToPolicyMap.ResourceToPolicies != nil {
-			if policyIDSet, ok := resourceToPolicyMap.ResourceToPolicies[resource]; ok {
-				//Add all related policies to result policy map
-				for id := range policyIDSet {
-					resultPolicyMap[id] = p.PolicyMap[id]
-				}
-			}
-		}
-
-		//Check resource expression policy map
-		p.getPoliciesFromResourceExpressionMap(resourceToPolicyMap, resultPolicyMap, resource, true)
+		resourceToPolicyMap.matchPolicies(resource, addPolicy)
 	} else {
 		//Do not neet match resouce, that mean return all the policies already matched the principal
-		if resourceToPolicyMap.ResourceToPolicies != nil {
-			for _, policyIDSet := range resourceToPolicyMap.ResourceToPolicies {
-				//Add all related policies to result policy map
-				for id := range policyIDSet {
-					resultPolicyMap[id] = p.PolicyMap[id]
-				}
-			}
-		}
-
-		p.getPoliciesFromResourceExpressionMap(resourceToPolicyMap, resultPolicyMap, resource, false)
+		resourceToPolicyMap.allPolicies(addPolicy)
 	}
 }
-
-func (p *PolicyCacheData) getPoliciesFromResourceExpressionMap(resourceToPolicyMap *ResourceToPolicyMap, resultPolicyMap map[string]*pms.Policy, resource string, matchResource bool) {
-
-	if matchResource {
-
-		fn := func(s string, v interface{}) bool {
-			policyIDSet := v.(map[string]bool)
-			for id := range policyIDSet {
-				resultPolicyMap[id] = p.PolicyMap[id]
-			}
-
-			return false
-		}
-		if resourceToPolicyMap.PrefixResourceExpressionTree != nil {
-			resourceToPolicyMap.PrefixResourceExpressionTree.WalkPath(resource, fn)
-		}
-
-		if resourceToPolicyMap.SuffixResourceExpressionTree != nil {
-			resourceToPolicyMap.SuffixResourceExpressionTree.WalkPath(ReverseString(resource), fn)
-		}
-
-		if resourceToPolicyMap.ResourceExpressionToPolicies != nil {
-			for resExp, policyIDSet := range resourceToPolicyMap.ResourceExpressionToPolicies {
-				matched, err := regexp.MatchString(resExp, resource)
-				if err != nil {
-					log.Errorf("Meet error when match the resource expression in poliy. err: %s", err)
-					continue
-				}
-				if matched {
-					//Add all related policies to result policy map
-					for id := range policyIDSet {
-						resultPolicyMap[id] = p.PolicyMap[id]
-					}
-				}
-			}
-		}
-	} else {
-
-		fn := func(s string, v interface{}) bool {
-			policyIDSet := v.(map[string]bool)
-			for id := range policyIDSet {
-				resultPolicyMap[id] = p.PolicyMap[id]
-			}
-
-			return false
-		}
-		if resourceToPolicyMap.PrefixResourceExpressionTree != nil {
-			resourceToPolicyMap.PrefixResourceExpressionTree.Walk(fn)
-		}
-
-		if resourceToPolicyMap.SuffixResourceExpressionTree != nil {
-			resourceToPolicyMap.SuffixResourceExpressionTree.Walk(fn)
-		}
-
-		if resourceToPolicyMap.ResourceExpressionToPolicies != nil {
-			for _, policyIDSet := range resourceToPolicyMap.ResourceExpressionToPolicies {
-				//Add all related policies to result policy map
-				for id := range policyIDSet {
-					resultPolicyMap[id] = p.PolicyMap[id]
-				}
-			}
-		}
-	}
-
-}
@@ -0,0 +1,428 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package eval
+
+import (
+	"regexp/syntax"
+	"sync"
+	"unicode/utf8"
+
+	radix "github.com/armon/go-radix"
+	log "github.com/sirupsen/logrus"
+)
+
+// expressionMatcher matches a resource against many resource expressions in one pass. The expressions are compiled
+// into one program, which is run as a Thompson NFA over the resource, so the states of all the expressions advance
+// together instead of matching the expressions one by one. The literal prefixes of the expressions are indexed in
+// radix trees, and an expression is only started where its prefix is found in the resource.
+// The expressions are added and removed with the runtime service locked, but matched concurrently by the evaluations.
+type expressionMatcher struct {
+	//{resourceExpression: index of entries}
+	indexes map[string]int
+	entries []matcherEntry
+	insts   []syntax.Inst
+	// owners is the index of the expression every instruction belongs to
+	owners []int
+	// deadInsts is the number of the instructions of the removed expressions
+	deadInsts int
+	// {literal prefix: start pcs of the expressions after the prefix}, anchoredPrefixes is only looked up at the
+	// beginning of the resource as the expressions start with ^
+	anchoredPrefixes *radix.Tree
+	prefixes         *radix.Tree
+	// the start pcs of the expressions without literal prefix
+	anchoredStarts []uint32
+	starts         []uint32
+	machines       sync.Pool
+}
+
+// matcherEntry is an expression of the matcher, and where it starts
+type matcherEntry struct {
+	expression string
+	anchored   bool
+	prefix     string
+	start      uint32
+	size       int
+}
+
+// matchMachine keeps the states of one match, it is reused by the matches of the same matcher
+type matchMachine struct {
+	current, next sparseSet
+	matched       []bool
+	matchedList   []int
+	// the start pcs of the expressions to be added at every position of the resource
+	pending [][]uint32
+	stack   []uint32
+}
+
+// sparseSet is a set of pcs, which is cleared in constant time
+type sparseSet struct {
+	sparse []uint32
+	dense  []uint32
+}
+
+func newSparseSet(size int) sparseSet {
+	return sparseSet{sparse: make([]uint32, size), dense: make([]uint32, 0, 16)}
+}
+
+func (s *sparseSet) contains(pc uint32) bool {
+	i := s.sparse[pc]
+	return i < uint32(len(s.dense)) && s.dense[i] == pc
+}
+
+func (s *sparseSet) insert(pc uint32) {
+	s.sparse[pc] = uint32(len(s.dense))
+	s.dense = append(s.dense, pc)
+}
+
+func (s *sparseSet) clear() {
+	s.dense = s.dense[:0]
+}
+
+func newExpressionMatcher(expressions []string) *expressionMatcher {
+	m := &expressionMatcher{
+		indexes:          make(map[string]int),
+		anchoredPrefixes: radix.New(),
+		prefixes:         radix.New(),
+	}
+	for _, expression := range expressions {
+		m.add(expression)
+	}
+	return m
+}
+
+// add compiles an expression and adds it to the program
+func (m *expressionMatcher) add(expression string) {
+	if _, exist := m.indexes[expression]; exist {
+		return
+	}
+	re, err := syntax.Parse(expression, syntax.Perl)
+	if err != nil {
+		log.Errorf("Meet error when compile the resource expression %q. err: %s", expression, err)
+		return
+	}
+	anchored, prefix, rest := splitLiteralPrefix(re.Simplify())
+	prog, err := syntax.Compile(rest)
+	if err != nil {
+		log.Errorf("Meet error when compile the resource expression %q. err: %s", expression, err)
+		return
+	}
+
+	index := len(m.entries)
+	offset := uint32(len(m.insts))
+	for _, inst := range prog.Inst {
+		inst.Out += offset
+		if inst.Op == syntax.InstAlt || inst.Op == syntax.InstAltMatch {
+			inst.Arg += offset
+		}
+		m.insts = append(m.insts, inst)
+		m.owners = append(m.owners, index)
+	}
+	entry := matcherEntry{
+		expression: expression,
+		anchored:   anchored,
+		prefix:     prefix,
+		start:      offset + uint32(prog.Start),
+		size:       len(prog.Inst),
+	}
+	m.indexes[expression] = index
+	m.entries = append(m.entries, entry)
+
+	switch {
+	case len(prefix) > 0 && anchored:
+		insertStart(m.anchoredPrefixes, prefix, entry.start)
+	case len(prefix) > 0:
+		insertStart(m.prefixes, prefix, entry.start)
+	case anchored:
+		m.anchoredStarts = append(m.anchoredStarts, entry.start)
+	default:
+		m.starts = append(m.starts, entry.start)
+	}
+}
+
+// remove removes an expression, its instructions are left in the program but never started
+func (m *expressionMatcher) remove(expression string) {
+	index, exist := m.indexes[expression]
+	if !exist {
+		return
+	}
+	delete(m.indexes, expression)
+	entry := m.entries[index]
+	switch {
+	case len(entry.prefix) > 0 && entry.anchored:
+		deleteStart(m.anchoredPrefixes, entry.prefix, entry.start)
+	case len(entry.prefix) > 0:
+		deleteStart(m.prefixes, entry.prefix, entry.start)
+	case entry.anchored:
+		m.anchoredStarts = removeStart(m.anchoredStarts, entry.start)
+	default:
+		m.starts = removeStart(m.starts, entry.start)
+	}
+	m.deadInsts += entry.size
+}
+
+// len returns the number of the expressions
+func (m *expressionMatcher) len() int {
+	return len(m.indexes)
+}
+
+// wasteful checks if most of the program is the instructions of the removed expressions
+func (m *expressionMatcher) wasteful() bool {
+	return m.deadInsts > len(m.insts)/2
+}
+
+func (m *expressionMatcher) newMachine() *matchMachine {
+	return &matchMachine{
+		current: newSparseSet(len(m.insts)),
+		next:    newSparseSet(len(m.insts)),
+		matched: make([]bool, len(m.entries)),
+	}
+}
+
+func insertStart(tree *radix.Tree, prefix string, start uint32) {
+	if value, exist := tree.Get(prefix); exist {
+		tree.Insert(prefix, append(value.([]uint32), start))
+	} else {
+		tree.Insert(prefix, []uint32{start})
+	}
+}
+
+func deleteStart(tree *radix.Tree, prefix string, start uint32) {
+	if value, exist := tree.Get(prefix); exist {
+		if starts := removeStart(value.([]uint32), start); len(starts) > 0 {
+			tree.Insert(prefix, starts)
+		} else {
+			tree.Delete(prefix)
+		}
+	}
+}
+
+// removeStart returns a copy of the start pcs without start
+func removeStart(starts []uint32, start uint32) []uint32 {
+	result := make([]uint32, 0, len(starts))
+	for _, s := range starts {
+		if s != start {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// splitLiteralPrefix splits a simplified expression into the literal it starts with, and the rest of it.
+// anchored is true if the expression starts with ^.
+func splitLiteralPrefix(re *syntax.Regexp) (anchored bool, prefix string, rest *syntax.Regexp) {
+	subs := []*syntax.Regexp{re}
+	if re.Op == syntax.OpConcat {
+		subs = re.Sub
+	}
+	i := 0
+	if i < len(subs) && subs[i].Op == syntax.OpBeginText {
+		anchored = true
+		i++
+	}
+	if i < len(subs) && subs[i].Op == syntax.OpLiteral && subs[i].Flags&syntax.FoldCase == 0 {
+		prefix = string(subs[i].Rune)
+		i++
+	}
+	switch len(subs) - i {
+	case 0:
+		rest = &syntax.Regexp{Op: syntax.OpEmptyMatch}
+	case 1:
+		rest = subs[i]
+	default:
+		rest = &syntax.Regexp{Op: syntax.OpConcat, Sub: subs[i:]}
+	}
+	return anchored, prefix, rest
+}
+
+func decodeRune(s string, pos int) (rune, int) {
+	if pos >= len(s) {
+		return -1, 0
+	}
+	return utf8.DecodeRuneInString(s[pos:])
+}
+
+// match calls fn with every expression matching the resource as regexp.MatchString does, i.e. the expressions are
+// not anchored unless they start with ^ or end with $
+func (m *expressionMatcher) match(resource string, fn func(expression string)) {
+	if m.len() == 0 {
+		return
+	}
+	mc, _ := m.machines.Get().(*matchMachine)
+	// the machines for the program before expressions are added are too small
+	if mc == nil || len(mc.matched) < len(m.entries) || len(mc.current.sparse) < len(m.insts) {
+		mc = m.newMachine()
+	}
+	defer m.release(mc)
+	if cap(mc.pending) < len(resource)+1 {
+		mc.pending = make([][]uint32, len(resource)+1)
+	}
+	mc.pending = mc.pending[:len(resource)+1]
+
+	r, width := decodeRune(resource, 0)
+	flag := syntax.EmptyOpContext(-1, r)
+	m.seed(mc, m.anchoredPrefixes, resource, 0)
+	for _, start := range m.anchoredStarts {
+		m.addState(mc, &mc.current, start, flag)
+	}
+	for pos := 0; ; {
+		m.seed(mc, m.prefixes, resource, pos)
+		for _, start := range mc.pending[pos] {
+			m.addState(mc, &mc.current, start, flag)
+		}
+		for _, start := range m.starts {
+			m.addState(mc, &mc.current, start, flag)
+		}
+
+		next := pos + width
+		nextRune, nextWidth := decodeRune(resource, next)
+		nextFlag := syntax.EmptyOpContext(r, nextRune)
+		for _, pc := range mc.current.dense {
+			owner := m.owners[pc]
+			if mc.matched[owner] {
+				continue
+			}
+			inst := &m.insts[pc]
+			switch inst.Op {
+			case syntax.InstMatch:
+				mc.matched[owner] = true
+				mc.matchedList = append(mc.matchedList, owner)
+				fn(m.entries[owner].expression)
+			case syntax.InstRune, syntax.InstRune1:
+				if width > 0 && inst.MatchRune(r) {
+					m.addState(mc, &mc.next, inst.Out, nextFlag)
+				}
+			case syntax.InstRuneAny:
+				if width > 0 {
+					m.addState(mc, &mc.next, inst.Out, nextFlag)
+				}
+			case syntax.InstRuneAnyNotNL:
+				if width > 0 && r != '\n' {
+					m.addState(mc, &mc.next, inst.Out, nextFlag)
+				}
+			}
+		}
+		if width == 0 || len(mc.matchedList) == m.len() {
+			return
+		}
+		mc.current, mc.next = mc.next, mc.current
+		mc.next.clear()
+		pos, r, width, flag = next, nextRune, nextWidth, nextFlag
+	}
+}
+
+// seed schedules the expressions whose literal prefixes are found at pos to start after the prefixes
+func (m *expressionMatcher) seed(mc *matchMachine, tree *radix.Tree, resource string, pos int) {
+	if tree.Len() == 0 {
+		return
+	}
+	tree.WalkPath(resource[pos:], func(prefix string, v interface{}) bool {
+		end := pos + len(prefix)
+		mc.pending[end] = append(mc.pending[end], v.([]uint32)...)
+		return false
+	})
+}
+
+// addState adds pc and the states reachable from it without consuming a rune to set
+func (m *expressionMatcher) addState(mc *matchMachine, set *sparseSet, pc uint32, flag syntax.EmptyOp) {
+	if mc.matched[m.owners[pc]] {
+		return
+	}
+	mc.stack = append(mc.stack[:0], pc)
+	for len(mc.stack) > 0 {
+		pc := mc.stack[len(mc.stack)-1]
+		mc.stack = mc.stack[:len(mc.stack)-1]
+		if set.contains(pc) {
+			continue
+		}
+		set.insert(pc)
+		inst := &m.insts[pc]
+		switch inst.Op {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			mc.stack = append(mc.stack, inst.Arg, inst.Out)
+		case syntax.InstNop, syntax.InstCapture:
+			mc.stack = append(mc.stack, inst.Out)
+		case syntax.InstEmptyWidth:
+			if syntax.EmptyOp(inst.Arg)&^flag == 0 {
+				mc.stack = append(mc.stack, inst.Out)
+			}
+		}
+	}
+}
+
+func (m *expressionMatcher) release(mc *matchMachine) {
+	mc.current.clear()
+	mc.next.clear()
+	for _, owner := range mc.matchedList {
+		mc.matched[owner] = false
+	}
+	mc.matchedList = mc.matchedList[:0]
+	for i := range mc.pending {
+		mc.pending[i] = mc.pending[i][:0]
+	}
+	m.machines.Put(mc)
+}
+
+// expressionSet keeps the resource expressions of a ResourceToPolicyMap which are not indexed by the radix trees,
+// and the matcher of them
+type expressionSet struct {
+	//{resourceExpression:{policyID: bool}}
+	policies map[string]map[string]bool
+	matcher  *expressionMatcher
+}
+
+func newExpressionSet() *expressionSet {
+	return &expressionSet{
+		policies: make(map[string]map[string]bool),
+		matcher:  newExpressionMatcher(nil),
+	}
+}
+
+func (s *expressionSet) add(expression, policyID string) {
+	policyIDSet, exist := s.policies[expression]
+	if !exist {
+		policyIDSet = make(map[string]bool)
+		s.policies[expression] = policyIDSet
+		s.matcher.add(expression)
+	}
+	policyIDSet[policyID] = true
+}
+
+func (s *expressionSet) delete(expression, policyID string) {
+	if policyIDSet, exist := s.policies[expression]; exist {
+		delete(policyIDSet, policyID)
+		if len(policyIDSet) == 0 {
+			delete(s.policies, expression)
+			s.matcher.remove(expression)
+			if s.matcher.wasteful() {
+				expressions := make([]string, 0, len(s.policies))
+				for expression := range s.policies {
+					expressions = append(expressions, expression)
+				}
+				s.matcher = newExpressionMatcher(expressions)
+			}
+		}
+	}
+}
+
+func (s *expressionSet) len() int {
+	return len(s.policies)
+}
+
+// match calls fn with the IDs of the policies whose resource expressions match the resource
+func (s *expressionSet) match(resource string, fn func(policyID string)) {
+	s.matcher.match(resource, func(expression string) {
+		for id := range s.policies[expression] {
+			fn(id)
+		}
+	})
+}
+
+// all calls fn with the IDs of all the policies
+func (s *expressionSet) all(fn func(policyID string)) {
+	for _, policyIDSet := range s.policies {
+		for id := range policyIDSet {
+			fn(id)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package eval
+
+import (
+	"regexp"
+	"sort"
+	"testing"
+)
+
+func TestExpressionMatcher(t *testing.T) {
+	expressions := []string{
+		"/books/[a-z]+/cover",
+		"^/books/[a-z]+/cover$",
+		"^/books$",
+		"books",
+		"^/movies/(action|comedy)/.+",
+		"/movies/.*/trailer$",
+		"k8s:.123:dev/core/pods/.*",
+		"(?i)^/MUSIC/",
+		"^[0-9]+$",
+		"\\bcover\\b",
+		"^$",
+		"x*",
+		"/日本/.",
+		"[",
+	}
+	resources := []string{
+		"",
+		"/books",
+		"/books/fiction/cover",
+		"/books/fiction/cover/back",
+		"/books/Fiction/cover",
+		"/old/books/fiction/cover",
+		"/movies/action/1",
+		"/movies/drama/1/trailer",
+		"/movies/comedy/",
+		"k8s:a123:dev/core/pods/p1",
+		"/music/jazz",
+		"12345",
+		"12a45",
+		"hardcover",
+		"/日本/語",
+	}
+
+	matcher := newExpressionMatcher(expressions)
+	for _, resource := range resources {
+		var expected, matched []string
+		for _, expression := range expressions {
+			if ok, err := regexp.MatchString(expression, resource); err == nil && ok {
+				expected = append(expected, expression)
+			}
+		}
+		matcher.match(resource, func(expression string) {
+			matched = append(matched, expression)
+		})
+		sort.Strings(expected)
+		sort.Strings(matched)
+		if len(expected) != len(matched) {
+			t.Errorf("resource %q: expected %q, but got %q", resource, expected, matched)
+			continue
+		}
+		for i := range expected {
+			if expected[i] != matched[i] {
+				t.Errorf("resource %q: expected %q, but got %q", resource, expected, matched)
+				break
+			}
+		}
+	}
+}
+
+func TestExpressionSet(t *testing.T) {
+	set := newExpressionSet()
+	set.add("/books/[a-z]+", "policy1")
+	set.add("/books/[a-z]+", "policy2")
+	set.add("^/movies/[0-9]+$", "policy3")
+
+	match := func(resource string) map[string]bool {
+		result := make(map[string]bool)
+		set.match(resource, func(id string) {
+			result[id] = true
+		})
+		return result
+	}
+	if result := match("/books/fiction"); len(result) != 2 || !result["policy1"] || !result["policy2"] {
+		t.Errorf("unexpected policies %v", result)
+	}
+
+	// the matcher is rebuilt after the expressions are changed
+	set.delete("/books/[a-z]+", "policy1")
+	set.delete("^/movies/[0-9]+$", "policy3")
+	set.add("/movies/.+", "policy4")
+	if result := match("/books/fiction"); len(result) != 1 || !result["policy2"] {
+		t.Errorf("unexpected policies %v", result)
+	}
+	if result := match("/movies/1"); len(result) != 1 || !result["policy4"] {
+		t.Errorf("unexpected policies %v", result)
+	}
+	if set.len() != 2 {
+		t.Errorf("expected 2 expressions, but got %d", set.len())
+	}
+}
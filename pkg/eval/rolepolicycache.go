@@ -4,12 +4,8 @@
 package eval
 
 import (
-	"regexp"
-
 	"github.com/teramoby/speedle-plus/api/pms"
 
-	log "github.com/sirupsen/logrus"
-
 	"github.com/teramoby/speedle-plus/3rdparty/github.com/Knetic/govaluate"
 )
 
@@ -24,6 +20,8 @@ func NewRolePolicyCacheData() (p *RolePolicyCacheData) {
 			PrincipalToPolicies:    make(map[string]*ResourceToPolicyMap),
 			NilPrincipalToPolicies: &ResourceToPolicyMap{},
 			Conditions:             make(map[string]*govaluate.EvaluableExpression),
+			Expressions:            make(map[string]*compiledExpression),
+			Globs:                  make(map[string]*compiledExpression),
 		},
 		PolicyMap: make(map[string]*pms.RolePolicy),
 	}
@@ -37,12 +35,22 @@ func nilPrincipalRolePolicy(rolePolicy *pms.RolePolicy) (result bool) {
 	return false
 }
 
+// AddRolePolicyToCache adds a role policy to the cache, the existing one with the same ID is replaced
 func (p *RolePolicyCacheData) AddRolePolicyToCache(policy *pms.RolePolicy, condition *govaluate.EvaluableExpression) {
+	// Remove the existing one first, so none of its index entries, condition or expressions is left behind
+	p.DeleteRolePolicyFromCache(policy.ID)
+
 	//First add role policy to PolicyMap
 	p.PolicyMap[policy.ID] = policy
 	if condition != nil {
 		p.Conditions[policy.ID] = condition
 	}
+	for _, resourceExpression := range policy.ResourceExpressions {
+		p.addExpression(resourceExpression)
+	}
+	for _, resourceGlob := range policy.ResourceGlobs {
+		p.addGlob(resourceGlob)
+	}
 
 	//No principal defined. that means the roles are granted to any user
 	if nilPrincipalRolePolicy(policy) {
@@ -73,34 +81,21 @@ func (p *RolePolicyCacheData) AddRolePolicyToCache(policy *pms.RolePolicy, condi
 func (p *RolePolicyCacheData) addRolePolicyToResourceToRolePolicyMap(resourceToRolePolicyMap *ResourceToPolicyMap, rolePolicy *pms.RolePolicy) {
 	//in role policy, resources/resExpressions could be empty, which means any resource
 	if nilResourceRolePolicy(rolePolicy) {
-		if resourceToRolePolicyMap.NilResourceToPolicies == nil {
-			resourceToRolePolicyMap.NilResourceToPolicies = make(map[string]bool)
-		}
-		resourceToRolePolicyMap.NilResourceToPolicies[rolePolicy.ID] = true
+		resourceToRolePolicyMap.addNilResource(rolePolicy.ID)
 		return
 	}
 
 	for _, resource := range rolePolicy.Resources {
-
-		if resourceToRolePolicyMap.ResourceToPolicies == nil {
-			resourceToRolePolicyMap.ResourceToPolicies = make(map[string]map[string]bool)
-			policyIDSet := make(map[string]bool)
-			policyIDSet[rolePolicy.ID] = true
-			resourceToRolePolicyMap.ResourceToPolicies[resource] = policyIDSet
-		} else {
-			if policyIDSet, exist := resourceToRolePolicyMap.ResourceToPolicies[resource]; exist {
-				policyIDSet[rolePolicy.ID] = true
-			} else {
-				policyIDSet := make(map[string]bool)
-				policyIDSet[rolePolicy.ID] = true
-				resourceToRolePolicyMap.ResourceToPolicies[resource] = policyIDSet
-			}
-		}
+		resourceToRolePolicyMap.addResource(resource, rolePolicy.ID)
 	}
 
 	for _, resourceExpression := range rolePolicy.ResourceExpressions {
 		AddPolicyToResourceExpressionCache(resourceToRolePolicyMap, resourceExpression, rolePolicy.ID)
 	}
+
+	for _, resourceGlob := range rolePolicy.ResourceGlobs {
+		AddPolicyToResourceGlobCache(resourceToRolePolicyMap, resourceGlob, rolePolicy.ID)
+	}
 }
 
 func (p *RolePolicyCacheData) DeleteRolePolicyFromCache(policyID string) {
@@ -112,8 +107,13 @@ func (p *RolePolicyCacheData) DeleteRolePolicyFromCache(policyID string) {
 		return
 	}
 	delete(p.PolicyMap, policyID)
-	if len(policy.Condition) > 0 { //remove related condition cache
-		delete(p.Conditions, policyID)
+	// remove related condition cache, the condition may have been compiled after the policy was added
+	delete(p.Conditions, policyID)
+	for _, resourceExpression := range policy.ResourceExpressions {
+		p.deleteExpression(resourceExpression)
+	}
+	for _, resourceGlob := range policy.ResourceGlobs {
+		p.deleteGlob(resourceGlob)
 	}
 
 	if nilPrincipalRolePolicy(policy) {
@@ -136,7 +136,8 @@ func (p *RolePolicyCacheData) DeleteRolePolicyFromCache(policyID string) {
 
 func nilResourceRolePolicy(rolePolicy *pms.RolePolicy) (result bool) {
 	if (rolePolicy.Resources == nil || len(rolePolicy.Resources) == 0) &&
-		(rolePolicy.ResourceExpressions == nil || len(rolePolicy.ResourceExpressions) == 0) {
+		(rolePolicy.ResourceExpressions == nil || len(rolePolicy.ResourceExpressions) == 0) &&
+		len(rolePolicy.ResourceGlobs) == 0 {
 		return true
 	}
 
@@ -146,27 +147,21 @@ func nilResourceRolePolicy(rolePolicy *pms.RolePolicy) (result bool) {
 func (p *RolePolicyCacheData) deleteRolePolicyFromResourceToRolePolicyMap(resourceToRolePolicyMap *ResourceToPolicyMap, policy *pms.RolePolicy) {
 	//in role policy, resources/resExpressions could be empty, which means any resource
 	if nilResourceRolePolicy(policy) {
-		if resourceToRolePolicyMap.NilResourceToPolicies != nil {
-			delete(resourceToRolePolicyMap.NilResourceToPolicies, policy.ID)
-		}
-
+		resourceToRolePolicyMap.deleteNilResource(policy.ID)
 		return
 	}
 
-	if resourceToRolePolicyMap.ResourceToPolicies != nil {
-		for _, resource := range policy.Resources {
-			if policyIDSet, exist := resourceToRolePolicyMap.ResourceToPolicies[resource]; exist {
-				delete(policyIDSet, policy.ID)
-				if len(policyIDSet) == 0 {
-					delete(resourceToRolePolicyMap.ResourceToPolicies, resource)
-				}
-			}
-		}
+	for _, resource := range policy.Resources {
+		resourceToRolePolicyMap.deleteResource(resource, policy.ID)
 	}
 
 	for _, resourceExpression := range policy.ResourceExpressions {
 		DeletePolicyFromResourceExpressionCache(resourceToRolePolicyMap, resourceExpression, policy.ID)
 	}
+
+	for _, resourceGlob := range policy.ResourceGlobs {
+		DeletePolicyFromResourceGlobCache(resourceToRolePolicyMap, resourceGlob, policy.ID)
+	}
 }
 
 func (p *RolePolicyCacheData) GetRelatedRolePolicyMap(subjectPrincipals []string, resource string) map[string]*pms.RolePolicy {
@@ -192,59 +187,7 @@ func (p *RolePolicyCacheData) GetRelatedRolePolicyMap(subjectPrincipals []string
 }
 
 func (p *RolePolicyCacheData) getRolePolicyFromResourceToRolePolicyMap(resourceToRolePolicyMap *ResourceToPolicyMap, resultRolePolicyMap map[string]*pms.RolePolicy, resource string) {
-
-	//Add all of the nil resource policies to result first
-	if resourceToRolePolicyMap.NilResourceToPolicies != nil {
-		for id := range resourceToRolePolicyMap.NilResourceToPolicies {
-			resultRolePolicyMap[id] = p.PolicyMap[id]
-		}
-	}
-
-	//Check resource policy map
-	if resourceToRolePolicyMap.ResourceToPolicies != nil {
-		if policyIDSet, ok := resourceToRolePolicyMap.ResourceToPolicies[resource]; ok {
-			//Add all related policies to result policy map
-			for id := range policyIDSet {
-				resultRolePolicyMap[id] = p.PolicyMap[id]
-			}
-		}
-	}
-
-	//Check resource expression
-	p.getRolePoliciesFromResourceExpressionMap(resourceToRolePolicyMap, resultRolePolicyMap, resource)
-}
-
-func (p *RolePolicyCacheData) getRolePoliciesFromResourceExpressionMap(resourceToRolePolicyMap *ResourceToPolicyMap, resultPolicyMap map[string]*pms.RolePolicy, resource string) {
-
-	fn := func(s string, v interface{}) bool {
-		policyIDSet := v.(map[string]bool)
-		for id := range policyIDSet {
-			resultPolicyMap[id] = p.PolicyMap[id]
-		}
-
-		return false
-	}
-	if resourceToRolePolicyMap.PrefixResourceExpressionTree != nil {
-		resourceToRolePolicyMap.PrefixResourceExpressionTree.WalkPath(resource, fn)
-	}
-
-	if resourceToRolePolicyMap.SuffixResourceExpressionTree != nil {
-		resourceToRolePolicyMap.SuffixResourceExpressionTree.WalkPath(ReverseString(resource), fn)
-	}
-
-	if resourceToRolePolicyMap.ResourceExpressionToPolicies != nil {
-		for resExp, policyIDSet := range resourceToRolePolicyMap.ResourceExpressionToPolicies {
-			matched, err := regexp.MatchString(resExp, resource)
-			if err != nil {
-				log.Errorf("Meet error when match the resource expression in role poliy. err: %s", err)
-				continue
-			}
-			if matched {
-				//Add all related policies to result policy map
-				for id := range policyIDSet {
-					resultPolicyMap[id] = p.PolicyMap[id]
-				}
-			}
-		}
-	}
+	resourceToRolePolicyMap.matchPolicies(resource, func(id string) {
+		resultRolePolicyMap[id] = p.PolicyMap[id]
+	})
 }
@@ -18,11 +18,14 @@ type RuntimePolicyStore struct {
 	RuntimeServices     map[string]*RuntimeService
 	FunctionResultCache *FuncResultCache
 	FuncSvcEndpoint     string //endpoint in sphinx side to call external customer function
+	// UncachableFunctions are the custom functions whose results aren't cachable
+	UncachableFunctions map[string]bool
 }
 
 func NewRuntimePolicyStore() *RuntimePolicyStore {
 	return &RuntimePolicyStore{
-		RuntimeServices: make(map[string]*RuntimeService),
+		RuntimeServices:     make(map[string]*RuntimeService),
+		UncachableFunctions: make(map[string]bool),
 		FunctionResultCache: &FuncResultCache{
 			Results: make(map[string]FuncResult),
 		},
@@ -31,11 +34,13 @@ func NewRuntimePolicyStore() *RuntimePolicyStore {
 
 type RuntimeService struct {
 	sync.RWMutex
-	Name              string
-	Type              string
-	PoliciesCache     *PolicyCacheData
-	RolePoliciesCache *RolePolicyCacheData
-	Functions         map[string]govaluate.ExpressionFunction
+	Name               string
+	Type               string
+	CombiningAlgorithm string
+	DefaultEffect      string
+	PoliciesCache      *PolicyCacheData
+	RolePoliciesCache  *RolePolicyCacheData
+	Functions          map[string]govaluate.ExpressionFunction
 }
 
 func NewRuntimeService() *RuntimeService {
@@ -51,6 +56,7 @@ func (rtps *RuntimePolicyStore) init(ps *pms.PolicyStore, funcSvcEndpoint string
 	}
 	// No need to lock, because this is a init method, evaluator should not be ready at this point
 	rtps.Functions = convertFunctions(ps.Functions, rtps.FunctionResultCache, &rtps.FuncSvcEndpoint)
+	rtps.UncachableFunctions = uncachableFunctions(ps.Functions)
 	for _, service := range ps.Services {
 		rtps.RuntimeServices[service.Name] = convertService(service, rtps.Functions)
 	}
@@ -72,6 +78,7 @@ func (rtps *RuntimePolicyStore) reloadPolicyStore(ps *pms.PolicyStore) {
 	rtps.Lock()
 	defer rtps.Unlock()
 	rtps.Functions = functions
+	rtps.UncachableFunctions = uncachableFunctions(ps.Functions)
 	rtps.RuntimeServices = services
 	rtps.FunctionResultCache = &fncsResultCache
 }
@@ -93,7 +100,9 @@ func (rtps *RuntimePolicyStore) deleteService(serviceName string) {
 func (rtps *RuntimePolicyStore) recompilePolicyConditionAtRuntime(serviceName string, policy *pms.Policy) (*govaluate.EvaluableExpression, error) {
 	fmt.Println("recompile condition for policy:", policy)
 	condition, err := compileCondition(policy.Condition, rtps.Functions)
-	if err == nil {
+	if err != nil {
+		log.Errorf("Unable to compile the condition of policy %s in service %s, err: %v.", policy.ID, serviceName, err)
+	} else {
 		fmt.Println("updating condition for policy in another goroutine:", policy)
 		go updatePolicyCondition(rtps, serviceName, policy, condition)
 	}
@@ -117,7 +126,9 @@ func updatePolicyCondition(rtps *RuntimePolicyStore, serviceName string, policy
 func (rtps *RuntimePolicyStore) recompileRolePolicyConditionAtRuntime(serviceName string, policy *pms.RolePolicy) (*govaluate.EvaluableExpression, error) {
 	fmt.Println("recompile condition for role policy:", policy)
 	condition, err := compileCondition(policy.Condition, rtps.Functions)
-	if err == nil {
+	if err != nil {
+		log.Errorf("Unable to compile the condition of role policy %s in service %s, err: %v.", policy.ID, serviceName, err)
+	} else {
 		fmt.Println("updating condition for role policy in another goroutine:", policy)
 		go updateRolePolicyCondition(rtps, serviceName, policy, condition)
 	}
@@ -142,7 +153,10 @@ func (rtps *RuntimePolicyStore) addPolicy(serviceName string, policy *pms.Policy
 	rtps.RLock()
 	defer rtps.RUnlock()
 
-	condition, _ := compileCondition(policy.Condition, rtps.Functions)
+	condition, err := compileCondition(policy.Condition, rtps.Functions)
+	if err != nil {
+		log.Errorf("Unable to compile the condition of policy %s in service %s, err: %v.", policy.ID, serviceName, err)
+	}
 	rtService, ok := rtps.RuntimeServices[serviceName]
 	if !ok {
 		// Service is not found
@@ -177,7 +191,10 @@ func (rtps *RuntimePolicyStore) addRolePolicy(serviceName string, rolePolicy *pm
 	rtps.RLock()
 	defer rtps.RUnlock()
 
-	condition, _ := compileCondition(rolePolicy.Condition, rtps.Functions)
+	condition, err := compileCondition(rolePolicy.Condition, rtps.Functions)
+	if err != nil {
+		log.Errorf("Unable to compile the condition of role policy %s in service %s, err: %v.", rolePolicy.ID, serviceName, err)
+	}
 	rtService, ok := rtps.RuntimeServices[serviceName]
 	if !ok {
 		// Service is not found
@@ -211,10 +228,18 @@ func (rtps *RuntimePolicyStore) deleteRolePolicy(serviceName string, rolePolicyI
 func (rtps *RuntimePolicyStore) addFunction(function *pms.Function) {
 	rtps.Lock()
 	defer rtps.Unlock()
+	rtps.addFunctionWithoutLock(function)
+}
 
+func (rtps *RuntimePolicyStore) addFunctionWithoutLock(function *pms.Function) {
 	ef, err := rtps.FunctionResultCache.generateCustomerExpressionFunction(&rtps.FuncSvcEndpoint, function)
 	if err == nil {
 		rtps.Functions[function.Name] = ef
+		if function.ResultCachable {
+			delete(rtps.UncachableFunctions, function.Name)
+		} else {
+			rtps.UncachableFunctions[function.Name] = true
+		}
 		log.Infof("loaded customer function %q.\n", function.Name)
 	} else {
 		log.Errorf("fail to load customer function %q, err is %v. \n", function.Name, err)
@@ -229,8 +254,12 @@ func (rtps *RuntimePolicyStore) deleteFunction(name string) {
 func (rtps *RuntimePolicyStore) delFunc_rtps(name string) {
 	rtps.Lock()
 	defer rtps.Unlock()
+	rtps.delFuncWithoutLock(name)
+}
 
+func (rtps *RuntimePolicyStore) delFuncWithoutLock(name string) {
 	delete(rtps.Functions, name)
+	delete(rtps.UncachableFunctions, name)
 	rtps.FunctionResultCache.DeleteFromCache(name)
 }
 
@@ -243,6 +272,76 @@ func (rtps *RuntimePolicyStore) delFunc_rtsvc() {
 	}
 }
 
+// applyEvents applies the incremental events of one transaction with the runtime policy store write locked, so no
+// request is evaluated against a part of them. It returns the names of the changed services, and whether all the
+// services are affected by a change of the functions.
+func (rtps *RuntimePolicyStore) applyEvents(events []pms.StoreChangeEvent) (services []string, all bool) {
+	rtps.Lock()
+	defer rtps.Unlock()
+
+	// The requests being evaluated hold the read lock of runtime policy store, so the services aren't locked
+	clearConditions := false
+	for _, e := range events {
+		switch e.Type {
+		case pms.SERVICE_ADD:
+			service := e.Content.(*pms.Service)
+			rtps.RuntimeServices[service.Name] = convertService(service, rtps.Functions)
+			services = append(services, service.Name)
+		case pms.SERVICE_DELETE:
+			for _, name := range e.Content.([]string) {
+				delete(rtps.RuntimeServices, name)
+				services = append(services, name)
+			}
+		case pms.POLICY_ADD, pms.POLICY_DELETE, pms.ROLEPOLICY_ADD, pms.ROLEPOLICY_DELETE:
+			for _, data := range e.Content.([]pms.StoreUpdateData) {
+				rtService, ok := rtps.RuntimeServices[data.ServiceName]
+				if !ok {
+					log.Errorf("Unable find service %s in runtime cache.", data.ServiceName)
+					continue
+				}
+				switch e.Type {
+				case pms.POLICY_ADD:
+					policy := data.Data.(*pms.Policy)
+					condition, err := compileCondition(policy.Condition, rtps.Functions)
+					if err != nil {
+						log.Errorf("Unable to compile the condition of policy %s in service %s, err: %v.", policy.ID, data.ServiceName, err)
+					}
+					rtService.PoliciesCache.AddPolicyToCache(policy, condition)
+				case pms.POLICY_DELETE:
+					rtService.PoliciesCache.DeletePolicyFromCache(data.Data.(*pms.Policy).ID)
+				case pms.ROLEPOLICY_ADD:
+					rolePolicy := data.Data.(*pms.RolePolicy)
+					condition, err := compileCondition(rolePolicy.Condition, rtps.Functions)
+					if err != nil {
+						log.Errorf("Unable to compile the condition of role policy %s in service %s, err: %v.", rolePolicy.ID, data.ServiceName, err)
+					}
+					rtService.RolePoliciesCache.AddRolePolicyToCache(rolePolicy, condition)
+				case pms.ROLEPOLICY_DELETE:
+					rtService.RolePoliciesCache.DeleteRolePolicyFromCache(data.Data.(*pms.RolePolicy).ID)
+				}
+				services = append(services, data.ServiceName)
+			}
+		case pms.FUNCTION_ADD:
+			rtps.addFunctionWithoutLock(e.Content.(*pms.Function))
+			all = true
+		case pms.FUNCTION_DELETE:
+			for _, name := range e.Content.([]string) {
+				rtps.delFuncWithoutLock(name)
+			}
+			clearConditions, all = true, true
+		default:
+			log.Errorf("Unexpected event %d in a batch.", e.Type)
+		}
+	}
+	if clearConditions {
+		for _, svc := range rtps.RuntimeServices {
+			svc.PoliciesCache.clearConditions()
+			svc.RolePoliciesCache.clearConditions()
+		}
+	}
+	return services, all
+}
+
 func (rtps *RuntimePolicyStore) expireFunctionResultCache() {
 	rtps.RLock()
 	defer rtps.RUnlock()
@@ -273,18 +372,26 @@ func compileCondition(condition string, functions map[string]govaluate.Expressio
 func convertService(service *pms.Service,
 	functions map[string]govaluate.ExpressionFunction) *RuntimeService {
 	rtService := RuntimeService{
-		Name:              service.Name,
-		Type:              service.Type,
-		PoliciesCache:     NewPolicyCacheData(),
-		RolePoliciesCache: NewRolePolicyCacheData(),
-		Functions:         functions,
+		Name:               service.Name,
+		Type:               service.Type,
+		CombiningAlgorithm: service.CombiningAlgorithm,
+		DefaultEffect:      service.DefaultEffect,
+		PoliciesCache:      NewPolicyCacheData(),
+		RolePoliciesCache:  NewRolePolicyCacheData(),
+		Functions:          functions,
 	}
 	for _, policy := range service.Policies {
-		condition, _ := compileCondition(policy.Condition, functions)
+		condition, err := compileCondition(policy.Condition, functions)
+		if err != nil {
+			log.Errorf("Unable to compile the condition of policy %s in service %s, err: %v.", policy.ID, service.Name, err)
+		}
 		rtService.PoliciesCache.AddPolicyToCache(policy, condition)
 	}
 	for _, rolePolicy := range service.RolePolicies {
-		condition, _ := compileCondition(rolePolicy.Condition, functions)
+		condition, err := compileCondition(rolePolicy.Condition, functions)
+		if err != nil {
+			log.Errorf("Unable to compile the condition of role policy %s in service %s, err: %v.", rolePolicy.ID, service.Name, err)
+		}
 		rtService.RolePoliciesCache.AddRolePolicyToCache(rolePolicy, condition)
 	}
 
@@ -312,6 +419,16 @@ func convertFunctions(functions []*pms.Function, resultCache *FuncResultCache, f
 	return funcs
 }
 
+func uncachableFunctions(functions []*pms.Function) map[string]bool {
+	names := make(map[string]bool)
+	for _, function := range functions {
+		if !function.ResultCachable {
+			names[function.Name] = true
+		}
+	}
+	return names
+}
+
 func (svc *RuntimeService) clearConditionsCache() {
 	svc.Lock()
 	defer svc.Unlock()
@@ -0,0 +1,244 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package eval
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	adsapi "github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store/file"
+)
+
+// SimulatedDecision is the decision on a request by the live or the proposed policies. Policies and RolePolicies are
+// the IDs of the policies and role policies taking effect.
+type SimulatedDecision struct {
+	Allowed      bool     `json:"allowed"`
+	Reason       string   `json:"reason"`
+	GrantedRoles []string `json:"grantedRoles,omitempty"`
+	Policies     []string `json:"policies,omitempty"`
+	RolePolicies []string `json:"rolePolicies,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// SimulationDifference is a request whose decision or granted roles are changed by the proposed operations.
+// The responsible policies and role policies are the ones taking effect by either the live or the proposed policies,
+// which don't take effect by the other, or are changed by the operations.
+type SimulationDifference struct {
+	Request                 *adsapi.RequestContext `json:"request"`
+	Live                    *SimulatedDecision     `json:"live"`
+	Proposed                *SimulatedDecision     `json:"proposed"`
+	ResponsiblePolicies     []string               `json:"responsiblePolicies,omitempty"`
+	ResponsibleRolePolicies []string               `json:"responsibleRolePolicies,omitempty"`
+}
+
+// SimulationReport lists the replayed requests whose decisions or granted roles differ
+type SimulationReport struct {
+	Requests    int                     `json:"requests"`
+	Changed     int                     `json:"changed"`
+	Differences []*SimulationDifference `json:"differences"`
+}
+
+// changedEntities are the IDs of the policies and role policies changed by the proposed operations
+type changedEntities struct {
+	policies     map[string]bool
+	rolePolicies map[string]bool
+}
+
+func (c *changedEntities) addService(service *pms.Service) {
+	if service == nil {
+		return
+	}
+	for _, policy := range service.Policies {
+		c.policies[policy.ID] = true
+	}
+	for _, rolePolicy := range service.RolePolicies {
+		c.rolePolicies[rolePolicy.ID] = true
+	}
+}
+
+func findService(ps *pms.PolicyStore, serviceName string) *pms.Service {
+	for _, service := range ps.Services {
+		if service.Name == serviceName {
+			return service
+		}
+	}
+	return nil
+}
+
+// copyPolicyStore returns a deep copy of a policy store, which could be changed without changing the original one
+func copyPolicyStore(ps *pms.PolicyStore) (*pms.PolicyStore, error) {
+	content, err := json.Marshal(ps)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.SerializationError, "unable to copy the policy store")
+	}
+	var ret pms.PolicyStore
+	if err := json.Unmarshal(content, &ret); err != nil {
+		return nil, errors.Wrap(err, errors.SerializationError, "unable to copy the policy store")
+	}
+	return &ret, nil
+}
+
+// proposePolicyStore applies the operations to a copy of the live policy store, and returns it with the policies
+// and role policies changed by the operations. The conditions of the created and updated ones should be valid.
+func proposePolicyStore(live *pms.PolicyStore, ops []*pms.Operation) (*pms.PolicyStore, *changedEntities, error) {
+	proposed, err := copyPolicyStore(live)
+	if err != nil {
+		return nil, nil, err
+	}
+	// the policies of a service to delete aren't in the proposed store, so they are collected first
+	changed := &changedEntities{policies: make(map[string]bool), rolePolicies: make(map[string]bool)}
+	for i, op := range ops {
+		if op == nil {
+			return nil, nil, errors.Errorf(errors.InvalidRequest, "operation %d is empty", i)
+		}
+		if op.Kind == pms.KindService && op.Op == pms.OpDelete {
+			changed.addService(findService(live, op.ID))
+		}
+	}
+	results, err := file.ApplyOperations(proposed, ops)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	functions := convertFunctions(proposed.Functions, &FuncResultCache{Results: make(map[string]FuncResult)}, new(string))
+	checkCondition := func(kind, id, condition string) error {
+		if _, err := compileCondition(condition, functions); err != nil {
+			return errors.Wrapf(err, errors.InvalidRequest, "invalid condition of %s %q", kind, id)
+		}
+		return nil
+	}
+	for _, result := range results {
+		switch result.Kind {
+		case pms.KindService:
+			changed.addService(result.Service)
+			if result.Service == nil {
+				continue
+			}
+			for _, policy := range result.Service.Policies {
+				if err := checkCondition("policy", policy.ID, policy.Condition); err != nil {
+					return nil, nil, err
+				}
+			}
+			for _, rolePolicy := range result.Service.RolePolicies {
+				if err := checkCondition("role policy", rolePolicy.ID, rolePolicy.Condition); err != nil {
+					return nil, nil, err
+				}
+			}
+		case pms.KindPolicy:
+			changed.policies[result.ID] = true
+			if result.Policy != nil {
+				if err := checkCondition("policy", result.ID, result.Policy.Condition); err != nil {
+					return nil, nil, err
+				}
+			}
+		case pms.KindRolePolicy:
+			changed.rolePolicies[result.ID] = true
+			if result.RolePolicy != nil {
+				if err := checkCondition("role policy", result.ID, result.RolePolicy.Condition); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+	return proposed, changed, nil
+}
+
+// newSimulationEvaluator creates an evaluator with a temporary runtime policy store, which isn't backed by any store
+func newSimulationEvaluator(ps *pms.PolicyStore) *PolicyEvalImpl {
+	runtimePolicyStore := NewRuntimePolicyStore()
+	runtimePolicyStore.init(ps, "")
+	return &PolicyEvalImpl{RuntimePolicyStore: runtimePolicyStore}
+}
+
+// simulate decides a request, and resolves the granted roles even if the service has no policy
+func (p *PolicyEvalImpl) simulate(ctx adsapi.RequestContext) *SimulatedDecision {
+	result, err := p.Diagnose(ctx)
+	decision := SimulatedDecision{Allowed: result.Allowed, Reason: result.Reason.String()}
+	if err != nil {
+		decision.Error = err.Error()
+		return &decision
+	}
+	for _, policy := range result.Policies {
+		if policy.Status == adsapi.Evaluation_TakeEffect {
+			decision.Policies = append(decision.Policies, policy.ID)
+		}
+	}
+
+	roleResult := adsapi.EvaluationResult{}
+	decision.GrantedRoles, err = p.getAllGrantedRoles(ctx, &roleResult)
+	if err != nil {
+		decision.Error = err.Error()
+		return &decision
+	}
+	for _, rolePolicy := range roleResult.RolePolicies {
+		if rolePolicy.Status == adsapi.Evaluation_TakeEffect && !contains(decision.RolePolicies, rolePolicy.ID) {
+			decision.RolePolicies = append(decision.RolePolicies, rolePolicy.ID)
+		}
+	}
+	if len(decision.GrantedRoles) == 0 {
+		decision.GrantedRoles = nil
+	}
+	sort.Strings(decision.GrantedRoles)
+	sort.Strings(decision.Policies)
+	sort.Strings(decision.RolePolicies)
+	return &decision
+}
+
+// responsible returns the IDs taking effect by either side, which don't take effect by the other or are changed
+func responsible(live, proposed []string, changed map[string]bool) []string {
+	var ret []string
+	for _, id := range live {
+		if changed[id] || !contains(proposed, id) {
+			ret = append(ret, id)
+		}
+	}
+	for _, id := range proposed {
+		if !contains(ret, id) && (changed[id] || !contains(live, id)) {
+			ret = append(ret, id)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Simulate replays the requests through the live policy store, and through a temporary one with the proposed
+// operations applied, which are in the format of a transaction. It reports the requests whose decisions or granted
+// roles differ. The live policy store isn't changed.
+func Simulate(live *pms.PolicyStore, ops []*pms.Operation, requests []*adsapi.RequestContext) (*SimulationReport, error) {
+	if live == nil {
+		return nil, errors.New(errors.InvalidRequest, "no live policy store to simulate")
+	}
+	proposed, changed, err := proposePolicyStore(live, ops)
+	if err != nil {
+		return nil, err
+	}
+	liveEvaluator := newSimulationEvaluator(live)
+	proposedEvaluator := newSimulationEvaluator(proposed)
+
+	report := SimulationReport{Requests: len(requests), Differences: []*SimulationDifference{}}
+	for i, request := range requests {
+		if request == nil {
+			return nil, errors.Errorf(errors.InvalidRequest, "request %d to replay is empty", i)
+		}
+		liveDecision := liveEvaluator.simulate(*request)
+		proposedDecision := proposedEvaluator.simulate(*request)
+		if liveDecision.Allowed == proposedDecision.Allowed &&
+			reflect.DeepEqual(liveDecision.GrantedRoles, proposedDecision.GrantedRoles) {
+			continue
+		}
+		report.Differences = append(report.Differences, &SimulationDifference{
+			Request:                 request,
+			Live:                    liveDecision,
+			Proposed:                proposedDecision,
+			ResponsiblePolicies:     responsible(liveDecision.Policies, proposedDecision.Policies, changed.policies),
+			ResponsibleRolePolicies: responsible(liveDecision.RolePolicies, proposedDecision.RolePolicies, changed.rolePolicies),
+		})
+	}
+	report.Changed = len(report.Differences)
+	return &report, nil
+}
@@ -0,0 +1,85 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package eval
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	adsapi "github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+)
+
+func TestSimulate(t *testing.T) {
+	var live pms.PolicyStore
+	err := json.Unmarshal([]byte(`{"services": [
+		{"name": "books",
+		 "policies": [
+			{"id": "p1", "effect": "grant", "permissions": [{"resource": "/books", "actions": ["read"]}], "principals": [["user:alice"]]},
+			{"id": "p2", "effect": "grant", "permissions": [{"resource": "/books", "actions": ["write"]}], "principals": [["role:editor"]]}
+		 ],
+		 "rolePolicies": [
+			{"id": "rp1", "effect": "grant", "roles": ["editor"], "principals": ["user:bob"]}
+		 ]}
+	]}`), &live)
+	if err != nil {
+		t.Fatal("fail to unmarshal policy store:", err)
+	}
+	requestOf := func(user, action string) *adsapi.RequestContext {
+		return &adsapi.RequestContext{
+			Subject:     &adsapi.Subject{Principals: []*adsapi.Principal{{Type: adsapi.PRINCIPAL_TYPE_USER, Name: user}}},
+			ServiceName: "books",
+			Resource:    "/books",
+			Action:      action,
+		}
+	}
+	requests := []*adsapi.RequestContext{requestOf("alice", "read"), requestOf("bob", "write"), requestOf("carol", "read"), requestOf("dave", "read")}
+	ops := []*pms.Operation{
+		{Op: pms.OpDelete, Kind: pms.KindPolicy, ServiceName: "books", ID: "p1"},
+		{Op: pms.OpDelete, Kind: pms.KindRolePolicy, ServiceName: "books", ID: "rp1"},
+		{Op: pms.OpCreate, Kind: pms.KindPolicy, ServiceName: "books", Policy: &pms.Policy{
+			Effect:      pms.Grant,
+			Principals:  [][]string{{"user:carol"}},
+			Permissions: []*pms.Permission{{Resource: "/books", Actions: []string{"read"}}},
+		}},
+	}
+
+	report, err := Simulate(&live, ops, requests)
+	if err != nil {
+		t.Fatalf("Unexcepted error happened [%v].", err)
+	}
+	if report.Requests != 4 || report.Changed != 3 || len(report.Differences) != 3 {
+		t.Fatalf("expected 3 of 4 requests changed, but got %+v", report)
+	}
+	alice, bob, carol := report.Differences[0], report.Differences[1], report.Differences[2]
+	if !alice.Live.Allowed || alice.Proposed.Allowed || !reflect.DeepEqual(alice.ResponsiblePolicies, []string{"p1"}) {
+		t.Errorf("unexpected difference of alice %+v %+v %+v", alice.Live, alice.Proposed, alice.ResponsiblePolicies)
+	}
+	if !reflect.DeepEqual(bob.Live.GrantedRoles, []string{"editor"}) || bob.Proposed.GrantedRoles != nil || bob.Proposed.Allowed ||
+		!reflect.DeepEqual(bob.ResponsibleRolePolicies, []string{"rp1"}) || !reflect.DeepEqual(bob.ResponsiblePolicies, []string{"p2"}) {
+		t.Errorf("unexpected difference of bob %+v %+v %+v", bob.Live, bob.Proposed, bob.ResponsibleRolePolicies)
+	}
+	if carol.Live.Allowed || !carol.Proposed.Allowed || len(carol.ResponsiblePolicies) != 1 || carol.Proposed.Reason != adsapi.GRANT_POLICY_FOUND.String() {
+		t.Errorf("unexpected difference of carol %+v %+v", carol.Proposed, carol.ResponsiblePolicies)
+	}
+	if len(live.Services[0].Policies) != 2 || len(live.Services[0].RolePolicies) != 1 {
+		t.Error("the live policy store should not be changed")
+	}
+
+	// invalid conditions and missing entities
+	_, err = Simulate(&live, []*pms.Operation{{Op: pms.OpCreate, Kind: pms.KindPolicy, ServiceName: "books", Policy: &pms.Policy{
+		Effect:      pms.Grant,
+		Permissions: []*pms.Permission{{Resource: "/books", Actions: []string{"read"}}},
+		Condition:   "age >",
+	}}}, requests)
+	if errors.Code(err) != errors.InvalidRequest {
+		t.Errorf("expected an invalid request error, but got %v", err)
+	}
+	_, err = Simulate(&live, []*pms.Operation{{Op: pms.OpDelete, Kind: pms.KindPolicy, ServiceName: "books", ID: "p3"}}, requests)
+	if errors.Code(err) != errors.EntityNotFound {
+		t.Errorf("expected an entity not found error, but got %v", err)
+	}
+}
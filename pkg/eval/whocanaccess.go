@@ -0,0 +1,214 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package eval
+
+import (
+	"sort"
+	"strings"
+
+	adsapi "github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+)
+
+// rolePolicyIndex indexes the role policies applicable to a resource by the roles they grant or deny
+type rolePolicyIndex struct {
+	grants  map[string][]*pms.RolePolicy
+	denials map[string][]*pms.RolePolicy
+}
+
+func newRolePolicyIndex(resource string, services ...*RuntimeService) *rolePolicyIndex {
+	index := &rolePolicyIndex{
+		grants:  make(map[string][]*pms.RolePolicy),
+		denials: make(map[string][]*pms.RolePolicy),
+	}
+	for _, service := range services {
+		if service == nil {
+			continue
+		}
+		for _, rolePolicy := range service.RolePoliciesCache.PolicyMap {
+			if !service.RolePoliciesCache.matchResource(resource, rolePolicy.Resources, rolePolicy.ResourceExpressions, rolePolicy.ResourceGlobs) {
+				continue
+			}
+			for _, role := range rolePolicy.Roles {
+				switch rolePolicy.Effect {
+				case pms.Grant:
+					index.grants[role] = append(index.grants[role], rolePolicy)
+				case pms.Deny:
+					index.denials[role] = append(index.denials[role], rolePolicy)
+				}
+			}
+		}
+	}
+	for _, rolePolicies := range index.grants {
+		sortRolePolicies(rolePolicies)
+	}
+	for _, rolePolicies := range index.denials {
+		sortRolePolicies(rolePolicies)
+	}
+	return index
+}
+
+func sortRolePolicies(rolePolicies []*pms.RolePolicy) {
+	sort.Slice(rolePolicies, func(i, j int) bool {
+		return rolePolicies[i].ID < rolePolicies[j].ID
+	})
+}
+
+// rolePolicyPrincipals returns the principals of a role policy, no principal means everyone
+func rolePolicyPrincipals(rolePolicy *pms.RolePolicy) []string {
+	if len(rolePolicy.Principals) == 0 {
+		return []string{convertRoleToPrincipal(adsapi.BuiltIn_Role_Everyone)}
+	}
+	return rolePolicy.Principals
+}
+
+func appendCondition(conditions []string, condition string) []string {
+	condition = strings.TrimSpace(condition)
+	if len(condition) == 0 {
+		return conditions
+	}
+	return append(conditions, condition)
+}
+
+// expand appends the path, and the paths through which the roles in the principals of the path are granted.
+// Only the principals from index from are expanded, so each combination of the role policies is walked once.
+func (index *rolePolicyIndex) expand(path *adsapi.AccessPath, from int, result []*adsapi.AccessPath) []*adsapi.AccessPath {
+	result = append(result, path)
+	for i := from; i < len(path.Principals); i++ {
+		if !strings.HasPrefix(path.Principals[i], "role:") {
+			continue
+		}
+		role := strings.TrimPrefix(path.Principals[i], "role:")
+		for _, rolePolicy := range index.grants[role] {
+			for _, principal := range rolePolicyPrincipals(rolePolicy) {
+				if principal == path.Principals[i] || (strings.HasPrefix(principal, "role:") && contains(path.Roles, strings.TrimPrefix(principal, "role:"))) {
+					// the role is granted by itself in a loop of role policies
+					continue
+				}
+				newPath := &adsapi.AccessPath{
+					Principals:    append([]string{}, path.Principals...),
+					Roles:         append(append([]string{}, path.Roles...), role),
+					RolePolicyIDs: append(append([]string{}, path.RolePolicyIDs...), rolePolicy.ID),
+					PolicyID:      path.PolicyID,
+					PolicyName:    path.PolicyName,
+					Conditions:    appendCondition(append([]string{}, path.Conditions...), rolePolicy.Condition),
+				}
+				newPath.Principals[i] = principal
+				result = index.expand(newPath, i, result)
+			}
+		}
+	}
+	return result
+}
+
+// policyPaths returns the access paths of the policies, with the roles in them expanded
+func (index *rolePolicyIndex) policyPaths(policies []*pms.Policy) []*adsapi.AccessPath {
+	var result []*adsapi.AccessPath
+	for _, policy := range policies {
+		principals := policy.Principals
+		if len(principals) == 0 {
+			principals = [][]string{{convertRoleToPrincipal(adsapi.BuiltIn_Role_Everyone)}}
+		}
+		for _, andPrincipals := range principals {
+			path := &adsapi.AccessPath{
+				Principals: append([]string{}, andPrincipals...),
+				PolicyID:   policy.ID,
+				PolicyName: policy.Name,
+				Conditions: appendCondition(nil, policy.Condition),
+			}
+			result = index.expand(path, 0, result)
+		}
+	}
+	return result
+}
+
+// roleDenials returns the deny role policies which deny the roles in the paths
+func (index *rolePolicyIndex) roleDenials(paths []*adsapi.AccessPath) []*adsapi.RoleDenial {
+	roleMap := make(map[string]bool)
+	for _, path := range paths {
+		for _, role := range path.Roles {
+			roleMap[role] = true
+		}
+		for _, principal := range path.Principals {
+			if strings.HasPrefix(principal, "role:") {
+				roleMap[strings.TrimPrefix(principal, "role:")] = true
+			}
+		}
+	}
+	var roles []string
+	for role := range roleMap {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	var result []*adsapi.RoleDenial
+	for _, role := range roles {
+		for _, rolePolicy := range index.denials[role] {
+			result = append(result, &adsapi.RoleDenial{
+				Principals:     rolePolicyPrincipals(rolePolicy),
+				Role:           role,
+				RolePolicyID:   rolePolicy.ID,
+				RolePolicyName: rolePolicy.Name,
+				Condition:      strings.TrimSpace(rolePolicy.Condition),
+			})
+		}
+	}
+	return result
+}
+
+func sortAccessPaths(paths []*adsapi.AccessPath) {
+	sort.SliceStable(paths, func(i, j int) bool {
+		if paths[i].PolicyID != paths[j].PolicyID {
+			return paths[i].PolicyID < paths[j].PolicyID
+		}
+		return len(paths[i].Roles) < len(paths[j].Roles)
+	})
+}
+
+// WhoCanAccess walks the policies of the service and the role policies of the service and the global service,
+// and returns the principals which could be granted or denied the action on the resource. Roles in the
+// policies are expanded to the principals which could be granted them. Conditions are not evaluated, the
+// paths with conditions are returned with their conditions.
+func (p *PolicyEvalImpl) WhoCanAccess(serviceName, resource, action string) (*adsapi.AccessReview, error) {
+	p.RuntimePolicyStore.RLock()
+	defer p.RuntimePolicyStore.RUnlock()
+	service, err := p.getService(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	ctx := p.newInternalContext(serviceName, service, &subject{}, nil, resource, action, nil)
+
+	service.RLock()
+	defer service.RUnlock()
+	if ctx.GlobalService != nil {
+		ctx.GlobalService.RLock()
+		defer ctx.GlobalService.RUnlock()
+	}
+
+	var grantedPolicies, deniedPolicies []*pms.Policy
+	for _, policy := range service.PoliciesCache.PolicyMap {
+		if !service.PoliciesCache.matchResourceAction(policy, ctx) {
+			continue
+		}
+		switch policy.Effect {
+		case pms.Grant:
+			grantedPolicies = append(grantedPolicies, policy)
+		case pms.Deny:
+			deniedPolicies = append(deniedPolicies, policy)
+		}
+	}
+
+	index := newRolePolicyIndex(resource, service, ctx.GlobalService)
+	review := adsapi.AccessReview{
+		ServiceName: serviceName,
+		Resource:    resource,
+		Action:      action,
+		Granted:     index.policyPaths(grantedPolicies),
+		Denied:      index.policyPaths(deniedPolicies),
+	}
+	sortAccessPaths(review.Granted)
+	sortAccessPaths(review.Denied)
+	review.DeniedRoles = index.roleDenials(review.Granted)
+	return &review, nil
+}
@@ -0,0 +1,163 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package eval
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	adsapi "github.com/teramoby/speedle-plus/api/ads"
+)
+
+func TestWhoCanAccess(t *testing.T) {
+	const appStream = `
+	{
+		"services": [
+		{
+			"name": "erp",
+			"policies": [
+			{
+				"id": "id1",
+				"effect": "grant",
+				"permissions": [{"resource": "/orders", "actions": ["approve"]}],
+				"principals": [["role:approver"], ["user:carl", "entity:/bin/erp"]]
+			},
+			{
+				"id": "id2",
+				"effect": "grant",
+				"permissions": [{"resourceExpression": "/orders.*", "actions": ["approve", "read"]}],
+				"principals": [["group:auditors"]],
+				"condition": "hour < 18"
+			},
+			{
+				"id": "id3",
+				"effect": "deny",
+				"permissions": [{"resource": "/orders"}],
+				"principals": [["role:contractor"]]
+			},
+			{
+				"id": "id4",
+				"effect": "grant",
+				"permissions": [{"resource": "/orders", "actions": ["read"]}],
+				"principals": [["user:dave"]]
+			},
+			{
+				"id": "id5",
+				"effect": "grant",
+				"permissions": [{"resource": "/invoices", "actions": ["approve"]}],
+				"principals": [["user:dave"]]
+			}
+			],
+			"rolePolicies": [
+			{
+				"id": "rp1",
+				"effect": "grant",
+				"roles": ["approver"],
+				"principals": ["role:manager", "user:alice"]
+			},
+			{
+				"id": "rp2",
+				"effect": "grant",
+				"roles": ["manager"],
+				"principals": ["group:managers"],
+				"condition": "level > 3"
+			},
+			{
+				"id": "rp3",
+				"effect": "grant",
+				"roles": ["approver"],
+				"principals": ["user:eve"],
+				"resources": ["/invoices"]
+			},
+			{
+				"id": "rp4",
+				"effect": "deny",
+				"roles": ["approver"],
+				"principals": ["group:interns"]
+			},
+			{
+				"id": "rp5",
+				"effect": "grant",
+				"roles": ["manager"],
+				"principals": ["role:approver"]
+			}
+			]
+		},
+		{
+			"name": "global",
+			"rolePolicies": [
+			{
+				"id": "rp6",
+				"effect": "grant",
+				"roles": ["contractor"],
+				"principals": ["group:vendors"]
+			}
+			]
+		}
+		]
+	}
+	`
+	preparePolicyDataInStore([]byte(appStream), t)
+
+	evaluator, err := NewWithStore(conf, testPS)
+	if err != nil {
+		t.Fatalf("Unable to initialize evaluator due to error [%v].", err)
+	}
+
+	review, err := evaluator.WhoCanAccess("erp", "/orders", "approve")
+	if err != nil {
+		t.Fatalf("Unexcepted error happened [%v].", err)
+	}
+
+	summarize := func(paths []*adsapi.AccessPath) []string {
+		var summary []string
+		for _, path := range paths {
+			line := path.PolicyID + ": " + strings.Join(path.Principals, "&")
+			if len(path.Roles) > 0 {
+				line += " via " + strings.Join(path.Roles, ",")
+			}
+			if len(path.Conditions) > 0 {
+				line += " if " + strings.Join(path.Conditions, " && ")
+			}
+			summary = append(summary, line)
+		}
+		return summary
+	}
+	expectedGranted := []string{
+		"id1: role:approver",
+		"id1: user:carl&entity:/bin/erp",
+		"id1: role:manager via approver",
+		"id1: user:alice via approver",
+		"id1: group:managers via approver,manager if level > 3",
+		"id2: group:auditors if hour < 18",
+	}
+	if got := summarize(review.Granted); !reflect.DeepEqual(got, expectedGranted) {
+		t.Errorf("expected granted %v, but got %v", expectedGranted, got)
+	}
+	expectedDenied := []string{
+		"id3: role:contractor",
+		"id3: group:vendors via contractor",
+	}
+	if got := summarize(review.Denied); !reflect.DeepEqual(got, expectedDenied) {
+		t.Errorf("expected denied %v, but got %v", expectedDenied, got)
+	}
+	if len(review.DeniedRoles) != 1 || review.DeniedRoles[0].Role != "approver" || review.DeniedRoles[0].RolePolicyID != "rp4" ||
+		!reflect.DeepEqual(review.DeniedRoles[0].Principals, []string{"group:interns"}) {
+		t.Errorf("role approver should be denied to group interns, but got %v", review.DeniedRoles)
+	}
+
+	// role policies with resources are applied to the resources only
+	review, err = evaluator.WhoCanAccess("erp", "/invoices", "approve")
+	if err != nil {
+		t.Fatalf("Unexcepted error happened [%v].", err)
+	}
+	if got := summarize(review.Granted); !reflect.DeepEqual(got, []string{"id5: user:dave"}) {
+		t.Errorf("only dave should be granted, but got %v", got)
+	}
+
+	if _, err := evaluator.WhoCanAccess("dummy", "/orders", "approve"); err == nil {
+		t.Fatal("should fail if service is not found")
+	}
+}
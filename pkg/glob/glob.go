@@ -0,0 +1,128 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+// Package glob matches hierarchical resources, e.g. REST paths and cloud resource names, with glob patterns.
+// The segments of a resource are separated by "/", and a pattern matches the whole resource. "*" matches any
+// characters in one segment, "**" as a whole segment matches any number of segments, and elsewhere any characters
+// including "/". "{a,b}" matches one of the alternatives, which may contain the other patterns, and "\" escapes the
+// next character:
+//
+//	/reports/*                  /reports/2019, but not /reports or /reports/2019/q1
+//	/reports/**                 /reports, /reports/2019 and /reports/2019/q1
+//	/reports/**/summary         /reports/summary and /reports/2019/q1/summary
+//	/reports/{2019,2020}/*      /reports/2019/q1 and /reports/2020/q1
+//	/reports/\*                 /reports/*
+//
+// The patterns are converted to regular expressions, so they are matched and indexed as resource expressions.
+package glob
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/teramoby/speedle-plus/pkg/errors"
+)
+
+// Regexp converts a glob pattern to a regular expression matching the same resources
+func Regexp(pattern string) (string, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	i, err := convert(&b, pattern, 0, false)
+	if err != nil {
+		return "", err
+	}
+	if i < len(pattern) {
+		return "", errors.Errorf(errors.InvalidRequest, "invalid glob %q, unexpected %q at %d", pattern, pattern[i], i)
+	}
+	b.WriteString("$")
+	return b.String(), nil
+}
+
+// Compile converts a glob pattern to a compiled regular expression
+func Compile(pattern string) (*regexp.Regexp, error) {
+	expression, err := Regexp(pattern)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(expression)
+	if err != nil {
+		return nil, errors.Wrapf(err, errors.InvalidRequest, "invalid glob %q", pattern)
+	}
+	return re, nil
+}
+
+// Match checks if the resource matches a glob pattern, an invalid pattern matches nothing
+func Match(pattern, resource string) bool {
+	re, err := Compile(pattern)
+	return err == nil && re.MatchString(resource)
+}
+
+// convert writes the regular expression of pattern[i:] to b, and returns where it stops, i.e. the end of pattern,
+// or the "," or "}" ending an alternative if inBraces is true
+func convert(b *strings.Builder, pattern string, i int, inBraces bool) (int, error) {
+	for i < len(pattern) {
+		switch c := pattern[i]; {
+		case c == '\\':
+			if i+1 == len(pattern) {
+				return i, errors.Errorf(errors.InvalidRequest, "invalid glob %q, nothing to escape at the end", pattern)
+			}
+			r, size := utf8.DecodeRuneInString(pattern[i+1:])
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			i += 1 + size
+		case c == '/' && strings.HasPrefix(pattern[i:], "/**") && segmentEnds(pattern, i+3, inBraces):
+			// the parent itself, or any of its descendants
+			b.WriteString("(?:/.*)?")
+			i += 3
+		case c == '*' && strings.HasPrefix(pattern[i:], "**"):
+			if segmentStarts(pattern, i) && i+2 < len(pattern) && pattern[i+2] == '/' {
+				// any number of segments followed by the rest
+				b.WriteString("(?:.*/)?")
+				i += 3
+			} else {
+				b.WriteString(".*")
+				i += 2
+			}
+		case c == '*':
+			b.WriteString("[^/]*")
+			i++
+		case c == '{':
+			b.WriteString("(?:")
+			i++
+			for {
+				var err error
+				if i, err = convert(b, pattern, i, true); err != nil {
+					return i, err
+				}
+				if i == len(pattern) {
+					return i, errors.Errorf(errors.InvalidRequest, "invalid glob %q, missing }", pattern)
+				}
+				i++
+				if pattern[i-1] == '}' {
+					break
+				}
+				b.WriteString("|")
+			}
+			b.WriteString(")")
+		case inBraces && (c == ',' || c == '}'):
+			return i, nil
+		case c == '}':
+			return i, errors.Errorf(errors.InvalidRequest, "invalid glob %q, unexpected } at %d", pattern, i)
+		default:
+			r, size := utf8.DecodeRuneInString(pattern[i:])
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			i += size
+		}
+	}
+	return i, nil
+}
+
+// segmentStarts checks if a segment starts at i
+func segmentStarts(pattern string, i int) bool {
+	return i == 0 || strings.IndexByte("/{,", pattern[i-1]) >= 0
+}
+
+// segmentEnds checks if a segment ends at i
+func segmentEnds(pattern string, i int, inBraces bool) bool {
+	return i == len(pattern) || pattern[i] == '/' || (inBraces && (pattern[i] == ',' || pattern[i] == '}'))
+}
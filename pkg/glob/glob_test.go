@@ -0,0 +1,68 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package glob
+
+import (
+	"testing"
+
+	"github.com/teramoby/speedle-plus/pkg/errors"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		resource string
+		match    bool
+	}{
+		{"/reports", "/reports", true},
+		{"/reports", "/reports/2019", false},
+		{"/reports/*", "/reports/2019", true},
+		{"/reports/*", "/reports/2019/q1", false},
+		{"/reports/*", "/reports", false},
+		{"/reports/*.pdf", "/reports/2019.pdf", true},
+		{"/reports/*.pdf", "/reports/2019.txt", false},
+		{"/reports/*/summary", "/reports/2019/summary", true},
+		{"/reports/*/summary", "/reports/2019/q1/summary", false},
+		{"/reports/**", "/reports", true},
+		{"/reports/**", "/reports/2019", true},
+		{"/reports/**", "/reports/2019/q1", true},
+		{"/reports/**", "/reportsx", false},
+		{"/reports/**/summary", "/reports/summary", true},
+		{"/reports/**/summary", "/reports/2019/q1/summary", true},
+		{"/reports/**/summary", "/reports/2019/q1/details", false},
+		{"**/summary", "summary", true},
+		{"**/summary", "reports/2019/summary", true},
+		{"**", "/any/thing", true},
+		{"/reports/{2019,2020}/*", "/reports/2020/q1", true},
+		{"/reports/{2019,2020}/*", "/reports/2021/q1", false},
+		{"/reports/{q*,summary}", "/reports/q1", true},
+		{"/reports/{q*,summary}", "/reports/summary", true},
+		{"/reports/{2019/**,2020}", "/reports/2019/q1/summary", true},
+		{"/reports/{a,{b,c}}", "/reports/c", true},
+		{"/reports/{a,}", "/reports/", true},
+		{"projects/*/zones/*/instances/**", "projects/p1/zones/z1/instances/i1", true},
+		{"projects/*/zones/*/instances/**", "projects/p1/zones/z1/disks/d1", false},
+		{"/reports/\\*", "/reports/*", true},
+		{"/reports/\\*", "/reports/2019", false},
+		{"/reports/a.b", "/reports/axb", false},
+		{"/reports/(x)", "/reports/(x)", true},
+		{"/报告/*", "/报告/年度", true},
+	}
+	for _, test := range tests {
+		if match := Match(test.pattern, test.resource); match != test.match {
+			t.Errorf("glob %q and resource %q: expected %v, but got %v", test.pattern, test.resource, test.match, match)
+		}
+	}
+}
+
+func TestInvalidPatterns(t *testing.T) {
+	for _, pattern := range []string{"/reports/{a,b", "/reports/a}", "/reports/\\", "/reports/{a,{b}"} {
+		if _, err := Regexp(pattern); errors.Code(err) != errors.InvalidRequest {
+			t.Errorf("glob %q should be invalid, but got %v", pattern, err)
+		}
+		if Match(pattern, "/reports/a") {
+			t.Errorf("invalid glob %q should match nothing", pattern)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/teramoby/speedle-plus/pkg/errors"
@@ -31,6 +32,12 @@ func errorCodeToHTTPStatus(err error) int {
 		return http.StatusBadRequest
 	case errors.ExceedLimit:
 		return http.StatusForbidden
+	case errors.RevisionConflict:
+		return http.StatusPreconditionFailed
+	case errors.Unauthenticated:
+		return http.StatusUnauthorized
+	case errors.PermissionDenied:
+		return http.StatusForbidden
 	default:
 		// Unknown status
 		return http.StatusInternalServerError
@@ -107,6 +114,31 @@ func SendBearerAtzErrorResponse(w http.ResponseWriter, realm string, err string,
 	w.WriteHeader(http.StatusUnauthorized)
 }
 
+// SetETag sets the ETag header of a response to the revision of the returned entity,
+// nothing is set if the revision is unknown
+func SetETag(w http.ResponseWriter, revision int64) {
+	if revision > 0 {
+		w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(revision, 10)))
+	}
+}
+
+// ParseIfMatch returns the revision in the If-Match header of a request, ok is false if
+// there is no such header. The wildcard "*" matches any revision, and is returned as 0.
+func ParseIfMatch(r *http.Request) (revision int64, ok bool, err error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if len(value) == 0 {
+		return 0, false, nil
+	}
+	if value == "*" {
+		return 0, true, nil
+	}
+	revision, err = strconv.ParseInt(strings.Trim(strings.TrimPrefix(value, "W/"), "\""), 10, 64)
+	if err != nil || revision <= 0 {
+		return 0, false, errors.Errorf(errors.InvalidRequest, "invalid If-Match header %q", value)
+	}
+	return revision, true, nil
+}
+
 func SendPageNotFoundResponse(w http.ResponseWriter) {
 	http.Error(w, "", http.StatusNotFound)
 }
@@ -0,0 +1,246 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package pdl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/subjectutils"
+)
+
+// keywords are quoted when they are used as tokens, so they are not taken as keywords when parsed
+var keywords = []string{grant, deny, "user", "group", "role", "entity", "from", "on", "priority", "if"}
+
+// FormatPolicy formats a policy object to a line, which is parsed by ParsePolicy to the same policy.
+// The name, ID and metadata of the policy are not kept in the line.
+func FormatPolicy(policy *pms.Policy) (string, error) {
+	effect, err := formatEffect(policy.Effect)
+	if err != nil {
+		return "", err
+	}
+	if len(policy.Principals) == 0 {
+		return "", errors.New("No principal found")
+	}
+	var principals []string
+	for _, andPrincipals := range policy.Principals {
+		if len(andPrincipals) == 0 {
+			return "", errors.New("No principal found between ()")
+		}
+		var formatted []string
+		for _, principal := range andPrincipals {
+			p, err := formatPrincipal(principal)
+			if err != nil {
+				return "", err
+			}
+			formatted = append(formatted, p)
+		}
+		if len(formatted) == 1 {
+			principals = append(principals, formatted[0])
+		} else {
+			principals = append(principals, "("+strings.Join(formatted, ", ")+")")
+		}
+	}
+	if len(policy.Permissions) == 0 {
+		return "", errors.New("No permission found")
+	}
+	var perms []string
+	for _, perm := range policy.Permissions {
+		p, err := formatPermission(perm)
+		if err != nil {
+			return "", err
+		}
+		perms = append(perms, p)
+	}
+	cmd := fmt.Sprintf("%s %s %s", effect, strings.Join(principals, ", "), strings.Join(perms, ", "))
+	if policy.Priority != 0 {
+		cmd += fmt.Sprintf(" priority %d", policy.Priority)
+	}
+	return appendCondition(cmd, policy.Condition)
+}
+
+// FormatRolePolicy formats a role policy object to a line, which is parsed by ParseRolePolicy to the same
+// role policy. The name, ID and metadata of the role policy are not kept in the line.
+func FormatRolePolicy(rolePolicy *pms.RolePolicy) (string, error) {
+	effect, err := formatEffect(rolePolicy.Effect)
+	if err != nil {
+		return "", err
+	}
+	if len(rolePolicy.Principals) == 0 {
+		return "", errors.New("No principal found")
+	}
+	var principals []string
+	for _, principal := range rolePolicy.Principals {
+		p, err := formatPrincipal(principal)
+		if err != nil {
+			return "", err
+		}
+		principals = append(principals, p)
+	}
+	if len(rolePolicy.Roles) == 0 {
+		return "", errors.New("No role found")
+	}
+	var roles []string
+	for _, role := range rolePolicy.Roles {
+		r, err := formatToken(role)
+		if err != nil {
+			return "", err
+		}
+		if strings.EqualFold("role", role) {
+			// a role named role is taken as the key word, unless it follows the key word
+			r = "role " + r
+		}
+		roles = append(roles, r)
+	}
+	cmd := fmt.Sprintf("%s %s %s", effect, strings.Join(principals, ", "), strings.Join(roles, ", "))
+
+	var resources []string
+	for _, resource := range rolePolicy.Resources {
+		r, err := formatResource(resource)
+		if err != nil {
+			return "", err
+		}
+		resources = append(resources, r)
+	}
+	for _, resExpr := range rolePolicy.ResourceExpressions {
+		r, err := formatToken(resExprPrefix + resExpr)
+		if err != nil {
+			return "", err
+		}
+		resources = append(resources, r)
+	}
+	for _, resGlob := range rolePolicy.ResourceGlobs {
+		r, err := formatToken(resGlobPrefix + resGlob)
+		if err != nil {
+			return "", err
+		}
+		resources = append(resources, r)
+	}
+	if len(resources) > 0 {
+		cmd += " on " + strings.Join(resources, ", ")
+	}
+	return appendCondition(cmd, rolePolicy.Condition)
+}
+
+func formatEffect(effect string) (string, error) {
+	switch {
+	case strings.EqualFold(grant, effect):
+		return grant, nil
+	case strings.EqualFold(deny, effect):
+		return deny, nil
+	default:
+		return "", fmt.Errorf("Invalid effect %q", effect)
+	}
+}
+
+func formatPrincipal(encoded string) (string, error) {
+	principal, err := subjectutils.DecodePrincipal(encoded)
+	if err != nil {
+		return "", err
+	}
+	switch principal.Type {
+	case ads.PRINCIPAL_TYPE_USER, ads.PRINCIPAL_TYPE_GROUP, ads.PRINCIPAL_TYPE_ROLE, ads.PRINCIPAL_TYPE_ENTITY:
+	default:
+		return "", fmt.Errorf("Invalid principal type %q in principal %q", principal.Type, encoded)
+	}
+	name, err := formatToken(principal.Name)
+	if err != nil {
+		return "", err
+	}
+	if len(principal.IDD) == 0 {
+		return principal.Type + " " + name, nil
+	}
+	idd, err := formatToken(principal.IDD)
+	if err != nil {
+		return "", err
+	}
+	return principal.Type + " " + name + " from " + idd, nil
+}
+
+func formatPermission(perm *pms.Permission) (string, error) {
+	if len(perm.Actions) == 0 {
+		return "", errors.New("No action found in permission")
+	}
+	var actions []string
+	for _, action := range perm.Actions {
+		a, err := formatToken(action)
+		if err != nil {
+			return "", err
+		}
+		actions = append(actions, a)
+	}
+	var resource string
+	var err error
+	switch {
+	case len(perm.Resource) > 0 && len(perm.ResourceExpression) > 0:
+		return "", fmt.Errorf("Permission has both resource %q and resource expression %q", perm.Resource, perm.ResourceExpression)
+	case len(perm.Resource) > 0 && len(perm.ResourceGlob) > 0:
+		return "", fmt.Errorf("Permission has both resource %q and resource glob %q", perm.Resource, perm.ResourceGlob)
+	case len(perm.ResourceExpression) > 0 && len(perm.ResourceGlob) > 0:
+		return "", fmt.Errorf("Permission has both resource expression %q and resource glob %q", perm.ResourceExpression, perm.ResourceGlob)
+	case len(perm.ResourceExpression) > 0:
+		resource, err = formatToken(resExprPrefix + perm.ResourceExpression)
+	case len(perm.ResourceGlob) > 0:
+		resource, err = formatToken(resGlobPrefix + perm.ResourceGlob)
+	default:
+		resource, err = formatResource(perm.Resource)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(actions, ",") + " " + resource, nil
+}
+
+func formatResource(resource string) (string, error) {
+	if isResExpr, _ := isResExpr(resource); isResExpr {
+		return "", fmt.Errorf("Resource %q is taken as a resource expression", resource)
+	}
+	if isResGlob, _ := isResGlob(resource); isResGlob {
+		return "", fmt.Errorf("Resource %q is taken as a resource glob", resource)
+	}
+	return formatToken(resource)
+}
+
+// formatToken quotes a token if it can not be read by getToken as it is
+func formatToken(token string) (string, error) {
+	if len(token) == 0 {
+		return "", errors.New("Empty token found")
+	}
+	if strings.ContainsAny(token, "\r\n") {
+		return "", fmt.Errorf("Token %q has line breaks", token)
+	}
+	needQuote := token[0] == '"' || token[0] == '\'' || strings.ContainsAny(token, ",()") ||
+		strings.IndexFunc(token, unicode.IsSpace) >= 0
+	for _, keyword := range keywords {
+		if strings.EqualFold(keyword, token) {
+			needQuote = true
+		}
+	}
+	switch {
+	case !needQuote:
+		return token, nil
+	case !strings.Contains(token, `"`):
+		return `"` + token + `"`, nil
+	case !strings.Contains(token, "'"):
+		return "'" + token + "'", nil
+	default:
+		return "", fmt.Errorf("Token %q has both single and double quotes", token)
+	}
+}
+
+// appendCondition appends the condition, the spaces around it are not kept
+func appendCondition(cmd, condition string) (string, error) {
+	condition = strings.TrimSpace(condition)
+	if len(condition) == 0 {
+		return cmd, nil
+	}
+	if strings.ContainsAny(condition, "\r\n") {
+		return "", fmt.Errorf("Condition %q has line breaks", condition)
+	}
+	return cmd + " if " + condition, nil
+}
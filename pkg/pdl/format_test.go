@@ -0,0 +1,93 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package pdl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+)
+
+func TestFormatPolicy(t *testing.T) {
+	cmds := []string{
+		"grant user Alice get,del books",
+		"DENY group Developers read expr:/books/.*",
+		"grant (user Alice, group Readers from idcs), role admin, entity /bin/cat read,write books, list shelf if x == false",
+		`grant user "Alice Smith" from 'my idd' "read, write" 'a "quoted" resource'`,
+		`grant user from "from" "if" if 'i > 30 && j < 4' || t >= "2012-05-06"`,
+		"grant user role read,write 'on'",
+		"deny user Alice read books priority 10 if x > 1",
+		"grant user Alice read 'priority' priority -3",
+		"grant group Analysts get,list glob:/reports/**, get glob:/reports/{2019,2020}/*",
+	}
+	for _, cmd := range cmds {
+		want, _, err := ParsePolicy(cmd, "")
+		if err != nil {
+			t.Fatalf("fail to parse %q: %v", cmd, err)
+		}
+		line, err := FormatPolicy(want)
+		if err != nil {
+			t.Fatalf("fail to format %q: %v", cmd, err)
+		}
+		got, _, err := ParsePolicy(line, "")
+		if err != nil {
+			t.Fatalf("fail to parse formatted line %q: %v", line, err)
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("cmd: %s, formatted: %s, got %v, want %v", cmd, line, got, want)
+		}
+	}
+}
+
+func TestFormatRolePolicy(t *testing.T) {
+	cmds := []string{
+		"grant user Alice role1",
+		"deny user Alice, group Developers from idcs role1, role2 on r1,r2, r3",
+		"grant user Alice role role, role2 on expr:/books/.*, r1 if a = 3 &&   b == 4",
+		`grant entity "/bin/my cat" 'on' on "if" if x > 1`,
+		"grant user Alice auditor on glob:/reports/{2019,2020}/**, r1, expr:/books/.*",
+	}
+	for _, cmd := range cmds {
+		want, _, err := ParseRolePolicy(cmd, "")
+		if err != nil {
+			t.Fatalf("fail to parse %q: %v", cmd, err)
+		}
+		line, err := FormatRolePolicy(want)
+		if err != nil {
+			t.Fatalf("fail to format %q: %v", cmd, err)
+		}
+		got, _, err := ParseRolePolicy(line, "")
+		if err != nil {
+			t.Fatalf("fail to parse formatted line %q: %v", line, err)
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("cmd: %s, formatted: %s, got %v, want %v", cmd, line, got, want)
+		}
+	}
+}
+
+func TestFormatNeg(t *testing.T) {
+	policies := []*pms.Policy{
+		{Effect: "permit", Principals: [][]string{{"user:Alice"}}, Permissions: []*pms.Permission{{Resource: "books", Actions: []string{"read"}}}},
+		{Effect: "grant", Permissions: []*pms.Permission{{Resource: "books", Actions: []string{"read"}}}},
+		{Effect: "grant", Principals: [][]string{{"alien:Alice"}}, Permissions: []*pms.Permission{{Resource: "books", Actions: []string{"read"}}}},
+		{Effect: "grant", Principals: [][]string{{"user:Alice"}}},
+		{Effect: "grant", Principals: [][]string{{"user:Alice"}}, Permissions: []*pms.Permission{{Resource: "books"}}},
+		{Effect: "grant", Principals: [][]string{{"user:Alice"}}, Permissions: []*pms.Permission{{Resource: "expr:books", Actions: []string{"read"}}}},
+		{Effect: "grant", Principals: [][]string{{"user:Alice"}}, Permissions: []*pms.Permission{{Resource: "glob:books", Actions: []string{"read"}}}},
+		{Effect: "grant", Principals: [][]string{{"user:Alice"}}, Permissions: []*pms.Permission{{Resource: "books", ResourceGlob: "/books/*", Actions: []string{"read"}}}},
+		{Effect: "grant", Principals: [][]string{{"user:Alice"}}, Permissions: []*pms.Permission{{ResourceExpression: "/books/.*", ResourceGlob: "/books/*", Actions: []string{"read"}}}},
+		{Effect: "grant", Principals: [][]string{{"user:Alice"}}, Permissions: []*pms.Permission{{Resource: `a "b" 'c'`, Actions: []string{"read"}}}},
+		{Effect: "grant", Principals: [][]string{{"user:Alice"}}, Permissions: []*pms.Permission{{Resource: "books", Actions: []string{"read"}}}, Condition: "a\n&& b"},
+	}
+	for _, policy := range policies {
+		if line, err := FormatPolicy(policy); err == nil {
+			t.Errorf("policy %v should not be formatted, but got %q", policy, line)
+		}
+	}
+	if line, err := FormatRolePolicy(&pms.RolePolicy{Effect: "grant", Principals: []string{"user:Alice"}}); err == nil {
+		t.Errorf("role policy without roles should not be formatted, but got %q", line)
+	}
+}
@@ -9,11 +9,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"unicode"
 
 	"github.com/teramoby/speedle-plus/api/ads"
 	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/glob"
 	"github.com/teramoby/speedle-plus/pkg/subjectutils"
 )
 
@@ -22,6 +24,7 @@ const (
 	deny  = "deny"
 
 	resExprPrefix = "expr:"
+	resGlobPrefix = "glob:"
 )
 
 // ParsePolicy parses a line to a policy object
@@ -41,6 +44,10 @@ func ParsePolicy(cmd, name string) (*pms.Policy, io.Reader, error) {
 	if len(perms) == 0 {
 		return nil, nil, errors.New("No permission found")
 	}
+	priority, i, err := getPriority(cmd, i)
+	if err != nil {
+		return nil, nil, err
+	}
 	condition, _, err := getCondition(cmd, i)
 	if err != nil {
 		return nil, nil, err
@@ -51,6 +58,7 @@ func ParsePolicy(cmd, name string) (*pms.Policy, io.Reader, error) {
 		Effect:      effect,
 		Principals:  principals,
 		Permissions: perms,
+		Priority:    priority,
 		Condition:   condition,
 	}
 
@@ -74,7 +82,7 @@ func ParseRolePolicy(cmd, name string) (*pms.RolePolicy, io.Reader, error) {
 	if len(roles) == 0 {
 		return nil, nil, errors.New("No role found")
 	}
-	resources, resExps, i, err := getResources(cmd, i)
+	resources, resExps, resGlobs, i, err := getResources(cmd, i)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -88,6 +96,7 @@ func ParseRolePolicy(cmd, name string) (*pms.RolePolicy, io.Reader, error) {
 		Principals:          principals,
 		Resources:           resources,
 		ResourceExpressions: resExps,
+		ResourceGlobs:       resGlobs,
 		Roles:               roles,
 		Condition:           condition,
 	}
@@ -337,6 +346,7 @@ func getPermission(cmd string, i int) (*pms.Permission, int, error) {
 	if len(acts) == 0 {
 		return nil, i, getError("Not found permission", cmd, i)
 	}
+	start := skipSpaces(cmd, i)
 	res, i := getToken(cmd, i)
 	if res == "" {
 		return nil, i, getError("Not found permission", cmd, i)
@@ -345,6 +355,12 @@ func getPermission(cmd string, i int) (*pms.Permission, int, error) {
 	if isResExpr, resExpr := isResExpr(res); isResExpr {
 		return &pms.Permission{ResourceExpression: resExpr, Actions: acts}, i, nil
 	}
+	if isResGlob, resGlob := isResGlob(res); isResGlob {
+		if _, err := glob.Regexp(resGlob); err != nil {
+			return nil, i, getError(err.Error(), cmd, start)
+		}
+		return &pms.Permission{ResourceGlob: resGlob, Actions: acts}, i, nil
+	}
 
 	return &pms.Permission{Resource: res, Actions: acts}, i, nil
 }
@@ -356,29 +372,41 @@ func isResExpr(res string) (bool, string) {
 	return false, res
 }
 
-func getResources(cmd string, i int) ([]string, []string, int, error) {
+func isResGlob(res string) (bool, string) {
+	if strings.HasPrefix(res, resGlobPrefix) {
+		return true, strings.TrimPrefix(res, resGlobPrefix)
+	}
+	return false, res
+}
+
+func getResources(cmd string, i int) ([]string, []string, []string, int, error) {
 	i = skipSpaces(cmd, i)
 	if i+3 <= len(cmd) && strings.EqualFold("on ", cmd[i:i+3]) {
 		i += 3
+		start := skipSpaces(cmd, i)
 		tokens, i, err := getTokens(cmd, i, "resource")
 		if err != nil {
-			return nil, nil, i, err
+			return nil, nil, nil, i, err
 		}
 		if len(tokens) == 0 {
-			return nil, nil, -1, getError("Not found resource", cmd, i)
+			return nil, nil, nil, -1, getError("Not found resource", cmd, i)
 		}
-		var resources, resExps []string
+		var resources, resExps, resGlobs []string
 		for _, token := range tokens {
-			isResExpr, resExp := isResExpr(token)
-			if !isResExpr {
-				resources = append(resources, token)
-			} else {
+			if isResExpr, resExp := isResExpr(token); isResExpr {
 				resExps = append(resExps, resExp)
+			} else if isResGlob, resGlob := isResGlob(token); isResGlob {
+				if _, err := glob.Regexp(resGlob); err != nil {
+					return nil, nil, nil, -1, getError(err.Error(), cmd, start)
+				}
+				resGlobs = append(resGlobs, resGlob)
+			} else {
+				resources = append(resources, token)
 			}
 		}
-		return resources, resExps, i, nil
+		return resources, resExps, resGlobs, i, nil
 	}
-	return nil, []string{}, i, nil
+	return nil, []string{}, nil, i, nil
 }
 
 func getService(cmd string, i int) (string, int, error) {
@@ -395,6 +423,24 @@ func getService(cmd string, i int) (string, int, error) {
 	return "", i, nil
 }
 
+func getPriority(cmd string, i int) (int, int, error) {
+	i = skipSpaces(cmd, i)
+	if i+9 <= len(cmd) && strings.EqualFold("priority ", cmd[i:i+9]) {
+		i += 9
+		var token string
+		token, i = getToken(cmd, i)
+		if token == "" {
+			return 0, -1, getError("Not found priority", cmd, i)
+		}
+		priority, err := strconv.Atoi(token)
+		if err != nil {
+			return 0, -1, getError("Priority should be an integer", cmd, i-len(token))
+		}
+		return priority, i, nil
+	}
+	return 0, i, nil
+}
+
 func getCondition(cmd string, i int) (string, int, error) {
 	i = skipSpaces(cmd, i)
 	if i+3 <= len(cmd) && strings.EqualFold("if ", cmd[i:i+3]) {
@@ -443,6 +489,9 @@ func getToken(cmd string, i int) (string, int) {
 		i++
 	}
 
+	// the alternatives of an unquoted resource glob, e.g. glob:/reports/{2019,2020}, are in one token
+	isGlob := strings.HasPrefix(cmd[i:], resGlobPrefix)
+	braces := 0
 	var buffer bytes.Buffer
 	for ; i < len(cmd); i++ {
 		if end == '"' || end == '\'' {
@@ -450,7 +499,14 @@ func getToken(cmd string, i int) (string, int) {
 				i++
 				break
 			}
-		} else if cmd[i] == ' ' || cmd[i] == ',' || cmd[i] == '(' || cmd[i] == ')' {
+		} else if isGlob && cmd[i] == '\\' && i+1 < len(cmd) {
+			buffer.WriteByte(cmd[i])
+			i++
+		} else if isGlob && cmd[i] == '{' {
+			braces++
+		} else if isGlob && cmd[i] == '}' && braces > 0 {
+			braces--
+		} else if cmd[i] == ' ' || (cmd[i] == ',' && braces == 0) || cmd[i] == '(' || cmd[i] == ')' {
 			break
 		}
 		buffer.WriteByte(cmd[i])
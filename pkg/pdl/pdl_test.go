@@ -416,7 +416,7 @@ func TestResources(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		got, _, i, err := getResources(tc.cmd, 0)
+		got, _, _, i, err := getResources(tc.cmd, 0)
 		if err != nil {
 			t.Errorf("cmd: %s, error: %v", tc.cmd, err)
 		}
@@ -435,6 +435,42 @@ func TestResources(t *testing.T) {
 	}
 }
 
+func TestResourceGlobs(t *testing.T) {
+	policy, _, err := ParsePolicy(`grant user Alice get,list glob:/reports/{2019,2020}/**, get "glob:/reports/*", list /reports`, "")
+	if err != nil {
+		t.Fatalf("fail to parse policy: %v", err)
+	}
+	want := []*pms.Permission{
+		{Actions: []string{"get", "list"}, ResourceGlob: "/reports/{2019,2020}/**"},
+		{Actions: []string{"get"}, ResourceGlob: "/reports/*"},
+		{Actions: []string{"list"}, Resource: "/reports"},
+	}
+	if !reflect.DeepEqual(policy.Permissions, want) {
+		t.Errorf("got permissions %v, want %v", toJSON(policy.Permissions), toJSON(want))
+	}
+
+	rolePolicy, _, err := ParseRolePolicy(`grant user Alice auditor on glob:/reports/{a\,b,c}/*, /reports, "glob:/logs/**"`, "")
+	if err != nil {
+		t.Fatalf("fail to parse role policy: %v", err)
+	}
+	if !reflect.DeepEqual(rolePolicy.ResourceGlobs, []string{`/reports/{a\,b,c}/*`, "/logs/**"}) ||
+		!reflect.DeepEqual(rolePolicy.Resources, []string{"/reports"}) {
+		t.Errorf("got resources %q and resource globs %q", rolePolicy.Resources, rolePolicy.ResourceGlobs)
+	}
+
+	for _, cmd := range []string{
+		"grant user Alice get glob:/reports/{2019",
+		"grant user Alice get glob:/reports/}",
+	} {
+		if _, _, err := ParsePolicy(cmd, ""); err == nil {
+			t.Errorf("cmd %q should fail", cmd)
+		}
+	}
+	if _, _, err := ParseRolePolicy("grant user Alice auditor on glob:/reports/{2019", ""); err == nil {
+		t.Errorf("role policy with invalid glob should fail")
+	}
+}
+
 func TestService(t *testing.T) {
 	testCases := []struct {
 		cmd  string
@@ -518,6 +554,33 @@ func TestConditions(t *testing.T) {
 	}
 }
 
+func TestPriority(t *testing.T) {
+	testCases := []struct {
+		cmd  string
+		want int
+	}{
+		{cmd: "", want: 0},
+		{cmd: "priority 10", want: 10},
+		{cmd: "  PRIORITY   -2 if a=3", want: -2},
+		{cmd: "if priority > 3", want: 0},
+	}
+	for _, tc := range testCases {
+		got, _, err := getPriority(tc.cmd, 0)
+		if err != nil {
+			t.Errorf("cmd: %s, error: %v", tc.cmd, err)
+		}
+		if got != tc.want {
+			t.Errorf("cmd: %s, got %v, want %v", tc.cmd, got, tc.want)
+		}
+	}
+
+	for _, cmd := range []string{"priority ", "priority high", "priority 1.5"} {
+		if _, _, err := getPriority(cmd, 0); err == nil {
+			t.Errorf("cmd: %s, should fail", cmd)
+		}
+	}
+}
+
 func TestFullCmd(t *testing.T) {
 	cmd := "grant user  user_bool_equal1   get,del res_equal1 if x == false"
 	_, _, err := ParsePolicy(cmd, "test")
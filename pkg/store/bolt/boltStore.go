@@ -0,0 +1,1009 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package bolt
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+	"github.com/teramoby/speedle-plus/pkg/store/filter"
+	"github.com/teramoby/speedle-plus/pkg/suid"
+)
+
+// openTimeout is the time to wait for the lock of the database file, which is held by one process at a time
+const openTimeout = 30 * time.Second
+
+// Store keeps policies in an embedded bbolt database file, every write of which is a crash-safe transaction.
+// The file is locked by one process at a time, so it is opened when the store is used and closed when the store
+// is idle, which allows PMS and ADS on the same node to share it.
+type Store struct {
+	path         string
+	pollInterval time.Duration
+
+	dbLock sync.Mutex
+	db     *bolt.DB
+	refs   int
+
+	stopLock sync.Mutex
+	stop     chan struct{}
+}
+
+// change is a write of the store. All changes made by it take the same revision.
+type change struct {
+	tx       *bolt.Tx
+	revision int64
+	// records are appended to the changelog, and sent as store change events to watchers
+	records []changeRecord
+	// ops are the changes made, which are kept in history
+	ops []*pms.Operation
+}
+
+func (c *change) record(record changeRecord) {
+	c.records = append(c.records, record)
+}
+
+// acquire opens the database if it is not opened, and must be followed by release
+func (s *Store) acquire() (*bolt.DB, error) {
+	s.dbLock.Lock()
+	defer s.dbLock.Unlock()
+	if s.db == nil {
+		db, err := bolt.Open(s.path, 0600, &bolt.Options{Timeout: openTimeout})
+		if err != nil {
+			return nil, errors.Wrapf(err, errors.StoreError, "unable to open bolt store %q", s.path)
+		}
+		s.db = db
+	}
+	s.refs++
+	return s.db, nil
+}
+
+// release closes the database once nobody uses it
+func (s *Store) release() {
+	s.dbLock.Lock()
+	defer s.dbLock.Unlock()
+	s.refs--
+	if s.refs == 0 {
+		if err := s.db.Close(); err != nil {
+			log.Warningf("failed to close bolt store %q, error: %v", s.path, err)
+		}
+		s.db = nil
+	}
+}
+
+// init creates the buckets of the store
+func (s *Store) init() error {
+	return s.update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketMeta, bucketServices, bucketFunctions, bucketChanges, bucketHistory, bucketDiscover} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return storeError(err)
+			}
+		}
+		return nil
+	})
+}
+
+// update runs fn in a read-write transaction, nothing is written if fn fails
+func (s *Store) update(fn func(tx *bolt.Tx) error) error {
+	db, err := s.acquire()
+	if err != nil {
+		return err
+	}
+	defer s.release()
+	tx, err := db.Begin(true)
+	if err != nil {
+		return errors.Wrap(err, errors.StoreError, "failed to start transaction")
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			tx.Rollback()
+		}
+	}()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, errors.StoreError, "failed to commit transaction")
+	}
+	committed = true
+	return nil
+}
+
+// write runs fn in a transaction with a new revision, and appends the changes made by fn to the changelog and
+// history in the same transaction. Nothing is written if fn fails.
+func (s *Store) write(fn func(c *change) error) error {
+	return s.update(func(tx *bolt.Tx) error {
+		revision := storeRevision(tx) + 1
+		if err := tx.Bucket(bucketMeta).Put(keyRevision, itob(revision)); err != nil {
+			return storeError(err)
+		}
+		c := change{tx: tx, revision: revision}
+		if err := fn(&c); err != nil {
+			return err
+		}
+		if err := appendChangelog(tx, revision, c.records); err != nil {
+			return err
+		}
+		return putHistory(tx, store.NewHistoryRecords(c.ops, revision))
+	})
+}
+
+// read runs fn in a read-only transaction
+func (s *Store) read(fn func(tx *bolt.Tx) error) error {
+	db, err := s.acquire()
+	if err != nil {
+		return err
+	}
+	defer s.release()
+	return db.View(fn)
+}
+
+func checkRevision(expected, current int64, kind, name string) error {
+	if expected > 0 && expected != current {
+		return errors.Errorf(errors.RevisionConflict, "revision %d of %s %q does not match the current revision %d", expected, kind, name, current)
+	}
+	return nil
+}
+
+// ReadPolicyStore reads all services and functions
+func (s *Store) ReadPolicyStore() (*pms.PolicyStore, error) {
+	var ps pms.PolicyStore
+	err := s.read(func(tx *bolt.Tx) error {
+		var err error
+		if ps.Services, err = loadServices(tx); err != nil {
+			return err
+		}
+		ps.Functions, err = loadFunctions(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ps, nil
+}
+
+// WritePolicyStore replaces all services and functions. The IDs of policies and role policies are kept,
+// and generated if they are empty. Watchers reload the whole store.
+func (s *Store) WritePolicyStore(ps *pms.PolicyStore) error {
+	return s.write(func(c *change) error {
+		if err := s.deleteServices(c); err != nil {
+			return err
+		}
+		if err := s.deleteFunctions(c); err != nil {
+			return err
+		}
+		for _, service := range ps.Services {
+			dupService := *service
+			dupService.Revision = c.revision
+			for _, policy := range dupService.Policies {
+				if len(policy.ID) == 0 {
+					policy.ID = suid.New().String()
+				}
+				policy.Revision = c.revision
+			}
+			for _, rolePolicy := range dupService.RolePolicies {
+				if len(rolePolicy.ID) == 0 {
+					rolePolicy.ID = suid.New().String()
+				}
+				rolePolicy.Revision = c.revision
+			}
+			if err := putServiceBucket(c.tx, &dupService); err != nil {
+				return err
+			}
+			c.ops = append(c.ops, &pms.Operation{Op: pms.OpCreate, Kind: pms.KindService, Service: &dupService})
+		}
+		for _, function := range ps.Functions {
+			if _, err := s.createFunction(c, function); err != nil {
+				return err
+			}
+		}
+		c.records = []changeRecord{{Type: pms.FULL_RELOAD}}
+		return nil
+	})
+}
+
+// ListAllServices lists all the services
+func (s *Store) ListAllServices() ([]*pms.Service, error) {
+	var services []*pms.Service
+	err := s.read(func(tx *bolt.Tx) error {
+		var err error
+		services, err = loadServices(tx)
+		return err
+	})
+	return services, err
+}
+
+// GetServiceNames reads all the service names
+func (s *Store) GetServiceNames() ([]string, error) {
+	var names []string
+	err := s.read(func(tx *bolt.Tx) error {
+		names = serviceNames(tx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// GetPolicyAndRolePolicyCounts returns a map, in which the key is the service name, and the value is the count of both policies and role policies in the service.
+func (s *Store) GetPolicyAndRolePolicyCounts() (map[string]*pms.PolicyAndRolePolicyCount, error) {
+	countMap := make(map[string]*pms.PolicyAndRolePolicyCount)
+	err := s.read(func(tx *bolt.Tx) error {
+		for _, name := range serviceNames(tx) {
+			sb, err := serviceBucket(tx, name)
+			if err != nil {
+				return err
+			}
+			countMap[name] = &pms.PolicyAndRolePolicyCount{
+				PolicyCount:     policyBuckets.count(sb),
+				RolePolicyCount: rolePolicyBuckets.count(sb),
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return countMap, nil
+}
+
+// GetServiceCount gets the service count
+func (s *Store) GetServiceCount() (int64, error) {
+	var count int64
+	err := s.read(func(tx *bolt.Tx) error {
+		count = int64(len(serviceNames(tx)))
+		return nil
+	})
+	return count, err
+}
+
+// GetService gets the detailed info of a service
+func (s *Store) GetService(serviceName string) (*pms.Service, error) {
+	var service *pms.Service
+	err := s.read(func(tx *bolt.Tx) error {
+		var err error
+		service, err = loadService(tx, serviceName)
+		return err
+	})
+	return service, err
+}
+
+// generateID returns a copy of service whose policies and role policies have new IDs
+func generateID(service *pms.Service, revision int64) *pms.Service {
+	result := *service
+	result.Revision = revision
+	result.Policies = make([]*pms.Policy, 0, len(service.Policies))
+	for _, policy := range service.Policies {
+		dupPolicy := *policy
+		dupPolicy.ID = suid.New().String()
+		dupPolicy.Revision = revision
+		result.Policies = append(result.Policies, &dupPolicy)
+	}
+	result.RolePolicies = make([]*pms.RolePolicy, 0, len(service.RolePolicies))
+	for _, rolePolicy := range service.RolePolicies {
+		dupRolePolicy := *rolePolicy
+		dupRolePolicy.ID = suid.New().String()
+		dupRolePolicy.Revision = revision
+		result.RolePolicies = append(result.RolePolicies, &dupRolePolicy)
+	}
+	return &result
+}
+
+// CreateService creates a new service
+func (s *Store) CreateService(service *pms.Service) error {
+	return s.write(func(c *change) error {
+		_, err := s.createService(c, service)
+		return err
+	})
+}
+
+func (s *Store) createService(c *change, service *pms.Service) (*pms.Service, error) {
+	if _, err := serviceBucket(c.tx, service.Name); err == nil {
+		return nil, errors.Errorf(errors.EntityAlreadyExists, "service %q already exists", service.Name)
+	}
+	serviceWithIDs := generateID(service, c.revision)
+	if err := putServiceBucket(c.tx, serviceWithIDs); err != nil {
+		return nil, err
+	}
+	c.record(changeRecord{Type: pms.SERVICE_ADD, Service: serviceWithIDs})
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpCreate, Kind: pms.KindService, Service: serviceWithIDs})
+	return serviceWithIDs, nil
+}
+
+// UpdateService updates the type, combining algorithm, default effect and metadata of an existing service
+func (s *Store) UpdateService(service *pms.Service) error {
+	return s.write(func(c *change) error {
+		_, err := s.updateService(c, service)
+		return err
+	})
+}
+
+func (s *Store) updateService(c *change, service *pms.Service) (*pms.Service, error) {
+	sb, err := serviceBucket(c.tx, service.Name)
+	if err != nil {
+		return nil, err
+	}
+	current, err := getServiceItself(sb)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRevision(service.Revision, current.Revision, "service", service.Name); err != nil {
+		return nil, err
+	}
+	current.Type = service.Type
+	current.CombiningAlgorithm = service.CombiningAlgorithm
+	current.DefaultEffect = service.DefaultEffect
+	current.Attributes = service.Attributes
+	current.Metadata = service.Metadata
+	current.Revision = c.revision
+	if err := putServiceItself(sb, current); err != nil {
+		return nil, err
+	}
+	updated, err := loadService(c.tx, service.Name)
+	if err != nil {
+		return nil, err
+	}
+	// SERVICE_ADD replaces the whole service in runtime cache
+	c.record(changeRecord{Type: pms.SERVICE_ADD, Service: updated})
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpUpdate, Kind: pms.KindService, Service: updated})
+	return updated, nil
+}
+
+// touchService sets the revision of a service to the one of the change, any change in a service increases its revision
+func (s *Store) touchService(c *change, serviceName string) (*bolt.Bucket, error) {
+	sb, err := serviceBucket(c.tx, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	service, err := getServiceItself(sb)
+	if err != nil {
+		return nil, err
+	}
+	service.Revision = c.revision
+	if err := putServiceItself(sb, service); err != nil {
+		return nil, err
+	}
+	return sb, nil
+}
+
+// DeleteService deletes a service with its policies and role policies
+func (s *Store) DeleteService(serviceName string) error {
+	return s.write(func(c *change) error {
+		return s.deleteService(c, serviceName, 0)
+	})
+}
+
+func (s *Store) deleteService(c *change, serviceName string, revision int64) error {
+	sb, err := serviceBucket(c.tx, serviceName)
+	if err != nil {
+		return err
+	}
+	current, err := getServiceItself(sb)
+	if err != nil {
+		return err
+	}
+	if err := checkRevision(revision, current.Revision, "service", serviceName); err != nil {
+		return err
+	}
+	if err := c.tx.Bucket(bucketServices).DeleteBucket([]byte(serviceName)); err != nil {
+		return storeError(err)
+	}
+	c.record(changeRecord{Type: pms.SERVICE_DELETE, Name: serviceName})
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindService, ID: serviceName})
+	return nil
+}
+
+// DeleteServices deletes all services
+func (s *Store) DeleteServices() error {
+	return s.write(s.deleteServices)
+}
+
+func (s *Store) deleteServices(c *change) error {
+	for _, name := range serviceNames(c.tx) {
+		if err := s.deleteService(c, name, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) Type() string {
+	return StoreType
+}
+
+// For policy manager
+func (s *Store) ListAllPolicies(serviceName string, filterStr string) ([]*pms.Policy, error) {
+	f, err := filter.Parse(pms.KindPolicy, filterStr)
+	if err != nil {
+		return nil, err
+	}
+	ret := []*pms.Policy{}
+	err = s.read(func(tx *bolt.Tx) error {
+		sb, err := serviceBucket(tx, serviceName)
+		if err != nil {
+			return err
+		}
+		policies, err := loadPolicies(sb)
+		if err != nil {
+			return err
+		}
+		for _, policy := range policies {
+			if f.MatchPolicy(policy) {
+				ret = append(ret, policy)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// ListPolicies lists a page of policies in the order of creation, scanning from the last one of the previous page
+func (s *Store) ListPolicies(serviceName string, opts *pms.ListOptions) ([]*pms.Policy, string, error) {
+	f, limit, after, err := store.ParseListOptions(pms.KindPolicy, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	afterSeq, err := parseSeq(after, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	ret := []*pms.Policy{}
+	var token string
+	err = s.read(func(tx *bolt.Tx) error {
+		sb, err := serviceBucket(tx, serviceName)
+		if err != nil {
+			return err
+		}
+		return policyBuckets.scan(sb, afterSeq, func(seq int64, value []byte) (bool, error) {
+			var policy pms.Policy
+			if err := unmarshal(value, &policy); err != nil {
+				return false, err
+			}
+			if !f.MatchPolicy(&policy) {
+				return true, nil
+			}
+			ret = append(ret, &policy)
+			if len(ret) == limit {
+				token = store.ContinueToken(strconv.FormatInt(seq, 10))
+				return false, nil
+			}
+			return true, nil
+		})
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return ret, token, nil
+}
+
+// parseSeq parses the key of a continue token, which is the seq of the last entity of the previous page
+func parseSeq(key string, opts *pms.ListOptions) (int64, error) {
+	if len(key) == 0 {
+		return 0, nil
+	}
+	seq, err := strconv.ParseInt(key, 10, 64)
+	if err != nil || seq <= 0 {
+		return 0, store.InvalidContinueToken(opts.Continue)
+	}
+	return seq, nil
+}
+
+// GetPolicyCount gets the policy count of a service, or of all services if serviceName is empty
+func (s *Store) GetPolicyCount(serviceName string) (int64, error) {
+	return s.count(policyBuckets, serviceName)
+}
+
+// count gets the number of policies or role policies of a service, or of all services if serviceName is empty
+func (s *Store) count(eb entityBuckets, serviceName string) (int64, error) {
+	var count int64
+	err := s.read(func(tx *bolt.Tx) error {
+		names := []string{serviceName}
+		if len(serviceName) == 0 {
+			names = serviceNames(tx)
+		}
+		for _, name := range names {
+			sb, err := serviceBucket(tx, name)
+			if err != nil {
+				return err
+			}
+			count += eb.count(sb)
+		}
+		return nil
+	})
+	return count, err
+}
+
+func (s *Store) GetPolicy(serviceName string, id string) (*pms.Policy, error) {
+	var policy pms.Policy
+	err := s.read(func(tx *bolt.Tx) error {
+		sb, err := serviceBucket(tx, serviceName)
+		if err != nil {
+			return err
+		}
+		return policyBuckets.get(sb, serviceName, id, &policy)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (s *Store) DeletePolicy(serviceName string, id string) error {
+	return s.write(func(c *change) error {
+		return s.deletePolicy(c, serviceName, id, 0)
+	})
+}
+
+func (s *Store) deletePolicy(c *change, serviceName string, id string, revision int64) error {
+	sb, err := s.touchService(c, serviceName)
+	if err != nil {
+		return err
+	}
+	var policy pms.Policy
+	if err := policyBuckets.get(sb, serviceName, id, &policy); err != nil {
+		return err
+	}
+	if err := checkRevision(revision, policy.Revision, "policy", id); err != nil {
+		return err
+	}
+	if err := policyBuckets.delete(sb, serviceName, id); err != nil {
+		return err
+	}
+	c.record(changeRecord{Type: pms.POLICY_DELETE, ServiceName: serviceName, Policy: &policy})
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindPolicy, ServiceName: serviceName, ID: id})
+	return nil
+}
+
+func (s *Store) DeletePolicies(serviceName string) error {
+	return s.write(func(c *change) error {
+		sb, err := s.touchService(c, serviceName)
+		if err != nil {
+			return err
+		}
+		policies, err := loadPolicies(sb)
+		if err != nil {
+			return err
+		}
+		if err := policyBuckets.clear(sb); err != nil {
+			return err
+		}
+		for _, policy := range policies {
+			c.record(changeRecord{Type: pms.POLICY_DELETE, ServiceName: serviceName, Policy: policy})
+			c.ops = append(c.ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindPolicy, ServiceName: serviceName, ID: policy.ID})
+		}
+		return nil
+	})
+}
+
+func (s *Store) CreatePolicy(serviceName string, policy *pms.Policy) (*pms.Policy, error) {
+	var created *pms.Policy
+	err := s.write(func(c *change) error {
+		var err error
+		created, err = s.createPolicy(c, serviceName, policy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *Store) createPolicy(c *change, serviceName string, policy *pms.Policy) (*pms.Policy, error) {
+	sb, err := s.touchService(c, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	dupPolicy := *policy
+	dupPolicy.ID = suid.New().String()
+	dupPolicy.Revision = c.revision
+	if err := policyBuckets.put(sb, dupPolicy.ID, &dupPolicy); err != nil {
+		return nil, err
+	}
+	c.record(changeRecord{Type: pms.POLICY_ADD, ServiceName: serviceName, Policy: &dupPolicy})
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpCreate, Kind: pms.KindPolicy, ServiceName: serviceName, Policy: &dupPolicy})
+	return &dupPolicy, nil
+}
+
+func (s *Store) UpdatePolicy(serviceName string, policy *pms.Policy) (*pms.Policy, error) {
+	var updated *pms.Policy
+	err := s.write(func(c *change) error {
+		var err error
+		updated, err = s.updatePolicy(c, serviceName, policy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *Store) updatePolicy(c *change, serviceName string, policy *pms.Policy) (*pms.Policy, error) {
+	sb, err := s.touchService(c, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	var current pms.Policy
+	if err := policyBuckets.get(sb, serviceName, policy.ID, &current); err != nil {
+		return nil, err
+	}
+	if err := checkRevision(policy.Revision, current.Revision, "policy", policy.ID); err != nil {
+		return nil, err
+	}
+	// the policy keeps its position in the service
+	dupPolicy := *policy
+	dupPolicy.Revision = c.revision
+	if err := policyBuckets.put(sb, dupPolicy.ID, &dupPolicy); err != nil {
+		return nil, err
+	}
+	// POLICY_ADD replaces the policy with the same ID in runtime cache
+	c.record(changeRecord{Type: pms.POLICY_ADD, ServiceName: serviceName, Policy: &dupPolicy})
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpUpdate, Kind: pms.KindPolicy, ServiceName: serviceName, Policy: &dupPolicy})
+	return &dupPolicy, nil
+}
+
+// For role policy manager
+func (s *Store) ListAllRolePolicies(serviceName string, filterStr string) ([]*pms.RolePolicy, error) {
+	f, err := filter.Parse(pms.KindRolePolicy, filterStr)
+	if err != nil {
+		return nil, err
+	}
+	ret := []*pms.RolePolicy{}
+	err = s.read(func(tx *bolt.Tx) error {
+		sb, err := serviceBucket(tx, serviceName)
+		if err != nil {
+			return err
+		}
+		rolePolicies, err := loadRolePolicies(sb)
+		if err != nil {
+			return err
+		}
+		for _, rolePolicy := range rolePolicies {
+			if f.MatchRolePolicy(rolePolicy) {
+				ret = append(ret, rolePolicy)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// ListRolePolicies lists a page of role policies in the order of creation, scanning from the last one of the previous page
+func (s *Store) ListRolePolicies(serviceName string, opts *pms.ListOptions) ([]*pms.RolePolicy, string, error) {
+	f, limit, after, err := store.ParseListOptions(pms.KindRolePolicy, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	afterSeq, err := parseSeq(after, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	ret := []*pms.RolePolicy{}
+	var token string
+	err = s.read(func(tx *bolt.Tx) error {
+		sb, err := serviceBucket(tx, serviceName)
+		if err != nil {
+			return err
+		}
+		return rolePolicyBuckets.scan(sb, afterSeq, func(seq int64, value []byte) (bool, error) {
+			var rolePolicy pms.RolePolicy
+			if err := unmarshal(value, &rolePolicy); err != nil {
+				return false, err
+			}
+			if !f.MatchRolePolicy(&rolePolicy) {
+				return true, nil
+			}
+			ret = append(ret, &rolePolicy)
+			if len(ret) == limit {
+				token = store.ContinueToken(strconv.FormatInt(seq, 10))
+				return false, nil
+			}
+			return true, nil
+		})
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return ret, token, nil
+}
+
+// GetRolePolicyCount gets the role policy count of a service, or of all services if serviceName is empty
+func (s *Store) GetRolePolicyCount(serviceName string) (int64, error) {
+	return s.count(rolePolicyBuckets, serviceName)
+}
+
+func (s *Store) GetRolePolicy(serviceName string, id string) (*pms.RolePolicy, error) {
+	var rolePolicy pms.RolePolicy
+	err := s.read(func(tx *bolt.Tx) error {
+		sb, err := serviceBucket(tx, serviceName)
+		if err != nil {
+			return err
+		}
+		return rolePolicyBuckets.get(sb, serviceName, id, &rolePolicy)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rolePolicy, nil
+}
+
+func (s *Store) DeleteRolePolicy(serviceName string, id string) error {
+	return s.write(func(c *change) error {
+		return s.deleteRolePolicy(c, serviceName, id, 0)
+	})
+}
+
+func (s *Store) deleteRolePolicy(c *change, serviceName string, id string, revision int64) error {
+	sb, err := s.touchService(c, serviceName)
+	if err != nil {
+		return err
+	}
+	var rolePolicy pms.RolePolicy
+	if err := rolePolicyBuckets.get(sb, serviceName, id, &rolePolicy); err != nil {
+		return err
+	}
+	if err := checkRevision(revision, rolePolicy.Revision, "role policy", id); err != nil {
+		return err
+	}
+	if err := rolePolicyBuckets.delete(sb, serviceName, id); err != nil {
+		return err
+	}
+	c.record(changeRecord{Type: pms.ROLEPOLICY_DELETE, ServiceName: serviceName, RolePolicy: &rolePolicy})
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindRolePolicy, ServiceName: serviceName, ID: id})
+	return nil
+}
+
+func (s *Store) DeleteRolePolicies(serviceName string) error {
+	return s.write(func(c *change) error {
+		sb, err := s.touchService(c, serviceName)
+		if err != nil {
+			return err
+		}
+		rolePolicies, err := loadRolePolicies(sb)
+		if err != nil {
+			return err
+		}
+		if err := rolePolicyBuckets.clear(sb); err != nil {
+			return err
+		}
+		for _, rolePolicy := range rolePolicies {
+			c.record(changeRecord{Type: pms.ROLEPOLICY_DELETE, ServiceName: serviceName, RolePolicy: rolePolicy})
+			c.ops = append(c.ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindRolePolicy, ServiceName: serviceName, ID: rolePolicy.ID})
+		}
+		return nil
+	})
+}
+
+func (s *Store) CreateRolePolicy(serviceName string, rolePolicy *pms.RolePolicy) (*pms.RolePolicy, error) {
+	var created *pms.RolePolicy
+	err := s.write(func(c *change) error {
+		var err error
+		created, err = s.createRolePolicy(c, serviceName, rolePolicy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *Store) createRolePolicy(c *change, serviceName string, rolePolicy *pms.RolePolicy) (*pms.RolePolicy, error) {
+	sb, err := s.touchService(c, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	dupRolePolicy := *rolePolicy
+	dupRolePolicy.ID = suid.New().String()
+	dupRolePolicy.Revision = c.revision
+	if err := rolePolicyBuckets.put(sb, dupRolePolicy.ID, &dupRolePolicy); err != nil {
+		return nil, err
+	}
+	c.record(changeRecord{Type: pms.ROLEPOLICY_ADD, ServiceName: serviceName, RolePolicy: &dupRolePolicy})
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpCreate, Kind: pms.KindRolePolicy, ServiceName: serviceName, RolePolicy: &dupRolePolicy})
+	return &dupRolePolicy, nil
+}
+
+func (s *Store) UpdateRolePolicy(serviceName string, rolePolicy *pms.RolePolicy) (*pms.RolePolicy, error) {
+	var updated *pms.RolePolicy
+	err := s.write(func(c *change) error {
+		var err error
+		updated, err = s.updateRolePolicy(c, serviceName, rolePolicy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *Store) updateRolePolicy(c *change, serviceName string, rolePolicy *pms.RolePolicy) (*pms.RolePolicy, error) {
+	sb, err := s.touchService(c, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	var current pms.RolePolicy
+	if err := rolePolicyBuckets.get(sb, serviceName, rolePolicy.ID, &current); err != nil {
+		return nil, err
+	}
+	if err := checkRevision(rolePolicy.Revision, current.Revision, "role policy", rolePolicy.ID); err != nil {
+		return nil, err
+	}
+	dupRolePolicy := *rolePolicy
+	dupRolePolicy.Revision = c.revision
+	if err := rolePolicyBuckets.put(sb, dupRolePolicy.ID, &dupRolePolicy); err != nil {
+		return nil, err
+	}
+	c.record(changeRecord{Type: pms.ROLEPOLICY_ADD, ServiceName: serviceName, RolePolicy: &dupRolePolicy})
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpUpdate, Kind: pms.KindRolePolicy, ServiceName: serviceName, RolePolicy: &dupRolePolicy})
+	return &dupRolePolicy, nil
+}
+
+func validateFunc(function *pms.Function) error {
+	if function.Name == "" || function.FuncURL == "" {
+		return errors.New(errors.InvalidRequest, "\"name\" and \"funcURL\" in function definition can not be empty")
+	}
+	return nil
+}
+
+func (s *Store) CreateFunction(function *pms.Function) (*pms.Function, error) {
+	var created *pms.Function
+	err := s.write(func(c *change) error {
+		var err error
+		created, err = s.createFunction(c, function)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *Store) createFunction(c *change, function *pms.Function) (*pms.Function, error) {
+	if err := validateFunc(function); err != nil {
+		return nil, err
+	}
+	if _, err := loadFunction(c.tx, function.Name); err == nil {
+		return nil, errors.Errorf(errors.EntityAlreadyExists, "function %q already exists", function.Name)
+	} else if errors.Code(err) != errors.EntityNotFound {
+		return nil, err
+	}
+	dupFunction := *function
+	dupFunction.Revision = c.revision
+	if err := put(c.tx.Bucket(bucketFunctions), []byte(function.Name), &dupFunction); err != nil {
+		return nil, err
+	}
+	c.record(changeRecord{Type: pms.FUNCTION_ADD, Function: &dupFunction})
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpCreate, Kind: pms.KindFunction, Function: &dupFunction})
+	return &dupFunction, nil
+}
+
+func (s *Store) UpdateFunction(function *pms.Function) (*pms.Function, error) {
+	var updated *pms.Function
+	err := s.write(func(c *change) error {
+		var err error
+		updated, err = s.updateFunction(c, function)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *Store) updateFunction(c *change, function *pms.Function) (*pms.Function, error) {
+	if err := validateFunc(function); err != nil {
+		return nil, err
+	}
+	current, err := loadFunction(c.tx, function.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRevision(function.Revision, current.Revision, "function", function.Name); err != nil {
+		return nil, err
+	}
+	dupFunction := *function
+	dupFunction.Revision = c.revision
+	if err := put(c.tx.Bucket(bucketFunctions), []byte(function.Name), &dupFunction); err != nil {
+		return nil, err
+	}
+	c.record(changeRecord{Type: pms.FUNCTION_ADD, Function: &dupFunction})
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpUpdate, Kind: pms.KindFunction, Function: &dupFunction})
+	return &dupFunction, nil
+}
+
+func (s *Store) DeleteFunction(funcName string) error {
+	return s.write(func(c *change) error {
+		return s.deleteFunction(c, funcName, 0)
+	})
+}
+
+func (s *Store) deleteFunction(c *change, funcName string, revision int64) error {
+	current, err := loadFunction(c.tx, funcName)
+	if err != nil {
+		return err
+	}
+	if err := checkRevision(revision, current.Revision, "function", funcName); err != nil {
+		return err
+	}
+	if err := c.tx.Bucket(bucketFunctions).Delete([]byte(funcName)); err != nil {
+		return storeError(err)
+	}
+	c.record(changeRecord{Type: pms.FUNCTION_DELETE, Name: funcName})
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindFunction, ID: funcName})
+	return nil
+}
+
+func (s *Store) DeleteFunctions() error {
+	return s.write(s.deleteFunctions)
+}
+
+func (s *Store) deleteFunctions(c *change) error {
+	var names []string
+	c.tx.Bucket(bucketFunctions).ForEach(func(k, v []byte) error {
+		names = append(names, string(k))
+		return nil
+	})
+	for _, name := range names {
+		if err := s.deleteFunction(c, name, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetFunction(funcName string) (*pms.Function, error) {
+	var function *pms.Function
+	err := s.read(func(tx *bolt.Tx) error {
+		var err error
+		function, err = loadFunction(tx, funcName)
+		return err
+	})
+	return function, err
+}
+
+func (s *Store) ListAllFunctions(filterStr string) ([]*pms.Function, error) {
+	f, err := filter.Parse(pms.KindFunction, filterStr)
+	if err != nil {
+		return nil, err
+	}
+	ret := []*pms.Function{}
+	err = s.read(func(tx *bolt.Tx) error {
+		functions, err := loadFunctions(tx)
+		if err != nil {
+			return err
+		}
+		for _, function := range functions {
+			if f.MatchFunction(function) {
+				ret = append(ret, function)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (s *Store) GetFunctionCount() (int64, error) {
+	var count int64
+	err := s.read(func(tx *bolt.Tx) error {
+		count = countKeys(tx.Bucket(bucketFunctions))
+		return nil
+	})
+	return count, err
+}
@@ -0,0 +1,175 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package bolt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+)
+
+// newTestStore opens a bolt store on a database file in a temporary directory
+func newTestStore(t *testing.T) (pms.PolicyStoreManager, func()) {
+	dir, err := ioutil.TempDir("", "boltstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps, err := store.NewStore(StoreType, map[string]interface{}{
+		BoltPathKey:         filepath.Join(dir, "speedle.db"),
+		BoltPollIntervalKey: "50ms",
+	})
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal("fail to new bolt store:", err)
+	}
+	return ps, func() {
+		ps.StopWatch()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestManagePolicies(t *testing.T) {
+	ps, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if err := ps.CreateService(&pms.Service{Name: "service1", Type: pms.TypeApplication}); err != nil {
+		t.Fatal("fail to create service:", err)
+	}
+	var ids []string
+	for _, name := range []string{"policy3", "policy1", "policy2"} {
+		policy, err := ps.CreatePolicy("service1", &pms.Policy{Name: name, Effect: "grant", Principals: [][]string{{"user:Alice"}}})
+		if err != nil {
+			t.Fatal("fail to create policy:", err)
+		}
+		ids = append(ids, policy.ID)
+	}
+
+	// policies are listed in the order of creation, and an update keeps the position
+	policy, err := ps.GetPolicy("service1", ids[0])
+	if err != nil {
+		t.Fatal("fail to get policy:", err)
+	}
+	policy.Effect = "deny"
+	if _, err := ps.UpdatePolicy("service1", policy); err != nil {
+		t.Fatal("fail to update policy:", err)
+	}
+	policies, err := ps.ListAllPolicies("service1", "")
+	if err != nil {
+		t.Fatal("fail to list policies:", err)
+	}
+	if len(policies) != 3 || policies[0].ID != ids[0] || policies[0].Effect != "deny" || policies[2].ID != ids[2] {
+		t.Errorf("policies are not in the order of creation: %v", policies)
+	}
+
+	service, err := ps.GetService("service1")
+	if err != nil {
+		t.Fatal("fail to get service:", err)
+	}
+	if service.Revision != policies[0].Revision {
+		t.Errorf("a policy change should increase the revision of its service, %d, %d", service.Revision, policies[0].Revision)
+	}
+	if _, err := ps.UpdatePolicy("service1", policy); errors.Code(err) != errors.RevisionConflict {
+		t.Error("should fail to update policy with stale revision:", err)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "boltstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "speedle.db")
+
+	s1, err := NewStore(path, DefaultPollInterval)
+	if err != nil {
+		t.Fatal("fail to new bolt store:", err)
+	}
+	if err := s1.CreateService(&pms.Service{Name: "service1", Policies: []*pms.Policy{{Name: "policy1", Effect: "grant"}}}); err != nil {
+		t.Fatal("fail to create service:", err)
+	}
+
+	// another store on the same file, e.g. the one of ADS, sees the changes
+	s2, err := NewStore(path, DefaultPollInterval)
+	if err != nil {
+		t.Fatal("fail to open the bolt store again:", err)
+	}
+	if count, err := s2.GetPolicyCount("service1"); err != nil || count != 1 {
+		t.Errorf("expected the policy to be kept, but got %d, %v", count, err)
+	}
+	if _, err := s2.CreatePolicy("service1", &pms.Policy{Name: "policy2", Effect: "deny"}); err != nil {
+		t.Fatal("fail to create policy:", err)
+	}
+	if count, err := s1.GetPolicyCount("service1"); err != nil || count != 2 {
+		t.Errorf("expected the policy created by the other store, but got %d, %v", count, err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ps, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if err := ps.CreateService(&pms.Service{Name: "service1", Type: pms.TypeApplication}); err != nil {
+		t.Fatal("fail to create service:", err)
+	}
+	ch, err := ps.Watch()
+	if err != nil {
+		t.Fatal("fail to watch:", err)
+	}
+	policy, err := ps.CreatePolicy("service1", &pms.Policy{Name: "policy1", Effect: "grant"})
+	if err != nil {
+		t.Fatal("fail to create policy:", err)
+	}
+	policy.Effect = "deny"
+	if _, err := ps.UpdatePolicy("service1", policy); err != nil {
+		t.Fatal("fail to update policy:", err)
+	}
+	if _, err := ps.CreateRolePolicy("service1", &pms.RolePolicy{Name: "rolePolicy1", Effect: "grant", Roles: []string{"role1"}}); err != nil {
+		t.Fatal("fail to create role policy:", err)
+	}
+	if err := ps.DeletePolicies("service1"); err != nil {
+		t.Fatal("fail to delete policies:", err)
+	}
+	if err := ps.WritePolicyStore(&pms.PolicyStore{}); err != nil {
+		t.Fatal("fail to write policy store:", err)
+	}
+
+	var events []pms.StoreChangeEvent
+	timeout := time.After(5 * time.Second)
+	for len(events) < 5 {
+		select {
+		case e := <-ch:
+			events = append(events, e)
+		case <-timeout:
+			t.Fatalf("expected 5 events, but got %d", len(events))
+		}
+	}
+	ps.StopWatch()
+	for range ch {
+	}
+
+	expected := []pms.EventType{pms.POLICY_ADD, pms.POLICY_ADD, pms.ROLEPOLICY_ADD, pms.POLICY_DELETE, pms.FULL_RELOAD}
+	for i, e := range events {
+		if e.Type != expected[i] {
+			t.Fatalf("expected event %d of type %d, but got %v", i, expected[i], e)
+		}
+		if i > 0 && e.ID <= events[i-1].ID {
+			t.Errorf("event IDs should be increased: %v", events)
+		}
+	}
+	if data := events[0].Content.([]pms.StoreUpdateData); len(data) != 1 || data[0].ServiceName != "service1" || data[0].Data.(*pms.Policy).ID != policy.ID {
+		t.Errorf("the created policy should be sent: %v", data)
+	}
+	// an update is sent as the addition of the new policy, which replaces the old one
+	if data := events[1].Content.([]pms.StoreUpdateData); len(data) != 1 || data[0].Data.(*pms.Policy).ID != policy.ID ||
+		data[0].Data.(*pms.Policy).Effect != "deny" {
+		t.Errorf("unexpected event of policy update: %v", data)
+	}
+}
@@ -0,0 +1,154 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package bolt
+
+import (
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+)
+
+// findDiscoverRequests gets the requests of a service saved after revision in the order of revision, and the
+// revision of the latest request saved. The requests are in reverse order if reverse is true, and at most
+// limit requests are got if limit is positive.
+func (s *Store) findDiscoverRequests(serviceName string, revision int64, reverse bool, limit int) ([]*ads.RequestContext, int64, error) {
+	requests := []*ads.RequestContext{}
+	var latest int64
+	err := s.read(func(tx *bolt.Tx) error {
+		discover := tx.Bucket(bucketDiscover)
+		latest = int64(discover.Sequence())
+		c := discover.Cursor()
+		k, v := c.Seek(itob(revision + 1))
+		next := c.Next
+		if reverse {
+			k, v = c.Last()
+			next = c.Prev
+		}
+		for ; k != nil && btoi(k) > revision; k, v = next() {
+			var request ads.RequestContext
+			if err := unmarshal(v, &request); err != nil {
+				return err
+			}
+			if len(serviceName) != 0 && request.ServiceName != serviceName {
+				continue
+			}
+			requests = append(requests, &request)
+			if limit > 0 && len(requests) == limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, -1, err
+	}
+	return requests, latest, nil
+}
+
+// SaveDiscoverRequest saves a discover request with a new revision, and removes the oldest requests
+// once there are more than store.MaxDiscoverRequestNum requests
+func (s *Store) SaveDiscoverRequest(request *ads.RequestContext) error {
+	err := s.update(func(tx *bolt.Tx) error {
+		discover := tx.Bucket(bucketDiscover)
+		seq, err := discover.NextSequence()
+		if err != nil {
+			return storeError(err)
+		}
+		revision := int64(seq)
+		if err := put(discover, itob(revision), request); err != nil {
+			return err
+		}
+		var expired [][]byte
+		c := discover.Cursor()
+		for k, _ := c.First(); k != nil && btoi(k) <= revision-store.MaxDiscoverRequestNum; k, _ = c.Next() {
+			expired = append(expired, append([]byte{}, k...))
+		}
+		for _, k := range expired {
+			if err := discover.Delete(k); err != nil {
+				return storeError(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, errors.Code(err), "unable to save discover request")
+	}
+	return nil
+}
+
+// GetLastDiscoverRequest gets the latest request of a service, or of all services if serviceName is empty
+func (s *Store) GetLastDiscoverRequest(serviceName string) (*ads.RequestContext, int64, error) {
+	requests, revision, err := s.findDiscoverRequests(serviceName, 0, true, 1)
+	if err != nil {
+		return nil, -1, err
+	}
+	if len(requests) == 0 {
+		return nil, -1, errors.Errorf(errors.EntityNotFound, "no request found for service %q", serviceName)
+	}
+	return requests[0], revision, nil
+}
+
+// GetDiscoverRequestsSinceRevision gets the requests saved after revision, and the latest revision
+func (s *Store) GetDiscoverRequestsSinceRevision(serviceName string, revision int64) ([]*ads.RequestContext, int64, error) {
+	requests, latest, err := s.findDiscoverRequests(serviceName, revision, false, 0)
+	if err != nil {
+		return nil, revision, errors.Wrapf(err, errors.Code(err), "unable to get discover request for service %q with revision %d", serviceName, revision)
+	}
+	return requests, latest, nil
+}
+
+// GetDiscoverRequests gets all requests of a service, or of all services if serviceName is empty
+func (s *Store) GetDiscoverRequests(serviceName string) ([]*ads.RequestContext, int64, error) {
+	return s.findDiscoverRequests(serviceName, 0, false, 0)
+}
+
+// ResetDiscoverRequests removes the requests of a service, or of all services if serviceName is empty
+func (s *Store) ResetDiscoverRequests(serviceName string) error {
+	err := s.update(func(tx *bolt.Tx) error {
+		discover := tx.Bucket(bucketDiscover)
+		var keys [][]byte
+		err := discover.ForEach(func(k, v []byte) error {
+			if len(serviceName) != 0 {
+				var request ads.RequestContext
+				if err := unmarshal(v, &request); err != nil {
+					return err
+				}
+				if request.ServiceName != serviceName {
+					return nil
+				}
+			}
+			keys = append(keys, append([]byte{}, k...))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err := discover.Delete(k); err != nil {
+				return storeError(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, errors.Code(err), "unable to reset discover requests from service %q", serviceName)
+	}
+	return nil
+}
+
+// GeneratePolicies generates policies for a principal, or all principals if principalXXX are empty, from the requests of a service
+func (s *Store) GeneratePolicies(serviceName, principalType, principalName, principalIDD string) (map[string]*pms.Service, int64, error) {
+	requests, revision, err := s.GetDiscoverRequests(serviceName)
+	if err != nil {
+		return nil, -1, err
+	}
+	serviceMap, err := store.GeneratePoliciesFromDiscoverRequests(requests, principalType, principalName, principalIDD)
+	if err != nil {
+		return nil, -1, err
+	}
+	return serviceMap, revision, nil
+}
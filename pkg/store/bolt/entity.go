@@ -0,0 +1,337 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package bolt
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+)
+
+// The layout of the database. Each service has a bucket in the services bucket, which keeps the service itself
+// without its policies and role policies under keyService, and the policies and role policies in sub buckets.
+var (
+	bucketMeta      = []byte("meta")
+	bucketServices  = []byte("services")
+	bucketFunctions = []byte("functions")
+	bucketChanges   = []byte("changes")
+	bucketHistory   = []byte("history")
+	bucketDiscover  = []byte("discover")
+
+	// keyRevision in the meta bucket is the revision of the store, which is increased by every write
+	keyRevision = []byte("revision")
+	keyService  = []byte("service")
+)
+
+// entityBuckets are the sub buckets of a service bucket keeping its policies or role policies in the order they
+// are created. items maps the sequence to the entity, and ids maps the ID of the entity to the sequence.
+type entityBuckets struct {
+	kind  string
+	items []byte
+	ids   []byte
+}
+
+var (
+	policyBuckets     = entityBuckets{kind: "policy", items: []byte("policies"), ids: []byte("policyIDs")}
+	rolePolicyBuckets = entityBuckets{kind: "role policy", items: []byte("rolePolicies"), ids: []byte("rolePolicyIDs")}
+)
+
+func itob(v int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(v))
+	return b
+}
+
+func btoi(b []byte) int64 {
+	if len(b) != 8 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+func storeError(err error) error {
+	return errors.Wrap(err, errors.StoreError, "failed to write bolt store")
+}
+
+// put marshals v and puts it under key
+func put(b *bolt.Bucket, key []byte, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrap(err, errors.SerializationError, "failed to marshal entity")
+	}
+	if err := b.Put(key, value); err != nil {
+		return storeError(err)
+	}
+	return nil
+}
+
+func unmarshal(value []byte, v interface{}) error {
+	if err := json.Unmarshal(value, v); err != nil {
+		return errors.Wrapf(err, errors.SerializationError, "failed to unmarshal %q", value)
+	}
+	return nil
+}
+
+// storeRevision gets the revision of the store, which is 0 if the buckets are not created, e.g. the database file is
+// removed while the store is watched
+func storeRevision(tx *bolt.Tx) int64 {
+	meta := tx.Bucket(bucketMeta)
+	if meta == nil {
+		return 0
+	}
+	return btoi(meta.Get(keyRevision))
+}
+
+// serviceBucket gets the bucket of a service, and returns EntityNotFound if the service does not exist
+func serviceBucket(tx *bolt.Tx, name string) (*bolt.Bucket, error) {
+	sb := tx.Bucket(bucketServices).Bucket([]byte(name))
+	if sb == nil {
+		return nil, errors.Errorf(errors.EntityNotFound, "service %q is not found", name)
+	}
+	return sb, nil
+}
+
+// putServiceBucket creates the bucket of a service with its policies and role policies
+func putServiceBucket(tx *bolt.Tx, service *pms.Service) error {
+	sb, err := tx.Bucket(bucketServices).CreateBucket([]byte(service.Name))
+	if err != nil {
+		return storeError(err)
+	}
+	for _, eb := range []entityBuckets{policyBuckets, rolePolicyBuckets} {
+		if err := eb.create(sb); err != nil {
+			return err
+		}
+	}
+	if err := putServiceItself(sb, service); err != nil {
+		return err
+	}
+	for _, policy := range service.Policies {
+		if err := policyBuckets.put(sb, policy.ID, policy); err != nil {
+			return err
+		}
+	}
+	for _, rolePolicy := range service.RolePolicies {
+		if err := rolePolicyBuckets.put(sb, rolePolicy.ID, rolePolicy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putServiceItself puts a service without its policies and role policies
+func putServiceItself(sb *bolt.Bucket, service *pms.Service) error {
+	dupService := *service
+	dupService.Policies = nil
+	dupService.RolePolicies = nil
+	return put(sb, keyService, &dupService)
+}
+
+// getServiceItself gets a service without its policies and role policies
+func getServiceItself(sb *bolt.Bucket) (*pms.Service, error) {
+	var service pms.Service
+	if err := unmarshal(sb.Get(keyService), &service); err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+// loadService gets a service with its policies and role policies
+func loadService(tx *bolt.Tx, name string) (*pms.Service, error) {
+	sb, err := serviceBucket(tx, name)
+	if err != nil {
+		return nil, err
+	}
+	service, err := getServiceItself(sb)
+	if err != nil {
+		return nil, err
+	}
+	if service.Policies, err = loadPolicies(sb); err != nil {
+		return nil, err
+	}
+	if service.RolePolicies, err = loadRolePolicies(sb); err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
+// loadServices gets all services in the order of their names
+func loadServices(tx *bolt.Tx) ([]*pms.Service, error) {
+	services := []*pms.Service{}
+	for _, name := range serviceNames(tx) {
+		service, err := loadService(tx, name)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+func serviceNames(tx *bolt.Tx) []string {
+	names := []string{}
+	tx.Bucket(bucketServices).ForEach(func(k, v []byte) error {
+		names = append(names, string(k))
+		return nil
+	})
+	return names
+}
+
+func loadPolicies(sb *bolt.Bucket) ([]*pms.Policy, error) {
+	var policies []*pms.Policy
+	err := policyBuckets.each(sb, func(value []byte) error {
+		var policy pms.Policy
+		if err := unmarshal(value, &policy); err != nil {
+			return err
+		}
+		policies = append(policies, &policy)
+		return nil
+	})
+	return policies, err
+}
+
+func loadRolePolicies(sb *bolt.Bucket) ([]*pms.RolePolicy, error) {
+	var rolePolicies []*pms.RolePolicy
+	err := rolePolicyBuckets.each(sb, func(value []byte) error {
+		var rolePolicy pms.RolePolicy
+		if err := unmarshal(value, &rolePolicy); err != nil {
+			return err
+		}
+		rolePolicies = append(rolePolicies, &rolePolicy)
+		return nil
+	})
+	return rolePolicies, err
+}
+
+func (eb entityBuckets) create(sb *bolt.Bucket) error {
+	if _, err := sb.CreateBucket(eb.items); err != nil {
+		return storeError(err)
+	}
+	if _, err := sb.CreateBucket(eb.ids); err != nil {
+		return storeError(err)
+	}
+	return nil
+}
+
+// clear deletes all entities in the buckets
+func (eb entityBuckets) clear(sb *bolt.Bucket) error {
+	if err := sb.DeleteBucket(eb.items); err != nil {
+		return storeError(err)
+	}
+	if err := sb.DeleteBucket(eb.ids); err != nil {
+		return storeError(err)
+	}
+	return eb.create(sb)
+}
+
+// get unmarshals the entity of id to v, and returns EntityNotFound if the entity does not exist
+func (eb entityBuckets) get(sb *bolt.Bucket, serviceName, id string, v interface{}) error {
+	seq := sb.Bucket(eb.ids).Get([]byte(id))
+	if seq == nil {
+		return errors.Errorf(errors.EntityNotFound, "unable to find %s %q in service %q", eb.kind, id, serviceName)
+	}
+	return unmarshal(sb.Bucket(eb.items).Get(seq), v)
+}
+
+// put adds an entity, or replaces the entity of id at the same position if it exists
+func (eb entityBuckets) put(sb *bolt.Bucket, id string, v interface{}) error {
+	items, ids := sb.Bucket(eb.items), sb.Bucket(eb.ids)
+	seq := ids.Get([]byte(id))
+	if seq != nil {
+		// the value got is only valid in the transaction, and the key put has to be valid till it is committed
+		seq = append([]byte{}, seq...)
+	} else {
+		next, err := items.NextSequence()
+		if err != nil {
+			return storeError(err)
+		}
+		seq = itob(int64(next))
+		if err := ids.Put([]byte(id), seq); err != nil {
+			return storeError(err)
+		}
+	}
+	return put(items, seq, v)
+}
+
+// delete deletes the entity of id, and returns EntityNotFound if the entity does not exist
+func (eb entityBuckets) delete(sb *bolt.Bucket, serviceName, id string) error {
+	ids := sb.Bucket(eb.ids)
+	seq := ids.Get([]byte(id))
+	if seq == nil {
+		return errors.Errorf(errors.EntityNotFound, "unable to find %s %q in service %q", eb.kind, id, serviceName)
+	}
+	if err := sb.Bucket(eb.items).Delete(seq); err != nil {
+		return storeError(err)
+	}
+	if err := ids.Delete([]byte(id)); err != nil {
+		return storeError(err)
+	}
+	return nil
+}
+
+// each calls fn with the entities in the order they are created
+func (eb entityBuckets) each(sb *bolt.Bucket, fn func(value []byte) error) error {
+	return sb.Bucket(eb.items).ForEach(func(k, v []byte) error {
+		return fn(v)
+	})
+}
+
+// scan calls fn with the entities created after the one of seq after in the order they are created, until fn returns false
+func (eb entityBuckets) scan(sb *bolt.Bucket, after int64, fn func(seq int64, value []byte) (bool, error)) error {
+	c := sb.Bucket(eb.items).Cursor()
+	for k, v := c.Seek(itob(after + 1)); k != nil; k, v = c.Next() {
+		next, err := fn(btoi(k), v)
+		if err != nil || !next {
+			return err
+		}
+	}
+	return nil
+}
+
+// count gets the number of entities
+func (eb entityBuckets) count(sb *bolt.Bucket) int64 {
+	return countKeys(sb.Bucket(eb.ids))
+}
+
+func countKeys(b *bolt.Bucket) int64 {
+	var count int64
+	c := b.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		count++
+	}
+	return count
+}
+
+// loadFunction gets a function, and returns EntityNotFound if the function does not exist
+func loadFunction(tx *bolt.Tx, name string) (*pms.Function, error) {
+	value := tx.Bucket(bucketFunctions).Get([]byte(name))
+	if value == nil {
+		return nil, errors.Errorf(errors.EntityNotFound, "function %q is not found", name)
+	}
+	var function pms.Function
+	if err := unmarshal(value, &function); err != nil {
+		return nil, err
+	}
+	return &function, nil
+}
+
+// loadFunctions gets all functions in the order of their names
+func loadFunctions(tx *bolt.Tx) ([]*pms.Function, error) {
+	functions := []*pms.Function{}
+	err := tx.Bucket(bucketFunctions).ForEach(func(k, v []byte) error {
+		var function pms.Function
+		if err := unmarshal(v, &function); err != nil {
+			return err
+		}
+		functions = append(functions, &function)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return functions, nil
+}
@@ -0,0 +1,111 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package bolt
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+)
+
+// historyPrefix is the prefix of the keys of the history records of an entity. A key is followed by
+// the version and the sequence of the record in the version, so the records are kept in order.
+func historyPrefix(kind, serviceName, id string) []byte {
+	var prefix bytes.Buffer
+	for _, part := range []string{kind, serviceName, id} {
+		prefix.WriteString(part)
+		prefix.WriteByte(0)
+	}
+	return prefix.Bytes()
+}
+
+// putHistory keeps the records in the transaction of the change, whose revision is the version of the records
+func putHistory(tx *bolt.Tx, records []*pms.HistoryRecord) error {
+	history := tx.Bucket(bucketHistory)
+	for i, record := range records {
+		prefix := historyPrefix(record.Kind, record.ServiceName, record.ID)
+		key := append(prefix, itob(record.Version)...)
+		seq := make([]byte, 4)
+		binary.BigEndian.PutUint32(seq, uint32(i))
+		if err := put(history, append(key, seq...), record); err != nil {
+			return err
+		}
+		if err := removeExpiredHistory(history, prefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeExpiredHistory removes the oldest versions of an entity, so at most store.MaxHistoryVersions versions are kept
+func removeExpiredHistory(history *bolt.Bucket, prefix []byte) error {
+	var versions []int64
+	c := history.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		version := btoi(k[len(prefix) : len(prefix)+8])
+		if len(versions) == 0 || versions[len(versions)-1] != version {
+			versions = append(versions, version)
+		}
+	}
+	expired := store.ExpiredHistoryVersion(versions)
+	if expired == 0 {
+		return nil
+	}
+	var keys [][]byte
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix) && btoi(k[len(prefix):len(prefix)+8]) <= expired; k, _ = c.Next() {
+		keys = append(keys, k)
+	}
+	for _, key := range keys {
+		if err := history.Delete(key); err != nil {
+			return errors.Wrap(err, errors.StoreError, "failed to remove history from bolt store")
+		}
+	}
+	return nil
+}
+
+// ListHistory gets all versions of a service, policy, role policy or function, the oldest first.
+func (s *Store) ListHistory(kind, serviceName, id string) ([]*pms.HistoryRecord, error) {
+	if kind == pms.KindService || kind == pms.KindFunction {
+		serviceName = ""
+	}
+	var records []*pms.HistoryRecord
+	err := s.read(func(tx *bolt.Tx) error {
+		prefix := historyPrefix(kind, serviceName, id)
+		c := tx.Bucket(bucketHistory).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var record pms.HistoryRecord
+			if err := unmarshal(v, &record); err != nil {
+				return err
+			}
+			records = append(records, &record)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.Errorf(errors.EntityNotFound, "no history found for %s %q", kind, id)
+	}
+	return records, nil
+}
+
+// GetHistory gets a version of a service, policy, role policy or function.
+func (s *Store) GetHistory(kind, serviceName, id string, version int64) (*pms.HistoryRecord, error) {
+	records, err := s.ListHistory(kind, serviceName, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.Version == version {
+			return record, nil
+		}
+	}
+	return nil, errors.Errorf(errors.EntityNotFound, "version %d of %s %q is not found", version, kind, id)
+}
@@ -0,0 +1,85 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package bolt
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+)
+
+const (
+	StoreType = "bolt"
+
+	//Following are keys of bolt store properties
+	BoltPathKey         = "BoltPath"
+	BoltPollIntervalKey = "BoltPollInterval"
+
+	BoltPathFlagName         = "boltstore-path"
+	BoltPollIntervalFlagName = "boltstore-pollinterval"
+
+	//default property values
+	DefaultPath         = "/tmp/speedle-test-bolt-store.db"
+	DefaultPollInterval = time.Second
+)
+
+type BoltStoreBuilder struct{}
+
+func (sb BoltStoreBuilder) NewStore(config map[string]interface{}) (pms.PolicyStoreManager, error) {
+	path, ok := config[BoltPathKey].(string)
+	if !ok || len(path) == 0 {
+		path = DefaultPath
+	}
+	pollInterval := DefaultPollInterval
+	if value, ok := config[BoltPollIntervalKey].(string); ok && len(value) != 0 {
+		var err error
+		if pollInterval, err = time.ParseDuration(value); err != nil || pollInterval <= 0 {
+			return nil, errors.Errorf(errors.ConfigError, "invalid poll interval %q of bolt store", value)
+		}
+	}
+	return NewStore(path, pollInterval)
+}
+
+// TenantStoreConfig keeps the policies of a tenant in a database file of the same name in the directory of the tenant
+func (sb BoltStoreBuilder) TenantStoreConfig(config map[string]interface{}, tenant string) (map[string]interface{}, error) {
+	path, ok := config[BoltPathKey].(string)
+	if !ok || len(path) == 0 {
+		path = DefaultPath
+	}
+	tenantPath, err := store.TenantPath(path, tenant)
+	if err != nil {
+		return nil, err
+	}
+	config[BoltPathKey] = tenantPath
+	return config, nil
+}
+
+func (sb BoltStoreBuilder) GetStoreParams() map[string]string {
+	return map[string]string{
+		BoltPathFlagName:         BoltPathKey,
+		BoltPollIntervalFlagName: BoltPollIntervalKey,
+	}
+}
+
+// NewStore creates the database file if it does not exist, and the buckets of the store
+func NewStore(path string, pollInterval time.Duration) (*Store, error) {
+	s := &Store{path: path, pollInterval: pollInterval}
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+	log.Infof("bolt store is opened at %s", path)
+	return s, nil
+}
+
+func init() {
+	pflag.String(BoltPathFlagName, DefaultPath, "Store config: path of the database file of bolt store.")
+	pflag.String(BoltPollIntervalFlagName, DefaultPollInterval.String(), "Store config: interval to poll the changes of bolt store when watching.")
+
+	store.Register(StoreType, BoltStoreBuilder{})
+}
@@ -0,0 +1,177 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package bolt
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/store/utils"
+)
+
+// changelogRetention is the number of revisions kept in the changelog, a watcher falling behind
+// further than that reloads the whole store
+const changelogRetention = 10000
+
+// changeRecord is a change of an entity kept in the changelog, which is sent to watchers as a store change event
+// of Type. Deleted services and functions are identified by Name, and the other entities are kept with their content.
+type changeRecord struct {
+	Type        pms.EventType   `json:"type"`
+	ServiceName string          `json:"serviceName,omitempty"`
+	Name        string          `json:"name,omitempty"`
+	Service     *pms.Service    `json:"service,omitempty"`
+	Policy      *pms.Policy     `json:"policy,omitempty"`
+	RolePolicy  *pms.RolePolicy `json:"rolePolicy,omitempty"`
+	Function    *pms.Function   `json:"function,omitempty"`
+}
+
+// appendChangelog keeps the records of a revision, and removes the revisions out of retention
+func appendChangelog(tx *bolt.Tx, revision int64, records []changeRecord) error {
+	changes := tx.Bucket(bucketChanges)
+	if len(records) != 0 {
+		if err := put(changes, itob(revision), records); err != nil {
+			return err
+		}
+	}
+	var expired [][]byte
+	c := changes.Cursor()
+	for k, _ := c.First(); k != nil && btoi(k) <= revision-changelogRetention; k, _ = c.Next() {
+		expired = append(expired, append([]byte{}, k...))
+	}
+	for _, k := range expired {
+		if err := changes.Delete(k); err != nil {
+			return storeError(err)
+		}
+	}
+	return nil
+}
+
+// Watch polls the changelog for the revisions made after it is called. The changes of a revision are sent
+// as incremental events, e.g. POLICY_ADD and POLICY_DELETE, in a BATCH if there are more than one.
+func (s *Store) Watch() (pms.StorageChangeChannel, error) {
+	log.Info("Enter Watch...")
+	var last int64
+	err := s.read(func(tx *bolt.Tx) error {
+		last = storeRevision(tx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	storeChangeChan := make(pms.StorageChangeChannel)
+	stop := make(chan struct{})
+	s.stopLock.Lock()
+	s.stop = stop
+	s.stopLock.Unlock()
+
+	go func() {
+		defer close(storeChangeChan)
+		ticker := time.NewTicker(s.pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				revision, err := s.pollChanges(storeChangeChan, last)
+				if err != nil {
+					log.Warningf("Error happened when polling the changes of bolt store, error: %v", err)
+					continue
+				}
+				last = revision
+			case <-stop:
+				log.Warning("Received stop signal")
+				return
+			}
+		}
+	}()
+	return storeChangeChan, nil
+}
+
+// pollChanges sends the events of the revisions after last, and returns the latest revision sent.
+// The events are read first, so the database is not held while they are sent.
+func (s *Store) pollChanges(ch pms.StorageChangeChannel, last int64) (int64, error) {
+	var current int64
+	var revisions [][]pms.StoreChangeEvent
+	err := s.read(func(tx *bolt.Tx) error {
+		current = storeRevision(tx)
+		if current <= last || current-last >= changelogRetention {
+			return nil
+		}
+		c := tx.Bucket(bucketChanges).Cursor()
+		for k, v := c.Seek(itob(last + 1)); k != nil && btoi(k) <= current; k, v = c.Next() {
+			var records []changeRecord
+			if err := unmarshal(v, &records); err != nil {
+				return err
+			}
+			revisions = append(revisions, changeEvents(btoi(k), records))
+		}
+		return nil
+	})
+	if err != nil {
+		return last, err
+	}
+	if current <= last {
+		return last, nil
+	}
+	if current-last >= changelogRetention {
+		log.Info("Reloading the bolt store...")
+		ch <- pms.StoreChangeEvent{Type: pms.FULL_RELOAD, ID: current}
+		return current, nil
+	}
+	for _, events := range revisions {
+		utils.SendChangeEvents(ch, events)
+	}
+	return current, nil
+}
+
+// changeEvents converts the changelog records of a revision to store change events. Consecutive records
+// of the same type are merged into one event if its content is a list.
+func changeEvents(revision int64, records []changeRecord) []pms.StoreChangeEvent {
+	var events []pms.StoreChangeEvent
+	for _, record := range records {
+		var last *pms.StoreChangeEvent
+		if len(events) > 0 && events[len(events)-1].Type == record.Type {
+			last = &events[len(events)-1]
+		}
+		switch record.Type {
+		case pms.SERVICE_ADD:
+			events = append(events, pms.StoreChangeEvent{Type: record.Type, ID: revision, Content: record.Service})
+		case pms.FUNCTION_ADD:
+			events = append(events, pms.StoreChangeEvent{Type: record.Type, ID: revision, Content: record.Function})
+		case pms.SERVICE_DELETE, pms.FUNCTION_DELETE:
+			if last != nil {
+				last.Content = append(last.Content.([]string), record.Name)
+			} else {
+				events = append(events, pms.StoreChangeEvent{Type: record.Type, ID: revision, Content: []string{record.Name}})
+			}
+		case pms.POLICY_ADD, pms.POLICY_DELETE, pms.ROLEPOLICY_ADD, pms.ROLEPOLICY_DELETE:
+			data := pms.StoreUpdateData{ServiceName: record.ServiceName}
+			if record.Type == pms.POLICY_ADD || record.Type == pms.POLICY_DELETE {
+				data.Data = record.Policy
+			} else {
+				data.Data = record.RolePolicy
+			}
+			if last != nil {
+				last.Content = append(last.Content.([]pms.StoreUpdateData), data)
+			} else {
+				events = append(events, pms.StoreChangeEvent{Type: record.Type, ID: revision, Content: []pms.StoreUpdateData{data}})
+			}
+		case pms.FULL_RELOAD:
+			events = append(events, pms.StoreChangeEvent{Type: record.Type, ID: revision})
+		}
+	}
+	return events
+}
+
+func (s *Store) StopWatch() {
+	s.stopLock.Lock()
+	defer s.stopLock.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
@@ -0,0 +1,32 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package etcd
+
+import (
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/store"
+	"github.com/teramoby/speedle-plus/pkg/store/storetest"
+)
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) (pms.PolicyStoreManager, func()) {
+		// each test has its own key prefix, so it starts with an empty store
+		config := map[string]interface{}{}
+		for k, v := range storeConfig.StoreProps {
+			config[k] = v
+		}
+		config[EtcdKeyPrefixKey] = "/speedle_conformance/" + t.Name() + "/"
+		ps, err := store.NewStore(storeConfig.StoreType, config)
+		if err != nil {
+			t.Fatal("fail to new etcd3 store:", err)
+		}
+		return ps, func() {
+			ps.DeleteServices()
+			ps.DeleteFunctions()
+			ps.(*Store).destroy()
+		}
+	})
+}
@@ -5,7 +5,6 @@ package etcd
 
 import (
 	"encoding/json"
-	"fmt"
 	"strings"
 	"time"
 
@@ -13,6 +12,9 @@ import (
 	"github.com/teramoby/speedle-plus/pkg/suid"
 
 	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/store"
+	"github.com/teramoby/speedle-plus/pkg/store/filter"
+	"github.com/teramoby/speedle-plus/pkg/store/utils"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/client/v3/concurrency"
@@ -31,6 +33,10 @@ const (
 	FunctionsKey    = "functions"
 	ServiceTypeKey  = "type"
 	pageSize        = 1000
+
+	ServiceCombiningAlgorithmKey = "combining_algorithm"
+	ServiceDefaultEffectKey      = "default_effect"
+	ServiceAttributesKey         = "attributes"
 )
 
 type Store struct {
@@ -226,12 +232,31 @@ func (s *Store) GetServiceItself(serviceName string) (*pms.Service, error) {
 	}
 	service := pms.Service{Name: serviceName}
 
-	resp, err = s.client.Get(ctx, serviceKey+KeySeparator+ServiceTypeKey)
+	var attributes string
+	for key, value := range map[string]*string{
+		ServiceTypeKey:               &service.Type,
+		ServiceCombiningAlgorithmKey: &service.CombiningAlgorithm,
+		ServiceDefaultEffectKey:      &service.DefaultEffect,
+		ServiceAttributesKey:         &attributes,
+	} {
+		resp, err = s.client.Get(ctx, serviceKey+KeySeparator+key)
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range resp.Kvs {
+			*value = string(kv.Value)
+		}
+	}
+	if err := decodeServiceAttributes([]byte(attributes), &service); err != nil {
+		return nil, err
+	}
+
+	resp, err = s.client.Get(ctx, serviceKey+KeySeparator)
 	if err != nil {
 		return nil, err
 	}
 	for _, kv := range resp.Kvs {
-		service.Type = string(kv.Value)
+		service.Revision = kv.ModRevision
 	}
 
 	return &service, nil
@@ -250,10 +275,25 @@ func (s *Store) GetService(serviceName string) (*pms.Service, error) {
 	service.Name = serviceName
 	for _, resp := range responses {
 		for _, kv := range resp.Kvs {
+			if strings.Compare(string(kv.Key), serviceKey) == 0 {
+				//service key, it is updated whenever the service or its policies change
+				service.Revision = kv.ModRevision
+			}
 			if strings.Compare(string(kv.Key), serviceKey+ServiceTypeKey) == 0 {
 				//service type
 				service.Type = string(kv.Value)
 			}
+			if strings.Compare(string(kv.Key), serviceKey+ServiceCombiningAlgorithmKey) == 0 {
+				service.CombiningAlgorithm = string(kv.Value)
+			}
+			if strings.Compare(string(kv.Key), serviceKey+ServiceDefaultEffectKey) == 0 {
+				service.DefaultEffect = string(kv.Value)
+			}
+			if strings.Compare(string(kv.Key), serviceKey+ServiceAttributesKey) == 0 {
+				if err := decodeServiceAttributes(kv.Value, &service); err != nil {
+					return nil, err
+				}
+			}
 			if strings.HasPrefix(string(kv.Key), serviceKey+PoliciesKey) {
 				//policies
 				var policy pms.Policy
@@ -261,6 +301,7 @@ func (s *Store) GetService(serviceName string) (*pms.Service, error) {
 				if err != nil {
 					return nil, errors.Errorf(errors.SerializationError, "failed to unmarshal policy %q", kv.Value)
 				}
+				policy.Revision = kv.ModRevision
 				service.Policies = append(service.Policies, &policy)
 			}
 			if strings.HasPrefix(string(kv.Key), serviceKey+RolePoliciesKey) {
@@ -270,6 +311,7 @@ func (s *Store) GetService(serviceName string) (*pms.Service, error) {
 				if err != nil {
 					return nil, errors.Errorf(errors.SerializationError, "failed to unmarshal role policy %q", kv.Value)
 				}
+				rolePolicy.Revision = kv.ModRevision
 				service.RolePolicies = append(service.RolePolicies, &rolePolicy)
 			}
 		}
@@ -283,6 +325,18 @@ func (s *Store) timeOutGet(key string, opts ...clientv3.OpOption) (*clientv3.Get
 	return s.client.Get(ctx, key, opts...)
 }
 
+// checkService returns EntityNotFound if the service does not exist
+func (s *Store) checkService(serviceName string) error {
+	getResp, err := s.timeOutGet(s.serviceKey(serviceName), clientv3.WithCountOnly())
+	if err != nil {
+		return errors.Wrapf(err, errors.StoreError, "failed to get service %q from etcd server", serviceName)
+	}
+	if getResp.Count == 0 {
+		return errors.Errorf(errors.EntityNotFound, "service %q is not found", serviceName)
+	}
+	return nil
+}
+
 func (s *Store) prefixGet(prefix string, opts ...clientv3.OpOption) ([]*clientv3.GetResponse, error) {
 	end := clientv3.GetPrefixRangeEnd(prefix)
 	getOpts := []clientv3.OpOption{clientv3.WithPrefix(), clientv3.WithLimit(pageSize), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend)}
@@ -314,7 +368,9 @@ func (s *Store) getPutOps(service *pms.Service) ([]clientv3.Op, error) {
 			policy.ID = suid.New().String()
 		}
 		key := s.KeyPrefix + ServicesKey + KeySeparator + service.Name + KeySeparator + PoliciesKey + KeySeparator + policy.ID
-		value, err := json.Marshal(policy)
+		dupPolicy := *policy
+		dupPolicy.Revision = 0
+		value, err := json.Marshal(dupPolicy)
 		if err != nil {
 			return nil, errors.Errorf(errors.SerializationError, "failed to marshal policy")
 		}
@@ -325,13 +381,15 @@ func (s *Store) getPutOps(service *pms.Service) ([]clientv3.Op, error) {
 			rolePolicy.ID = suid.New().String()
 		}
 		key := s.KeyPrefix + ServicesKey + KeySeparator + service.Name + KeySeparator + RolePoliciesKey + KeySeparator + rolePolicy.ID
-		value, err := json.Marshal(rolePolicy)
+		dupRolePolicy := *rolePolicy
+		dupRolePolicy.Revision = 0
+		value, err := json.Marshal(dupRolePolicy)
 		if err != nil {
 			return nil, errors.Errorf(errors.SerializationError, "failed to marshal role policy")
 		}
 		ops = append(ops, clientv3.OpPut(key, string(value)))
 	}
-	ops = append(ops, clientv3.OpPut(s.KeyPrefix+ServicesKey+KeySeparator+service.Name+KeySeparator+ServiceTypeKey, service.Type))
+	ops = append(ops, serviceAttributeOps(s.KeyPrefix+ServicesKey+KeySeparator+service.Name+KeySeparator, service)...)
 	//make sure updating service key is the last operation, so watch could work correctly
 	ops = append(ops, clientv3.OpPut(s.KeyPrefix+ServicesKey+KeySeparator+service.Name+KeySeparator, ""))
 	return ops, nil
@@ -343,6 +401,12 @@ func (s *Store) CreateService(service *pms.Service) error {
 	if err != nil {
 		return err
 	}
+	historyOps, err := s.historyOps([]*pms.Operation{{Op: pms.OpCreate, Kind: pms.KindService, Service: service}})
+	if err != nil {
+		return err
+	}
+	//keep the history with the data, and make sure updating service key is still the last operation
+	ops = append(ops[:len(ops)-1:len(ops)-1], append(historyOps, ops[len(ops)-1])...)
 	//currently etcd transaction only support up to 128 operations in one transaction.
 	//https://github.com/coreos/etcd/issues/7826, it seems the MaxOpsPerTxn is configurable in later release.
 	maxOps := int(embed.DefaultMaxTxnOps)
@@ -370,11 +434,20 @@ func (s *Store) CreateService(service *pms.Service) error {
 		startIndex = endIndex
 	}
 	if fail { //clean all data inserted
-		_, err := s.client.KV.Txn(context.TODO()).Then(
-			clientv3.OpDelete(s.KeyPrefix+ServicesKey+KeySeparator+service.Name+KeySeparator, clientv3.WithPrefix()),
-		).Commit()
-		if err != nil {
-			return err
+		cleanOps := []clientv3.Op{clientv3.OpDelete(s.KeyPrefix+ServicesKey+KeySeparator+service.Name+KeySeparator, clientv3.WithPrefix())}
+		for _, historyOp := range historyOps {
+			if historyOp.IsPut() {
+				cleanOps = append(cleanOps, clientv3.OpDelete(string(historyOp.KeyBytes())))
+			}
+		}
+		for startIndex = 0; startIndex < len(cleanOps); startIndex += maxOps {
+			endIndex = startIndex + maxOps
+			if endIndex > len(cleanOps) {
+				endIndex = len(cleanOps)
+			}
+			if _, err := s.client.KV.Txn(context.TODO()).Then(cleanOps[startIndex:endIndex]...).Commit(); err != nil {
+				return err
+			}
 		}
 		return errors.Errorf(errors.StoreError, "failed to create service %q", service.Name)
 	}
@@ -382,15 +455,94 @@ func (s *Store) CreateService(service *pms.Service) error {
 
 }
 
+// updateCompares returns the conditions of updating key: the key must exist, and its
+// mod revision must equal to the expected one if a positive revision is given
+func updateCompares(key string, expected int64) []clientv3.Cmp {
+	cmps := []clientv3.Cmp{clientv3.Compare(clientv3.Version(key), ">", 0)}
+	if expected > 0 {
+		cmps = append(cmps, clientv3.Compare(clientv3.ModRevision(key), "=", expected))
+	}
+	return cmps
+}
+
+// currentModRevision returns the mod revision got by the else branch of a failed update,
+// found is false if the key does not exist
+func currentModRevision(txnResp *clientv3.TxnResponse) (current int64, found bool) {
+	if len(txnResp.Responses) == 0 {
+		return 0, false
+	}
+	rangeResp := txnResp.Responses[0].GetResponseRange()
+	if rangeResp == nil || len(rangeResp.Kvs) == 0 {
+		return 0, false
+	}
+	return rangeResp.Kvs[0].ModRevision, true
+}
+
+// serviceAttributeOps returns the operations putting the attributes of a service under the service key
+func serviceAttributeOps(serviceKey string, service *pms.Service) []clientv3.Op {
+	return []clientv3.Op{
+		clientv3.OpPut(serviceKey+ServiceTypeKey, service.Type),
+		clientv3.OpPut(serviceKey+ServiceCombiningAlgorithmKey, service.CombiningAlgorithm),
+		clientv3.OpPut(serviceKey+ServiceDefaultEffectKey, service.DefaultEffect),
+		clientv3.OpPut(serviceKey+ServiceAttributesKey, encodeServiceAttributes(service)),
+	}
+}
+
+// encodeServiceAttributes returns the attribute schema of a service in json, or an empty string if there is none
+func encodeServiceAttributes(service *pms.Service) string {
+	if len(service.Attributes) == 0 {
+		return ""
+	}
+	value, _ := json.Marshal(service.Attributes)
+	return string(value)
+}
+
+func decodeServiceAttributes(value []byte, service *pms.Service) error {
+	if len(value) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(value, &service.Attributes); err != nil {
+		return errors.Errorf(errors.SerializationError, "failed to unmarshal attributes of service %q", service.Name)
+	}
+	return nil
+}
+
+// UpdateService updates the type and the combining algorithm of an existing service, service metadata is not persisted in etcd
+func (s *Store) UpdateService(service *pms.Service) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	serviceKey := s.KeyPrefix + ServicesKey + KeySeparator + service.Name + KeySeparator
+	//make sure updating service key is the last operation, so watch could work correctly
+	ops := append(serviceAttributeOps(serviceKey, service), clientv3.OpPut(serviceKey, ""))
+	txnResp, err := s.commitWithHistory(ctx, []*pms.Operation{{Op: pms.OpUpdate, Kind: pms.KindService, Service: service}},
+		updateCompares(serviceKey, service.Revision),
+		ops,
+		clientv3.OpGet(serviceKey),
+	)
+	if err != nil {
+		return errors.Wrapf(err, errors.StoreError, "failed to update service %q in etcd server", service.Name)
+	}
+	if !txnResp.Succeeded {
+		if current, found := currentModRevision(txnResp); found {
+			return errors.Errorf(errors.RevisionConflict, "revision %d of service %q does not match the current revision %d", service.Revision, service.Name, current)
+		}
+		return errors.Errorf(errors.EntityNotFound, "service %q is not found", service.Name)
+	}
+	return nil
+}
+
 //delete application from etcd3
 func (s *Store) DeleteService(serviceName string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
 	defer cancel()
-	txnResp, err := s.client.KV.Txn(ctx).If(
-		clientv3.Compare(clientv3.Version(s.KeyPrefix+ServicesKey+KeySeparator+serviceName+KeySeparator), ">", 0), //key exist
-	).Then(
-		clientv3.OpDelete(s.KeyPrefix+ServicesKey+KeySeparator+serviceName+KeySeparator, clientv3.WithPrefix()),
-	).Commit()
+	txnResp, err := s.commitWithHistory(ctx, []*pms.Operation{{Op: pms.OpDelete, Kind: pms.KindService, ID: serviceName}},
+		[]clientv3.Cmp{
+			clientv3.Compare(clientv3.Version(s.KeyPrefix+ServicesKey+KeySeparator+serviceName+KeySeparator), ">", 0), //key exist
+		},
+		[]clientv3.Op{
+			clientv3.OpDelete(s.KeyPrefix+ServicesKey+KeySeparator+serviceName+KeySeparator, clientv3.WithPrefix()),
+		},
+	)
 	if err != nil {
 		return err
 	}
@@ -403,13 +555,18 @@ func (s *Store) DeleteService(serviceName string) error {
 func (s *Store) DeleteServices() error {
 	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
 	defer cancel()
-	_, err := s.client.KV.Txn(ctx).Then(
-		clientv3.OpDelete(s.KeyPrefix+ServicesKey+KeySeparator, clientv3.WithPrefix()),
-	).Commit()
+	serviceNames, err := s.childNames(s.KeyPrefix + ServicesKey + KeySeparator)
 	if err != nil {
 		return err
 	}
-	return nil
+	var keys []string
+	var ops []*pms.Operation
+	for _, serviceName := range serviceNames {
+		keys = append(keys, s.serviceKey(serviceName))
+		ops = append(ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindService, ID: serviceName})
+	}
+	_, err = s.deleteWithHistory(ctx, keys, ops, true, nil)
+	return err
 }
 
 //get the storage type of the store
@@ -431,33 +588,75 @@ func (s *Store) CreateFunction(function *pms.Function) (*pms.Function, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
 	defer cancel()
 	functionKey := s.KeyPrefix + FunctionsKey + KeySeparator + function.Name
-	value, err := json.Marshal(*function)
+	dupFunction := *function
+	dupFunction.Revision = 0
+	value, err := json.Marshal(dupFunction)
 	if err != nil {
 		return nil, errors.Wrap(err, errors.StoreError, "failed to marshal function")
 	}
-	txnResp, err := s.client.KV.Txn(ctx).If(
-		clientv3.Compare(clientv3.Version(functionKey), "=", 0), //policy key does not exist
-	).Then(
-		clientv3.OpPut(functionKey, string(value)),
-	).Commit()
+	txnResp, err := s.commitWithHistory(ctx, []*pms.Operation{{Op: pms.OpCreate, Kind: pms.KindFunction, Function: &dupFunction}},
+		[]clientv3.Cmp{
+			clientv3.Compare(clientv3.Version(functionKey), "=", 0), //policy key does not exist
+		},
+		[]clientv3.Op{
+			clientv3.OpPut(functionKey, string(value)),
+		},
+	)
 	if err != nil {
 		return nil, errors.Wrap(err, errors.StoreError, "failed to insert function into etcd server")
 	}
 	if !txnResp.Succeeded {
 		return nil, errors.Errorf(errors.EntityAlreadyExists, "function %q already exists", function.Name)
 	}
-	return function, nil
+	dupFunction.Revision = txnResp.Header.Revision
+	return &dupFunction, nil
+}
+
+func (s *Store) UpdateFunction(function *pms.Function) (*pms.Function, error) {
+	if err := validateFunc(function); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	functionKey := s.KeyPrefix + FunctionsKey + KeySeparator + function.Name
+	dupFunction := *function
+	dupFunction.Revision = 0
+	value, err := json.Marshal(dupFunction)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.StoreError, "failed to marshal function")
+	}
+	txnResp, err := s.commitWithHistory(ctx, []*pms.Operation{{Op: pms.OpUpdate, Kind: pms.KindFunction, Function: &dupFunction}},
+		updateCompares(functionKey, function.Revision),
+		[]clientv3.Op{
+			clientv3.OpPut(functionKey, string(value)),
+		},
+		clientv3.OpGet(functionKey),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.StoreError, "failed to update function in etcd server")
+	}
+	if !txnResp.Succeeded {
+		if current, found := currentModRevision(txnResp); found {
+			return nil, errors.Errorf(errors.RevisionConflict, "revision %d of function %q does not match the current revision %d", function.Revision, function.Name, current)
+		}
+		return nil, errors.Errorf(errors.EntityNotFound, "function %q is not found", function.Name)
+	}
+	dupFunction.Revision = txnResp.Header.Revision
+	return &dupFunction, nil
 }
 
 func (s *Store) DeleteFunction(funcName string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
 	defer cancel()
 	functionKey := s.KeyPrefix + FunctionsKey + KeySeparator + funcName
-	txnResp, err := s.client.KV.Txn(ctx).If(
-		clientv3.Compare(clientv3.Version(functionKey), ">", 0), //key exist
-	).Then(
-		clientv3.OpDelete(functionKey),
-	).Commit()
+	txnResp, err := s.commitWithHistory(ctx, []*pms.Operation{{Op: pms.OpDelete, Kind: pms.KindFunction, ID: funcName}},
+		[]clientv3.Cmp{
+			clientv3.Compare(clientv3.Version(functionKey), ">", 0), //key exist
+		},
+		[]clientv3.Op{
+			clientv3.OpDelete(functionKey),
+		},
+	)
 	if err != nil {
 		return errors.Wrap(err, errors.StoreError, "failed to delete function from etcd server")
 	}
@@ -470,10 +669,17 @@ func (s *Store) DeleteFunction(funcName string) error {
 func (s *Store) DeleteFunctions() error {
 	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
 	defer cancel()
-	_, err := s.client.KV.Txn(ctx).Then(
-		clientv3.OpDelete(s.KeyPrefix+FunctionsKey, clientv3.WithPrefix()),
-	).Commit()
+	funcNames, err := s.childNames(s.KeyPrefix + FunctionsKey + KeySeparator)
 	if err != nil {
+		return err
+	}
+	var keys []string
+	var ops []*pms.Operation
+	for _, funcName := range funcNames {
+		keys = append(keys, s.KeyPrefix+FunctionsKey+KeySeparator+funcName)
+		ops = append(ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindFunction, ID: funcName})
+	}
+	if _, err := s.deleteWithHistory(ctx, keys, ops, false, nil); err != nil {
 		return errors.Wrap(err, errors.StoreError, "failed to delete all functions from etcd server")
 	}
 	return nil
@@ -495,11 +701,15 @@ func (s *Store) GetFunction(funcName string) (*pms.Function, error) {
 	if err != nil {
 		return nil, errors.Errorf(errors.SerializationError, "failed to unmarshal function %q", getResp.Kvs[0].Value)
 	}
+	function.Revision = getResp.Kvs[0].ModRevision
 	return &function, nil
 }
 
-func (s *Store) ListAllFunctions(filter string) ([]*pms.Function, error) {
-	f := parseFilter(filter)
+func (s *Store) ListAllFunctions(filterStr string) ([]*pms.Function, error) {
+	f, err := filter.Parse(pms.KindFunction, filterStr)
+	if err != nil {
+		return nil, err
+	}
 
 	functionKeyPrefix := s.KeyPrefix + FunctionsKey + KeySeparator
 	responses, err := s.prefixGet(functionKeyPrefix)
@@ -514,12 +724,8 @@ func (s *Store) ListAllFunctions(filter string) ([]*pms.Function, error) {
 			if err != nil {
 				return nil, errors.Errorf(errors.SerializationError, "failed to unmarshal function %q", kv.Value)
 			}
-			isExpected := true
-			if f != nil {
-				isExpected = nameFilter(function.Name, f)
-			}
-
-			if isExpected {
+			function.Revision = kv.ModRevision
+			if f.MatchFunction(&function) {
 				functions = append(functions, &function)
 			}
 		}
@@ -611,6 +817,8 @@ func watch(evalChan chan pms.StoreChangeEvent, s *Store, errChan chan error, sto
 				errChan <- err
 				return
 			}
+			// events of one response come from one txn, send them together
+			var events []pms.StoreChangeEvent
 			for _, e := range resp.Events {
 				id := time.Now().Unix()
 				//Note: In each policy/rolePolicy creation/deletion, service node (s.KeyPrefix+serviceName+keySeparator) will be updated.
@@ -620,11 +828,11 @@ func watch(evalChan chan pms.StoreChangeEvent, s *Store, errChan chan error, sto
 						serviceName := strings.TrimPrefix(string(e.Kv.Key), s.KeyPrefix+ServicesKey+KeySeparator)
 						serviceName = strings.TrimSuffix(serviceName, KeySeparator)
 						if strings.Index(serviceName, KeySeparator) == -1 {
-							evalChan <- pms.StoreChangeEvent{Type: pms.SERVICE_DELETE, ID: id, Content: []string{serviceName}}
+							events = append(events, pms.StoreChangeEvent{Type: pms.SERVICE_DELETE, ID: id, Content: []string{serviceName}})
 						}
 					} else if strings.HasPrefix(string(e.Kv.Key), s.KeyPrefix+FunctionsKey+KeySeparator) {
 						functionName := strings.TrimPrefix(string(e.Kv.Key), s.KeyPrefix+FunctionsKey+KeySeparator)
-						evalChan <- pms.StoreChangeEvent{Type: pms.FUNCTION_DELETE, ID: id, Content: []string{functionName}}
+						events = append(events, pms.StoreChangeEvent{Type: pms.FUNCTION_DELETE, ID: id, Content: []string{functionName}})
 					}
 
 				} else if clientv3.EventTypePut == e.Type {
@@ -637,7 +845,7 @@ func watch(evalChan chan pms.StoreChangeEvent, s *Store, errChan chan error, sto
 								log.Warningf("Unable get service due to error %v.\n", err)
 								continue
 							}
-							evalChan <- pms.StoreChangeEvent{Type: pms.SERVICE_ADD, ID: id, Content: service}
+							events = append(events, pms.StoreChangeEvent{Type: pms.SERVICE_ADD, ID: id, Content: service})
 						}
 					} else if strings.HasPrefix(string(e.Kv.Key), s.KeyPrefix+FunctionsKey+KeySeparator) {
 						functionName := strings.TrimPrefix(string(e.Kv.Key), s.KeyPrefix+FunctionsKey+KeySeparator)
@@ -645,11 +853,12 @@ func watch(evalChan chan pms.StoreChangeEvent, s *Store, errChan chan error, sto
 						if err != nil {
 							log.Warningf("Unable to get function due to error %v.\n", err)
 						}
-						evalChan <- pms.StoreChangeEvent{Type: pms.FUNCTION_ADD, ID: id, Content: function}
+						events = append(events, pms.StoreChangeEvent{Type: pms.FUNCTION_ADD, ID: id, Content: function})
 
 					}
 				}
 			}
+			utils.SendChangeEvents(evalChan, events)
 			// receive the stop signal
 		case <-s.stop:
 			log.Warning("Receiving stop signal")
@@ -672,9 +881,15 @@ func (s *Store) StopWatch() {
 }
 
 // For policy manager
-func (s *Store) ListAllPolicies(serviceName string, filter string) ([]*pms.Policy, error) {
-	f := parseFilter(filter)
+func (s *Store) ListAllPolicies(serviceName string, filterStr string) ([]*pms.Policy, error) {
+	f, err := filter.Parse(pms.KindPolicy, filterStr)
+	if err != nil {
+		return nil, err
+	}
 
+	if err := s.checkService(serviceName); err != nil {
+		return nil, err
+	}
 	policyKeyPrefix := s.KeyPrefix + ServicesKey + KeySeparator + serviceName + KeySeparator + PoliciesKey
 	responses, err := s.prefixGet(policyKeyPrefix)
 	if err != nil {
@@ -688,12 +903,8 @@ func (s *Store) ListAllPolicies(serviceName string, filter string) ([]*pms.Polic
 			if err != nil {
 				return nil, errors.Wrap(err, errors.SerializationError, "failed to unmarshal policies")
 			}
-			isExpected := true
-			if f != nil {
-				isExpected = nameFilter(policy.Name, f)
-			}
-
-			if isExpected {
+			policy.Revision = kv.ModRevision
+			if f.MatchPolicy(&policy) {
 				policies = append(policies, &policy)
 			}
 		}
@@ -705,6 +916,9 @@ func (s *Store) GetPolicyCount(serviceName string) (int64, error) {
 	var policyCount int64 = 0
 	if len(serviceName) > 0 {
 		// Get the policy count in the specified service
+		if err := s.checkService(serviceName); err != nil {
+			return 0, err
+		}
 		return s.getPolicyCountImpl(serviceName)
 	} else {
 		// Get the policy count in all services
@@ -756,56 +970,24 @@ func (s *Store) GetPolicy(serviceName string, id string) (*pms.Policy, error) {
 	if err != nil {
 		return nil, errors.Wrapf(err, errors.SerializationError, "failed to unmarshal a policy")
 	}
+	policy.Revision = getResp.Kvs[0].ModRevision
 	return &policy, nil
 }
 
-//TODO: to be implemented
-func (s *Store) GetRolePolicies(serviceName string, startID string, amount int) (policies []*pms.RolePolicy, nextID string, err error) {
-	if amount <= 0 {
-		return nil, "", errors.Errorf(errors.InvalidRequest, "invalid amount %d", amount)
-	}
-	policyPrefix := s.KeyPrefix + ServicesKey + KeySeparator + serviceName + KeySeparator + RolePoliciesKey + KeySeparator
-	end := clientv3.GetPrefixRangeEnd(policyPrefix)
-	getOpts := []clientv3.OpOption{clientv3.WithRange(end), clientv3.WithLimit(int64(amount)), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend)}
-	resp, err := s.timeOutGet(policyPrefix+startID, getOpts...)
-	if err != nil {
-		return nil, "", err
-	}
-	for _, kv := range resp.Kvs {
-		var policy pms.RolePolicy
-		err = json.Unmarshal(kv.Value, &policy)
-		if err != nil {
-			return nil, "", errors.Wrap(err, errors.SerializationError, "failed to unmarshal role policy")
-		}
-		policies = append(policies, &policy)
-	}
-
-	if len(policies) == amount {
-		lastKey := string(resp.Kvs[amount-1].Key)
-		startOfNextRange := clientv3.GetPrefixRangeEnd(lastKey)
-		getOpts = []clientv3.OpOption{clientv3.WithRange(end), clientv3.WithKeysOnly(), clientv3.WithLimit(1), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend)}
-		resp, err = s.timeOutGet(startOfNextRange, getOpts...)
-		if err != nil {
-			return nil, "", err
-		}
-		for _, kv := range resp.Kvs {
-			nextID = strings.TrimPrefix(string(kv.Key), policyPrefix)
-		}
-	}
-	return policies, nextID, err
-}
-
 func (s *Store) DeletePolicy(serviceName string, id string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
 	defer cancel()
 	policyKey := s.KeyPrefix + ServicesKey + KeySeparator + serviceName + KeySeparator + PoliciesKey + KeySeparator + id
-	txnResp, err := s.client.KV.Txn(ctx).If(
-		clientv3.Compare(clientv3.Version(policyKey), ">", 0), //key exist
-	).Then(
-		clientv3.OpDelete(policyKey),
-		//make sure updating service key is the last operation, so watch could work correctly
-		clientv3.OpPut(s.KeyPrefix+ServicesKey+KeySeparator+serviceName+KeySeparator, ""),
-	).Commit()
+	txnResp, err := s.commitWithHistory(ctx, []*pms.Operation{{Op: pms.OpDelete, Kind: pms.KindPolicy, ServiceName: serviceName, ID: id}},
+		[]clientv3.Cmp{
+			clientv3.Compare(clientv3.Version(policyKey), ">", 0), //key exist
+		},
+		[]clientv3.Op{
+			clientv3.OpDelete(policyKey),
+			//make sure updating service key is the last operation, so watch could work correctly
+			clientv3.OpPut(s.KeyPrefix+ServicesKey+KeySeparator+serviceName+KeySeparator, ""),
+		},
+	)
 	if err != nil {
 		return err
 	}
@@ -818,20 +1000,35 @@ func (s *Store) DeletePolicy(serviceName string, id string) error {
 func (s *Store) DeletePolicies(serviceName string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
 	defer cancel()
-	_, err := s.client.KV.Txn(ctx).Then(
-		clientv3.OpDelete(s.KeyPrefix+ServicesKey+KeySeparator+serviceName+KeySeparator+PoliciesKey, clientv3.WithPrefix()),
+	prefix := s.KeyPrefix + ServicesKey + KeySeparator + serviceName + KeySeparator + PoliciesKey + KeySeparator
+	ids, err := s.childNames(prefix)
+	if err != nil {
+		return err
+	}
+	var keys []string
+	var ops []*pms.Operation
+	for _, id := range ids {
+		keys = append(keys, prefix+id)
+		ops = append(ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindPolicy, ServiceName: serviceName, ID: id})
+	}
+	succeeded, err := s.deleteWithHistory(ctx, keys, ops, false,
+		[]clientv3.Cmp{clientv3.Compare(clientv3.Version(s.serviceKey(serviceName)), ">", 0)}, //service key exist
 		//make sure updating service key is the last operation, so watch could work correctly
 		clientv3.OpPut(s.KeyPrefix+ServicesKey+KeySeparator+serviceName+KeySeparator, ""),
-	).Commit()
+	)
 	if err != nil {
 		return errors.Wrap(err, errors.StoreError, "failed to delete all policies from etcd server")
 	}
+	if !succeeded {
+		return errors.Errorf(errors.EntityNotFound, "service %q is not found", serviceName)
+	}
 	return nil
 }
 
 func (s *Store) CreatePolicy(serviceName string, policy *pms.Policy) (*pms.Policy, error) {
 	//TODO:validate policy
 	dupPolicy := *policy
+	dupPolicy.Revision = 0
 	if policy.ID == "" {
 		dupPolicy.ID = suid.New().String()
 	}
@@ -844,26 +1041,74 @@ func (s *Store) CreatePolicy(serviceName string, policy *pms.Policy) (*pms.Polic
 	if err != nil {
 		return nil, errors.Wrap(err, errors.SerializationError, "falied to marshal policy")
 	}
-	txnResp, err := s.client.KV.Txn(ctx).If(
-		clientv3.Compare(clientv3.Version(serviceKey), ">", 0), //service key exist
-		clientv3.Compare(clientv3.Version(policyKey), "=", 0),  //policy key does not exist
-	).Then(
-		clientv3.OpPut(policyKey, string(value)),
-		//make sure updating service key is the last operation, so watch could work correctly
-		clientv3.OpPut(serviceKey, ""),
-	).Commit()
+	txnResp, err := s.commitWithHistory(ctx, []*pms.Operation{{Op: pms.OpCreate, Kind: pms.KindPolicy, ServiceName: serviceName, Policy: &dupPolicy}},
+		[]clientv3.Cmp{
+			clientv3.Compare(clientv3.Version(serviceKey), ">", 0), //service key exist
+			clientv3.Compare(clientv3.Version(policyKey), "=", 0),  //policy key does not exist
+		},
+		[]clientv3.Op{
+			clientv3.OpPut(policyKey, string(value)),
+			//make sure updating service key is the last operation, so watch could work correctly
+			clientv3.OpPut(serviceKey, ""),
+		},
+		clientv3.OpGet(serviceKey),
+	)
 	if err != nil {
 		return nil, errors.Wrapf(err, errors.StoreError, "falied to create a policy in service %q", serviceName)
 	}
 	if !txnResp.Succeeded {
+		if _, found := currentModRevision(txnResp); !found {
+			return nil, errors.Errorf(errors.EntityNotFound, "service %q is not found", serviceName)
+		}
 		return nil, errors.Errorf(errors.EntityAlreadyExists, "policy %q already exists in service %q", policy.ID, serviceName)
 	}
+	dupPolicy.Revision = txnResp.Header.Revision
+	return &dupPolicy, nil
+}
+
+func (s *Store) UpdatePolicy(serviceName string, policy *pms.Policy) (*pms.Policy, error) {
+	dupPolicy := *policy
+	dupPolicy.Revision = 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	serviceKey := s.KeyPrefix + ServicesKey + KeySeparator + serviceName + KeySeparator
+	policyKey := s.KeyPrefix + ServicesKey + KeySeparator + serviceName + KeySeparator + PoliciesKey + KeySeparator + dupPolicy.ID
+	value, err := json.Marshal(dupPolicy)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.SerializationError, "falied to marshal policy")
+	}
+	txnResp, err := s.commitWithHistory(ctx, []*pms.Operation{{Op: pms.OpUpdate, Kind: pms.KindPolicy, ServiceName: serviceName, Policy: &dupPolicy}},
+		updateCompares(policyKey, policy.Revision),
+		[]clientv3.Op{
+			clientv3.OpPut(policyKey, string(value)),
+			//make sure updating service key is the last operation, so watch could work correctly
+			clientv3.OpPut(serviceKey, ""),
+		},
+		clientv3.OpGet(policyKey),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, errors.StoreError, "falied to update a policy in service %q", serviceName)
+	}
+	if !txnResp.Succeeded {
+		if current, found := currentModRevision(txnResp); found {
+			return nil, errors.Errorf(errors.RevisionConflict, "revision %d of policy %q does not match the current revision %d", policy.Revision, policy.ID, current)
+		}
+		return nil, errors.Errorf(errors.EntityNotFound, "policy %q is not found in service %q", policy.ID, serviceName)
+	}
+	dupPolicy.Revision = txnResp.Header.Revision
 	return &dupPolicy, nil
 }
 
 // For role policy manager
-func (s *Store) ListAllRolePolicies(serviceName string, filter string) ([]*pms.RolePolicy, error) {
-	f := parseFilter(filter)
+func (s *Store) ListAllRolePolicies(serviceName string, filterStr string) ([]*pms.RolePolicy, error) {
+	f, err := filter.Parse(pms.KindRolePolicy, filterStr)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkService(serviceName); err != nil {
+		return nil, err
+	}
 	rolePolicyKeyPrefix := s.KeyPrefix + ServicesKey + KeySeparator + serviceName + KeySeparator + RolePoliciesKey
 	responses, err := s.prefixGet(rolePolicyKeyPrefix)
 	if err != nil {
@@ -877,11 +1122,8 @@ func (s *Store) ListAllRolePolicies(serviceName string, filter string) ([]*pms.R
 			if err != nil {
 				return nil, errors.New(errors.SerializationError, "failed to unmarshal role policy")
 			}
-			isExpected := true
-			if f != nil {
-				isExpected = nameFilter(rolePolicy.Name, f)
-			}
-			if isExpected {
+			rolePolicy.Revision = kv.ModRevision
+			if f.MatchRolePolicy(&rolePolicy) {
 				rolePolicies = append(rolePolicies, &rolePolicy)
 			}
 		}
@@ -893,6 +1135,9 @@ func (s *Store) GetRolePolicyCount(serviceName string) (int64, error) {
 	var rolePolicyCount int64 = 0
 	if len(serviceName) > 0 {
 		// Get the rolePolicy count in the specified service
+		if err := s.checkService(serviceName); err != nil {
+			return 0, err
+		}
 		return s.getRolePolicyCountImpl(serviceName)
 	} else {
 		// Get the rolePolicy count in all services
@@ -928,39 +1173,145 @@ func (s *Store) getRolePolicyCountImpl(serviceName string) (int64, error) {
 	return getResp.Count, nil
 }
 
-//TODO: to be implemented
-func (s *Store) GetPolicies(serviceName string, startID string, amount int) (policies []*pms.Policy, nextID string, err error) {
+// getPage gets at most amount entities under prefix in the order of keys, starting from the entity of startID.
+// add is called with the value and the revision of each entity, and the ID of the entity after them is returned.
+func (s *Store) getPage(prefix string, startID string, amount int, add func(value []byte, revision int64) error) (nextID string, err error) {
 	if amount <= 0 {
-		return nil, "", errors.Errorf(errors.InvalidRequest, "invalid input amount %d", amount)
+		return "", errors.Errorf(errors.InvalidRequest, "invalid input amount %d", amount)
 	}
-	policyPrefix := s.KeyPrefix + ServicesKey + KeySeparator + serviceName + KeySeparator + PoliciesKey + KeySeparator
-	end := clientv3.GetPrefixRangeEnd(policyPrefix)
+	end := clientv3.GetPrefixRangeEnd(prefix)
 	getOpts := []clientv3.OpOption{clientv3.WithRange(end), clientv3.WithLimit(int64(amount)), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend)}
-	resp, err := s.timeOutGet(policyPrefix+startID, getOpts...)
+	resp, err := s.timeOutGet(prefix+startID, getOpts...)
 	if err != nil {
-		return nil, "", err
+		return "", errors.Wrap(err, errors.StoreError, "failed to get entities from etcd server")
 	}
 	for _, kv := range resp.Kvs {
-		var policy pms.Policy
-		err = json.Unmarshal(kv.Value, &policy)
-		if err != nil {
-			return nil, "", errors.Wrap(err, errors.SerializationError, "failed to unmarshal policies")
+		if err := add(kv.Value, kv.ModRevision); err != nil {
+			return "", err
 		}
-		policies = append(policies, &policy)
 	}
-	if len(policies) == amount {
+	if len(resp.Kvs) == amount {
 		lastKey := string(resp.Kvs[amount-1].Key)
 		startOfNextRange := clientv3.GetPrefixRangeEnd(lastKey)
 		getOpts = []clientv3.OpOption{clientv3.WithRange(end), clientv3.WithKeysOnly(), clientv3.WithLimit(1), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend)}
 		resp, err = s.timeOutGet(startOfNextRange, getOpts...)
 		if err != nil {
-			return nil, "", err
+			return "", errors.Wrap(err, errors.StoreError, "failed to get entities from etcd server")
 		}
 		for _, kv := range resp.Kvs {
-			nextID = strings.TrimPrefix(string(kv.Key), policyPrefix)
+			nextID = strings.TrimPrefix(string(kv.Key), prefix)
+		}
+	}
+	return nextID, nil
+}
+
+// GetPolicies gets at most amount policies of a service in the order of IDs, starting from the policy of startID,
+// and the ID of the policy after them, which is empty if there are no more policies
+func (s *Store) GetPolicies(serviceName string, startID string, amount int) (policies []*pms.Policy, nextID string, err error) {
+	policyPrefix := s.KeyPrefix + ServicesKey + KeySeparator + serviceName + KeySeparator + PoliciesKey + KeySeparator
+	nextID, err = s.getPage(policyPrefix, startID, amount, func(value []byte, revision int64) error {
+		var policy pms.Policy
+		if err := json.Unmarshal(value, &policy); err != nil {
+			return errors.Wrap(err, errors.SerializationError, "failed to unmarshal policies")
+		}
+		policy.Revision = revision
+		policies = append(policies, &policy)
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return policies, nextID, nil
+}
+
+// GetRolePolicies gets at most amount role policies of a service in the order of IDs, starting from the role policy
+// of startID, and the ID of the role policy after them, which is empty if there are no more role policies
+func (s *Store) GetRolePolicies(serviceName string, startID string, amount int) (rolePolicies []*pms.RolePolicy, nextID string, err error) {
+	rolePolicyPrefix := s.KeyPrefix + ServicesKey + KeySeparator + serviceName + KeySeparator + RolePoliciesKey + KeySeparator
+	nextID, err = s.getPage(rolePolicyPrefix, startID, amount, func(value []byte, revision int64) error {
+		var rolePolicy pms.RolePolicy
+		if err := json.Unmarshal(value, &rolePolicy); err != nil {
+			return errors.Wrap(err, errors.SerializationError, "failed to unmarshal role policy")
+		}
+		rolePolicy.Revision = revision
+		rolePolicies = append(rolePolicies, &rolePolicy)
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return rolePolicies, nextID, nil
+}
+
+// ListPolicies lists a page of policies in the order of their IDs, which are read in batches by GetPolicies
+func (s *Store) ListPolicies(serviceName string, opts *pms.ListOptions) ([]*pms.Policy, string, error) {
+	f, limit, after, err := store.ParseListOptions(pms.KindPolicy, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.checkService(serviceName); err != nil {
+		return nil, "", err
+	}
+	// the first ID after the one the page continues after
+	startID := ""
+	if len(after) != 0 {
+		startID = after + "\x00"
+	}
+	policies := []*pms.Policy{}
+	for {
+		batch, nextID, err := s.GetPolicies(serviceName, startID, store.ListBatchSize)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, policy := range batch {
+			if !f.MatchPolicy(policy) {
+				continue
+			}
+			policies = append(policies, policy)
+			if len(policies) == limit {
+				return policies, store.ContinueToken(policy.ID), nil
+			}
+		}
+		if len(nextID) == 0 {
+			return policies, "", nil
+		}
+		startID = nextID
+	}
+}
+
+// ListRolePolicies lists a page of role policies in the order of their IDs, which are read in batches by GetRolePolicies
+func (s *Store) ListRolePolicies(serviceName string, opts *pms.ListOptions) ([]*pms.RolePolicy, string, error) {
+	f, limit, after, err := store.ParseListOptions(pms.KindRolePolicy, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.checkService(serviceName); err != nil {
+		return nil, "", err
+	}
+	startID := ""
+	if len(after) != 0 {
+		startID = after + "\x00"
+	}
+	rolePolicies := []*pms.RolePolicy{}
+	for {
+		batch, nextID, err := s.GetRolePolicies(serviceName, startID, store.ListBatchSize)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, rolePolicy := range batch {
+			if !f.MatchRolePolicy(rolePolicy) {
+				continue
+			}
+			rolePolicies = append(rolePolicies, rolePolicy)
+			if len(rolePolicies) == limit {
+				return rolePolicies, store.ContinueToken(rolePolicy.ID), nil
+			}
 		}
+		if len(nextID) == 0 {
+			return rolePolicies, "", nil
+		}
+		startID = nextID
 	}
-	return policies, nextID, err
 }
 
 func (s *Store) GetRolePolicy(serviceName string, id string) (*pms.RolePolicy, error) {
@@ -979,6 +1330,7 @@ func (s *Store) GetRolePolicy(serviceName string, id string) (*pms.RolePolicy, e
 	if err != nil {
 		return nil, errors.Wrap(err, errors.SerializationError, "failed to unmarshal role policy")
 	}
+	rolePolicy.Revision = getResp.Kvs[0].ModRevision
 	return &rolePolicy, nil
 }
 
@@ -986,13 +1338,16 @@ func (s *Store) DeleteRolePolicy(serviceName string, id string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
 	defer cancel()
 	rolePolicyKey := s.KeyPrefix + ServicesKey + KeySeparator + serviceName + KeySeparator + RolePoliciesKey + KeySeparator + id
-	txnResp, err := s.client.KV.Txn(ctx).If(
-		clientv3.Compare(clientv3.Version(rolePolicyKey), ">", 0), //key exist
-	).Then(
-		clientv3.OpDelete(rolePolicyKey),
-		//make sure updating service key is the last operation, so watch could work correctly
-		clientv3.OpPut(s.KeyPrefix+ServicesKey+KeySeparator+serviceName+KeySeparator, ""),
-	).Commit()
+	txnResp, err := s.commitWithHistory(ctx, []*pms.Operation{{Op: pms.OpDelete, Kind: pms.KindRolePolicy, ServiceName: serviceName, ID: id}},
+		[]clientv3.Cmp{
+			clientv3.Compare(clientv3.Version(rolePolicyKey), ">", 0), //key exist
+		},
+		[]clientv3.Op{
+			clientv3.OpDelete(rolePolicyKey),
+			//make sure updating service key is the last operation, so watch could work correctly
+			clientv3.OpPut(s.KeyPrefix+ServicesKey+KeySeparator+serviceName+KeySeparator, ""),
+		},
+	)
 	if err != nil {
 		return errors.Wrap(err, errors.StoreError, "failed to delete a role policy from etcd server")
 	}
@@ -1005,20 +1360,35 @@ func (s *Store) DeleteRolePolicy(serviceName string, id string) error {
 func (s *Store) DeleteRolePolicies(serviceName string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
 	defer cancel()
-	_, err := s.client.KV.Txn(ctx).Then(
-		clientv3.OpDelete(s.KeyPrefix+ServicesKey+KeySeparator+serviceName+KeySeparator+RolePoliciesKey, clientv3.WithPrefix()),
+	prefix := s.KeyPrefix + ServicesKey + KeySeparator + serviceName + KeySeparator + RolePoliciesKey + KeySeparator
+	ids, err := s.childNames(prefix)
+	if err != nil {
+		return err
+	}
+	var keys []string
+	var ops []*pms.Operation
+	for _, id := range ids {
+		keys = append(keys, prefix+id)
+		ops = append(ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindRolePolicy, ServiceName: serviceName, ID: id})
+	}
+	succeeded, err := s.deleteWithHistory(ctx, keys, ops, false,
+		[]clientv3.Cmp{clientv3.Compare(clientv3.Version(s.serviceKey(serviceName)), ">", 0)}, //service key exist
 		//make sure updating service key is the last operation, so watch could work correctly
 		clientv3.OpPut(s.KeyPrefix+ServicesKey+KeySeparator+serviceName+KeySeparator, ""),
-	).Commit()
+	)
 	if err != nil {
 		return errors.Wrap(err, errors.StoreError, "failed to delete all policies from etcd server")
 	}
+	if !succeeded {
+		return errors.Errorf(errors.EntityNotFound, "service %q is not found", serviceName)
+	}
 	return nil
 }
 
 func (s *Store) CreateRolePolicy(serviceName string, rolePolicy *pms.RolePolicy) (*pms.RolePolicy, error) {
 	//TODO: validate rolePolicy
 	dupRolePolicy := *rolePolicy
+	dupRolePolicy.Revision = 0
 	if rolePolicy.ID == "" {
 		dupRolePolicy.ID = suid.New().String()
 	}
@@ -1031,78 +1401,61 @@ func (s *Store) CreateRolePolicy(serviceName string, rolePolicy *pms.RolePolicy)
 
 	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
 	defer cancel()
-	txnResp, err := s.client.KV.Txn(ctx).If(
-		clientv3.Compare(clientv3.Version(serviceKey), ">", 0),    //service key exist
-		clientv3.Compare(clientv3.Version(rolePolicyKey), "=", 0), //role policy key does not exist
-	).Then(
-		clientv3.OpPut(rolePolicyKey, string(value)),
-		//make sure updating service key is the last operation, so watch could work correctly
-		clientv3.OpPut(serviceKey, ""),
-	).Commit()
+	txnResp, err := s.commitWithHistory(ctx, []*pms.Operation{{Op: pms.OpCreate, Kind: pms.KindRolePolicy, ServiceName: serviceName, RolePolicy: &dupRolePolicy}},
+		[]clientv3.Cmp{
+			clientv3.Compare(clientv3.Version(serviceKey), ">", 0),    //service key exist
+			clientv3.Compare(clientv3.Version(rolePolicyKey), "=", 0), //role policy key does not exist
+		},
+		[]clientv3.Op{
+			clientv3.OpPut(rolePolicyKey, string(value)),
+			//make sure updating service key is the last operation, so watch could work correctly
+			clientv3.OpPut(serviceKey, ""),
+		},
+		clientv3.OpGet(serviceKey),
+	)
 	if err != nil {
 		return nil, errors.Wrap(err, errors.StoreError, "failed to create role policy in etcd server")
 	}
 	if !txnResp.Succeeded {
+		if _, found := currentModRevision(txnResp); !found {
+			return nil, errors.Errorf(errors.EntityNotFound, "service %q is not found", serviceName)
+		}
 		return nil, errors.Errorf(errors.EntityAlreadyExists, "role policy %q already exists in service %q", dupRolePolicy.ID, serviceName)
 	}
+	dupRolePolicy.Revision = txnResp.Header.Revision
 	return &dupRolePolicy, nil
 }
 
-type filter struct {
-	field    string
-	operator string
-	target   string
-}
-
-func (f filter) String() string {
-	return fmt.Sprint(f.field, f.operator, f.target)
-}
+func (s *Store) UpdateRolePolicy(serviceName string, rolePolicy *pms.RolePolicy) (*pms.RolePolicy, error) {
+	dupRolePolicy := *rolePolicy
+	dupRolePolicy.Revision = 0
+	serviceKey := s.KeyPrefix + ServicesKey + KeySeparator + serviceName + KeySeparator
+	rolePolicyKey := s.KeyPrefix + ServicesKey + KeySeparator + serviceName + KeySeparator + RolePoliciesKey + KeySeparator + dupRolePolicy.ID
+	value, err := json.Marshal(dupRolePolicy)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.SerializationError, "failed to marshal role policy")
+	}
 
-func parseFilter(filterStr string) *filter {
-	if len(filterStr) == 0 {
-		return nil
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	txnResp, err := s.commitWithHistory(ctx, []*pms.Operation{{Op: pms.OpUpdate, Kind: pms.KindRolePolicy, ServiceName: serviceName, RolePolicy: &dupRolePolicy}},
+		updateCompares(rolePolicyKey, rolePolicy.Revision),
+		[]clientv3.Op{
+			clientv3.OpPut(rolePolicyKey, string(value)),
+			//make sure updating service key is the last operation, so watch could work correctly
+			clientv3.OpPut(serviceKey, ""),
+		},
+		clientv3.OpGet(rolePolicyKey),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.StoreError, "failed to update role policy in etcd server")
 	}
-	values := strings.Split(filterStr, " ")
-	if len(values) >= 2 {
-		f := &filter{
-			field:    values[0],
-			operator: values[1],
-		}
-		if len(values) > 2 {
-			f.target = values[2]
+	if !txnResp.Succeeded {
+		if current, found := currentModRevision(txnResp); found {
+			return nil, errors.Errorf(errors.RevisionConflict, "revision %d of role policy %q does not match the current revision %d", rolePolicy.Revision, rolePolicy.ID, current)
 		}
-		return f
-	} else {
-		log.Error("invalid filter string:", filterStr)
-		return nil
-	}
-
-}
-
-func nameFilter(name string, f *filter) bool { //this filter function return true when the input filter is invalid.
-	if f.field != "name" {
-		log.Error("invalid name filter. filter is:", f)
-		return true
-	}
-	switch f.operator {
-	case "eq":
-		return name == f.target
-	case "co":
-		return strings.Contains(name, f.target)
-	case "sw":
-		return strings.HasPrefix(name, f.target)
-	case "pr":
-		return len(name) > 0
-	case "gt":
-		return name > f.target
-	case "ge":
-		return name >= f.target
-	case "lt":
-		return name < f.target
-	case "le":
-		return name <= f.target
-	default:
-		log.Error("invalid name filter:", f)
-		return true
+		return nil, errors.Errorf(errors.EntityNotFound, "role policy %q is not found in service %q", dupRolePolicy.ID, serviceName)
 	}
+	dupRolePolicy.Revision = txnResp.Header.Revision
+	return &dupRolePolicy, nil
 }
@@ -709,4 +709,38 @@ func TestWatch(t *testing.T) {
 		}
 	}
 
+	//changes of a transaction are received together
+	_, err = store.ExecuteTransaction([]*pms.Operation{
+		{Op: pms.OpCreate, Kind: pms.KindService, Service: &pms.Service{Name: "app2_new", Type: pms.TypeApplication}},
+		{Op: pms.OpCreate, Kind: pms.KindFunction, Function: &pms.Function{Name: "func_new", FuncURL: "https://localhost:23456/func_new"}},
+	})
+	if err != nil {
+		t.Fatal("fail to execute transaction:", err)
+	}
+	select {
+	case <-time.After(5 * time.Second):
+		t.Errorf("fail to receive policy update event")
+	case e := <-ch:
+		if e.Type != pms.BATCH {
+			t.Errorf("expected event type: %d, received event type :%d\n", pms.BATCH, e.Type)
+		} else if events := e.Content.([]pms.StoreChangeEvent); len(events) != 2 {
+			t.Errorf("expected 2 events in batch, received %d\n", len(events))
+		}
+	}
+
+	_, err = store.ExecuteTransaction([]*pms.Operation{
+		{Op: pms.OpDelete, Kind: pms.KindService, ID: "app2_new"},
+		{Op: pms.OpDelete, Kind: pms.KindFunction, ID: "func_new"},
+	})
+	if err != nil {
+		t.Fatal("fail to execute transaction:", err)
+	}
+	select {
+	case <-time.After(5 * time.Second):
+		t.Errorf("fail to receive policy update event")
+	case e := <-ch:
+		if e.Type != pms.BATCH {
+			t.Errorf("expected event type: %d, received event type :%d\n", pms.BATCH, e.Type)
+		}
+	}
 }
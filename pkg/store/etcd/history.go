@@ -0,0 +1,222 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package etcd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+	"github.com/teramoby/speedle-plus/pkg/suid"
+	"golang.org/x/net/context"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+)
+
+const (
+	HistoryKey = "history"
+)
+
+// historyKeyPrefix returns the prefix of the keys of all versions of an entity, e.g.
+// ${KeyPrefix}history/policy/${serviceName}/${policyID}/ or ${KeyPrefix}history/function/${functionName}/
+func (s *Store) historyKeyPrefix(kind, serviceName, id string) string {
+	prefix := s.KeyPrefix + HistoryKey + KeySeparator + kind + KeySeparator
+	if kind == pms.KindPolicy || kind == pms.KindRolePolicy {
+		prefix += serviceName + KeySeparator
+	}
+	return prefix + id + KeySeparator
+}
+
+// historyOps returns the operations keeping the changes made by ops, which are committed in the same txn as
+// the changes. The version of a record is the revision of the txn, which is unknown before the txn is committed,
+// so a record is put under a new key, and its version is taken from the create revision of the key.
+// The oldest versions of an entity are removed, so at most store.MaxHistoryVersions versions are kept.
+func (s *Store) historyOps(ops []*pms.Operation) ([]clientv3.Op, error) {
+	txnID := suid.New().String()
+	var historyOps []clientv3.Op
+	expired := make(map[string]bool)
+	for i, record := range store.NewHistoryRecords(ops, 0) {
+		value, err := json.Marshal(record)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.SerializationError, "failed to marshal history record")
+		}
+		prefix := s.historyKeyPrefix(record.Kind, record.ServiceName, record.ID)
+		historyOps = append(historyOps, clientv3.OpPut(prefix+txnID+fmt.Sprintf("%04d", i), string(value)))
+		if expired[prefix] {
+			continue
+		}
+		expired[prefix] = true
+		deletes, err := s.expiredHistoryOps(prefix)
+		if err != nil {
+			return nil, err
+		}
+		historyOps = append(historyOps, deletes...)
+	}
+	return historyOps, nil
+}
+
+// expiredHistoryOps returns the operations deleting the oldest versions of an entity, which leaves room for a new version
+func (s *Store) expiredHistoryOps(prefix string) ([]clientv3.Op, error) {
+	responses, err := s.prefixGet(prefix, clientv3.WithKeysOnly())
+	if err != nil {
+		return nil, errors.Wrap(err, errors.StoreError, "failed to get history from etcd server")
+	}
+	type historyKey struct {
+		key     string
+		version int64
+	}
+	var keys []historyKey
+	for _, resp := range responses {
+		for _, kv := range resp.Kvs {
+			keys = append(keys, historyKey{key: string(kv.Key), version: kv.CreateRevision})
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].version < keys[j].version })
+	var versions []int64
+	for _, key := range keys {
+		if len(versions) == 0 || versions[len(versions)-1] != key.version {
+			versions = append(versions, key.version)
+		}
+	}
+	// the new version is unknown yet, which is after all existing ones
+	expired := store.ExpiredHistoryVersion(append(versions, math.MaxInt64))
+	var deletes []clientv3.Op
+	for _, key := range keys {
+		if key.version > expired {
+			break
+		}
+		deletes = append(deletes, clientv3.OpDelete(key.key))
+	}
+	return deletes, nil
+}
+
+// commitWithHistory commits the change made by ops in one txn with their history, the txn changes keys
+// only if the conditions are met. The history is put first, so thenOps could still end with the service key.
+func (s *Store) commitWithHistory(ctx context.Context, ops []*pms.Operation, cmps []clientv3.Cmp, thenOps []clientv3.Op, elseOps ...clientv3.Op) (*clientv3.TxnResponse, error) {
+	historyOps, err := s.historyOps(ops)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.KV.Txn(ctx).If(cmps...).Then(append(historyOps, thenOps...)...).Else(elseOps...).Commit()
+}
+
+// childNames returns the last segment of the keys directly under prefix
+func (s *Store) childNames(prefix string) ([]string, error) {
+	responses, err := s.prefixGet(prefix, clientv3.WithKeysOnly())
+	if err != nil {
+		return nil, errors.Wrap(err, errors.StoreError, "failed to get data from etcd server")
+	}
+	var names []string
+	for _, resp := range responses {
+		for _, kv := range resp.Kvs {
+			name := strings.TrimSuffix(strings.TrimPrefix(string(kv.Key), prefix), KeySeparator)
+			if len(name) > 0 && !strings.Contains(name, KeySeparator) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// deleteWithHistory deletes the keys, with all keys under them if prefix is true, and keeps the deletions made by ops,
+// one for each key, in the same txn. etcd allows a limited number of operations in one txn, so many keys are deleted
+// in several txns, each of which is committed only if the conditions are met, and ends with thenOps.
+func (s *Store) deleteWithHistory(ctx context.Context, keys []string, ops []*pms.Operation, prefix bool, cmps []clientv3.Cmp, thenOps ...clientv3.Op) (bool, error) {
+	maxOps := int(embed.DefaultMaxTxnOps) - len(thenOps)
+	var txnOps []clientv3.Op
+	commit := func() (bool, error) {
+		txnResp, err := s.client.KV.Txn(ctx).If(cmps...).Then(append(txnOps, thenOps...)...).Commit()
+		if err != nil {
+			return false, err
+		}
+		txnOps = nil
+		return txnResp.Succeeded, nil
+	}
+	for i, key := range keys {
+		historyOps, err := s.historyOps(ops[i : i+1])
+		if err != nil {
+			return false, err
+		}
+		deleteOp := clientv3.OpDelete(key)
+		if prefix {
+			deleteOp = clientv3.OpDelete(key, clientv3.WithPrefix())
+		}
+		keyOps := append(historyOps, deleteOp)
+		if len(txnOps) > 0 && len(txnOps)+len(keyOps) > maxOps {
+			if succeeded, err := commit(); err != nil || !succeeded {
+				return succeeded, err
+			}
+		}
+		txnOps = append(txnOps, keyOps...)
+	}
+	return commit()
+}
+
+// ListHistory gets all versions of a service, policy, role policy or function, the oldest first.
+func (s *Store) ListHistory(kind, serviceName, id string) ([]*pms.HistoryRecord, error) {
+	responses, err := s.prefixGet(s.historyKeyPrefix(kind, serviceName, id))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.StoreError, "failed to get history from etcd server")
+	}
+	var records []*pms.HistoryRecord
+	for _, resp := range responses {
+		for _, kv := range resp.Kvs {
+			record, err := decodeHistoryRecord(kv.Value, kv.CreateRevision)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, record)
+		}
+	}
+	if len(records) == 0 {
+		return nil, errors.Errorf(errors.EntityNotFound, "no history found for %s %q", kind, id)
+	}
+	// the keys of the records of one version are in order
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Version < records[j].Version })
+	return records, nil
+}
+
+// decodeHistoryRecord gets a history record, whose version is the create revision of its key
+// if it is not kept in the record
+func decodeHistoryRecord(value []byte, createRevision int64) (*pms.HistoryRecord, error) {
+	var record pms.HistoryRecord
+	if err := json.Unmarshal(value, &record); err != nil {
+		return nil, errors.Wrap(err, errors.SerializationError, "failed to unmarshal history record")
+	}
+	if record.Version != 0 {
+		return &record, nil
+	}
+	record.Version = createRevision
+	switch {
+	case record.Service != nil:
+		record.Service.Revision = record.Version
+	case record.Policy != nil:
+		record.Policy.Revision = record.Version
+	case record.RolePolicy != nil:
+		record.RolePolicy.Revision = record.Version
+	case record.Function != nil:
+		record.Function.Revision = record.Version
+	}
+	return &record, nil
+}
+
+// GetHistory gets a version of a service, policy, role policy or function.
+func (s *Store) GetHistory(kind, serviceName, id string, version int64) (*pms.HistoryRecord, error) {
+	records, err := s.ListHistory(kind, serviceName, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.Version == version {
+			return record, nil
+		}
+	}
+	return nil, errors.Errorf(errors.EntityNotFound, "version %d of %s %q is not found", version, kind, id)
+}
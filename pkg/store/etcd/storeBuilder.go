@@ -161,6 +161,29 @@ func convertValueToBool(val interface{}, keyName string) (bool, error) {
 	}
 }
 
+// TenantStoreConfig keeps the policies of a tenant under the key prefix of the default tenant followed by the tenant,
+// e.g. /speedle_ps_acme/, which is not under the one of the default tenant so its watchers do not see the changes.
+// The stores of the tenants connect to the embedded etcd server started by the store of the default tenant.
+func (esb Etcd3StoreBuilder) TenantStoreConfig(config map[string]interface{}, tenant string) (map[string]interface{}, error) {
+	keyPrefix, ok := config[EtcdKeyPrefixKey].(string)
+	if !ok {
+		keyPrefix = DefaultKeyPrefix
+	}
+	config[EtcdKeyPrefixKey] = strings.TrimSuffix(keyPrefix, KeySeparator) + "_" + tenant + KeySeparator
+
+	if val, ok := config[IsEmbeddedEtcdKey]; ok {
+		isEmbeddedEtcd, err := convertValueToBool(val, IsEmbeddedEtcdKey)
+		if err != nil {
+			return nil, err
+		}
+		if isEmbeddedEtcd {
+			config[IsEmbeddedEtcdKey] = false
+			config[EtcdEndpointKey] = "localhost:2379"
+		}
+	}
+	return config, nil
+}
+
 func (esb Etcd3StoreBuilder) GetStoreParams() map[string]string {
 	return map[string]string{
 
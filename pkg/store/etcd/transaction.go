@@ -0,0 +1,427 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package etcd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/suid"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+	"golang.org/x/net/context"
+)
+
+// txnGuard is a condition of a key checked when the transaction is committed
+type txnGuard struct {
+	key      string
+	exist    bool
+	revision int64
+	desc     string
+}
+
+func (g *txnGuard) violation(found bool, current int64) error {
+	if found != g.exist {
+		if g.exist {
+			return errors.Errorf(errors.EntityNotFound, "%s is not found", g.desc)
+		}
+		return errors.Errorf(errors.EntityAlreadyExists, "%s already exists", g.desc)
+	}
+	if g.exist && g.revision > 0 && g.revision != current {
+		return errors.Errorf(errors.RevisionConflict, "revision %d of %s does not match the current revision %d", g.revision, g.desc, current)
+	}
+	return nil
+}
+
+type txnChange struct {
+	key     string
+	value   string
+	delete  bool
+	prefix  bool
+	dropped bool
+}
+
+// txnBuilder merges the operations of a transaction into one etcd txn. etcd does not allow
+// a key to be written twice in one txn, so only the last change of each key is kept.
+type txnBuilder struct {
+	store    *Store
+	guards   []*txnGuard
+	cmps     []clientv3.Cmp
+	changes  []*txnChange
+	changeOf map[string]*txnChange
+	// service keys are updated at last, so watch could work correctly
+	serviceKeys []string
+	touched     map[string]bool
+	// existence of the keys changed by earlier operations in this transaction
+	exists  map[string]bool
+	deleted []string
+}
+
+func (s *Store) serviceKey(serviceName string) string {
+	return s.KeyPrefix + ServicesKey + KeySeparator + serviceName + KeySeparator
+}
+
+func (b *txnBuilder) existsInTxn(key string) (exist bool, known bool) {
+	if exist, ok := b.exists[key]; ok {
+		return exist, true
+	}
+	for _, prefix := range b.deleted {
+		if strings.HasPrefix(key, prefix) {
+			return false, true
+		}
+	}
+	return false, false
+}
+
+func (b *txnBuilder) require(guard *txnGuard) error {
+	if exist, known := b.existsInTxn(guard.key); known {
+		if exist != guard.exist {
+			return guard.violation(exist, 0)
+		}
+		if guard.exist && guard.revision > 0 {
+			// the key is changed by this transaction, so the caller could not know its revision
+			return errors.Errorf(errors.RevisionConflict, "%s is changed by an earlier operation of the transaction", guard.desc)
+		}
+		return nil
+	}
+	b.guards = append(b.guards, guard)
+	if guard.exist {
+		b.cmps = append(b.cmps, updateCompares(guard.key, guard.revision)...)
+	} else {
+		b.cmps = append(b.cmps, clientv3.Compare(clientv3.Version(guard.key), "=", 0))
+	}
+	return nil
+}
+
+func (b *txnBuilder) setChange(change *txnChange) {
+	if old, ok := b.changeOf[change.key]; ok {
+		*old = *change
+		return
+	}
+	b.changes = append(b.changes, change)
+	b.changeOf[change.key] = change
+}
+
+func (b *txnBuilder) put(key string, value string) error {
+	for _, prefix := range b.deleted {
+		if strings.HasPrefix(key, prefix) {
+			return errors.Errorf(errors.InvalidRequest, "%q can not be written after its service is deleted in the same transaction", key)
+		}
+	}
+	b.setChange(&txnChange{key: key, value: value})
+	b.exists[key] = true
+	return nil
+}
+
+func (b *txnBuilder) delete(key string) {
+	b.setChange(&txnChange{key: key, delete: true})
+	b.exists[key] = false
+}
+
+func (b *txnBuilder) deleteService(serviceName string) {
+	prefix := b.store.serviceKey(serviceName)
+	for _, change := range b.changes {
+		if strings.HasPrefix(change.key, prefix) {
+			change.dropped = true
+		}
+	}
+	for key := range b.exists {
+		if strings.HasPrefix(key, prefix) {
+			delete(b.exists, key)
+		}
+	}
+	delete(b.touched, prefix)
+	b.setChange(&txnChange{key: prefix, delete: true, prefix: true})
+	b.deleted = append(b.deleted, prefix)
+}
+
+func (b *txnBuilder) touchService(serviceName string) {
+	key := b.store.serviceKey(serviceName)
+	if !b.touched[key] {
+		b.serviceKeys = append(b.serviceKeys, key)
+		b.touched[key] = true
+	}
+	b.exists[key] = true
+}
+
+func (b *txnBuilder) ops() []clientv3.Op {
+	var ops []clientv3.Op
+	for _, change := range b.changes {
+		switch {
+		case change.dropped:
+		case change.delete && change.prefix:
+			ops = append(ops, clientv3.OpDelete(change.key, clientv3.WithPrefix()))
+		case change.delete:
+			ops = append(ops, clientv3.OpDelete(change.key))
+		default:
+			ops = append(ops, clientv3.OpPut(change.key, change.value))
+		}
+	}
+	for _, key := range b.serviceKeys {
+		if b.touched[key] {
+			ops = append(ops, clientv3.OpPut(key, ""))
+		}
+	}
+	return ops
+}
+
+// diagnose finds out the guard failing the transaction
+func (b *txnBuilder) diagnose() error {
+	for _, guard := range b.guards {
+		getResp, err := b.store.timeOutGet(guard.key)
+		if err != nil {
+			return errors.Wrap(err, errors.StoreError, "failed to get data from etcd server")
+		}
+		var current int64
+		found := len(getResp.Kvs) > 0
+		if found {
+			current = getResp.Kvs[0].ModRevision
+		}
+		if err := guard.violation(found, current); err != nil {
+			return err
+		}
+	}
+	return errors.New(errors.RevisionConflict, "the store is changed during the transaction")
+}
+
+// ExecuteTransaction applies all the operations in one etcd txn
+func (s *Store) ExecuteTransaction(ops []*pms.Operation) ([]*pms.Operation, error) {
+	b := &txnBuilder{
+		store:    s,
+		changeOf: make(map[string]*txnChange),
+		touched:  make(map[string]bool),
+		exists:   make(map[string]bool),
+	}
+	results := make([]*pms.Operation, 0, len(ops))
+	for i, op := range ops {
+		result, err := b.addOperation(op)
+		if err != nil {
+			return nil, errors.Wrapf(err, errors.Code(err), "operation %d (%s %s) of the transaction failed", i, op.Op, op.Kind)
+		}
+		results = append(results, result)
+	}
+
+	historyOps, err := s.historyOps(results)
+	if err != nil {
+		return nil, err
+	}
+	txnOps := append(historyOps, b.ops()...)
+	maxOps := int(embed.DefaultMaxTxnOps)
+	if len(txnOps) > maxOps || len(b.cmps) > maxOps {
+		return nil, errors.Errorf(errors.ExceedLimit, "the transaction changes too many keys, at most %d keys are allowed", maxOps)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	txnResp, err := s.client.KV.Txn(ctx).If(b.cmps...).Then(txnOps...).Commit()
+	if err != nil {
+		return nil, errors.Wrap(err, errors.StoreError, "failed to commit the transaction to etcd server")
+	}
+	if !txnResp.Succeeded {
+		return nil, b.diagnose()
+	}
+	for _, result := range results {
+		setResultRevision(result, txnResp.Header.Revision)
+	}
+	return results, nil
+}
+
+func setResultRevision(result *pms.Operation, revision int64) {
+	if result.Service != nil {
+		result.Service.Revision = revision
+		for _, policy := range result.Service.Policies {
+			policy.Revision = revision
+		}
+		for _, rolePolicy := range result.Service.RolePolicies {
+			rolePolicy.Revision = revision
+		}
+	}
+	if result.Policy != nil {
+		result.Policy.Revision = revision
+	}
+	if result.RolePolicy != nil {
+		result.RolePolicy.Revision = revision
+	}
+	if result.Function != nil {
+		result.Function.Revision = revision
+	}
+}
+
+func (b *txnBuilder) addOperation(op *pms.Operation) (*pms.Operation, error) {
+	if op.Op != pms.OpCreate && op.Op != pms.OpUpdate && op.Op != pms.OpDelete {
+		return nil, errors.Errorf(errors.InvalidRequest, "unknown operation %q", op.Op)
+	}
+	switch op.Kind {
+	case pms.KindService:
+		return b.addServiceOperation(op)
+	case pms.KindPolicy, pms.KindRolePolicy:
+		serviceDesc := fmt.Sprintf("service %q", op.ServiceName)
+		if err := b.require(&txnGuard{key: b.store.serviceKey(op.ServiceName), exist: true, desc: serviceDesc}); err != nil {
+			return nil, err
+		}
+		if op.Kind == pms.KindPolicy {
+			return b.addPolicyOperation(op)
+		}
+		return b.addRolePolicyOperation(op)
+	case pms.KindFunction:
+		return b.addFunctionOperation(op)
+	}
+	return nil, errors.Errorf(errors.InvalidRequest, "unknown kind %q", op.Kind)
+}
+
+func (b *txnBuilder) addServiceOperation(op *pms.Operation) (*pms.Operation, error) {
+	result := pms.Operation{Op: op.Op, Kind: op.Kind, ID: op.ID, ChangedBy: op.ChangedBy}
+	if op.Op == pms.OpDelete {
+		if err := b.require(&txnGuard{key: b.store.serviceKey(op.ID), exist: true, revision: op.Revision, desc: fmt.Sprintf("service %q", op.ID)}); err != nil {
+			return nil, err
+		}
+		b.deleteService(op.ID)
+		return &result, nil
+	}
+
+	if op.Service == nil {
+		return nil, errors.New(errors.InvalidRequest, "service is not specified")
+	}
+	dupService := *op.Service
+	result.ID = dupService.Name
+	guard := &txnGuard{key: b.store.serviceKey(dupService.Name), exist: op.Op == pms.OpUpdate, revision: dupService.Revision, desc: fmt.Sprintf("service %q", dupService.Name)}
+	if err := b.require(guard); err != nil {
+		return nil, err
+	}
+	if op.Op == pms.OpCreate {
+		putOps, err := b.store.getPutOps(&dupService)
+		if err != nil {
+			return nil, err
+		}
+		// the last one is the service key, which is updated by touchService
+		for _, putOp := range putOps[:len(putOps)-1] {
+			if err := b.put(string(putOp.KeyBytes()), string(putOp.ValueBytes())); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		dupService.Policies = nil
+		dupService.RolePolicies = nil
+		for _, putOp := range serviceAttributeOps(b.store.serviceKey(dupService.Name), &dupService) {
+			if err := b.put(string(putOp.KeyBytes()), string(putOp.ValueBytes())); err != nil {
+				return nil, err
+			}
+		}
+	}
+	b.touchService(dupService.Name)
+	result.Service = &dupService
+	return &result, nil
+}
+
+func (b *txnBuilder) addPolicyOperation(op *pms.Operation) (*pms.Operation, error) {
+	result := pms.Operation{Op: op.Op, Kind: op.Kind, ServiceName: op.ServiceName, ID: op.ID, ChangedBy: op.ChangedBy}
+	prefix := b.store.serviceKey(op.ServiceName) + PoliciesKey + KeySeparator
+	if op.Op == pms.OpDelete {
+		if err := b.require(&txnGuard{key: prefix + op.ID, exist: true, revision: op.Revision, desc: fmt.Sprintf("policy %q in service %q", op.ID, op.ServiceName)}); err != nil {
+			return nil, err
+		}
+		b.delete(prefix + op.ID)
+		b.touchService(op.ServiceName)
+		return &result, nil
+	}
+
+	if op.Policy == nil {
+		return nil, errors.New(errors.InvalidRequest, "policy is not specified")
+	}
+	dupPolicy := *op.Policy
+	if op.Op == pms.OpCreate {
+		dupPolicy.ID = suid.New().String()
+	}
+	result.ID = dupPolicy.ID
+	guard := &txnGuard{key: prefix + dupPolicy.ID, exist: op.Op == pms.OpUpdate, revision: dupPolicy.Revision, desc: fmt.Sprintf("policy %q in service %q", dupPolicy.ID, op.ServiceName)}
+	if err := b.require(guard); err != nil {
+		return nil, err
+	}
+	dupPolicy.Revision = 0
+	value, err := json.Marshal(dupPolicy)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.SerializationError, "failed to marshal policy")
+	}
+	if err := b.put(prefix+dupPolicy.ID, string(value)); err != nil {
+		return nil, err
+	}
+	b.touchService(op.ServiceName)
+	result.Policy = &dupPolicy
+	return &result, nil
+}
+
+func (b *txnBuilder) addRolePolicyOperation(op *pms.Operation) (*pms.Operation, error) {
+	result := pms.Operation{Op: op.Op, Kind: op.Kind, ServiceName: op.ServiceName, ID: op.ID, ChangedBy: op.ChangedBy}
+	prefix := b.store.serviceKey(op.ServiceName) + RolePoliciesKey + KeySeparator
+	if op.Op == pms.OpDelete {
+		if err := b.require(&txnGuard{key: prefix + op.ID, exist: true, revision: op.Revision, desc: fmt.Sprintf("role policy %q in service %q", op.ID, op.ServiceName)}); err != nil {
+			return nil, err
+		}
+		b.delete(prefix + op.ID)
+		b.touchService(op.ServiceName)
+		return &result, nil
+	}
+
+	if op.RolePolicy == nil {
+		return nil, errors.New(errors.InvalidRequest, "role policy is not specified")
+	}
+	dupRolePolicy := *op.RolePolicy
+	if op.Op == pms.OpCreate {
+		dupRolePolicy.ID = suid.New().String()
+	}
+	result.ID = dupRolePolicy.ID
+	guard := &txnGuard{key: prefix + dupRolePolicy.ID, exist: op.Op == pms.OpUpdate, revision: dupRolePolicy.Revision, desc: fmt.Sprintf("role policy %q in service %q", dupRolePolicy.ID, op.ServiceName)}
+	if err := b.require(guard); err != nil {
+		return nil, err
+	}
+	dupRolePolicy.Revision = 0
+	value, err := json.Marshal(dupRolePolicy)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.SerializationError, "failed to marshal role policy")
+	}
+	if err := b.put(prefix+dupRolePolicy.ID, string(value)); err != nil {
+		return nil, err
+	}
+	b.touchService(op.ServiceName)
+	result.RolePolicy = &dupRolePolicy
+	return &result, nil
+}
+
+func (b *txnBuilder) addFunctionOperation(op *pms.Operation) (*pms.Operation, error) {
+	result := pms.Operation{Op: op.Op, Kind: op.Kind, ID: op.ID, ChangedBy: op.ChangedBy}
+	prefix := b.store.KeyPrefix + FunctionsKey + KeySeparator
+	if op.Op == pms.OpDelete {
+		if err := b.require(&txnGuard{key: prefix + op.ID, exist: true, revision: op.Revision, desc: fmt.Sprintf("function %q", op.ID)}); err != nil {
+			return nil, err
+		}
+		b.delete(prefix + op.ID)
+		return &result, nil
+	}
+
+	if op.Function == nil {
+		return nil, errors.New(errors.InvalidRequest, "function is not specified")
+	}
+	if err := validateFunc(op.Function); err != nil {
+		return nil, err
+	}
+	dupFunction := *op.Function
+	result.ID = dupFunction.Name
+	guard := &txnGuard{key: prefix + dupFunction.Name, exist: op.Op == pms.OpUpdate, revision: dupFunction.Revision, desc: fmt.Sprintf("function %q", dupFunction.Name)}
+	if err := b.require(guard); err != nil {
+		return nil, err
+	}
+	dupFunction.Revision = 0
+	value, err := json.Marshal(dupFunction)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.SerializationError, "failed to marshal function")
+	}
+	if err := b.put(prefix+dupFunction.Name, string(value)); err != nil {
+		return nil, err
+	}
+	result.Function = &dupFunction
+	return &result, nil
+}
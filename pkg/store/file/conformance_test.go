@@ -0,0 +1,30 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/store"
+	"github.com/teramoby/speedle-plus/pkg/store/storetest"
+)
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) (pms.PolicyStoreManager, func()) {
+		dir, err := ioutil.TempDir("", "filestore")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ps, err := store.NewStore(StoreType, map[string]interface{}{FileLocationKey: filepath.Join(dir, "ps.json")})
+		if err != nil {
+			os.RemoveAll(dir)
+			t.Fatal("fail to new file store:", err)
+		}
+		return ps, func() { os.RemoveAll(dir) }
+	})
+}
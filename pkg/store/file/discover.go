@@ -190,7 +190,7 @@ func (s *discoverRequestStore) getDiscoverRequestsSinceRevision(serviceName stri
 		}
 		return requests, sContent.Requests[len(sContent.Requests)-1].Index, nil
 	}
-	return nil, -1, errors.Errorf(errors.EntityNotFound, "no discover request found for service %q.", serviceName)
+	return requests, revision, nil
 }
 
 // GetDiscoverRequests gets request logs for a service.
@@ -219,8 +219,7 @@ func (s *discoverRequestStore) getDiscoverRequests(serviceName string) ([]*ads.R
 		}
 		return requests, sContent.Requests[len(sContent.Requests)-1].Index, nil
 	}
-
-	return nil, -1, errors.Errorf(errors.EntityNotFound, "no discover request found for service %q.", serviceName)
+	return requests, -1, nil
 }
 
 // ResetDiscoverRequests cleans request logs for a service.
@@ -6,12 +6,14 @@ package file
 import (
 	"bufio"
 	"encoding/json"
-	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+	"github.com/teramoby/speedle-plus/pkg/store/filter"
 	"github.com/teramoby/speedle-plus/pkg/suid"
 
 	"github.com/fsnotify/fsnotify"
@@ -20,10 +22,15 @@ import (
 )
 
 type Store struct {
-	FileLocation  string
+	FileLocation string
+	// Functions are the names of the custom functions defined elsewhere, which the conditions in a SPDL file could
+	// call besides the built-in ones
+	Functions     []string
 	stop          chan struct{}
 	rwLock        sync.RWMutex
 	discoverStore *discoverRequestStore
+	historyStore  *historyStore
+	historyOnce   sync.Once
 }
 
 // ReadPolicyStore reads policy store from a file
@@ -66,19 +73,77 @@ func (s *Store) WritePolicyStore(ps *pms.PolicyStore) error {
 	s.rwLock.Lock()
 	defer s.rwLock.Unlock()
 
+	// keep the revision counter increasing even if the whole store is replaced
+	if current, err := s.readPolicyStoreWithoutLock(); err == nil && current.Revision > ps.Revision {
+		ps.Revision = current.Revision
+	}
+	for _, service := range ps.Services {
+		for _, policy := range service.Policies {
+			if len(policy.ID) == 0 {
+				policy.ID = suid.New().String()
+			}
+		}
+		for _, rolePolicy := range service.RolePolicies {
+			if len(rolePolicy.ID) == 0 {
+				rolePolicy.ID = suid.New().String()
+			}
+		}
+	}
 	return s.writePolicyStoreWithoutLock(ps)
 }
 
+// stampRevisions increases the revision counter of the store, and assigns it to the entities
+// whose revision is reset to 0 because they are newly created or changed
+func stampRevisions(ps *pms.PolicyStore) {
+	ps.Revision++
+	for _, function := range ps.Functions {
+		if function.Revision == 0 {
+			function.Revision = ps.Revision
+		}
+	}
+	for _, service := range ps.Services {
+		if service.Revision == 0 {
+			service.Revision = ps.Revision
+		}
+		for _, policy := range service.Policies {
+			if policy.Revision == 0 {
+				policy.Revision = ps.Revision
+			}
+		}
+		for _, rolePolicy := range service.RolePolicies {
+			if rolePolicy.Revision == 0 {
+				rolePolicy.Revision = ps.Revision
+			}
+		}
+	}
+}
+
+func checkRevision(expected, current int64, kind, name string) error {
+	if expected > 0 && expected != current {
+		return errors.Errorf(errors.RevisionConflict, "revision %d of %s %q does not match the current revision %d", expected, kind, name, current)
+	}
+	return nil
+}
+
 func (s *Store) writePolicyStoreWithoutLock(ps *pms.PolicyStore) error {
+	stampRevisions(ps)
+	var psB []byte
+	if strings.HasSuffix(s.FileLocation, ".spdl") {
+		var err error
+		if psB, err = formatSPDL(ps); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if psB, err = json.MarshalIndent(ps, "", "    "); err != nil {
+			return errors.Wrap(err, errors.StoreError, "marshal indent failed")
+		}
+	}
 	jsonFile, err := os.Create(s.FileLocation)
 	defer jsonFile.Close()
 	if err != nil {
 		return errors.Wrapf(err, errors.StoreError, "unable to create file %q", s.FileLocation)
 	}
-	psB, err := json.MarshalIndent(ps, "", "    ")
-	if err != nil {
-		return errors.Wrap(err, errors.StoreError, "marshal indent failed")
-	}
 	if _, err := jsonFile.Write(psB); err != nil {
 		return errors.Wrapf(err, errors.StoreError, "unable to write to file %q", s.FileLocation)
 	}
@@ -221,17 +286,54 @@ func (s *Store) CreateService(service *pms.Service) error {
 		ps.Services = append(ps.Services, serviceWithIDs)
 		err = s.writePolicyStoreWithoutLock(ps)
 	}
+	if err == nil {
+		s.recordHistory([]*pms.Operation{{Op: pms.OpCreate, Kind: pms.KindService, Service: serviceWithIDs}}, ps.Revision)
+	}
 	return err
 }
 
+// UpdateService updates the type, combining algorithm, default effect and metadata of an existing service
+func (s *Store) UpdateService(service *pms.Service) error {
+
+	s.rwLock.Lock()
+	defer s.rwLock.Unlock()
+
+	ps, err := s.readPolicyStoreWithoutLock()
+	if err != nil {
+		return err
+	}
+	for _, value := range ps.Services {
+		if service.Name == value.Name {
+			if err := checkRevision(service.Revision, value.Revision, "service", service.Name); err != nil {
+				return err
+			}
+			value.Type = service.Type
+			value.CombiningAlgorithm = service.CombiningAlgorithm
+			value.DefaultEffect = service.DefaultEffect
+			value.Attributes = service.Attributes
+			value.Metadata = service.Metadata
+			value.Revision = 0
+			if err := s.writePolicyStoreWithoutLock(ps); err != nil {
+				return err
+			}
+			s.recordHistory([]*pms.Operation{{Op: pms.OpUpdate, Kind: pms.KindService, Service: value}}, ps.Revision)
+			return nil
+		}
+	}
+	return errors.Errorf(errors.EntityNotFound, "service %q is not found", service.Name)
+}
+
 func generateID(service *pms.Service) (*pms.Service, error) {
 	var result pms.Service
 	result = *service
+	result.Revision = 0
 	for _, policy := range result.Policies {
 		policy.ID = suid.New().String()
+		policy.Revision = 0
 	}
 	for _, rolePolicy := range result.RolePolicies {
 		rolePolicy.ID = suid.New().String()
+		rolePolicy.Revision = 0
 	}
 	return &result, nil
 }
@@ -257,6 +359,8 @@ func (s *Store) writeServiceWithoutLock(service *pms.Service) error {
 			break
 		}
 	}
+	// any change in a service increases its revision
+	service.Revision = 0
 	ps.Services = append(ps.Services, service)
 	if err := s.writePolicyStoreWithoutLock(ps); err != nil {
 		return err
@@ -285,7 +389,10 @@ func (s *Store) DeleteService(serviceName string) error {
 	if !found {
 		return errors.Errorf(errors.EntityNotFound, "service %q is not found", serviceName)
 	}
-	s.writePolicyStoreWithoutLock(ps)
+	if err := s.writePolicyStoreWithoutLock(ps); err != nil {
+		return err
+	}
+	s.recordHistory([]*pms.Operation{{Op: pms.OpDelete, Kind: pms.KindService, ID: serviceName}}, ps.Revision)
 	return nil
 
 }
@@ -299,9 +406,17 @@ func (s *Store) DeleteServices() error {
 	if err != nil {
 		return err
 	}
+	var ops []*pms.Operation
+	for _, service := range ps.Services {
+		ops = append(ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindService, ID: service.Name})
+	}
 	ps.Services = []*pms.Service{}
 
-	return s.writePolicyStoreWithoutLock(ps)
+	if err := s.writePolicyStoreWithoutLock(ps); err != nil {
+		return err
+	}
+	s.recordHistory(ops, ps.Revision)
+	return nil
 }
 
 func (s *Store) Watch() (pms.StorageChangeChannel, error) {
@@ -379,29 +494,57 @@ func (s *Store) Type() string {
 }
 
 // For policy manager
-func (s *Store) ListAllPolicies(serviceName string, filter string) ([]*pms.Policy, error) {
+func (s *Store) ListAllPolicies(serviceName string, filterStr string) ([]*pms.Policy, error) {
 
 	s.rwLock.RLock()
 	defer s.rwLock.RUnlock()
 
-	f := parseFilter(filter)
+	f, err := filter.Parse(pms.KindPolicy, filterStr)
+	if err != nil {
+		return nil, err
+	}
 	service, err := s.getServiceWithoutLock(serviceName)
 	if err != nil {
 		return nil, err
 	}
 	ret := []*pms.Policy{}
 	for _, policy := range service.Policies {
-		isExpected := true
-		if f != nil {
-			isExpected = nameFilter(policy.Name, f)
-		}
-		if isExpected {
+		if f.MatchPolicy(policy) {
 			ret = append(ret, policy)
 		}
 	}
 	return ret, nil
 }
 
+// ListPolicies lists a page of policies in the order of their IDs
+func (s *Store) ListPolicies(serviceName string, opts *pms.ListOptions) ([]*pms.Policy, string, error) {
+	f, limit, after, err := store.ParseListOptions(pms.KindPolicy, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.rwLock.RLock()
+	defer s.rwLock.RUnlock()
+
+	service, err := s.getServiceWithoutLock(serviceName)
+	if err != nil {
+		return nil, "", err
+	}
+	policies := append([]*pms.Policy{}, service.Policies...)
+	sort.Slice(policies, func(i, j int) bool { return policies[i].ID < policies[j].ID })
+	ret := []*pms.Policy{}
+	for _, policy := range policies[sort.Search(len(policies), func(i int) bool { return policies[i].ID > after }):] {
+		if !f.MatchPolicy(policy) {
+			continue
+		}
+		ret = append(ret, policy)
+		if len(ret) == limit {
+			return ret, store.ContinueToken(policy.ID), nil
+		}
+	}
+	return ret, "", nil
+}
+
 func (s *Store) GetPolicyCount(serviceName string) (int64, error) {
 
 	s.rwLock.RLock()
@@ -478,7 +621,11 @@ func (s *Store) DeletePolicy(serviceName string, id string) error {
 		if policy.ID == id {
 			// Found
 			service.Policies = append(service.Policies[:index], service.Policies[index+1:]...)
-			return s.writeServiceWithoutLock(service)
+			if err := s.writeServiceWithoutLock(service); err != nil {
+				return err
+			}
+			s.recordHistory([]*pms.Operation{{Op: pms.OpDelete, Kind: pms.KindPolicy, ServiceName: serviceName, ID: id}}, service.Revision)
+			return nil
 		}
 	}
 
@@ -494,10 +641,15 @@ func (s *Store) DeletePolicies(serviceName string) error {
 	if err != nil {
 		return err
 	}
+	var ops []*pms.Operation
+	for _, policy := range service.Policies {
+		ops = append(ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindPolicy, ServiceName: serviceName, ID: policy.ID})
+	}
 	service.Policies = []*pms.Policy{}
 	if err := s.writeServiceWithoutLock(service); err != nil {
 		return err
 	}
+	s.recordHistory(ops, service.Revision)
 	return nil
 }
 
@@ -512,38 +664,97 @@ func (s *Store) CreatePolicy(serviceName string, policy *pms.Policy) (*pms.Polic
 	}
 	dupPolicy := *policy
 	dupPolicy.ID = suid.New().String()
+	dupPolicy.Revision = 0
 
 	service.Policies = append(service.Policies, &dupPolicy)
 	if err := s.writeServiceWithoutLock(service); err != nil {
 		return nil, err
 	}
+	s.recordHistory([]*pms.Operation{{Op: pms.OpCreate, Kind: pms.KindPolicy, ServiceName: serviceName, Policy: &dupPolicy}}, dupPolicy.Revision)
 	return &dupPolicy, nil
 }
 
+func (s *Store) UpdatePolicy(serviceName string, policy *pms.Policy) (*pms.Policy, error) {
+
+	s.rwLock.Lock()
+	defer s.rwLock.Unlock()
+
+	service, err := s.getServiceWithoutLock(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	for index, value := range service.Policies {
+		if value.ID == policy.ID {
+			// Found
+			if err := checkRevision(policy.Revision, value.Revision, "policy", policy.ID); err != nil {
+				return nil, err
+			}
+			dupPolicy := *policy
+			dupPolicy.Revision = 0
+			service.Policies[index] = &dupPolicy
+			if err := s.writeServiceWithoutLock(service); err != nil {
+				return nil, err
+			}
+			s.recordHistory([]*pms.Operation{{Op: pms.OpUpdate, Kind: pms.KindPolicy, ServiceName: serviceName, Policy: &dupPolicy}}, dupPolicy.Revision)
+			return &dupPolicy, nil
+		}
+	}
+
+	return nil, errors.Errorf(errors.EntityNotFound, "unable to find policy %q in service %q", policy.ID, serviceName)
+}
+
 // For role policy manager
-func (s *Store) ListAllRolePolicies(serviceName string, filter string) ([]*pms.RolePolicy, error) {
+func (s *Store) ListAllRolePolicies(serviceName string, filterStr string) ([]*pms.RolePolicy, error) {
 
 	s.rwLock.RLock()
 	defer s.rwLock.RUnlock()
 
-	f := parseFilter(filter)
+	f, err := filter.Parse(pms.KindRolePolicy, filterStr)
+	if err != nil {
+		return nil, err
+	}
 	service, err := s.getServiceWithoutLock(serviceName)
 	if err != nil {
 		return nil, err
 	}
 	ret := []*pms.RolePolicy{}
 	for _, rolePolicy := range service.RolePolicies {
-		isExpected := true
-		if f != nil {
-			isExpected = nameFilter(rolePolicy.Name, f)
-		}
-		if isExpected {
+		if f.MatchRolePolicy(rolePolicy) {
 			ret = append(ret, rolePolicy)
 		}
 	}
 	return ret, nil
 }
 
+// ListRolePolicies lists a page of role policies in the order of their IDs
+func (s *Store) ListRolePolicies(serviceName string, opts *pms.ListOptions) ([]*pms.RolePolicy, string, error) {
+	f, limit, after, err := store.ParseListOptions(pms.KindRolePolicy, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.rwLock.RLock()
+	defer s.rwLock.RUnlock()
+
+	service, err := s.getServiceWithoutLock(serviceName)
+	if err != nil {
+		return nil, "", err
+	}
+	rolePolicies := append([]*pms.RolePolicy{}, service.RolePolicies...)
+	sort.Slice(rolePolicies, func(i, j int) bool { return rolePolicies[i].ID < rolePolicies[j].ID })
+	ret := []*pms.RolePolicy{}
+	for _, rolePolicy := range rolePolicies[sort.Search(len(rolePolicies), func(i int) bool { return rolePolicies[i].ID > after }):] {
+		if !f.MatchRolePolicy(rolePolicy) {
+			continue
+		}
+		ret = append(ret, rolePolicy)
+		if len(ret) == limit {
+			return ret, store.ContinueToken(rolePolicy.ID), nil
+		}
+	}
+	return ret, "", nil
+}
+
 func (s *Store) GetRolePolicyCount(serviceName string) (int64, error) {
 
 	s.rwLock.RLock()
@@ -620,7 +831,11 @@ func (s *Store) DeleteRolePolicy(serviceName string, id string) error {
 		if rolePolicy.ID == id {
 			// Found
 			service.RolePolicies = append(service.RolePolicies[:index], service.RolePolicies[index+1:]...)
-			return s.writeServiceWithoutLock(service)
+			if err := s.writeServiceWithoutLock(service); err != nil {
+				return err
+			}
+			s.recordHistory([]*pms.Operation{{Op: pms.OpDelete, Kind: pms.KindRolePolicy, ServiceName: serviceName, ID: id}}, service.Revision)
+			return nil
 		}
 	}
 	return errors.Errorf(errors.EntityNotFound, "unable to find role policy %q in service %q", id, serviceName)
@@ -635,9 +850,17 @@ func (s *Store) DeleteRolePolicies(serviceName string) error {
 	if err != nil {
 		return err
 	}
+	var ops []*pms.Operation
+	for _, rolePolicy := range service.RolePolicies {
+		ops = append(ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindRolePolicy, ServiceName: serviceName, ID: rolePolicy.ID})
+	}
 	service.RolePolicies = []*pms.RolePolicy{}
 
-	return s.writeServiceWithoutLock(service)
+	if err := s.writeServiceWithoutLock(service); err != nil {
+		return err
+	}
+	s.recordHistory(ops, service.Revision)
+	return nil
 }
 
 func (s *Store) CreateRolePolicy(serviceName string, rolePolicy *pms.RolePolicy) (*pms.RolePolicy, error) {
@@ -651,14 +874,45 @@ func (s *Store) CreateRolePolicy(serviceName string, rolePolicy *pms.RolePolicy)
 	}
 	dupRolePolicy := *rolePolicy
 	dupRolePolicy.ID = suid.New().String()
+	dupRolePolicy.Revision = 0
 
 	service.RolePolicies = append(service.RolePolicies, &dupRolePolicy)
 	if err := s.writeServiceWithoutLock(service); err != nil {
 		return nil, err
 	}
+	s.recordHistory([]*pms.Operation{{Op: pms.OpCreate, Kind: pms.KindRolePolicy, ServiceName: serviceName, RolePolicy: &dupRolePolicy}}, dupRolePolicy.Revision)
 	return &dupRolePolicy, nil
 }
 
+func (s *Store) UpdateRolePolicy(serviceName string, rolePolicy *pms.RolePolicy) (*pms.RolePolicy, error) {
+
+	s.rwLock.Lock()
+	defer s.rwLock.Unlock()
+
+	service, err := s.getServiceWithoutLock(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	for index, value := range service.RolePolicies {
+		if value.ID == rolePolicy.ID {
+			// Found
+			if err := checkRevision(rolePolicy.Revision, value.Revision, "role policy", rolePolicy.ID); err != nil {
+				return nil, err
+			}
+			dupRolePolicy := *rolePolicy
+			dupRolePolicy.Revision = 0
+			service.RolePolicies[index] = &dupRolePolicy
+			if err := s.writeServiceWithoutLock(service); err != nil {
+				return nil, err
+			}
+			s.recordHistory([]*pms.Operation{{Op: pms.OpUpdate, Kind: pms.KindRolePolicy, ServiceName: serviceName, RolePolicy: &dupRolePolicy}}, dupRolePolicy.Revision)
+			return &dupRolePolicy, nil
+		}
+	}
+
+	return nil, errors.Errorf(errors.EntityNotFound, "unable to find role policy %q in service %q", rolePolicy.ID, serviceName)
+}
+
 func validateFunc(function *pms.Function) error {
 	if function.Name == "" || function.FuncURL == "" {
 		return errors.New(errors.InvalidRequest, "\"name\" and \"funcURL\" in function definition can not be empty")
@@ -682,16 +936,47 @@ func (s *Store) CreateFunction(function *pms.Function) (*pms.Function, error) {
 			return nil, errors.Errorf(errors.EntityAlreadyExists, "function %q already exists", function.Name)
 		}
 	}
+	function.Revision = 0
 	ps.Functions = append(ps.Functions, function)
 
 	err = s.writePolicyStoreWithoutLock(ps)
 	if err != nil {
 		return nil, err
 	}
+	s.recordHistory([]*pms.Operation{{Op: pms.OpCreate, Kind: pms.KindFunction, Function: function}}, ps.Revision)
 
 	return function, nil
 }
 
+func (s *Store) UpdateFunction(function *pms.Function) (*pms.Function, error) {
+	if err := validateFunc(function); err != nil {
+		return nil, err
+	}
+	s.rwLock.Lock()
+	defer s.rwLock.Unlock()
+
+	ps, err := s.readPolicyStoreWithoutLock()
+	if err != nil {
+		return nil, err
+	}
+	for index, value := range ps.Functions {
+		if function.Name == value.Name {
+			if err := checkRevision(function.Revision, value.Revision, "function", function.Name); err != nil {
+				return nil, err
+			}
+			dupFunction := *function
+			dupFunction.Revision = 0
+			ps.Functions[index] = &dupFunction
+			if err := s.writePolicyStoreWithoutLock(ps); err != nil {
+				return nil, err
+			}
+			s.recordHistory([]*pms.Operation{{Op: pms.OpUpdate, Kind: pms.KindFunction, Function: &dupFunction}}, ps.Revision)
+			return &dupFunction, nil
+		}
+	}
+	return nil, errors.Errorf(errors.EntityNotFound, "function %q is not found", function.Name)
+}
+
 func (s *Store) DeleteFunction(funcName string) error {
 	s.rwLock.Lock()
 	defer s.rwLock.Unlock()
@@ -704,7 +989,11 @@ func (s *Store) DeleteFunction(funcName string) error {
 	for index, value := range ps.Functions {
 		if funcName == value.Name {
 			ps.Functions = append(ps.Functions[:index], ps.Functions[index+1:]...)
-			return s.writePolicyStoreWithoutLock(ps)
+			if err := s.writePolicyStoreWithoutLock(ps); err != nil {
+				return err
+			}
+			s.recordHistory([]*pms.Operation{{Op: pms.OpDelete, Kind: pms.KindFunction, ID: funcName}}, ps.Revision)
+			return nil
 		}
 	}
 	return errors.Errorf(errors.EntityNotFound, "function %q is not found", funcName)
@@ -718,8 +1007,16 @@ func (s *Store) DeleteFunctions() error {
 	if err != nil {
 		return err
 	}
+	var ops []*pms.Operation
+	for _, function := range ps.Functions {
+		ops = append(ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindFunction, ID: function.Name})
+	}
 	ps.Functions = []*pms.Function{}
-	return s.writePolicyStoreWithoutLock(ps)
+	if err := s.writePolicyStoreWithoutLock(ps); err != nil {
+		return err
+	}
+	s.recordHistory(ops, ps.Revision)
+	return nil
 }
 
 func (s *Store) GetFunction(funcName string) (*pms.Function, error) {
@@ -738,22 +1035,21 @@ func (s *Store) GetFunction(funcName string) (*pms.Function, error) {
 	return nil, errors.Errorf(errors.EntityNotFound, "function %q is not found", funcName)
 }
 
-func (s *Store) ListAllFunctions(filter string) ([]*pms.Function, error) {
+func (s *Store) ListAllFunctions(filterStr string) ([]*pms.Function, error) {
 	s.rwLock.RLock()
 	defer s.rwLock.RUnlock()
 
-	f := parseFilter(filter)
+	f, err := filter.Parse(pms.KindFunction, filterStr)
+	if err != nil {
+		return nil, err
+	}
 	ps, err := s.readPolicyStoreWithoutLock()
 	if err != nil {
 		return nil, err
 	}
 	ret := []*pms.Function{}
 	for _, value := range ps.Functions {
-		isExpected := true
-		if f != nil {
-			isExpected = nameFilter(value.Name, f)
-		}
-		if isExpected {
+		if f.MatchFunction(value) {
 			ret = append(ret, value)
 		}
 	}
@@ -774,66 +1070,3 @@ func (s *Store) GetFunctionCount() (int64, error) {
 		return int64(len(ps.Functions)), nil
 	}
 }
-
-type filter struct {
-	field    string
-	operator string
-	target   string
-}
-
-func (f filter) String() string {
-	return fmt.Sprint(f.field, f.operator, f.target)
-}
-
-func parseFilter(filterStr string) *filter {
-	if len(filterStr) == 0 {
-		return nil
-	}
-	if !strings.HasPrefix(filterStr, "name") {
-		log.Error("unsupported filter string:", filterStr)
-		return nil
-	}
-	values := strings.Split(filterStr, " ")
-	if len(values) >= 2 {
-		f := &filter{
-			field:    values[0],
-			operator: values[1],
-		}
-		if len(values) > 2 {
-			f.target = values[2]
-		}
-		return f
-	} else {
-		log.Error("invalid filter string:", filterStr)
-		return nil
-	}
-
-}
-
-func nameFilter(name string, f *filter) bool { //this filter function return true when the input filter is invalid.
-	if f.field != "name" {
-		log.Error("invalid name filter. filter is:", f)
-		return true
-	}
-	switch f.operator {
-	case "eq":
-		return name == f.target
-	case "co":
-		return strings.Contains(name, f.target)
-	case "sw":
-		return strings.HasPrefix(name, f.target)
-	case "pr":
-		return len(name) > 0
-	case "gt":
-		return name > f.target
-	case "ge":
-		return name >= f.target
-	case "lt":
-		return name < f.target
-	case "le":
-		return name <= f.target
-	default:
-		log.Error("invalid name filter:", f)
-		return true
-	}
-}
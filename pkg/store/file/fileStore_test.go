@@ -24,6 +24,7 @@ func TestMain(m *testing.M) {
 
 func testMain(m *testing.M) int {
 	defer os.Remove("ps.json")
+	defer os.Remove(historyStoreFileName)
 	storeConfig["FileLocation"] = "./ps.json"
 
 	return m.Run()
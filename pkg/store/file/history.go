@@ -0,0 +1,156 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package file
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+)
+
+type historyStore struct {
+	FileLocation string
+	rwLock       sync.RWMutex
+}
+
+type HistoryContent struct {
+	Records []*pms.HistoryRecord `json:"records"`
+}
+
+const (
+	historyStoreFileName = "speedle_history.json"
+)
+
+func (s *historyStore) readHistoryStoreWithoutLock() (*HistoryContent, error) {
+	var hs HistoryContent
+	raw, err := ioutil.ReadFile(s.FileLocation)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &hs, nil
+		}
+		return &hs, errors.Wrapf(err, errors.StoreError, "unable to read file %q", s.FileLocation)
+	}
+	if err := json.Unmarshal(raw, &hs); err != nil {
+		return &hs, errors.Wrapf(err, errors.SerializationError, "unable to parse file %q", s.FileLocation)
+	}
+	return &hs, nil
+}
+
+func (s *historyStore) writeHistoryStoreWithoutLock(hs *HistoryContent) error {
+	hsB, err := json.MarshalIndent(*hs, "", "    ")
+	if err != nil {
+		return errors.Wrap(err, errors.SerializationError, "marshal indent failed")
+	}
+	if err := ioutil.WriteFile(s.FileLocation, hsB, 0644); err != nil {
+		return errors.Wrapf(err, errors.StoreError, "unable to write to file %q", s.FileLocation)
+	}
+	return nil
+}
+
+func (s *historyStore) appendRecords(records []*pms.HistoryRecord) error {
+	s.rwLock.Lock()
+	defer s.rwLock.Unlock()
+	hs, err := s.readHistoryStoreWithoutLock()
+	if err != nil {
+		return err
+	}
+	hs.Records = append(hs.Records, records...)
+	hs.Records = removeExpiredRecords(hs.Records)
+	return s.writeHistoryStoreWithoutLock(hs)
+}
+
+// removeExpiredRecords removes the oldest versions of every entity, so at most store.MaxHistoryVersions versions are kept
+func removeExpiredRecords(records []*pms.HistoryRecord) []*pms.HistoryRecord {
+	type entity struct {
+		kind, serviceName, id string
+	}
+	versions := make(map[entity][]int64)
+	for _, record := range records {
+		key := entity{record.Kind, record.ServiceName, record.ID}
+		if v := versions[key]; len(v) == 0 || v[len(v)-1] != record.Version {
+			versions[key] = append(v, record.Version)
+		}
+	}
+	kept := records[:0]
+	for _, record := range records {
+		if record.Version > store.ExpiredHistoryVersion(versions[entity{record.Kind, record.ServiceName, record.ID}]) {
+			kept = append(kept, record)
+		}
+	}
+	return kept
+}
+
+func (s *historyStore) listRecords(kind, serviceName, id string) ([]*pms.HistoryRecord, error) {
+	s.rwLock.RLock()
+	defer s.rwLock.RUnlock()
+	hs, err := s.readHistoryStoreWithoutLock()
+	if err != nil {
+		return nil, err
+	}
+	var records []*pms.HistoryRecord
+	for _, record := range hs.Records {
+		if record.Kind == kind && record.ServiceName == serviceName && record.ID == id {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// getHistoryStore returns the history kept in the same directory as the policy store file
+func (s *Store) getHistoryStore() *historyStore {
+	s.historyOnce.Do(func() {
+		dir, _ := filepath.Split(s.FileLocation)
+		historyFileLocation := filepath.Join(dir, historyStoreFileName)
+		if dir == "./" {
+			historyFileLocation = dir + historyStoreFileName
+		}
+		log.Infof("history store file location:%s\n", historyFileLocation)
+		s.historyStore = &historyStore{FileLocation: historyFileLocation}
+	})
+	return s.historyStore
+}
+
+// recordHistory keeps the changes made by ops in one write of the policy store, whose revision is the version.
+// The change itself is done already, so failing to record it is only logged.
+func (s *Store) recordHistory(ops []*pms.Operation, version int64) {
+	if err := s.getHistoryStore().appendRecords(store.NewHistoryRecords(ops, version)); err != nil {
+		log.Errorf("failed to record history: %v", err)
+	}
+}
+
+// ListHistory gets all versions of a service, policy, role policy or function, the oldest first.
+func (s *Store) ListHistory(kind, serviceName, id string) ([]*pms.HistoryRecord, error) {
+	if kind == pms.KindService || kind == pms.KindFunction {
+		serviceName = ""
+	}
+	records, err := s.getHistoryStore().listRecords(kind, serviceName, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.Errorf(errors.EntityNotFound, "no history found for %s %q", kind, id)
+	}
+	return records, nil
+}
+
+// GetHistory gets a version of a service, policy, role policy or function.
+func (s *Store) GetHistory(kind, serviceName, id string, version int64) (*pms.HistoryRecord, error) {
+	records, err := s.ListHistory(kind, serviceName, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.Version == version {
+			return record, nil
+		}
+	}
+	return nil, errors.Errorf(errors.EntityNotFound, "version %d of %s %q is not found", version, kind, id)
+}
@@ -5,14 +5,18 @@ package file
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/teramoby/speedle-plus/api/pms"
 	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/eval/condition"
 	"github.com/teramoby/speedle-plus/pkg/pdl"
 	"github.com/teramoby/speedle-plus/pkg/suid"
 )
@@ -67,10 +71,21 @@ type lineCtx struct {
 	section string
 	phs     phase
 	service *pms.Service
+	// functions are the custom functions the conditions could call besides the built-in ones
+	functions []string
 }
 
 var emptyPS pms.PolicyStore
 
+// Keys of service attributes in SPDL, the type of an attribute of requests is declared by "attribute.<name> = <type>",
+// or "attribute.<name> = list of <type>" for a list
+const (
+	spdlCombiningAlgorithm = "combining-algorithm"
+	spdlDefaultEffect      = "default-effect"
+	spdlAttributePrefix    = "attribute."
+	spdlListPrefix         = "list of "
+)
+
 func (s *Store) readSPDLWithoutLock() (*pms.PolicyStore, error) {
 	var ps pms.PolicyStore
 
@@ -84,7 +99,7 @@ func (s *Store) readSPDLWithoutLock() (*pms.PolicyStore, error) {
 		}
 	}()
 
-	lc := lineCtx{}
+	lc := lineCtx{functions: s.Functions}
 	r := bufio.NewReader(f)
 	for {
 		if err := readLine(r, &lc); err != nil {
@@ -180,6 +195,9 @@ func processPolicyPDL(ps *pms.PolicyStore, lc *lineCtx) error {
 	if err != nil {
 		return err
 	}
+	if err := checkSPDLCondition(policy.Condition, lc); err != nil {
+		return err
+	}
 	policy.ID = suid.New().String()
 	lc.service.Policies = append(lc.service.Policies, policy)
 	return nil
@@ -190,13 +208,75 @@ func processRolePolicyPDL(ps *pms.PolicyStore, lc *lineCtx) error {
 	if err != nil {
 		return err
 	}
+	if err := checkSPDLCondition(rolePolicy.Condition, lc); err != nil {
+		return err
+	}
 	rolePolicy.ID = suid.New().String()
 	lc.service.RolePolicies = append(lc.service.RolePolicies, rolePolicy)
 	return nil
 }
 
+// checkSPDLCondition compiles the condition at the end of the line against the attribute schema of the service, the
+// error has the line and the column of the problem
+func checkSPDLCondition(cond string, lc *lineCtx) error {
+	err := condition.Check(cond, lc.functions, lc.service.Attributes)
+	if err == nil {
+		return nil
+	}
+	conditionErr, ok := err.(*condition.Error)
+	if !ok || conditionErr.Column <= 0 {
+		return fmt.Errorf("Invalid condition %q at line %d: %v", cond, lc.no, err)
+	}
+	// the condition is the end of the line without comments and spaces
+	start := len(lc.origin) - len(strings.TrimLeftFunc(lc.origin, unicode.IsSpace)) + len(lc.trimed) - len(cond)
+	column := utf8.RuneCountInString(lc.origin[:start]) + conditionErr.Column
+	return fmt.Errorf("Invalid condition %q at line %d column %d: %s", cond, lc.no, column, conditionErr.Message)
+}
+
+// processServiceAttribute sets an attribute of the service from a "key = value" line before its policies
+func processServiceAttribute(ps *pms.PolicyStore, lc *lineCtx) error {
+	idx := strings.Index(lc.trimed, "=")
+	if idx == -1 {
+		return fmt.Errorf("Wrong service attribute definition at line %d", lc.no)
+	}
+	key := strings.TrimSpace(lc.trimed[:idx])
+	value := strings.TrimSpace(lc.trimed[idx+1:])
+	switch key {
+	case spdlCombiningAlgorithm:
+		for _, algorithm := range pms.CombiningAlgorithms {
+			if value == algorithm {
+				lc.service.CombiningAlgorithm = value
+				return nil
+			}
+		}
+		return fmt.Errorf("Unknown combining algorithm %s at line %d", value, lc.no)
+	case spdlDefaultEffect:
+		if value != pms.Grant && value != pms.Deny {
+			return fmt.Errorf("Unknown default effect %s at line %d", value, lc.no)
+		}
+		lc.service.DefaultEffect = value
+		return nil
+	default:
+		if !strings.HasPrefix(key, spdlAttributePrefix) {
+			return fmt.Errorf("Unknown service attribute %s at line %d", key, lc.no)
+		}
+		attribute := pms.Attribute{Name: strings.TrimSpace(key[len(spdlAttributePrefix):]), Type: value}
+		if strings.HasPrefix(value, spdlListPrefix) {
+			attribute.Type = strings.TrimSpace(value[len(spdlListPrefix):])
+			attribute.List = true
+		}
+		if err := condition.CheckSchema(append(lc.service.Attributes, &attribute)); err != nil {
+			return fmt.Errorf("Wrong attribute declaration at line %d: %v", lc.no, err)
+		}
+		lc.service.Attributes = append(lc.service.Attributes, &attribute)
+		return nil
+	}
+}
+
 func processPolicyDef(ps *pms.PolicyStore, lc *lineCtx) error {
 	switch lc.phs {
+	case phaseService:
+		return processServiceAttribute(ps, lc)
 	case phasePolicy:
 		return processPolicyPDL(ps, lc)
 	case phaseRolepolicy:
@@ -259,3 +339,66 @@ func readLine(r *bufio.Reader, lc *lineCtx) error {
 
 	return nil
 }
+
+// formatSPDL formats the services, policies and role policies in policy store in SPDL, which are read by
+// readSPDLWithoutLock to the same ones. Service types, functions, names, IDs and metadata are not kept.
+func formatSPDL(ps *pms.PolicyStore) ([]byte, error) {
+	var buffer bytes.Buffer
+	for i, service := range ps.Services {
+		if len(service.Name) == 0 || service.Name != strings.TrimSpace(service.Name) || strings.ContainsAny(service.Name, "#\r\n") {
+			return nil, errors.Errorf(errors.SerializationError, "service name %q can not be written in SPDL", service.Name)
+		}
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+		fmt.Fprintf(&buffer, "[service.%s]\n", service.Name)
+		if len(service.CombiningAlgorithm) > 0 {
+			fmt.Fprintf(&buffer, "%s = %s\n", spdlCombiningAlgorithm, service.CombiningAlgorithm)
+		}
+		if len(service.DefaultEffect) > 0 {
+			fmt.Fprintf(&buffer, "%s = %s\n", spdlDefaultEffect, service.DefaultEffect)
+		}
+		for _, attribute := range service.Attributes {
+			if attribute.List {
+				fmt.Fprintf(&buffer, "%s%s = %s%s\n", spdlAttributePrefix, attribute.Name, spdlListPrefix, attribute.Type)
+			} else {
+				fmt.Fprintf(&buffer, "%s%s = %s\n", spdlAttributePrefix, attribute.Name, attribute.Type)
+			}
+		}
+		if len(service.Policies) > 0 {
+			buffer.WriteString("[policy]\n")
+		}
+		for _, policy := range service.Policies {
+			line, err := pdl.FormatPolicy(policy)
+			if err != nil {
+				return nil, errors.Wrapf(err, errors.SerializationError, "policy %q in service %q can not be written in SPDL", policy.ID, service.Name)
+			}
+			if err := writeSPDLLine(&buffer, line); err != nil {
+				return nil, errors.Wrapf(err, errors.SerializationError, "policy %q in service %q can not be written in SPDL", policy.ID, service.Name)
+			}
+		}
+		if len(service.RolePolicies) > 0 {
+			buffer.WriteString("[rolepolicy]\n")
+		}
+		for _, rolePolicy := range service.RolePolicies {
+			line, err := pdl.FormatRolePolicy(rolePolicy)
+			if err != nil {
+				return nil, errors.Wrapf(err, errors.SerializationError, "role policy %q in service %q can not be written in SPDL", rolePolicy.ID, service.Name)
+			}
+			if err := writeSPDLLine(&buffer, line); err != nil {
+				return nil, errors.Wrapf(err, errors.SerializationError, "role policy %q in service %q can not be written in SPDL", rolePolicy.ID, service.Name)
+			}
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+func writeSPDLLine(buffer *bytes.Buffer, line string) error {
+	// everything after # is taken as comments, even if it is quoted
+	if strings.Contains(line, "#") {
+		return fmt.Errorf("%q has the comment character #", line)
+	}
+	buffer.WriteString(line)
+	buffer.WriteString("\n")
+	return nil
+}
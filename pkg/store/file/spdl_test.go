@@ -7,8 +7,14 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/teramoby/speedle-plus/api/pms"
 )
 
 func TestReadLine(t *testing.T) {
@@ -110,3 +116,137 @@ func TestReadSPDLWithoutLock(t *testing.T) {
 		}
 	}
 }
+
+func clearIDsAndRevisions(ps *pms.PolicyStore) {
+	ps.Revision = 0
+	for _, service := range ps.Services {
+		service.Revision = 0
+		for _, policy := range service.Policies {
+			policy.ID, policy.Revision = "", 0
+		}
+		for _, rolePolicy := range service.RolePolicies {
+			rolePolicy.ID, rolePolicy.Revision = "", 0
+		}
+	}
+}
+
+func TestWriteSPDL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spdl")
+	if err != nil {
+		t.Fatal("fail to create temp dir:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ps, err := (&Store{FileLocation: "./spdl_test.spdl"}).readSPDLWithoutLock()
+	if err != nil {
+		t.Fatalf("Can't read PDL file due to error %v", err)
+	}
+	ps.Services = append(ps.Services, &pms.Service{Name: "service3", CombiningAlgorithm: pms.PermitOverrides, DefaultEffect: pms.Grant,
+		Attributes: []*pms.Attribute{{Name: "age", Type: pms.AttributeTypeNumeric}, {Name: "tags", Type: pms.AttributeTypeString, List: true}}})
+	ps.Services[1].CombiningAlgorithm = pms.FirstApplicable
+	ps.Services[1].Policies = append(ps.Services[1].Policies, &pms.Policy{
+		Effect:      "deny",
+		Principals:  [][]string{{"user:Alice Smith", "idd=idcs:group:readers"}},
+		Permissions: []*pms.Permission{{ResourceExpression: "/books/.*", Actions: []string{"read", "if"}}},
+		Priority:    5,
+		Condition:   "age < 18",
+	})
+
+	store := Store{FileLocation: filepath.Join(dir, "export.spdl")}
+	if err := store.WritePolicyStore(ps); err != nil {
+		t.Fatalf("Can't write PDL file due to error %v", err)
+	}
+	got, err := store.ReadPolicyStore()
+	if err != nil {
+		t.Fatalf("Can't read written PDL file due to error %v", err)
+	}
+	clearIDsAndRevisions(ps)
+	clearIDsAndRevisions(got)
+	if !reflect.DeepEqual(ps, got) {
+		t.Fatalf("expected %v, but got %v", ps, got)
+	}
+
+	// the file is kept if the policy store can not be written in SPDL
+	ps.Services[0].Policies[0].Condition = "a == '#'"
+	if err := store.WritePolicyStore(ps); err == nil {
+		t.Fatal("a condition with comment character should not be written in SPDL")
+	}
+	if got, err = store.ReadPolicyStore(); err != nil || len(got.Services) != 3 {
+		t.Fatal("the file should be kept:", got, err)
+	}
+}
+
+func TestReadSPDLServiceAttributes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spdl")
+	if err != nil {
+		t.Fatal("fail to create temp dir:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for content, valid := range map[string]bool{
+		"[service.s1]\ncombining-algorithm = first-applicable\ndefault-effect=grant\n[policy]\ngrant user Alice read books priority 3\n": true,
+		"[service.s1]\ncombining-algorithm = any-applicable\n":                                                                           false,
+		"[service.s1]\ndefault-effect = permit\n":                                                                                        false,
+		"[service.s1]\nowner = Alice\n":                                                                                                  false,
+		"[service.s1]\ngrant user Alice read books\n":                                                                                    false,
+	} {
+		fileName := filepath.Join(dir, "attributes.spdl")
+		if err := ioutil.WriteFile(fileName, []byte(content), 0644); err != nil {
+			t.Fatal("fail to write file:", err)
+		}
+		ps, err := (&Store{FileLocation: fileName}).readSPDLWithoutLock()
+		if !valid {
+			if err == nil {
+				t.Errorf("%q should not be read", content)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("fail to read %q: %v", content, err)
+		}
+		service := ps.Services[0]
+		if service.CombiningAlgorithm != pms.FirstApplicable || service.DefaultEffect != pms.Grant || service.Policies[0].Priority != 3 {
+			t.Errorf("unexpected service %+v read from %q", service, content)
+		}
+	}
+}
+
+func TestReadSPDLConditions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spdl")
+	if err != nil {
+		t.Fatal("fail to create temp dir:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	header := "[service.s1]\nattribute.age = numeric\nattribute.tags = list of string\n[policy]\n"
+	for content, expected := range map[string]string{
+		"grant user Alice read books if age > 18 && 'new' in tags":  "",
+		"grant user Alice read books if IsWeekend(request_time)":    "",
+		"grant user Alice read books if IsWeekday(request_time)":    "line 5 column 32: undefined function IsWeekday",
+		"  grant user Alice read books if age > 18 && (tags == 'a'": "line 5 column 46: unbalanced parenthesis",
+		"grant user Alice read books if age == 'adult'":             "line 5 column 36: == can't be applied to age (numeric) and 'adult' (string)",
+	} {
+		fileName := filepath.Join(dir, "conditions.spdl")
+		if err := ioutil.WriteFile(fileName, []byte(header+content+"\n"), 0644); err != nil {
+			t.Fatal("fail to write file:", err)
+		}
+		_, err := (&Store{FileLocation: fileName, Functions: []string{"IsWeekend"}}).readSPDLWithoutLock()
+		if len(expected) == 0 {
+			if err != nil {
+				t.Errorf("fail to read %q: %v", content, err)
+			}
+		} else if err == nil || !strings.HasSuffix(err.Error(), expected) {
+			t.Errorf("expected error %q when reading %q, but got %v", expected, content, err)
+		}
+	}
+
+	for _, declaration := range []string{"attribute.age = integer", "attribute.age = list of", "attribute. = string"} {
+		fileName := filepath.Join(dir, "attributes.spdl")
+		if err := ioutil.WriteFile(fileName, []byte("[service.s1]\n"+declaration+"\n"), 0644); err != nil {
+			t.Fatal("fail to write file:", err)
+		}
+		if _, err := (&Store{FileLocation: fileName}).readSPDLWithoutLock(); err == nil {
+			t.Errorf("%q should not be read", declaration)
+		}
+	}
+}
@@ -19,6 +19,8 @@ const (
 
 	//following are keys of file store properties
 	FileLocationKey = "FileLocation"
+	// FunctionsKey is the key of the names of the custom functions the conditions in a SPDL file could call
+	FunctionsKey = "Functions"
 
 	FileLocationFlagName = "filestore-loc"
 
@@ -39,7 +41,22 @@ func (fs FileStoreBuilder) NewStore(config map[string]interface{}) (pms.PolicySt
 			return nil, err1
 		}
 	}
-	return &Store{FileLocation: fileLocation}, nil
+	functions, _ := config[FunctionsKey].([]string)
+	return &Store{FileLocation: fileLocation, Functions: functions}, nil
+}
+
+// TenantStoreConfig keeps the policies of a tenant in a file of the same name in the directory of the tenant
+func (fs FileStoreBuilder) TenantStoreConfig(config map[string]interface{}, tenant string) (map[string]interface{}, error) {
+	fileLocation, ok := config[FileLocationKey].(string)
+	if !ok {
+		return nil, errors.New(errors.ConfigError, "configure item FileLocation is not found")
+	}
+	tenantLocation, err := store.TenantPath(fileLocation, tenant)
+	if err != nil {
+		return nil, err
+	}
+	config[FileLocationKey] = tenantLocation
+	return config, nil
 }
 
 func (fs FileStoreBuilder) GetStoreParams() map[string]string {
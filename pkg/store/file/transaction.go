@@ -0,0 +1,269 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package file
+
+import (
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/suid"
+)
+
+// ExecuteTransaction applies all the operations to the policy store in memory, and writes the file once.
+// Nothing is written if any operation fails.
+func (s *Store) ExecuteTransaction(ops []*pms.Operation) ([]*pms.Operation, error) {
+	s.rwLock.Lock()
+	defer s.rwLock.Unlock()
+
+	ps, err := s.readPolicyStoreWithoutLock()
+	if err != nil {
+		return nil, err
+	}
+	results, err := ApplyOperations(ps, ops)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.writePolicyStoreWithoutLock(ps); err != nil {
+		return nil, err
+	}
+	s.recordHistory(results, ps.Revision)
+	return results, nil
+}
+
+// ApplyOperations applies the operations of a transaction to a policy store in memory, and returns the results of
+// the operations. The policy store is partly changed if any operation fails.
+func ApplyOperations(ps *pms.PolicyStore, ops []*pms.Operation) ([]*pms.Operation, error) {
+	results := make([]*pms.Operation, 0, len(ops))
+	for i, op := range ops {
+		result, err := applyOperation(ps, op)
+		if err != nil {
+			return nil, errors.Wrapf(err, errors.Code(err), "operation %d (%s %s) of the transaction failed", i, op.Op, op.Kind)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func findService(ps *pms.PolicyStore, serviceName string) (int, *pms.Service) {
+	for index, service := range ps.Services {
+		if service.Name == serviceName {
+			return index, service
+		}
+	}
+	return -1, nil
+}
+
+func applyOperation(ps *pms.PolicyStore, op *pms.Operation) (*pms.Operation, error) {
+	switch op.Kind {
+	case pms.KindService:
+		return applyServiceOperation(ps, op)
+	case pms.KindPolicy, pms.KindRolePolicy:
+		_, service := findService(ps, op.ServiceName)
+		if service == nil {
+			return nil, errors.Errorf(errors.EntityNotFound, "service %q is not found", op.ServiceName)
+		}
+		// any change in a service increases its revision
+		service.Revision = 0
+		if op.Kind == pms.KindPolicy {
+			return applyPolicyOperation(service, op)
+		}
+		return applyRolePolicyOperation(service, op)
+	case pms.KindFunction:
+		return applyFunctionOperation(ps, op)
+	}
+	return nil, errors.Errorf(errors.InvalidRequest, "unknown kind %q", op.Kind)
+}
+
+func applyServiceOperation(ps *pms.PolicyStore, op *pms.Operation) (*pms.Operation, error) {
+	result := pms.Operation{Op: op.Op, Kind: op.Kind, ChangedBy: op.ChangedBy}
+	switch op.Op {
+	case pms.OpCreate, pms.OpUpdate:
+		if op.Service == nil {
+			return nil, errors.New(errors.InvalidRequest, "service is not specified")
+		}
+		result.ID = op.Service.Name
+		_, existing := findService(ps, op.Service.Name)
+		if op.Op == pms.OpCreate {
+			if existing != nil {
+				return nil, errors.Errorf(errors.EntityAlreadyExists, "service %q already exists", op.Service.Name)
+			}
+			service, _ := generateID(op.Service)
+			ps.Services = append(ps.Services, service)
+			result.Service = service
+			return &result, nil
+		}
+		if existing == nil {
+			return nil, errors.Errorf(errors.EntityNotFound, "service %q is not found", op.Service.Name)
+		}
+		if err := checkRevision(op.Service.Revision, existing.Revision, "service", existing.Name); err != nil {
+			return nil, err
+		}
+		existing.Type = op.Service.Type
+		existing.CombiningAlgorithm = op.Service.CombiningAlgorithm
+		existing.DefaultEffect = op.Service.DefaultEffect
+		existing.Attributes = op.Service.Attributes
+		existing.Metadata = op.Service.Metadata
+		existing.Revision = 0
+		result.Service = existing
+		return &result, nil
+	case pms.OpDelete:
+		result.ID = op.ID
+		index, existing := findService(ps, op.ID)
+		if index < 0 {
+			return nil, errors.Errorf(errors.EntityNotFound, "service %q is not found", op.ID)
+		}
+		if err := checkRevision(op.Revision, existing.Revision, "service", op.ID); err != nil {
+			return nil, err
+		}
+		ps.Services = append(ps.Services[:index], ps.Services[index+1:]...)
+		return &result, nil
+	}
+	return nil, errors.Errorf(errors.InvalidRequest, "unknown operation %q", op.Op)
+}
+
+func applyPolicyOperation(service *pms.Service, op *pms.Operation) (*pms.Operation, error) {
+	result := pms.Operation{Op: op.Op, Kind: op.Kind, ServiceName: service.Name, ChangedBy: op.ChangedBy}
+	if op.Op == pms.OpCreate || op.Op == pms.OpUpdate {
+		if op.Policy == nil {
+			return nil, errors.New(errors.InvalidRequest, "policy is not specified")
+		}
+	}
+	switch op.Op {
+	case pms.OpCreate:
+		dupPolicy := *op.Policy
+		dupPolicy.ID = suid.New().String()
+		dupPolicy.Revision = 0
+		service.Policies = append(service.Policies, &dupPolicy)
+		result.ID = dupPolicy.ID
+		result.Policy = &dupPolicy
+		return &result, nil
+	case pms.OpUpdate, pms.OpDelete:
+		id := op.ID
+		if op.Op == pms.OpUpdate {
+			id = op.Policy.ID
+		}
+		result.ID = id
+		for index, value := range service.Policies {
+			if value.ID != id {
+				continue
+			}
+			if op.Op == pms.OpDelete {
+				if err := checkRevision(op.Revision, value.Revision, "policy", id); err != nil {
+					return nil, err
+				}
+				service.Policies = append(service.Policies[:index], service.Policies[index+1:]...)
+				return &result, nil
+			}
+			if err := checkRevision(op.Policy.Revision, value.Revision, "policy", id); err != nil {
+				return nil, err
+			}
+			dupPolicy := *op.Policy
+			dupPolicy.Revision = 0
+			service.Policies[index] = &dupPolicy
+			result.Policy = &dupPolicy
+			return &result, nil
+		}
+		return nil, errors.Errorf(errors.EntityNotFound, "policy %q is not found in service %q", id, service.Name)
+	}
+	return nil, errors.Errorf(errors.InvalidRequest, "unknown operation %q", op.Op)
+}
+
+func applyRolePolicyOperation(service *pms.Service, op *pms.Operation) (*pms.Operation, error) {
+	result := pms.Operation{Op: op.Op, Kind: op.Kind, ServiceName: service.Name, ChangedBy: op.ChangedBy}
+	if op.Op == pms.OpCreate || op.Op == pms.OpUpdate {
+		if op.RolePolicy == nil {
+			return nil, errors.New(errors.InvalidRequest, "role policy is not specified")
+		}
+	}
+	switch op.Op {
+	case pms.OpCreate:
+		dupRolePolicy := *op.RolePolicy
+		dupRolePolicy.ID = suid.New().String()
+		dupRolePolicy.Revision = 0
+		service.RolePolicies = append(service.RolePolicies, &dupRolePolicy)
+		result.ID = dupRolePolicy.ID
+		result.RolePolicy = &dupRolePolicy
+		return &result, nil
+	case pms.OpUpdate, pms.OpDelete:
+		id := op.ID
+		if op.Op == pms.OpUpdate {
+			id = op.RolePolicy.ID
+		}
+		result.ID = id
+		for index, value := range service.RolePolicies {
+			if value.ID != id {
+				continue
+			}
+			if op.Op == pms.OpDelete {
+				if err := checkRevision(op.Revision, value.Revision, "role policy", id); err != nil {
+					return nil, err
+				}
+				service.RolePolicies = append(service.RolePolicies[:index], service.RolePolicies[index+1:]...)
+				return &result, nil
+			}
+			if err := checkRevision(op.RolePolicy.Revision, value.Revision, "role policy", id); err != nil {
+				return nil, err
+			}
+			dupRolePolicy := *op.RolePolicy
+			dupRolePolicy.Revision = 0
+			service.RolePolicies[index] = &dupRolePolicy
+			result.RolePolicy = &dupRolePolicy
+			return &result, nil
+		}
+		return nil, errors.Errorf(errors.EntityNotFound, "role policy %q is not found in service %q", id, service.Name)
+	}
+	return nil, errors.Errorf(errors.InvalidRequest, "unknown operation %q", op.Op)
+}
+
+func applyFunctionOperation(ps *pms.PolicyStore, op *pms.Operation) (*pms.Operation, error) {
+	result := pms.Operation{Op: op.Op, Kind: op.Kind, ChangedBy: op.ChangedBy}
+	name := op.ID
+	if op.Op == pms.OpCreate || op.Op == pms.OpUpdate {
+		if op.Function == nil {
+			return nil, errors.New(errors.InvalidRequest, "function is not specified")
+		}
+		if err := validateFunc(op.Function); err != nil {
+			return nil, err
+		}
+		name = op.Function.Name
+	}
+	result.ID = name
+	index := -1
+	for i, value := range ps.Functions {
+		if value.Name == name {
+			index = i
+			break
+		}
+	}
+	switch op.Op {
+	case pms.OpCreate:
+		if index >= 0 {
+			return nil, errors.Errorf(errors.EntityAlreadyExists, "function %q already exists", name)
+		}
+		dupFunction := *op.Function
+		dupFunction.Revision = 0
+		ps.Functions = append(ps.Functions, &dupFunction)
+		result.Function = &dupFunction
+		return &result, nil
+	case pms.OpUpdate, pms.OpDelete:
+		if index < 0 {
+			return nil, errors.Errorf(errors.EntityNotFound, "function %q is not found", name)
+		}
+		if op.Op == pms.OpDelete {
+			if err := checkRevision(op.Revision, ps.Functions[index].Revision, "function", name); err != nil {
+				return nil, err
+			}
+			ps.Functions = append(ps.Functions[:index], ps.Functions[index+1:]...)
+			return &result, nil
+		}
+		if err := checkRevision(op.Function.Revision, ps.Functions[index].Revision, "function", name); err != nil {
+			return nil, err
+		}
+		dupFunction := *op.Function
+		dupFunction.Revision = 0
+		ps.Functions[index] = &dupFunction
+		result.Function = &dupFunction
+		return &result, nil
+	}
+	return nil, errors.Errorf(errors.InvalidRequest, "unknown operation %q", op.Op)
+}
@@ -0,0 +1,460 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+// Package filter parses and evaluates the filters of the list APIs, e.g.
+//
+//	name sw books and (effect eq deny or principal eq "user:Alice")
+//
+// A comparison is an attribute, an operator and a value. The operators are eq, ne, co (contains), sw (starts with),
+// ew (ends with), gt, ge, lt, le, and pr (present and not empty), which takes no value. Comparisons are combined with
+// not, and, or and parentheses, in the order of precedence. A value with spaces, parentheses or quotes is quoted by
+// double quotes, in which \" and \\ are escaped. Keywords and operators are case insensitive.
+//
+// The attributes of a policy are id, name, effect, principal, resource, action, condition, metadata.KEY and
+// createtime. A role policy has role instead of action, and a function has name, metadata.KEY and createtime.
+// An attribute with several values, e.g. principal, matches if any of its values matches, while ne matches if
+// none of them is equal. createtime is the creation time kept in metadata, which is compared with a time
+// like 2006-01-02T15:04:05Z or a date like 2006-01-02.
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+)
+
+const (
+	metadataPrefix = "metadata."
+	createTime     = "createtime"
+)
+
+// attributes are the attributes could be filtered on of each kind of entity, besides metadata.KEY
+var attributes = map[string]map[string]bool{
+	pms.KindPolicy: {
+		"id": true, "name": true, "effect": true, "principal": true, "resource": true,
+		"action": true, "condition": true, createTime: true,
+	},
+	pms.KindRolePolicy: {
+		"id": true, "name": true, "effect": true, "principal": true, "role": true,
+		"resource": true, "condition": true, createTime: true,
+	},
+	pms.KindFunction: {
+		"name": true, createTime: true,
+	},
+}
+
+var operators = map[string]bool{
+	"eq": true, "ne": true, "co": true, "sw": true, "ew": true,
+	"gt": true, "ge": true, "lt": true, "le": true, "pr": true,
+}
+
+// Filter is a parsed filter, a nil Filter matches everything
+type Filter struct {
+	root node
+}
+
+// valuesFunc gets the values of an attribute of an entity, key is the metadata key of metadata.KEY. A single valued
+// attribute has a value even if it is empty, which is not present though.
+type valuesFunc func(attr, key string) []string
+
+type node interface {
+	match(values valuesFunc) bool
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n *andNode) match(values valuesFunc) bool {
+	return n.left.match(values) && n.right.match(values)
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n *orNode) match(values valuesFunc) bool {
+	return n.left.match(values) || n.right.match(values)
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) match(values valuesFunc) bool {
+	return !n.operand.match(values)
+}
+
+type comparison struct {
+	attr     string
+	key      string
+	operator string
+	value    string
+	// time is the value of a createtime comparison other than co, sw, ew and pr
+	time *time.Time
+}
+
+func (c *comparison) match(values valuesFunc) bool {
+	vs := values(c.attr, c.key)
+	switch c.operator {
+	case "pr":
+		for _, v := range vs {
+			if len(v) != 0 {
+				return true
+			}
+		}
+		return false
+	case "ne":
+		for _, v := range vs {
+			if result, ok := c.compare(v); ok && result == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	for _, v := range vs {
+		if c.matchValue(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *comparison) matchValue(v string) bool {
+	switch c.operator {
+	case "co":
+		return strings.Contains(v, c.value)
+	case "sw":
+		return strings.HasPrefix(v, c.value)
+	case "ew":
+		return strings.HasSuffix(v, c.value)
+	}
+	result, ok := c.compare(v)
+	if !ok {
+		return false
+	}
+	switch c.operator {
+	case "eq":
+		return result == 0
+	case "gt":
+		return result > 0
+	case "ge":
+		return result >= 0
+	case "lt":
+		return result < 0
+	case "le":
+		return result <= 0
+	}
+	return false
+}
+
+// compare compares a value with the value of the comparison, false is returned if the value is not a valid time
+// in a time comparison
+func (c *comparison) compare(v string) (int, bool) {
+	if c.time == nil {
+		return strings.Compare(v, c.value), true
+	}
+	t, err := parseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	switch {
+	case t.Before(*c.time):
+		return -1, true
+	case t.After(*c.time):
+		return 1, true
+	}
+	return 0, true
+}
+
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+// Parse parses the filter of a kind of entities, i.e. pms.KindPolicy, pms.KindRolePolicy or pms.KindFunction.
+// nil is returned for an empty filter.
+func Parse(kind, expr string) (*Filter, error) {
+	if len(strings.TrimSpace(expr)) == 0 {
+		return nil, nil
+	}
+	attrs, ok := attributes[kind]
+	if !ok {
+		return nil, errors.Errorf(errors.InvalidRequest, "%s could not be filtered", kind)
+	}
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, errors.Wrapf(err, errors.InvalidRequest, "invalid filter %q", expr)
+	}
+	p := parser{tokens: tokens, attrs: attrs}
+	root, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, errors.InvalidRequest, "invalid filter %q", expr)
+	}
+	return &Filter{root: root}, nil
+}
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+// tokenize splits a filter to words, quoted strings and parentheses
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		switch c := expr[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, token{text: string(c)})
+			i++
+		case c == '"':
+			var text strings.Builder
+			for i++; ; i++ {
+				if i >= len(expr) {
+					return nil, fmt.Errorf("unterminated quoted string")
+				}
+				if expr[i] == '"' {
+					i++
+					break
+				}
+				if expr[i] == '\\' && i+1 < len(expr) && (expr[i+1] == '"' || expr[i+1] == '\\') {
+					i++
+				}
+				text.WriteByte(expr[i])
+			}
+			tokens = append(tokens, token{text: text.String(), quoted: true})
+		default:
+			start := i
+			for i < len(expr) && !strings.ContainsRune(" \t\n\r()\"", rune(expr[i])) {
+				i++
+			}
+			tokens = append(tokens, token{text: expr[start:i]})
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	attrs  map[string]bool
+}
+
+// keyword tells whether the next token is an unquoted keyword or parenthesis
+func (p *parser) keyword(word string) bool {
+	return p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && strings.EqualFold(p.tokens[p.pos].text, word)
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	switch {
+	case p.keyword("not"):
+		p.pos++
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	case p.keyword("("):
+		p.pos++
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword(")") {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return n, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("missing attribute")
+	}
+	attrToken := p.tokens[p.pos]
+	if attrToken.quoted || attrToken.text == "(" || attrToken.text == ")" {
+		return nil, fmt.Errorf("unexpected %q", attrToken.text)
+	}
+	c := comparison{attr: strings.ToLower(attrToken.text)}
+	if strings.HasPrefix(c.attr, metadataPrefix) && len(attrToken.text) > len(metadataPrefix) {
+		c.key = attrToken.text[len(metadataPrefix):]
+		c.attr = strings.TrimSuffix(metadataPrefix, ".")
+	} else if !p.attrs[c.attr] {
+		return nil, fmt.Errorf("unknown attribute %q", attrToken.text)
+	}
+	p.pos++
+
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].quoted || !operators[strings.ToLower(p.tokens[p.pos].text)] {
+		return nil, fmt.Errorf("missing operator after %q", attrToken.text)
+	}
+	c.operator = strings.ToLower(p.tokens[p.pos].text)
+	p.pos++
+	if c.operator == "pr" {
+		return &c, nil
+	}
+
+	if p.pos >= len(p.tokens) || p.keyword("(") || p.keyword(")") {
+		return nil, fmt.Errorf("missing value after %q", c.operator)
+	}
+	c.value = p.tokens[p.pos].text
+	p.pos++
+	if c.attr == createTime && c.operator != "co" && c.operator != "sw" && c.operator != "ew" {
+		t, err := parseTime(c.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q", c.value)
+		}
+		c.time = &t
+	}
+	return &c, nil
+}
+
+// MatchPolicy tells whether a policy matches the filter
+func (f *Filter) MatchPolicy(policy *pms.Policy) bool {
+	if f == nil {
+		return true
+	}
+	return f.root.match(func(attr, key string) []string {
+		switch attr {
+		case "id":
+			return []string{policy.ID}
+		case "name":
+			return []string{policy.Name}
+		case "effect":
+			return []string{policy.Effect}
+		case "principal":
+			var principals []string
+			for _, and := range policy.Principals {
+				principals = append(principals, and...)
+			}
+			return nonEmpty(principals...)
+		case "resource":
+			var resources []string
+			for _, permission := range policy.Permissions {
+				if permission != nil {
+					resources = append(resources, permission.Resource, permission.ResourceExpression, permission.ResourceGlob)
+				}
+			}
+			return nonEmpty(resources...)
+		case "action":
+			var actions []string
+			for _, permission := range policy.Permissions {
+				if permission != nil {
+					actions = append(actions, permission.Actions...)
+				}
+			}
+			return nonEmpty(actions...)
+		case "condition":
+			return []string{policy.Condition}
+		}
+		return metadataValues(policy.Metadata, attr, key)
+	})
+}
+
+// MatchRolePolicy tells whether a role policy matches the filter
+func (f *Filter) MatchRolePolicy(rolePolicy *pms.RolePolicy) bool {
+	if f == nil {
+		return true
+	}
+	return f.root.match(func(attr, key string) []string {
+		switch attr {
+		case "id":
+			return []string{rolePolicy.ID}
+		case "name":
+			return []string{rolePolicy.Name}
+		case "effect":
+			return []string{rolePolicy.Effect}
+		case "principal":
+			return nonEmpty(rolePolicy.Principals...)
+		case "role":
+			return nonEmpty(rolePolicy.Roles...)
+		case "resource":
+			resources := append(append([]string{}, rolePolicy.Resources...), rolePolicy.ResourceExpressions...)
+			return nonEmpty(append(resources, rolePolicy.ResourceGlobs...)...)
+		case "condition":
+			return []string{rolePolicy.Condition}
+		}
+		return metadataValues(rolePolicy.Metadata, attr, key)
+	})
+}
+
+// MatchFunction tells whether a function matches the filter
+func (f *Filter) MatchFunction(function *pms.Function) bool {
+	if f == nil {
+		return true
+	}
+	return f.root.match(func(attr, key string) []string {
+		if attr == "name" {
+			return []string{function.Name}
+		}
+		return metadataValues(function.Metadata, attr, key)
+	})
+}
+
+// metadataValues gets the value of metadata.KEY or createtime
+func metadataValues(metadata map[string]string, attr, key string) []string {
+	if attr == createTime {
+		key = createTime
+	}
+	if len(key) == 0 {
+		return nil
+	}
+	value, ok := metadata[key]
+	if !ok {
+		return nil
+	}
+	return []string{value}
+}
+
+func nonEmpty(values ...string) []string {
+	var ret []string
+	for _, v := range values {
+		if len(v) != 0 {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,145 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package filter
+
+import (
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+)
+
+var testPolicy = &pms.Policy{
+	ID:         "id1",
+	Name:       "books policy",
+	Effect:     "grant",
+	Principals: [][]string{{"user:Alice", "group:Admins"}, {"user:Bob"}},
+	Permissions: []*pms.Permission{
+		{Resource: "/books", Actions: []string{"get", "list"}},
+		{ResourceExpression: "/books/.*", Actions: []string{"delete"}},
+	},
+	Condition: "request_time > '2019-01-01'",
+	Metadata:  map[string]string{"createby": "Carol", "createtime": "2019-03-05T10:00:00Z", "empty": ""},
+}
+
+func TestMatchPolicy(t *testing.T) {
+	tests := []struct {
+		filter string
+		match  bool
+	}{
+		{"", true},
+		{"name eq \"books policy\"", true},
+		{"name sw books", true},
+		{"name ew policy", true},
+		{"name co ks", true},
+		{"name pr", true},
+		{"name gt books", true},
+		{"name lt books", false},
+		{"ID EQ id1", true},
+		{"effect eq grant", true},
+		{"effect eq deny", false},
+		{"effect ne deny", true},
+		{"principal eq user:Bob", true},
+		{"principal eq user:Carol", false},
+		{"principal ne user:Bob", false},
+		{"principal sw group:", true},
+		{"resource eq /books", true},
+		{"resource eq /books/.*", true},
+		{"action eq delete", true},
+		{"action eq put", false},
+		{"condition co request_time", true},
+		{"metadata.createby eq Carol", true},
+		{"metadata.createby eq carol", false},
+		{"metadata.empty pr", false},
+		{"metadata.empty eq \"\"", true},
+		{"metadata.updateby pr", false},
+		{"createtime gt 2019-03-01", true},
+		{"createtime lt 2019-03-05T09:00:00-02:00", true},
+		{"createtime ge 2019-03-05T10:00:00Z", true},
+		{"createtime sw 2019-03", true},
+		{"effect eq grant and principal eq user:Alice", true},
+		{"effect eq deny or principal eq user:Alice", true},
+		{"effect eq deny or principal eq user:Carol and name pr", false},
+		{"(effect eq deny or principal eq user:Carol) and name pr", false},
+		{"effect eq grant or principal eq user:Carol and name eq x", true},
+		{"not effect eq deny", true},
+		{"NOT (effect eq grant AND action eq get)", false},
+		{"not not name pr", true},
+	}
+	for _, test := range tests {
+		f, err := Parse(pms.KindPolicy, test.filter)
+		if err != nil {
+			t.Errorf("fail to parse filter %q: %v", test.filter, err)
+			continue
+		}
+		if match := f.MatchPolicy(testPolicy); match != test.match {
+			t.Errorf("filter %q: expected %v, but got %v", test.filter, test.match, match)
+		}
+	}
+}
+
+func TestMatchRolePolicyAndFunction(t *testing.T) {
+	rolePolicy := &pms.RolePolicy{
+		Name:                "rolePolicy1",
+		Effect:              "deny",
+		Roles:               []string{"role1", "role2"},
+		Principals:          []string{"user:Alice"},
+		Resources:           []string{"/books"},
+		ResourceExpressions: []string{"/movies/.*"},
+	}
+	for filter, expected := range map[string]bool{
+		"role eq role2":                            true,
+		"resource eq /movies/.*":                   true,
+		"effect eq deny and principal eq user:Bob": false,
+		"createtime pr":                            false,
+	} {
+		f, err := Parse(pms.KindRolePolicy, filter)
+		if err != nil {
+			t.Errorf("fail to parse filter %q: %v", filter, err)
+			continue
+		}
+		if match := f.MatchRolePolicy(rolePolicy); match != expected {
+			t.Errorf("filter %q: expected %v, but got %v", filter, expected, match)
+		}
+	}
+
+	function := &pms.Function{Name: "func1", Metadata: map[string]string{"createtime": "2019-03-05T10:00:00Z"}}
+	f, err := Parse(pms.KindFunction, "name eq func1 and createtime le 2019-03-05")
+	if err != nil {
+		t.Fatal("fail to parse filter:", err)
+	}
+	if f.MatchFunction(function) {
+		t.Error("the function is created after the date")
+	}
+}
+
+func TestParseInvalidFilters(t *testing.T) {
+	tests := []struct {
+		kind   string
+		filter string
+	}{
+		{pms.KindPolicy, "name"},
+		{pms.KindPolicy, "name eq"},
+		{pms.KindPolicy, "name in beta"},
+		{pms.KindPolicy, "name eq beta gamma"},
+		{pms.KindPolicy, "owner eq alice"},
+		{pms.KindPolicy, "role eq role1"},
+		{pms.KindPolicy, "metadata. pr"},
+		{pms.KindPolicy, "name eq \"beta"},
+		{pms.KindPolicy, "(name eq beta"},
+		{pms.KindPolicy, "name eq beta)"},
+		{pms.KindPolicy, "name eq beta and"},
+		{pms.KindPolicy, "name eq beta or or name pr"},
+		{pms.KindPolicy, "\"name\" eq beta"},
+		{pms.KindPolicy, "createtime gt yesterday"},
+		{pms.KindRolePolicy, "action eq get"},
+		{pms.KindFunction, "effect eq grant"},
+		{pms.KindService, "name eq service1"},
+	}
+	for _, test := range tests {
+		if _, err := Parse(test.kind, test.filter); errors.Code(err) != errors.InvalidRequest {
+			t.Errorf("%s filter %q should be invalid, but got %v", test.kind, test.filter, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package store
+
+import (
+	"time"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+)
+
+const DefaultMaxHistoryVersions = 100
+
+// MaxHistoryVersions is the number of versions kept for each service, policy, role policy or function,
+// the oldest versions are removed when an entity is changed again. All versions are kept if it is not positive.
+var MaxHistoryVersions = DefaultMaxHistoryVersions
+
+// ExpiredHistoryVersion returns the latest version to remove from the versions of an entity, which are distinct and
+// the oldest first, so that at most MaxHistoryVersions versions are kept. 0 is returned if no version is to be removed.
+func ExpiredHistoryVersion(versions []int64) int64 {
+	if MaxHistoryVersions <= 0 || len(versions) <= MaxHistoryVersions {
+		return 0
+	}
+	return versions[len(versions)-MaxHistoryVersions-1]
+}
+
+type HistoryManager interface {
+	//Get all versions of a service, policy, role policy or function, the oldest first. serviceName is ignored for services and functions.
+	ListHistory(kind, serviceName, id string) ([]*pms.HistoryRecord, error)
+	//Get a version of a service, policy, role policy or function.
+	GetHistory(kind, serviceName, id string, version int64) (*pms.HistoryRecord, error)
+}
+
+// NewHistoryRecord returns the history record of a change made by op, which carries the changed entity if it is not a deletion.
+func NewHistoryRecord(op *pms.Operation, version int64) *pms.HistoryRecord {
+	record := pms.HistoryRecord{
+		Kind:        op.Kind,
+		ServiceName: op.ServiceName,
+		ID:          op.ID,
+		Version:     version,
+		Op:          op.Op,
+	}
+	var metadata map[string]string
+	switch {
+	case op.Service != nil:
+		service := *op.Service
+		service.Policies = nil
+		service.RolePolicies = nil
+		record.ID, record.ServiceName = service.Name, ""
+		record.Service, metadata = &service, service.Metadata
+	case op.Policy != nil:
+		record.ID = op.Policy.ID
+		record.Policy, metadata = op.Policy, op.Policy.Metadata
+	case op.RolePolicy != nil:
+		record.ID = op.RolePolicy.ID
+		record.RolePolicy, metadata = op.RolePolicy, op.RolePolicy.Metadata
+	case op.Function != nil:
+		record.ID, record.ServiceName = op.Function.Name, ""
+		record.Function, metadata = op.Function, op.Function.Metadata
+	}
+
+	// who and when are taken from the metadata set by policy management service
+	switch op.Op {
+	case pms.OpCreate:
+		record.ChangedBy, record.ChangedAt = metadata["createby"], metadata["createtime"]
+	case pms.OpUpdate:
+		record.ChangedBy, record.ChangedAt = metadata["updateby"], metadata["updatetime"]
+	case pms.OpDelete:
+		record.ChangedBy = op.ChangedBy
+	}
+	if len(record.ChangedAt) == 0 {
+		record.ChangedAt = time.Unix(time.Now().Unix(), 0).Format(time.RFC3339)
+	}
+	return &record
+}
+
+// NewHistoryRecords returns the history records of the changes made by ops in one write of the store, whose
+// revision after the write is the version of the records. Creating a service also creates its policies and role policies.
+func NewHistoryRecords(ops []*pms.Operation, version int64) []*pms.HistoryRecord {
+	var records []*pms.HistoryRecord
+	for _, op := range ops {
+		records = append(records, NewHistoryRecord(op, version))
+		if op.Op != pms.OpCreate || op.Service == nil {
+			continue
+		}
+		for _, policy := range op.Service.Policies {
+			records = append(records, NewHistoryRecord(&pms.Operation{Op: pms.OpCreate, Kind: pms.KindPolicy, ServiceName: op.Service.Name, Policy: policy}, version))
+		}
+		for _, rolePolicy := range op.Service.RolePolicies {
+			records = append(records, NewHistoryRecord(&pms.Operation{Op: pms.OpCreate, Kind: pms.KindRolePolicy, ServiceName: op.Service.Name, RolePolicy: rolePolicy}, version))
+		}
+	}
+	return records
+}
@@ -0,0 +1,46 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package store
+
+import (
+	"encoding/base64"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store/filter"
+)
+
+// ListBatchSize is the number of entities a store reads at a time to fill a page of a list
+const ListBatchSize = 500
+
+// ContinueToken returns the token of a page whose last entity has key, from which the next page continues.
+// key is the position of the entity in the order of a store, e.g. its ID or sequence.
+func ContinueToken(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// ParseListOptions validates the options to list a kind of entities, and returns the parsed filter, the limit and
+// the key of the entity to continue after, which is empty for the first page
+func ParseListOptions(kind string, opts *pms.ListOptions) (*filter.Filter, int, string, error) {
+	if opts == nil {
+		return nil, 0, "", nil
+	}
+	if opts.Limit < 0 {
+		return nil, 0, "", errors.Errorf(errors.InvalidRequest, "invalid limit %d", opts.Limit)
+	}
+	f, err := filter.Parse(kind, opts.Filter)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	key, err := base64.RawURLEncoding.DecodeString(opts.Continue)
+	if err != nil || (len(opts.Continue) != 0 && len(key) == 0) {
+		return nil, 0, "", InvalidContinueToken(opts.Continue)
+	}
+	return f, opts.Limit, string(key), nil
+}
+
+// InvalidContinueToken returns the error of a continue token which is not issued by the store
+func InvalidContinueToken(token string) error {
+	return errors.Errorf(errors.InvalidRequest, "invalid continue token %q", token)
+}
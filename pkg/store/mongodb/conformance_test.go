@@ -0,0 +1,28 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/store"
+	"github.com/teramoby/speedle-plus/pkg/store/storetest"
+)
+
+func TestConformance(t *testing.T) {
+	if !mongoAvailable {
+		t.Skip("MongoDB not available")
+	}
+	storetest.Run(t, func(t *testing.T) (pms.PolicyStoreManager, func()) {
+		ps, err := store.NewStore(storeConfig.StoreType, storeConfig.StoreProps)
+		if err != nil {
+			t.Fatal("fail to new mongodb store:", err)
+		}
+		// the tests share the database, so it is cleaned before and after each of them
+		clean := func() {
+			ps.DeleteServices()
+			ps.DeleteFunctions()
+		}
+		clean()
+		return ps, clean
+	})
+}
@@ -0,0 +1,204 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+)
+
+const (
+	discoverCollectionName = "discoverRequests"
+	// discoverRequestSize is the average size in bytes reserved for a request in the capped collection,
+	// the collection is capped by store.MaxDiscoverRequestNum in the first place
+	discoverRequestSize = int64(4096)
+	// namespaceExistsCode is the error code of MongoDB when the collection to create exists already
+	namespaceExistsCode = 48
+)
+
+// discoverRequestDocument is a discover request kept in the capped collection. Revision is increased by
+// the discover counter for every request, so requests could be tailed by revision as on etcd.
+type discoverRequestDocument struct {
+	Revision    int64  `bson:"revision"`
+	ServiceName string `bson:"serviceName"`
+	Request     string `bson:"request"`
+}
+
+// discoverCollection returns the capped collection of discover requests, which is created at the first time.
+// The oldest requests are removed by MongoDB once there are store.MaxDiscoverRequestNum requests.
+func (s *Store) discoverCollection(ctx context.Context) (*mongo.Collection, error) {
+	db := s.client.Database(s.Database)
+	s.discoverLock.Lock()
+	defer s.discoverLock.Unlock()
+	if s.discoverReady {
+		return db.Collection(discoverCollectionName), nil
+	}
+
+	names, err := db.ListCollectionNames(ctx, bson.D{{"name", discoverCollectionName}})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.StoreError, "unable to list collections")
+	}
+	if len(names) == 0 {
+		create := bson.D{
+			{"create", discoverCollectionName},
+			{"capped", true},
+			{"max", store.MaxDiscoverRequestNum},
+			{"size", store.MaxDiscoverRequestNum * discoverRequestSize},
+		}
+		err := db.RunCommand(ctx, create).Err()
+		if cmdErr, ok := err.(mongo.CommandError); err != nil && !(ok && cmdErr.Code == namespaceExistsCode) {
+			return nil, errors.Wrap(err, errors.StoreError, "unable to create the collection of discover requests")
+		}
+	}
+	collection := db.Collection(discoverCollectionName)
+	index := mongo.IndexModel{Keys: bson.D{{"serviceName", 1}, {"revision", 1}}}
+	if _, err := collection.Indexes().CreateOne(ctx, index); err != nil {
+		return nil, errors.Wrap(err, errors.StoreError, "unable to create the index of discover requests")
+	}
+	s.discoverReady = true
+	return collection, nil
+}
+
+func discoverFilter(serviceName string) bson.D {
+	if len(serviceName) == 0 {
+		return bson.D{}
+	}
+	return bson.D{{"serviceName", serviceName}}
+}
+
+// findDiscoverRequests gets the requests matching filter, the oldest first, and the revision of the latest one, which
+// is since if no request is found. The revision is taken from the requests rather than the discover counter, since
+// a request may be saved with a lower revision than the counter after it's read, and be skipped by a tailer.
+func (s *Store) findDiscoverRequests(ctx context.Context, filter bson.D, opts *options.FindOptions, since int64) ([]*ads.RequestContext, int64, error) {
+	collection, err := s.discoverCollection(ctx)
+	if err != nil {
+		return nil, -1, err
+	}
+	revision := since
+	cur, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, -1, errors.Wrap(err, errors.StoreError, "unable to get discover requests")
+	}
+	defer cur.Close(ctx)
+	var documents []*discoverRequestDocument
+	if err := cur.All(ctx, &documents); err != nil {
+		return nil, -1, errors.Wrap(err, errors.StoreError, "unable to get discover requests")
+	}
+	requests := []*ads.RequestContext{}
+	for _, document := range documents {
+		var request ads.RequestContext
+		if err := json.Unmarshal([]byte(document.Request), &request); err != nil {
+			return nil, -1, errors.Wrapf(err, errors.SerializationError, "failed to unmarshal request context %q", document.Request)
+		}
+		requests = append(requests, &request)
+		if document.Revision > revision {
+			revision = document.Revision
+		}
+	}
+	return requests, revision, nil
+}
+
+// SaveDiscoverRequest saves a discover request with a new revision
+func (s *Store) SaveDiscoverRequest(request *ads.RequestContext) error {
+	value, err := json.Marshal(request)
+	if err != nil {
+		return errors.Wrap(err, errors.SerializationError, "failed to marshal request")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	collection, err := s.discoverCollection(ctx)
+	if err != nil {
+		return err
+	}
+	// the requests of this store are inserted in the order of their revisions
+	s.discoverSaveLock.Lock()
+	defer s.discoverSaveLock.Unlock()
+	revision, err := s.nextCounter(ctx, "discover")
+	if err != nil {
+		return errors.Wrap(err, errors.StoreError, "unable to get the revision of discover request")
+	}
+	document := discoverRequestDocument{Revision: revision, ServiceName: request.ServiceName, Request: string(value)}
+	if _, err := collection.InsertOne(ctx, &document); err != nil {
+		return errors.Wrap(err, errors.StoreError, "unable to save discover request")
+	}
+	return nil
+}
+
+// GetLastDiscoverRequest gets the latest request of a service, or of all services if serviceName is empty
+func (s *Store) GetLastDiscoverRequest(serviceName string) (*ads.RequestContext, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{"revision", -1}}).SetLimit(1)
+	requests, revision, err := s.findDiscoverRequests(ctx, discoverFilter(serviceName), opts, 0)
+	if err != nil {
+		return nil, -1, err
+	}
+	if len(requests) == 0 {
+		return nil, -1, errors.Errorf(errors.EntityNotFound, "no request found for service %q", serviceName)
+	}
+	return requests[0], revision, nil
+}
+
+// GetDiscoverRequestsSinceRevision gets the requests saved after revision, and the revision of the latest one
+func (s *Store) GetDiscoverRequestsSinceRevision(serviceName string, revision int64) ([]*ads.RequestContext, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	filter := append(discoverFilter(serviceName), bson.E{"revision", bson.D{{"$gt", revision}}})
+	requests, latest, err := s.findDiscoverRequests(ctx, filter, options.Find().SetSort(bson.D{{"revision", 1}}), revision)
+	if err != nil {
+		return nil, revision, errors.Wrapf(err, errors.Code(err), "unable to get discover request for service %q with revision %d", serviceName, revision)
+	}
+	return requests, latest, nil
+}
+
+// GetDiscoverRequests gets all requests of a service, or of all services if serviceName is empty
+func (s *Store) GetDiscoverRequests(serviceName string) ([]*ads.RequestContext, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.findDiscoverRequests(ctx, discoverFilter(serviceName), options.Find().SetSort(bson.D{{"revision", 1}}), 0)
+}
+
+// ResetDiscoverRequests removes the requests of a service, or of all services if serviceName is empty.
+// The collection is dropped to remove all requests, while removing the requests of a service
+// from the capped collection requires MongoDB 5.0 or later.
+func (s *Store) ResetDiscoverRequests(serviceName string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	collection, err := s.discoverCollection(ctx)
+	if err != nil {
+		return err
+	}
+	if len(serviceName) == 0 {
+		s.discoverLock.Lock()
+		defer s.discoverLock.Unlock()
+		s.discoverReady = false
+		err = collection.Drop(ctx)
+	} else {
+		_, err = collection.DeleteMany(ctx, discoverFilter(serviceName))
+	}
+	if err != nil {
+		return errors.Wrapf(err, errors.StoreError, "unable to reset discover requests from service %q", serviceName)
+	}
+	return nil
+}
+
+// GeneratePolicies generates policies for a principal, or all principals if principalXXX are empty, from the requests of a service
+func (s *Store) GeneratePolicies(serviceName, principalType, principalName, principalIDD string) (map[string]*pms.Service, int64, error) {
+	requests, revision, err := s.GetDiscoverRequests(serviceName)
+	if err != nil {
+		return nil, -1, err
+	}
+	serviceMap, err := store.GeneratePoliciesFromDiscoverRequests(requests, principalType, principalName, principalIDD)
+	if err != nil {
+		return nil, -1, err
+	}
+	return serviceMap, revision, nil
+}
@@ -0,0 +1,75 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package mongodb
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/pkg/store"
+)
+
+// TestDiscoverRequests checks the revisions of the requests and the size of the capped collection, the other cases
+// are run by the conformance suite
+func TestDiscoverRequests(t *testing.T) {
+	if !mongoAvailable {
+		t.Skip("MongoDB not available")
+	}
+	s, err := store.NewStore(storeConfig.StoreType, storeConfig.StoreProps)
+	if err != nil {
+		t.Fatal("fail to new mongodb store:", err)
+	}
+	discover := s.(store.DiscoverRequestManager)
+	maxNum := store.MaxDiscoverRequestNum
+	store.MaxDiscoverRequestNum = int64(100)
+	defer func() { store.MaxDiscoverRequestNum = maxNum }()
+	// the capped collection is created again with the max number above
+	if err := discover.ResetDiscoverRequests(""); err != nil {
+		t.Fatal("fail to reset discover requests:", err)
+	}
+	save := func(i int) {
+		user := ads.Principal{Type: ads.PRINCIPAL_TYPE_USER, Name: "user" + strconv.Itoa(i%10)}
+		request := ads.RequestContext{Subject: &ads.Subject{Principals: []*ads.Principal{&user}}, ServiceName: "erp",
+			Resource: "/res" + strconv.Itoa(i), Action: "read", Attributes: map[string]interface{}{}}
+		if err := discover.SaveDiscoverRequest(&request); err != nil {
+			t.Fatal("fail to save request:", err)
+		}
+	}
+
+	save(0)
+	_, revision, err := discover.GetLastDiscoverRequest("erp")
+	if err != nil {
+		t.Fatal("fail to get last request:", err)
+	}
+	for i := 1; i < 6; i++ {
+		save(i)
+	}
+	_, latest, err := discover.GetDiscoverRequestsSinceRevision("erp", revision)
+	if err != nil {
+		t.Fatal("fail to get requests since revision:", err)
+	}
+	if latest != revision+5 {
+		t.Errorf("expected revision %d, but got %d", revision+5, latest)
+	}
+	// the revision is kept if there is no newer request
+	if requests, again, err := discover.GetDiscoverRequestsSinceRevision("erp", latest); err != nil || len(requests) != 0 || again != latest {
+		t.Errorf("expected no request and revision %d, but got %v, %d, %v", latest, requests, again, err)
+	}
+
+	// the capped collection keeps the latest requests only
+	for i := 6; i < 200; i++ {
+		save(i)
+	}
+	requests, _, err := discover.GetDiscoverRequests("")
+	if err != nil {
+		t.Fatal("fail to get requests:", err)
+	}
+	if int64(len(requests)) != store.MaxDiscoverRequestNum || requests[0].Resource != "/res100" {
+		t.Errorf("expected the latest %d requests from /res100, but got %d", store.MaxDiscoverRequestNum, len(requests))
+	}
+	if err := discover.ResetDiscoverRequests(""); err != nil {
+		t.Fatal("fail to reset discover requests:", err)
+	}
+}
@@ -0,0 +1,135 @@
+package mongodb
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+)
+
+// nextHistoryVersion increases the version counter kept in the counters collection, so versions keep
+// increasing even if an entity is deleted and created again
+func (s *Store) nextHistoryVersion(ctx context.Context) (int64, error) {
+	return s.nextCounter(ctx, "history")
+}
+
+// nextCounter increases the named counter kept in the counters collection and returns the new value
+func (s *Store) nextCounter(ctx context.Context, name string) (int64, error) {
+	counterCollection := s.client.Database(s.Database).Collection("counters")
+	filter := bson.D{{"_id", name}}
+	update := bson.D{{"$inc", bson.D{{"value", 1}}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var counter struct {
+		Value int64 `bson:"value"`
+	}
+	if err := counterCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&counter); err != nil {
+		return 0, err
+	}
+	return counter.Value, nil
+}
+
+// recordHistory keeps the changes made by ops, which are done already, so failing to record them is only logged.
+func (s *Store) recordHistory(ops []*pms.Operation) {
+	if len(ops) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	version, err := s.nextHistoryVersion(ctx)
+	if err != nil {
+		log.Errorf("failed to get the version of history: %v", err)
+		return
+	}
+	var documents []interface{}
+	for _, record := range store.NewHistoryRecords(ops, version) {
+		documents = append(documents, record)
+	}
+	historyCollection := s.client.Database(s.Database).Collection("history")
+	if _, err := historyCollection.InsertMany(ctx, documents); err != nil {
+		log.Errorf("failed to record history: %v", err)
+		return
+	}
+	for _, document := range documents {
+		record := document.(*pms.HistoryRecord)
+		if err := s.removeExpiredHistory(ctx, historyFilter(record.Kind, record.ServiceName, record.ID)); err != nil {
+			log.Errorf("failed to remove expired history: %v", err)
+		}
+	}
+}
+
+// removeExpiredHistory removes the oldest versions of an entity, so at most store.MaxHistoryVersions versions are kept
+func (s *Store) removeExpiredHistory(ctx context.Context, filter bson.D) error {
+	historyCollection := s.client.Database(s.Database).Collection("history")
+	values, err := historyCollection.Distinct(ctx, "version", filter)
+	if err != nil {
+		return err
+	}
+	var versions []int64
+	for _, value := range values {
+		if version, ok := value.(int64); ok {
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	expired := store.ExpiredHistoryVersion(versions)
+	if expired == 0 {
+		return nil
+	}
+	_, err = historyCollection.DeleteMany(ctx, append(filter, bson.E{"version", bson.D{{"$lte", expired}}}))
+	return err
+}
+
+func historyFilter(kind, serviceName, id string) bson.D {
+	if kind == pms.KindService || kind == pms.KindFunction {
+		serviceName = ""
+	}
+	filter := bson.D{{"kind", kind}, {"id", id}}
+	if len(serviceName) > 0 {
+		filter = append(filter, bson.E{"serviceName", serviceName})
+	}
+	return filter
+}
+
+// ListHistory gets all versions of a service, policy, role policy or function, the oldest first.
+func (s *Store) ListHistory(kind, serviceName, id string) ([]*pms.HistoryRecord, error) {
+	historyCollection := s.client.Database(s.Database).Collection("history")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cur, err := historyCollection.Find(ctx, historyFilter(kind, serviceName, id), options.Find().SetSort(bson.D{{"version", 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	var records []*pms.HistoryRecord
+	if err := cur.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.Errorf(errors.EntityNotFound, "no history found for %s %q", kind, id)
+	}
+	return records, nil
+}
+
+// GetHistory gets a version of a service, policy, role policy or function.
+func (s *Store) GetHistory(kind, serviceName, id string, version int64) (*pms.HistoryRecord, error) {
+	historyCollection := s.client.Database(s.Database).Collection("history")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	filter := append(historyFilter(kind, serviceName, id), bson.E{"version", version})
+	var record pms.HistoryRecord
+	err := historyCollection.FindOne(ctx, filter).Decode(&record)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.Errorf(errors.EntityNotFound, "version %d of %s %q is not found", version, kind, id)
+	} else if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
@@ -2,7 +2,8 @@ package mongodb
 
 import (
 	"context"
-	"strings"
+	"fmt"
+	"sync"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,12 +13,21 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/teramoby/speedle-plus/api/pms"
 	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+	"github.com/teramoby/speedle-plus/pkg/store/filter"
+	"github.com/teramoby/speedle-plus/pkg/store/utils"
 	"github.com/teramoby/speedle-plus/pkg/suid"
 )
 
 type Store struct {
 	client   *mongo.Client
 	Database string
+
+	// discoverReady is set once the capped collection of discover requests exists
+	discoverLock  sync.Mutex
+	discoverReady bool
+	// discoverSaveLock keeps the discover counter and the insert of a request together
+	discoverSaveLock sync.Mutex
 }
 
 // ReadPolicyStore reads policy store from a file
@@ -171,9 +181,13 @@ func (s *Store) GetServiceCount() (int64, error) {
 
 // GetService gets the detailed info of a service
 func (s *Store) GetService(serviceName string) (*pms.Service, error) {
-	serviceCollection := s.client.Database(s.Database).Collection("services")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	return s.getService(ctx, serviceName)
+}
+
+func (s *Store) getService(ctx context.Context, serviceName string) (*pms.Service, error) {
+	serviceCollection := s.client.Database(s.Database).Collection("services")
 	singleResult := serviceCollection.FindOne(ctx, bson.M{"_id": serviceName})
 	if singleResult.Err() != nil {
 		if singleResult.Err() == mongo.ErrNoDocuments {
@@ -199,20 +213,35 @@ func generateID(service *pms.Service) (*pms.Service, error) {
 	if result.RolePolicies == nil {
 		result.RolePolicies = []*pms.RolePolicy{}
 	}
+	result.Revision = 1
 	for _, policy := range result.Policies {
 		policy.ID = suid.New().String()
+		policy.Revision = 1
 	}
 	for _, rolePolicy := range result.RolePolicies {
 		rolePolicy.ID = suid.New().String()
+		rolePolicy.Revision = 1
 	}
 	return &result, nil
 }
 
+func revisionConflict(kind string, name string, revision int64) error {
+	return errors.Errorf(errors.RevisionConflict, "revision %d of %s %q does not match the current revision", revision, kind, name)
+}
+
 // CreateService creates a new service
 func (s *Store) CreateService(service *pms.Service) error {
-	serviceCollection := s.client.Database(s.Database).Collection("services")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	if err := s.createService(ctx, service); err != nil {
+		return err
+	}
+	s.recordHistory([]*pms.Operation{{Op: pms.OpCreate, Kind: pms.KindService, Service: service}})
+	return nil
+}
+
+func (s *Store) createService(ctx context.Context, service *pms.Service) error {
+	serviceCollection := s.client.Database(s.Database).Collection("services")
 	serviceWithID, _ := generateID(service)
 	insertResult, err := serviceCollection.InsertOne(ctx, serviceWithID)
 	if err != nil {
@@ -222,28 +251,88 @@ func (s *Store) CreateService(service *pms.Service) error {
 	return nil
 }
 
+// UpdateService updates the type and metadata of an existing service
+func (s *Store) UpdateService(service *pms.Service) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.updateService(ctx, service); err != nil {
+		return err
+	}
+	s.recordHistory([]*pms.Operation{{Op: pms.OpUpdate, Kind: pms.KindService, Service: service}})
+	return nil
+}
+
+// updateService updates a service, whose revision is checked only if the expected revision is given
+func (s *Store) updateService(ctx context.Context, service *pms.Service) error {
+	serviceCollection := s.client.Database(s.Database).Collection("services")
+	filter := bson.D{{"_id", service.Name}}
+	if service.Revision > 0 {
+		filter = append(filter, bson.E{"revision", service.Revision})
+	}
+	update := bson.D{{"$set", bson.D{{"type", service.Type}, {"combiningalgorithm", service.CombiningAlgorithm},
+		{"defaulteffect", service.DefaultEffect}, {"attributes", service.Attributes}, {"metadata", service.Metadata}}},
+		{"$inc", bson.D{{"revision", 1}}}}
+	result, err := serviceCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if _, err := s.getService(ctx, service.Name); err != nil {
+			return err
+		}
+		return revisionConflict("service", service.Name, service.Revision)
+	}
+	return nil
+}
+
 // DeleteService deletes a service named ${serviceName} from a file
 func (s *Store) DeleteService(serviceName string) error {
-	serviceCollection := s.client.Database(s.Database).Collection("services")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	deleteResult, err := serviceCollection.DeleteOne(ctx, bson.M{"_id": serviceName})
+	if err := s.deleteService(ctx, serviceName, 0); err != nil {
+		return err
+	}
+	s.recordHistory([]*pms.Operation{{Op: pms.OpDelete, Kind: pms.KindService, ID: serviceName}})
+	return nil
+}
+
+func (s *Store) deleteService(ctx context.Context, serviceName string, revision int64) error {
+	serviceCollection := s.client.Database(s.Database).Collection("services")
+	filter := bson.D{{"_id", serviceName}}
+	if revision > 0 {
+		filter = append(filter, bson.E{"revision", revision})
+	}
+	deleteResult, err := serviceCollection.DeleteOne(ctx, filter)
 	if err != nil {
 		return err
 	}
 	if deleteResult.DeletedCount == 0 {
-		return errors.Errorf(errors.EntityNotFound, "service %q is not found", serviceName)
+		if _, err := s.getService(ctx, serviceName); err != nil || revision <= 0 {
+			return errors.Errorf(errors.EntityNotFound, "service %q is not found", serviceName)
+		}
+		return revisionConflict("service", serviceName, revision)
 	}
 	return nil
 }
 
 // DeleteServices deletes all services from a file
 func (s *Store) DeleteServices() error {
+	serviceNames, err := s.GetServiceNames()
+	if err != nil {
+		return err
+	}
 	serviceCollection := s.client.Database(s.Database).Collection("services")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	err := serviceCollection.Drop(ctx)
-	return err
+	if err := serviceCollection.Drop(ctx); err != nil {
+		return err
+	}
+	var ops []*pms.Operation
+	for _, serviceName := range serviceNames {
+		ops = append(ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindService, ID: serviceName})
+	}
+	s.recordHistory(ops)
+	return nil
 }
 
 func (s *Store) Watch() (pms.StorageChangeChannel, error) {
@@ -264,102 +353,42 @@ func (s *Store) Watch() (pms.StorageChangeChannel, error) {
 			close(storeChangeChan)
 		}()
 
-		for changeStream.Next(context.TODO()) {
-			// A new event variable should be declared for each event.
-			var event bson.M
-			if err := changeStream.Decode(&event); err != nil {
-				log.Error(err)
-				continue
+		// events of a transaction share the same lsid and txnNumber, they are sent together
+		var pending bson.M
+		for {
+			event := pending
+			pending = nil
+			if event == nil {
+				if !changeStream.Next(context.TODO()) {
+					break
+				}
+				// A new event variable should be declared for each event.
+				if err := changeStream.Decode(&event); err != nil {
+					log.Error(err)
+					continue
+				}
 			}
-			log.Info("-----watched event:", event)
-			log.Info("---------- fulldocument:", event["fullDocument"])
-			var ns bson.M
-			ns = event["ns"].(bson.M)
-
-			//ns.coll =="services"
-			if ns["coll"] == "services" {
-				//operationType == update
-				if event["operationType"] == "update" {
-					log.Info("===update service")
-					id := time.Now().Unix()
-					var service pms.Service
-					docb, err := bson.Marshal(event["fullDocument"])
-					if err != nil {
-						log.Error(err)
-						continue
-					}
-					err = bson.Unmarshal(docb, &service)
-					if err != nil {
-						log.Error(err)
-						continue
-					}
-
-					serviceDeleteEvent := pms.StoreChangeEvent{Type: pms.SERVICE_DELETE, ID: id, Content: []string{service.Name}}
-					log.Info("serviceDeleteEvent:", serviceDeleteEvent)
-					storeChangeChan <- serviceDeleteEvent
-					id = time.Now().Unix()
-					serviceAddEvent := pms.StoreChangeEvent{Type: pms.SERVICE_ADD, ID: id, Content: &service}
-					log.Info("serviceAddEvent:", serviceAddEvent)
-					storeChangeChan <- serviceAddEvent
-
-				} else if event["operationType"] == "insert" {
-					log.Info("===insert service")
-					id := time.Now().Unix()
-					var service pms.Service
-					docb, err := bson.Marshal(event["fullDocument"])
-					if err != nil {
-						log.Error(err)
-						continue
-					}
-					err = bson.Unmarshal(docb, &service)
-					if err != nil {
+			var events []pms.StoreChangeEvent
+			if e, ok := toStoreChangeEvent(event); ok {
+				events = append(events, *e)
+			}
+			if txn := transactionOf(event); len(txn) > 0 {
+				for changeStream.TryNext(context.TODO()) {
+					var next bson.M
+					if err := changeStream.Decode(&next); err != nil {
 						log.Error(err)
 						continue
 					}
-					serviceAddEvent := pms.StoreChangeEvent{Type: pms.SERVICE_ADD, ID: id, Content: &service}
-					log.Info("###serviceAddEvent:", serviceAddEvent)
-					storeChangeChan <- serviceAddEvent
-
-				} else if event["operationType"] == "delete" {
-					log.Info("===delete service")
-					id := time.Now().Unix()
-					serviceName := event["documentKey"].(bson.M)["_id"].(string)
-					serviceDeleteEvent := pms.StoreChangeEvent{Type: pms.SERVICE_DELETE, ID: id, Content: []string{serviceName}}
-					log.Info("###serviceDeleteEvent:", serviceDeleteEvent)
-					storeChangeChan <- serviceDeleteEvent
-
-				}
-			} else if ns["coll"] == "functions" {
-
-				if event["operationType"] == "insert" {
-					log.Info("===insert function")
-					id := time.Now().Unix()
-					var f pms.Function
-					docb, err := bson.Marshal(event["fullDocument"])
-					if err != nil {
-						log.Error(err)
-						continue
+					if transactionOf(next) != txn {
+						pending = next
+						break
 					}
-					err = bson.Unmarshal(docb, &f)
-					if err != nil {
-						log.Error(err)
-						continue
+					if e, ok := toStoreChangeEvent(next); ok {
+						events = append(events, *e)
 					}
-					funcAddEvent := pms.StoreChangeEvent{Type: pms.FUNCTION_ADD, ID: id, Content: &f}
-					log.Info("###funcAddEvent:", funcAddEvent)
-					storeChangeChan <- funcAddEvent
-
-				} else if event["operationType"] == "delete" {
-					log.Info("===delete function")
-					id := time.Now().Unix()
-					funcName := event["documentKey"].(bson.M)["_id"].(string)
-					funcDeleteEvent := pms.StoreChangeEvent{Type: pms.FUNCTION_DELETE, ID: id, Content: []string{funcName}}
-					log.Info("###funcDeleteEvent:", funcDeleteEvent)
-					storeChangeChan <- funcDeleteEvent
-
 				}
 			}
-
+			utils.SendChangeEvents(storeChangeChan, events)
 		}
 		log.Info("###exit for loop")
 
@@ -373,6 +402,106 @@ func (s *Store) Watch() (pms.StorageChangeChannel, error) {
 
 }
 
+// toStoreChangeEvent converts an event of the change stream to a store change event
+func toStoreChangeEvent(event bson.M) (*pms.StoreChangeEvent, bool) {
+	log.Info("-----watched event:", event)
+	log.Info("---------- fulldocument:", event["fullDocument"])
+	var ns bson.M
+	ns = event["ns"].(bson.M)
+
+	//ns.coll =="services"
+	if ns["coll"] == "services" {
+		//operationType == update or replace
+		if event["operationType"] == "update" || event["operationType"] == "replace" {
+			log.Info("===update service")
+			id := time.Now().Unix()
+			var service pms.Service
+			docb, err := bson.Marshal(event["fullDocument"])
+			if err != nil {
+				log.Error(err)
+				return nil, false
+			}
+			err = bson.Unmarshal(docb, &service)
+			if err != nil {
+				log.Error(err)
+				return nil, false
+			}
+
+			// SERVICE_ADD replaces the whole service in runtime cache, so a single event is enough
+			serviceAddEvent := pms.StoreChangeEvent{Type: pms.SERVICE_ADD, ID: id, Content: &service}
+			log.Info("serviceAddEvent:", serviceAddEvent)
+			return &serviceAddEvent, true
+
+		} else if event["operationType"] == "insert" {
+			log.Info("===insert service")
+			id := time.Now().Unix()
+			var service pms.Service
+			docb, err := bson.Marshal(event["fullDocument"])
+			if err != nil {
+				log.Error(err)
+				return nil, false
+			}
+			err = bson.Unmarshal(docb, &service)
+			if err != nil {
+				log.Error(err)
+				return nil, false
+			}
+			serviceAddEvent := pms.StoreChangeEvent{Type: pms.SERVICE_ADD, ID: id, Content: &service}
+			log.Info("###serviceAddEvent:", serviceAddEvent)
+			return &serviceAddEvent, true
+
+		} else if event["operationType"] == "delete" {
+			log.Info("===delete service")
+			id := time.Now().Unix()
+			serviceName := event["documentKey"].(bson.M)["_id"].(string)
+			serviceDeleteEvent := pms.StoreChangeEvent{Type: pms.SERVICE_DELETE, ID: id, Content: []string{serviceName}}
+			log.Info("###serviceDeleteEvent:", serviceDeleteEvent)
+			return &serviceDeleteEvent, true
+
+		}
+	} else if ns["coll"] == "functions" {
+
+		if event["operationType"] == "insert" || event["operationType"] == "update" || event["operationType"] == "replace" {
+			log.Info("===insert or update function")
+			id := time.Now().Unix()
+			var f pms.Function
+			docb, err := bson.Marshal(event["fullDocument"])
+			if err != nil {
+				log.Error(err)
+				return nil, false
+			}
+			err = bson.Unmarshal(docb, &f)
+			if err != nil {
+				log.Error(err)
+				return nil, false
+			}
+			funcAddEvent := pms.StoreChangeEvent{Type: pms.FUNCTION_ADD, ID: id, Content: &f}
+			log.Info("###funcAddEvent:", funcAddEvent)
+			return &funcAddEvent, true
+
+		} else if event["operationType"] == "delete" {
+			log.Info("===delete function")
+			id := time.Now().Unix()
+			funcName := event["documentKey"].(bson.M)["_id"].(string)
+			funcDeleteEvent := pms.StoreChangeEvent{Type: pms.FUNCTION_DELETE, ID: id, Content: []string{funcName}}
+			log.Info("###funcDeleteEvent:", funcDeleteEvent)
+			return &funcDeleteEvent, true
+
+		}
+	}
+	return nil, false
+}
+
+// transactionOf returns the transaction which an event of the change stream belongs to,
+// or empty if the event is not caused by a transaction
+func transactionOf(event bson.M) string {
+	txnNumber, ok := event["txnNumber"]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprint(event["lsid"], txnNumber)
+}
+
 func (s *Store) StopWatch() {
 
 }
@@ -381,73 +510,53 @@ func (s *Store) Type() string {
 	return StoreType
 }
 
-func parseFilter(filterStr string) (bson.D, error) {
-	if len(filterStr) == 0 {
-		return bson.D{{"$eq", bson.A{1, 1}}}, nil
-	}
-	values := strings.Split(filterStr, " ")
-	if len(values) == 2 {
-		field := values[0]
-		operator := values[1]
-		switch operator {
-		case "pr":
-			return bson.D{{"$gt", bson.A{"$$p." + field, nil}}}, nil
-		default:
-			log.Error("invalid name filter:", filterStr)
-			return nil, errors.Errorf(errors.InvalidRequest, "invalid filter %q", filterStr)
-		}
-
-	} else if len(values) == 3 {
-		field := values[0]
-		operator := values[1]
-		target := values[2]
-		switch operator {
-		case "eq":
-			return bson.D{{"$eq", bson.A{"$$p." + field, target}}}, nil
-		case "co":
-			return bson.D{{"$gte", bson.A{bson.D{{"$indexOfBytes", bson.A{"$$p." + field, target}}}, 0}}}, nil
-		case "sw":
-			return bson.D{{"$eq", bson.A{0, bson.D{{"$indexOfBytes", bson.A{"$$p." + field, target}}}}}}, nil
-		case "gt":
-			return bson.D{{"$gt", bson.A{"$$p." + field, target}}}, nil
-		case "ge":
-			return bson.D{{"$gte", bson.A{"$$p." + field, target}}}, nil
-		case "lt":
-			return bson.D{{"$lt", bson.A{"$$p." + field, target}}}, nil
-		case "le":
-			return bson.D{{"$lte", bson.A{"$$p." + field, target}}}, nil
-		default:
-			log.Error("invalid name filter:", filterStr)
-			return nil, errors.Errorf(errors.InvalidRequest, "invalid filter %q", filterStr)
-		}
-
-	} else {
-		log.Error("invalid filter string:", filterStr)
-		return nil, errors.Errorf(errors.InvalidRequest, "invalid filter %q", filterStr)
+// checkService returns EntityNotFound if the service does not exist
+func (s *Store) checkService(ctx context.Context, serviceName string) error {
+	serviceCollection := s.client.Database(s.Database).Collection("services")
+	num, err := serviceCollection.CountDocuments(ctx, bson.D{{"_id", serviceName}})
+	if err != nil {
+		return errors.Wrapf(err, errors.StoreError, "failed to get service %q", serviceName)
 	}
+	if num == 0 {
+		return errors.Errorf(errors.EntityNotFound, "service %q is not found", serviceName)
+	}
+	return nil
+}
 
+// nextBatch gets at most store.ListBatchSize entities in the array field of a service whose IDs are after the given one
+// in the order of IDs, and decodes them to v, which is a pointer to a slice
+func (s *Store) nextBatch(ctx context.Context, serviceName, field, after string, v interface{}) error {
+	serviceCollection := s.client.Database(s.Database).Collection("services")
+	pipeline := mongo.Pipeline{
+		{{"$match", bson.D{{"_id", serviceName}}}},
+		{{"$unwind", "$" + field}},
+		{{"$match", bson.D{{field + "._id", bson.D{{"$gt", after}}}}}},
+		{{"$sort", bson.D{{field + "._id", 1}}}},
+		{{"$limit", store.ListBatchSize}},
+		{{"$replaceRoot", bson.D{{"newRoot", "$" + field}}}},
+	}
+	opts := options.Aggregate().SetMaxTime(5 * time.Second)
+	cur, err := serviceCollection.Aggregate(ctx, pipeline, opts)
+	if err != nil {
+		return errors.Wrapf(err, errors.StoreError, "failed to get %s of service %q", field, serviceName)
+	}
+	if err := cur.All(ctx, v); err != nil {
+		return errors.Wrapf(err, errors.StoreError, "failed to get %s of service %q", field, serviceName)
+	}
+	return nil
 }
 
 // For policy manager
-func (s *Store) ListAllPolicies(serviceName string, filter string) ([]*pms.Policy, error) {
+func (s *Store) ListAllPolicies(serviceName string, filterStr string) ([]*pms.Policy, error) {
+	f, err := filter.Parse(pms.KindPolicy, filterStr)
+	if err != nil {
+		return nil, err
+	}
 	serviceCollection := s.client.Database(s.Database).Collection("services")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	matchstag := bson.D{{"$match", bson.D{{"_id", serviceName}}}}
-	condition, err := parseFilter(filter)
-	if err != nil {
-		return nil, err
-	}
-	projectstag := bson.D{
-		{"$project", bson.D{
-			{"policies", bson.D{
-				{"$filter", bson.D{
-					{"input", "$policies"},
-					{"as", "p"},
-					{"cond", condition}},
-				}},
-			}},
-		}}
+	projectstag := bson.D{{"$project", bson.D{{"policies", 1}}}}
 	opts := options.Aggregate().SetMaxTime(2 * time.Second)
 	cur, err := serviceCollection.Aggregate(ctx, mongo.Pipeline{matchstag, projectstag}, opts)
 	if err != nil {
@@ -460,9 +569,46 @@ func (s *Store) ListAllPolicies(serviceName string, filter string) ([]*pms.Polic
 	if services == nil || len(services) == 0 {
 		return nil, errors.Errorf(errors.EntityNotFound, "service %q is not found", serviceName)
 	}
+	ret := []*pms.Policy{}
+	for _, policy := range services[0].Policies {
+		if f.MatchPolicy(policy) {
+			ret = append(ret, policy)
+		}
+	}
+	return ret, nil
+}
 
-	return services[0].Policies, nil
-
+// ListPolicies lists a page of policies in the order of their IDs, which are read in batches by an aggregation
+func (s *Store) ListPolicies(serviceName string, opts *pms.ListOptions) ([]*pms.Policy, string, error) {
+	f, limit, after, err := store.ParseListOptions(pms.KindPolicy, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.checkService(ctx, serviceName); err != nil {
+		return nil, "", err
+	}
+	ret := []*pms.Policy{}
+	for {
+		var batch []*pms.Policy
+		if err := s.nextBatch(ctx, serviceName, "policies", after, &batch); err != nil {
+			return nil, "", err
+		}
+		for _, policy := range batch {
+			if !f.MatchPolicy(policy) {
+				continue
+			}
+			ret = append(ret, policy)
+			if len(ret) == limit {
+				return ret, store.ContinueToken(policy.ID), nil
+			}
+		}
+		if len(batch) < store.ListBatchSize {
+			return ret, "", nil
+		}
+		after = batch[len(batch)-1].ID
+	}
 }
 
 func (s *Store) GetPolicyCount(serviceName string) (int64, error) {
@@ -505,9 +651,13 @@ func (s *Store) GetPolicyCount(serviceName string) (int64, error) {
 }
 
 func (s *Store) GetPolicy(serviceName string, id string) (*pms.Policy, error) {
-	serviceCollection := s.client.Database(s.Database).Collection("services")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	return s.getPolicy(ctx, serviceName, id)
+}
+
+func (s *Store) getPolicy(ctx context.Context, serviceName string, id string) (*pms.Policy, error) {
+	serviceCollection := s.client.Database(s.Database).Collection("services")
 	matchstag := bson.D{{"$match", bson.D{{"_id", serviceName}}}}
 	projectstag := bson.D{
 		{"$project", bson.D{
@@ -539,20 +689,34 @@ func (s *Store) GetPolicy(serviceName string, id string) (*pms.Policy, error) {
 }
 
 func (s *Store) DeletePolicy(serviceName string, id string) error {
-	serviceCollection := s.client.Database(s.Database).Collection("services")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	filter := bson.D{{"_id", serviceName}}
-	update := bson.D{{"$pull", bson.D{{"policies", bson.D{{"_id", id}}}}}}
+	if err := s.deletePolicy(ctx, serviceName, id, 0); err != nil {
+		return err
+	}
+	s.recordHistory([]*pms.Operation{{Op: pms.OpDelete, Kind: pms.KindPolicy, ServiceName: serviceName, ID: id}})
+	return nil
+}
+
+func (s *Store) deletePolicy(ctx context.Context, serviceName string, id string, revision int64) error {
+	serviceCollection := s.client.Database(s.Database).Collection("services")
+	filter := bson.D{{"_id", serviceName}, {"policies._id", id}}
+	if revision > 0 {
+		filter = bson.D{{"_id", serviceName}, {"policies", bson.D{{"$elemMatch", bson.D{{"_id", id}, {"revision", revision}}}}}}
+	}
+	update := bson.D{{"$pull", bson.D{{"policies", bson.D{{"_id", id}}}}}, {"$inc", bson.D{{"revision", 1}}}}
 	result, err := serviceCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return err
 	}
 	if result.MatchedCount == 0 {
-		return errors.Errorf(errors.EntityNotFound, "service %q is not found", serviceName)
-	}
-	if result.ModifiedCount == 0 {
-		return errors.Errorf(errors.EntityNotFound, "policy %q is not found", id)
+		if _, err := s.getPolicy(ctx, serviceName, id); err != nil || revision <= 0 {
+			if _, err := s.getService(ctx, serviceName); err != nil {
+				return err
+			}
+			return errors.Errorf(errors.EntityNotFound, "policy %q is not found", id)
+		}
+		return revisionConflict("policy", id, revision)
 	}
 	return nil
 
@@ -563,24 +727,46 @@ func (s *Store) DeletePolicies(serviceName string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	filter := bson.D{{"_id", serviceName}}
-	update := bson.D{{"$pull", bson.D{{"policies", bson.D{{"$exists", true}}}}}}
+	update := bson.D{{"$pull", bson.D{{"policies", bson.D{{"$exists", true}}}}}, {"$inc", bson.D{{"revision", 1}}}}
 	result := serviceCollection.FindOneAndUpdate(ctx, filter, update)
 	if result.Err() == mongo.ErrNoDocuments {
 		return errors.Errorf(errors.EntityNotFound, "service %q is not found", serviceName)
-	} else {
+	} else if result.Err() != nil {
 		return result.Err()
 	}
+	// the document before the update has the deleted ones
+	var service pms.Service
+	if err := result.Decode(&service); err != nil {
+		log.Errorf("failed to decode service %q: %v", serviceName, err)
+		return nil
+	}
+	var ops []*pms.Operation
+	for _, deleted := range service.Policies {
+		ops = append(ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindPolicy, ServiceName: serviceName, ID: deleted.ID})
+	}
+	s.recordHistory(ops)
+	return nil
 
 }
 
 func (s *Store) CreatePolicy(serviceName string, policy *pms.Policy) (*pms.Policy, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := s.createPolicy(ctx, serviceName, policy)
+	if err != nil {
+		return nil, err
+	}
+	s.recordHistory([]*pms.Operation{{Op: pms.OpCreate, Kind: pms.KindPolicy, ServiceName: serviceName, Policy: result}})
+	return result, nil
+}
+
+func (s *Store) createPolicy(ctx context.Context, serviceName string, policy *pms.Policy) (*pms.Policy, error) {
 	dupPolicy := *policy
 	dupPolicy.ID = suid.New().String()
+	dupPolicy.Revision = 1
 	serviceCollection := s.client.Database(s.Database).Collection("services")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
 	filter := bson.D{{"_id", serviceName}}
-	update := bson.D{{"$push", bson.D{{"policies", dupPolicy}}}}
+	update := bson.D{{"$push", bson.D{{"policies", dupPolicy}}}, {"$inc", bson.D{{"revision", 1}}}}
 	result := serviceCollection.FindOneAndUpdate(ctx, filter, update)
 	if result.Err() == nil {
 		return &dupPolicy, nil
@@ -592,26 +778,69 @@ func (s *Store) CreatePolicy(serviceName string, policy *pms.Policy) (*pms.Polic
 
 }
 
-// For role policy manager
-func (s *Store) ListAllRolePolicies(serviceName string, filter string) ([]*pms.RolePolicy, error) {
-	serviceCollection := s.client.Database(s.Database).Collection("services")
+func (s *Store) UpdatePolicy(serviceName string, policy *pms.Policy) (*pms.Policy, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	matchstag := bson.D{{"$match", bson.D{{"_id", serviceName}}}}
-	condition, err := parseFilter(filter)
+	result, err := s.updatePolicy(ctx, serviceName, policy)
 	if err != nil {
 		return nil, err
 	}
-	projectstag := bson.D{
-		{"$project", bson.D{
-			{"rolepolicies", bson.D{
-				{"$filter", bson.D{
-					{"input", "$rolepolicies"},
-					{"as", "p"},
-					{"cond", condition}},
-				}},
-			}},
-		}}
+	s.recordHistory([]*pms.Operation{{Op: pms.OpUpdate, Kind: pms.KindPolicy, ServiceName: serviceName, Policy: result}})
+	return result, nil
+}
+
+// updatePolicy updates a policy, whose revision is checked only if the expected revision is given. The revisions
+// of the policy and its service are increased by the update itself, so concurrent updates without revisions
+// don't conflict.
+func (s *Store) updatePolicy(ctx context.Context, serviceName string, policy *pms.Policy) (*pms.Policy, error) {
+	serviceCollection := s.client.Database(s.Database).Collection("services")
+	filter := bson.D{{"_id", serviceName}, {"policies", bson.D{{"$elemMatch", elementFilter(policy.ID, policy.Revision)}}}}
+	update := bson.D{{"$set", bson.D{{"policies.$.name", policy.Name}, {"policies.$.effect", policy.Effect},
+		{"policies.$.permissions", policy.Permissions}, {"policies.$.principals", policy.Principals},
+		{"policies.$.condition", policy.Condition}, {"policies.$.priority", policy.Priority}, {"policies.$.metadata", policy.Metadata}}},
+		{"$inc", bson.D{{"revision", 1}, {"policies.$.revision", 1}}}}
+	var service pms.Service
+	if err := serviceCollection.FindOneAndUpdate(ctx, filter, update, updatedElementOptions("policies", policy.ID)).Decode(&service); err != nil {
+		if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+		if _, err := s.getPolicy(ctx, serviceName, policy.ID); err != nil {
+			return nil, err
+		}
+		return nil, revisionConflict("policy", policy.ID, policy.Revision)
+	}
+	if len(service.Policies) != 1 {
+		return nil, errors.Errorf(errors.StoreError, "unable to read the updated policy %q", policy.ID)
+	}
+	return service.Policies[0], nil
+}
+
+// elementFilter matches the policy or role policy with the id, and the revision if it is given
+func elementFilter(id string, revision int64) bson.D {
+	filter := bson.D{{"_id", id}}
+	if revision > 0 {
+		filter = append(filter, bson.E{"revision", revision})
+	}
+	return filter
+}
+
+// updatedElementOptions returns the updated service with the policy or role policy of the id in the array only
+func updatedElementOptions(array, id string) *options.FindOneAndUpdateOptions {
+	return options.FindOneAndUpdate().SetReturnDocument(options.After).
+		SetProjection(bson.D{{array, bson.D{{"$elemMatch", bson.D{{"_id", id}}}}}})
+}
+
+// For role policy manager
+func (s *Store) ListAllRolePolicies(serviceName string, filterStr string) ([]*pms.RolePolicy, error) {
+	f, err := filter.Parse(pms.KindRolePolicy, filterStr)
+	if err != nil {
+		return nil, err
+	}
+	serviceCollection := s.client.Database(s.Database).Collection("services")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	matchstag := bson.D{{"$match", bson.D{{"_id", serviceName}}}}
+	projectstag := bson.D{{"$project", bson.D{{"rolepolicies", 1}}}}
 	opts := options.Aggregate().SetMaxTime(2 * time.Second)
 	cur, err := serviceCollection.Aggregate(ctx, mongo.Pipeline{matchstag, projectstag}, opts)
 	if err != nil {
@@ -624,9 +853,46 @@ func (s *Store) ListAllRolePolicies(serviceName string, filter string) ([]*pms.R
 	if services == nil || len(services) == 0 {
 		return nil, errors.Errorf(errors.EntityNotFound, "service %q is not found", serviceName)
 	}
+	ret := []*pms.RolePolicy{}
+	for _, rolePolicy := range services[0].RolePolicies {
+		if f.MatchRolePolicy(rolePolicy) {
+			ret = append(ret, rolePolicy)
+		}
+	}
+	return ret, nil
+}
 
-	return services[0].RolePolicies, nil
-
+// ListRolePolicies lists a page of role policies in the order of their IDs, which are read in batches by an aggregation
+func (s *Store) ListRolePolicies(serviceName string, opts *pms.ListOptions) ([]*pms.RolePolicy, string, error) {
+	f, limit, after, err := store.ParseListOptions(pms.KindRolePolicy, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.checkService(ctx, serviceName); err != nil {
+		return nil, "", err
+	}
+	ret := []*pms.RolePolicy{}
+	for {
+		var batch []*pms.RolePolicy
+		if err := s.nextBatch(ctx, serviceName, "rolepolicies", after, &batch); err != nil {
+			return nil, "", err
+		}
+		for _, rolePolicy := range batch {
+			if !f.MatchRolePolicy(rolePolicy) {
+				continue
+			}
+			ret = append(ret, rolePolicy)
+			if len(ret) == limit {
+				return ret, store.ContinueToken(rolePolicy.ID), nil
+			}
+		}
+		if len(batch) < store.ListBatchSize {
+			return ret, "", nil
+		}
+		after = batch[len(batch)-1].ID
+	}
 }
 
 func (s *Store) GetRolePolicyCount(serviceName string) (int64, error) {
@@ -669,9 +935,13 @@ func (s *Store) GetRolePolicyCount(serviceName string) (int64, error) {
 }
 
 func (s *Store) GetRolePolicy(serviceName string, id string) (*pms.RolePolicy, error) {
-	serviceCollection := s.client.Database(s.Database).Collection("services")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	return s.getRolePolicy(ctx, serviceName, id)
+}
+
+func (s *Store) getRolePolicy(ctx context.Context, serviceName string, id string) (*pms.RolePolicy, error) {
+	serviceCollection := s.client.Database(s.Database).Collection("services")
 	matchstag := bson.D{{"$match", bson.D{{"_id", serviceName}}}}
 	projectstag := bson.D{
 		{"$project", bson.D{
@@ -703,20 +973,34 @@ func (s *Store) GetRolePolicy(serviceName string, id string) (*pms.RolePolicy, e
 }
 
 func (s *Store) DeleteRolePolicy(serviceName string, id string) error {
-	serviceCollection := s.client.Database(s.Database).Collection("services")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	filter := bson.D{{"_id", serviceName}}
-	update := bson.D{{"$pull", bson.D{{"rolepolicies", bson.D{{"_id", id}}}}}}
+	if err := s.deleteRolePolicy(ctx, serviceName, id, 0); err != nil {
+		return err
+	}
+	s.recordHistory([]*pms.Operation{{Op: pms.OpDelete, Kind: pms.KindRolePolicy, ServiceName: serviceName, ID: id}})
+	return nil
+}
+
+func (s *Store) deleteRolePolicy(ctx context.Context, serviceName string, id string, revision int64) error {
+	serviceCollection := s.client.Database(s.Database).Collection("services")
+	filter := bson.D{{"_id", serviceName}, {"rolepolicies._id", id}}
+	if revision > 0 {
+		filter = bson.D{{"_id", serviceName}, {"rolepolicies", bson.D{{"$elemMatch", bson.D{{"_id", id}, {"revision", revision}}}}}}
+	}
+	update := bson.D{{"$pull", bson.D{{"rolepolicies", bson.D{{"_id", id}}}}}, {"$inc", bson.D{{"revision", 1}}}}
 	result, err := serviceCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return err
 	}
 	if result.MatchedCount == 0 {
-		return errors.Errorf(errors.EntityNotFound, "service %q is not found", serviceName)
-	}
-	if result.ModifiedCount == 0 {
-		return errors.Errorf(errors.EntityNotFound, "rolepolicy %q is not found", id)
+		if _, err := s.getRolePolicy(ctx, serviceName, id); err != nil || revision <= 0 {
+			if _, err := s.getService(ctx, serviceName); err != nil {
+				return err
+			}
+			return errors.Errorf(errors.EntityNotFound, "rolepolicy %q is not found", id)
+		}
+		return revisionConflict("role policy", id, revision)
 	}
 	return nil
 
@@ -727,24 +1011,46 @@ func (s *Store) DeleteRolePolicies(serviceName string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	filter := bson.D{{"_id", serviceName}}
-	update := bson.D{{"$pull", bson.D{{"rolepolicies", bson.D{{"$exists", true}}}}}}
+	update := bson.D{{"$pull", bson.D{{"rolepolicies", bson.D{{"$exists", true}}}}}, {"$inc", bson.D{{"revision", 1}}}}
 	result := serviceCollection.FindOneAndUpdate(ctx, filter, update)
 	if result.Err() == mongo.ErrNoDocuments {
 		return errors.Errorf(errors.EntityNotFound, "service %q is not found", serviceName)
-	} else {
+	} else if result.Err() != nil {
 		return result.Err()
 	}
+	// the document before the update has the deleted ones
+	var service pms.Service
+	if err := result.Decode(&service); err != nil {
+		log.Errorf("failed to decode service %q: %v", serviceName, err)
+		return nil
+	}
+	var ops []*pms.Operation
+	for _, deleted := range service.RolePolicies {
+		ops = append(ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindRolePolicy, ServiceName: serviceName, ID: deleted.ID})
+	}
+	s.recordHistory(ops)
+	return nil
 
 }
 
 func (s *Store) CreateRolePolicy(serviceName string, rolePolicy *pms.RolePolicy) (*pms.RolePolicy, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := s.createRolePolicy(ctx, serviceName, rolePolicy)
+	if err != nil {
+		return nil, err
+	}
+	s.recordHistory([]*pms.Operation{{Op: pms.OpCreate, Kind: pms.KindRolePolicy, ServiceName: serviceName, RolePolicy: result}})
+	return result, nil
+}
+
+func (s *Store) createRolePolicy(ctx context.Context, serviceName string, rolePolicy *pms.RolePolicy) (*pms.RolePolicy, error) {
 	dupPolicy := *rolePolicy
 	dupPolicy.ID = suid.New().String()
+	dupPolicy.Revision = 1
 	serviceCollection := s.client.Database(s.Database).Collection("services")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
 	filter := bson.D{{"_id", serviceName}}
-	update := bson.D{{"$push", bson.D{{"rolepolicies", dupPolicy}}}}
+	update := bson.D{{"$push", bson.D{{"rolepolicies", dupPolicy}}}, {"$inc", bson.D{{"revision", 1}}}}
 	result := serviceCollection.FindOneAndUpdate(ctx, filter, update)
 	if result.Err() == nil {
 		return &dupPolicy, nil
@@ -755,6 +1061,43 @@ func (s *Store) CreateRolePolicy(serviceName string, rolePolicy *pms.RolePolicy)
 	}
 }
 
+func (s *Store) UpdateRolePolicy(serviceName string, rolePolicy *pms.RolePolicy) (*pms.RolePolicy, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := s.updateRolePolicy(ctx, serviceName, rolePolicy)
+	if err != nil {
+		return nil, err
+	}
+	s.recordHistory([]*pms.Operation{{Op: pms.OpUpdate, Kind: pms.KindRolePolicy, ServiceName: serviceName, RolePolicy: result}})
+	return result, nil
+}
+
+// updateRolePolicy updates a role policy like updatePolicy
+func (s *Store) updateRolePolicy(ctx context.Context, serviceName string, rolePolicy *pms.RolePolicy) (*pms.RolePolicy, error) {
+	serviceCollection := s.client.Database(s.Database).Collection("services")
+	filter := bson.D{{"_id", serviceName}, {"rolepolicies", bson.D{{"$elemMatch", elementFilter(rolePolicy.ID, rolePolicy.Revision)}}}}
+	update := bson.D{{"$set", bson.D{{"rolepolicies.$.name", rolePolicy.Name}, {"rolepolicies.$.effect", rolePolicy.Effect},
+		{"rolepolicies.$.roles", rolePolicy.Roles}, {"rolepolicies.$.principals", rolePolicy.Principals},
+		{"rolepolicies.$.resources", rolePolicy.Resources}, {"rolepolicies.$.resourceexpressions", rolePolicy.ResourceExpressions},
+		{"rolepolicies.$.resourceglobs", rolePolicy.ResourceGlobs}, {"rolepolicies.$.condition", rolePolicy.Condition},
+		{"rolepolicies.$.metadata", rolePolicy.Metadata}}},
+		{"$inc", bson.D{{"revision", 1}, {"rolepolicies.$.revision", 1}}}}
+	var service pms.Service
+	if err := serviceCollection.FindOneAndUpdate(ctx, filter, update, updatedElementOptions("rolepolicies", rolePolicy.ID)).Decode(&service); err != nil {
+		if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+		if _, err := s.getRolePolicy(ctx, serviceName, rolePolicy.ID); err != nil {
+			return nil, err
+		}
+		return nil, revisionConflict("role policy", rolePolicy.ID, rolePolicy.Revision)
+	}
+	if len(service.RolePolicies) != 1 {
+		return nil, errors.Errorf(errors.StoreError, "unable to read the updated role policy %q", rolePolicy.ID)
+	}
+	return service.RolePolicies[0], nil
+}
+
 func validateFunc(function *pms.Function) error {
 	if function.Name == "" || function.FuncURL == "" {
 		return errors.New(errors.InvalidRequest, "\"name\" and \"funcURL\" in function definition can not be empty")
@@ -763,51 +1106,134 @@ func validateFunc(function *pms.Function) error {
 }
 
 func (s *Store) CreateFunction(function *pms.Function) (*pms.Function, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := s.createFunction(ctx, function)
+	if err != nil {
+		return nil, err
+	}
+	s.recordHistory([]*pms.Operation{{Op: pms.OpCreate, Kind: pms.KindFunction, Function: result}})
+	return result, nil
+}
+
+func (s *Store) createFunction(ctx context.Context, function *pms.Function) (*pms.Function, error) {
 	if err := validateFunc(function); err != nil {
 		return nil, err
 	}
+	dupFunction := *function
+	dupFunction.Revision = 1
 	serviceCollection := s.client.Database(s.Database).Collection("functions")
+	insertResult, err := serviceCollection.InsertOne(ctx, dupFunction)
+	if err != nil {
+		return nil, err
+	}
+	log.Info(insertResult.InsertedID)
+	return &dupFunction, nil
+
+}
+
+func (s *Store) UpdateFunction(function *pms.Function) (*pms.Function, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	insertResult, err := serviceCollection.InsertOne(ctx, function)
+	result, err := s.updateFunction(ctx, function)
 	if err != nil {
 		return nil, err
 	}
-	log.Info(insertResult.InsertedID)
-	return function, nil
+	s.recordHistory([]*pms.Operation{{Op: pms.OpUpdate, Kind: pms.KindFunction, Function: result}})
+	return result, nil
+}
+
+func (s *Store) updateFunction(ctx context.Context, function *pms.Function) (*pms.Function, error) {
+	if err := validateFunc(function); err != nil {
+		return nil, err
+	}
+	serviceCollection := s.client.Database(s.Database).Collection("functions")
+	filter := bson.D{{"_id", function.Name}}
+	if function.Revision > 0 {
+		filter = append(filter, bson.E{"revision", function.Revision})
+	}
+	update := bson.D{{"$set", bson.D{{"description", function.Description}, {"funcurl", function.FuncURL},
+		{"localfuncurl", function.LocalFuncURL}, {"ca", function.CA}, {"resultcachable", function.ResultCachable},
+		{"resultttl", function.ResultTTL}, {"metadata", function.Metadata}}},
+		{"$inc", bson.D{{"revision", 1}}}}
+	var updated pms.Function
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if err := serviceCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated); err != nil {
+		if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+		if _, err := s.getFunction(ctx, function.Name); err != nil {
+			return nil, err
+		}
+		return nil, revisionConflict("function", function.Name, function.Revision)
+	}
+	return &updated, nil
 
 }
 
 func (s *Store) DeleteFunction(funcName string) error {
-	serviceCollection := s.client.Database(s.Database).Collection("functions")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	deleteResult, err := serviceCollection.DeleteOne(ctx, bson.M{"_id": funcName})
+	if err := s.deleteFunction(ctx, funcName, 0); err != nil {
+		return err
+	}
+	s.recordHistory([]*pms.Operation{{Op: pms.OpDelete, Kind: pms.KindFunction, ID: funcName}})
+	return nil
+}
+
+func (s *Store) deleteFunction(ctx context.Context, funcName string, revision int64) error {
+	serviceCollection := s.client.Database(s.Database).Collection("functions")
+	filter := bson.D{{"_id", funcName}}
+	if revision > 0 {
+		filter = append(filter, bson.E{"revision", revision})
+	}
+	deleteResult, err := serviceCollection.DeleteOne(ctx, filter)
 	if err != nil {
 		return err
 	}
 	if deleteResult.DeletedCount == 0 {
-		return errors.Errorf(errors.EntityNotFound, "function %q is not found", funcName)
+		if _, err := s.getFunction(ctx, funcName); err != nil || revision <= 0 {
+			return errors.Errorf(errors.EntityNotFound, "function %q is not found", funcName)
+		}
+		return revisionConflict("function", funcName, revision)
 	}
 	return nil
 
 }
 
 func (s *Store) DeleteFunctions() error {
+	functions, err := s.ListAllFunctions("")
+	if err != nil {
+		return err
+	}
 	serviceCollection := s.client.Database(s.Database).Collection("functions")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	err := serviceCollection.Drop(ctx)
-	return err
+	if err := serviceCollection.Drop(ctx); err != nil {
+		return err
+	}
+	var ops []*pms.Operation
+	for _, function := range functions {
+		ops = append(ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindFunction, ID: function.Name})
+	}
+	s.recordHistory(ops)
+	return nil
 
 }
 
 func (s *Store) GetFunction(funcName string) (*pms.Function, error) {
-	serviceCollection := s.client.Database(s.Database).Collection("functions")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	return s.getFunction(ctx, funcName)
+}
+
+func (s *Store) getFunction(ctx context.Context, funcName string) (*pms.Function, error) {
+	serviceCollection := s.client.Database(s.Database).Collection("functions")
 	singleResult := serviceCollection.FindOne(ctx, bson.M{"_id": funcName})
 	if singleResult.Err() != nil {
+		if singleResult.Err() == mongo.ErrNoDocuments {
+			return nil, errors.Errorf(errors.EntityNotFound, "function %q is not found", funcName)
+		}
 		return nil, singleResult.Err()
 	}
 	var f *pms.Function
@@ -817,7 +1243,11 @@ func (s *Store) GetFunction(funcName string) (*pms.Function, error) {
 
 }
 
-func (s *Store) ListAllFunctions(filter string) ([]*pms.Function, error) {
+func (s *Store) ListAllFunctions(filterStr string) ([]*pms.Function, error) {
+	f, err := filter.Parse(pms.KindFunction, filterStr)
+	if err != nil {
+		return nil, err
+	}
 	serviceCollection := s.client.Database(s.Database).Collection("functions")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -828,12 +1258,14 @@ func (s *Store) ListAllFunctions(filter string) ([]*pms.Function, error) {
 	defer cur.Close(ctx)
 	functions := []*pms.Function{}
 	for cur.Next(ctx) {
-		var f pms.Function
-		err := cur.Decode(&f)
+		var function pms.Function
+		err := cur.Decode(&function)
 		if err != nil {
 			return nil, err
 		}
-		functions = append(functions, &f)
+		if f.MatchFunction(&function) {
+			functions = append(functions, &function)
+		}
 	}
 	return functions, nil
 
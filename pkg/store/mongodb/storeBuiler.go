@@ -67,6 +67,17 @@ func (msb MongoStoreBuilder) NewStore(config map[string]interface{}) (pms.Policy
 
 }
 
+// TenantStoreConfig keeps the policies of a tenant in the database named after the one of the default tenant and
+// the tenant, e.g. speedleplus_acme
+func (msb MongoStoreBuilder) TenantStoreConfig(config map[string]interface{}, tenant string) (map[string]interface{}, error) {
+	mongoDatabase, ok := config[MongoDatabaseNameKey].(string)
+	if !ok {
+		mongoDatabase = DefaultDatabaseName
+	}
+	config[MongoDatabaseNameKey] = mongoDatabase + "_" + tenant
+	return config, nil
+}
+
 func (msb MongoStoreBuilder) GetStoreParams() map[string]string {
 	return map[string]string{
 		MongoURIFlag:          MongoURIKey,
@@ -0,0 +1,117 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+)
+
+// ExecuteTransaction applies all the operations in a session transaction,
+// which requires mongodb running as a replica set
+func (s *Store) ExecuteTransaction(ops []*pms.Operation) ([]*pms.Operation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var results []*pms.Operation
+	err := s.client.UseSession(ctx, func(sc mongo.SessionContext) error {
+		if err := sc.StartTransaction(); err != nil {
+			return errors.Wrap(err, errors.StoreError, "failed to start transaction")
+		}
+		results = make([]*pms.Operation, 0, len(ops))
+		for i, op := range ops {
+			result, err := s.applyOperation(sc, op)
+			if err != nil {
+				sc.AbortTransaction(context.Background())
+				return errors.Wrapf(err, errors.Code(err), "operation %d (%s %s) of the transaction failed", i, op.Op, op.Kind)
+			}
+			results = append(results, result)
+		}
+		if err := sc.CommitTransaction(sc); err != nil {
+			return errors.Wrap(err, errors.StoreError, "failed to commit transaction")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.recordHistory(results)
+	return results, nil
+}
+
+func (s *Store) applyOperation(ctx context.Context, op *pms.Operation) (*pms.Operation, error) {
+	if op.Op != pms.OpCreate && op.Op != pms.OpUpdate && op.Op != pms.OpDelete {
+		return nil, errors.Errorf(errors.InvalidRequest, "unknown operation %q", op.Op)
+	}
+	result := pms.Operation{Op: op.Op, Kind: op.Kind, ServiceName: op.ServiceName, ID: op.ID, ChangedBy: op.ChangedBy}
+	var err error
+	switch op.Kind {
+	case pms.KindService:
+		if op.Op == pms.OpDelete {
+			return &result, s.deleteService(ctx, op.ID, op.Revision)
+		}
+		if op.Service == nil {
+			return nil, errors.New(errors.InvalidRequest, "service is not specified")
+		}
+		result.ID = op.Service.Name
+		if op.Op == pms.OpCreate {
+			err = s.createService(ctx, op.Service)
+		} else {
+			err = s.updateService(ctx, op.Service)
+		}
+		if err == nil {
+			result.Service, err = s.getService(ctx, op.Service.Name)
+		}
+	case pms.KindPolicy:
+		if op.Op == pms.OpDelete {
+			return &result, s.deletePolicy(ctx, op.ServiceName, op.ID, op.Revision)
+		}
+		if op.Policy == nil {
+			return nil, errors.New(errors.InvalidRequest, "policy is not specified")
+		}
+		if op.Op == pms.OpCreate {
+			result.Policy, err = s.createPolicy(ctx, op.ServiceName, op.Policy)
+		} else {
+			result.Policy, err = s.updatePolicy(ctx, op.ServiceName, op.Policy)
+		}
+		if err == nil {
+			result.ID = result.Policy.ID
+		}
+	case pms.KindRolePolicy:
+		if op.Op == pms.OpDelete {
+			return &result, s.deleteRolePolicy(ctx, op.ServiceName, op.ID, op.Revision)
+		}
+		if op.RolePolicy == nil {
+			return nil, errors.New(errors.InvalidRequest, "role policy is not specified")
+		}
+		if op.Op == pms.OpCreate {
+			result.RolePolicy, err = s.createRolePolicy(ctx, op.ServiceName, op.RolePolicy)
+		} else {
+			result.RolePolicy, err = s.updateRolePolicy(ctx, op.ServiceName, op.RolePolicy)
+		}
+		if err == nil {
+			result.ID = result.RolePolicy.ID
+		}
+	case pms.KindFunction:
+		if op.Op == pms.OpDelete {
+			return &result, s.deleteFunction(ctx, op.ID, op.Revision)
+		}
+		if op.Function == nil {
+			return nil, errors.New(errors.InvalidRequest, "function is not specified")
+		}
+		result.ID = op.Function.Name
+		if op.Op == pms.OpCreate {
+			result.Function, err = s.createFunction(ctx, op.Function)
+		} else {
+			result.Function, err = s.updateFunction(ctx, op.Function)
+		}
+	default:
+		return nil, errors.Errorf(errors.InvalidRequest, "unknown kind %q", op.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
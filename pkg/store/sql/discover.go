@@ -0,0 +1,131 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+)
+
+// findDiscoverRequests gets the requests of a service saved after revision in the order of revision, and the
+// revision of the latest request saved. limit is appended to the query.
+func (s *Store) findDiscoverRequests(serviceName string, revision int64, order string, limit string) ([]*ads.RequestContext, int64, error) {
+	requests := []*ads.RequestContext{}
+	var latest int64
+	err := s.read(func(ctx context.Context, q querier) error {
+		var err error
+		if latest, err = s.queryInt64(ctx, q, "SELECT counter_value FROM counters WHERE name = ?", counterDiscover); err != nil {
+			return err
+		}
+		cond, args := where(serviceName, "", "")
+		if len(cond) == 0 {
+			cond = " WHERE revision > ?"
+		} else {
+			cond += " AND revision > ?"
+		}
+		return s.query(ctx, q, func(rows *sql.Rows) error {
+			var value string
+			if err := rows.Scan(&value); err != nil {
+				return err
+			}
+			var request ads.RequestContext
+			if err := json.Unmarshal([]byte(value), &request); err != nil {
+				return errors.Wrapf(err, errors.SerializationError, "failed to unmarshal request context %q", value)
+			}
+			requests = append(requests, &request)
+			return nil
+		}, "SELECT request FROM discover_requests"+cond+" ORDER BY revision "+order+limit, append(args, revision)...)
+	})
+	if err != nil {
+		return nil, -1, err
+	}
+	return requests, latest, nil
+}
+
+// SaveDiscoverRequest saves a discover request with a new revision, and removes the oldest requests
+// once there are more than store.MaxDiscoverRequestNum requests
+func (s *Store) SaveDiscoverRequest(request *ads.RequestContext) error {
+	value, err := json.Marshal(request)
+	if err != nil {
+		return errors.Wrap(err, errors.SerializationError, "failed to marshal request")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, errors.StoreError, "failed to start transaction")
+	}
+	defer tx.Rollback()
+	revision, err := s.nextCounter(ctx, tx, counterDiscover)
+	if err != nil {
+		return err
+	}
+	if _, err := s.exec(ctx, tx, "INSERT INTO discover_requests (revision, service_name, request) VALUES (?, ?, ?)",
+		revision, request.ServiceName, string(value)); err != nil {
+		return err
+	}
+	if _, err := s.exec(ctx, tx, "DELETE FROM discover_requests WHERE revision <= ?", revision-store.MaxDiscoverRequestNum); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, errors.StoreError, "unable to save discover request")
+	}
+	return nil
+}
+
+// GetLastDiscoverRequest gets the latest request of a service, or of all services if serviceName is empty
+func (s *Store) GetLastDiscoverRequest(serviceName string) (*ads.RequestContext, int64, error) {
+	requests, revision, err := s.findDiscoverRequests(serviceName, 0, "DESC", " LIMIT 1")
+	if err != nil {
+		return nil, -1, err
+	}
+	if len(requests) == 0 {
+		return nil, -1, errors.Errorf(errors.EntityNotFound, "no request found for service %q", serviceName)
+	}
+	return requests[0], revision, nil
+}
+
+// GetDiscoverRequestsSinceRevision gets the requests saved after revision, and the latest revision
+func (s *Store) GetDiscoverRequestsSinceRevision(serviceName string, revision int64) ([]*ads.RequestContext, int64, error) {
+	requests, latest, err := s.findDiscoverRequests(serviceName, revision, "ASC", "")
+	if err != nil {
+		return nil, revision, errors.Wrapf(err, errors.Code(err), "unable to get discover request for service %q with revision %d", serviceName, revision)
+	}
+	return requests, latest, nil
+}
+
+// GetDiscoverRequests gets all requests of a service, or of all services if serviceName is empty
+func (s *Store) GetDiscoverRequests(serviceName string) ([]*ads.RequestContext, int64, error) {
+	return s.findDiscoverRequests(serviceName, 0, "ASC", "")
+}
+
+// ResetDiscoverRequests removes the requests of a service, or of all services if serviceName is empty
+func (s *Store) ResetDiscoverRequests(serviceName string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+	cond, args := where(serviceName, "", "")
+	if _, err := s.exec(ctx, s.db, "DELETE FROM discover_requests"+cond, args...); err != nil {
+		return errors.Wrapf(err, errors.StoreError, "unable to reset discover requests from service %q", serviceName)
+	}
+	return nil
+}
+
+// GeneratePolicies generates policies for a principal, or all principals if principalXXX are empty, from the requests of a service
+func (s *Store) GeneratePolicies(serviceName, principalType, principalName, principalIDD string) (map[string]*pms.Service, int64, error) {
+	requests, revision, err := s.GetDiscoverRequests(serviceName)
+	if err != nil {
+		return nil, -1, err
+	}
+	serviceMap, err := store.GeneratePoliciesFromDiscoverRequests(requests, principalType, principalName, principalIDD)
+	if err != nil {
+		return nil, -1, err
+	}
+	return serviceMap, revision, nil
+}
@@ -0,0 +1,39 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package sql
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/pkg/store"
+)
+
+// TestDiscoverRequestLimit checks the oldest rows are deleted once there are more than store.MaxDiscoverRequestNum
+// requests, the other cases are run by the conformance suite
+func TestDiscoverRequestLimit(t *testing.T) {
+	ps, cleanup := newTestStore(t)
+	defer cleanup()
+	discover := ps.(store.DiscoverRequestManager)
+	maxNum := store.MaxDiscoverRequestNum
+	store.MaxDiscoverRequestNum = int64(100)
+	defer func() { store.MaxDiscoverRequestNum = maxNum }()
+
+	for i := 0; i < 200; i++ {
+		user := ads.Principal{Type: ads.PRINCIPAL_TYPE_USER, Name: "user" + strconv.Itoa(i%10)}
+		request := ads.RequestContext{Subject: &ads.Subject{Principals: []*ads.Principal{&user}}, ServiceName: "erp",
+			Resource: "/res" + strconv.Itoa(i), Action: "read", Attributes: map[string]interface{}{}}
+		if err := discover.SaveDiscoverRequest(&request); err != nil {
+			t.Fatal("fail to save request:", err)
+		}
+	}
+	requests, _, err := discover.GetDiscoverRequests("")
+	if err != nil {
+		t.Fatal("fail to get requests:", err)
+	}
+	if int64(len(requests)) != store.MaxDiscoverRequestNum || requests[0].Resource != "/res100" {
+		t.Errorf("expected the latest %d requests from /res100, but got %d", store.MaxDiscoverRequestNum, len(requests))
+	}
+}
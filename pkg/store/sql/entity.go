@@ -0,0 +1,601 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+)
+
+// Kinds of the items of a role policy in role_policy_items
+const (
+	itemRole               = "role"
+	itemPrincipal          = "principal"
+	itemResource           = "resource"
+	itemResourceExpression = "resourceExpression"
+	itemResourceGlob       = "resourceGlob"
+)
+
+// querier is either the database or a transaction
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (s *Store) exec(ctx context.Context, q querier, query string, args ...interface{}) (sql.Result, error) {
+	result, err := q.ExecContext(ctx, s.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.StoreError, "failed to update sql store")
+	}
+	return result, nil
+}
+
+// query runs a query and calls scan for every row, the rows are closed before it returns,
+// so the next query could run on the same connection
+func (s *Store) query(ctx context.Context, q querier, scan func(rows *sql.Rows) error, query string, args ...interface{}) error {
+	rows, err := q.QueryContext(ctx, s.dialect.rebind(query), args...)
+	if err != nil {
+		return errors.Wrap(err, errors.StoreError, "failed to query sql store")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return errors.Wrap(err, errors.StoreError, "failed to read sql store")
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, errors.StoreError, "failed to read sql store")
+	}
+	return nil
+}
+
+// queryInt64 runs a query returning a single number
+func (s *Store) queryInt64(ctx context.Context, q querier, query string, args ...interface{}) (int64, error) {
+	var value int64
+	if err := q.QueryRowContext(ctx, s.dialect.rebind(query), args...).Scan(&value); err != nil {
+		return 0, errors.Wrap(err, errors.StoreError, "failed to query sql store")
+	}
+	return value, nil
+}
+
+// where returns the condition matching the service name and id columns, an empty value matches all
+func where(serviceName, idColumn, id string) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if len(serviceName) != 0 {
+		conds, args = append(conds, "service_name = ?"), append(args, serviceName)
+	}
+	if len(id) != 0 {
+		conds, args = append(conds, idColumn+" = ?"), append(args, id)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// seqRange selects the policies or role policies of a service in table whose seq is in (after, upTo]
+type seqRange struct {
+	table       string
+	after, upTo int64
+}
+
+// and adds the range on idColumn to a condition of a service got by where
+func (r *seqRange) and(cond string, args []interface{}, serviceName, idColumn string) (string, []interface{}) {
+	if r == nil {
+		return cond, args
+	}
+	return cond + " AND " + idColumn + " IN (SELECT id FROM " + r.table + " WHERE service_name = ? AND seq > ? AND seq <= ?)",
+		append(args, serviceName, r.after, r.upTo)
+}
+
+func entityKey(serviceName, id string) string {
+	return serviceName + "\x00" + id
+}
+
+// loadMetadata gets the metadata of the entities of kind, keyed by entityKey
+func (s *Store) loadMetadata(ctx context.Context, q querier, kind, serviceName, id string, r *seqRange) (map[string]map[string]string, error) {
+	cond, args := where(serviceName, "id", id)
+	cond, args = r.and(cond, args, serviceName, "id")
+	if len(cond) == 0 {
+		cond = " WHERE kind = ?"
+	} else {
+		cond += " AND kind = ?"
+	}
+	args = append(args, kind)
+	metadata := make(map[string]map[string]string)
+	err := s.query(ctx, q, func(rows *sql.Rows) error {
+		var rowService, rowID, key, value string
+		if err := rows.Scan(&rowService, &rowID, &key, &value); err != nil {
+			return err
+		}
+		entity := entityKey(rowService, rowID)
+		if metadata[entity] == nil {
+			metadata[entity] = make(map[string]string)
+		}
+		metadata[entity][key] = value
+		return nil
+	}, "SELECT service_name, id, meta_key, meta_value FROM metadata"+cond, args...)
+	return metadata, err
+}
+
+func (s *Store) insertMetadata(ctx context.Context, q querier, kind, serviceName, id string, metadata map[string]string) error {
+	for key, value := range metadata {
+		if _, err := s.exec(ctx, q, "INSERT INTO metadata (kind, service_name, id, meta_key, meta_value) VALUES (?, ?, ?, ?, ?)",
+			kind, serviceName, id, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteMetadata removes the metadata of the entities of kind, an empty id matches all entities in the service
+func (s *Store) deleteMetadata(ctx context.Context, q querier, kind, serviceName, id string) error {
+	cond, args := where(serviceName, "id", id)
+	if len(cond) == 0 {
+		cond = " WHERE kind = ?"
+	} else {
+		cond += " AND kind = ?"
+	}
+	_, err := s.exec(ctx, q, "DELETE FROM metadata"+cond, append(args, kind)...)
+	return err
+}
+
+// loadServices gets a service, or all services if name is empty, with their policies and role policies
+func (s *Store) loadServices(ctx context.Context, q querier, name string) ([]*pms.Service, error) {
+	cond, args := where("", "name", name)
+	services := []*pms.Service{}
+	err := s.query(ctx, q, func(rows *sql.Rows) error {
+		var service pms.Service
+		if err := rows.Scan(&service.Name, &service.Type, &service.CombiningAlgorithm, &service.DefaultEffect, &service.Revision); err != nil {
+			return err
+		}
+		services = append(services, &service)
+		return nil
+	}, "SELECT name, service_type, combining_algorithm, default_effect, revision FROM services"+cond+" ORDER BY name", args...)
+	if err != nil || len(services) == 0 {
+		return services, err
+	}
+	metadata, err := s.loadMetadata(ctx, q, pms.KindService, name, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	attributes, err := s.loadServiceAttributes(ctx, q, name)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := s.loadPolicies(ctx, q, name, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	rolePolicies, err := s.loadRolePolicies(ctx, q, name, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, service := range services {
+		service.Metadata = metadata[entityKey(service.Name, "")]
+		service.Attributes = attributes[service.Name]
+		service.Policies = policies[service.Name]
+		service.RolePolicies = rolePolicies[service.Name]
+	}
+	return services, nil
+}
+
+func (s *Store) loadService(ctx context.Context, q querier, name string) (*pms.Service, error) {
+	services, err := s.loadServices(ctx, q, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(services) == 0 {
+		return nil, errors.Errorf(errors.EntityNotFound, "service %q is not found", name)
+	}
+	return services[0], nil
+}
+
+// serviceRevision gets the revision of a service, and returns EntityNotFound if the service does not exist
+func (s *Store) serviceRevision(ctx context.Context, q querier, name string) (int64, error) {
+	var revision int64
+	err := q.QueryRowContext(ctx, s.dialect.rebind("SELECT revision FROM services WHERE name = ?"), name).Scan(&revision)
+	if err == sql.ErrNoRows {
+		return 0, errors.Errorf(errors.EntityNotFound, "service %q is not found", name)
+	} else if err != nil {
+		return 0, errors.Wrap(err, errors.StoreError, "failed to query sql store")
+	}
+	return revision, nil
+}
+
+func (s *Store) insertService(ctx context.Context, q querier, service *pms.Service) error {
+	if _, err := s.exec(ctx, q, "INSERT INTO services (name, service_type, combining_algorithm, default_effect, revision) VALUES (?, ?, ?, ?, ?)",
+		service.Name, service.Type, service.CombiningAlgorithm, service.DefaultEffect, service.Revision); err != nil {
+		return err
+	}
+	if err := s.insertMetadata(ctx, q, pms.KindService, service.Name, "", service.Metadata); err != nil {
+		return err
+	}
+	if err := s.insertServiceAttributes(ctx, q, service.Name, service.Attributes); err != nil {
+		return err
+	}
+	for i, policy := range service.Policies {
+		if err := s.insertPolicy(ctx, q, service.Name, int64(i+1), policy); err != nil {
+			return err
+		}
+	}
+	for i, rolePolicy := range service.RolePolicies {
+		if err := s.insertRolePolicy(ctx, q, service.Name, int64(i+1), rolePolicy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteServiceRows removes a service, or all services if name is empty, with their policies and role policies
+func (s *Store) deleteServiceRows(ctx context.Context, q querier, name string) error {
+	if err := s.deletePolicyRows(ctx, q, name, ""); err != nil {
+		return err
+	}
+	if err := s.deleteRolePolicyRows(ctx, q, name, ""); err != nil {
+		return err
+	}
+	if err := s.deleteMetadata(ctx, q, pms.KindService, name, ""); err != nil {
+		return err
+	}
+	if err := s.deleteServiceAttributeRows(ctx, q, name); err != nil {
+		return err
+	}
+	cond, args := where("", "name", name)
+	_, err := s.exec(ctx, q, "DELETE FROM services"+cond, args...)
+	return err
+}
+
+// loadServiceAttributes gets the attribute schema of a service, or of all services if name is empty, keyed by service name
+func (s *Store) loadServiceAttributes(ctx context.Context, q querier, name string) (map[string][]*pms.Attribute, error) {
+	cond, args := where(name, "", "")
+	attributes := make(map[string][]*pms.Attribute)
+	err := s.query(ctx, q, func(rows *sql.Rows) error {
+		var serviceName string
+		var attribute pms.Attribute
+		var isList int
+		if err := rows.Scan(&serviceName, &attribute.Name, &attribute.Type, &isList); err != nil {
+			return err
+		}
+		attribute.List = isList != 0
+		attributes[serviceName] = append(attributes[serviceName], &attribute)
+		return nil
+	}, "SELECT service_name, name, attribute_type, is_list FROM service_attributes"+cond+" ORDER BY service_name, seq", args...)
+	return attributes, err
+}
+
+func (s *Store) insertServiceAttributes(ctx context.Context, q querier, serviceName string, attributes []*pms.Attribute) error {
+	for i, attribute := range attributes {
+		if attribute == nil {
+			continue
+		}
+		isList := 0
+		if attribute.List {
+			isList = 1
+		}
+		if _, err := s.exec(ctx, q, "INSERT INTO service_attributes (service_name, seq, name, attribute_type, is_list) VALUES (?, ?, ?, ?, ?)",
+			serviceName, i, attribute.Name, attribute.Type, isList); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteServiceAttributeRows removes the attribute schema of a service, or of all services if name is empty
+func (s *Store) deleteServiceAttributeRows(ctx context.Context, q querier, name string) error {
+	cond, args := where(name, "", "")
+	_, err := s.exec(ctx, q, "DELETE FROM service_attributes"+cond, args...)
+	return err
+}
+
+// loadPolicies gets a policy, or all policies of a service or of all services, keyed by service name in the order of creation.
+// The policies of a service are limited to a range of seq if r is not nil.
+func (s *Store) loadPolicies(ctx context.Context, q querier, serviceName, id string, r *seqRange) (map[string][]*pms.Policy, error) {
+	cond, args := where(serviceName, "id", id)
+	cond, args = r.and(cond, args, serviceName, "id")
+	policies := make(map[string][]*pms.Policy)
+	byKey := make(map[string]*pms.Policy)
+	err := s.query(ctx, q, func(rows *sql.Rows) error {
+		var rowService string
+		var policy pms.Policy
+		if err := rows.Scan(&rowService, &policy.ID, &policy.Name, &policy.Effect, &policy.Condition, &policy.Priority, &policy.Revision); err != nil {
+			return err
+		}
+		policies[rowService] = append(policies[rowService], &policy)
+		byKey[entityKey(rowService, policy.ID)] = &policy
+		return nil
+	}, "SELECT service_name, id, name, effect, condition_expr, priority, revision FROM policies"+cond+" ORDER BY service_name, seq", args...)
+	if err != nil || len(byKey) == 0 {
+		return policies, err
+	}
+
+	cond, args = where(serviceName, "policy_id", id)
+	cond, args = r.and(cond, args, serviceName, "policy_id")
+	permissions := make(map[string]*pms.Permission)
+	err = s.query(ctx, q, func(rows *sql.Rows) error {
+		var rowService, policyID, seq string
+		var permission pms.Permission
+		if err := rows.Scan(&rowService, &policyID, &seq, &permission.Resource, &permission.ResourceExpression, &permission.ResourceGlob); err != nil {
+			return err
+		}
+		if policy, ok := byKey[entityKey(rowService, policyID)]; ok {
+			policy.Permissions = append(policy.Permissions, &permission)
+			permissions[entityKey(entityKey(rowService, policyID), seq)] = &permission
+		}
+		return nil
+	}, "SELECT service_name, policy_id, seq, resource, resource_expression, COALESCE(resource_glob, '') FROM policy_permissions"+cond+" ORDER BY service_name, policy_id, seq", args...)
+	if err != nil {
+		return nil, err
+	}
+	err = s.query(ctx, q, func(rows *sql.Rows) error {
+		var rowService, policyID, permissionSeq, action string
+		if err := rows.Scan(&rowService, &policyID, &permissionSeq, &action); err != nil {
+			return err
+		}
+		if permission, ok := permissions[entityKey(entityKey(rowService, policyID), permissionSeq)]; ok {
+			permission.Actions = append(permission.Actions, action)
+		}
+		return nil
+	}, "SELECT service_name, policy_id, permission_seq, action FROM policy_permission_actions"+cond+" ORDER BY service_name, policy_id, permission_seq, seq", args...)
+	if err != nil {
+		return nil, err
+	}
+	err = s.query(ctx, q, func(rows *sql.Rows) error {
+		var rowService, policyID, principal string
+		var andSeq int
+		if err := rows.Scan(&rowService, &policyID, &andSeq, &principal); err != nil {
+			return err
+		}
+		if policy, ok := byKey[entityKey(rowService, policyID)]; ok {
+			for len(policy.Principals) <= andSeq {
+				policy.Principals = append(policy.Principals, []string{})
+			}
+			policy.Principals[andSeq] = append(policy.Principals[andSeq], principal)
+		}
+		return nil
+	}, "SELECT service_name, policy_id, and_seq, principal FROM policy_principals"+cond+" ORDER BY service_name, policy_id, and_seq, seq", args...)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := s.loadMetadata(ctx, q, pms.KindPolicy, serviceName, id, r)
+	if err != nil {
+		return nil, err
+	}
+	for key, policy := range byKey {
+		policy.Metadata = metadata[key]
+	}
+	return policies, nil
+}
+
+func (s *Store) loadPolicy(ctx context.Context, q querier, serviceName, id string) (*pms.Policy, error) {
+	policies, err := s.loadPolicies(ctx, q, serviceName, id, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(policies[serviceName]) == 0 {
+		return nil, errors.Errorf(errors.EntityNotFound, "unable to find policy %q in service %q", id, serviceName)
+	}
+	return policies[serviceName][0], nil
+}
+
+func (s *Store) insertPolicy(ctx context.Context, q querier, serviceName string, seq int64, policy *pms.Policy) error {
+	if _, err := s.exec(ctx, q, "INSERT INTO policies (service_name, id, seq, name, effect, condition_expr, priority, revision) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		serviceName, policy.ID, seq, policy.Name, policy.Effect, policy.Condition, policy.Priority, policy.Revision); err != nil {
+		return err
+	}
+	for i, permission := range policy.Permissions {
+		if permission == nil {
+			continue
+		}
+		if _, err := s.exec(ctx, q, "INSERT INTO policy_permissions (service_name, policy_id, seq, resource, resource_expression, resource_glob) VALUES (?, ?, ?, ?, ?, ?)",
+			serviceName, policy.ID, i, permission.Resource, permission.ResourceExpression, permission.ResourceGlob); err != nil {
+			return err
+		}
+		for j, action := range permission.Actions {
+			if _, err := s.exec(ctx, q, "INSERT INTO policy_permission_actions (service_name, policy_id, permission_seq, seq, action) VALUES (?, ?, ?, ?, ?)",
+				serviceName, policy.ID, i, j, action); err != nil {
+				return err
+			}
+		}
+	}
+	for i, principals := range policy.Principals {
+		for j, principal := range principals {
+			if _, err := s.exec(ctx, q, "INSERT INTO policy_principals (service_name, policy_id, and_seq, seq, principal) VALUES (?, ?, ?, ?, ?)",
+				serviceName, policy.ID, i, j, principal); err != nil {
+				return err
+			}
+		}
+	}
+	return s.insertMetadata(ctx, q, pms.KindPolicy, serviceName, policy.ID, policy.Metadata)
+}
+
+// deletePolicyRows removes a policy, or all policies of a service if id is empty, or of all services if serviceName is empty too
+func (s *Store) deletePolicyRows(ctx context.Context, q querier, serviceName, id string) error {
+	cond, args := where(serviceName, "policy_id", id)
+	for _, table := range []string{"policy_permissions", "policy_permission_actions", "policy_principals"} {
+		if _, err := s.exec(ctx, q, "DELETE FROM "+table+cond, args...); err != nil {
+			return err
+		}
+	}
+	if err := s.deleteMetadata(ctx, q, pms.KindPolicy, serviceName, id); err != nil {
+		return err
+	}
+	cond, args = where(serviceName, "id", id)
+	_, err := s.exec(ctx, q, "DELETE FROM policies"+cond, args...)
+	return err
+}
+
+// loadRolePolicies gets a role policy, or all role policies of a service or of all services, keyed by service name in the order of creation.
+// The role policies of a service are limited to a range of seq if r is not nil.
+func (s *Store) loadRolePolicies(ctx context.Context, q querier, serviceName, id string, r *seqRange) (map[string][]*pms.RolePolicy, error) {
+	cond, args := where(serviceName, "id", id)
+	cond, args = r.and(cond, args, serviceName, "id")
+	rolePolicies := make(map[string][]*pms.RolePolicy)
+	byKey := make(map[string]*pms.RolePolicy)
+	err := s.query(ctx, q, func(rows *sql.Rows) error {
+		var rowService string
+		var rolePolicy pms.RolePolicy
+		if err := rows.Scan(&rowService, &rolePolicy.ID, &rolePolicy.Name, &rolePolicy.Effect, &rolePolicy.Condition, &rolePolicy.Revision); err != nil {
+			return err
+		}
+		rolePolicies[rowService] = append(rolePolicies[rowService], &rolePolicy)
+		byKey[entityKey(rowService, rolePolicy.ID)] = &rolePolicy
+		return nil
+	}, "SELECT service_name, id, name, effect, condition_expr, revision FROM role_policies"+cond+" ORDER BY service_name, seq", args...)
+	if err != nil || len(byKey) == 0 {
+		return rolePolicies, err
+	}
+
+	cond, args = where(serviceName, "role_policy_id", id)
+	cond, args = r.and(cond, args, serviceName, "role_policy_id")
+	err = s.query(ctx, q, func(rows *sql.Rows) error {
+		var rowService, rolePolicyID, kind, value string
+		if err := rows.Scan(&rowService, &rolePolicyID, &kind, &value); err != nil {
+			return err
+		}
+		rolePolicy, ok := byKey[entityKey(rowService, rolePolicyID)]
+		if !ok {
+			return nil
+		}
+		switch kind {
+		case itemRole:
+			rolePolicy.Roles = append(rolePolicy.Roles, value)
+		case itemPrincipal:
+			rolePolicy.Principals = append(rolePolicy.Principals, value)
+		case itemResource:
+			rolePolicy.Resources = append(rolePolicy.Resources, value)
+		case itemResourceExpression:
+			rolePolicy.ResourceExpressions = append(rolePolicy.ResourceExpressions, value)
+		case itemResourceGlob:
+			rolePolicy.ResourceGlobs = append(rolePolicy.ResourceGlobs, value)
+		}
+		return nil
+	}, "SELECT service_name, role_policy_id, item_kind, item_value FROM role_policy_items"+cond+" ORDER BY service_name, role_policy_id, item_kind, seq", args...)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := s.loadMetadata(ctx, q, pms.KindRolePolicy, serviceName, id, r)
+	if err != nil {
+		return nil, err
+	}
+	for key, rolePolicy := range byKey {
+		rolePolicy.Metadata = metadata[key]
+	}
+	return rolePolicies, nil
+}
+
+func (s *Store) loadRolePolicy(ctx context.Context, q querier, serviceName, id string) (*pms.RolePolicy, error) {
+	rolePolicies, err := s.loadRolePolicies(ctx, q, serviceName, id, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(rolePolicies[serviceName]) == 0 {
+		return nil, errors.Errorf(errors.EntityNotFound, "unable to find role policy %q in service %q", id, serviceName)
+	}
+	return rolePolicies[serviceName][0], nil
+}
+
+func (s *Store) insertRolePolicy(ctx context.Context, q querier, serviceName string, seq int64, rolePolicy *pms.RolePolicy) error {
+	if _, err := s.exec(ctx, q, "INSERT INTO role_policies (service_name, id, seq, name, effect, condition_expr, revision) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		serviceName, rolePolicy.ID, seq, rolePolicy.Name, rolePolicy.Effect, rolePolicy.Condition, rolePolicy.Revision); err != nil {
+		return err
+	}
+	items := map[string][]string{
+		itemRole:               rolePolicy.Roles,
+		itemPrincipal:          rolePolicy.Principals,
+		itemResource:           rolePolicy.Resources,
+		itemResourceExpression: rolePolicy.ResourceExpressions,
+		itemResourceGlob:       rolePolicy.ResourceGlobs,
+	}
+	for kind, values := range items {
+		for i, value := range values {
+			if _, err := s.exec(ctx, q, "INSERT INTO role_policy_items (service_name, role_policy_id, item_kind, seq, item_value) VALUES (?, ?, ?, ?, ?)",
+				serviceName, rolePolicy.ID, kind, i, value); err != nil {
+				return err
+			}
+		}
+	}
+	return s.insertMetadata(ctx, q, pms.KindRolePolicy, serviceName, rolePolicy.ID, rolePolicy.Metadata)
+}
+
+// deleteRolePolicyRows removes a role policy, or all role policies of a service if id is empty, or of all services if serviceName is empty too
+func (s *Store) deleteRolePolicyRows(ctx context.Context, q querier, serviceName, id string) error {
+	cond, args := where(serviceName, "role_policy_id", id)
+	if _, err := s.exec(ctx, q, "DELETE FROM role_policy_items"+cond, args...); err != nil {
+		return err
+	}
+	if err := s.deleteMetadata(ctx, q, pms.KindRolePolicy, serviceName, id); err != nil {
+		return err
+	}
+	cond, args = where(serviceName, "id", id)
+	_, err := s.exec(ctx, q, "DELETE FROM role_policies"+cond, args...)
+	return err
+}
+
+// loadFunctions gets a function, or all functions if name is empty
+func (s *Store) loadFunctions(ctx context.Context, q querier, name string) ([]*pms.Function, error) {
+	cond, args := where("", "name", name)
+	functions := []*pms.Function{}
+	err := s.query(ctx, q, func(rows *sql.Rows) error {
+		var function pms.Function
+		var resultCachable int
+		if err := rows.Scan(&function.Name, &function.Description, &function.FuncURL, &function.LocalFuncURL, &function.CA,
+			&resultCachable, &function.ResultTTL, &function.Revision); err != nil {
+			return err
+		}
+		function.ResultCachable = resultCachable != 0
+		functions = append(functions, &function)
+		return nil
+	}, "SELECT name, description, func_url, local_func_url, ca, result_cachable, result_ttl, revision FROM functions"+cond+" ORDER BY name", args...)
+	if err != nil || len(functions) == 0 {
+		return functions, err
+	}
+	metadata, err := s.loadMetadata(ctx, q, pms.KindFunction, "", name, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, function := range functions {
+		function.Metadata = metadata[entityKey("", function.Name)]
+	}
+	return functions, nil
+}
+
+func (s *Store) loadFunction(ctx context.Context, q querier, name string) (*pms.Function, error) {
+	functions, err := s.loadFunctions(ctx, q, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(functions) == 0 {
+		return nil, errors.Errorf(errors.EntityNotFound, "function %q is not found", name)
+	}
+	return functions[0], nil
+}
+
+func (s *Store) insertFunction(ctx context.Context, q querier, function *pms.Function) error {
+	resultCachable := 0
+	if function.ResultCachable {
+		resultCachable = 1
+	}
+	if _, err := s.exec(ctx, q, "INSERT INTO functions (name, description, func_url, local_func_url, ca, result_cachable, result_ttl, revision) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		function.Name, function.Description, function.FuncURL, function.LocalFuncURL, function.CA, resultCachable, function.ResultTTL, function.Revision); err != nil {
+		return err
+	}
+	return s.insertMetadata(ctx, q, pms.KindFunction, "", function.Name, function.Metadata)
+}
+
+// deleteFunctionRows removes a function, or all functions if name is empty
+func (s *Store) deleteFunctionRows(ctx context.Context, q querier, name string) error {
+	if err := s.deleteMetadata(ctx, q, pms.KindFunction, "", name); err != nil {
+		return err
+	}
+	cond, args := where("", "name", name)
+	_, err := s.exec(ctx, q, "DELETE FROM functions"+cond, args...)
+	return err
+}
@@ -0,0 +1,97 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+)
+
+// insertHistory keeps the records in the transaction of the change, whose revision is the version of the records
+func (s *Store) insertHistory(ctx context.Context, q querier, records []*pms.HistoryRecord) error {
+	for i, record := range records {
+		value, err := json.Marshal(record)
+		if err != nil {
+			return errors.Wrap(err, errors.SerializationError, "failed to marshal history record")
+		}
+		if _, err := s.exec(ctx, q, "INSERT INTO history (kind, service_name, id, version, record_seq, record) VALUES (?, ?, ?, ?, ?, ?)",
+			record.Kind, record.ServiceName, record.ID, record.Version, i, string(value)); err != nil {
+			return err
+		}
+		if err := s.removeExpiredHistory(ctx, q, record.Kind, record.ServiceName, record.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeExpiredHistory removes the oldest versions of an entity, so at most store.MaxHistoryVersions versions are kept
+func (s *Store) removeExpiredHistory(ctx context.Context, q querier, kind, serviceName, id string) error {
+	var versions []int64
+	err := s.query(ctx, q, func(rows *sql.Rows) error {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return err
+		}
+		versions = append(versions, version)
+		return nil
+	}, "SELECT DISTINCT version FROM history WHERE kind = ? AND service_name = ? AND id = ? ORDER BY version", kind, serviceName, id)
+	if err != nil {
+		return err
+	}
+	expired := store.ExpiredHistoryVersion(versions)
+	if expired == 0 {
+		return nil
+	}
+	_, err = s.exec(ctx, q, "DELETE FROM history WHERE kind = ? AND service_name = ? AND id = ? AND version <= ?", kind, serviceName, id, expired)
+	return err
+}
+
+// ListHistory gets all versions of a service, policy, role policy or function, the oldest first.
+func (s *Store) ListHistory(kind, serviceName, id string) ([]*pms.HistoryRecord, error) {
+	if kind == pms.KindService || kind == pms.KindFunction {
+		serviceName = ""
+	}
+	var records []*pms.HistoryRecord
+	err := s.read(func(ctx context.Context, q querier) error {
+		return s.query(ctx, q, func(rows *sql.Rows) error {
+			var value string
+			if err := rows.Scan(&value); err != nil {
+				return err
+			}
+			var record pms.HistoryRecord
+			if err := json.Unmarshal([]byte(value), &record); err != nil {
+				return errors.Wrapf(err, errors.SerializationError, "failed to unmarshal history record %q", value)
+			}
+			records = append(records, &record)
+			return nil
+		}, "SELECT record FROM history WHERE kind = ? AND service_name = ? AND id = ? ORDER BY version, record_seq", kind, serviceName, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.Errorf(errors.EntityNotFound, "no history found for %s %q", kind, id)
+	}
+	return records, nil
+}
+
+// GetHistory gets a version of a service, policy, role policy or function.
+func (s *Store) GetHistory(kind, serviceName, id string, version int64) (*pms.HistoryRecord, error) {
+	records, err := s.ListHistory(kind, serviceName, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.Version == version {
+			return record, nil
+		}
+	}
+	return nil, errors.Errorf(errors.EntityNotFound, "version %d of %s %q is not found", version, kind, id)
+}
@@ -0,0 +1,224 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package sql
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/teramoby/speedle-plus/pkg/errors"
+)
+
+// dialect keeps the differences of the supported databases, queries are written with ? as placeholders,
+// and column types with {key} and {text}
+type dialect struct {
+	driver string
+	// keyType is the type of the columns in primary keys and indexes, which is limited to 191 characters
+	// so the keys fit in the index of MySQL with utf8mb4
+	keyType string
+	// textType is the type of the columns with unlimited length
+	textType string
+}
+
+var dialects = map[string]*dialect{
+	DriverSQLite:   {driver: DriverSQLite, keyType: "VARCHAR(191)", textType: "TEXT"},
+	DriverPostgres: {driver: DriverPostgres, keyType: "VARCHAR(191)", textType: "TEXT"},
+	DriverMySQL:    {driver: DriverMySQL, keyType: "VARCHAR(191)", textType: "LONGTEXT"},
+}
+
+func dialectOf(driver string) (*dialect, error) {
+	d, ok := dialects[driver]
+	if !ok {
+		return nil, errors.Errorf(errors.ConfigError, "unsupported sql driver %q, it should be %s, %s or %s", driver, DriverSQLite, DriverPostgres, DriverMySQL)
+	}
+	return d, nil
+}
+
+// rebind replaces the ? placeholders of query with the ones of the database
+func (d *dialect) rebind(query string) string {
+	if d.driver != DriverPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// ddl replaces the column types in a statement of the schema with the ones of the database
+func (d *dialect) ddl(statement string) string {
+	return strings.NewReplacer("{key}", d.keyType, "{text}", d.textType).Replace(statement)
+}
+
+// Names of the counters. The revision counter is increased by every change of the policy store,
+// and the discover counter by every discover request saved.
+const (
+	counterRevision = "revision"
+	counterDiscover = "discover"
+)
+
+// migrations are the versions of the schema, a migration is applied once in a transaction if the database supports
+// transactional DDL. New versions are appended, and the applied ones must never be changed.
+//
+// Every entity has its own table, the lists in an entity are kept in child tables ordered by seq, and the
+// metadata of all kinds of entities in the metadata table. Services and functions changed by a revision are
+// appended to the changelog, which is polled by watchers.
+var migrations = [][]string{
+	{
+		`CREATE TABLE counters (
+			name {key} NOT NULL,
+			counter_value BIGINT NOT NULL,
+			PRIMARY KEY (name))`,
+		`INSERT INTO counters (name, counter_value) VALUES ('revision', 0)`,
+		`INSERT INTO counters (name, counter_value) VALUES ('discover', 0)`,
+		`CREATE TABLE services (
+			name {key} NOT NULL,
+			service_type {key} NOT NULL,
+			combining_algorithm {key} NOT NULL,
+			default_effect {key} NOT NULL,
+			revision BIGINT NOT NULL,
+			PRIMARY KEY (name))`,
+		`CREATE TABLE policies (
+			service_name {key} NOT NULL,
+			id {key} NOT NULL,
+			seq BIGINT NOT NULL,
+			name {text} NOT NULL,
+			effect {key} NOT NULL,
+			condition_expr {text} NOT NULL,
+			priority BIGINT NOT NULL,
+			revision BIGINT NOT NULL,
+			PRIMARY KEY (service_name, id))`,
+		`CREATE TABLE policy_permissions (
+			service_name {key} NOT NULL,
+			policy_id {key} NOT NULL,
+			seq BIGINT NOT NULL,
+			resource {text} NOT NULL,
+			resource_expression {text} NOT NULL,
+			PRIMARY KEY (service_name, policy_id, seq))`,
+		`CREATE TABLE policy_permission_actions (
+			service_name {key} NOT NULL,
+			policy_id {key} NOT NULL,
+			permission_seq BIGINT NOT NULL,
+			seq BIGINT NOT NULL,
+			action {text} NOT NULL,
+			PRIMARY KEY (service_name, policy_id, permission_seq, seq))`,
+		`CREATE TABLE policy_principals (
+			service_name {key} NOT NULL,
+			policy_id {key} NOT NULL,
+			and_seq BIGINT NOT NULL,
+			seq BIGINT NOT NULL,
+			principal {text} NOT NULL,
+			PRIMARY KEY (service_name, policy_id, and_seq, seq))`,
+		`CREATE TABLE role_policies (
+			service_name {key} NOT NULL,
+			id {key} NOT NULL,
+			seq BIGINT NOT NULL,
+			name {text} NOT NULL,
+			effect {key} NOT NULL,
+			condition_expr {text} NOT NULL,
+			revision BIGINT NOT NULL,
+			PRIMARY KEY (service_name, id))`,
+		`CREATE TABLE role_policy_items (
+			service_name {key} NOT NULL,
+			role_policy_id {key} NOT NULL,
+			item_kind {key} NOT NULL,
+			seq BIGINT NOT NULL,
+			item_value {text} NOT NULL,
+			PRIMARY KEY (service_name, role_policy_id, item_kind, seq))`,
+		`CREATE TABLE functions (
+			name {key} NOT NULL,
+			description {text} NOT NULL,
+			func_url {text} NOT NULL,
+			local_func_url {text} NOT NULL,
+			ca {text} NOT NULL,
+			result_cachable INTEGER NOT NULL,
+			result_ttl BIGINT NOT NULL,
+			revision BIGINT NOT NULL,
+			PRIMARY KEY (name))`,
+		`CREATE TABLE metadata (
+			kind {key} NOT NULL,
+			service_name {key} NOT NULL,
+			id {key} NOT NULL,
+			meta_key {key} NOT NULL,
+			meta_value {text} NOT NULL,
+			PRIMARY KEY (kind, service_name, id, meta_key))`,
+		`CREATE TABLE changelog (
+			revision BIGINT NOT NULL,
+			kind {key} NOT NULL,
+			name {key} NOT NULL)`,
+		`CREATE INDEX changelog_revision ON changelog (revision)`,
+		`CREATE TABLE history (
+			kind {key} NOT NULL,
+			service_name {key} NOT NULL,
+			id {key} NOT NULL,
+			version BIGINT NOT NULL,
+			record_seq BIGINT NOT NULL,
+			record {text} NOT NULL)`,
+		`CREATE INDEX history_entity ON history (kind, service_name, id, version)`,
+		`CREATE TABLE discover_requests (
+			revision BIGINT NOT NULL,
+			service_name {key} NOT NULL,
+			request {text} NOT NULL,
+			PRIMARY KEY (revision))`,
+		`CREATE INDEX discover_requests_service ON discover_requests (service_name, revision)`,
+	},
+	{
+		// resource globs of permissions, the column is nullable as MySQL doesn't support defaults of text columns
+		`ALTER TABLE policy_permissions ADD COLUMN resource_glob {text}`,
+	},
+	{
+		// attribute schemas of services
+		`CREATE TABLE service_attributes (
+			service_name {key} NOT NULL,
+			seq BIGINT NOT NULL,
+			name {key} NOT NULL,
+			attribute_type {key} NOT NULL,
+			is_list INTEGER NOT NULL,
+			PRIMARY KEY (service_name, seq))`,
+	},
+}
+
+// migrate applies the migrations which are not applied yet, the versions applied are kept in schema_migrations
+func (s *Store) migrate() error {
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+	if _, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL, PRIMARY KEY (version))`); err != nil {
+		return errors.Wrap(err, errors.StoreError, "unable to create the table of schema migrations")
+	}
+	var current int64
+	if err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return errors.Wrap(err, errors.StoreError, "unable to get the version of schema")
+	}
+	for version := current + 1; version <= int64(len(migrations)); version++ {
+		log.Infof("migrating the schema of sql store to version %d", version)
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return errors.Wrap(err, errors.StoreError, "unable to start transaction")
+		}
+		for _, statement := range migrations[version-1] {
+			if _, err := tx.ExecContext(ctx, s.dialect.ddl(statement)); err != nil {
+				tx.Rollback()
+				return errors.Wrapf(err, errors.StoreError, "unable to migrate the schema to version %d", version)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, s.dialect.rebind(`INSERT INTO schema_migrations (version) VALUES (?)`), version); err != nil {
+			tx.Rollback()
+			return errors.Wrapf(err, errors.StoreError, "unable to migrate the schema to version %d", version)
+		}
+		if err := tx.Commit(); err != nil {
+			return errors.Wrapf(err, errors.StoreError, "unable to migrate the schema to version %d", version)
+		}
+	}
+	return nil
+}
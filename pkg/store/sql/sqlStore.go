@@ -0,0 +1,1114 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+	"github.com/teramoby/speedle-plus/pkg/store/filter"
+	"github.com/teramoby/speedle-plus/pkg/suid"
+)
+
+// operationTimeout is the timeout of a read or a write of the store
+const operationTimeout = 30 * time.Second
+
+// Store keeps policies in a relational database, which could be SQLite, PostgreSQL or MySQL
+type Store struct {
+	db           *sql.DB
+	dialect      *dialect
+	pollInterval time.Duration
+
+	stopLock sync.Mutex
+	stop     chan struct{}
+}
+
+// change is a write of the store. All changes made by it take the same revision, which is increased
+// when the write starts, so writes are serialized by the row lock of the revision counter.
+type change struct {
+	ctx      context.Context
+	tx       *sql.Tx
+	revision int64
+	// services and functions changed, which are appended to the changelog
+	services  []string
+	functions []string
+	// ops are the changes made, which are kept in history
+	ops []*pms.Operation
+}
+
+func (c *change) serviceChanged(name string) {
+	for _, service := range c.services {
+		if service == name {
+			return
+		}
+	}
+	c.services = append(c.services, name)
+}
+
+func (c *change) functionChanged(name string) {
+	for _, function := range c.functions {
+		if function == name {
+			return
+		}
+	}
+	c.functions = append(c.functions, name)
+}
+
+// nextCounter increases the named counter and returns the new value
+func (s *Store) nextCounter(ctx context.Context, q querier, name string) (int64, error) {
+	if _, err := s.exec(ctx, q, "UPDATE counters SET counter_value = counter_value + 1 WHERE name = ?", name); err != nil {
+		return 0, err
+	}
+	return s.queryInt64(ctx, q, "SELECT counter_value FROM counters WHERE name = ?", name)
+}
+
+// write runs fn in a transaction with a new revision, and appends the services and functions changed by fn to
+// the changelog and the changes to history in the same transaction. Nothing is written if fn fails.
+func (s *Store) write(fn func(c *change) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, errors.StoreError, "failed to start transaction")
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			tx.Rollback()
+		}
+	}()
+	revision, err := s.nextCounter(ctx, tx, counterRevision)
+	if err != nil {
+		return err
+	}
+	c := change{ctx: ctx, tx: tx, revision: revision}
+	if err := fn(&c); err != nil {
+		return err
+	}
+	for _, name := range c.services {
+		if _, err := s.exec(ctx, tx, "INSERT INTO changelog (revision, kind, name) VALUES (?, ?, ?)", revision, pms.KindService, name); err != nil {
+			return err
+		}
+	}
+	for _, name := range c.functions {
+		if _, err := s.exec(ctx, tx, "INSERT INTO changelog (revision, kind, name) VALUES (?, ?, ?)", revision, pms.KindFunction, name); err != nil {
+			return err
+		}
+	}
+	if _, err := s.exec(ctx, tx, "DELETE FROM changelog WHERE revision <= ?", revision-changelogRetention); err != nil {
+		return err
+	}
+	if err := s.insertHistory(ctx, tx, store.NewHistoryRecords(c.ops, revision)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, errors.StoreError, "failed to commit transaction")
+	}
+	committed = true
+	return nil
+}
+
+// read runs fn with the database
+func (s *Store) read(fn func(ctx context.Context, q querier) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+	return fn(ctx, s.db)
+}
+
+func checkRevision(expected, current int64, kind, name string) error {
+	if expected > 0 && expected != current {
+		return errors.Errorf(errors.RevisionConflict, "revision %d of %s %q does not match the current revision %d", expected, kind, name, current)
+	}
+	return nil
+}
+
+// ReadPolicyStore reads all services and functions
+func (s *Store) ReadPolicyStore() (*pms.PolicyStore, error) {
+	var ps pms.PolicyStore
+	err := s.read(func(ctx context.Context, q querier) error {
+		var err error
+		if ps.Services, err = s.loadServices(ctx, q, ""); err != nil {
+			return err
+		}
+		ps.Functions, err = s.loadFunctions(ctx, q, "")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ps, nil
+}
+
+// WritePolicyStore replaces all services and functions. The IDs of policies and role policies are kept,
+// and generated if they are empty.
+func (s *Store) WritePolicyStore(ps *pms.PolicyStore) error {
+	return s.write(func(c *change) error {
+		if err := s.deleteServices(c); err != nil {
+			return err
+		}
+		if err := s.deleteFunctions(c); err != nil {
+			return err
+		}
+		for _, service := range ps.Services {
+			dupService := *service
+			dupService.Revision = c.revision
+			for _, policy := range dupService.Policies {
+				if len(policy.ID) == 0 {
+					policy.ID = suid.New().String()
+				}
+				policy.Revision = c.revision
+			}
+			for _, rolePolicy := range dupService.RolePolicies {
+				if len(rolePolicy.ID) == 0 {
+					rolePolicy.ID = suid.New().String()
+				}
+				rolePolicy.Revision = c.revision
+			}
+			if err := s.insertService(c.ctx, c.tx, &dupService); err != nil {
+				return err
+			}
+			c.serviceChanged(dupService.Name)
+			c.ops = append(c.ops, &pms.Operation{Op: pms.OpCreate, Kind: pms.KindService, Service: &dupService})
+		}
+		for _, function := range ps.Functions {
+			if _, err := s.createFunction(c, function); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListAllServices lists all the services
+func (s *Store) ListAllServices() ([]*pms.Service, error) {
+	var services []*pms.Service
+	err := s.read(func(ctx context.Context, q querier) error {
+		var err error
+		services, err = s.loadServices(ctx, q, "")
+		return err
+	})
+	return services, err
+}
+
+// GetServiceNames reads all the service names
+func (s *Store) GetServiceNames() ([]string, error) {
+	names := []string{}
+	err := s.read(func(ctx context.Context, q querier) error {
+		return s.query(ctx, q, func(rows *sql.Rows) error {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return err
+			}
+			names = append(names, name)
+			return nil
+		}, "SELECT name FROM services ORDER BY name")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// GetPolicyAndRolePolicyCounts returns a map, in which the key is the service name, and the value is the count of both policies and role policies in the service.
+func (s *Store) GetPolicyAndRolePolicyCounts() (map[string]*pms.PolicyAndRolePolicyCount, error) {
+	countMap := make(map[string]*pms.PolicyAndRolePolicyCount)
+	err := s.read(func(ctx context.Context, q querier) error {
+		err := s.query(ctx, q, func(rows *sql.Rows) error {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return err
+			}
+			countMap[name] = &pms.PolicyAndRolePolicyCount{}
+			return nil
+		}, "SELECT name FROM services")
+		if err != nil {
+			return err
+		}
+		for _, table := range []string{"policies", "role_policies"} {
+			err := s.query(ctx, q, func(rows *sql.Rows) error {
+				var name string
+				var count int64
+				if err := rows.Scan(&name, &count); err != nil {
+					return err
+				}
+				if counts, ok := countMap[name]; ok {
+					if table == "policies" {
+						counts.PolicyCount = count
+					} else {
+						counts.RolePolicyCount = count
+					}
+				}
+				return nil
+			}, "SELECT service_name, COUNT(*) FROM "+table+" GROUP BY service_name")
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return countMap, nil
+}
+
+// GetServiceCount gets the service count
+func (s *Store) GetServiceCount() (int64, error) {
+	var count int64
+	err := s.read(func(ctx context.Context, q querier) error {
+		var err error
+		count, err = s.queryInt64(ctx, q, "SELECT COUNT(*) FROM services")
+		return err
+	})
+	return count, err
+}
+
+// GetService gets the detailed info of a service
+func (s *Store) GetService(serviceName string) (*pms.Service, error) {
+	var service *pms.Service
+	err := s.read(func(ctx context.Context, q querier) error {
+		var err error
+		service, err = s.loadService(ctx, q, serviceName)
+		return err
+	})
+	return service, err
+}
+
+// generateID returns a copy of service whose policies and role policies have new IDs
+func generateID(service *pms.Service, revision int64) *pms.Service {
+	result := *service
+	result.Revision = revision
+	result.Policies = make([]*pms.Policy, 0, len(service.Policies))
+	for _, policy := range service.Policies {
+		dupPolicy := *policy
+		dupPolicy.ID = suid.New().String()
+		dupPolicy.Revision = revision
+		result.Policies = append(result.Policies, &dupPolicy)
+	}
+	result.RolePolicies = make([]*pms.RolePolicy, 0, len(service.RolePolicies))
+	for _, rolePolicy := range service.RolePolicies {
+		dupRolePolicy := *rolePolicy
+		dupRolePolicy.ID = suid.New().String()
+		dupRolePolicy.Revision = revision
+		result.RolePolicies = append(result.RolePolicies, &dupRolePolicy)
+	}
+	return &result
+}
+
+// CreateService creates a new service
+func (s *Store) CreateService(service *pms.Service) error {
+	return s.write(func(c *change) error {
+		_, err := s.createService(c, service)
+		return err
+	})
+}
+
+func (s *Store) createService(c *change, service *pms.Service) (*pms.Service, error) {
+	if _, err := s.serviceRevision(c.ctx, c.tx, service.Name); err == nil {
+		return nil, errors.Errorf(errors.EntityAlreadyExists, "service %q already exists", service.Name)
+	} else if errors.Code(err) != errors.EntityNotFound {
+		return nil, err
+	}
+	serviceWithIDs := generateID(service, c.revision)
+	if err := s.insertService(c.ctx, c.tx, serviceWithIDs); err != nil {
+		return nil, err
+	}
+	c.serviceChanged(service.Name)
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpCreate, Kind: pms.KindService, Service: serviceWithIDs})
+	return serviceWithIDs, nil
+}
+
+// UpdateService updates the type, combining algorithm, default effect and metadata of an existing service
+func (s *Store) UpdateService(service *pms.Service) error {
+	return s.write(func(c *change) error {
+		_, err := s.updateService(c, service)
+		return err
+	})
+}
+
+func (s *Store) updateService(c *change, service *pms.Service) (*pms.Service, error) {
+	current, err := s.serviceRevision(c.ctx, c.tx, service.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRevision(service.Revision, current, "service", service.Name); err != nil {
+		return nil, err
+	}
+	if _, err := s.exec(c.ctx, c.tx, "UPDATE services SET service_type = ?, combining_algorithm = ?, default_effect = ?, revision = ? WHERE name = ?",
+		service.Type, service.CombiningAlgorithm, service.DefaultEffect, c.revision, service.Name); err != nil {
+		return nil, err
+	}
+	if err := s.deleteMetadata(c.ctx, c.tx, pms.KindService, service.Name, ""); err != nil {
+		return nil, err
+	}
+	if err := s.insertMetadata(c.ctx, c.tx, pms.KindService, service.Name, "", service.Metadata); err != nil {
+		return nil, err
+	}
+	if err := s.deleteServiceAttributeRows(c.ctx, c.tx, service.Name); err != nil {
+		return nil, err
+	}
+	if err := s.insertServiceAttributes(c.ctx, c.tx, service.Name, service.Attributes); err != nil {
+		return nil, err
+	}
+	updated, err := s.loadService(c.ctx, c.tx, service.Name)
+	if err != nil {
+		return nil, err
+	}
+	c.serviceChanged(service.Name)
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpUpdate, Kind: pms.KindService, Service: updated})
+	return updated, nil
+}
+
+// touchService sets the revision of a service to the one of the change, any change in a service increases its revision
+func (s *Store) touchService(c *change, serviceName string) error {
+	if _, err := s.serviceRevision(c.ctx, c.tx, serviceName); err != nil {
+		return err
+	}
+	if _, err := s.exec(c.ctx, c.tx, "UPDATE services SET revision = ? WHERE name = ?", c.revision, serviceName); err != nil {
+		return err
+	}
+	c.serviceChanged(serviceName)
+	return nil
+}
+
+// DeleteService deletes a service with its policies and role policies
+func (s *Store) DeleteService(serviceName string) error {
+	return s.write(func(c *change) error {
+		return s.deleteService(c, serviceName, 0)
+	})
+}
+
+func (s *Store) deleteService(c *change, serviceName string, revision int64) error {
+	current, err := s.serviceRevision(c.ctx, c.tx, serviceName)
+	if err != nil {
+		return err
+	}
+	if err := checkRevision(revision, current, "service", serviceName); err != nil {
+		return err
+	}
+	if err := s.deleteServiceRows(c.ctx, c.tx, serviceName); err != nil {
+		return err
+	}
+	c.serviceChanged(serviceName)
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindService, ID: serviceName})
+	return nil
+}
+
+// DeleteServices deletes all services
+func (s *Store) DeleteServices() error {
+	return s.write(s.deleteServices)
+}
+
+func (s *Store) deleteServices(c *change) error {
+	var names []string
+	err := s.query(c.ctx, c.tx, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		names = append(names, name)
+		return nil
+	}, "SELECT name FROM services")
+	if err != nil {
+		return err
+	}
+	if err := s.deleteServiceRows(c.ctx, c.tx, ""); err != nil {
+		return err
+	}
+	for _, name := range names {
+		c.serviceChanged(name)
+		c.ops = append(c.ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindService, ID: name})
+	}
+	return nil
+}
+
+func (s *Store) Type() string {
+	return StoreType
+}
+
+// For policy manager
+func (s *Store) ListAllPolicies(serviceName string, filterStr string) ([]*pms.Policy, error) {
+	f, err := filter.Parse(pms.KindPolicy, filterStr)
+	if err != nil {
+		return nil, err
+	}
+	ret := []*pms.Policy{}
+	err = s.read(func(ctx context.Context, q querier) error {
+		if _, err := s.serviceRevision(ctx, q, serviceName); err != nil {
+			return err
+		}
+		policies, err := s.loadPolicies(ctx, q, serviceName, "", nil)
+		if err != nil {
+			return err
+		}
+		for _, policy := range policies[serviceName] {
+			if f.MatchPolicy(policy) {
+				ret = append(ret, policy)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// ListPolicies lists a page of policies in the order of creation, which are read in batches of seq ranges
+func (s *Store) ListPolicies(serviceName string, opts *pms.ListOptions) ([]*pms.Policy, string, error) {
+	f, limit, after, err := store.ParseListOptions(pms.KindPolicy, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	afterSeq, err := parseSeq(after, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	ret := []*pms.Policy{}
+	var token string
+	err = s.read(func(ctx context.Context, q querier) error {
+		if _, err := s.serviceRevision(ctx, q, serviceName); err != nil {
+			return err
+		}
+		for {
+			r, seqs, err := s.nextSeqRange(ctx, q, "policies", serviceName, afterSeq)
+			if err != nil || r == nil {
+				return err
+			}
+			policies, err := s.loadPolicies(ctx, q, serviceName, "", r)
+			if err != nil {
+				return err
+			}
+			for _, policy := range policies[serviceName] {
+				if !f.MatchPolicy(policy) {
+					continue
+				}
+				ret = append(ret, policy)
+				if len(ret) == limit {
+					token = store.ContinueToken(strconv.FormatInt(seqs[policy.ID], 10))
+					return nil
+				}
+			}
+			afterSeq = r.upTo
+		}
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return ret, token, nil
+}
+
+// nextSeqRange gets the range of the next batch of at most store.ListBatchSize entities of a service in table
+// whose seq is after the given one, and the seq of each entity keyed by ID. nil is returned if there are no more entities.
+func (s *Store) nextSeqRange(ctx context.Context, q querier, table, serviceName string, after int64) (*seqRange, map[string]int64, error) {
+	seqs := make(map[string]int64)
+	upTo := after
+	err := s.query(ctx, q, func(rows *sql.Rows) error {
+		var id string
+		var seq int64
+		if err := rows.Scan(&id, &seq); err != nil {
+			return err
+		}
+		seqs[id], upTo = seq, seq
+		return nil
+	}, "SELECT id, seq FROM "+table+" WHERE service_name = ? AND seq > ? ORDER BY seq LIMIT ?", serviceName, after, store.ListBatchSize)
+	if err != nil || len(seqs) == 0 {
+		return nil, nil, err
+	}
+	return &seqRange{table: table, after: after, upTo: upTo}, seqs, nil
+}
+
+// parseSeq parses the key of a continue token, which is the seq of the last entity of the previous page
+func parseSeq(key string, opts *pms.ListOptions) (int64, error) {
+	if len(key) == 0 {
+		return 0, nil
+	}
+	seq, err := strconv.ParseInt(key, 10, 64)
+	if err != nil || seq <= 0 {
+		return 0, store.InvalidContinueToken(opts.Continue)
+	}
+	return seq, nil
+}
+
+// GetPolicyCount gets the policy count of a service, or of all services if serviceName is empty
+func (s *Store) GetPolicyCount(serviceName string) (int64, error) {
+	return s.count("policies", serviceName)
+}
+
+// count gets the number of rows in table of a service, or of all services if serviceName is empty
+func (s *Store) count(table, serviceName string) (int64, error) {
+	var count int64
+	err := s.read(func(ctx context.Context, q querier) error {
+		if len(serviceName) != 0 {
+			if _, err := s.serviceRevision(ctx, q, serviceName); err != nil {
+				return err
+			}
+		}
+		cond, args := where(serviceName, "id", "")
+		var err error
+		count, err = s.queryInt64(ctx, q, "SELECT COUNT(*) FROM "+table+cond, args...)
+		return err
+	})
+	return count, err
+}
+
+func (s *Store) GetPolicy(serviceName string, id string) (*pms.Policy, error) {
+	var policy *pms.Policy
+	err := s.read(func(ctx context.Context, q querier) error {
+		if _, err := s.serviceRevision(ctx, q, serviceName); err != nil {
+			return err
+		}
+		var err error
+		policy, err = s.loadPolicy(ctx, q, serviceName, id)
+		return err
+	})
+	return policy, err
+}
+
+func (s *Store) DeletePolicy(serviceName string, id string) error {
+	return s.write(func(c *change) error {
+		return s.deletePolicy(c, serviceName, id, 0)
+	})
+}
+
+func (s *Store) deletePolicy(c *change, serviceName string, id string, revision int64) error {
+	if err := s.touchService(c, serviceName); err != nil {
+		return err
+	}
+	current, err := s.policyRevision(c, serviceName, id)
+	if err != nil {
+		return err
+	}
+	if err := checkRevision(revision, current, "policy", id); err != nil {
+		return err
+	}
+	if err := s.deletePolicyRows(c.ctx, c.tx, serviceName, id); err != nil {
+		return err
+	}
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindPolicy, ServiceName: serviceName, ID: id})
+	return nil
+}
+
+func (s *Store) DeletePolicies(serviceName string) error {
+	return s.write(func(c *change) error {
+		if err := s.touchService(c, serviceName); err != nil {
+			return err
+		}
+		var ids []string
+		err := s.query(c.ctx, c.tx, func(rows *sql.Rows) error {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+			return nil
+		}, "SELECT id FROM policies WHERE service_name = ? ORDER BY seq", serviceName)
+		if err != nil {
+			return err
+		}
+		if err := s.deletePolicyRows(c.ctx, c.tx, serviceName, ""); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			c.ops = append(c.ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindPolicy, ServiceName: serviceName, ID: id})
+		}
+		return nil
+	})
+}
+
+// policyRevision gets the revision of a policy, and returns EntityNotFound if the policy does not exist
+func (s *Store) policyRevision(c *change, serviceName, id string) (int64, error) {
+	var revision int64
+	err := c.tx.QueryRowContext(c.ctx, s.dialect.rebind("SELECT revision FROM policies WHERE service_name = ? AND id = ?"), serviceName, id).Scan(&revision)
+	if err == sql.ErrNoRows {
+		return 0, errors.Errorf(errors.EntityNotFound, "unable to find policy %q in service %q", id, serviceName)
+	} else if err != nil {
+		return 0, errors.Wrap(err, errors.StoreError, "failed to query sql store")
+	}
+	return revision, nil
+}
+
+func (s *Store) CreatePolicy(serviceName string, policy *pms.Policy) (*pms.Policy, error) {
+	var created *pms.Policy
+	err := s.write(func(c *change) error {
+		var err error
+		created, err = s.createPolicy(c, serviceName, policy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *Store) createPolicy(c *change, serviceName string, policy *pms.Policy) (*pms.Policy, error) {
+	if err := s.touchService(c, serviceName); err != nil {
+		return nil, err
+	}
+	seq, err := s.queryInt64(c.ctx, c.tx, "SELECT COALESCE(MAX(seq), 0) + 1 FROM policies WHERE service_name = ?", serviceName)
+	if err != nil {
+		return nil, err
+	}
+	dupPolicy := *policy
+	dupPolicy.ID = suid.New().String()
+	dupPolicy.Revision = c.revision
+	if err := s.insertPolicy(c.ctx, c.tx, serviceName, seq, &dupPolicy); err != nil {
+		return nil, err
+	}
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpCreate, Kind: pms.KindPolicy, ServiceName: serviceName, Policy: &dupPolicy})
+	return &dupPolicy, nil
+}
+
+func (s *Store) UpdatePolicy(serviceName string, policy *pms.Policy) (*pms.Policy, error) {
+	var updated *pms.Policy
+	err := s.write(func(c *change) error {
+		var err error
+		updated, err = s.updatePolicy(c, serviceName, policy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *Store) updatePolicy(c *change, serviceName string, policy *pms.Policy) (*pms.Policy, error) {
+	if err := s.touchService(c, serviceName); err != nil {
+		return nil, err
+	}
+	current, err := s.policyRevision(c, serviceName, policy.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRevision(policy.Revision, current, "policy", policy.ID); err != nil {
+		return nil, err
+	}
+	// the policy keeps its position in the service
+	seq, err := s.queryInt64(c.ctx, c.tx, "SELECT seq FROM policies WHERE service_name = ? AND id = ?", serviceName, policy.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.deletePolicyRows(c.ctx, c.tx, serviceName, policy.ID); err != nil {
+		return nil, err
+	}
+	dupPolicy := *policy
+	dupPolicy.Revision = c.revision
+	if err := s.insertPolicy(c.ctx, c.tx, serviceName, seq, &dupPolicy); err != nil {
+		return nil, err
+	}
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpUpdate, Kind: pms.KindPolicy, ServiceName: serviceName, Policy: &dupPolicy})
+	return &dupPolicy, nil
+}
+
+// For role policy manager
+func (s *Store) ListAllRolePolicies(serviceName string, filterStr string) ([]*pms.RolePolicy, error) {
+	f, err := filter.Parse(pms.KindRolePolicy, filterStr)
+	if err != nil {
+		return nil, err
+	}
+	ret := []*pms.RolePolicy{}
+	err = s.read(func(ctx context.Context, q querier) error {
+		if _, err := s.serviceRevision(ctx, q, serviceName); err != nil {
+			return err
+		}
+		rolePolicies, err := s.loadRolePolicies(ctx, q, serviceName, "", nil)
+		if err != nil {
+			return err
+		}
+		for _, rolePolicy := range rolePolicies[serviceName] {
+			if f.MatchRolePolicy(rolePolicy) {
+				ret = append(ret, rolePolicy)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// ListRolePolicies lists a page of role policies in the order of creation, which are read in batches of seq ranges
+func (s *Store) ListRolePolicies(serviceName string, opts *pms.ListOptions) ([]*pms.RolePolicy, string, error) {
+	f, limit, after, err := store.ParseListOptions(pms.KindRolePolicy, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	afterSeq, err := parseSeq(after, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	ret := []*pms.RolePolicy{}
+	var token string
+	err = s.read(func(ctx context.Context, q querier) error {
+		if _, err := s.serviceRevision(ctx, q, serviceName); err != nil {
+			return err
+		}
+		for {
+			r, seqs, err := s.nextSeqRange(ctx, q, "role_policies", serviceName, afterSeq)
+			if err != nil || r == nil {
+				return err
+			}
+			rolePolicies, err := s.loadRolePolicies(ctx, q, serviceName, "", r)
+			if err != nil {
+				return err
+			}
+			for _, rolePolicy := range rolePolicies[serviceName] {
+				if !f.MatchRolePolicy(rolePolicy) {
+					continue
+				}
+				ret = append(ret, rolePolicy)
+				if len(ret) == limit {
+					token = store.ContinueToken(strconv.FormatInt(seqs[rolePolicy.ID], 10))
+					return nil
+				}
+			}
+			afterSeq = r.upTo
+		}
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return ret, token, nil
+}
+
+// GetRolePolicyCount gets the role policy count of a service, or of all services if serviceName is empty
+func (s *Store) GetRolePolicyCount(serviceName string) (int64, error) {
+	return s.count("role_policies", serviceName)
+}
+
+func (s *Store) GetRolePolicy(serviceName string, id string) (*pms.RolePolicy, error) {
+	var rolePolicy *pms.RolePolicy
+	err := s.read(func(ctx context.Context, q querier) error {
+		if _, err := s.serviceRevision(ctx, q, serviceName); err != nil {
+			return err
+		}
+		var err error
+		rolePolicy, err = s.loadRolePolicy(ctx, q, serviceName, id)
+		return err
+	})
+	return rolePolicy, err
+}
+
+func (s *Store) DeleteRolePolicy(serviceName string, id string) error {
+	return s.write(func(c *change) error {
+		return s.deleteRolePolicy(c, serviceName, id, 0)
+	})
+}
+
+func (s *Store) deleteRolePolicy(c *change, serviceName string, id string, revision int64) error {
+	if err := s.touchService(c, serviceName); err != nil {
+		return err
+	}
+	current, err := s.rolePolicyRevision(c, serviceName, id)
+	if err != nil {
+		return err
+	}
+	if err := checkRevision(revision, current, "role policy", id); err != nil {
+		return err
+	}
+	if err := s.deleteRolePolicyRows(c.ctx, c.tx, serviceName, id); err != nil {
+		return err
+	}
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindRolePolicy, ServiceName: serviceName, ID: id})
+	return nil
+}
+
+func (s *Store) DeleteRolePolicies(serviceName string) error {
+	return s.write(func(c *change) error {
+		if err := s.touchService(c, serviceName); err != nil {
+			return err
+		}
+		var ids []string
+		err := s.query(c.ctx, c.tx, func(rows *sql.Rows) error {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+			return nil
+		}, "SELECT id FROM role_policies WHERE service_name = ? ORDER BY seq", serviceName)
+		if err != nil {
+			return err
+		}
+		if err := s.deleteRolePolicyRows(c.ctx, c.tx, serviceName, ""); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			c.ops = append(c.ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindRolePolicy, ServiceName: serviceName, ID: id})
+		}
+		return nil
+	})
+}
+
+// rolePolicyRevision gets the revision of a role policy, and returns EntityNotFound if the role policy does not exist
+func (s *Store) rolePolicyRevision(c *change, serviceName, id string) (int64, error) {
+	var revision int64
+	err := c.tx.QueryRowContext(c.ctx, s.dialect.rebind("SELECT revision FROM role_policies WHERE service_name = ? AND id = ?"), serviceName, id).Scan(&revision)
+	if err == sql.ErrNoRows {
+		return 0, errors.Errorf(errors.EntityNotFound, "unable to find role policy %q in service %q", id, serviceName)
+	} else if err != nil {
+		return 0, errors.Wrap(err, errors.StoreError, "failed to query sql store")
+	}
+	return revision, nil
+}
+
+func (s *Store) CreateRolePolicy(serviceName string, rolePolicy *pms.RolePolicy) (*pms.RolePolicy, error) {
+	var created *pms.RolePolicy
+	err := s.write(func(c *change) error {
+		var err error
+		created, err = s.createRolePolicy(c, serviceName, rolePolicy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *Store) createRolePolicy(c *change, serviceName string, rolePolicy *pms.RolePolicy) (*pms.RolePolicy, error) {
+	if err := s.touchService(c, serviceName); err != nil {
+		return nil, err
+	}
+	seq, err := s.queryInt64(c.ctx, c.tx, "SELECT COALESCE(MAX(seq), 0) + 1 FROM role_policies WHERE service_name = ?", serviceName)
+	if err != nil {
+		return nil, err
+	}
+	dupRolePolicy := *rolePolicy
+	dupRolePolicy.ID = suid.New().String()
+	dupRolePolicy.Revision = c.revision
+	if err := s.insertRolePolicy(c.ctx, c.tx, serviceName, seq, &dupRolePolicy); err != nil {
+		return nil, err
+	}
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpCreate, Kind: pms.KindRolePolicy, ServiceName: serviceName, RolePolicy: &dupRolePolicy})
+	return &dupRolePolicy, nil
+}
+
+func (s *Store) UpdateRolePolicy(serviceName string, rolePolicy *pms.RolePolicy) (*pms.RolePolicy, error) {
+	var updated *pms.RolePolicy
+	err := s.write(func(c *change) error {
+		var err error
+		updated, err = s.updateRolePolicy(c, serviceName, rolePolicy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *Store) updateRolePolicy(c *change, serviceName string, rolePolicy *pms.RolePolicy) (*pms.RolePolicy, error) {
+	if err := s.touchService(c, serviceName); err != nil {
+		return nil, err
+	}
+	current, err := s.rolePolicyRevision(c, serviceName, rolePolicy.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRevision(rolePolicy.Revision, current, "role policy", rolePolicy.ID); err != nil {
+		return nil, err
+	}
+	// the role policy keeps its position in the service
+	seq, err := s.queryInt64(c.ctx, c.tx, "SELECT seq FROM role_policies WHERE service_name = ? AND id = ?", serviceName, rolePolicy.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.deleteRolePolicyRows(c.ctx, c.tx, serviceName, rolePolicy.ID); err != nil {
+		return nil, err
+	}
+	dupRolePolicy := *rolePolicy
+	dupRolePolicy.Revision = c.revision
+	if err := s.insertRolePolicy(c.ctx, c.tx, serviceName, seq, &dupRolePolicy); err != nil {
+		return nil, err
+	}
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpUpdate, Kind: pms.KindRolePolicy, ServiceName: serviceName, RolePolicy: &dupRolePolicy})
+	return &dupRolePolicy, nil
+}
+
+func validateFunc(function *pms.Function) error {
+	if function.Name == "" || function.FuncURL == "" {
+		return errors.New(errors.InvalidRequest, "\"name\" and \"funcURL\" in function definition can not be empty")
+	}
+	return nil
+}
+
+// functionRevision gets the revision of a function, and returns EntityNotFound if the function does not exist
+func (s *Store) functionRevision(c *change, name string) (int64, error) {
+	var revision int64
+	err := c.tx.QueryRowContext(c.ctx, s.dialect.rebind("SELECT revision FROM functions WHERE name = ?"), name).Scan(&revision)
+	if err == sql.ErrNoRows {
+		return 0, errors.Errorf(errors.EntityNotFound, "function %q is not found", name)
+	} else if err != nil {
+		return 0, errors.Wrap(err, errors.StoreError, "failed to query sql store")
+	}
+	return revision, nil
+}
+
+func (s *Store) CreateFunction(function *pms.Function) (*pms.Function, error) {
+	var created *pms.Function
+	err := s.write(func(c *change) error {
+		var err error
+		created, err = s.createFunction(c, function)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *Store) createFunction(c *change, function *pms.Function) (*pms.Function, error) {
+	if err := validateFunc(function); err != nil {
+		return nil, err
+	}
+	if _, err := s.functionRevision(c, function.Name); err == nil {
+		return nil, errors.Errorf(errors.EntityAlreadyExists, "function %q already exists", function.Name)
+	} else if errors.Code(err) != errors.EntityNotFound {
+		return nil, err
+	}
+	dupFunction := *function
+	dupFunction.Revision = c.revision
+	if err := s.insertFunction(c.ctx, c.tx, &dupFunction); err != nil {
+		return nil, err
+	}
+	c.functionChanged(function.Name)
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpCreate, Kind: pms.KindFunction, Function: &dupFunction})
+	return &dupFunction, nil
+}
+
+func (s *Store) UpdateFunction(function *pms.Function) (*pms.Function, error) {
+	var updated *pms.Function
+	err := s.write(func(c *change) error {
+		var err error
+		updated, err = s.updateFunction(c, function)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *Store) updateFunction(c *change, function *pms.Function) (*pms.Function, error) {
+	if err := validateFunc(function); err != nil {
+		return nil, err
+	}
+	current, err := s.functionRevision(c, function.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRevision(function.Revision, current, "function", function.Name); err != nil {
+		return nil, err
+	}
+	if err := s.deleteFunctionRows(c.ctx, c.tx, function.Name); err != nil {
+		return nil, err
+	}
+	dupFunction := *function
+	dupFunction.Revision = c.revision
+	if err := s.insertFunction(c.ctx, c.tx, &dupFunction); err != nil {
+		return nil, err
+	}
+	c.functionChanged(function.Name)
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpUpdate, Kind: pms.KindFunction, Function: &dupFunction})
+	return &dupFunction, nil
+}
+
+func (s *Store) DeleteFunction(funcName string) error {
+	return s.write(func(c *change) error {
+		return s.deleteFunction(c, funcName, 0)
+	})
+}
+
+func (s *Store) deleteFunction(c *change, funcName string, revision int64) error {
+	current, err := s.functionRevision(c, funcName)
+	if err != nil {
+		return err
+	}
+	if err := checkRevision(revision, current, "function", funcName); err != nil {
+		return err
+	}
+	if err := s.deleteFunctionRows(c.ctx, c.tx, funcName); err != nil {
+		return err
+	}
+	c.functionChanged(funcName)
+	c.ops = append(c.ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindFunction, ID: funcName})
+	return nil
+}
+
+func (s *Store) DeleteFunctions() error {
+	return s.write(s.deleteFunctions)
+}
+
+func (s *Store) deleteFunctions(c *change) error {
+	var names []string
+	err := s.query(c.ctx, c.tx, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		names = append(names, name)
+		return nil
+	}, "SELECT name FROM functions")
+	if err != nil {
+		return err
+	}
+	if err := s.deleteFunctionRows(c.ctx, c.tx, ""); err != nil {
+		return err
+	}
+	for _, name := range names {
+		c.functionChanged(name)
+		c.ops = append(c.ops, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindFunction, ID: name})
+	}
+	return nil
+}
+
+func (s *Store) GetFunction(funcName string) (*pms.Function, error) {
+	var function *pms.Function
+	err := s.read(func(ctx context.Context, q querier) error {
+		var err error
+		function, err = s.loadFunction(ctx, q, funcName)
+		return err
+	})
+	return function, err
+}
+
+func (s *Store) ListAllFunctions(filterStr string) ([]*pms.Function, error) {
+	f, err := filter.Parse(pms.KindFunction, filterStr)
+	if err != nil {
+		return nil, err
+	}
+	ret := []*pms.Function{}
+	err = s.read(func(ctx context.Context, q querier) error {
+		functions, err := s.loadFunctions(ctx, q, "")
+		if err != nil {
+			return err
+		}
+		for _, function := range functions {
+			if f.MatchFunction(function) {
+				ret = append(ret, function)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (s *Store) GetFunctionCount() (int64, error) {
+	var count int64
+	err := s.read(func(ctx context.Context, q querier) error {
+		var err error
+		count, err = s.queryInt64(ctx, q, "SELECT COUNT(*) FROM functions")
+		return err
+	})
+	return count, err
+}
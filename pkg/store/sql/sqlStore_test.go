@@ -0,0 +1,337 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package sql
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+)
+
+// newTestStore opens a sql store on a SQLite database in a temporary directory
+func newTestStore(t *testing.T) (pms.PolicyStoreManager, func()) {
+	dir, err := ioutil.TempDir("", "sqlstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps, err := store.NewStore(StoreType, map[string]interface{}{
+		SQLDriverKey:       DriverSQLite,
+		SQLDataSourceKey:   "file:" + filepath.Join(dir, "speedle.db") + "?_busy_timeout=5000",
+		SQLPollIntervalKey: "50ms",
+	})
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal("fail to new sql store:", err)
+	}
+	return ps, func() {
+		ps.(*Store).db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestWriteReadPolicyStore(t *testing.T) {
+	ps, cleanup := newTestStore(t)
+	defer cleanup()
+
+	policy := pms.Policy{
+		ID:     "p1",
+		Name:   "policy1",
+		Effect: "grant",
+		Permissions: []*pms.Permission{
+			{Resource: "/node1", Actions: []string{"get", "create"}},
+			{ResourceExpression: "/node2/.*", Actions: []string{"delete"}},
+		},
+		Principals: [][]string{{"user:Alice", "group:Admin"}, {"user:Bill"}},
+		Condition:  "age > 18",
+		Priority:   3,
+		Metadata:   map[string]string{"createby": "Alice"},
+	}
+	rolePolicy := pms.RolePolicy{
+		ID:                  "rp1",
+		Name:                "rolePolicy1",
+		Effect:              "grant",
+		Roles:               []string{"role1", "role2"},
+		Principals:          []string{"user:Alice"},
+		Resources:           []string{"/node1"},
+		ResourceExpressions: []string{"/node2/.*"},
+		Condition:           "age > 18",
+	}
+	function := pms.Function{Name: "func1", FuncURL: "https://localhost:23456/func1", ResultCachable: true, ResultTTL: 60, Metadata: map[string]string{"createby": "Alice"}}
+	in := pms.PolicyStore{
+		Services: []*pms.Service{
+			{Name: "service1", Type: pms.TypeApplication, CombiningAlgorithm: pms.FirstApplicable, DefaultEffect: pms.Grant,
+				Policies: []*pms.Policy{&policy}, RolePolicies: []*pms.RolePolicy{&rolePolicy}, Metadata: map[string]string{"owner": "Alice"}},
+			{Name: "service2", Type: pms.TypeK8SCluster},
+		},
+		Functions: []*pms.Function{&function},
+	}
+	if err := ps.WritePolicyStore(&in); err != nil {
+		t.Fatal("fail to write policy store:", err)
+	}
+	out, err := ps.ReadPolicyStore()
+	if err != nil {
+		t.Fatal("fail to read policy store:", err)
+	}
+	if len(out.Services) != 2 || len(out.Functions) != 1 {
+		t.Fatalf("expected 2 services and 1 function, but got %d and %d", len(out.Services), len(out.Functions))
+	}
+	service := out.Services[0]
+	if service.Name != "service1" || service.CombiningAlgorithm != pms.FirstApplicable || service.DefaultEffect != pms.Grant ||
+		service.Metadata["owner"] != "Alice" || service.Revision == 0 {
+		t.Errorf("service is not kept: %+v", service)
+	}
+	if len(service.Policies) != 1 || len(service.RolePolicies) != 1 {
+		t.Fatalf("policies of service are not kept: %+v", service)
+	}
+	policy.Revision = service.Policies[0].Revision
+	if !reflect.DeepEqual(service.Policies[0], &policy) {
+		t.Errorf("expected policy %+v, but got %+v", policy, *service.Policies[0])
+	}
+	rolePolicy.Revision = service.RolePolicies[0].Revision
+	if !reflect.DeepEqual(service.RolePolicies[0], &rolePolicy) {
+		t.Errorf("expected role policy %+v, but got %+v", rolePolicy, *service.RolePolicies[0])
+	}
+	function.Revision = out.Functions[0].Revision
+	if !reflect.DeepEqual(out.Functions[0], &function) {
+		t.Errorf("expected function %+v, but got %+v", function, *out.Functions[0])
+	}
+
+	// the whole store is replaced
+	if err := ps.WritePolicyStore(&pms.PolicyStore{Services: []*pms.Service{{Name: "service3"}}}); err != nil {
+		t.Fatal("fail to write policy store:", err)
+	}
+	names, err := ps.GetServiceNames()
+	if err != nil || !reflect.DeepEqual(names, []string{"service3"}) {
+		t.Errorf("expected service3 only, but got %v, %v", names, err)
+	}
+	if count, err := ps.GetFunctionCount(); err != nil || count != 0 {
+		t.Errorf("expected no function, but got %d, %v", count, err)
+	}
+}
+
+func TestManagePolicies(t *testing.T) {
+	ps, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if err := ps.CreateService(&pms.Service{Name: "service1", Type: pms.TypeApplication}); err != nil {
+		t.Fatal("fail to create service:", err)
+	}
+	if err := ps.CreateService(&pms.Service{Name: "service1"}); errors.Code(err) != errors.EntityAlreadyExists {
+		t.Fatal("should fail to create an existing service:", err)
+	}
+	if _, err := ps.CreatePolicy("nonexistService", &pms.Policy{Name: "policy1"}); errors.Code(err) != errors.EntityNotFound {
+		t.Fatal("should fail to create policy in a non-existing service:", err)
+	}
+	var ids []string
+	for _, name := range []string{"policy3", "policy1", "policy2"} {
+		policy, err := ps.CreatePolicy("service1", &pms.Policy{Name: name, Effect: "grant", Principals: [][]string{{"user:Alice"}}})
+		if err != nil {
+			t.Fatal("fail to create policy:", err)
+		}
+		ids = append(ids, policy.ID)
+	}
+	if _, err := ps.CreateRolePolicy("service1", &pms.RolePolicy{Name: "rolePolicy1", Effect: "grant", Roles: []string{"role1"}}); err != nil {
+		t.Fatal("fail to create role policy:", err)
+	}
+
+	// policies are listed in the order of creation, and an update keeps the position
+	policy, err := ps.GetPolicy("service1", ids[0])
+	if err != nil {
+		t.Fatal("fail to get policy:", err)
+	}
+	policy.Effect = "deny"
+	if _, err := ps.UpdatePolicy("service1", policy); err != nil {
+		t.Fatal("fail to update policy:", err)
+	}
+	policies, err := ps.ListAllPolicies("service1", "")
+	if err != nil {
+		t.Fatal("fail to list policies:", err)
+	}
+	if len(policies) != 3 || policies[0].ID != ids[0] || policies[0].Effect != "deny" || policies[2].ID != ids[2] {
+		t.Errorf("policies are not in the order of creation: %v", policies)
+	}
+	if policies, err := ps.ListAllPolicies("service1", "name sw policy1"); err != nil || len(policies) != 1 || policies[0].ID != ids[1] {
+		t.Errorf("expected policy1 to be filtered, but got %v, %v", policies, err)
+	}
+	if _, err := ps.ListAllPolicies("service1", "owner eq alice"); errors.Code(err) != errors.InvalidRequest {
+		t.Error("should fail to list policies with an invalid filter:", err)
+	}
+
+	counts, err := ps.GetPolicyAndRolePolicyCounts()
+	if err != nil || counts["service1"].PolicyCount != 3 || counts["service1"].RolePolicyCount != 1 {
+		t.Errorf("expected 3 policies and 1 role policy, but got %v, %v", counts["service1"], err)
+	}
+	if count, err := ps.GetPolicyCount(""); err != nil || count != 3 {
+		t.Errorf("expected 3 policies, but got %d, %v", count, err)
+	}
+
+	if err := ps.DeletePolicy("service1", ids[1]); err != nil {
+		t.Fatal("fail to delete policy:", err)
+	}
+	if _, err := ps.GetPolicy("service1", ids[1]); errors.Code(err) != errors.EntityNotFound {
+		t.Error("policy should be deleted:", err)
+	}
+	if err := ps.DeletePolicy("service1", ids[1]); errors.Code(err) != errors.EntityNotFound {
+		t.Error("should fail to delete a non-existing policy:", err)
+	}
+	if err := ps.DeleteRolePolicies("service1"); err != nil {
+		t.Fatal("fail to delete role policies:", err)
+	}
+	if count, err := ps.GetRolePolicyCount("service1"); err != nil || count != 0 {
+		t.Errorf("expected no role policy, but got %d, %v", count, err)
+	}
+
+	// policies are deleted with the service
+	if err := ps.DeleteService("service1"); err != nil {
+		t.Fatal("fail to delete service:", err)
+	}
+	if err := ps.CreateService(&pms.Service{Name: "service1"}); err != nil {
+		t.Fatal("fail to create service:", err)
+	}
+	if count, err := ps.GetPolicyCount("service1"); err != nil || count != 0 {
+		t.Errorf("expected no policy, but got %d, %v", count, err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ps, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if err := ps.CreateService(&pms.Service{Name: "existingService"}); err != nil {
+		t.Fatal("fail to create service:", err)
+	}
+	ch, err := ps.Watch()
+	if err != nil {
+		t.Fatal("fail to watch:", err)
+	}
+	if err := ps.CreateService(&pms.Service{Name: "service1", Type: pms.TypeApplication}); err != nil {
+		t.Fatal("fail to create service:", err)
+	}
+	if _, err := ps.CreatePolicy("service1", &pms.Policy{Name: "policy1", Effect: "grant"}); err != nil {
+		t.Fatal("fail to create policy:", err)
+	}
+	_, err = ps.ExecuteTransaction([]*pms.Operation{
+		{Op: pms.OpCreate, Kind: pms.KindFunction, Function: &pms.Function{Name: "func1", FuncURL: "https://localhost:23456/func1"}},
+		{Op: pms.OpDelete, Kind: pms.KindService, ID: "existingService"},
+	})
+	if err != nil {
+		t.Fatal("fail to execute transaction:", err)
+	}
+
+	var events []pms.StoreChangeEvent
+	timeout := time.After(5 * time.Second)
+	for len(events) < 3 {
+		select {
+		case e := <-ch:
+			events = append(events, e)
+		case <-timeout:
+			t.Fatalf("expected 3 events, but got %d", len(events))
+		}
+	}
+	ps.StopWatch()
+	for range ch {
+	}
+
+	if events[0].Type != pms.SERVICE_ADD || events[1].Type != pms.SERVICE_ADD || events[2].Type != pms.BATCH {
+		t.Fatalf("unexpected events: %v", events)
+	}
+	if service := events[1].Content.(*pms.Service); len(service.Policies) != 1 {
+		t.Errorf("the service should be sent with its policies: %+v", service)
+	}
+	batch := events[2].Content.([]pms.StoreChangeEvent)
+	if len(batch) != 2 || batch[0].Type+batch[1].Type != pms.SERVICE_DELETE+pms.FUNCTION_ADD {
+		t.Errorf("the changes of a transaction should be sent together: %v", batch)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqlstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dataSource := "file:" + filepath.Join(dir, "speedle.db")
+	s, err := NewStore(DriverSQLite, dataSource, DefaultPollInterval)
+	if err != nil {
+		t.Fatal("fail to new sql store:", err)
+	}
+	if err := s.CreateService(&pms.Service{Name: "service1"}); err != nil {
+		t.Fatal("fail to create service:", err)
+	}
+	s.db.Close()
+
+	// the migrations applied are not applied again
+	s, err = NewStore(DriverSQLite, dataSource, DefaultPollInterval)
+	if err != nil {
+		t.Fatal("fail to open the sql store again:", err)
+	}
+	defer s.db.Close()
+	if _, err := s.GetService("service1"); err != nil {
+		t.Error("service should be kept:", err)
+	}
+	if _, err := NewStore("oracle", dataSource, DefaultPollInterval); errors.Code(err) != errors.ConfigError {
+		t.Error("should fail to open a sql store with an unsupported driver:", err)
+	}
+}
+
+func TestRebind(t *testing.T) {
+	query := "SELECT id FROM policies WHERE service_name = ? AND id = ?"
+	if got := dialects[DriverPostgres].rebind(query); got != "SELECT id FROM policies WHERE service_name = $1 AND id = $2" {
+		t.Errorf("unexpected query for postgres: %s", got)
+	}
+	if got := dialects[DriverMySQL].rebind(query); got != query {
+		t.Errorf("unexpected query for mysql: %s", got)
+	}
+}
+
+func TestTenantStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqlstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config := map[string]interface{}{
+		SQLDriverKey:     DriverSQLite,
+		SQLDataSourceKey: "file:" + filepath.Join(dir, "speedle.db") + "?_busy_timeout=5000",
+	}
+	ps, err := store.NewTenantStore(StoreType, config, store.DefaultTenant)
+	if err != nil {
+		t.Fatal("fail to new sql store:", err)
+	}
+	defer ps.(*Store).db.Close()
+	tenantStore, err := store.NewTenantStore(StoreType, config, "acme")
+	if err != nil {
+		t.Fatal("fail to new sql store of tenant:", err)
+	}
+	defer tenantStore.(*Store).db.Close()
+
+	if err := tenantStore.CreateService(&pms.Service{Name: "service1"}); err != nil {
+		t.Fatal("fail to create service:", err)
+	}
+	if _, err := ps.GetService("service1"); errors.Code(err) != errors.EntityNotFound {
+		t.Error("service of tenant should not be found in the default tenant:", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "acme", "speedle.db")); err != nil {
+		t.Error("database of tenant should be next to the one of the default tenant:", err)
+	}
+
+	memory := map[string]interface{}{SQLDriverKey: DriverSQLite, SQLDataSourceKey: "file::memory:?cache=shared"}
+	if _, err := store.TenantStoreConfig(StoreType, memory, "acme"); errors.Code(err) != errors.ConfigError {
+		t.Error("tenants should not be supported by in-memory database:", err)
+	}
+	if _, err := store.TenantStoreConfig(StoreType, config, "Acme"); errors.Code(err) != errors.ConfigError {
+		t.Error("tenant with upper case letters should be invalid:", err)
+	}
+	postgres := map[string]interface{}{SQLDriverKey: DriverPostgres, SQLDataSourceKey: "postgres://localhost/speedle"}
+	if tenantConfig, err := store.TenantStoreConfig(StoreType, postgres, "acme"); err != nil || tenantConfig[SQLSchemaKey] != "speedle_acme" {
+		t.Errorf("unexpected config of postgres tenant %v: %v", tenantConfig, err)
+	}
+}
@@ -0,0 +1,233 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package sql
+
+import (
+	"database/sql"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+)
+
+const (
+	StoreType = "sql"
+
+	//Following are keys of sql store properties
+	SQLDriverKey       = "SQLDriver"
+	SQLDataSourceKey   = "SQLDataSource"
+	SQLPollIntervalKey = "SQLPollInterval"
+	SQLSchemaKey       = "SQLSchema"
+
+	SQLDriverFlagName       = "sqlstore-driver"
+	SQLDataSourceFlagName   = "sqlstore-datasource"
+	SQLPollIntervalFlagName = "sqlstore-pollinterval"
+	SQLSchemaFlagName       = "sqlstore-schema"
+
+	// supported drivers
+	DriverSQLite   = "sqlite3"
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+
+	//default property values
+	DefaultDriver       = DriverSQLite
+	DefaultDataSource   = "file:/tmp/speedle-test-sql-store.db?_busy_timeout=5000"
+	DefaultPollInterval = time.Second
+	// DefaultTenantSchema is the prefix of the schemas of tenants in PostgreSQL if no schema is configured
+	DefaultTenantSchema = "speedle"
+)
+
+var schemaPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+type SQLStoreBuilder struct{}
+
+func (sb SQLStoreBuilder) NewStore(config map[string]interface{}) (pms.PolicyStoreManager, error) {
+	driver, ok := config[SQLDriverKey].(string)
+	if !ok || len(driver) == 0 {
+		driver = DefaultDriver
+	}
+	dataSource, ok := config[SQLDataSourceKey].(string)
+	if !ok || len(dataSource) == 0 {
+		dataSource = DefaultDataSource
+	}
+	pollInterval := DefaultPollInterval
+	if value, ok := config[SQLPollIntervalKey].(string); ok && len(value) != 0 {
+		var err error
+		if pollInterval, err = time.ParseDuration(value); err != nil || pollInterval <= 0 {
+			return nil, errors.Errorf(errors.ConfigError, "invalid poll interval %q of sql store", value)
+		}
+	}
+	if schema, ok := config[SQLSchemaKey].(string); ok && len(schema) != 0 {
+		var err error
+		if dataSource, err = useSchema(driver, dataSource, schema); err != nil {
+			return nil, err
+		}
+	}
+	return NewStore(driver, dataSource, pollInterval)
+}
+
+// TenantStoreConfig keeps the policies of a tenant in a database file of the same name in the directory of the tenant
+// for SQLite, in the schema named after the configured one and the tenant for PostgreSQL, e.g. speedle_acme, and
+// in the database named after the one of the data source and the tenant for MySQL
+func (sb SQLStoreBuilder) TenantStoreConfig(config map[string]interface{}, tenant string) (map[string]interface{}, error) {
+	driver, ok := config[SQLDriverKey].(string)
+	if !ok || len(driver) == 0 {
+		driver = DefaultDriver
+	}
+	dataSource, ok := config[SQLDataSourceKey].(string)
+	if !ok || len(dataSource) == 0 {
+		dataSource = DefaultDataSource
+	}
+	schema, _ := config[SQLSchemaKey].(string)
+
+	switch driver {
+	case DriverSQLite:
+		tenantDataSource, err := sqliteTenantDataSource(dataSource, tenant)
+		if err != nil {
+			return nil, err
+		}
+		config[SQLDataSourceKey] = tenantDataSource
+		return config, nil
+	case DriverPostgres:
+		if len(schema) == 0 {
+			schema = DefaultTenantSchema
+		}
+	case DriverMySQL:
+		if len(schema) == 0 {
+			c, err := mysql.ParseDSN(dataSource)
+			if err != nil {
+				return nil, errors.Wrap(err, errors.ConfigError, "invalid data source of mysql")
+			}
+			schema = c.DBName
+		}
+	default:
+		return nil, errors.Errorf(errors.ConfigError, "unsupported sql driver %q", driver)
+	}
+	config[SQLSchemaKey] = schema + "_" + tenant
+	return config, nil
+}
+
+// sqliteTenantDataSource returns the data source of a tenant, whose database file is in the directory of the tenant
+func sqliteTenantDataSource(dataSource, tenant string) (string, error) {
+	path, query := dataSource, ""
+	if i := strings.Index(dataSource, "?"); i >= 0 {
+		path, query = dataSource[:i], dataSource[i:]
+	}
+	prefix := ""
+	if strings.HasPrefix(path, "file:") {
+		prefix, path = "file:", strings.TrimPrefix(path, "file:")
+	}
+	if len(path) == 0 || strings.Contains(path, ":memory:") || strings.Contains(query, "mode=memory") {
+		return "", errors.New(errors.ConfigError, "tenants are not supported by in-memory sqlite database")
+	}
+	tenantPath, err := store.TenantPath(path, tenant)
+	if err != nil {
+		return "", err
+	}
+	return prefix + tenantPath + query, nil
+}
+
+// useSchema creates the schema of PostgreSQL or the database of MySQL if it does not exist, and returns the data
+// source connecting to it
+func useSchema(driver, dataSource, schema string) (string, error) {
+	if !schemaPattern.MatchString(schema) {
+		return "", errors.Errorf(errors.ConfigError, "invalid schema %q of sql store", schema)
+	}
+	switch driver {
+	case DriverPostgres:
+		if err := execOnce(driver, dataSource, `CREATE SCHEMA IF NOT EXISTS "`+schema+`"`); err != nil {
+			return "", err
+		}
+		// lib/pq passes the unknown parameters to the server as run-time parameters
+		if strings.HasPrefix(dataSource, "postgres://") || strings.HasPrefix(dataSource, "postgresql://") {
+			u, err := url.Parse(dataSource)
+			if err != nil {
+				return "", errors.Wrap(err, errors.ConfigError, "invalid data source of postgres")
+			}
+			q := u.Query()
+			q.Set("search_path", schema)
+			u.RawQuery = q.Encode()
+			return u.String(), nil
+		}
+		return dataSource + " search_path=" + schema, nil
+	case DriverMySQL:
+		c, err := mysql.ParseDSN(dataSource)
+		if err != nil {
+			return "", errors.Wrap(err, errors.ConfigError, "invalid data source of mysql")
+		}
+		c.DBName = ""
+		if err := execOnce(driver, c.FormatDSN(), "CREATE DATABASE IF NOT EXISTS `"+schema+"`"); err != nil {
+			return "", err
+		}
+		c.DBName = schema
+		return c.FormatDSN(), nil
+	default:
+		return "", errors.Errorf(errors.ConfigError, "schema is not supported by sql driver %q", driver)
+	}
+}
+
+// execOnce executes a statement on a connection which is closed after
+func execOnce(driver, dataSource, statement string) error {
+	db, err := sql.Open(driver, dataSource)
+	if err != nil {
+		return errors.Wrapf(err, errors.StoreError, "unable to open %s database", driver)
+	}
+	defer db.Close()
+	if _, err := db.Exec(statement); err != nil {
+		return errors.Wrapf(err, errors.StoreError, "failed to execute %q", statement)
+	}
+	return nil
+}
+
+func (sb SQLStoreBuilder) GetStoreParams() map[string]string {
+	return map[string]string{
+		SQLDriverFlagName:       SQLDriverKey,
+		SQLDataSourceFlagName:   SQLDataSourceKey,
+		SQLPollIntervalFlagName: SQLPollIntervalKey,
+		SQLSchemaFlagName:       SQLSchemaKey,
+	}
+}
+
+// NewStore opens the database and migrates its schema to the latest version
+func NewStore(driver, dataSource string, pollInterval time.Duration) (*Store, error) {
+	d, err := dialectOf(driver)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(driver, dataSource)
+	if err != nil {
+		return nil, errors.Wrapf(err, errors.StoreError, "unable to open %s database", driver)
+	}
+	if driver == DriverSQLite {
+		// SQLite allows one writer at a time, sharing a single connection avoids busy errors,
+		// and keeps in-memory databases alive
+		db.SetMaxOpenConns(1)
+	}
+	s := &Store{db: db, dialect: d, pollInterval: pollInterval}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	log.Infof("sql store is opened with driver %s", driver)
+	return s, nil
+}
+
+func init() {
+	pflag.String(SQLDriverFlagName, DefaultDriver, "Store config: driver of sql store, which is sqlite3, postgres or mysql.")
+	pflag.String(SQLDataSourceFlagName, DefaultDataSource, "Store config: data source name of sql store.")
+	pflag.String(SQLPollIntervalFlagName, DefaultPollInterval.String(), "Store config: interval to poll the changes of sql store when watching.")
+	pflag.String(SQLSchemaFlagName, "", "Store config: schema of PostgreSQL or database of MySQL to keep the policies in, which is created if it does not exist.")
+
+	store.Register(StoreType, SQLStoreBuilder{})
+}
@@ -0,0 +1,112 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package sql
+
+import (
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+)
+
+// ExecuteTransaction applies all the operations in a database transaction, which takes one revision.
+// Nothing is written if any operation fails.
+func (s *Store) ExecuteTransaction(ops []*pms.Operation) ([]*pms.Operation, error) {
+	var results []*pms.Operation
+	err := s.write(func(c *change) error {
+		results = make([]*pms.Operation, 0, len(ops))
+		for i, op := range ops {
+			result, err := s.applyOperation(c, op)
+			if err != nil {
+				return errors.Wrapf(err, errors.Code(err), "operation %d (%s %s) of the transaction failed", i, op.Op, op.Kind)
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s *Store) applyOperation(c *change, op *pms.Operation) (*pms.Operation, error) {
+	if op.Op != pms.OpCreate && op.Op != pms.OpUpdate && op.Op != pms.OpDelete {
+		return nil, errors.Errorf(errors.InvalidRequest, "unknown operation %q", op.Op)
+	}
+	result := pms.Operation{Op: op.Op, Kind: op.Kind, ServiceName: op.ServiceName, ID: op.ID, ChangedBy: op.ChangedBy}
+	if op.Op == pms.OpDelete {
+		if err := s.applyDelete(c, op); err != nil {
+			return nil, err
+		}
+		// the deletion is the last change made, who deletes the entity is kept in its history
+		c.ops[len(c.ops)-1].ChangedBy = op.ChangedBy
+		return &result, nil
+	}
+	var err error
+	switch op.Kind {
+	case pms.KindService:
+		if op.Service == nil {
+			return nil, errors.New(errors.InvalidRequest, "service is not specified")
+		}
+		result.ID = op.Service.Name
+		if op.Op == pms.OpCreate {
+			result.Service, err = s.createService(c, op.Service)
+		} else {
+			result.Service, err = s.updateService(c, op.Service)
+		}
+	case pms.KindPolicy:
+		if op.Policy == nil {
+			return nil, errors.New(errors.InvalidRequest, "policy is not specified")
+		}
+		if op.Op == pms.OpCreate {
+			result.Policy, err = s.createPolicy(c, op.ServiceName, op.Policy)
+		} else {
+			result.Policy, err = s.updatePolicy(c, op.ServiceName, op.Policy)
+		}
+		if err == nil {
+			result.ID = result.Policy.ID
+		}
+	case pms.KindRolePolicy:
+		if op.RolePolicy == nil {
+			return nil, errors.New(errors.InvalidRequest, "role policy is not specified")
+		}
+		if op.Op == pms.OpCreate {
+			result.RolePolicy, err = s.createRolePolicy(c, op.ServiceName, op.RolePolicy)
+		} else {
+			result.RolePolicy, err = s.updateRolePolicy(c, op.ServiceName, op.RolePolicy)
+		}
+		if err == nil {
+			result.ID = result.RolePolicy.ID
+		}
+	case pms.KindFunction:
+		if op.Function == nil {
+			return nil, errors.New(errors.InvalidRequest, "function is not specified")
+		}
+		result.ID = op.Function.Name
+		if op.Op == pms.OpCreate {
+			result.Function, err = s.createFunction(c, op.Function)
+		} else {
+			result.Function, err = s.updateFunction(c, op.Function)
+		}
+	default:
+		return nil, errors.Errorf(errors.InvalidRequest, "unknown kind %q", op.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (s *Store) applyDelete(c *change, op *pms.Operation) error {
+	switch op.Kind {
+	case pms.KindService:
+		return s.deleteService(c, op.ID, op.Revision)
+	case pms.KindPolicy:
+		return s.deletePolicy(c, op.ServiceName, op.ID, op.Revision)
+	case pms.KindRolePolicy:
+		return s.deleteRolePolicy(c, op.ServiceName, op.ID, op.Revision)
+	case pms.KindFunction:
+		return s.deleteFunction(c, op.ID, op.Revision)
+	}
+	return errors.Errorf(errors.InvalidRequest, "unknown kind %q", op.Kind)
+}
@@ -0,0 +1,152 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store/utils"
+)
+
+// changelogRetention is the number of revisions kept in the changelog, a watcher falling behind
+// further than that reloads the whole store
+const changelogRetention = 10000
+
+type changelogEntry struct {
+	revision int64
+	kind     string
+	name     string
+}
+
+// Watch polls the changelog for the revisions made after it is called. The services and functions changed
+// by a revision are sent as SERVICE_ADD or FUNCTION_ADD with their current content, or as SERVICE_DELETE
+// or FUNCTION_DELETE if they do not exist any more.
+func (s *Store) Watch() (pms.StorageChangeChannel, error) {
+	log.Info("Enter Watch...")
+	var last int64
+	err := s.read(func(ctx context.Context, q querier) error {
+		var err error
+		last, err = s.queryInt64(ctx, q, "SELECT counter_value FROM counters WHERE name = ?", counterRevision)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	storeChangeChan := make(pms.StorageChangeChannel)
+	stop := make(chan struct{})
+	s.stopLock.Lock()
+	s.stop = stop
+	s.stopLock.Unlock()
+
+	go func() {
+		defer close(storeChangeChan)
+		ticker := time.NewTicker(s.pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				revision, err := s.pollChanges(storeChangeChan, last)
+				if err != nil {
+					log.Warningf("Error happened when polling the changes of sql store, error: %v", err)
+					continue
+				}
+				last = revision
+			case <-stop:
+				log.Warning("Received stop signal")
+				return
+			}
+		}
+	}()
+	return storeChangeChan, nil
+}
+
+// pollChanges sends the events of the revisions after last, and returns the latest revision sent
+func (s *Store) pollChanges(ch pms.StorageChangeChannel, last int64) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+	current, err := s.queryInt64(ctx, s.db, "SELECT counter_value FROM counters WHERE name = ?", counterRevision)
+	if err != nil {
+		return last, err
+	}
+	if current <= last {
+		return last, nil
+	}
+	if current-last >= changelogRetention {
+		log.Info("Reloading the sql store...")
+		ch <- pms.StoreChangeEvent{Type: pms.FULL_RELOAD, ID: current}
+		return current, nil
+	}
+
+	var entries []changelogEntry
+	err = s.query(ctx, s.db, func(rows *sql.Rows) error {
+		var entry changelogEntry
+		if err := rows.Scan(&entry.revision, &entry.kind, &entry.name); err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+		return nil
+	}, "SELECT revision, kind, name FROM changelog WHERE revision > ? AND revision <= ? ORDER BY revision", last, current)
+	if err != nil {
+		return last, err
+	}
+	for start := 0; start < len(entries); {
+		end := start
+		for end < len(entries) && entries[end].revision == entries[start].revision {
+			end++
+		}
+		events, err := s.changeEvents(ctx, entries[start:end])
+		if err != nil {
+			return last, err
+		}
+		utils.SendChangeEvents(ch, events)
+		last = entries[start].revision
+		start = end
+	}
+	return current, nil
+}
+
+// changeEvents converts the changelog entries of a revision to store change events
+func (s *Store) changeEvents(ctx context.Context, entries []changelogEntry) ([]pms.StoreChangeEvent, error) {
+	var events []pms.StoreChangeEvent
+	for _, entry := range entries {
+		switch entry.kind {
+		case pms.KindService:
+			service, err := s.loadService(ctx, s.db, entry.name)
+			if errors.Code(err) == errors.EntityNotFound {
+				events = append(events, pms.StoreChangeEvent{Type: pms.SERVICE_DELETE, ID: entry.revision, Content: []string{entry.name}})
+			} else if err != nil {
+				return nil, err
+			} else {
+				// SERVICE_ADD replaces the whole service in runtime cache, so a single event is enough
+				events = append(events, pms.StoreChangeEvent{Type: pms.SERVICE_ADD, ID: entry.revision, Content: service})
+			}
+		case pms.KindFunction:
+			function, err := s.loadFunction(ctx, s.db, entry.name)
+			if errors.Code(err) == errors.EntityNotFound {
+				events = append(events, pms.StoreChangeEvent{Type: pms.FUNCTION_DELETE, ID: entry.revision, Content: []string{entry.name}})
+			} else if err != nil {
+				return nil, err
+			} else {
+				events = append(events, pms.StoreChangeEvent{Type: pms.FUNCTION_ADD, ID: entry.revision, Content: function})
+			}
+		}
+	}
+	return events, nil
+}
+
+func (s *Store) StopWatch() {
+	s.stopLock.Lock()
+	defer s.stopLock.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
@@ -0,0 +1,450 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package storetest
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+)
+
+func testServices(t *testing.T, ps pms.PolicyStoreManager) {
+	_, err := ps.GetService("books")
+	expectCode(t, err, errors.EntityNotFound, "get a missing service")
+
+	service := pms.Service{
+		Name:               "books",
+		Type:               pms.TypeApplication,
+		CombiningAlgorithm: pms.FirstApplicable,
+		DefaultEffect:      pms.Grant,
+		Attributes:         []*pms.Attribute{{Name: "age", Type: pms.AttributeTypeNumeric}, {Name: "tags", Type: pms.AttributeTypeString, List: true}},
+		Policies:           []*pms.Policy{newPolicy("p1")},
+		RolePolicies:       []*pms.RolePolicy{newRolePolicy("rp1")},
+	}
+	mustSucceed(t, ps.CreateService(&service), "create a service")
+	expectCode(t, ps.CreateService(&pms.Service{Name: "books", Type: pms.TypeApplication}), errors.EntityAlreadyExists, "create an existing service")
+
+	got, err := ps.GetService("books")
+	mustSucceed(t, err, "get a service")
+	if got.Name != service.Name || got.Type != service.Type || got.CombiningAlgorithm != service.CombiningAlgorithm || got.DefaultEffect != service.DefaultEffect {
+		t.Errorf("service attributes are not kept, expected %+v, but got %+v", service, *got)
+	}
+	if !reflect.DeepEqual(got.Attributes, service.Attributes) {
+		t.Errorf("attribute schema of the service is not kept, expected %s, but got %s", toJSON(service.Attributes), toJSON(got.Attributes))
+	}
+	if len(got.Policies) != 1 || !samePolicy(got.Policies[0], newPolicy("p1")) {
+		t.Errorf("policies of the service are not kept: %s", toJSON(got.Policies))
+	}
+	if len(got.RolePolicies) != 1 || !sameRolePolicy(got.RolePolicies[0], newRolePolicy("rp1")) {
+		t.Errorf("role policies of the service are not kept: %s", toJSON(got.RolePolicies))
+	}
+
+	update := pms.Service{Name: "books", Type: pms.TypeK8SCluster, CombiningAlgorithm: pms.DenyOverrides, DefaultEffect: pms.Deny,
+		Attributes: []*pms.Attribute{{Name: "since", Type: pms.AttributeTypeDatetime}}}
+	mustSucceed(t, ps.UpdateService(&update), "update a service")
+	got, err = ps.GetService("books")
+	mustSucceed(t, err, "get an updated service")
+	if got.Type != update.Type || got.CombiningAlgorithm != update.CombiningAlgorithm || got.DefaultEffect != update.DefaultEffect {
+		t.Errorf("service is not updated, expected %+v, but got %+v", update, *got)
+	}
+	if !reflect.DeepEqual(got.Attributes, update.Attributes) {
+		t.Errorf("attribute schema of the service is not updated, expected %s, but got %s", toJSON(update.Attributes), toJSON(got.Attributes))
+	}
+	if len(got.Policies) != 1 || len(got.RolePolicies) != 1 {
+		t.Errorf("updating a service should not touch its policies and role policies: %s", toJSON(got))
+	}
+	expectCode(t, ps.UpdateService(&pms.Service{Name: "magazines", Type: pms.TypeApplication}), errors.EntityNotFound, "update a missing service")
+
+	mustSucceed(t, ps.CreateService(&pms.Service{Name: "magazines", Type: pms.TypeApplication}), "create another service")
+	names, err := ps.GetServiceNames()
+	mustSucceed(t, err, "get service names")
+	if !reflect.DeepEqual(sortedStrings(names), []string{"books", "magazines"}) {
+		t.Errorf("expected service names [books magazines], but got %v", names)
+	}
+	services, err := ps.ListAllServices()
+	mustSucceed(t, err, "list services")
+	if len(services) != 2 {
+		t.Errorf("expected 2 services, but got %d", len(services))
+	}
+
+	mustSucceed(t, ps.DeleteService("books"), "delete a service")
+	_, err = ps.GetService("books")
+	expectCode(t, err, errors.EntityNotFound, "get a deleted service")
+	expectCode(t, ps.DeleteService("books"), errors.EntityNotFound, "delete a missing service")
+	_, err = ps.GetPolicy("books", got.Policies[0].ID)
+	expectCode(t, err, errors.EntityNotFound, "get a policy of a deleted service")
+
+	mustSucceed(t, ps.DeleteServices(), "delete all services")
+	if count, err := ps.GetServiceCount(); err != nil || count != 0 {
+		t.Errorf("expected no service after deleting all services, but got %d, %v", count, err)
+	}
+}
+
+func testPolicies(t *testing.T, ps pms.PolicyStoreManager) {
+	_, err := ps.CreatePolicy("books", newPolicy("p1"))
+	expectCode(t, err, errors.EntityNotFound, "create a policy in a missing service")
+	mustSucceed(t, ps.CreateService(&pms.Service{Name: "books", Type: pms.TypeApplication}), "create a service")
+
+	created, err := ps.CreatePolicy("books", newPolicy("p1"))
+	mustSucceed(t, err, "create a policy")
+	if len(created.ID) == 0 || !samePolicy(created, newPolicy("p1")) {
+		t.Errorf("unexpected created policy: %s", toJSON(created))
+	}
+	got, err := ps.GetPolicy("books", created.ID)
+	mustSucceed(t, err, "get a policy")
+	if got.ID != created.ID || !samePolicy(got, newPolicy("p1")) {
+		t.Errorf("policy is not kept, expected %s, but got %s", toJSON(created), toJSON(got))
+	}
+	_, err = ps.GetPolicy("books", "missing")
+	expectCode(t, err, errors.EntityNotFound, "get a missing policy")
+	_, err = ps.GetPolicy("magazines", created.ID)
+	expectCode(t, err, errors.EntityNotFound, "get a policy from a missing service")
+
+	update := *got
+	update.Effect = pms.Deny
+	update.Condition = ""
+	updated, err := ps.UpdatePolicy("books", &update)
+	mustSucceed(t, err, "update a policy")
+	got, err = ps.GetPolicy("books", created.ID)
+	mustSucceed(t, err, "get an updated policy")
+	if got.Effect != pms.Deny || got.Condition != "" || !samePolicy(got, updated) {
+		t.Errorf("policy is not updated, expected %s, but got %s", toJSON(updated), toJSON(got))
+	}
+	if update.Revision > 0 && got.Revision != update.Revision {
+		stale := *got
+		stale.Revision = update.Revision
+		_, err = ps.UpdatePolicy("books", &stale)
+		expectCode(t, err, errors.RevisionConflict, "update a policy with a stale revision")
+	}
+	missing := *newPolicy("p2")
+	missing.ID = "missing"
+	_, err = ps.UpdatePolicy("books", &missing)
+	expectCode(t, err, errors.EntityNotFound, "update a missing policy")
+
+	policies, err := ps.ListAllPolicies("books", "")
+	mustSucceed(t, err, "list policies")
+	if len(policies) != 1 || policies[0].ID != created.ID {
+		t.Errorf("expected the only policy %q, but got %s", created.ID, toJSON(policies))
+	}
+	_, err = ps.ListAllPolicies("magazines", "")
+	expectCode(t, err, errors.EntityNotFound, "list policies of a missing service")
+
+	mustSucceed(t, ps.DeletePolicy("books", created.ID), "delete a policy")
+	_, err = ps.GetPolicy("books", created.ID)
+	expectCode(t, err, errors.EntityNotFound, "get a deleted policy")
+	expectCode(t, ps.DeletePolicy("books", created.ID), errors.EntityNotFound, "delete a missing policy")
+
+	for _, name := range []string{"p2", "p3"} {
+		_, err := ps.CreatePolicy("books", newPolicy(name))
+		mustSucceed(t, err, "create a policy")
+	}
+	mustSucceed(t, ps.DeletePolicies("books"), "delete all policies of a service")
+	if count, err := ps.GetPolicyCount("books"); err != nil || count != 0 {
+		t.Errorf("expected no policy after deleting all policies, but got %d, %v", count, err)
+	}
+	expectCode(t, ps.DeletePolicies("magazines"), errors.EntityNotFound, "delete all policies of a missing service")
+	if _, err := ps.GetService("magazines"); errors.Code(err) != errors.EntityNotFound {
+		t.Errorf("deleting policies should not create a service, but got %v", err)
+	}
+}
+
+func testRolePolicies(t *testing.T, ps pms.PolicyStoreManager) {
+	_, err := ps.CreateRolePolicy("books", newRolePolicy("rp1"))
+	expectCode(t, err, errors.EntityNotFound, "create a role policy in a missing service")
+	mustSucceed(t, ps.CreateService(&pms.Service{Name: "books", Type: pms.TypeApplication}), "create a service")
+
+	created, err := ps.CreateRolePolicy("books", newRolePolicy("rp1"))
+	mustSucceed(t, err, "create a role policy")
+	if len(created.ID) == 0 || !sameRolePolicy(created, newRolePolicy("rp1")) {
+		t.Errorf("unexpected created role policy: %s", toJSON(created))
+	}
+	got, err := ps.GetRolePolicy("books", created.ID)
+	mustSucceed(t, err, "get a role policy")
+	if got.ID != created.ID || !sameRolePolicy(got, newRolePolicy("rp1")) {
+		t.Errorf("role policy is not kept, expected %s, but got %s", toJSON(created), toJSON(got))
+	}
+	_, err = ps.GetRolePolicy("books", "missing")
+	expectCode(t, err, errors.EntityNotFound, "get a missing role policy")
+	_, err = ps.GetRolePolicy("magazines", created.ID)
+	expectCode(t, err, errors.EntityNotFound, "get a role policy from a missing service")
+
+	update := *got
+	update.Effect = pms.Deny
+	update.Roles = []string{"writer"}
+	updated, err := ps.UpdateRolePolicy("books", &update)
+	mustSucceed(t, err, "update a role policy")
+	got, err = ps.GetRolePolicy("books", created.ID)
+	mustSucceed(t, err, "get an updated role policy")
+	if got.Effect != pms.Deny || !reflect.DeepEqual(got.Roles, []string{"writer"}) || !sameRolePolicy(got, updated) {
+		t.Errorf("role policy is not updated, expected %s, but got %s", toJSON(updated), toJSON(got))
+	}
+	if update.Revision > 0 && got.Revision != update.Revision {
+		stale := *got
+		stale.Revision = update.Revision
+		_, err = ps.UpdateRolePolicy("books", &stale)
+		expectCode(t, err, errors.RevisionConflict, "update a role policy with a stale revision")
+	}
+	missing := *newRolePolicy("rp2")
+	missing.ID = "missing"
+	_, err = ps.UpdateRolePolicy("books", &missing)
+	expectCode(t, err, errors.EntityNotFound, "update a missing role policy")
+
+	rolePolicies, err := ps.ListAllRolePolicies("books", "")
+	mustSucceed(t, err, "list role policies")
+	if len(rolePolicies) != 1 || rolePolicies[0].ID != created.ID {
+		t.Errorf("expected the only role policy %q, but got %s", created.ID, toJSON(rolePolicies))
+	}
+	_, err = ps.ListAllRolePolicies("magazines", "")
+	expectCode(t, err, errors.EntityNotFound, "list role policies of a missing service")
+
+	mustSucceed(t, ps.DeleteRolePolicy("books", created.ID), "delete a role policy")
+	_, err = ps.GetRolePolicy("books", created.ID)
+	expectCode(t, err, errors.EntityNotFound, "get a deleted role policy")
+	expectCode(t, ps.DeleteRolePolicy("books", created.ID), errors.EntityNotFound, "delete a missing role policy")
+
+	for _, name := range []string{"rp2", "rp3"} {
+		_, err := ps.CreateRolePolicy("books", newRolePolicy(name))
+		mustSucceed(t, err, "create a role policy")
+	}
+	mustSucceed(t, ps.DeleteRolePolicies("books"), "delete all role policies of a service")
+	if count, err := ps.GetRolePolicyCount("books"); err != nil || count != 0 {
+		t.Errorf("expected no role policy after deleting all role policies, but got %d, %v", count, err)
+	}
+	expectCode(t, ps.DeleteRolePolicies("magazines"), errors.EntityNotFound, "delete all role policies of a missing service")
+	if _, err := ps.GetService("magazines"); errors.Code(err) != errors.EntityNotFound {
+		t.Errorf("deleting role policies should not create a service, but got %v", err)
+	}
+}
+
+func testFunctions(t *testing.T, ps pms.PolicyStoreManager) {
+	_, err := ps.CreateFunction(&pms.Function{Name: "f1"})
+	expectCode(t, err, errors.InvalidRequest, "create a function without funcURL")
+
+	created, err := ps.CreateFunction(newFunction("f1"))
+	mustSucceed(t, err, "create a function")
+	if !sameFunction(created, newFunction("f1")) {
+		t.Errorf("unexpected created function: %s", toJSON(created))
+	}
+	_, err = ps.CreateFunction(newFunction("f1"))
+	expectCode(t, err, errors.EntityAlreadyExists, "create an existing function")
+	got, err := ps.GetFunction("f1")
+	mustSucceed(t, err, "get a function")
+	if !sameFunction(got, newFunction("f1")) {
+		t.Errorf("function is not kept, expected %s, but got %s", toJSON(newFunction("f1")), toJSON(got))
+	}
+	_, err = ps.GetFunction("f2")
+	expectCode(t, err, errors.EntityNotFound, "get a missing function")
+
+	update := *got
+	update.FuncURL = "http://localhost:9999/funcs/f1/v2"
+	update.ResultCachable = false
+	_, err = ps.UpdateFunction(&update)
+	mustSucceed(t, err, "update a function")
+	got, err = ps.GetFunction("f1")
+	mustSucceed(t, err, "get an updated function")
+	if got.FuncURL != update.FuncURL || got.ResultCachable {
+		t.Errorf("function is not updated, expected %s, but got %s", toJSON(update), toJSON(got))
+	}
+	if update.Revision > 0 && got.Revision != update.Revision {
+		stale := *got
+		stale.Revision = update.Revision
+		_, err = ps.UpdateFunction(&stale)
+		expectCode(t, err, errors.RevisionConflict, "update a function with a stale revision")
+	}
+	_, err = ps.UpdateFunction(newFunction("f2"))
+	expectCode(t, err, errors.EntityNotFound, "update a missing function")
+
+	_, err = ps.CreateFunction(newFunction("f2"))
+	mustSucceed(t, err, "create another function")
+	functions, err := ps.ListAllFunctions("")
+	mustSucceed(t, err, "list functions")
+	if !reflect.DeepEqual(functionNames(functions), []string{"f1", "f2"}) {
+		t.Errorf("expected functions [f1 f2], but got %s", toJSON(functions))
+	}
+
+	mustSucceed(t, ps.DeleteFunction("f1"), "delete a function")
+	_, err = ps.GetFunction("f1")
+	expectCode(t, err, errors.EntityNotFound, "get a deleted function")
+	expectCode(t, ps.DeleteFunction("f1"), errors.EntityNotFound, "delete a missing function")
+
+	mustSucceed(t, ps.DeleteFunctions(), "delete all functions")
+	if count, err := ps.GetFunctionCount(); err != nil || count != 0 {
+		t.Errorf("expected no function after deleting all functions, but got %d, %v", count, err)
+	}
+}
+
+func testPolicyStore(t *testing.T, ps pms.PolicyStoreManager) {
+	mustSucceed(t, ps.CreateService(&pms.Service{Name: "obsolete", Type: pms.TypeApplication}), "create a service")
+
+	written := pms.PolicyStore{
+		Services: []*pms.Service{
+			{Name: "books", Type: pms.TypeApplication, Policies: []*pms.Policy{newPolicy("p1"), newPolicy("p2")}, RolePolicies: []*pms.RolePolicy{newRolePolicy("rp1")}},
+			{Name: "magazines", Type: pms.TypeK8SCluster, Policies: []*pms.Policy{newPolicy("p3")}},
+		},
+		Functions: []*pms.Function{newFunction("f1")},
+	}
+	mustSucceed(t, ps.WritePolicyStore(&written), "write the policy store")
+
+	read, err := ps.ReadPolicyStore()
+	mustSucceed(t, err, "read the policy store")
+	services := map[string]*pms.Service{}
+	for _, service := range read.Services {
+		services[service.Name] = service
+	}
+	if len(services) != 2 || services["books"] == nil || services["magazines"] == nil {
+		t.Fatalf("the written services should replace the existing ones, but got %s", toJSON(read.Services))
+	}
+	if !reflect.DeepEqual(policyNames(services["books"].Policies), []string{"p1", "p2"}) ||
+		!reflect.DeepEqual(rolePolicyNames(services["books"].RolePolicies), []string{"rp1"}) ||
+		!reflect.DeepEqual(policyNames(services["magazines"].Policies), []string{"p3"}) {
+		t.Errorf("policies are not kept: %s", toJSON(read.Services))
+	}
+	if services["magazines"].Type != pms.TypeK8SCluster {
+		t.Errorf("service type is not kept: %s", toJSON(services["magazines"]))
+	}
+	if len(read.Functions) != 1 || !sameFunction(read.Functions[0], newFunction("f1")) {
+		t.Errorf("functions are not kept: %s", toJSON(read.Functions))
+	}
+	for _, service := range read.Services {
+		for _, policy := range service.Policies {
+			got, err := ps.GetPolicy(service.Name, policy.ID)
+			if err != nil || got.Name != policy.Name {
+				t.Errorf("policy %q of service %q could not be got by its ID: %v", policy.ID, service.Name, err)
+			}
+		}
+	}
+}
+
+func testTransaction(t *testing.T, ps pms.PolicyStoreManager) {
+	results, err := ps.ExecuteTransaction([]*pms.Operation{
+		{Op: pms.OpCreate, Kind: pms.KindService, Service: &pms.Service{Name: "books", Type: pms.TypeApplication}},
+		{Op: pms.OpCreate, Kind: pms.KindPolicy, ServiceName: "books", Policy: newPolicy("p1")},
+		{Op: pms.OpCreate, Kind: pms.KindRolePolicy, ServiceName: "books", RolePolicy: newRolePolicy("rp1")},
+		{Op: pms.OpCreate, Kind: pms.KindFunction, Function: newFunction("f1")},
+	})
+	mustSucceed(t, err, "execute a transaction")
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, but got %s", toJSON(results))
+	}
+	if results[1].Policy == nil || len(results[1].ID) == 0 || results[1].ID != results[1].Policy.ID {
+		t.Errorf("the result of creating a policy should carry the policy and its ID: %s", toJSON(results[1]))
+	} else if _, err := ps.GetPolicy("books", results[1].ID); err != nil {
+		t.Errorf("the policy created in a transaction is not found: %v", err)
+	}
+	if results[2].RolePolicy == nil || len(results[2].ID) == 0 || results[2].ID != results[2].RolePolicy.ID {
+		t.Errorf("the result of creating a role policy should carry the role policy and its ID: %s", toJSON(results[2]))
+	} else if _, err := ps.GetRolePolicy("books", results[2].ID); err != nil {
+		t.Errorf("the role policy created in a transaction is not found: %v", err)
+	}
+	if _, err := ps.GetFunction("f1"); err != nil {
+		t.Errorf("the function created in a transaction is not found: %v", err)
+	}
+
+	before, err := ps.ReadPolicyStore()
+	mustSucceed(t, err, "read the policy store")
+	_, err = ps.ExecuteTransaction([]*pms.Operation{
+		{Op: pms.OpCreate, Kind: pms.KindPolicy, ServiceName: "books", Policy: newPolicy("p2")},
+		{Op: pms.OpDelete, Kind: pms.KindFunction, ID: "f1"},
+		{Op: pms.OpDelete, Kind: pms.KindPolicy, ServiceName: "books", ID: "missing"},
+	})
+	expectCode(t, err, errors.EntityNotFound, "execute a transaction deleting a missing policy")
+	after, err := ps.ReadPolicyStore()
+	mustSucceed(t, err, "read the policy store")
+	if canonical(before) != canonical(after) {
+		t.Errorf("a failed transaction should not change the store, before: %s, after: %s", canonical(before), canonical(after))
+	}
+
+	// updates in a transaction are checked against the current revisions
+	policy, err := ps.GetPolicy("books", results[1].ID)
+	mustSucceed(t, err, "get the policy created in a transaction")
+	update := *policy
+	update.Effect = pms.Deny
+	_, err = ps.ExecuteTransaction([]*pms.Operation{{Op: pms.OpUpdate, Kind: pms.KindPolicy, ServiceName: "books", Policy: &update}})
+	mustSucceed(t, err, "update a policy in a transaction")
+	if got, err := ps.GetPolicy("books", policy.ID); err != nil || got.Effect != pms.Deny {
+		t.Errorf("the policy updated in a transaction is not changed: %s, %v", toJSON(got), err)
+	}
+	if update.Revision > 0 {
+		before, err = ps.ReadPolicyStore()
+		mustSucceed(t, err, "read the policy store")
+		_, err = ps.ExecuteTransaction([]*pms.Operation{
+			{Op: pms.OpDelete, Kind: pms.KindFunction, ID: "f1"},
+			{Op: pms.OpUpdate, Kind: pms.KindPolicy, ServiceName: "books", Policy: policy},
+		})
+		expectCode(t, err, errors.RevisionConflict, "execute a transaction updating a policy with a stale revision")
+		after, err = ps.ReadPolicyStore()
+		mustSucceed(t, err, "read the policy store")
+		if canonical(before) != canonical(after) {
+			t.Errorf("a transaction with a stale revision should not change the store, before: %s, after: %s", canonical(before), canonical(after))
+		}
+	}
+
+	// a service created and deleted in a failed transaction is not kept
+	_, err = ps.ExecuteTransaction([]*pms.Operation{
+		{Op: pms.OpCreate, Kind: pms.KindService, Service: &pms.Service{Name: "magazines", Type: pms.TypeApplication}},
+		{Op: pms.OpDelete, Kind: pms.KindService, ID: "magazines"},
+		{Op: pms.OpCreate, Kind: pms.KindService, Service: &pms.Service{Name: "books", Type: pms.TypeApplication}},
+	})
+	expectCode(t, err, errors.EntityAlreadyExists, "execute a transaction creating an existing service")
+	_, err = ps.GetService("magazines")
+	expectCode(t, err, errors.EntityNotFound, "get a service created and deleted in a failed transaction")
+	if _, err := ps.GetFunction("f1"); err != nil {
+		t.Errorf("the function should not be deleted by a failed transaction: %v", err)
+	}
+}
+
+func testConcurrentWriters(t *testing.T, ps pms.PolicyStoreManager) {
+	mustSucceed(t, ps.CreateService(&pms.Service{Name: "books", Type: pms.TypeApplication}), "create a service")
+	const writers, writes = 8, 10
+	errChan := make(chan error, writers*writes*3)
+	done := make(chan struct{})
+	for i := 0; i < writers; i++ {
+		go func(i int) {
+			defer func() { done <- struct{}{} }()
+			for j := 0; j < writes; j++ {
+				name := fmt.Sprintf("w%d-%d", i, j)
+				if _, err := ps.CreatePolicy("books", newPolicy(name)); err != nil {
+					errChan <- err
+				}
+				if _, err := ps.CreateRolePolicy("books", newRolePolicy(name)); err != nil {
+					errChan <- err
+				}
+				if _, err := ps.CreateFunction(newFunction(name)); err != nil {
+					errChan <- err
+				}
+			}
+		}(i)
+	}
+	for i := 0; i < writers; i++ {
+		<-done
+	}
+	close(errChan)
+	for err := range errChan {
+		t.Errorf("concurrent write failed: %v", err)
+	}
+
+	policies, err := ps.ListAllPolicies("books", "")
+	mustSucceed(t, err, "list policies")
+	rolePolicies, err := ps.ListAllRolePolicies("books", "")
+	mustSucceed(t, err, "list role policies")
+	functions, err := ps.ListAllFunctions("")
+	mustSucceed(t, err, "list functions")
+	if len(policies) != writers*writes || len(rolePolicies) != writers*writes || len(functions) != writers*writes {
+		t.Errorf("expected %d policies, role policies and functions, but got %d, %d and %d",
+			writers*writes, len(policies), len(rolePolicies), len(functions))
+	}
+	ids := map[string]bool{}
+	for _, policy := range policies {
+		ids[policy.ID] = true
+	}
+	for _, rolePolicy := range rolePolicies {
+		ids[rolePolicy.ID] = true
+	}
+	if len(ids) != len(policies)+len(rolePolicies) {
+		t.Errorf("IDs generated by concurrent writers are not unique")
+	}
+}
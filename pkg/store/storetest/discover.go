@@ -0,0 +1,115 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package storetest
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+)
+
+func newDiscoverRequest(serviceName string, i int) *ads.RequestContext {
+	user := ads.Principal{Type: ads.PRINCIPAL_TYPE_USER, Name: "user" + strconv.Itoa(i%3)}
+	return &ads.RequestContext{
+		Subject:     &ads.Subject{Principals: []*ads.Principal{&user}},
+		ServiceName: serviceName,
+		Resource:    "/" + serviceName + "/res" + strconv.Itoa(i),
+		Action:      "read",
+		Attributes:  map[string]interface{}{},
+	}
+}
+
+func resources(requests []*ads.RequestContext) []string {
+	values := []string{}
+	for _, request := range requests {
+		values = append(values, request.Resource)
+	}
+	return values
+}
+
+func testDiscover(t *testing.T, ps pms.PolicyStoreManager) {
+	discover, ok := ps.(store.DiscoverRequestManager)
+	if !ok {
+		t.Skipf("store %q does not support discover", ps.Type())
+	}
+	mustSucceed(t, discover.ResetDiscoverRequests(""), "reset discover requests")
+
+	requests, _, err := discover.GetDiscoverRequests("books")
+	if err != nil || len(requests) != 0 {
+		t.Errorf("expected no discover request, but got %v, %v", resources(requests), err)
+	}
+	_, _, err = discover.GetLastDiscoverRequest("books")
+	expectCode(t, err, errors.EntityNotFound, "get the last discover request when there is none")
+
+	var all, books []string
+	for i := 0; i < 5; i++ {
+		for _, serviceName := range []string{"books", "magazines"} {
+			request := newDiscoverRequest(serviceName, i)
+			mustSucceed(t, discover.SaveDiscoverRequest(request), "save a discover request")
+			all = append(all, request.Resource)
+			if serviceName == "books" {
+				books = append(books, request.Resource)
+			}
+		}
+	}
+	requests, _, err = discover.GetDiscoverRequests("books")
+	if err != nil || !reflect.DeepEqual(resources(requests), books) {
+		t.Errorf("expected discover requests %v of a service in order, but got %v, %v", books, resources(requests), err)
+	}
+	requests, _, err = discover.GetDiscoverRequests("")
+	if err != nil || !reflect.DeepEqual(resources(requests), all) {
+		t.Errorf("expected all discover requests %v in order, but got %v, %v", all, resources(requests), err)
+	}
+
+	last, revision, err := discover.GetLastDiscoverRequest("books")
+	mustSucceed(t, err, "get the last discover request")
+	if last.Resource != books[len(books)-1] {
+		t.Errorf("expected the last discover request %q, but got %q", books[len(books)-1], last.Resource)
+	}
+	var newer []string
+	for i := 5; i < 8; i++ {
+		request := newDiscoverRequest("books", i)
+		mustSucceed(t, discover.SaveDiscoverRequest(request), "save a discover request")
+		newer = append(newer, request.Resource)
+	}
+	requests, latest, err := discover.GetDiscoverRequestsSinceRevision("books", revision)
+	if err != nil || !reflect.DeepEqual(resources(requests), newer) {
+		t.Errorf("expected discover requests %v since revision %d, but got %v, %v", newer, revision, resources(requests), err)
+	}
+	requests, _, err = discover.GetDiscoverRequestsSinceRevision("books", latest)
+	if err != nil || len(requests) != 0 {
+		t.Errorf("expected no discover request since the latest revision %d, but got %v, %v", latest, resources(requests), err)
+	}
+
+	services, _, err := discover.GeneratePolicies("books", "", "", "")
+	mustSucceed(t, err, "generate policies")
+	if len(services) != 1 || services["books"] == nil || len(services["books"].Policies) == 0 {
+		t.Errorf("expected policies generated for service books only, but got %s", toJSON(services))
+	}
+	services, _, err = discover.GeneratePolicies("", ads.PRINCIPAL_TYPE_USER, "user0", "")
+	mustSucceed(t, err, "generate policies of a principal")
+	if len(services) != 2 {
+		t.Errorf("expected policies generated for 2 services, but got %s", toJSON(services))
+	}
+
+	mustSucceed(t, discover.ResetDiscoverRequests("magazines"), "reset discover requests of a service")
+	requests, _, err = discover.GetDiscoverRequests("magazines")
+	if err != nil || len(requests) != 0 {
+		t.Errorf("discover requests of a service should be reset, but got %v, %v", resources(requests), err)
+	}
+	requests, _, err = discover.GetDiscoverRequests("books")
+	if err != nil || len(requests) != len(books)+len(newer) {
+		t.Errorf("discover requests of other services should be kept, but got %v, %v", resources(requests), err)
+	}
+	mustSucceed(t, discover.ResetDiscoverRequests(""), "reset all discover requests")
+	requests, _, err = discover.GetDiscoverRequests("")
+	if err != nil || len(requests) != 0 {
+		t.Errorf("all discover requests should be reset, but got %v, %v", resources(requests), err)
+	}
+}
@@ -0,0 +1,121 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package storetest
+
+import (
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+)
+
+func testHistory(t *testing.T, ps pms.PolicyStoreManager) {
+	historyManager, ok := ps.(store.HistoryManager)
+	if !ok {
+		t.Skipf("store %q does not keep history", ps.Type())
+	}
+
+	mustSucceed(t, ps.CreateService(&pms.Service{Name: "books", Type: pms.TypeApplication}), "create service")
+	created := newPolicy("p1")
+	created.Metadata = map[string]string{"createby": "Alice"}
+	policy, err := ps.CreatePolicy("books", created)
+	mustSucceed(t, err, "create policy")
+	update := *policy
+	update.Effect = pms.Deny
+	update.Metadata = map[string]string{"createby": "Alice", "updateby": "Bill"}
+	_, err = ps.UpdatePolicy("books", &update)
+	mustSucceed(t, err, "update policy")
+	mustSucceed(t, ps.DeletePolicy("books", policy.ID), "delete policy")
+
+	records, err := historyManager.ListHistory(pms.KindPolicy, "books", policy.ID)
+	mustSucceed(t, err, "list history of policy")
+	if len(records) != 3 {
+		t.Fatalf("expected 3 versions of policy, but got %s", toJSON(records))
+	}
+	for i, op := range []string{pms.OpCreate, pms.OpUpdate, pms.OpDelete} {
+		if records[i].Op != op {
+			t.Errorf("version %d of policy should be %s, but got %s", i, op, records[i].Op)
+		}
+		if i > 0 && records[i].Version <= records[i-1].Version {
+			t.Errorf("versions should be increasing, but got %s", toJSON(records))
+		}
+	}
+	if records[0].ChangedBy != "Alice" || records[1].ChangedBy != "Bill" {
+		t.Errorf("who changed the policy is not kept: %s", toJSON(records))
+	}
+	if records[1].Policy == nil || records[1].Policy.Effect != pms.Deny || records[2].Policy != nil {
+		t.Errorf("the changed policy is not kept: %s", toJSON(records))
+	}
+
+	record, err := historyManager.GetHistory(pms.KindPolicy, "books", policy.ID, records[0].Version)
+	mustSucceed(t, err, "get a version of policy")
+	if record.Policy == nil || record.Policy.Effect != pms.Grant {
+		t.Errorf("the first version of policy is not kept: %s", toJSON(record))
+	}
+	_, err = historyManager.GetHistory(pms.KindPolicy, "books", policy.ID, records[2].Version+1)
+	expectCode(t, err, errors.EntityNotFound, "get a missing version of policy")
+	_, err = historyManager.ListHistory(pms.KindPolicy, "books", "missing")
+	expectCode(t, err, errors.EntityNotFound, "list history of a missing policy")
+
+	// changes in a transaction have the same version
+	_, err = ps.ExecuteTransaction([]*pms.Operation{
+		{Op: pms.OpCreate, Kind: pms.KindFunction, Function: newFunction("f1")},
+		{Op: pms.OpDelete, Kind: pms.KindService, ID: "books"},
+	})
+	mustSucceed(t, err, "execute a transaction")
+	functionRecords, err := historyManager.ListHistory(pms.KindFunction, "", "f1")
+	mustSucceed(t, err, "list history of function")
+	serviceRecords, err := historyManager.ListHistory(pms.KindService, "", "books")
+	mustSucceed(t, err, "list history of service")
+	if len(serviceRecords) < 2 {
+		t.Fatalf("expected the creation and deletion of service, but got %s", toJSON(serviceRecords))
+	}
+	lastFunction, lastService := functionRecords[len(functionRecords)-1], serviceRecords[len(serviceRecords)-1]
+	if lastFunction.Op != pms.OpCreate || lastService.Op != pms.OpDelete || lastFunction.Version != lastService.Version {
+		t.Errorf("changes in a transaction should be kept in the same version: %s, %s", toJSON(lastFunction), toJSON(lastService))
+	}
+	if previous := serviceRecords[len(serviceRecords)-2]; previous.Service == nil || previous.Service.Type != pms.TypeApplication {
+		t.Errorf("the created service is not kept: %s", toJSON(previous))
+	}
+}
+
+func testHistoryRetention(t *testing.T, ps pms.PolicyStoreManager) {
+	historyManager, ok := ps.(store.HistoryManager)
+	if !ok {
+		t.Skipf("store %q does not keep history", ps.Type())
+	}
+	maxVersions := store.MaxHistoryVersions
+	store.MaxHistoryVersions = 3
+	defer func() { store.MaxHistoryVersions = maxVersions }()
+
+	mustSucceed(t, ps.CreateService(&pms.Service{Name: "books", Type: pms.TypeApplication}), "create service")
+	policy, err := ps.CreatePolicy("books", newPolicy("read"))
+	mustSucceed(t, err, "create policy")
+	for _, effect := range []string{pms.Deny, pms.Grant, pms.Deny} {
+		policy.Effect = effect
+		policy, err = ps.UpdatePolicy("books", policy)
+		mustSucceed(t, err, "update policy")
+	}
+	records, err := historyManager.ListHistory(pms.KindPolicy, "books", policy.ID)
+	mustSucceed(t, err, "list history of policy")
+	if len(records) != 3 || records[0].Op != pms.OpUpdate || records[2].Policy == nil || records[2].Policy.Effect != pms.Deny {
+		t.Fatalf("expected the latest 3 versions of policy, but got %s", toJSON(records))
+	}
+	if records[2].Version != policy.Revision {
+		t.Errorf("expected the latest version %d, but got %d", policy.Revision, records[2].Version)
+	}
+
+	// who deletes an entity is kept in its history
+	_, err = ps.ExecuteTransaction([]*pms.Operation{{Op: pms.OpDelete, Kind: pms.KindPolicy, ServiceName: "books", ID: policy.ID, ChangedBy: "user:Alice"}})
+	mustSucceed(t, err, "delete policy in a transaction")
+	records, err = historyManager.ListHistory(pms.KindPolicy, "books", policy.ID)
+	mustSucceed(t, err, "list history of policy")
+	if len(records) != 3 || records[2].Op != pms.OpDelete || records[2].ChangedBy != "user:Alice" {
+		t.Errorf("expected the deletion by user:Alice as the latest version, but got %s", toJSON(records))
+	}
+	if records[0].Version >= records[1].Version || records[1].Version >= records[2].Version {
+		t.Errorf("versions should be increasing, but got %s", toJSON(records))
+	}
+}
@@ -0,0 +1,284 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package storetest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+)
+
+func testCounts(t *testing.T, ps pms.PolicyStoreManager) {
+	if count, err := ps.GetServiceCount(); err != nil || count != 0 {
+		t.Errorf("expected no service in an empty store, but got %d, %v", count, err)
+	}
+	if count, err := ps.GetPolicyCount(""); err != nil || count != 0 {
+		t.Errorf("expected no policy in an empty store, but got %d, %v", count, err)
+	}
+	if count, err := ps.GetFunctionCount(); err != nil || count != 0 {
+		t.Errorf("expected no function in an empty store, but got %d, %v", count, err)
+	}
+
+	mustSucceed(t, ps.CreateService(&pms.Service{
+		Name:         "books",
+		Type:         pms.TypeApplication,
+		Policies:     []*pms.Policy{newPolicy("p1"), newPolicy("p2"), newPolicy("p3")},
+		RolePolicies: []*pms.RolePolicy{newRolePolicy("rp1"), newRolePolicy("rp2")},
+	}), "create a service")
+	mustSucceed(t, ps.CreateService(&pms.Service{
+		Name:     "magazines",
+		Type:     pms.TypeApplication,
+		Policies: []*pms.Policy{newPolicy("p4")},
+	}), "create a service")
+	for _, name := range []string{"f1", "f2"} {
+		_, err := ps.CreateFunction(newFunction(name))
+		mustSucceed(t, err, "create a function")
+	}
+
+	expected := []struct {
+		what     string
+		count    func() (int64, error)
+		expected int64
+	}{
+		{"services", ps.GetServiceCount, 2},
+		{"policies of books", func() (int64, error) { return ps.GetPolicyCount("books") }, 3},
+		{"policies of all services", func() (int64, error) { return ps.GetPolicyCount("") }, 4},
+		{"role policies of books", func() (int64, error) { return ps.GetRolePolicyCount("books") }, 2},
+		{"role policies of magazines", func() (int64, error) { return ps.GetRolePolicyCount("magazines") }, 0},
+		{"role policies of all services", func() (int64, error) { return ps.GetRolePolicyCount("") }, 2},
+		{"functions", ps.GetFunctionCount, 2},
+	}
+	for _, e := range expected {
+		if count, err := e.count(); err != nil || count != e.expected {
+			t.Errorf("expected %d %s, but got %d, %v", e.expected, e.what, count, err)
+		}
+	}
+
+	counts, err := ps.GetPolicyAndRolePolicyCounts()
+	mustSucceed(t, err, "get policy and role policy counts")
+	if len(counts) != 2 || counts["books"] == nil || counts["magazines"] == nil ||
+		*counts["books"] != (pms.PolicyAndRolePolicyCount{PolicyCount: 3, RolePolicyCount: 2}) ||
+		*counts["magazines"] != (pms.PolicyAndRolePolicyCount{PolicyCount: 1}) {
+		t.Errorf("unexpected policy and role policy counts: %s", toJSON(counts))
+	}
+
+	_, err = ps.GetPolicyCount("newspapers")
+	expectCode(t, err, errors.EntityNotFound, "count policies of a missing service")
+	_, err = ps.GetRolePolicyCount("newspapers")
+	expectCode(t, err, errors.EntityNotFound, "count role policies of a missing service")
+}
+
+// nameFilters are the supported filters, and the names they match in filterNames
+var nameFilters = []struct {
+	filter   string
+	expected []string
+}{
+	{"", []string{"alpha", "alphabet", "beta", "gamma"}},
+	{"name eq beta", []string{"beta"}},
+	{"name co ph", []string{"alpha", "alphabet"}},
+	{"name sw al", []string{"alpha", "alphabet"}},
+	{"name pr", []string{"alpha", "alphabet", "beta", "gamma"}},
+	{"name gt beta", []string{"gamma"}},
+	{"name ge beta", []string{"beta", "gamma"}},
+	{"name lt beta", []string{"alpha", "alphabet"}},
+	{"name le beta", []string{"alpha", "alphabet", "beta"}},
+	{"name eq delta", []string{}},
+}
+
+var filterNames = []string{"gamma", "alpha", "beta", "alphabet"}
+
+// invalidFilters are rejected with InvalidRequest
+var invalidFilters = []string{"name", "name eq", "name in beta", "owner eq alice", "name eq beta gamma", "(name eq beta"}
+
+func testFilters(t *testing.T, ps pms.PolicyStoreManager) {
+	mustSucceed(t, ps.CreateService(&pms.Service{Name: "books", Type: pms.TypeApplication}), "create a service")
+	for _, name := range filterNames {
+		_, err := ps.CreatePolicy("books", newPolicy(name))
+		mustSucceed(t, err, "create a policy")
+		_, err = ps.CreateRolePolicy("books", newRolePolicy(name))
+		mustSucceed(t, err, "create a role policy")
+		_, err = ps.CreateFunction(newFunction(name))
+		mustSucceed(t, err, "create a function")
+	}
+
+	for _, f := range nameFilters {
+		policies, err := ps.ListAllPolicies("books", f.filter)
+		if err != nil || !reflect.DeepEqual(policyNames(policies), f.expected) {
+			t.Errorf("filter %q of policies: expected %v, but got %v, %v", f.filter, f.expected, policyNames(policies), err)
+		}
+		rolePolicies, err := ps.ListAllRolePolicies("books", f.filter)
+		if err != nil || !reflect.DeepEqual(rolePolicyNames(rolePolicies), f.expected) {
+			t.Errorf("filter %q of role policies: expected %v, but got %v, %v", f.filter, f.expected, rolePolicyNames(rolePolicies), err)
+		}
+		functions, err := ps.ListAllFunctions(f.filter)
+		if err != nil || !reflect.DeepEqual(functionNames(functions), f.expected) {
+			t.Errorf("filter %q of functions: expected %v, but got %v, %v", f.filter, f.expected, functionNames(functions), err)
+		}
+	}
+
+	for _, filter := range invalidFilters {
+		_, err := ps.ListAllPolicies("books", filter)
+		expectCode(t, err, errors.InvalidRequest, "list policies with filter "+filter)
+		_, err = ps.ListAllRolePolicies("books", filter)
+		expectCode(t, err, errors.InvalidRequest, "list role policies with filter "+filter)
+		_, err = ps.ListAllFunctions(filter)
+		expectCode(t, err, errors.InvalidRequest, "list functions with filter "+filter)
+	}
+	_, err := ps.ListAllFunctions("effect eq grant")
+	expectCode(t, err, errors.InvalidRequest, "list functions with filter on effect")
+}
+
+func testPagination(t *testing.T, ps pms.PolicyStoreManager) {
+	mustSucceed(t, ps.CreateService(&pms.Service{Name: "books", Type: pms.TypeApplication}), "create a service")
+	var names, denied []string
+	for i := 0; i < 7; i++ {
+		name := fmt.Sprintf("p%d", i)
+		policy, rolePolicy := newPolicy(name), newRolePolicy(name)
+		if i%2 == 1 {
+			policy.Effect, rolePolicy.Effect = pms.Deny, pms.Deny
+			denied = append(denied, name)
+		}
+		_, err := ps.CreatePolicy("books", policy)
+		mustSucceed(t, err, "create a policy")
+		_, err = ps.CreateRolePolicy("books", rolePolicy)
+		mustSucceed(t, err, "create a role policy")
+		names = append(names, name)
+	}
+
+	pages := []struct {
+		filter   string
+		limit    int
+		expected []string
+	}{
+		{"", 0, names},
+		{"", 3, names},
+		{"", 7, names},
+		{"effect eq deny", 2, denied},
+		{"effect eq deny and principal eq user:alice", 1, denied},
+		{"not effect eq deny and name ne p0", 2, []string{"p2", "p4", "p6"}},
+		{"name eq p9", 2, []string{}},
+	}
+	for _, p := range pages {
+		var policies []*pms.Policy
+		opts := &pms.ListOptions{Filter: p.filter, Limit: p.limit}
+		for i := 0; ; i++ {
+			// the last page could be empty, if it is the one after a full page
+			if i > len(names)+1 {
+				t.Fatalf("too many pages of policies with filter %q and limit %d", p.filter, p.limit)
+			}
+			page, token, err := ps.ListPolicies("books", opts)
+			mustSucceed(t, err, "list a page of policies")
+			if p.limit > 0 && len(page) > p.limit {
+				t.Errorf("expected at most %d policies in a page, but got %d", p.limit, len(page))
+			}
+			policies = append(policies, page...)
+			if len(token) == 0 {
+				break
+			}
+			opts.Continue = token
+		}
+		if !reflect.DeepEqual(policyNames(policies), p.expected) || len(policies) != len(p.expected) {
+			t.Errorf("pages of policies with filter %q and limit %d: expected %v, but got %v", p.filter, p.limit, p.expected, policyNames(policies))
+		}
+
+		var rolePolicies []*pms.RolePolicy
+		opts = &pms.ListOptions{Filter: strings.Replace(p.filter, "user:alice", "user:bob", 1), Limit: p.limit}
+		for i := 0; ; i++ {
+			if i > len(names)+1 {
+				t.Fatalf("too many pages of role policies with filter %q and limit %d", p.filter, p.limit)
+			}
+			page, token, err := ps.ListRolePolicies("books", opts)
+			mustSucceed(t, err, "list a page of role policies")
+			rolePolicies = append(rolePolicies, page...)
+			if len(token) == 0 {
+				break
+			}
+			opts.Continue = token
+		}
+		if !reflect.DeepEqual(rolePolicyNames(rolePolicies), p.expected) || len(rolePolicies) != len(p.expected) {
+			t.Errorf("pages of role policies with filter %q and limit %d: expected %v, but got %v", p.filter, p.limit, p.expected, rolePolicyNames(rolePolicies))
+		}
+	}
+
+	// a page continues after the previous one even if its last policy is deleted
+	page, token, err := ps.ListPolicies("books", &pms.ListOptions{Limit: 2})
+	mustSucceed(t, err, "list the first page of policies")
+	mustSucceed(t, ps.DeletePolicy("books", page[1].ID), "delete a policy")
+	rest, _, err := ps.ListPolicies("books", &pms.ListOptions{Continue: token})
+	mustSucceed(t, err, "list the rest of policies")
+	if len(page)+len(rest) != len(names) {
+		t.Errorf("expected %d policies in the pages, but got %v and %v", len(names), policyNames(page), policyNames(rest))
+	}
+
+	_, _, err = ps.ListPolicies("books", &pms.ListOptions{Limit: -1})
+	expectCode(t, err, errors.InvalidRequest, "list policies with a negative limit")
+	_, _, err = ps.ListPolicies("books", &pms.ListOptions{Continue: "not a token!"})
+	expectCode(t, err, errors.InvalidRequest, "list policies with an invalid continue token")
+	_, _, err = ps.ListRolePolicies("books", &pms.ListOptions{Filter: "action eq get"})
+	expectCode(t, err, errors.InvalidRequest, "list role policies with filter on action")
+	_, _, err = ps.ListPolicies("newspapers", &pms.ListOptions{Limit: 2})
+	expectCode(t, err, errors.EntityNotFound, "list policies of a missing service")
+	_, _, err = ps.ListRolePolicies("newspapers", nil)
+	expectCode(t, err, errors.EntityNotFound, "list role policies of a missing service")
+}
+
+func testIDGeneration(t *testing.T, ps pms.PolicyStoreManager) {
+	mustSucceed(t, ps.CreateService(&pms.Service{
+		Name:         "books",
+		Type:         pms.TypeApplication,
+		Policies:     []*pms.Policy{newPolicy("p1"), newPolicy("p2")},
+		RolePolicies: []*pms.RolePolicy{newRolePolicy("rp1"), newRolePolicy("rp2")},
+	}), "create a service")
+	service, err := ps.GetService("books")
+	mustSucceed(t, err, "get a service")
+
+	ids := map[string]bool{}
+	checkID := func(kind, id string) {
+		if len(id) == 0 {
+			t.Errorf("no ID is generated for a %s", kind)
+		} else if ids[id] {
+			t.Errorf("ID %q of a %s is not unique", id, kind)
+		}
+		ids[id] = true
+	}
+	for _, policy := range service.Policies {
+		checkID("policy created with its service", policy.ID)
+		if got, err := ps.GetPolicy("books", policy.ID); err != nil || got.Name != policy.Name {
+			t.Errorf("policy %q created with its service could not be got by its ID: %v", policy.ID, err)
+		}
+	}
+	for _, rolePolicy := range service.RolePolicies {
+		checkID("role policy created with its service", rolePolicy.ID)
+		if got, err := ps.GetRolePolicy("books", rolePolicy.ID); err != nil || got.Name != rolePolicy.Name {
+			t.Errorf("role policy %q created with its service could not be got by its ID: %v", rolePolicy.ID, err)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		policy, err := ps.CreatePolicy("books", newPolicy("p"))
+		mustSucceed(t, err, "create a policy")
+		checkID("policy", policy.ID)
+		rolePolicy, err := ps.CreateRolePolicy("books", newRolePolicy("rp"))
+		mustSucceed(t, err, "create a role policy")
+		checkID("role policy", rolePolicy.ID)
+	}
+	results, err := ps.ExecuteTransaction([]*pms.Operation{
+		{Op: pms.OpCreate, Kind: pms.KindPolicy, ServiceName: "books", Policy: newPolicy("p")},
+		{Op: pms.OpCreate, Kind: pms.KindRolePolicy, ServiceName: "books", RolePolicy: newRolePolicy("rp")},
+	})
+	mustSucceed(t, err, "execute a transaction")
+	for _, result := range results {
+		checkID(result.Kind+" created in a transaction", result.ID)
+	}
+
+	if count, err := ps.GetPolicyCount("books"); err != nil || count != 23 {
+		t.Errorf("expected 23 policies with different IDs, but got %d, %v", count, err)
+	}
+	if count, err := ps.GetRolePolicyCount("books"); err != nil || count != 23 {
+		t.Errorf("expected 23 role policies with different IDs, but got %d, %v", count, err)
+	}
+}
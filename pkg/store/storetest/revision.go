@@ -0,0 +1,116 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package storetest
+
+import (
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+)
+
+func testRevisions(t *testing.T, ps pms.PolicyStoreManager) {
+	mustSucceed(t, ps.CreateService(&pms.Service{Name: "books", Type: pms.TypeApplication}), "create a service")
+	service, err := ps.GetService("books")
+	mustSucceed(t, err, "get a service")
+	if service.Revision <= 0 {
+		t.Fatalf("revision of a service should be positive, but got %d", service.Revision)
+	}
+
+	policy, err := ps.CreatePolicy("books", newPolicy("p1"))
+	mustSucceed(t, err, "create a policy")
+	policy, err = ps.GetPolicy("books", policy.ID)
+	mustSucceed(t, err, "get a policy")
+	stale := *policy
+	policy.Effect = pms.Deny
+	updated, err := ps.UpdatePolicy("books", policy)
+	mustSucceed(t, err, "update a policy with its current revision")
+	if updated.Revision == stale.Revision {
+		t.Errorf("revision of a policy should change after an update, but got %d", updated.Revision)
+	}
+	_, err = ps.UpdatePolicy("books", &stale)
+	expectCode(t, err, errors.RevisionConflict, "update a policy with a stale revision")
+	stale.Revision = 0
+	_, err = ps.UpdatePolicy("books", &stale)
+	mustSucceed(t, err, "update a policy without revision")
+
+	// any change in a service changes its revision
+	changed, err := ps.GetService("books")
+	mustSucceed(t, err, "get a service")
+	if changed.Revision == service.Revision {
+		t.Errorf("revision of a service should change after its policies are changed, but got %d", changed.Revision)
+	}
+	expectCode(t, ps.UpdateService(&pms.Service{Name: "books", Type: pms.TypeK8SCluster, Revision: service.Revision}),
+		errors.RevisionConflict, "update a service with a stale revision")
+	mustSucceed(t, ps.UpdateService(&pms.Service{Name: "books", Type: pms.TypeK8SCluster, Revision: changed.Revision}),
+		"update a service with its current revision")
+
+	rolePolicy, err := ps.CreateRolePolicy("books", newRolePolicy("rp1"))
+	mustSucceed(t, err, "create a role policy")
+	rolePolicy, err = ps.GetRolePolicy("books", rolePolicy.ID)
+	mustSucceed(t, err, "get a role policy")
+	rolePolicy.Roles = []string{"writer"}
+	_, err = ps.UpdateRolePolicy("books", rolePolicy)
+	mustSucceed(t, err, "update a role policy with its current revision")
+	_, err = ps.UpdateRolePolicy("books", rolePolicy)
+	expectCode(t, err, errors.RevisionConflict, "update a role policy with a stale revision")
+
+	_, err = ps.CreateFunction(newFunction("f1"))
+	mustSucceed(t, err, "create a function")
+	function, err := ps.GetFunction("f1")
+	mustSucceed(t, err, "get a function")
+	function.ResultTTL = 120
+	_, err = ps.UpdateFunction(function)
+	mustSucceed(t, err, "update a function with its current revision")
+	_, err = ps.UpdateFunction(function)
+	expectCode(t, err, errors.RevisionConflict, "update a function with a stale revision")
+	missing := newFunction("f2")
+	missing.Revision = 1
+	_, err = ps.UpdateFunction(missing)
+	expectCode(t, err, errors.EntityNotFound, "update a missing function with a revision")
+
+	// a deletion with the expected revision fails if the entity is changed
+	deletions := []struct {
+		op      pms.Operation
+		current func() (int64, error)
+	}{
+		{pms.Operation{Op: pms.OpDelete, Kind: pms.KindPolicy, ServiceName: "books", ID: policy.ID, Revision: updated.Revision}, func() (int64, error) {
+			current, err := ps.GetPolicy("books", policy.ID)
+			if err != nil {
+				return 0, err
+			}
+			return current.Revision, nil
+		}},
+		{pms.Operation{Op: pms.OpDelete, Kind: pms.KindRolePolicy, ServiceName: "books", ID: rolePolicy.ID, Revision: rolePolicy.Revision}, func() (int64, error) {
+			current, err := ps.GetRolePolicy("books", rolePolicy.ID)
+			if err != nil {
+				return 0, err
+			}
+			return current.Revision, nil
+		}},
+		{pms.Operation{Op: pms.OpDelete, Kind: pms.KindFunction, ID: "f1", Revision: function.Revision}, func() (int64, error) {
+			current, err := ps.GetFunction("f1")
+			if err != nil {
+				return 0, err
+			}
+			return current.Revision, nil
+		}},
+		{pms.Operation{Op: pms.OpDelete, Kind: pms.KindService, ID: "books", Revision: changed.Revision}, func() (int64, error) {
+			current, err := ps.GetService("books")
+			if err != nil {
+				return 0, err
+			}
+			return current.Revision, nil
+		}},
+	}
+	for _, deletion := range deletions {
+		op := deletion.op
+		_, err = ps.ExecuteTransaction([]*pms.Operation{&op})
+		expectCode(t, err, errors.RevisionConflict, "delete a "+op.Kind+" with a stale revision")
+		op.Revision, err = deletion.current()
+		mustSucceed(t, err, "get the current revision of a "+op.Kind)
+		_, err = ps.ExecuteTransaction([]*pms.Operation{&op})
+		mustSucceed(t, err, "delete a "+op.Kind+" with its current revision")
+	}
+}
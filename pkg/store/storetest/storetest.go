@@ -0,0 +1,228 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+// Package storetest is a conformance suite for policy stores. Any store registered with store.Register
+// could run it from its own tests to check it behaves like the built-in stores:
+//
+//	func TestConformance(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) (pms.PolicyStoreManager, func()) {
+//			ps, err := store.NewStore(StoreType, config)
+//			if err != nil {
+//				t.Fatal("fail to new store:", err)
+//			}
+//			return ps, func() { ... }
+//		})
+//	}
+//
+// The suite covers CRUD, revisions, transactions, counts, filters, pagination, ID generation, watch events,
+// concurrent writers and, for stores implementing store.DiscoverRequestManager and store.HistoryManager,
+// the discover and history APIs.
+package storetest
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+)
+
+// NewStoreFunc returns an empty store for a test, and the function releasing it
+type NewStoreFunc func(t *testing.T) (pms.PolicyStoreManager, func())
+
+type conformanceTest struct {
+	name string
+	run  func(t *testing.T, ps pms.PolicyStoreManager)
+}
+
+var conformanceTests = []conformanceTest{
+	{"Services", testServices},
+	{"Policies", testPolicies},
+	{"RolePolicies", testRolePolicies},
+	{"Functions", testFunctions},
+	{"PolicyStore", testPolicyStore},
+	{"Transaction", testTransaction},
+	{"Revisions", testRevisions},
+	{"Counts", testCounts},
+	{"Filters", testFilters},
+	{"Pagination", testPagination},
+	{"IDGeneration", testIDGeneration},
+	{"ConcurrentWriters", testConcurrentWriters},
+	{"Watch", testWatch},
+	{"Discover", testDiscover},
+	{"History", testHistory},
+	{"HistoryRetention", testHistoryRetention},
+}
+
+// Run runs the conformance tests as subtests of t, each of them on a new store got from newStore
+func Run(t *testing.T, newStore NewStoreFunc) {
+	for _, test := range conformanceTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			ps, cleanup := newStore(t)
+			defer cleanup()
+			test.run(t, ps)
+		})
+	}
+}
+
+// expectCode fails the test if err does not have the code
+func expectCode(t *testing.T, err error, code errors.ErrorCode, action string) {
+	t.Helper()
+	if errors.Code(err) != code {
+		t.Errorf("%s: expected error code %v, but got %v", action, code, err)
+	}
+}
+
+// mustSucceed stops the test if err is not nil
+func mustSucceed(t *testing.T, err error, action string) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", action, err)
+	}
+}
+
+// canonical returns the JSON of a policy store with revisions removed and entities sorted,
+// so two stores with the same content have the same canonical form
+func canonical(ps *pms.PolicyStore) string {
+	var dup pms.PolicyStore
+	if ps != nil {
+		dup.Services = ps.Services
+		dup.Functions = ps.Functions
+	}
+	raw, err := json.Marshal(dup)
+	if err != nil {
+		return err.Error()
+	}
+	dup = pms.PolicyStore{}
+	if err := json.Unmarshal(raw, &dup); err != nil {
+		return err.Error()
+	}
+	sort.Slice(dup.Services, func(i, j int) bool { return dup.Services[i].Name < dup.Services[j].Name })
+	for _, service := range dup.Services {
+		service.Revision = 0
+		sort.Slice(service.Policies, func(i, j int) bool { return service.Policies[i].ID < service.Policies[j].ID })
+		for _, policy := range service.Policies {
+			policy.Revision = 0
+		}
+		sort.Slice(service.RolePolicies, func(i, j int) bool { return service.RolePolicies[i].ID < service.RolePolicies[j].ID })
+		for _, rolePolicy := range service.RolePolicies {
+			rolePolicy.Revision = 0
+		}
+	}
+	sort.Slice(dup.Functions, func(i, j int) bool { return dup.Functions[i].Name < dup.Functions[j].Name })
+	for _, function := range dup.Functions {
+		function.Revision = 0
+	}
+	raw, err = json.Marshal(dup)
+	if err != nil {
+		return err.Error()
+	}
+	return string(raw)
+}
+
+// samePolicy compares two policies ignoring their IDs and revisions
+func samePolicy(p1, p2 *pms.Policy) bool {
+	dup1, dup2 := *p1, *p2
+	dup1.ID, dup1.Revision, dup2.ID, dup2.Revision = "", 0, "", 0
+	return toJSON(&dup1) == toJSON(&dup2)
+}
+
+// sameRolePolicy compares two role policies ignoring their IDs and revisions
+func sameRolePolicy(p1, p2 *pms.RolePolicy) bool {
+	dup1, dup2 := *p1, *p2
+	dup1.ID, dup1.Revision, dup2.ID, dup2.Revision = "", 0, "", 0
+	return toJSON(&dup1) == toJSON(&dup2)
+}
+
+// sameFunction compares two functions ignoring their revisions
+func sameFunction(f1, f2 *pms.Function) bool {
+	dup1, dup2 := *f1, *f2
+	dup1.Revision, dup2.Revision = 0, 0
+	return toJSON(&dup1) == toJSON(&dup2)
+}
+
+func toJSON(v interface{}) string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err.Error()
+	}
+	return string(raw)
+}
+
+func newPolicy(name string) *pms.Policy {
+	return &pms.Policy{
+		Name:   name,
+		Effect: pms.Grant,
+		Permissions: []*pms.Permission{
+			{Resource: "/books/" + name, Actions: []string{"get", "update"}},
+			{ResourceExpression: "/shelves/.*", Actions: []string{"list"}},
+			{ResourceGlob: "/authors/{" + name + ",anonymous}/**", Actions: []string{"get"}},
+		},
+		Principals: [][]string{{"user:alice", "group:readers"}, {"role:admin"}},
+		Condition:  "age > 18",
+		Priority:   2,
+		Metadata:   map[string]string{"createdBy": "storetest"},
+	}
+}
+
+func newRolePolicy(name string) *pms.RolePolicy {
+	return &pms.RolePolicy{
+		Name:                name,
+		Effect:              pms.Grant,
+		Roles:               []string{"reader"},
+		Principals:          []string{"user:bob", "group:staff"},
+		Resources:           []string{"/books/" + name},
+		ResourceExpressions: []string{"/shelves/.*"},
+		ResourceGlobs:       []string{"/authors/*/books/**"},
+		Condition:           "age > 18",
+		Metadata:            map[string]string{"createdBy": "storetest"},
+	}
+}
+
+func newFunction(name string) *pms.Function {
+	return &pms.Function{
+		Name:           name,
+		Description:    "function " + name,
+		FuncURL:        "http://localhost:9999/funcs/" + name,
+		ResultCachable: true,
+		ResultTTL:      60,
+	}
+}
+
+// policyNames returns the sorted names of policies
+func policyNames(policies []*pms.Policy) []string {
+	names := []string{}
+	for _, policy := range policies {
+		names = append(names, policy.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// rolePolicyNames returns the sorted names of role policies
+func rolePolicyNames(rolePolicies []*pms.RolePolicy) []string {
+	names := []string{}
+	for _, rolePolicy := range rolePolicies {
+		names = append(names, rolePolicy.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// functionNames returns the sorted names of functions
+func functionNames(functions []*pms.Function) []string {
+	names := []string{}
+	for _, function := range functions {
+		names = append(names, function.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedStrings(values []string) []string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return sorted
+}
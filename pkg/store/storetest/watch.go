@@ -0,0 +1,395 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package storetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+)
+
+const (
+	// watchTimeout is how long the events of the changes made by testWatch could take to arrive
+	watchTimeout = 20 * time.Second
+	// watchQuietPeriod is how long no event arrives before the events are regarded as all received
+	watchQuietPeriod = 500 * time.Millisecond
+)
+
+var eventNames = map[pms.EventType]string{
+	pms.INVALID:           "INVALID",
+	pms.SERVICE_DELETE:    "SERVICE_DELETE",
+	pms.SERVICE_ADD:       "SERVICE_ADD",
+	pms.POLICY_DELETE:     "POLICY_DELETE",
+	pms.POLICY_ADD:        "POLICY_ADD",
+	pms.ROLEPOLICY_DELETE: "ROLEPOLICY_DELETE",
+	pms.ROLEPOLICY_ADD:    "ROLEPOLICY_ADD",
+	pms.FUNCTION_DELETE:   "FUNCTION_DELETE",
+	pms.FUNCTION_ADD:      "FUNCTION_ADD",
+	pms.SYNC_RELOAD:       "SYNC_RELOAD",
+	pms.FULL_RELOAD:       "FULL_RELOAD",
+	pms.BATCH:             "BATCH",
+}
+
+func eventName(eventType pms.EventType) string {
+	if name, ok := eventNames[eventType]; ok {
+		return name
+	}
+	return fmt.Sprintf("EventType(%d)", eventType)
+}
+
+// replica is a copy of the store kept up to date by the watch events, the same way as the runtime cache of
+// the evaluator. Events applied in a wrong order, or with wrong content, make it diverge from the store.
+type replica struct {
+	store     pms.PolicyStoreManager
+	services  map[string]*pms.Service
+	functions map[string]*pms.Function
+	// received counts the events by type, including the ones in batches
+	received map[pms.EventType]int
+}
+
+func newReplica(ps pms.PolicyStoreManager) (*replica, error) {
+	r := &replica{store: ps, received: map[pms.EventType]int{}}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload replaces the content of the replica with the current content of the store
+func (r *replica) reload() error {
+	ps, err := r.store.ReadPolicyStore()
+	if err != nil {
+		return err
+	}
+	r.services = map[string]*pms.Service{}
+	for _, service := range ps.Services {
+		var dup pms.Service
+		if err := deepCopy(service, &dup); err != nil {
+			return err
+		}
+		r.services[dup.Name] = &dup
+	}
+	r.functions = map[string]*pms.Function{}
+	for _, function := range ps.Functions {
+		dup := *function
+		r.functions[dup.Name] = &dup
+	}
+	return nil
+}
+
+func (r *replica) policyStore() *pms.PolicyStore {
+	var ps pms.PolicyStore
+	for _, service := range r.services {
+		ps.Services = append(ps.Services, service)
+	}
+	for _, function := range r.functions {
+		ps.Functions = append(ps.Functions, function)
+	}
+	return &ps
+}
+
+// apply checks the content of an event against its type and applies it
+func (r *replica) apply(e pms.StoreChangeEvent) error {
+	r.received[e.Type]++
+	switch e.Type {
+	case pms.SERVICE_ADD:
+		service, ok := e.Content.(*pms.Service)
+		if !ok || service == nil || len(service.Name) == 0 {
+			return fmt.Errorf("content of SERVICE_ADD should be a *pms.Service with name, but got %T %v", e.Content, e.Content)
+		}
+		var dup pms.Service
+		if err := deepCopy(service, &dup); err != nil {
+			return err
+		}
+		r.services[dup.Name] = &dup
+	case pms.SERVICE_DELETE:
+		names, ok := e.Content.([]string)
+		if !ok || len(names) == 0 {
+			return fmt.Errorf("content of SERVICE_DELETE should be the names of services, but got %T %v", e.Content, e.Content)
+		}
+		for _, name := range names {
+			delete(r.services, name)
+		}
+	case pms.POLICY_ADD, pms.POLICY_DELETE:
+		data, ok := e.Content.([]pms.StoreUpdateData)
+		if !ok || len(data) == 0 {
+			return fmt.Errorf("content of %s should be []pms.StoreUpdateData, but got %T %v", eventName(e.Type), e.Content, e.Content)
+		}
+		for _, d := range data {
+			policy, ok := d.Data.(*pms.Policy)
+			if !ok || policy == nil || len(policy.ID) == 0 {
+				return fmt.Errorf("data of %s should be a *pms.Policy with ID, but got %T %v", eventName(e.Type), d.Data, d.Data)
+			}
+			service, ok := r.services[d.ServiceName]
+			if !ok {
+				return fmt.Errorf("%s of policy %q arrives when service %q does not exist", eventName(e.Type), policy.ID, d.ServiceName)
+			}
+			var policies []*pms.Policy
+			for _, existing := range service.Policies {
+				if existing.ID != policy.ID {
+					policies = append(policies, existing)
+				}
+			}
+			if e.Type == pms.POLICY_ADD {
+				dup := *policy
+				policies = append(policies, &dup)
+			}
+			service.Policies = policies
+		}
+	case pms.ROLEPOLICY_ADD, pms.ROLEPOLICY_DELETE:
+		data, ok := e.Content.([]pms.StoreUpdateData)
+		if !ok || len(data) == 0 {
+			return fmt.Errorf("content of %s should be []pms.StoreUpdateData, but got %T %v", eventName(e.Type), e.Content, e.Content)
+		}
+		for _, d := range data {
+			rolePolicy, ok := d.Data.(*pms.RolePolicy)
+			if !ok || rolePolicy == nil || len(rolePolicy.ID) == 0 {
+				return fmt.Errorf("data of %s should be a *pms.RolePolicy with ID, but got %T %v", eventName(e.Type), d.Data, d.Data)
+			}
+			service, ok := r.services[d.ServiceName]
+			if !ok {
+				return fmt.Errorf("%s of role policy %q arrives when service %q does not exist", eventName(e.Type), rolePolicy.ID, d.ServiceName)
+			}
+			var rolePolicies []*pms.RolePolicy
+			for _, existing := range service.RolePolicies {
+				if existing.ID != rolePolicy.ID {
+					rolePolicies = append(rolePolicies, existing)
+				}
+			}
+			if e.Type == pms.ROLEPOLICY_ADD {
+				dup := *rolePolicy
+				rolePolicies = append(rolePolicies, &dup)
+			}
+			service.RolePolicies = rolePolicies
+		}
+	case pms.FUNCTION_ADD:
+		function, ok := e.Content.(*pms.Function)
+		if !ok || function == nil || len(function.Name) == 0 {
+			return fmt.Errorf("content of FUNCTION_ADD should be a *pms.Function with name, but got %T %v", e.Content, e.Content)
+		}
+		dup := *function
+		r.functions[dup.Name] = &dup
+	case pms.FUNCTION_DELETE:
+		names, ok := e.Content.([]string)
+		if !ok || len(names) == 0 {
+			return fmt.Errorf("content of FUNCTION_DELETE should be the names of functions, but got %T %v", e.Content, e.Content)
+		}
+		for _, name := range names {
+			delete(r.functions, name)
+		}
+	case pms.FULL_RELOAD:
+		return r.reload()
+	case pms.BATCH:
+		events, ok := e.Content.([]pms.StoreChangeEvent)
+		if !ok || len(events) == 0 {
+			return fmt.Errorf("content of BATCH should be []pms.StoreChangeEvent, but got %T %v", e.Content, e.Content)
+		}
+		for _, event := range events {
+			if event.Type == pms.BATCH {
+				return fmt.Errorf("BATCH should not be nested")
+			}
+			if err := r.apply(event); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%s is not expected from a store", eventName(e.Type))
+	}
+	return nil
+}
+
+func deepCopy(from, to interface{}) error {
+	raw, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, to)
+}
+
+// watchStep is a change made to the store while it is watched
+type watchStep struct {
+	name   string
+	change func(ps pms.PolicyStoreManager) error
+}
+
+var watchSteps = []watchStep{
+	{"create a service with policies", func(ps pms.PolicyStoreManager) error {
+		return ps.CreateService(&pms.Service{Name: "books", Type: pms.TypeApplication,
+			Policies: []*pms.Policy{newPolicy("p1")}, RolePolicies: []*pms.RolePolicy{newRolePolicy("rp1")}})
+	}},
+	{"create an empty service", func(ps pms.PolicyStoreManager) error {
+		return ps.CreateService(&pms.Service{Name: "magazines", Type: pms.TypeApplication})
+	}},
+	{"create and update a policy", func(ps pms.PolicyStoreManager) error {
+		policy, err := ps.CreatePolicy("books", newPolicy("p2"))
+		if err != nil {
+			return err
+		}
+		policy.Effect = pms.Deny
+		_, err = ps.UpdatePolicy("books", policy)
+		return err
+	}},
+	{"create a role policy and delete another one", func(ps pms.PolicyStoreManager) error {
+		if _, err := ps.CreateRolePolicy("books", newRolePolicy("rp2")); err != nil {
+			return err
+		}
+		rolePolicies, err := ps.ListAllRolePolicies("books", "name eq rp1")
+		if err != nil || len(rolePolicies) != 1 {
+			return fmt.Errorf("role policy rp1 is not found: %v", err)
+		}
+		return ps.DeleteRolePolicy("books", rolePolicies[0].ID)
+	}},
+	{"delete a policy", func(ps pms.PolicyStoreManager) error {
+		policies, err := ps.ListAllPolicies("books", "name eq p1")
+		if err != nil || len(policies) != 1 {
+			return fmt.Errorf("policy p1 is not found: %v", err)
+		}
+		return ps.DeletePolicy("books", policies[0].ID)
+	}},
+	{"update a service", func(ps pms.PolicyStoreManager) error {
+		return ps.UpdateService(&pms.Service{Name: "books", Type: pms.TypeApplication, CombiningAlgorithm: pms.PermitOverrides})
+	}},
+	{"create, update and delete functions", func(ps pms.PolicyStoreManager) error {
+		function, err := ps.CreateFunction(newFunction("f1"))
+		if err != nil {
+			return err
+		}
+		function.FuncURL = "http://localhost:9999/funcs/f1/v2"
+		if _, err := ps.UpdateFunction(function); err != nil {
+			return err
+		}
+		if _, err := ps.CreateFunction(newFunction("f2")); err != nil {
+			return err
+		}
+		return ps.DeleteFunction("f2")
+	}},
+	{"execute a transaction", func(ps pms.PolicyStoreManager) error {
+		policies, err := ps.ListAllPolicies("books", "name eq p2")
+		if err != nil || len(policies) != 1 {
+			return fmt.Errorf("policy p2 is not found: %v", err)
+		}
+		_, err = ps.ExecuteTransaction([]*pms.Operation{
+			{Op: pms.OpCreate, Kind: pms.KindPolicy, ServiceName: "magazines", Policy: newPolicy("p3")},
+			{Op: pms.OpCreate, Kind: pms.KindRolePolicy, ServiceName: "magazines", RolePolicy: newRolePolicy("rp3")},
+			{Op: pms.OpDelete, Kind: pms.KindPolicy, ServiceName: "books", ID: policies[0].ID},
+			{Op: pms.OpCreate, Kind: pms.KindFunction, Function: newFunction("f3")},
+		})
+		return err
+	}},
+	{"delete and recreate a service", func(ps pms.PolicyStoreManager) error {
+		if err := ps.DeleteService("magazines"); err != nil {
+			return err
+		}
+		return ps.CreateService(&pms.Service{Name: "magazines", Type: pms.TypeK8SCluster, Policies: []*pms.Policy{newPolicy("p4")}})
+	}},
+	{"delete all policies and role policies of a service", func(ps pms.PolicyStoreManager) error {
+		if err := ps.DeletePolicies("books"); err != nil {
+			return err
+		}
+		return ps.DeleteRolePolicies("books")
+	}},
+	{"delete and recreate a function", func(ps pms.PolicyStoreManager) error {
+		if err := ps.DeleteFunction("f1"); err != nil {
+			return err
+		}
+		function := newFunction("f1")
+		function.FuncURL = "http://localhost:9999/funcs/f1/v3"
+		_, err := ps.CreateFunction(function)
+		return err
+	}},
+	{"delete all functions", func(ps pms.PolicyStoreManager) error {
+		return ps.DeleteFunctions()
+	}},
+	{"delete all services and create one", func(ps pms.PolicyStoreManager) error {
+		if err := ps.DeleteServices(); err != nil {
+			return err
+		}
+		return ps.CreateService(&pms.Service{Name: "newspapers", Type: pms.TypeApplication, RolePolicies: []*pms.RolePolicy{newRolePolicy("rp5")}})
+	}},
+}
+
+func testWatch(t *testing.T, ps pms.PolicyStoreManager) {
+	ch, err := ps.Watch()
+	mustSucceed(t, err, "watch the store")
+	events := make(chan pms.StoreChangeEvent, 10000)
+	go func() {
+		for e := range ch {
+			events <- e
+		}
+	}()
+	defer ps.StopWatch()
+
+	// a store may start watching asynchronously, so make sure it is ready before making changes
+	ready := false
+	for i := 0; i < 50 && !ready; i++ {
+		function := newFunction("probe")
+		function.Description = fmt.Sprintf("probe %d", i)
+		if i == 0 {
+			_, err = ps.CreateFunction(function)
+		} else {
+			_, err = ps.UpdateFunction(function)
+		}
+		mustSucceed(t, err, "change the store before watching")
+		select {
+		case <-events:
+			ready = true
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+	if !ready {
+		t.Fatal("no event is received from the store")
+	}
+
+	r, err := newReplica(ps)
+	mustSucceed(t, err, "read the store")
+	for i, step := range watchSteps {
+		if err := step.change(ps); err != nil {
+			t.Fatalf("step %q: %v", step.name, err)
+		}
+		// converge in the middle as well, so the ordering of events of different steps are checked separately
+		if i == len(watchSteps)/2 {
+			waitForReplica(t, ps, r, events)
+		}
+	}
+	waitForReplica(t, ps, r, events)
+
+	counts := map[string]int{}
+	for eventType, count := range r.received {
+		counts[eventName(eventType)] = count
+	}
+	t.Logf("events received: %v", counts)
+}
+
+// waitForReplica applies the events received until the replica has the same content as the store,
+// and no more event arrives in watchQuietPeriod
+func waitForReplica(t *testing.T, ps pms.PolicyStoreManager, r *replica, events <-chan pms.StoreChangeEvent) {
+	t.Helper()
+	deadline := time.Now().Add(watchTimeout)
+	var lastID int64
+	for {
+		select {
+		case e := <-events:
+			if e.ID < lastID {
+				t.Errorf("event %s with ID %d arrives after the one with ID %d", eventName(e.Type), e.ID, lastID)
+			}
+			lastID = e.ID
+			if err := r.apply(e); err != nil {
+				t.Fatalf("invalid event: %v", err)
+			}
+		case <-time.After(watchQuietPeriod):
+			current, err := ps.ReadPolicyStore()
+			mustSucceed(t, err, "read the store")
+			want, got := canonical(current), canonical(r.policyStore())
+			if want == got {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("the store watched is different from the store, expected:\n%s\nbut got:\n%s", want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+)
+
+// DefaultTenant is the tenant of the requests choosing no tenant, its policies are kept where they were before tenants
+// are supported
+const DefaultTenant = ""
+
+// tenantPattern limits the tenant names to the ones valid as a file name, a key of etcd, a database of mongodb and
+// MySQL, and a schema of PostgreSQL
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_]{0,31}$`)
+
+// TenantStoreBuilder is implemented by the store builders supporting tenants. The services and functions of every
+// tenant are kept in a namespace of their own, e.g. an etcd key prefix or a mongodb database.
+type TenantStoreBuilder interface {
+	// TenantStoreConfig returns the configuration of the store of a tenant derived from the one of the default tenant
+	TenantStoreConfig(storeConfig map[string]interface{}, tenant string) (map[string]interface{}, error)
+}
+
+// ValidateTenant checks a tenant name, which consists of at most 32 lower case letters, digits and underscores
+func ValidateTenant(tenant string) error {
+	if !tenantPattern.MatchString(tenant) {
+		return errors.Errorf(errors.InvalidRequest, "invalid tenant %q, it should consist of at most 32 lower case letters, digits and underscores", tenant)
+	}
+	return nil
+}
+
+// TenantStoreConfig returns the configuration of the store of a tenant, which is storeConfig for the default tenant
+func TenantStoreConfig(storeType string, storeConfig map[string]interface{}, tenant string) (map[string]interface{}, error) {
+	if tenant == DefaultTenant {
+		return storeConfig, nil
+	}
+	if err := ValidateTenant(tenant); err != nil {
+		return nil, errors.Wrap(err, errors.ConfigError, "failed to configure the store of tenant")
+	}
+	storeBuildersMu.RLock()
+	storeBuilder, ok := storeBuilders[storeType]
+	storeBuildersMu.RUnlock()
+	if !ok {
+		return nil, errors.Errorf(errors.ConfigError, "unknown store type %q (forgotten import?)", storeType)
+	}
+	tenantBuilder, ok := storeBuilder.(TenantStoreBuilder)
+	if !ok {
+		return nil, errors.Errorf(errors.ConfigError, "store type %q does not support tenants", storeType)
+	}
+	return tenantBuilder.TenantStoreConfig(copyConfig(storeConfig), tenant)
+}
+
+// NewTenantStore creates the store of a tenant
+func NewTenantStore(storeType string, storeConfig map[string]interface{}, tenant string) (pms.PolicyStoreManager, error) {
+	config, err := TenantStoreConfig(storeType, storeConfig, tenant)
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(storeType, config)
+}
+
+// TenantPath returns the path of a file of a tenant, which is in the directory named after the tenant next to the
+// file of the default tenant, so the files kept beside it, e.g. the discover requests of the file store, are
+// separated too. The directory is created if it does not exist.
+func TenantPath(path, tenant string) (string, error) {
+	dir, file := filepath.Split(path)
+	dir = filepath.Join(dir, tenant)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", errors.Wrapf(err, errors.ConfigError, "failed to create the directory of tenant %s", tenant)
+	}
+	return filepath.Join(dir, file), nil
+}
+
+func copyConfig(storeConfig map[string]interface{}) map[string]interface{} {
+	config := make(map[string]interface{}, len(storeConfig))
+	for k, v := range storeConfig {
+		config[k] = v
+	}
+	return config
+}
@@ -30,3 +30,16 @@ func readPolicyStore(reader io.Reader) (*pms.PolicyStore, error) {
 	}
 	return &policyStore, nil
 }
+
+// SendChangeEvents sends the events caused by one change of the store. Multiple events,
+// e.g. the ones caused by a transaction, are sent as one BATCH event, which the evaluator applies
+// to its runtime cache under one write lock, so no request sees a part of the change.
+func SendChangeEvents(ch pms.StorageChangeChannel, events []pms.StoreChangeEvent) {
+	switch len(events) {
+	case 0:
+	case 1:
+		ch <- events[0]
+	default:
+		ch <- pms.StoreChangeEvent{Type: pms.BATCH, ID: events[0].ID, Content: events}
+	}
+}
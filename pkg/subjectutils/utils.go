@@ -5,6 +5,7 @@ package subjectutils
 
 import (
 	"fmt"
+	"strings"
 
 	adsapi "github.com/teramoby/speedle-plus/api/ads"
 )
@@ -17,3 +18,23 @@ func EncodePrincipal(principal *adsapi.Principal) string {
 	}
 	return fmt.Sprintf("%s:%s", principal.Type, principal.Name)
 }
+
+// DecodePrincipal decodes a string in the form of EncodePrincipal to principal object.
+// The IDD is taken up to the first colon, so it should not contain colons.
+func DecodePrincipal(encoded string) (*adsapi.Principal, error) {
+	var principal adsapi.Principal
+	rest := encoded
+	if strings.HasPrefix(rest, "idd=") {
+		parts := strings.SplitN(rest[len("idd="):], ":", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			return nil, fmt.Errorf("invalid principal %q", encoded)
+		}
+		principal.IDD, rest = parts[0], parts[1]
+	}
+	parts := strings.SplitN(rest, ":", 2)
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return nil, fmt.Errorf("invalid principal %q", encoded)
+	}
+	principal.Type, principal.Name = parts[0], parts[1]
+	return &principal, nil
+}
@@ -185,6 +185,35 @@ func TestMats_GRpc_IsAllowed_User(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name:     "TestIsAllowedBatch",
+			Enabled:  true,
+			Executer: testutil.NewGRpcTestExecuter,
+			Method:   testutil.METHOD_IS_ALLOWED_BATCH,
+			Data: &testutil.GRpcTestData{
+				InputBody: &adsPB.BatchRequest{
+					Subject: &adsPB.Subject{
+						Principals: []*adsPB.Principal{
+							{
+								Type: "user",
+								Name: "userA",
+							},
+						},
+					},
+					Accesses: []*adsPB.Access{
+						{ServiceName: sName, Resource: "res1", Action: "read"},
+						{ServiceName: sName, Resource: "res1", Action: "invalid-read"},
+					},
+				},
+				OutputBody: &adsPB.BatchResponse{},
+				ExpectedBody: &adsPB.BatchResponse{
+					Decisions: []*adsPB.IsAllowedResponse{
+						{Allowed: true, Reason: int32(adsapi.GRANT_POLICY_FOUND)},
+						{Allowed: false, Reason: int32(adsapi.NO_APPLICABLE_POLICIES)},
+					},
+				},
+			},
+		},
 		{
 			Name:     "TesDeleteService1",
 			Enabled:  true,
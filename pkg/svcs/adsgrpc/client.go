@@ -0,0 +1,141 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package adsgrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/teramoby/speedle-plus/pkg/svcs/adsgrpc/pb"
+	"google.golang.org/grpc"
+)
+
+// ErrStreamClosed is returned by StreamClient.IsAllowed when the stream is closed by the client
+var ErrStreamClosed = errors.New("IsAllowed stream is closed")
+
+/*
+StreamClient multiplexes the IsAllowed calls of many goroutines over one IsAllowedStream.
+Each request is tagged with a unique correlation ID, and the response with the same ID is
+returned to the caller, no matter in which order the responses are received.
+*/
+type StreamClient struct {
+	stream pb.Evaluator_IsAllowedStreamClient
+	nextID uint64
+
+	sendLock sync.Mutex
+
+	lock    sync.Mutex
+	pending map[string]chan *pb.IsAllowedResponse
+	err     error
+	done    chan struct{}
+}
+
+// NewStreamClient opens an IsAllowedStream, the stream is alive until ctx is done or Close is called
+func NewStreamClient(ctx context.Context, client pb.EvaluatorClient, opts ...grpc.CallOption) (*StreamClient, error) {
+	stream, err := client.IsAllowedStream(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	sc := &StreamClient{
+		stream:  stream,
+		pending: make(map[string]chan *pb.IsAllowedResponse),
+		done:    make(chan struct{}),
+	}
+	go sc.receive()
+	return sc, nil
+}
+
+func (sc *StreamClient) receive() {
+	for {
+		response, err := sc.stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				err = ErrStreamClosed
+			}
+			sc.lock.Lock()
+			sc.err = err
+			sc.pending = nil
+			sc.lock.Unlock()
+			close(sc.done)
+			return
+		}
+		sc.lock.Lock()
+		ch, ok := sc.pending[response.CorrelationID]
+		delete(sc.pending, response.CorrelationID)
+		sc.lock.Unlock()
+		if ok {
+			// the channel is buffered, so it never blocks
+			ch <- response
+		}
+	}
+}
+
+// IsAllowed sends the request over the stream, and waits for its response. It is safe to be called by
+// multiple goroutines. The correlation ID in the request is replaced by the client.
+func (sc *StreamClient) IsAllowed(ctx context.Context, in *pb.ContextRequest) (*pb.IsAllowedResponse, error) {
+	id := strconv.FormatUint(atomic.AddUint64(&sc.nextID, 1), 10)
+	req := *in
+	req.CorrelationID = id
+
+	ch := make(chan *pb.IsAllowedResponse, 1)
+	sc.lock.Lock()
+	if sc.pending == nil {
+		err := sc.err
+		sc.lock.Unlock()
+		return nil, err
+	}
+	sc.pending[id] = ch
+	sc.lock.Unlock()
+
+	sc.sendLock.Lock()
+	err := sc.stream.Send(&req)
+	sc.sendLock.Unlock()
+	if err != nil {
+		sc.cancel(id)
+		if err == io.EOF {
+			// the real error is returned by Recv
+			<-sc.done
+			err = sc.err
+		}
+		return nil, err
+	}
+
+	select {
+	case response := <-ch:
+		return response, nil
+	case <-ctx.Done():
+		sc.cancel(id)
+		return nil, ctx.Err()
+	case <-sc.done:
+		// the response may be received right before the stream is broken
+		select {
+		case response := <-ch:
+			return response, nil
+		default:
+			return nil, sc.err
+		}
+	}
+}
+
+func (sc *StreamClient) cancel(id string) {
+	sc.lock.Lock()
+	delete(sc.pending, id)
+	sc.lock.Unlock()
+}
+
+// Close closes the sending side of the stream, and waits for the responses of the requests being evaluated
+func (sc *StreamClient) Close() error {
+	sc.sendLock.Lock()
+	err := sc.stream.CloseSend()
+	sc.sendLock.Unlock()
+	if err != nil {
+		return err
+	}
+	<-sc.done
+	return nil
+}
@@ -18,13 +18,16 @@ import (
 // GRPCService is the ADS GRPC implementation
 type GRPCService struct {
 	evaluator eval.InternalEvaluator
+	// StreamWorkers is the number of requests evaluated concurrently in an IsAllowedStream
+	StreamWorkers int
 }
 
 // NewGRPCService constructs a new ADS GRPC service instance
 func NewGRPCService(evaluator eval.InternalEvaluator) (*GRPCService, error) {
 
 	return &GRPCService{
-		evaluator: evaluator,
+		evaluator:     evaluator,
+		StreamWorkers: DefaultStreamWorkers,
 	}, nil
 }
 
@@ -78,9 +81,9 @@ func (impl *GRPCService) IsAllowed(ctx context.Context, in *pb.ContextRequest) (
 	reqCtx := convertGRPCContextRequest(in)
 
 	// assert token
-	impl.evaluator.AssertToken(reqCtx)
+	impl.evaluatorOf(ctx).AssertToken(reqCtx)
 
-	allowed, reason, err := impl.evaluator.IsAllowed(*reqCtx)
+	allowed, reason, err := impl.evaluatorOf(ctx).IsAllowed(*reqCtx)
 	if err != nil {
 		// Audit log
 		logging.WriteSimpleFailedAuditLog("[gRPC]IsAllowed", reqCtx, err.Error())
@@ -98,13 +101,63 @@ func (impl *GRPCService) IsAllowed(ctx context.Context, in *pb.ContextRequest) (
 	return &response, nil
 }
 
+func convertGRPCAccesses(accesses []*pb.Access) []adsapi.Access {
+	ret := make([]adsapi.Access, 0, len(accesses))
+	for _, access := range accesses {
+		apiAccess := adsapi.Access{
+			ServiceName: access.ServiceName,
+			Resource:    access.Resource,
+			Action:      access.Action,
+		}
+		if access.Attributes != nil {
+			apiAccess.Attributes = make(map[string]interface{})
+			for k, v := range access.Attributes {
+				apiAccess.Attributes[k] = v
+			}
+		}
+		ret = append(ret, apiAccess)
+	}
+	return ret
+}
+
+func (impl *GRPCService) IsAllowedBatch(ctx context.Context, in *pb.BatchRequest) (*pb.BatchResponse, error) {
+	subject := convertGRPCSubject(in.Subject)
+	accesses := convertGRPCAccesses(in.Accesses)
+
+	decisions, err := impl.evaluatorOf(ctx).IsAllowedBatch(subject, accesses)
+	if err != nil {
+		// Audit log
+		logging.WriteSimpleFailedAuditLog("[gRPC]IsAllowedBatch", in, err.Error())
+		return nil, err
+	}
+
+	response := pb.BatchResponse{
+		Decisions: make([]*pb.IsAllowedResponse, 0, len(decisions)),
+	}
+	for _, decision := range decisions {
+		result := pb.IsAllowedResponse{
+			Allowed: decision.Allowed,
+			Reason:  int32(decision.Reason),
+		}
+		if decision.Error != nil {
+			result.ErrMsg = decision.Error.Error()
+		}
+		response.Decisions = append(response.Decisions, &result)
+	}
+
+	// Audit log
+	logging.WriteSimpleSucceededAuditLog("[gRPC]IsAllowedBatch", in, response)
+
+	return &response, nil
+}
+
 func (impl *GRPCService) GetAllGrantedRoles(ctx context.Context, in *pb.ContextRequest) (*pb.AllRoleResponse, error) {
 	reqCtx := convertGRPCContextRequest(in)
 
 	// assert token
-	impl.evaluator.AssertToken(reqCtx)
+	impl.evaluatorOf(ctx).AssertToken(reqCtx)
 
-	roles, err := impl.evaluator.GetAllGrantedRoles(*reqCtx)
+	roles, err := impl.evaluatorOf(ctx).GetAllGrantedRoles(*reqCtx)
 	if err != nil {
 		// Audit log
 		logging.WriteSimpleFailedAuditLog("[gRPC]GetAllGrantedRoles", reqCtx, err.Error())
@@ -123,9 +176,9 @@ func (impl *GRPCService) GetAllPermissions(ctx context.Context, in *pb.ContextRe
 	reqCtx := convertGRPCContextRequest(in)
 
 	// assert token
-	impl.evaluator.AssertToken(reqCtx)
+	impl.evaluatorOf(ctx).AssertToken(reqCtx)
 
-	perms, err := impl.evaluator.GetAllGrantedPermissions(*reqCtx)
+	perms, err := impl.evaluatorOf(ctx).GetAllGrantedPermissions(*reqCtx)
 	if err != nil {
 		// Audit log
 		logging.WriteSimpleFailedAuditLog("[gRPC]GetAllGrantedPermissions", reqCtx, err.Error())
@@ -137,8 +190,9 @@ func (impl *GRPCService) GetAllPermissions(ctx context.Context, in *pb.ContextRe
 	}
 	for _, perm := range perms {
 		ret.Permissions = append(ret.Permissions, &pb.AllPermissionResponse_Permission{
-			Resource: perm.Resource,
-			Actions:  perm.Actions,
+			Resource:     perm.Resource,
+			ResourceGlob: perm.ResourceGlob,
+			Actions:      perm.Actions,
 		})
 	}
 
@@ -152,9 +206,9 @@ func (impl *GRPCService) Discover(ctx context.Context, in *pb.ContextRequest) (*
 	reqCtx := convertGRPCContextRequest(in)
 
 	// assert token
-	impl.evaluator.AssertToken(reqCtx)
+	impl.evaluatorOf(ctx).AssertToken(reqCtx)
 
-	allowed, reason, err := impl.evaluator.Discover(*reqCtx)
+	allowed, reason, err := impl.evaluatorOf(ctx).Discover(*reqCtx)
 	if err != nil {
 		// Audit log
 		logging.WriteSimpleFailedAuditLog("[gRPC]Discovery", reqCtx, err.Error())
@@ -241,6 +295,7 @@ func convertAPIPolicy2PolicyResponse(apiPolicy *pms.Policy, policyResp *pb.Polic
 			Resource:           permission.Resource,
 			Actions:            permission.Actions,
 			ResourceExpression: permission.ResourceExpression,
+			ResourceGlob:       permission.ResourceGlob,
 		})
 	}
 
@@ -264,6 +319,7 @@ func convertAPIPolicy2EvaluatedPolicyResponse(apiPolicy *adsapi.EvaluatedPolicy,
 			Resource:           permission.Resource,
 			Actions:            permission.Actions,
 			ResourceExpression: permission.ResourceExpression,
+			ResourceGlob:       permission.ResourceGlob,
 		})
 	}
 
@@ -296,6 +352,7 @@ func convertAPIRolePolicy2RolePolicyResponse(apiRolePolicy *pms.RolePolicy, role
 	rolePolicyResp.Principals = apiRolePolicy.Principals
 	rolePolicyResp.Resources = apiRolePolicy.Resources
 	rolePolicyResp.ResourceExpressions = apiRolePolicy.ResourceExpressions
+	rolePolicyResp.ResourceGlobs = apiRolePolicy.ResourceGlobs
 	rolePolicyResp.Condition = apiRolePolicy.Condition
 }
 
@@ -315,6 +372,7 @@ func convertAPIRolePolicy2EvaluatedRolePolicyResponse(apiRolePolicy *adsapi.Eval
 	}
 	rolePolicyResp.Resources = apiRolePolicy.Resources
 	rolePolicyResp.ResourceExpressions = apiRolePolicy.ResourceExpressions
+	rolePolicyResp.ResourceGlobs = apiRolePolicy.ResourceGlobs
 	if apiRolePolicy.Condition != nil {
 		rolePolicyResp.Condition = &pb.EvaluatedCondition{
 			ConditionExpression: apiRolePolicy.Condition.ConditionExpression,
@@ -327,9 +385,9 @@ func (impl *GRPCService) Diagnose(ctx context.Context, in *pb.ContextRequest) (*
 	reqCtx := convertGRPCContextRequest(in)
 
 	// assert token
-	impl.evaluator.AssertToken(reqCtx)
+	impl.evaluatorOf(ctx).AssertToken(reqCtx)
 
-	evaResult, err := impl.evaluator.Diagnose(*reqCtx)
+	evaResult, err := impl.evaluatorOf(ctx).Diagnose(*reqCtx)
 	if err != nil {
 		// Audit log
 		logging.WriteSimpleFailedAuditLog("[gRPC]Diagnose", reqCtx, err.Error())
@@ -354,12 +412,14 @@ func (impl *GRPCService) Diagnose(ctx context.Context, in *pb.ContextRequest) (*
 
 	// Construct & return the response
 	response := pb.EvaluationDebugResponse{
-		Allowed:        evaResult.Allowed,
-		Reason:         evaResult.Reason.String(),
-		RequestContext: in,
-		GrantedRoles:   evaResult.GrantedRoles,
-		RolePolicies:   retRolePolicies,
-		Policies:       retPolicies,
+		Allowed:            evaResult.Allowed,
+		Reason:             evaResult.Reason.String(),
+		RequestContext:     in,
+		GrantedRoles:       evaResult.GrantedRoles,
+		RolePolicies:       retRolePolicies,
+		Policies:           retPolicies,
+		CombiningAlgorithm: evaResult.CombiningAlgorithm,
+		Explanation:        evaResult.Explanation,
 	}
 
 	// Audit log
@@ -367,3 +427,49 @@ func (impl *GRPCService) Diagnose(ctx context.Context, in *pb.ContextRequest) (*
 
 	return &response, nil
 }
+
+func convertAPIAccessPaths(paths []*adsapi.AccessPath) []*pb.AccessPath {
+	ret := make([]*pb.AccessPath, 0, len(paths))
+	for _, path := range paths {
+		ret = append(ret, &pb.AccessPath{
+			Principals:    path.Principals,
+			Roles:         path.Roles,
+			RolePolicyIDs: path.RolePolicyIDs,
+			PolicyID:      path.PolicyID,
+			PolicyName:    path.PolicyName,
+			Conditions:    path.Conditions,
+		})
+	}
+	return ret
+}
+
+func (impl *GRPCService) WhoCanAccess(ctx context.Context, in *pb.Access) (*pb.AccessReviewResponse, error) {
+	review, err := impl.evaluatorOf(ctx).WhoCanAccess(in.ServiceName, in.Resource, in.Action)
+	if err != nil {
+		// Audit log
+		logging.WriteSimpleFailedAuditLog("[gRPC]WhoCanAccess", in, err.Error())
+		return nil, err
+	}
+
+	response := pb.AccessReviewResponse{
+		ServiceName: review.ServiceName,
+		Resource:    review.Resource,
+		Action:      review.Action,
+		Granted:     convertAPIAccessPaths(review.Granted),
+		Denied:      convertAPIAccessPaths(review.Denied),
+	}
+	for _, denial := range review.DeniedRoles {
+		response.DeniedRoles = append(response.DeniedRoles, &pb.RoleDenial{
+			Principals:     denial.Principals,
+			Role:           denial.Role,
+			RolePolicyID:   denial.RolePolicyID,
+			RolePolicyName: denial.RolePolicyName,
+			Condition:      denial.Condition,
+		})
+	}
+
+	// Audit log
+	logging.WriteSimpleSucceededAuditLog("[gRPC]WhoCanAccess", in, response)
+
+	return &response, nil
+}
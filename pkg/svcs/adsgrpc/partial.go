@@ -0,0 +1,82 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package adsgrpc
+
+import (
+	"context"
+	"encoding/json"
+
+	adsapi "github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/logging"
+	"github.com/teramoby/speedle-plus/pkg/svcs/adsgrpc/pb"
+)
+
+// PartialEvaluate returns the residual condition on the unknown attributes under which the subject is allowed the
+// action, and its translations to a SQL WHERE clause and a MongoDB filter. Values are encoded in JSON.
+func (impl *GRPCService) PartialEvaluate(ctx context.Context, in *pb.PartialEvaluationRequest) (*pb.PartialEvaluationResponse, error) {
+	if in.Context == nil {
+		return nil, errors.New(errors.InvalidRequest, "context is required")
+	}
+	result, err := impl.evaluatorOf(ctx).PartialEvaluate(*convertGRPCContextRequest(in.Context), in.Unknowns)
+	if err != nil {
+		// Audit log
+		logging.WriteSimpleFailedAuditLog("[gRPC]PartialEvaluate", in, err.Error())
+		return nil, err
+	}
+
+	response := pb.PartialEvaluationResponse{GrantedRoles: result.GrantedRoles}
+	if response.Residual, err = convertAPIResidual(result.Residual); err != nil {
+		return nil, err
+	}
+	where, args, err := result.Residual.ToSQL(in.SqlDialect)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.InvalidRequest, "unable to translate the residual to SQL")
+	}
+	response.SqlWhere = where
+	for _, arg := range args {
+		value, err := json.Marshal(arg)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.SerializationError, "unable to encode the SQL argument")
+		}
+		response.SqlArgs = append(response.SqlArgs, string(value))
+	}
+	filter, err := result.Residual.ToMongo()
+	if err != nil {
+		return nil, errors.Wrap(err, errors.InvalidRequest, "unable to translate the residual to a MongoDB filter")
+	}
+	mongoFilter, err := json.Marshal(filter)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.SerializationError, "unable to encode the MongoDB filter")
+	}
+	response.MongoFilter = string(mongoFilter)
+
+	// Audit log
+	logging.WriteSimpleSucceededAuditLog("[gRPC]PartialEvaluate", in, response)
+
+	return &response, nil
+}
+
+func convertAPIResidual(residual *adsapi.Residual) (*pb.Residual, error) {
+	ret := pb.Residual{
+		Op:             residual.Op,
+		Attribute:      residual.Attribute,
+		OtherAttribute: residual.OtherAttribute,
+	}
+	if residual.Value != nil {
+		value, err := json.Marshal(residual.Value)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.SerializationError, "unable to encode the residual value")
+		}
+		ret.Value = string(value)
+	}
+	for _, operand := range residual.Operands {
+		pbOperand, err := convertAPIResidual(operand)
+		if err != nil {
+			return nil, err
+		}
+		ret.Operands = append(ret.Operands, pbOperand)
+	}
+	return &ret, nil
+}
@@ -0,0 +1,43 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package adsgrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/teramoby/speedle-plus/pkg/svcs/adsgrpc/pb"
+)
+
+func TestPartialEvaluate(t *testing.T) {
+	client, stop := startTestServer(t, 1)
+	defer stop()
+
+	request := pb.PartialEvaluationRequest{
+		Context: &pb.ContextRequest{
+			Subject:     &pb.Subject{Principals: []*pb.Principal{{Type: "user", Name: "userA"}}},
+			ServiceName: "erp",
+			Resource:    "res1",
+			Action:      "read",
+		},
+		Unknowns: []string{"owner"},
+	}
+	response, err := client.PartialEvaluate(context.Background(), &request)
+	if err != nil {
+		t.Fatal("fail to partially evaluate:", err)
+	}
+	if response.Residual.Op != "true" || response.SqlWhere != "1 = 1" || response.MongoFilter != "{}" {
+		t.Fatal("userA should be granted without condition, but got", response)
+	}
+
+	request.Context.Subject.Principals[0].Name = "userB"
+	if response, err = client.PartialEvaluate(context.Background(), &request); err != nil || response.Residual.Op != "false" {
+		t.Fatal("userB should be denied, but got", response, err)
+	}
+
+	request.Context.ServiceName = "dummy"
+	if _, err := client.PartialEvaluate(context.Background(), &request); err == nil {
+		t.Fatal("should fail if service is not found")
+	}
+}
@@ -12,6 +12,12 @@ It has these top-level messages:
 	Subject
 	ContextRequest
 	IsAllowedResponse
+	Access
+	BatchRequest
+	BatchResponse
+	AccessPath
+	RoleDenial
+	AccessReviewResponse
 	AndPrincipals
 	RolePolicy
 	Policy
@@ -21,6 +27,9 @@ It has these top-level messages:
 	EvaluationDebugResponse
 	AllRoleResponse
 	AllPermissionResponse
+	PartialEvaluationRequest
+	Residual
+	PartialEvaluationResponse
 */
 package pb
 
@@ -114,6 +123,8 @@ type ContextRequest struct {
 	Resource    string            `protobuf:"bytes,3,opt,name=resource" json:"resource,omitempty"`
 	Action      string            `protobuf:"bytes,4,opt,name=action" json:"action,omitempty"`
 	Attributes  map[string]string `protobuf:"bytes,5,rep,name=attributes" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// correlates the response of IsAllowedStream with the request
+	CorrelationID string `protobuf:"bytes,6,opt,name=correlationID" json:"correlationID,omitempty"`
 }
 
 func (m *ContextRequest) Reset()                    { *m = ContextRequest{} }
@@ -156,10 +167,18 @@ func (m *ContextRequest) GetAttributes() map[string]string {
 	return nil
 }
 
+func (m *ContextRequest) GetCorrelationID() string {
+	if m != nil {
+		return m.CorrelationID
+	}
+	return ""
+}
+
 type IsAllowedResponse struct {
-	Allowed bool   `protobuf:"varint,1,opt,name=allowed" json:"allowed,omitempty"`
-	Reason  int32  `protobuf:"varint,2,opt,name=reason" json:"reason,omitempty"`
-	ErrMsg  string `protobuf:"bytes,3,opt,name=errMsg" json:"errMsg,omitempty"`
+	Allowed       bool   `protobuf:"varint,1,opt,name=allowed" json:"allowed,omitempty"`
+	Reason        int32  `protobuf:"varint,2,opt,name=reason" json:"reason,omitempty"`
+	ErrMsg        string `protobuf:"bytes,3,opt,name=errMsg" json:"errMsg,omitempty"`
+	CorrelationID string `protobuf:"bytes,4,opt,name=correlationID" json:"correlationID,omitempty"`
 }
 
 func (m *IsAllowedResponse) Reset()                    { *m = IsAllowedResponse{} }
@@ -188,6 +207,253 @@ func (m *IsAllowedResponse) GetErrMsg() string {
 	return ""
 }
 
+func (m *IsAllowedResponse) GetCorrelationID() string {
+	if m != nil {
+		return m.CorrelationID
+	}
+	return ""
+}
+
+type Access struct {
+	ServiceName string            `protobuf:"bytes,1,opt,name=serviceName" json:"serviceName,omitempty"`
+	Resource    string            `protobuf:"bytes,2,opt,name=resource" json:"resource,omitempty"`
+	Action      string            `protobuf:"bytes,3,opt,name=action" json:"action,omitempty"`
+	Attributes  map[string]string `protobuf:"bytes,4,rep,name=attributes" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *Access) Reset()                    { *m = Access{} }
+func (m *Access) String() string            { return proto.CompactTextString(m) }
+func (*Access) ProtoMessage()               {}
+func (*Access) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *Access) GetServiceName() string {
+	if m != nil {
+		return m.ServiceName
+	}
+	return ""
+}
+
+func (m *Access) GetResource() string {
+	if m != nil {
+		return m.Resource
+	}
+	return ""
+}
+
+func (m *Access) GetAction() string {
+	if m != nil {
+		return m.Action
+	}
+	return ""
+}
+
+func (m *Access) GetAttributes() map[string]string {
+	if m != nil {
+		return m.Attributes
+	}
+	return nil
+}
+
+type BatchRequest struct {
+	Subject  *Subject  `protobuf:"bytes,1,opt,name=subject" json:"subject,omitempty"`
+	Accesses []*Access `protobuf:"bytes,2,rep,name=accesses" json:"accesses,omitempty"`
+}
+
+func (m *BatchRequest) Reset()                    { *m = BatchRequest{} }
+func (m *BatchRequest) String() string            { return proto.CompactTextString(m) }
+func (*BatchRequest) ProtoMessage()               {}
+func (*BatchRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *BatchRequest) GetSubject() *Subject {
+	if m != nil {
+		return m.Subject
+	}
+	return nil
+}
+
+func (m *BatchRequest) GetAccesses() []*Access {
+	if m != nil {
+		return m.Accesses
+	}
+	return nil
+}
+
+type BatchResponse struct {
+	Decisions []*IsAllowedResponse `protobuf:"bytes,1,rep,name=decisions" json:"decisions,omitempty"`
+}
+
+func (m *BatchResponse) Reset()                    { *m = BatchResponse{} }
+func (m *BatchResponse) String() string            { return proto.CompactTextString(m) }
+func (*BatchResponse) ProtoMessage()               {}
+func (*BatchResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *BatchResponse) GetDecisions() []*IsAllowedResponse {
+	if m != nil {
+		return m.Decisions
+	}
+	return nil
+}
+
+type AccessPath struct {
+	Principals    []string `protobuf:"bytes,1,rep,name=principals" json:"principals,omitempty"`
+	Roles         []string `protobuf:"bytes,2,rep,name=roles" json:"roles,omitempty"`
+	RolePolicyIDs []string `protobuf:"bytes,3,rep,name=rolePolicyIDs" json:"rolePolicyIDs,omitempty"`
+	PolicyID      string   `protobuf:"bytes,4,opt,name=policyID" json:"policyID,omitempty"`
+	PolicyName    string   `protobuf:"bytes,5,opt,name=policyName" json:"policyName,omitempty"`
+	Conditions    []string `protobuf:"bytes,6,rep,name=conditions" json:"conditions,omitempty"`
+}
+
+func (m *AccessPath) Reset()                    { *m = AccessPath{} }
+func (m *AccessPath) String() string            { return proto.CompactTextString(m) }
+func (*AccessPath) ProtoMessage()               {}
+func (*AccessPath) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *AccessPath) GetPrincipals() []string {
+	if m != nil {
+		return m.Principals
+	}
+	return nil
+}
+
+func (m *AccessPath) GetRoles() []string {
+	if m != nil {
+		return m.Roles
+	}
+	return nil
+}
+
+func (m *AccessPath) GetRolePolicyIDs() []string {
+	if m != nil {
+		return m.RolePolicyIDs
+	}
+	return nil
+}
+
+func (m *AccessPath) GetPolicyID() string {
+	if m != nil {
+		return m.PolicyID
+	}
+	return ""
+}
+
+func (m *AccessPath) GetPolicyName() string {
+	if m != nil {
+		return m.PolicyName
+	}
+	return ""
+}
+
+func (m *AccessPath) GetConditions() []string {
+	if m != nil {
+		return m.Conditions
+	}
+	return nil
+}
+
+type RoleDenial struct {
+	Principals     []string `protobuf:"bytes,1,rep,name=principals" json:"principals,omitempty"`
+	Role           string   `protobuf:"bytes,2,opt,name=role" json:"role,omitempty"`
+	RolePolicyID   string   `protobuf:"bytes,3,opt,name=rolePolicyID" json:"rolePolicyID,omitempty"`
+	RolePolicyName string   `protobuf:"bytes,4,opt,name=rolePolicyName" json:"rolePolicyName,omitempty"`
+	Condition      string   `protobuf:"bytes,5,opt,name=condition" json:"condition,omitempty"`
+}
+
+func (m *RoleDenial) Reset()                    { *m = RoleDenial{} }
+func (m *RoleDenial) String() string            { return proto.CompactTextString(m) }
+func (*RoleDenial) ProtoMessage()               {}
+func (*RoleDenial) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *RoleDenial) GetPrincipals() []string {
+	if m != nil {
+		return m.Principals
+	}
+	return nil
+}
+
+func (m *RoleDenial) GetRole() string {
+	if m != nil {
+		return m.Role
+	}
+	return ""
+}
+
+func (m *RoleDenial) GetRolePolicyID() string {
+	if m != nil {
+		return m.RolePolicyID
+	}
+	return ""
+}
+
+func (m *RoleDenial) GetRolePolicyName() string {
+	if m != nil {
+		return m.RolePolicyName
+	}
+	return ""
+}
+
+func (m *RoleDenial) GetCondition() string {
+	if m != nil {
+		return m.Condition
+	}
+	return ""
+}
+
+type AccessReviewResponse struct {
+	ServiceName string        `protobuf:"bytes,1,opt,name=serviceName" json:"serviceName,omitempty"`
+	Resource    string        `protobuf:"bytes,2,opt,name=resource" json:"resource,omitempty"`
+	Action      string        `protobuf:"bytes,3,opt,name=action" json:"action,omitempty"`
+	Granted     []*AccessPath `protobuf:"bytes,4,rep,name=granted" json:"granted,omitempty"`
+	Denied      []*AccessPath `protobuf:"bytes,5,rep,name=denied" json:"denied,omitempty"`
+	DeniedRoles []*RoleDenial `protobuf:"bytes,6,rep,name=deniedRoles" json:"deniedRoles,omitempty"`
+}
+
+func (m *AccessReviewResponse) Reset()                    { *m = AccessReviewResponse{} }
+func (m *AccessReviewResponse) String() string            { return proto.CompactTextString(m) }
+func (*AccessReviewResponse) ProtoMessage()               {}
+func (*AccessReviewResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *AccessReviewResponse) GetServiceName() string {
+	if m != nil {
+		return m.ServiceName
+	}
+	return ""
+}
+
+func (m *AccessReviewResponse) GetResource() string {
+	if m != nil {
+		return m.Resource
+	}
+	return ""
+}
+
+func (m *AccessReviewResponse) GetAction() string {
+	if m != nil {
+		return m.Action
+	}
+	return ""
+}
+
+func (m *AccessReviewResponse) GetGranted() []*AccessPath {
+	if m != nil {
+		return m.Granted
+	}
+	return nil
+}
+
+func (m *AccessReviewResponse) GetDenied() []*AccessPath {
+	if m != nil {
+		return m.Denied
+	}
+	return nil
+}
+
+func (m *AccessReviewResponse) GetDeniedRoles() []*RoleDenial {
+	if m != nil {
+		return m.DeniedRoles
+	}
+	return nil
+}
+
 type AndPrincipals struct {
 	Principals []string `protobuf:"bytes,1,rep,name=principals" json:"principals,omitempty"`
 }
@@ -195,7 +461,7 @@ type AndPrincipals struct {
 func (m *AndPrincipals) Reset()                    { *m = AndPrincipals{} }
 func (m *AndPrincipals) String() string            { return proto.CompactTextString(m) }
 func (*AndPrincipals) ProtoMessage()               {}
-func (*AndPrincipals) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+func (*AndPrincipals) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
 
 func (m *AndPrincipals) GetPrincipals() []string {
 	if m != nil {
@@ -213,12 +479,13 @@ type RolePolicy struct {
 	Resources           []string `protobuf:"bytes,6,rep,name=Resources" json:"Resources,omitempty"`
 	ResourceExpressions []string `protobuf:"bytes,7,rep,name=ResourceExpressions" json:"ResourceExpressions,omitempty"`
 	Condition           string   `protobuf:"bytes,8,opt,name=Condition" json:"Condition,omitempty"`
+	ResourceGlobs       []string `protobuf:"bytes,9,rep,name=ResourceGlobs" json:"ResourceGlobs,omitempty"`
 }
 
 func (m *RolePolicy) Reset()                    { *m = RolePolicy{} }
 func (m *RolePolicy) String() string            { return proto.CompactTextString(m) }
 func (*RolePolicy) ProtoMessage()               {}
-func (*RolePolicy) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+func (*RolePolicy) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
 
 func (m *RolePolicy) GetID() string {
 	if m != nil {
@@ -276,6 +543,13 @@ func (m *RolePolicy) GetCondition() string {
 	return ""
 }
 
+func (m *RolePolicy) GetResourceGlobs() []string {
+	if m != nil {
+		return m.ResourceGlobs
+	}
+	return nil
+}
+
 type Policy struct {
 	ID          string               `protobuf:"bytes,1,opt,name=ID" json:"ID,omitempty"`
 	Name        string               `protobuf:"bytes,2,opt,name=Name" json:"Name,omitempty"`
@@ -288,7 +562,7 @@ type Policy struct {
 func (m *Policy) Reset()                    { *m = Policy{} }
 func (m *Policy) String() string            { return proto.CompactTextString(m) }
 func (*Policy) ProtoMessage()               {}
-func (*Policy) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+func (*Policy) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
 
 func (m *Policy) GetID() string {
 	if m != nil {
@@ -336,12 +610,13 @@ type Policy_Permission struct {
 	Resource           string   `protobuf:"bytes,1,opt,name=resource" json:"resource,omitempty"`
 	ResourceExpression string   `protobuf:"bytes,2,opt,name=resourceExpression" json:"resourceExpression,omitempty"`
 	Actions            []string `protobuf:"bytes,3,rep,name=actions" json:"actions,omitempty"`
+	ResourceGlob       string   `protobuf:"bytes,4,opt,name=resourceGlob" json:"resourceGlob,omitempty"`
 }
 
 func (m *Policy_Permission) Reset()                    { *m = Policy_Permission{} }
 func (m *Policy_Permission) String() string            { return proto.CompactTextString(m) }
 func (*Policy_Permission) ProtoMessage()               {}
-func (*Policy_Permission) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6, 0} }
+func (*Policy_Permission) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12, 0} }
 
 func (m *Policy_Permission) GetResource() string {
 	if m != nil {
@@ -364,6 +639,13 @@ func (m *Policy_Permission) GetActions() []string {
 	return nil
 }
 
+func (m *Policy_Permission) GetResourceGlob() string {
+	if m != nil {
+		return m.ResourceGlob
+	}
+	return ""
+}
+
 type EvaluatedCondition struct {
 	ConditionExpression string `protobuf:"bytes,1,opt,name=ConditionExpression" json:"ConditionExpression,omitempty"`
 	EvaluationResult    string `protobuf:"bytes,2,opt,name=EvaluationResult" json:"EvaluationResult,omitempty"`
@@ -372,7 +654,7 @@ type EvaluatedCondition struct {
 func (m *EvaluatedCondition) Reset()                    { *m = EvaluatedCondition{} }
 func (m *EvaluatedCondition) String() string            { return proto.CompactTextString(m) }
 func (*EvaluatedCondition) ProtoMessage()               {}
-func (*EvaluatedCondition) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+func (*EvaluatedCondition) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
 
 func (m *EvaluatedCondition) GetConditionExpression() string {
 	if m != nil {
@@ -398,12 +680,13 @@ type EvaluatedRolePolicy struct {
 	Resources           []string            `protobuf:"bytes,7,rep,name=Resources" json:"Resources,omitempty"`
 	ResourceExpressions []string            `protobuf:"bytes,8,rep,name=ResourceExpressions" json:"ResourceExpressions,omitempty"`
 	Condition           *EvaluatedCondition `protobuf:"bytes,9,opt,name=Condition" json:"Condition,omitempty"`
+	ResourceGlobs       []string            `protobuf:"bytes,10,rep,name=ResourceGlobs" json:"ResourceGlobs,omitempty"`
 }
 
 func (m *EvaluatedRolePolicy) Reset()                    { *m = EvaluatedRolePolicy{} }
 func (m *EvaluatedRolePolicy) String() string            { return proto.CompactTextString(m) }
 func (*EvaluatedRolePolicy) ProtoMessage()               {}
-func (*EvaluatedRolePolicy) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+func (*EvaluatedRolePolicy) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
 
 func (m *EvaluatedRolePolicy) GetStatus() string {
 	if m != nil {
@@ -468,6 +751,13 @@ func (m *EvaluatedRolePolicy) GetCondition() *EvaluatedCondition {
 	return nil
 }
 
+func (m *EvaluatedRolePolicy) GetResourceGlobs() []string {
+	if m != nil {
+		return m.ResourceGlobs
+	}
+	return nil
+}
+
 type EvaluatedPolicy struct {
 	Status      string                        `protobuf:"bytes,1,opt,name=Status" json:"Status,omitempty"`
 	ID          string                        `protobuf:"bytes,2,opt,name=ID" json:"ID,omitempty"`
@@ -481,7 +771,7 @@ type EvaluatedPolicy struct {
 func (m *EvaluatedPolicy) Reset()                    { *m = EvaluatedPolicy{} }
 func (m *EvaluatedPolicy) String() string            { return proto.CompactTextString(m) }
 func (*EvaluatedPolicy) ProtoMessage()               {}
-func (*EvaluatedPolicy) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+func (*EvaluatedPolicy) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
 
 func (m *EvaluatedPolicy) GetStatus() string {
 	if m != nil {
@@ -536,12 +826,13 @@ type EvaluatedPolicy_Permission struct {
 	Resource           string   `protobuf:"bytes,1,opt,name=resource" json:"resource,omitempty"`
 	ResourceExpression string   `protobuf:"bytes,2,opt,name=resourceExpression" json:"resourceExpression,omitempty"`
 	Actions            []string `protobuf:"bytes,3,rep,name=actions" json:"actions,omitempty"`
+	ResourceGlob       string   `protobuf:"bytes,4,opt,name=resourceGlob" json:"resourceGlob,omitempty"`
 }
 
 func (m *EvaluatedPolicy_Permission) Reset()                    { *m = EvaluatedPolicy_Permission{} }
 func (m *EvaluatedPolicy_Permission) String() string            { return proto.CompactTextString(m) }
 func (*EvaluatedPolicy_Permission) ProtoMessage()               {}
-func (*EvaluatedPolicy_Permission) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9, 0} }
+func (*EvaluatedPolicy_Permission) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15, 0} }
 
 func (m *EvaluatedPolicy_Permission) GetResource() string {
 	if m != nil {
@@ -564,19 +855,28 @@ func (m *EvaluatedPolicy_Permission) GetActions() []string {
 	return nil
 }
 
+func (m *EvaluatedPolicy_Permission) GetResourceGlob() string {
+	if m != nil {
+		return m.ResourceGlob
+	}
+	return ""
+}
+
 type EvaluationDebugResponse struct {
-	Allowed        bool                   `protobuf:"varint,1,opt,name=allowed" json:"allowed,omitempty"`
-	Reason         string                 `protobuf:"bytes,2,opt,name=reason" json:"reason,omitempty"`
-	RequestContext *ContextRequest        `protobuf:"bytes,3,opt,name=requestContext" json:"requestContext,omitempty"`
-	GrantedRoles   []string               `protobuf:"bytes,4,rep,name=grantedRoles" json:"grantedRoles,omitempty"`
-	RolePolicies   []*EvaluatedRolePolicy `protobuf:"bytes,5,rep,name=rolePolicies" json:"rolePolicies,omitempty"`
-	Policies       []*EvaluatedPolicy     `protobuf:"bytes,6,rep,name=policies" json:"policies,omitempty"`
+	Allowed            bool                   `protobuf:"varint,1,opt,name=allowed" json:"allowed,omitempty"`
+	Reason             string                 `protobuf:"bytes,2,opt,name=reason" json:"reason,omitempty"`
+	RequestContext     *ContextRequest        `protobuf:"bytes,3,opt,name=requestContext" json:"requestContext,omitempty"`
+	GrantedRoles       []string               `protobuf:"bytes,4,rep,name=grantedRoles" json:"grantedRoles,omitempty"`
+	RolePolicies       []*EvaluatedRolePolicy `protobuf:"bytes,5,rep,name=rolePolicies" json:"rolePolicies,omitempty"`
+	Policies           []*EvaluatedPolicy     `protobuf:"bytes,6,rep,name=policies" json:"policies,omitempty"`
+	CombiningAlgorithm string                 `protobuf:"bytes,7,opt,name=combiningAlgorithm" json:"combiningAlgorithm,omitempty"`
+	Explanation        string                 `protobuf:"bytes,8,opt,name=explanation" json:"explanation,omitempty"`
 }
 
 func (m *EvaluationDebugResponse) Reset()                    { *m = EvaluationDebugResponse{} }
 func (m *EvaluationDebugResponse) String() string            { return proto.CompactTextString(m) }
 func (*EvaluationDebugResponse) ProtoMessage()               {}
-func (*EvaluationDebugResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+func (*EvaluationDebugResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
 
 func (m *EvaluationDebugResponse) GetAllowed() bool {
 	if m != nil {
@@ -620,6 +920,20 @@ func (m *EvaluationDebugResponse) GetPolicies() []*EvaluatedPolicy {
 	return nil
 }
 
+func (m *EvaluationDebugResponse) GetCombiningAlgorithm() string {
+	if m != nil {
+		return m.CombiningAlgorithm
+	}
+	return ""
+}
+
+func (m *EvaluationDebugResponse) GetExplanation() string {
+	if m != nil {
+		return m.Explanation
+	}
+	return ""
+}
+
 type AllRoleResponse struct {
 	Roles []string `protobuf:"bytes,1,rep,name=roles" json:"roles,omitempty"`
 }
@@ -627,7 +941,7 @@ type AllRoleResponse struct {
 func (m *AllRoleResponse) Reset()                    { *m = AllRoleResponse{} }
 func (m *AllRoleResponse) String() string            { return proto.CompactTextString(m) }
 func (*AllRoleResponse) ProtoMessage()               {}
-func (*AllRoleResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+func (*AllRoleResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
 
 func (m *AllRoleResponse) GetRoles() []string {
 	if m != nil {
@@ -643,7 +957,7 @@ type AllPermissionResponse struct {
 func (m *AllPermissionResponse) Reset()                    { *m = AllPermissionResponse{} }
 func (m *AllPermissionResponse) String() string            { return proto.CompactTextString(m) }
 func (*AllPermissionResponse) ProtoMessage()               {}
-func (*AllPermissionResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+func (*AllPermissionResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
 
 func (m *AllPermissionResponse) GetPermissions() []*AllPermissionResponse_Permission {
 	if m != nil {
@@ -653,15 +967,16 @@ func (m *AllPermissionResponse) GetPermissions() []*AllPermissionResponse_Permis
 }
 
 type AllPermissionResponse_Permission struct {
-	Resource string   `protobuf:"bytes,1,opt,name=resource" json:"resource,omitempty"`
-	Actions  []string `protobuf:"bytes,2,rep,name=actions" json:"actions,omitempty"`
+	Resource     string   `protobuf:"bytes,1,opt,name=resource" json:"resource,omitempty"`
+	Actions      []string `protobuf:"bytes,2,rep,name=actions" json:"actions,omitempty"`
+	ResourceGlob string   `protobuf:"bytes,3,opt,name=resourceGlob" json:"resourceGlob,omitempty"`
 }
 
 func (m *AllPermissionResponse_Permission) Reset()         { *m = AllPermissionResponse_Permission{} }
 func (m *AllPermissionResponse_Permission) String() string { return proto.CompactTextString(m) }
 func (*AllPermissionResponse_Permission) ProtoMessage()    {}
 func (*AllPermissionResponse_Permission) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{12, 0}
+	return fileDescriptor0, []int{18, 0}
 }
 
 func (m *AllPermissionResponse_Permission) GetResource() string {
@@ -678,11 +993,152 @@ func (m *AllPermissionResponse_Permission) GetActions() []string {
 	return nil
 }
 
+func (m *AllPermissionResponse_Permission) GetResourceGlob() string {
+	if m != nil {
+		return m.ResourceGlob
+	}
+	return ""
+}
+
+type PartialEvaluationRequest struct {
+	Context    *ContextRequest `protobuf:"bytes,1,opt,name=context" json:"context,omitempty"`
+	Unknowns   []string        `protobuf:"bytes,2,rep,name=unknowns" json:"unknowns,omitempty"`
+	SqlDialect string          `protobuf:"bytes,3,opt,name=sqlDialect" json:"sqlDialect,omitempty"`
+}
+
+func (m *PartialEvaluationRequest) Reset()                    { *m = PartialEvaluationRequest{} }
+func (m *PartialEvaluationRequest) String() string            { return proto.CompactTextString(m) }
+func (*PartialEvaluationRequest) ProtoMessage()               {}
+func (*PartialEvaluationRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *PartialEvaluationRequest) GetContext() *ContextRequest {
+	if m != nil {
+		return m.Context
+	}
+	return nil
+}
+
+func (m *PartialEvaluationRequest) GetUnknowns() []string {
+	if m != nil {
+		return m.Unknowns
+	}
+	return nil
+}
+
+func (m *PartialEvaluationRequest) GetSqlDialect() string {
+	if m != nil {
+		return m.SqlDialect
+	}
+	return ""
+}
+
+type Residual struct {
+	Op             string      `protobuf:"bytes,1,opt,name=op" json:"op,omitempty"`
+	Operands       []*Residual `protobuf:"bytes,2,rep,name=operands" json:"operands,omitempty"`
+	Attribute      string      `protobuf:"bytes,3,opt,name=attribute" json:"attribute,omitempty"`
+	Value          string      `protobuf:"bytes,4,opt,name=value" json:"value,omitempty"`
+	OtherAttribute string      `protobuf:"bytes,5,opt,name=otherAttribute" json:"otherAttribute,omitempty"`
+}
+
+func (m *Residual) Reset()                    { *m = Residual{} }
+func (m *Residual) String() string            { return proto.CompactTextString(m) }
+func (*Residual) ProtoMessage()               {}
+func (*Residual) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *Residual) GetOp() string {
+	if m != nil {
+		return m.Op
+	}
+	return ""
+}
+
+func (m *Residual) GetOperands() []*Residual {
+	if m != nil {
+		return m.Operands
+	}
+	return nil
+}
+
+func (m *Residual) GetAttribute() string {
+	if m != nil {
+		return m.Attribute
+	}
+	return ""
+}
+
+func (m *Residual) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+func (m *Residual) GetOtherAttribute() string {
+	if m != nil {
+		return m.OtherAttribute
+	}
+	return ""
+}
+
+type PartialEvaluationResponse struct {
+	Residual     *Residual `protobuf:"bytes,1,opt,name=residual" json:"residual,omitempty"`
+	GrantedRoles []string  `protobuf:"bytes,2,rep,name=grantedRoles" json:"grantedRoles,omitempty"`
+	SqlWhere     string    `protobuf:"bytes,3,opt,name=sqlWhere" json:"sqlWhere,omitempty"`
+	SqlArgs      []string  `protobuf:"bytes,4,rep,name=sqlArgs" json:"sqlArgs,omitempty"`
+	MongoFilter  string    `protobuf:"bytes,5,opt,name=mongoFilter" json:"mongoFilter,omitempty"`
+}
+
+func (m *PartialEvaluationResponse) Reset()                    { *m = PartialEvaluationResponse{} }
+func (m *PartialEvaluationResponse) String() string            { return proto.CompactTextString(m) }
+func (*PartialEvaluationResponse) ProtoMessage()               {}
+func (*PartialEvaluationResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *PartialEvaluationResponse) GetResidual() *Residual {
+	if m != nil {
+		return m.Residual
+	}
+	return nil
+}
+
+func (m *PartialEvaluationResponse) GetGrantedRoles() []string {
+	if m != nil {
+		return m.GrantedRoles
+	}
+	return nil
+}
+
+func (m *PartialEvaluationResponse) GetSqlWhere() string {
+	if m != nil {
+		return m.SqlWhere
+	}
+	return ""
+}
+
+func (m *PartialEvaluationResponse) GetSqlArgs() []string {
+	if m != nil {
+		return m.SqlArgs
+	}
+	return nil
+}
+
+func (m *PartialEvaluationResponse) GetMongoFilter() string {
+	if m != nil {
+		return m.MongoFilter
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*Principal)(nil), "pb.Principal")
 	proto.RegisterType((*Subject)(nil), "pb.Subject")
 	proto.RegisterType((*ContextRequest)(nil), "pb.ContextRequest")
 	proto.RegisterType((*IsAllowedResponse)(nil), "pb.IsAllowedResponse")
+	proto.RegisterType((*Access)(nil), "pb.Access")
+	proto.RegisterType((*BatchRequest)(nil), "pb.BatchRequest")
+	proto.RegisterType((*BatchResponse)(nil), "pb.BatchResponse")
+	proto.RegisterType((*AccessPath)(nil), "pb.AccessPath")
+	proto.RegisterType((*RoleDenial)(nil), "pb.RoleDenial")
+	proto.RegisterType((*AccessReviewResponse)(nil), "pb.AccessReviewResponse")
 	proto.RegisterType((*AndPrincipals)(nil), "pb.AndPrincipals")
 	proto.RegisterType((*RolePolicy)(nil), "pb.RolePolicy")
 	proto.RegisterType((*Policy)(nil), "pb.Policy")
@@ -695,6 +1151,9 @@ func init() {
 	proto.RegisterType((*AllRoleResponse)(nil), "pb.AllRoleResponse")
 	proto.RegisterType((*AllPermissionResponse)(nil), "pb.AllPermissionResponse")
 	proto.RegisterType((*AllPermissionResponse_Permission)(nil), "pb.AllPermissionResponse.Permission")
+	proto.RegisterType((*PartialEvaluationRequest)(nil), "pb.PartialEvaluationRequest")
+	proto.RegisterType((*Residual)(nil), "pb.Residual")
+	proto.RegisterType((*PartialEvaluationResponse)(nil), "pb.PartialEvaluationResponse")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -709,10 +1168,14 @@ const _ = grpc.SupportPackageIsVersion4
 
 type EvaluatorClient interface {
 	IsAllowed(ctx context.Context, in *ContextRequest, opts ...grpc.CallOption) (*IsAllowedResponse, error)
+	IsAllowedBatch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	IsAllowedStream(ctx context.Context, opts ...grpc.CallOption) (Evaluator_IsAllowedStreamClient, error)
 	GetAllGrantedRoles(ctx context.Context, in *ContextRequest, opts ...grpc.CallOption) (*AllRoleResponse, error)
 	GetAllPermissions(ctx context.Context, in *ContextRequest, opts ...grpc.CallOption) (*AllPermissionResponse, error)
 	Discover(ctx context.Context, in *ContextRequest, opts ...grpc.CallOption) (*IsAllowedResponse, error)
 	Diagnose(ctx context.Context, in *ContextRequest, opts ...grpc.CallOption) (*EvaluationDebugResponse, error)
+	WhoCanAccess(ctx context.Context, in *Access, opts ...grpc.CallOption) (*AccessReviewResponse, error)
+	PartialEvaluate(ctx context.Context, in *PartialEvaluationRequest, opts ...grpc.CallOption) (*PartialEvaluationResponse, error)
 }
 
 type evaluatorClient struct {
@@ -732,6 +1195,46 @@ func (c *evaluatorClient) IsAllowed(ctx context.Context, in *ContextRequest, opt
 	return out, nil
 }
 
+func (c *evaluatorClient) IsAllowedBatch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	out := new(BatchResponse)
+	err := grpc.Invoke(ctx, "/pb.Evaluator/IsAllowedBatch", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *evaluatorClient) IsAllowedStream(ctx context.Context, opts ...grpc.CallOption) (Evaluator_IsAllowedStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Evaluator_serviceDesc.Streams[0], c.cc, "/pb.Evaluator/IsAllowedStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &evaluatorIsAllowedStreamClient{stream}
+	return x, nil
+}
+
+type Evaluator_IsAllowedStreamClient interface {
+	Send(*ContextRequest) error
+	Recv() (*IsAllowedResponse, error)
+	grpc.ClientStream
+}
+
+type evaluatorIsAllowedStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *evaluatorIsAllowedStreamClient) Send(m *ContextRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *evaluatorIsAllowedStreamClient) Recv() (*IsAllowedResponse, error) {
+	m := new(IsAllowedResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *evaluatorClient) GetAllGrantedRoles(ctx context.Context, in *ContextRequest, opts ...grpc.CallOption) (*AllRoleResponse, error) {
 	out := new(AllRoleResponse)
 	err := grpc.Invoke(ctx, "/pb.Evaluator/GetAllGrantedRoles", in, out, c.cc, opts...)
@@ -768,14 +1271,36 @@ func (c *evaluatorClient) Diagnose(ctx context.Context, in *ContextRequest, opts
 	return out, nil
 }
 
+func (c *evaluatorClient) WhoCanAccess(ctx context.Context, in *Access, opts ...grpc.CallOption) (*AccessReviewResponse, error) {
+	out := new(AccessReviewResponse)
+	err := grpc.Invoke(ctx, "/pb.Evaluator/WhoCanAccess", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *evaluatorClient) PartialEvaluate(ctx context.Context, in *PartialEvaluationRequest, opts ...grpc.CallOption) (*PartialEvaluationResponse, error) {
+	out := new(PartialEvaluationResponse)
+	err := grpc.Invoke(ctx, "/pb.Evaluator/PartialEvaluate", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Evaluator service
 
 type EvaluatorServer interface {
 	IsAllowed(context.Context, *ContextRequest) (*IsAllowedResponse, error)
+	IsAllowedBatch(context.Context, *BatchRequest) (*BatchResponse, error)
+	IsAllowedStream(Evaluator_IsAllowedStreamServer) error
 	GetAllGrantedRoles(context.Context, *ContextRequest) (*AllRoleResponse, error)
 	GetAllPermissions(context.Context, *ContextRequest) (*AllPermissionResponse, error)
 	Discover(context.Context, *ContextRequest) (*IsAllowedResponse, error)
 	Diagnose(context.Context, *ContextRequest) (*EvaluationDebugResponse, error)
+	WhoCanAccess(context.Context, *Access) (*AccessReviewResponse, error)
+	PartialEvaluate(context.Context, *PartialEvaluationRequest) (*PartialEvaluationResponse, error)
 }
 
 func RegisterEvaluatorServer(s *grpc.Server, srv EvaluatorServer) {
@@ -800,6 +1325,50 @@ func _Evaluator_IsAllowed_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Evaluator_IsAllowedBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EvaluatorServer).IsAllowedBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Evaluator/IsAllowedBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EvaluatorServer).IsAllowedBatch(ctx, req.(*BatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Evaluator_IsAllowedStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EvaluatorServer).IsAllowedStream(&evaluatorIsAllowedStreamServer{stream})
+}
+
+type Evaluator_IsAllowedStreamServer interface {
+	Send(*IsAllowedResponse) error
+	Recv() (*ContextRequest, error)
+	grpc.ServerStream
+}
+
+type evaluatorIsAllowedStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *evaluatorIsAllowedStreamServer) Send(m *IsAllowedResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *evaluatorIsAllowedStreamServer) Recv() (*ContextRequest, error) {
+	m := new(ContextRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func _Evaluator_GetAllGrantedRoles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ContextRequest)
 	if err := dec(in); err != nil {
@@ -872,6 +1441,42 @@ func _Evaluator_Diagnose_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Evaluator_WhoCanAccess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Access)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EvaluatorServer).WhoCanAccess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Evaluator/WhoCanAccess",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EvaluatorServer).WhoCanAccess(ctx, req.(*Access))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Evaluator_PartialEvaluate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PartialEvaluationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EvaluatorServer).PartialEvaluate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Evaluator/PartialEvaluate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EvaluatorServer).PartialEvaluate(ctx, req.(*PartialEvaluationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Evaluator_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "pb.Evaluator",
 	HandlerType: (*EvaluatorServer)(nil),
@@ -880,6 +1485,10 @@ var _Evaluator_serviceDesc = grpc.ServiceDesc{
 			MethodName: "IsAllowed",
 			Handler:    _Evaluator_IsAllowed_Handler,
 		},
+		{
+			MethodName: "IsAllowedBatch",
+			Handler:    _Evaluator_IsAllowedBatch_Handler,
+		},
 		{
 			MethodName: "GetAllGrantedRoles",
 			Handler:    _Evaluator_GetAllGrantedRoles_Handler,
@@ -896,70 +1505,122 @@ var _Evaluator_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Diagnose",
 			Handler:    _Evaluator_Diagnose_Handler,
 		},
+		{
+			MethodName: "WhoCanAccess",
+			Handler:    _Evaluator_WhoCanAccess_Handler,
+		},
+		{
+			MethodName: "PartialEvaluate",
+			Handler:    _Evaluator_PartialEvaluate_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "IsAllowedStream",
+			Handler:       _Evaluator_IsAllowedStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "service.proto",
 }
 
 func init() { proto.RegisterFile("service.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 904 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x56, 0xdd, 0x8e, 0xdb, 0x44,
-	0x14, 0x4e, 0x9c, 0xc4, 0x89, 0x4f, 0xba, 0xbb, 0xdd, 0x49, 0xbb, 0x35, 0x01, 0x55, 0xab, 0x11,
-	0x88, 0x0a, 0x89, 0x14, 0x02, 0x52, 0xab, 0xa2, 0x0a, 0xd2, 0x26, 0x54, 0x7b, 0x01, 0x8a, 0x5c,
-	0x6e, 0xb9, 0x70, 0x92, 0x69, 0x64, 0xea, 0x7a, 0xcc, 0xcc, 0x78, 0x69, 0xde, 0x82, 0x5b, 0xae,
-	0x11, 0x2f, 0x82, 0x78, 0x1c, 0x6e, 0x78, 0x03, 0x34, 0x3f, 0xb6, 0xc7, 0xb1, 0xc3, 0xee, 0x4a,
-	0x20, 0xee, 0x7c, 0xce, 0x9c, 0x39, 0x7f, 0xdf, 0x77, 0xe6, 0x18, 0x8e, 0x38, 0x61, 0x97, 0xd1,
-	0x9a, 0x4c, 0x52, 0x46, 0x05, 0x45, 0x4e, 0xba, 0xc2, 0x0b, 0xf0, 0x96, 0x2c, 0x4a, 0xd6, 0x51,
-	0x1a, 0xc6, 0x08, 0x41, 0x57, 0xec, 0x52, 0xe2, 0xb7, 0xcf, 0xdb, 0x0f, 0xbc, 0x40, 0x7d, 0x4b,
-	0x5d, 0x12, 0xbe, 0x21, 0xbe, 0xa3, 0x75, 0xf2, 0x1b, 0xdd, 0x86, 0x4e, 0xb4, 0xd9, 0xf8, 0x1d,
-	0xa5, 0x92, 0x9f, 0x38, 0x86, 0xfe, 0xcb, 0x6c, 0xf5, 0x03, 0x59, 0x0b, 0xf4, 0x31, 0x40, 0x9a,
-	0x7b, 0xe4, 0x7e, 0xfb, 0xbc, 0xf3, 0x60, 0x38, 0x3d, 0x9a, 0xa4, 0xab, 0x49, 0x11, 0x27, 0xb0,
-	0x0c, 0xd0, 0x7b, 0xe0, 0x09, 0xfa, 0x9a, 0x24, 0xdf, 0xc9, 0xc0, 0x3a, 0x48, 0xa9, 0x40, 0x77,
-	0xa0, 0xa7, 0x04, 0x13, 0x4b, 0x0b, 0xf8, 0x67, 0x07, 0x8e, 0x9f, 0xd3, 0x44, 0x90, 0xb7, 0x22,
-	0x20, 0x3f, 0x66, 0x84, 0x0b, 0xf4, 0x01, 0xf4, 0xb9, 0x4e, 0x40, 0x65, 0x3f, 0x9c, 0x0e, 0x65,
-	0x48, 0x93, 0x53, 0x90, 0x9f, 0xa1, 0x73, 0x18, 0x9a, 0x1e, 0x7c, 0x5b, 0x16, 0x65, 0xab, 0xd0,
-	0x18, 0x06, 0x8c, 0x70, 0x9a, 0xb1, 0x35, 0x31, 0x41, 0x0b, 0x19, 0x9d, 0x81, 0x1b, 0xae, 0x45,
-	0x44, 0x13, 0xbf, 0xab, 0x4e, 0x8c, 0x84, 0x9e, 0x01, 0x84, 0x42, 0xb0, 0x68, 0x95, 0x09, 0xc2,
-	0xfd, 0x9e, 0x2a, 0x19, 0xcb, 0xf8, 0xd5, 0x24, 0x27, 0xb3, 0xc2, 0x68, 0x91, 0x08, 0xb6, 0x0b,
-	0xac, 0x5b, 0xe3, 0xa7, 0x70, 0xb2, 0x77, 0x2c, 0xdb, 0xfc, 0x9a, 0xec, 0x0c, 0x1a, 0xf2, 0x53,
-	0xb6, 0xe3, 0x32, 0x8c, 0xb3, 0x3c, 0x71, 0x2d, 0x3c, 0x71, 0x1e, 0xb7, 0xf1, 0xf7, 0x70, 0x7a,
-	0xc1, 0x67, 0x71, 0x4c, 0x7f, 0x22, 0x9b, 0x80, 0xf0, 0x94, 0x26, 0x9c, 0x20, 0x1f, 0xfa, 0xa1,
-	0x56, 0x29, 0x27, 0x83, 0x20, 0x17, 0x65, 0x25, 0x8c, 0x84, 0x9c, 0x26, 0xca, 0x53, 0x2f, 0x30,
-	0x92, 0xd4, 0x13, 0xc6, 0xbe, 0xe1, 0x5b, 0x53, 0xbb, 0x91, 0xf0, 0x43, 0x38, 0x9a, 0x25, 0x9b,
-	0x65, 0x09, 0xdb, 0xfd, 0x1a, 0xca, 0x9e, 0x0d, 0x2b, 0xfe, 0xb3, 0x0d, 0x10, 0xd0, 0x98, 0x2c,
-	0x69, 0x1c, 0xad, 0x77, 0xe8, 0x18, 0x9c, 0x8b, 0xb9, 0xa9, 0xc4, 0xb9, 0x98, 0x4b, 0x56, 0x59,
-	0x00, 0xa8, 0x6f, 0x19, 0x7b, 0xf1, 0xea, 0x95, 0x44, 0xd0, 0xc4, 0xd6, 0x92, 0x2c, 0x5a, 0x7a,
-	0xe2, 0x7e, 0x57, 0x45, 0xd1, 0x82, 0x4c, 0xa0, 0x4c, 0x47, 0xf5, 0xdc, 0x0b, 0x2c, 0x8d, 0xe4,
-	0x55, 0x60, 0x70, 0xe3, 0xbe, 0xab, 0x8e, 0x4b, 0x05, 0xfa, 0x04, 0x46, 0xb9, 0xb0, 0x78, 0x9b,
-	0x32, 0xc2, 0x79, 0x44, 0x13, 0xee, 0xf7, 0x95, 0x5d, 0xd3, 0x91, 0xf4, 0xf7, 0x9c, 0x26, 0x9b,
-	0x48, 0xc1, 0x3f, 0xd0, 0x3c, 0x2d, 0x14, 0xf8, 0x77, 0x07, 0xdc, 0x7f, 0xa1, 0xd4, 0x47, 0x30,
-	0x4c, 0x09, 0x7b, 0x13, 0x99, 0x74, 0xba, 0x8a, 0x49, 0x77, 0xd5, 0xf0, 0x28, 0xe7, 0x93, 0x65,
-	0x71, 0x1a, 0xd8, 0x96, 0xe8, 0xd3, 0x5a, 0x37, 0x86, 0xd3, 0x53, 0x79, 0xaf, 0x82, 0xda, 0x7e,
-	0x83, 0xca, 0x82, 0xdc, 0xbd, 0x82, 0xc6, 0x0c, 0xa0, 0x8c, 0x55, 0x19, 0x8a, 0xf6, 0xde, 0x50,
-	0x4c, 0x00, 0xb1, 0x5a, 0xbf, 0x4c, 0xb5, 0x0d, 0x27, 0x8a, 0x94, 0x6a, 0x6c, 0xb8, 0xdf, 0x51,
-	0xed, 0xce, 0x45, 0xcc, 0x00, 0x2d, 0x24, 0xa3, 0x43, 0x41, 0x36, 0x45, 0x26, 0x12, 0xaa, 0x42,
-	0xb0, 0x02, 0xe8, 0x34, 0x9a, 0x8e, 0xd0, 0x47, 0x70, 0xdb, 0xf8, 0x91, 0x7d, 0x22, 0x3c, 0x8b,
-	0x85, 0xc9, 0xa7, 0xa6, 0xc7, 0xbf, 0x39, 0x30, 0x2a, 0x82, 0x5a, 0x84, 0x3d, 0x03, 0xf7, 0xa5,
-	0x08, 0x45, 0xc6, 0x4d, 0x20, 0x23, 0x19, 0x74, 0x9d, 0x1a, 0xba, 0x9d, 0x46, 0x74, 0xbb, 0xcd,
-	0x44, 0xee, 0x1d, 0x26, 0xb2, 0xfb, 0xcf, 0x44, 0xee, 0x5f, 0x93, 0xc8, 0x83, 0xc3, 0x44, 0xfe,
-	0xdc, 0xc6, 0xdd, 0x53, 0x6f, 0xe5, 0x99, 0x64, 0x4a, 0xbd, 0xf5, 0x36, 0xc1, 0xff, 0x72, 0xe0,
-	0xa4, 0xb0, 0xf8, 0x0f, 0x7b, 0xf4, 0x55, 0x75, 0x02, 0x34, 0x93, 0xef, 0x57, 0xf2, 0xbb, 0x62,
-	0x14, 0xae, 0xea, 0x67, 0xa5, 0xfe, 0xfe, 0x35, 0xeb, 0xff, 0x5f, 0xe6, 0xe1, 0x17, 0x07, 0xee,
-	0x95, 0x84, 0x9d, 0x93, 0x55, 0xb6, 0xbd, 0xf1, 0xd3, 0xee, 0x15, 0x4f, 0xfb, 0x13, 0x38, 0x66,
-	0x7a, 0x0f, 0x99, 0xad, 0xa4, 0xf0, 0x18, 0x4e, 0x51, 0x7d, 0x51, 0x05, 0x7b, 0x96, 0x08, 0xc3,
-	0xad, 0x2d, 0x0b, 0x13, 0x33, 0x22, 0xf9, 0x4b, 0x5c, 0xd1, 0xa1, 0x2f, 0xe0, 0x16, 0xcb, 0xe7,
-	0x27, 0x2a, 0xd6, 0xe0, 0xbd, 0x4a, 0x6b, 0xcb, 0x01, 0x0b, 0x2a, 0xc6, 0xe8, 0x21, 0x0c, 0xd2,
-	0xfc, 0xa2, 0xab, 0x2e, 0x8e, 0x1a, 0x30, 0x0f, 0x0a, 0x23, 0xfc, 0x21, 0x9c, 0xcc, 0xe2, 0x58,
-	0xfa, 0x2b, 0x5a, 0x72, 0x07, 0x7a, 0x4c, 0x65, 0xa7, 0xb7, 0x91, 0x16, 0xf0, 0xaf, 0x6d, 0xb8,
-	0x3b, 0x8b, 0x63, 0x8b, 0x2d, 0xb9, 0xfd, 0xd7, 0x55, 0xaa, 0xe9, 0x3f, 0x95, 0xf7, 0xd5, 0xa3,
-	0xd9, 0x64, 0x7f, 0x88, 0x70, 0xe3, 0x67, 0xd7, 0xa6, 0x86, 0x05, 0xb5, 0x53, 0x81, 0x7a, 0xfa,
-	0x87, 0x03, 0x9e, 0x29, 0x96, 0x32, 0xf4, 0x18, 0xbc, 0x62, 0x99, 0xa3, 0x06, 0x7c, 0xc6, 0x6a,
-	0x25, 0xd4, 0xf6, 0x3d, 0x6e, 0xa1, 0x2f, 0x01, 0xbd, 0x20, 0x62, 0x16, 0xc7, 0x2f, 0x6c, 0x68,
-	0x9a, 0x5c, 0x8c, 0x4c, 0xa1, 0x76, 0x0b, 0x71, 0x0b, 0xcd, 0xe1, 0x54, 0x3b, 0x58, 0x5a, 0x23,
-	0xd5, 0x74, 0xff, 0x9d, 0x83, 0x8d, 0xc2, 0x2d, 0xf4, 0x08, 0x06, 0xf3, 0x88, 0xaf, 0xe9, 0x25,
-	0x61, 0x37, 0xcb, 0xff, 0xa9, 0xbc, 0x18, 0x6e, 0x13, 0xca, 0x49, 0xe3, 0xc5, 0x77, 0x2d, 0x56,
-	0xec, 0xcf, 0x04, 0x6e, 0xad, 0x5c, 0xf5, 0x63, 0xfb, 0xd9, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff,
-	0x50, 0x10, 0x21, 0x84, 0xe9, 0x0a, 0x00, 0x00,
+	// 1492 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x58, 0xcd, 0x6e, 0xdb, 0xc6,
+	0x13, 0x37, 0x29, 0x59, 0x12, 0x47, 0xfe, 0x88, 0xd7, 0xf9, 0x60, 0xf4, 0xcf, 0x3f, 0x08, 0x88,
+	0x34, 0x35, 0x8a, 0xd6, 0x71, 0x9d, 0xa2, 0x09, 0x52, 0x04, 0xad, 0x12, 0x39, 0x81, 0x0f, 0x2d,
+	0x04, 0xa6, 0x40, 0x4e, 0x3d, 0x50, 0xd4, 0x46, 0x66, 0xb3, 0xe6, 0xd2, 0xcb, 0x95, 0x13, 0x9f,
+	0xdb, 0x27, 0xe8, 0x21, 0xe7, 0x9e, 0xfb, 0x00, 0xbd, 0x17, 0x28, 0xd0, 0x43, 0x9e, 0xa0, 0x4f,
+	0xd0, 0x43, 0x2f, 0x7d, 0x84, 0x62, 0x3f, 0xb8, 0x5c, 0x4a, 0x94, 0x1d, 0xa3, 0x0d, 0xd0, 0xdb,
+	0xce, 0xec, 0x70, 0x77, 0x66, 0x7e, 0xbf, 0xd9, 0x9d, 0x25, 0xac, 0xe6, 0x98, 0x1d, 0x27, 0x31,
+	0xde, 0xce, 0x18, 0xe5, 0x14, 0xb9, 0xd9, 0x28, 0xd8, 0x03, 0x6f, 0xc8, 0x92, 0x34, 0x4e, 0xb2,
+	0x88, 0x20, 0x04, 0x4d, 0x7e, 0x92, 0x61, 0xdf, 0xb9, 0xe1, 0x6c, 0x79, 0xa1, 0x1c, 0x0b, 0x5d,
+	0x1a, 0x1d, 0x62, 0xdf, 0x55, 0x3a, 0x31, 0x46, 0x17, 0xa0, 0x91, 0x8c, 0xc7, 0x7e, 0x43, 0xaa,
+	0xc4, 0x30, 0x20, 0xd0, 0x7e, 0x3a, 0x1d, 0x7d, 0x8b, 0x63, 0x8e, 0x3e, 0x02, 0xc8, 0x8a, 0x15,
+	0x73, 0xdf, 0xb9, 0xd1, 0xd8, 0xea, 0xee, 0xae, 0x6e, 0x67, 0xa3, 0x6d, 0xb3, 0x4f, 0x68, 0x19,
+	0xa0, 0x6b, 0xe0, 0x71, 0xfa, 0x02, 0xa7, 0x5f, 0x9f, 0x64, 0xc5, 0x26, 0xa5, 0x02, 0x5d, 0x84,
+	0x65, 0x29, 0xe8, 0xbd, 0x94, 0x10, 0xfc, 0xec, 0xc2, 0xda, 0x23, 0x9a, 0x72, 0xfc, 0x8a, 0x87,
+	0xf8, 0x68, 0x8a, 0x73, 0x8e, 0xde, 0x83, 0x76, 0xae, 0x1c, 0x90, 0xde, 0x77, 0x77, 0xbb, 0x62,
+	0x4b, 0xed, 0x53, 0x58, 0xcc, 0xa1, 0x1b, 0xd0, 0xd5, 0x39, 0xf8, 0xaa, 0x0c, 0xca, 0x56, 0xa1,
+	0x1e, 0x74, 0x18, 0xce, 0xe9, 0x94, 0xc5, 0x58, 0x6f, 0x6a, 0x64, 0x74, 0x19, 0x5a, 0x51, 0xcc,
+	0x13, 0x9a, 0xfa, 0x4d, 0x39, 0xa3, 0x25, 0xf4, 0x10, 0x20, 0xe2, 0x9c, 0x25, 0xa3, 0x29, 0xc7,
+	0xb9, 0xbf, 0x2c, 0x43, 0x0e, 0xc4, 0xfe, 0x55, 0x27, 0xb7, 0xfb, 0xc6, 0x68, 0x2f, 0xe5, 0xec,
+	0x24, 0xb4, 0xbe, 0x42, 0x37, 0x61, 0x35, 0xa6, 0x8c, 0x61, 0x12, 0x89, 0x25, 0xf7, 0x07, 0x7e,
+	0x4b, 0x6e, 0x51, 0x55, 0xf6, 0x1e, 0xc0, 0xfa, 0xcc, 0x22, 0x02, 0x8c, 0x17, 0xf8, 0x44, 0x63,
+	0x26, 0x86, 0x22, 0x69, 0xc7, 0x11, 0x99, 0x16, 0xe1, 0x29, 0xe1, 0xbe, 0x7b, 0xcf, 0x09, 0xbe,
+	0x73, 0x60, 0x63, 0x3f, 0xef, 0x13, 0x42, 0x5f, 0xe2, 0x71, 0x88, 0xf3, 0x8c, 0xa6, 0x39, 0x46,
+	0x3e, 0xb4, 0x23, 0xa5, 0x92, 0xab, 0x74, 0xc2, 0x42, 0x14, 0x01, 0x33, 0x1c, 0xe5, 0x34, 0x95,
+	0x4b, 0x2d, 0x87, 0x5a, 0x12, 0x7a, 0xcc, 0xd8, 0x97, 0xf9, 0x44, 0xa7, 0x48, 0x4b, 0xf3, 0x41,
+	0x34, 0x6b, 0x82, 0x08, 0x7e, 0x77, 0xa0, 0xd5, 0x8f, 0x63, 0x9c, 0xe7, 0xb3, 0x78, 0x38, 0xa7,
+	0xe3, 0xe1, 0x2e, 0xc4, 0xa3, 0x51, 0xc1, 0xe3, 0x7e, 0x05, 0x8f, 0xa6, 0xc4, 0xa3, 0x27, 0xf0,
+	0x50, 0xbb, 0x9e, 0x86, 0xc3, 0x3f, 0xcd, 0xf0, 0x37, 0xb0, 0xf2, 0x30, 0xe2, 0xf1, 0xc1, 0x39,
+	0x79, 0x79, 0x0b, 0x3a, 0x91, 0xf4, 0x0d, 0xe7, 0xbe, 0x2b, 0xfd, 0x85, 0xd2, 0xdf, 0xd0, 0xcc,
+	0x05, 0x03, 0x58, 0xd5, 0xcb, 0x6b, 0xec, 0xee, 0x80, 0x37, 0xc6, 0x71, 0x92, 0x27, 0x34, 0x2d,
+	0x8a, 0xed, 0x92, 0xf8, 0x72, 0x0e, 0xe5, 0xb0, 0xb4, 0x0b, 0x7e, 0x75, 0x00, 0xd4, 0xd2, 0xc3,
+	0x88, 0x1f, 0xa0, 0xeb, 0x73, 0x15, 0xeb, 0x55, 0x4a, 0xf4, 0x22, 0x2c, 0x33, 0x4a, 0xb4, 0x67,
+	0x5e, 0xa8, 0x04, 0x81, 0xb5, 0x18, 0x0c, 0x29, 0x49, 0xe2, 0x93, 0xfd, 0x41, 0xee, 0x37, 0xe4,
+	0x6c, 0x55, 0x29, 0xe0, 0xcb, 0xb4, 0xa0, 0xc9, 0x60, 0x64, 0xb9, 0xaf, 0x1c, 0x4b, 0xec, 0x97,
+	0xe5, 0xac, 0xa5, 0x11, 0xf3, 0x31, 0x4d, 0xc7, 0x09, 0x97, 0xc1, 0xb5, 0x94, 0x5f, 0xa5, 0x26,
+	0xf8, 0xc9, 0x01, 0x08, 0x29, 0xc1, 0x03, 0x9c, 0x26, 0x11, 0x39, 0x33, 0x0c, 0x04, 0x4d, 0xe1,
+	0x5b, 0x71, 0x92, 0x89, 0x31, 0x0a, 0x60, 0xc5, 0xf6, 0x57, 0xf3, 0xa8, 0xa2, 0x43, 0xb7, 0x60,
+	0xad, 0x94, 0xa5, 0xab, 0x2a, 0x90, 0x19, 0xad, 0x38, 0xc9, 0x8c, 0x73, 0x3a, 0x9a, 0x52, 0x11,
+	0xfc, 0xe5, 0xc0, 0x45, 0x0d, 0x27, 0x3e, 0x4e, 0xf0, 0x4b, 0x83, 0xe0, 0xbb, 0x29, 0x81, 0x2d,
+	0x68, 0x4f, 0x58, 0x94, 0x72, 0x3c, 0xd6, 0xfc, 0x5f, 0x2b, 0xf9, 0x24, 0x40, 0x0f, 0x8b, 0x69,
+	0x74, 0x0b, 0x5a, 0x63, 0x9c, 0x26, 0x78, 0xec, 0x2f, 0xd7, 0x1a, 0xea, 0x59, 0xb4, 0x03, 0x5d,
+	0x35, 0x0a, 0x25, 0x17, 0x5a, 0xa5, 0x71, 0x89, 0x41, 0x68, 0x9b, 0x04, 0xb7, 0x61, 0xb5, 0x9f,
+	0x8e, 0x87, 0x25, 0x02, 0x67, 0x20, 0x14, 0xfc, 0xe0, 0x2a, 0x40, 0x55, 0x52, 0xd1, 0x1a, 0xb8,
+	0xfb, 0x03, 0x9d, 0x10, 0x77, 0x7f, 0x20, 0x00, 0xb4, 0x4e, 0x6d, 0x39, 0x16, 0xf1, 0xef, 0x3d,
+	0x7f, 0x2e, 0xca, 0x4b, 0xc7, 0xaf, 0x24, 0xc1, 0x59, 0xe5, 0x67, 0x53, 0x71, 0x56, 0x0a, 0xc2,
+	0x81, 0xd2, 0x1d, 0x19, 0xaf, 0x17, 0x5a, 0x1a, 0x01, 0x61, 0xa8, 0x33, 0x5b, 0x10, 0xae, 0x54,
+	0xa0, 0x1d, 0xd8, 0x2c, 0x84, 0xbd, 0x57, 0x19, 0xc3, 0xb9, 0xaa, 0xba, 0xb6, 0xb4, 0xab, 0x9b,
+	0x12, 0xeb, 0x3d, 0x32, 0x94, 0xe8, 0x28, 0x4a, 0x18, 0x85, 0xa8, 0xa0, 0xe2, 0xa3, 0x27, 0x84,
+	0x8e, 0x72, 0xdf, 0x53, 0x15, 0x54, 0x51, 0x06, 0x7f, 0xb8, 0xd0, 0xfa, 0x17, 0x12, 0x72, 0x17,
+	0xba, 0x19, 0x66, 0x87, 0x89, 0x76, 0xba, 0x59, 0x1e, 0x15, 0x6a, 0xf1, 0xed, 0xa1, 0x99, 0x0d,
+	0x6d, 0x4b, 0xf4, 0xf1, 0x5c, 0xce, 0xba, 0xbb, 0x1b, 0x92, 0x23, 0x36, 0xb6, 0xb3, 0x69, 0x2c,
+	0xc3, 0x6e, 0xcd, 0x84, 0xdd, 0x7b, 0xed, 0x00, 0x94, 0x9b, 0x55, 0xd8, 0xed, 0xcc, 0xb0, 0x7b,
+	0x1b, 0x10, 0x9b, 0x4b, 0xab, 0x0e, 0xb7, 0x66, 0x46, 0xde, 0x64, 0xb1, 0x3a, 0x2e, 0xd4, 0x69,
+	0x54, 0x88, 0xb2, 0xd0, 0xad, 0xb4, 0xea, 0x12, 0xae, 0xe8, 0x02, 0x06, 0x68, 0x4f, 0x9c, 0xe4,
+	0x11, 0xc7, 0xe3, 0x12, 0xa5, 0x1d, 0xd8, 0x34, 0x82, 0xe5, 0x84, 0x72, 0xb5, 0x6e, 0x0a, 0x7d,
+	0x00, 0x17, 0xf4, 0x3a, 0x22, 0x99, 0x38, 0x9f, 0x12, 0xae, 0x7d, 0x9e, 0xd3, 0x07, 0x6f, 0x5c,
+	0xd8, 0x34, 0x9b, 0x5a, 0xdc, 0xbf, 0x0c, 0xad, 0xa7, 0x3c, 0xe2, 0xd3, 0x5c, 0x6f, 0xa4, 0x25,
+	0x4d, 0x01, 0x77, 0x8e, 0x02, 0x8d, 0x5a, 0x0a, 0x34, 0xeb, 0x6b, 0x62, 0x79, 0x71, 0x4d, 0xb4,
+	0x4e, 0xaf, 0x89, 0xf6, 0x5b, 0xd6, 0x44, 0x67, 0x71, 0x4d, 0x7c, 0x62, 0x93, 0xc3, 0x93, 0x77,
+	0xe2, 0x65, 0x41, 0xa7, 0xf9, 0xd4, 0x9f, 0x5a, 0x2b, 0x50, 0x57, 0x2b, 0xaf, 0x1b, 0xb0, 0x6e,
+	0xd6, 0x79, 0x87, 0x99, 0xfc, 0xa2, 0x5a, 0x4c, 0xaa, 0x28, 0xae, 0x57, 0xa2, 0x38, 0xa3, 0xaa,
+	0xce, 0xca, 0x7a, 0x25, 0x4b, 0xed, 0xb7, 0xcc, 0xd2, 0x7f, 0xb7, 0xb4, 0xfe, 0x74, 0xe1, 0x4a,
+	0xc9, 0xfd, 0x01, 0x1e, 0x4d, 0x27, 0xe7, 0x6e, 0x3f, 0x3d, 0xd3, 0x7e, 0xde, 0x87, 0x35, 0xa6,
+	0xfa, 0x2b, 0xdd, 0x60, 0x4b, 0xd0, 0xba, 0xbb, 0x68, 0xbe, 0xe7, 0x0e, 0x67, 0x2c, 0x85, 0xb7,
+	0xfa, 0xe6, 0xb3, 0xef, 0x87, 0x8a, 0x0e, 0x7d, 0x66, 0x75, 0x05, 0x89, 0xe9, 0xe8, 0xaf, 0x54,
+	0xf2, 0x5f, 0xd6, 0x6a, 0x58, 0x31, 0x46, 0xb7, 0x75, 0xc7, 0x93, 0x98, 0x4b, 0x72, 0xb3, 0x86,
+	0x18, 0xa1, 0x31, 0x12, 0x48, 0xc4, 0xf4, 0x70, 0x94, 0xa4, 0x49, 0x3a, 0xe9, 0x93, 0x09, 0x65,
+	0x09, 0x3f, 0x38, 0x94, 0x98, 0x7b, 0x61, 0xcd, 0x8c, 0x68, 0x18, 0xf0, 0xab, 0x8c, 0x44, 0x69,
+	0x64, 0x5d, 0x2b, 0xb6, 0x2a, 0x78, 0x1f, 0xd6, 0xfb, 0x84, 0x08, 0x0f, 0x4d, 0x92, 0x4d, 0x0f,
+	0xe7, 0x58, 0x3d, 0x5c, 0xf0, 0x9b, 0x03, 0x97, 0xfa, 0x84, 0x58, 0x24, 0x2d, 0xec, 0x1f, 0x57,
+	0x19, 0xae, 0x3a, 0xcb, 0x9b, 0xf2, 0xd8, 0xaf, 0xb3, 0x5f, 0xc4, 0xf3, 0xde, 0xf3, 0xb7, 0x26,
+	0xa4, 0x45, 0x30, 0xf7, 0x74, 0x82, 0x35, 0x6a, 0x08, 0xf6, 0xbd, 0x03, 0xfe, 0x30, 0x62, 0x3c,
+	0x89, 0x88, 0x7d, 0xc6, 0xaa, 0x26, 0xfc, 0x43, 0x68, 0xc7, 0x9a, 0x28, 0xce, 0x42, 0xa2, 0x14,
+	0x26, 0xc2, 0xc9, 0x69, 0xfa, 0x22, 0xa5, 0x2f, 0x8d, 0x27, 0x46, 0x16, 0x65, 0x9b, 0x1f, 0x91,
+	0x41, 0x12, 0x91, 0xf2, 0x86, 0xb5, 0x34, 0xc1, 0x8f, 0x0e, 0x74, 0x42, 0x9c, 0x27, 0xe3, 0x69,
+	0x44, 0xc4, 0x09, 0x43, 0xb3, 0xe2, 0xba, 0xa6, 0x19, 0xda, 0x82, 0x0e, 0xcd, 0x30, 0x8b, 0xd2,
+	0x71, 0xd1, 0xe4, 0xaf, 0xc8, 0xf6, 0x49, 0xdb, 0x87, 0x66, 0x56, 0x9c, 0xb9, 0xe6, 0x49, 0xa2,
+	0x77, 0x29, 0x15, 0xe5, 0xeb, 0xa3, 0x69, 0xbd, 0x3e, 0x44, 0x9b, 0x4a, 0xf9, 0x01, 0x66, 0xe6,
+	0xf5, 0xa2, 0x7b, 0xd0, 0x19, 0x6d, 0xf0, 0x8b, 0x03, 0x57, 0x6b, 0x32, 0xa5, 0x71, 0xdf, 0x92,
+	0x08, 0x49, 0x7f, 0x74, 0xae, 0x66, 0x7c, 0x2c, 0x66, 0xe7, 0x0a, 0xc9, 0xad, 0x29, 0xa4, 0x1e,
+	0x74, 0xf2, 0x23, 0xf2, 0xec, 0x00, 0x33, 0xf3, 0x98, 0x2e, 0x64, 0x81, 0x77, 0x7e, 0x44, 0xfa,
+	0x6c, 0x52, 0xd4, 0x60, 0x21, 0x0a, 0x82, 0x1f, 0xd2, 0x74, 0x42, 0x1f, 0x27, 0x84, 0x63, 0xa6,
+	0xc3, 0xb0, 0x55, 0xbb, 0x6f, 0x9a, 0xe0, 0x69, 0xe7, 0x29, 0x43, 0xf7, 0xc0, 0x33, 0xcf, 0x1d,
+	0x54, 0x03, 0x6d, 0xaf, 0xfe, 0x45, 0x14, 0x2c, 0xa1, 0xbb, 0xb0, 0x66, 0xd4, 0xf2, 0x5d, 0x85,
+	0x2e, 0x08, 0x53, 0xfb, 0x05, 0xd7, 0xdb, 0xb0, 0x34, 0xe6, 0xc3, 0x87, 0xb0, 0x6e, 0x3e, 0x7c,
+	0xca, 0x19, 0x8e, 0x0e, 0xcf, 0xb5, 0xf1, 0x96, 0xb3, 0xe3, 0xa0, 0xcf, 0x01, 0x3d, 0xc1, 0xbc,
+	0x4f, 0xc8, 0x13, 0x3b, 0x65, 0x75, 0xcb, 0x6c, 0xea, 0xba, 0xb3, 0x2b, 0x3a, 0x58, 0x42, 0x03,
+	0xd8, 0x50, 0x0b, 0x0c, 0xad, 0x8b, 0xa5, 0xee, 0xfb, 0xab, 0x0b, 0xeb, 0x56, 0xe6, 0xa0, 0x33,
+	0x48, 0xf2, 0x98, 0x1e, 0x63, 0x76, 0xbe, 0xe4, 0x3d, 0x10, 0x1f, 0x46, 0x93, 0x94, 0xe6, 0xb8,
+	0xf6, 0xc3, 0xff, 0x59, 0xc7, 0xde, 0xec, 0xa1, 0x1f, 0x2c, 0xa1, 0x4f, 0x61, 0xe5, 0xd9, 0x01,
+	0x7d, 0x14, 0xa5, 0xfa, 0x57, 0x80, 0xf5, 0xe0, 0xed, 0xf9, 0xe5, 0xb8, 0xfa, 0x5a, 0x0a, 0x96,
+	0xd0, 0x10, 0xd6, 0xab, 0xf4, 0xc5, 0xe8, 0x9a, 0x6c, 0x63, 0x17, 0x54, 0x7f, 0xef, 0xff, 0x0b,
+	0x66, 0x8b, 0x15, 0x47, 0x2d, 0xf9, 0x3b, 0xec, 0xce, 0xdf, 0x03, 0x00, 0x94, 0xd2, 0x58, 0xc9,
+	0x1f, 0x13, 0x00, 0x00,
 }
@@ -0,0 +1,112 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package adsgrpc
+
+import (
+	"io"
+	"sync"
+
+	"github.com/teramoby/speedle-plus/pkg/eval"
+	"github.com/teramoby/speedle-plus/pkg/logging"
+	"github.com/teramoby/speedle-plus/pkg/svcs/adsgrpc/pb"
+)
+
+// DefaultStreamWorkers is the default number of requests evaluated concurrently in an IsAllowedStream
+const DefaultStreamWorkers = 16
+
+func (impl *GRPCService) isAllowedInStream(evaluator eval.InternalEvaluator, in *pb.ContextRequest) *pb.IsAllowedResponse {
+	reqCtx := convertGRPCContextRequest(in)
+
+	// assert token
+	evaluator.AssertToken(reqCtx)
+
+	allowed, reason, err := evaluator.IsAllowed(*reqCtx)
+	response := pb.IsAllowedResponse{
+		Allowed:       allowed,
+		Reason:        int32(reason),
+		CorrelationID: in.CorrelationID,
+	}
+	if err != nil {
+		// an error fails the request only, the stream goes on
+		response.ErrMsg = err.Error()
+		// Audit log
+		logging.WriteSimpleFailedAuditLog("[gRPC]IsAllowedStream", reqCtx, err.Error())
+		return &response
+	}
+
+	// Audit log
+	logging.WriteSimpleSucceededAuditLog("[gRPC]IsAllowedStream", reqCtx, response)
+	return &response
+}
+
+/*
+IsAllowedStream evaluates the requests received from a stream concurrently, and sends the responses as
+they complete, so the responses may be out of order and are correlated with requests by correlation ID.
+
+At most StreamWorkers requests are evaluated at the same time. When all workers are busy, no more requests
+are received, so the flow control of the stream slows down the client.
+*/
+func (impl *GRPCService) IsAllowedStream(stream pb.Evaluator_IsAllowedStreamServer) error {
+	evaluator := impl.evaluatorOf(stream.Context())
+	workers := impl.StreamWorkers
+	if workers <= 0 {
+		workers = DefaultStreamWorkers
+	}
+
+	requests := make(chan *pb.ContextRequest)
+	responses := make(chan *pb.IsAllowedResponse, workers)
+	// done is closed when the handler returns, so the goroutines do not wait for each other any more
+	done := make(chan struct{})
+	defer close(done)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for in := range requests {
+				select {
+				case responses <- impl.isAllowedInStream(evaluator, in):
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(responses)
+	}()
+
+	recvErr := make(chan error, 1)
+	go func() {
+		defer close(requests)
+		for {
+			in, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					recvErr <- err
+				}
+				return
+			}
+			select {
+			case requests <- in:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for response := range responses {
+		if err := stream.Send(response); err != nil {
+			return err
+		}
+	}
+	select {
+	case err := <-recvErr:
+		return err
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,177 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package adsgrpc
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	adsapi "github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/pkg/cfg"
+	"github.com/teramoby/speedle-plus/pkg/eval"
+	_ "github.com/teramoby/speedle-plus/pkg/store/file"
+	"github.com/teramoby/speedle-plus/pkg/svcs/adsgrpc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testPolicies = `
+{
+	"services": [
+	{
+		"name": "erp",
+		"policies": [
+		{
+			"id": "id1",
+			"effect": "grant",
+			"permissions": [{"resource": "res1", "actions": ["read"]}],
+			"principals": [["user:userA"]]
+		}
+		]
+	}
+	]
+}
+`
+
+func startTestServer(t *testing.T, workers int) (pb.EvaluatorClient, func()) {
+	dir, err := ioutil.TempDir("", "adsgrpc-stream")
+	if err != nil {
+		t.Fatal("fail to create temp dir:", err)
+	}
+	storeFile := filepath.Join(dir, "ps.json")
+	if err := ioutil.WriteFile(storeFile, []byte(testPolicies), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf := cfg.Config{
+		StoreConfig: &cfg.StoreConfig{
+			StoreType:  cfg.StorageTypeFile,
+			StoreProps: map[string]interface{}{"FileLocation": storeFile},
+		},
+	}
+	evaluator, err := eval.NewFromConfig(&conf)
+	if err != nil {
+		t.Fatal("fail to create evaluator:", err)
+	}
+	service, err := NewGRPCService(evaluator)
+	if err != nil {
+		t.Fatal("fail to create service:", err)
+	}
+	service.StreamWorkers = workers
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	pb.RegisterEvaluatorServer(server, service)
+	go server.Serve(listener)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal("fail to connect to server:", err)
+	}
+	return pb.NewEvaluatorClient(conn), func() {
+		conn.Close()
+		server.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestIsAllowedStream(t *testing.T) {
+	client, stop := startTestServer(t, 4)
+	defer stop()
+
+	streamClient, err := NewStreamClient(context.Background(), client)
+	if err != nil {
+		t.Fatal("fail to open stream:", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// even requests are granted, odd ones are not
+			user := "userA"
+			if i%2 == 1 {
+				user = fmt.Sprintf("user%d", i)
+			}
+			service := "erp"
+			if i%10 == 9 {
+				service = "dummy"
+			}
+			response, err := streamClient.IsAllowed(context.Background(), &pb.ContextRequest{
+				Subject: &pb.Subject{
+					Principals: []*pb.Principal{{Type: adsapi.PRINCIPAL_TYPE_USER, Name: user}},
+				},
+				ServiceName: service,
+				Resource:    "res1",
+				Action:      "read",
+			})
+			switch {
+			case err != nil:
+				errs <- fmt.Errorf("request %d: %v", i, err)
+			case response.Allowed != (i%2 == 0):
+				errs <- fmt.Errorf("request %d: unexpected response %v", i, response)
+			case service == "dummy" && (response.Reason != int32(adsapi.SERVICE_NOT_FOUND) || len(response.ErrMsg) == 0):
+				errs <- fmt.Errorf("request %d: error should be returned in response, but got %v", i, response)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if err := streamClient.Close(); err != nil {
+		t.Fatal("fail to close stream:", err)
+	}
+	if _, err := streamClient.IsAllowed(context.Background(), &pb.ContextRequest{ServiceName: "erp"}); err != ErrStreamClosed {
+		t.Fatal("request should fail after the stream is closed, but got", err)
+	}
+}
+
+func TestIsAllowedStreamOutOfOrder(t *testing.T) {
+	client, stop := startTestServer(t, 2)
+	defer stop()
+
+	stream, err := client.IsAllowedStream(context.Background())
+	if err != nil {
+		t.Fatal("fail to open stream:", err)
+	}
+	ids := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		id := fmt.Sprintf("req-%d", i)
+		ids[id] = true
+		if err := stream.Send(&pb.ContextRequest{ServiceName: "erp", Resource: "res1", Action: "read", CorrelationID: id}); err != nil {
+			t.Fatal("fail to send request:", err)
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		response, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		if !ids[response.CorrelationID] {
+			t.Fatalf("unexpected correlation ID %q", response.CorrelationID)
+		}
+		delete(ids, response.CorrelationID)
+	}
+	if len(ids) != 0 {
+		t.Fatal("responses are not received for", ids)
+	}
+}
@@ -0,0 +1,69 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package adsgrpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/teramoby/speedle-plus/pkg/eval"
+	"github.com/teramoby/speedle-plus/pkg/svcs"
+)
+
+type evaluatorKey struct{}
+
+// tenantStream is a server stream whose context keeps the evaluator of the tenant
+type tenantStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tenantStream) Context() context.Context {
+	return s.ctx
+}
+
+// withTenant returns the context with the evaluator of the tenant chosen by the speedle-tenant metadata
+func withTenant(ctx context.Context, evaluators map[string]eval.InternalEvaluator) (context.Context, error) {
+	tenant := svcs.TenantFromContext(ctx)
+	evaluator, ok := evaluators[tenant]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "tenant %q is not found", tenant)
+	}
+	return context.WithValue(ctx, evaluatorKey{}, evaluator), nil
+}
+
+// UnaryTenantInterceptor evaluates every call with the evaluator of the tenant chosen by the speedle-tenant metadata,
+// every tenant has its own policies in the runtime policy store of its evaluator
+func UnaryTenantInterceptor(evaluators map[string]eval.InternalEvaluator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := withTenant(ctx, evaluators)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamTenantInterceptor evaluates the requests of every stream with the evaluator of the tenant chosen by the
+// speedle-tenant metadata
+func StreamTenantInterceptor(evaluators map[string]eval.InternalEvaluator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := withTenant(ss.Context(), evaluators)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &tenantStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// evaluatorOf returns the evaluator of the tenant of a call, which is the one of the service if no tenant is chosen
+func (impl *GRPCService) evaluatorOf(ctx context.Context) eval.InternalEvaluator {
+	if evaluator, ok := ctx.Value(evaluatorKey{}).(eval.InternalEvaluator); ok {
+		return evaluator
+	}
+	return impl.evaluator
+}
@@ -0,0 +1,29 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package adsgrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/teramoby/speedle-plus/pkg/svcs/adsgrpc/pb"
+)
+
+func TestWhoCanAccess(t *testing.T) {
+	client, stop := startTestServer(t, 1)
+	defer stop()
+
+	review, err := client.WhoCanAccess(context.Background(), &pb.Access{ServiceName: "erp", Resource: "res1", Action: "read"})
+	if err != nil {
+		t.Fatal("fail to review access:", err)
+	}
+	if len(review.Granted) != 1 || review.Granted[0].PolicyID != "id1" || len(review.Granted[0].Principals) != 1 ||
+		review.Granted[0].Principals[0] != "user:userA" || len(review.Denied) != 0 {
+		t.Fatal("only userA should be granted, but got", review)
+	}
+
+	if _, err := client.WhoCanAccess(context.Background(), &pb.Access{ServiceName: "dummy", Resource: "res1", Action: "read"}); err == nil {
+		t.Fatal("should fail if service is not found")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	adsapi "github.com/teramoby/speedle-plus/api/ads"
+	pmsapi "github.com/teramoby/speedle-plus/api/pms"
 	"github.com/teramoby/speedle-plus/pkg/cfg"
 	"github.com/teramoby/speedle-plus/pkg/errors"
 	"github.com/teramoby/speedle-plus/pkg/eval"
@@ -46,6 +47,18 @@ type JsonContext struct {
 	Attributes  []*JsonAttribute `json:"attributes"`
 }
 
+type JsonAccess struct {
+	ServiceName string           `json:"serviceName"`
+	Resource    string           `json:"resource"`
+	Action      string           `json:"action"`
+	Attributes  []*JsonAttribute `json:"attributes"`
+}
+
+type JsonBatchContext struct {
+	Subject  *JsonSubject  `json:"subject"`
+	Requests []*JsonAccess `json:"requests"`
+}
+
 type RESTService struct {
 	Evaluator eval.InternalEvaluator
 }
@@ -62,8 +75,9 @@ type AuditEvaluationResult struct {
 }
 
 type PermissionResponse struct {
-	Resource string   `json:"resource"`
-	Actions  []string `json:"actions"`
+	Resource     string   `json:"resource,omitempty"`
+	ResourceGlob string   `json:"resourceGlob,omitempty"`
+	Actions      []string `json:"actions"`
 }
 
 type PolicyResponse struct {
@@ -85,12 +99,14 @@ type RolePolicyResponse struct {
 	Principals          []string           `json:"principals,omitempty"`
 	Resources           []string           `json:"resources,omitempty"`
 	ResourceExpressions []string           `json:"resourceExpressions,omitempty"`
+	ResourceGlobs       []string           `json:"resourceGlobs,omitempty"`
 	Condition           EvaluatedCondition `json:"condition,omitempty"`
 }
 
 type Permission struct {
 	Resource           string   `json:"resource,omitempty"`
 	ResourceExpression string   `json:"resourceExpression,omitempty"`
+	ResourceGlob       string   `json:"resourceGlob,omitempty"`
 	Actions            []string `json:"actions,omitempty"`
 }
 
@@ -101,13 +117,15 @@ type EvaluatedCondition struct {
 
 // Should we add Both of ReasonCode and ReasonMessage
 type EvaluationDebugResponse struct {
-	Allowed        bool                   `json:"allowed"`
-	Reason         string                 `json:"reason"`
-	RequestContext JsonContext            `json:"requestContext,omitempty"`
-	Attributes     map[string]interface{} `json:"attributes,omitempty"`
-	GrantedRoles   []string               `json:"grantedRoles,omitempty"`
-	RolePolicies   []RolePolicyResponse   `json:"rolePolicies,omitempty"`
-	Policies       []PolicyResponse       `json:"policies,omitempty"`
+	Allowed            bool                   `json:"allowed"`
+	Reason             string                 `json:"reason"`
+	RequestContext     JsonContext            `json:"requestContext,omitempty"`
+	Attributes         map[string]interface{} `json:"attributes,omitempty"`
+	GrantedRoles       []string               `json:"grantedRoles,omitempty"`
+	RolePolicies       []RolePolicyResponse   `json:"rolePolicies,omitempty"`
+	Policies           []PolicyResponse       `json:"policies,omitempty"`
+	CombiningAlgorithm string                 `json:"combiningAlgorithm,omitempty"`
+	Explanation        string                 `json:"explanation,omitempty"`
 }
 
 func NewRESTService(conf *cfg.Config) (*RESTService, error) {
@@ -154,10 +172,10 @@ func VerifyAttributeName(attrName string) error {
 // Key is data type in json
 // Value is the data type in go
 var dataTypeMap = map[string]string{
-	"string":   "string",
-	"numeric":  "float64",
-	"bool":     "bool",
-	"datetime": "string",
+	pmsapi.AttributeTypeString:   "string",
+	pmsapi.AttributeTypeNumeric:  "float64",
+	pmsapi.AttributeTypeBool:     "bool",
+	pmsapi.AttributeTypeDatetime: "string",
 }
 
 var supportDateTimeLayout = []string{
@@ -194,7 +212,7 @@ func ConvSingleValue(dataType string, value interface{}) (interface{}, error) {
 	}
 
 	switch dataType {
-	case "datetime":
+	case pmsapi.AttributeTypeDatetime:
 		strValue, _ := value.(string)
 		retTime, err := ParseDateTime(strValue)
 		if err != nil {
@@ -270,16 +288,21 @@ func DumpPrincipals(principals []*JsonPrincipal) []*adsapi.Principal {
 	return ret
 }
 
-func ConvertJSONRequestToContext(ctxContext *JsonContext) (*adsapi.RequestContext, error) {
+func ConvertJSONSubject(jsonSubject *JsonSubject) *adsapi.Subject {
 	subject := adsapi.Subject{}
-	if ctxContext.Subject != nil {
-		apiPrincipals := DumpPrincipals(ctxContext.Subject.Principals)
+	if jsonSubject != nil {
+		apiPrincipals := DumpPrincipals(jsonSubject.Principals)
 		subject = adsapi.Subject{
 			Principals: apiPrincipals,
-			TokenType:  ctxContext.Subject.TokenType,
-			Token:      ctxContext.Subject.Token,
+			TokenType:  jsonSubject.TokenType,
+			Token:      jsonSubject.Token,
 		}
 	}
+	return &subject
+}
+
+func ConvertJSONRequestToContext(ctxContext *JsonContext) (*adsapi.RequestContext, error) {
+	subject := ConvertJSONSubject(ctxContext.Subject)
 
 	contextAttr, err := DumpRequestAttributes(ctxContext.Attributes)
 	if err != nil {
@@ -287,7 +310,7 @@ func ConvertJSONRequestToContext(ctxContext *JsonContext) (*adsapi.RequestContex
 	}
 
 	context := adsapi.RequestContext{
-		Subject:     &subject,
+		Subject:     subject,
 		ServiceName: ctxContext.ServiceName,
 		Resource:    ctxContext.Resource,
 		Action:      ctxContext.Action,
@@ -351,6 +374,78 @@ func (e *RESTService) IsAllowed(w http.ResponseWriter, r *http.Request) {
 	httputils.SendOKResponse(w, &response)
 }
 
+func ConvertJSONAccesses(jsonAccesses []*JsonAccess) ([]adsapi.Access, error) {
+	accesses := make([]adsapi.Access, 0, len(jsonAccesses))
+	for _, jsonAccess := range jsonAccesses {
+		if jsonAccess == nil {
+			return nil, errors.New(errors.InvalidRequest, "null request is not allowed")
+		}
+		attributes, err := DumpRequestAttributes(jsonAccess.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		accesses = append(accesses, adsapi.Access{
+			ServiceName: jsonAccess.ServiceName,
+			Resource:    jsonAccess.Resource,
+			Action:      jsonAccess.Action,
+			Attributes:  attributes,
+		})
+	}
+	return accesses, nil
+}
+
+// IsAllowedBatch checks many accesses for a subject, the decisions are returned in the order of the requests
+func (e *RESTService) IsAllowedBatch(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	var jsonRequest JsonBatchContext
+	if err := decoder.Decode(&jsonRequest); err != nil {
+		httputils.HandleError(w, errors.Wrap(err, errors.InvalidRequest, "unable to decode request"))
+		return
+	}
+
+	subject := ConvertJSONSubject(jsonRequest.Subject)
+	accesses, err := ConvertJSONAccesses(jsonRequest.Requests)
+	if err != nil {
+		httputils.HandleError(w, err)
+		return
+	}
+
+	decisions, err := e.Evaluator.IsAllowedBatch(subject, accesses)
+	if err != nil {
+		httputils.HandleError(w, err)
+		// Audit log
+		logging.WriteFailedAuditLog("IsAllowedBatch", log.Fields{"subject": subject, "requests": accesses}, err.Error())
+		return
+	}
+
+	response := make([]IsAllowedResponse, 0, len(decisions))
+	responseForAudit := make([]*AuditEvaluationResult, 0, len(decisions))
+	for _, decision := range decisions {
+		result := IsAllowedResponse{
+			Allowed: decision.Allowed,
+			Reason:  int32(decision.Reason),
+		}
+		if decision.Error != nil {
+			result.ErrorMessage = decision.Error.Error()
+		}
+		response = append(response, result)
+		responseForAudit = append(responseForAudit, constructEvaluationResultForAudit(decision.Allowed, decision.Reason))
+	}
+
+	//Token assertion is done in e.Evaluator.IsAllowedBatch(). Now subject has been populated with subject info
+	for _, principal := range subject.Principals {
+		if principal.Type == adsapi.PRINCIPAL_TYPE_USER {
+			w.Header().Add(svcs.PrincipalsHeader, principal.Name)
+			break
+		}
+	}
+
+	// Audit log
+	logging.WriteSucceededAuditLog("IsAllowedBatch", log.Fields{"subject": subject, "requests": accesses}, log.Fields{"evaluationResults": responseForAudit})
+
+	httputils.SendOKResponse(w, response)
+}
+
 func (e *RESTService) GetAllGrantedRoles(w http.ResponseWriter, r *http.Request) {
 	jsonRequest, err := DecodeJSONContext(r)
 	if err != nil {
@@ -406,8 +501,9 @@ func (e *RESTService) GetAllGrantedPermissions(w http.ResponseWriter, r *http.Re
 	var retPermissions []PermissionResponse
 	for _, permission := range permissions {
 		retPermissions = append(retPermissions, PermissionResponse{
-			Resource: permission.Resource,
-			Actions:  permission.Actions,
+			Resource:     permission.Resource,
+			ResourceGlob: permission.ResourceGlob,
+			Actions:      permission.Actions,
 		})
 	}
 
@@ -434,6 +530,7 @@ func ConvertAPIPolicy2PolicyResponse(apiPolicy *adsapi.EvaluatedPolicy, policyRe
 			Resource:           permission.Resource,
 			Actions:            permission.Actions,
 			ResourceExpression: permission.ResourceExpression,
+			ResourceGlob:       permission.ResourceGlob,
 		})
 	}
 
@@ -467,6 +564,7 @@ func ConvertAPIRolePolicy2RolePolicyResponse(apiRolePolicy *adsapi.EvaluatedRole
 	rolePolicyResp.Principals = apiRolePolicy.Principals
 	rolePolicyResp.Resources = apiRolePolicy.Resources
 	rolePolicyResp.ResourceExpressions = apiRolePolicy.ResourceExpressions
+	rolePolicyResp.ResourceGlobs = apiRolePolicy.ResourceGlobs
 
 	if apiRolePolicy.Condition != nil {
 		rolePolicyResp.Condition = EvaluatedCondition{
@@ -515,13 +613,15 @@ func (e *RESTService) Diagnose(w http.ResponseWriter, r *http.Request) {
 
 	// Construct & return the response
 	response := EvaluationDebugResponse{
-		Allowed:        evaResult.Allowed,
-		Reason:         evaResult.Reason.String(),
-		RequestContext: *jsonRequest,
-		Attributes:     evaResult.Attributes,
-		GrantedRoles:   evaResult.GrantedRoles,
-		RolePolicies:   retRolePolicies,
-		Policies:       retPolicies,
+		Allowed:            evaResult.Allowed,
+		Reason:             evaResult.Reason.String(),
+		RequestContext:     *jsonRequest,
+		Attributes:         evaResult.Attributes,
+		GrantedRoles:       evaResult.GrantedRoles,
+		RolePolicies:       retRolePolicies,
+		Policies:           retPolicies,
+		CombiningAlgorithm: evaResult.CombiningAlgorithm,
+		Explanation:        evaResult.Explanation,
 	}
 
 	// Audit log
@@ -529,3 +629,8 @@ func (e *RESTService) Diagnose(w http.ResponseWriter, r *http.Request) {
 
 	httputils.SendOKResponse(w, &response)
 }
+
+// DecisionCacheStats returns the hit and miss statistics of the decision cache
+func (e *RESTService) DecisionCacheStats(w http.ResponseWriter, r *http.Request) {
+	httputils.SendOKResponse(w, e.Evaluator.DecisionCacheStats())
+}
@@ -12,6 +12,7 @@ import (
 
 	"net/http/httptest"
 
+	adsapi "github.com/teramoby/speedle-plus/api/ads"
 	"github.com/teramoby/speedle-plus/pkg/assertion"
 	"github.com/teramoby/speedle-plus/pkg/svcs"
 )
@@ -118,3 +119,122 @@ func newADSServerWithAsserter(assertserverendpoint string, t *testing.T) (*httpt
 	conf.AsserterWebhookConfig = asconfig
 	return NewTestServerWithConfig(conf)
 }
+
+func TestIsAllowedBatch(t *testing.T) {
+	assertserver := assertion.NewTestServer(t, nil)
+	defer assertserver.Close()
+
+	adsserver, err := newADSServerWithAsserter(assertserver.URL, t)
+	if err != nil {
+		t.Fatal("Failed to start ADS! Error:", err)
+	}
+	defer adsserver.Close()
+
+	request := JsonBatchContext{
+		Subject: &JsonSubject{
+			TokenType: "WERCKER",
+			Token:     "testtoken",
+		},
+		Requests: []*JsonAccess{
+			{ServiceName: "fakservice", Resource: "res1", Action: "get"}, //fakeservice is a predefined service in fakestore.json
+			{ServiceName: "nosuchservice", Resource: "res1", Action: "get"},
+			{ServiceName: "fakservice", Resource: "res2", Action: "get", Attributes: []*JsonAttribute{{Name: "level", Type: "numeric", Value: 3}}},
+		},
+	}
+	batchURL := adsserver.URL + "/authz-check/v1/is-allowed-batch"
+	buf, err := json.Marshal(request)
+	if err != nil {
+		t.Fatal("failed to marshal test request")
+	}
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+	}
+	resp, err := client.Post(batchURL, "application/json", bytes.NewBuffer(buf))
+	if err != nil {
+		t.Fatal("failed get response")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, but got %d", resp.StatusCode)
+	}
+	if len(resp.Header.Get(svcs.PrincipalsHeader)) == 0 {
+		t.Fatal("No principal is returned!")
+	}
+	var decisions []IsAllowedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decisions); err != nil {
+		t.Fatal("failed to decode response:", err)
+	}
+	if len(decisions) != len(request.Requests) {
+		t.Fatalf("expected %d decisions, but got %v", len(request.Requests), decisions)
+	}
+	if decisions[0].Allowed || decisions[0].Reason != int32(adsapi.NO_APPLICABLE_POLICIES) || len(decisions[0].ErrorMessage) != 0 {
+		t.Fatal("unexpected decision:", decisions[0])
+	}
+	if decisions[1].Allowed || decisions[1].Reason != int32(adsapi.SERVICE_NOT_FOUND) || len(decisions[1].ErrorMessage) == 0 {
+		t.Fatal("unexpected decision:", decisions[1])
+	}
+	if decisions[2].Reason != int32(adsapi.NO_APPLICABLE_POLICIES) {
+		t.Fatal("unexpected decision:", decisions[2])
+	}
+
+	// an invalid attribute fails the whole batch
+	request.Requests[2].Attributes[0].Type = "bool"
+	buf, err = json.Marshal(request)
+	if err != nil {
+		t.Fatal("failed to marshal test request")
+	}
+	resp, err = client.Post(batchURL, "application/json", bytes.NewBuffer(buf))
+	if err != nil {
+		t.Fatal("failed get response")
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, but got %d", resp.StatusCode)
+	}
+}
+
+func TestWhoCanAccess(t *testing.T) {
+	assertserver := assertion.NewTestServer(t, nil)
+	defer assertserver.Close()
+
+	adsserver, err := newADSServerWithAsserter(assertserver.URL, t)
+	if err != nil {
+		t.Fatal("Failed to start ADS! Error:", err)
+	}
+	defer adsserver.Close()
+
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+	}
+	reviewURL := adsserver.URL + "/authz-check/v1/who-can-access"
+	for _, tc := range []struct {
+		request JsonWhoCanAccessRequest
+		status  int
+	}{
+		{JsonWhoCanAccessRequest{ServiceName: "fakservice", Resource: "res1", Action: "get"}, http.StatusOK},
+		{JsonWhoCanAccessRequest{Resource: "res1", Action: "get"}, http.StatusBadRequest},
+	} {
+		buf, err := json.Marshal(tc.request)
+		if err != nil {
+			t.Fatal("failed to marshal test request")
+		}
+		resp, err := client.Post(reviewURL, "application/json", bytes.NewBuffer(buf))
+		if err != nil {
+			t.Fatal("failed get response")
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Fatalf("request %v: expected status %d, but got %d", tc.request, tc.status, resp.StatusCode)
+		}
+		if resp.StatusCode != http.StatusOK {
+			continue
+		}
+		var review adsapi.AccessReview
+		if err := json.NewDecoder(resp.Body).Decode(&review); err != nil {
+			t.Fatal("failed to decode response:", err)
+		}
+		if review.ServiceName != tc.request.ServiceName || len(review.Granted) != 0 || len(review.Denied) != 0 {
+			t.Fatal("unexpected review:", review)
+		}
+	}
+}
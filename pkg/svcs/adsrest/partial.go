@@ -0,0 +1,91 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package adsrest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	adsapi "github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/httputils"
+	"github.com/teramoby/speedle-plus/pkg/logging"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type JsonPartialEvaluationRequest struct {
+	JsonContext
+	// Unknowns are the attributes whose values are unknown, e.g. the columns of the rows to filter
+	Unknowns []string `json:"unknowns"`
+	// SQLDialect is the dialect of the SQL filter, sqlite3, postgres or mysql
+	SQLDialect string `json:"sqlDialect,omitempty"`
+	// ResourcePattern is a glob pattern of the resources to filter instead of the resource, the resource is the
+	// unknown attribute request_resource of the residual then
+	ResourcePattern string `json:"resourcePattern,omitempty"`
+}
+
+type SQLFilter struct {
+	Where string        `json:"where"`
+	Args  []interface{} `json:"args"`
+}
+
+type PartialEvaluationResponse struct {
+	Residual     *adsapi.Residual       `json:"residual"`
+	GrantedRoles []string               `json:"grantedRoles,omitempty"`
+	SQL          SQLFilter              `json:"sql"`
+	MongoFilter  map[string]interface{} `json:"mongoFilter"`
+}
+
+// PartialEvaluate returns the residual condition on the unknown attributes under which the subject is allowed the
+// action, and its translations to a SQL WHERE clause and a MongoDB filter
+func (e *RESTService) PartialEvaluate(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	var jsonRequest JsonPartialEvaluationRequest
+	if err := decoder.Decode(&jsonRequest); err != nil {
+		httputils.HandleError(w, errors.Wrap(err, errors.InvalidRequest, "unable to decode request"))
+		return
+	}
+
+	context, err := ConvertJSONRequestToContext(&jsonRequest.JsonContext)
+	if err != nil {
+		httputils.HandleError(w, err)
+		return
+	}
+
+	if len(jsonRequest.ResourcePattern) != 0 {
+		if len(context.Resource) != 0 {
+			httputils.HandleError(w, errors.New(errors.InvalidRequest, "resource and resourcePattern can't be both set"))
+			return
+		}
+		context.Resource = jsonRequest.ResourcePattern
+		jsonRequest.Unknowns = append(jsonRequest.Unknowns, adsapi.BuiltIn_Attr_RequestResource)
+	}
+
+	result, err := e.Evaluator.PartialEvaluate(*context, jsonRequest.Unknowns)
+	if err != nil {
+		httputils.HandleError(w, err)
+		// Audit log
+		logging.WriteFailedAuditLog("PartialEvaluate", log.Fields{"requestContext": context, "unknowns": jsonRequest.Unknowns}, err.Error())
+		return
+	}
+
+	response := PartialEvaluationResponse{
+		Residual:     result.Residual,
+		GrantedRoles: result.GrantedRoles,
+	}
+	if response.SQL.Where, response.SQL.Args, err = result.Residual.ToSQL(jsonRequest.SQLDialect); err != nil {
+		httputils.HandleError(w, errors.Wrap(err, errors.InvalidRequest, "unable to translate the residual to SQL"))
+		return
+	}
+	if response.MongoFilter, err = result.Residual.ToMongo(); err != nil {
+		httputils.HandleError(w, errors.Wrap(err, errors.InvalidRequest, "unable to translate the residual to a MongoDB filter"))
+		return
+	}
+
+	// Audit log
+	logging.WriteSucceededAuditLog("PartialEvaluate", log.Fields{"requestContext": context, "unknowns": jsonRequest.Unknowns}, log.Fields{"residual": result.Residual})
+
+	httputils.SendOKResponse(w, &response)
+}
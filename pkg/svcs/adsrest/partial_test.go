@@ -0,0 +1,140 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package adsrest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/cfg"
+	"github.com/teramoby/speedle-plus/pkg/eval"
+	"github.com/teramoby/speedle-plus/pkg/store"
+	"github.com/teramoby/speedle-plus/pkg/svcs"
+)
+
+func TestPartialEvaluate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "adspartial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	conf := &cfg.Config{
+		StoreConfig: &cfg.StoreConfig{
+			StoreType:  cfg.StorageTypeFile,
+			StoreProps: map[string]interface{}{"FileLocation": filepath.Join(dir, "policies.json")},
+		},
+	}
+	ps, err := store.NewStore(conf.StoreConfig.StoreType, conf.StoreConfig.StoreProps)
+	if err != nil {
+		t.Fatal("fail to create store:", err)
+	}
+	err = ps.CreateService(&pms.Service{
+		Name: "service1",
+		Type: pms.TypeApplication,
+		Policies: []*pms.Policy{{
+			Name:        "policy1",
+			Effect:      "grant",
+			Principals:  [][]string{{"user:alice"}},
+			Permissions: []*pms.Permission{{Resource: "/books", Actions: []string{"get"}}},
+			Condition:   "owner == request_user",
+		}},
+	})
+	if err != nil {
+		t.Fatal("fail to create service:", err)
+	}
+
+	evaluator, err := eval.NewFromConfig(conf)
+	if err != nil {
+		t.Fatal("fail to create evaluator:", err)
+	}
+	router, err := NewRouter(evaluator)
+	if err != nil {
+		t.Fatal("fail to create router:", err)
+	}
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	request := JsonPartialEvaluationRequest{
+		JsonContext: JsonContext{
+			Subject:     &JsonSubject{Principals: []*JsonPrincipal{{Type: "user", Name: "alice"}}},
+			ServiceName: "service1",
+			Resource:    "/books",
+			Action:      "get",
+		},
+		Unknowns:   []string{"owner"},
+		SQLDialect: "postgres",
+	}
+	body, err := json.Marshal(&request)
+	if err != nil {
+		t.Fatal("fail to marshal request:", err)
+	}
+	resp, err := http.Post(server.URL+svcs.PolicyAtzPath+"partial-evaluate", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal("fail to send request:", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, but got %d", resp.StatusCode)
+	}
+	var response PartialEvaluationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatal("fail to decode response:", err)
+	}
+	if response.SQL.Where != `"owner" = $1` || len(response.SQL.Args) != 1 || response.SQL.Args[0] != "alice" {
+		t.Errorf("unexpected sql filter %+v", response.SQL)
+	}
+	filter, _ := json.Marshal(response.MongoFilter)
+	if string(filter) != `{"owner":{"$eq":"alice"}}` {
+		t.Errorf("unexpected mongo filter %s", filter)
+	}
+
+	// unknown dialects are bad requests
+	request.SQLDialect = "oracle"
+	body, _ = json.Marshal(&request)
+	resp, err = http.Post(server.URL+svcs.PolicyAtzPath+"partial-evaluate", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal("fail to send request:", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, but got %d", resp.StatusCode)
+	}
+
+	// a resource pattern makes the resource unknown
+	request.SQLDialect = "postgres"
+	request.ResourcePattern = "/books/**"
+	body, _ = json.Marshal(&request)
+	resp, err = http.Post(server.URL+svcs.PolicyAtzPath+"partial-evaluate", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal("fail to send request:", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 if both resource and resourcePattern are set, but got %d", resp.StatusCode)
+	}
+	request.Resource = ""
+	body, _ = json.Marshal(&request)
+	resp, err = http.Post(server.URL+svcs.PolicyAtzPath+"partial-evaluate", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal("fail to send request:", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, but got %d", resp.StatusCode)
+	}
+	response = PartialEvaluationResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatal("fail to decode response:", err)
+	}
+	if expected := `("request_resource" ~ $1) AND ("request_resource" = $2) AND ("owner" = $3)`; response.SQL.Where != expected {
+		t.Errorf("expected where clause %s, but got %s", expected, response.SQL.Where)
+	}
+}
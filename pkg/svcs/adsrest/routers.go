@@ -49,6 +49,13 @@ func initRouters(evaluator eval.InternalEvaluator) (*routes, error) {
 			restService.IsAllowed,
 		},
 
+		route{
+			"IsAllowedBatch",
+			"POST",
+			svcs.PolicyAtzPath + "is-allowed-batch",
+			restService.IsAllowedBatch,
+		},
+
 		route{
 			"Diagnose",
 			"POST",
@@ -56,12 +63,33 @@ func initRouters(evaluator eval.InternalEvaluator) (*routes, error) {
 			restService.Diagnose,
 		},
 
+		route{
+			"WhoCanAccess",
+			"POST",
+			svcs.PolicyAtzPath + "who-can-access",
+			restService.WhoCanAccess,
+		},
+
 		route{
 			"Discover",
 			"POST",
 			svcs.PolicyAtzPath + "discover",
 			restService.Discover,
 		},
+
+		route{
+			"PartialEvaluate",
+			"POST",
+			svcs.PolicyAtzPath + "partial-evaluate",
+			restService.PartialEvaluate,
+		},
+
+		route{
+			"DecisionCacheStats",
+			"GET",
+			svcs.PolicyAtzPath + "decision-cache-stats",
+			restService.DecisionCacheStats,
+		},
 	}, nil
 }
 
@@ -84,3 +112,17 @@ func NewRouter(evaluator eval.InternalEvaluator) (*mux.Router, error) {
 
 	return router, nil
 }
+
+// NewTenantRouter creates the routers of the evaluators of the tenants, and dispatches every request to the one of its
+// tenant, which is chosen by the Speedle-Tenant header or the path, e.g. /authz-check/v1/tenant/acme/is-allowed
+func NewTenantRouter(evaluators map[string]eval.InternalEvaluator) (http.Handler, error) {
+	handlers := make(map[string]http.Handler, len(evaluators))
+	for tenant, evaluator := range evaluators {
+		router, err := NewRouter(evaluator)
+		if err != nil {
+			return nil, err
+		}
+		handlers[tenant] = router
+	}
+	return svcs.TenantHandler(svcs.PolicyAtzPath, handlers), nil
+}
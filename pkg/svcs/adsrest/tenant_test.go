@@ -0,0 +1,106 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package adsrest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/cfg"
+	"github.com/teramoby/speedle-plus/pkg/eval"
+	"github.com/teramoby/speedle-plus/pkg/store"
+	"github.com/teramoby/speedle-plus/pkg/svcs"
+)
+
+func TestTenants(t *testing.T) {
+	dir, err := ioutil.TempDir("", "adstenants")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	conf := &cfg.Config{
+		StoreConfig: &cfg.StoreConfig{
+			StoreType:  cfg.StorageTypeFile,
+			StoreProps: map[string]interface{}{"FileLocation": filepath.Join(dir, "policies.json")},
+		},
+		Tenants: []string{"acme"},
+	}
+	ps, err := store.NewTenantStore(conf.StoreConfig.StoreType, conf.StoreConfig.StoreProps, "acme")
+	if err != nil {
+		t.Fatal("fail to create store of tenant:", err)
+	}
+	err = ps.CreateService(&pms.Service{
+		Name: "service1",
+		Type: pms.TypeApplication,
+		Policies: []*pms.Policy{{
+			Name:        "policy1",
+			Effect:      "grant",
+			Principals:  [][]string{{"user:alice"}},
+			Permissions: []*pms.Permission{{Resource: "/books", Actions: []string{"get"}}},
+		}},
+	})
+	if err != nil {
+		t.Fatal("fail to create service in tenant:", err)
+	}
+
+	evaluators, err := eval.NewTenantEvaluators(conf)
+	if err != nil {
+		t.Fatal("fail to create evaluators:", err)
+	}
+	router, err := NewTenantRouter(evaluators)
+	if err != nil {
+		t.Fatal("fail to create router:", err)
+	}
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	context := JsonContext{
+		Subject:     &JsonSubject{Principals: []*JsonPrincipal{{Type: "user", Name: "alice"}}},
+		ServiceName: "service1",
+		Resource:    "/books",
+		Action:      "get",
+	}
+	body, err := json.Marshal(&context)
+	if err != nil {
+		t.Fatal("fail to marshal request:", err)
+	}
+	tests := []struct {
+		path    string
+		tenant  string
+		code    int
+		allowed bool
+	}{
+		{"tenant/acme/is-allowed", "", http.StatusOK, true},
+		{"is-allowed", "acme", http.StatusOK, true},
+		{"is-allowed", "", http.StatusOK, false},
+		{"tenant/globex/is-allowed", "", http.StatusNotFound, false},
+	}
+	for _, test := range tests {
+		req, err := http.NewRequest("POST", server.URL+svcs.PolicyAtzPath+test.path, bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatal("fail to make test request:", err)
+		}
+		if len(test.tenant) != 0 {
+			req.Header.Set(svcs.TenantHeader, test.tenant)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal("fail to get response:", err)
+		}
+		var result IsAllowedResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if resp.StatusCode != test.code || result.Allowed != test.allowed {
+			t.Errorf("%s of tenant %q: expected status %d and allowed %v, but got %d and %v",
+				test.path, test.tenant, test.code, test.allowed, resp.StatusCode, result.Allowed)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package adsrest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/httputils"
+	"github.com/teramoby/speedle-plus/pkg/logging"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type JsonWhoCanAccessRequest struct {
+	ServiceName string `json:"serviceName"`
+	Resource    string `json:"resource"`
+	Action      string `json:"action"`
+}
+
+// WhoCanAccess returns the principals which could be granted, or denied, an action on a resource
+func (e *RESTService) WhoCanAccess(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	var jsonRequest JsonWhoCanAccessRequest
+	if err := decoder.Decode(&jsonRequest); err != nil {
+		httputils.HandleError(w, errors.Wrap(err, errors.InvalidRequest, "unable to decode request"))
+		return
+	}
+	if len(jsonRequest.ServiceName) == 0 {
+		httputils.HandleError(w, errors.New(errors.InvalidRequest, "service name is required"))
+		return
+	}
+
+	review, err := e.Evaluator.WhoCanAccess(jsonRequest.ServiceName, jsonRequest.Resource, jsonRequest.Action)
+	if err != nil {
+		httputils.HandleError(w, err)
+		// Audit log
+		logging.WriteFailedAuditLog("WhoCanAccess", log.Fields{"request": jsonRequest}, err.Error())
+		return
+	}
+
+	// Audit log
+	logging.WriteSucceededAuditLog("WhoCanAccess", log.Fields{"request": jsonRequest}, log.Fields{"review": review})
+
+	httputils.SendOKResponse(w, review)
+}
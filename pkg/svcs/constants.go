@@ -10,4 +10,11 @@ const (
 	PolicyAtzPath = "/authz-check/v1/"
 	// Header to store asserted pincipals
 	PrincipalsHeader = "Speedle-Principals"
+	// Header to return the token to continue a list with, if there are more entities than the page
+	ContinueTokenHeader = "Speedle-Continue-Token"
+	// Header to choose the tenant of a request, which is also the key of the metadata of gRPC calls in lower case
+	TenantHeader = "Speedle-Tenant"
+	// TenantPathSegment chooses the tenant by the path following the prefix of the rest services,
+	// e.g. /policy-mgmt/v1/tenant/acme/service
+	TenantPathSegment = "tenant/"
 )
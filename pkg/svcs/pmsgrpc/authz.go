@@ -0,0 +1,130 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package pmsgrpc
+
+import (
+	"context"
+	"crypto/x509"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/assertion"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/logging"
+	"github.com/teramoby/speedle-plus/pkg/svcs/pmsgrpc/pb"
+	"github.com/teramoby/speedle-plus/pkg/svcs/pmsimpl"
+)
+
+// UnaryAuthzInterceptor authenticates the callers of the policy management service by the token in metadata
+// or the client certificate, and authorizes every call against the admin policies, which is the case when enable-authz is set
+func UnaryAuthzInterceptor(authorizer *pmsimpl.Authorizer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		method := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+		subject, err := authorizer.Authenticate(metadataValue(ctx, assertion.TokenKey), metadataValue(ctx, assertion.IdpTypeKey), peerCertificates(ctx))
+		if err != nil {
+			logging.WriteSimpleFailedAuditLog("[gRPC]"+method, nil, err.Error())
+			return nil, toGRPCStatus(err)
+		}
+		if err := authorizeCall(authorizer, subject, method, req); err != nil {
+			logging.WriteSimpleFailedAuditLog("[gRPC]"+method, nil, err.Error())
+			return nil, toGRPCStatus(err)
+		}
+		return handler(ctx, req)
+	}
+}
+
+func metadataValue(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) != 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func peerCertificates(ctx context.Context) []*x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 {
+		return nil
+	}
+	return tlsInfo.State.VerifiedChains[0]
+}
+
+// writeAction returns the action of the calls creating or updating an entity
+func writeAction(method string) string {
+	if strings.HasPrefix(method, "Create") {
+		return pmsimpl.ActionCreate
+	}
+	return pmsimpl.ActionUpdate
+}
+
+// queryAction returns the action of the calls querying or deleting entities, id is empty if all entities are queried
+func queryAction(method, id string) string {
+	switch {
+	case strings.HasPrefix(method, "Delete"):
+		return pmsimpl.ActionDelete
+	case len(id) != 0:
+		return pmsimpl.ActionGet
+	default:
+		return pmsimpl.ActionList
+	}
+}
+
+// authorizeCall maps a call to its admin resource and action, and authorizes it
+func authorizeCall(authorizer *pmsimpl.Authorizer, subject *ads.Subject, method string, req interface{}) error {
+	var resource, action string
+	switch in := req.(type) {
+	case *pb.Function:
+		resource, action = pmsimpl.FunctionResource(in.Name), writeAction(method)
+	case *pb.FunctionQueryRequest:
+		resource, action = pmsimpl.FunctionResource(in.Name), queryAction(method, in.Name)
+	case *pb.ServiceRequest:
+		resource, action = pmsimpl.ServiceResource(in.Name), writeAction(method)
+	case *pb.ServiceQueryRequest:
+		resource, action = pmsimpl.ServiceResource(in.Name), queryAction(method, in.Name)
+	case *pb.PolicyRequest:
+		resource, action = pmsimpl.PolicyResource(in.ServiceName), writeAction(method)
+	case *pb.PolicyQueryRequest:
+		resource, action = pmsimpl.PolicyResource(in.ServiceName), queryAction(method, in.PolicyID)
+	case *pb.RolePolicyRequest:
+		resource, action = pmsimpl.RolePolicyResource(in.ServiceName), writeAction(method)
+	case *pb.RolePolicyQueryRequest:
+		resource, action = pmsimpl.RolePolicyResource(in.ServiceName), queryAction(method, in.RolePolicyID)
+	case *pb.Empty:
+		resource, action = pmsimpl.ServiceResource(""), pmsimpl.ActionList
+	case *pb.TransactionRequest:
+		var ops []*pms.Operation
+		for _, op := range in.Operations {
+			if op != nil {
+				ops = append(ops, convertRPCOperation(op))
+			}
+		}
+		return authorizer.AuthorizeOperations(subject, ops)
+	case *pb.HistoryRequest:
+		resource, action = pmsimpl.EntityResource(in.Kind, in.ServiceName, in.Id), pmsimpl.ActionGet
+	case *pb.RollbackRequest:
+		resource, action = pmsimpl.EntityResource(in.Kind, in.ServiceName, in.Id), pmsimpl.ActionRollback
+	case *pb.DiscoverRequestsRequest:
+		resource, action = pmsimpl.DiscoverResource(in.ServiceName), pmsimpl.ActionGet
+	case *pb.DiscoverPoliciesRequest:
+		resource, action = pmsimpl.DiscoverResource(in.ServiceName), pmsimpl.ActionGet
+	case *pb.ResetRequestsRequest:
+		resource, action = pmsimpl.DiscoverResource(in.ServiceName), pmsimpl.ActionDelete
+	default:
+		return errors.Errorf(errors.PermissionDenied, "unknown call %s", method)
+	}
+	return authorizer.Authorize(subject, resource, action)
+}
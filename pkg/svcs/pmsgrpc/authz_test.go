@@ -0,0 +1,85 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package pmsgrpc
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/teramoby/speedle-plus/pkg/assertion"
+	"github.com/teramoby/speedle-plus/pkg/cfg"
+	"github.com/teramoby/speedle-plus/pkg/store"
+	_ "github.com/teramoby/speedle-plus/pkg/store/file"
+	"github.com/teramoby/speedle-plus/pkg/svcs/pmsgrpc/pb"
+	"github.com/teramoby/speedle-plus/pkg/svcs/pmsimpl"
+)
+
+func TestUnaryAuthzInterceptor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pmsauthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	storeFile := filepath.Join(dir, "store.json")
+	err = ioutil.WriteFile(storeFile, []byte(`{"services": [{"name": "speedle-admin", "policies": [
+		{"id": "p1", "effect": "grant", "principals": [["user:testUser"]],
+		 "permissions": [{"resourceExpression": "/service/s1(/.*)?", "actions": ["create", "get", "list", "update", "delete"]}]}
+	]}]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps, err := store.NewStore(cfg.StorageTypeFile, map[string]interface{}{"FileLocation": storeFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	asserterServer := assertion.NewTestServer(t, nil)
+	defer asserterServer.Close()
+	asserter, err := assertion.NewAsserter(&assertion.AsserterConfig{Endpoint: asserterServer.URL + "/assert"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorizer, err := pmsimpl.NewAuthorizer(&cfg.Config{}, ps, asserter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	interceptor := UnaryAuthzInterceptor(authorizer)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &pb.Empty{}, nil
+	}
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		req    interface{}
+		code   codes.Code
+	}{
+		{"no token", "CreatePolicy", "", &pb.PolicyRequest{ServiceName: "s1"}, codes.Unauthenticated},
+		{"delegated service", "CreatePolicy", "token", &pb.PolicyRequest{ServiceName: "s1"}, codes.OK},
+		{"other service", "CreatePolicy", "token", &pb.PolicyRequest{ServiceName: "s2"}, codes.PermissionDenied},
+		{"list services", "QueryServices", "token", &pb.ServiceQueryRequest{}, codes.PermissionDenied},
+		{"get delegated service", "QueryServices", "token", &pb.ServiceQueryRequest{Name: "s1"}, codes.OK},
+		{"transaction", "ExecuteTransaction", "token", &pb.TransactionRequest{Operations: []*pb.Operation{
+			{Op: "delete", Kind: "policy", ServiceName: "s2", Id: "p1"},
+		}}, codes.PermissionDenied},
+	}
+	for _, test := range tests {
+		ctx := context.Background()
+		if len(test.token) != 0 {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(assertion.TokenKey, test.token))
+		}
+		_, err := interceptor(ctx, test.req, &grpc.UnaryServerInfo{FullMethod: "/pb.PolicyManager/" + test.method}, handler)
+		if status.Code(err) != test.code {
+			t.Errorf("%s: expected code %v, but got %v", test.name, test.code, err)
+		}
+	}
+}
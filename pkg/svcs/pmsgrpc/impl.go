@@ -19,8 +19,6 @@ import (
 	"github.com/teramoby/speedle-plus/api/ads"
 	"github.com/teramoby/speedle-plus/api/pms"
 
-	"strings"
-
 	"github.com/teramoby/speedle-plus/pkg/logging"
 )
 
@@ -44,6 +42,7 @@ func convertRPCFunction(rpcFunction *pb.Function) *pms.Function {
 		CA:             rpcFunction.Ca,
 		ResultCachable: rpcFunction.ResultCachable,
 		ResultTTL:      rpcFunction.ResultTTL,
+		Revision:       rpcFunction.Revision,
 	}
 }
 
@@ -56,13 +55,18 @@ func convertMetaFunction(function *pms.Function) *pb.Function {
 		Ca:             function.CA,
 		ResultCachable: function.ResultCachable,
 		ResultTTL:      function.ResultTTL,
+		Revision:       function.Revision,
 	}
 	return &ret
 }
 
 func convertRPCServiceRequest(rpcService *pb.ServiceRequest) *pms.Service {
 	ret := pms.Service{
-		Name: rpcService.Name,
+		Name:               rpcService.Name,
+		CombiningAlgorithm: rpcService.CombiningAlgorithm,
+		DefaultEffect:      rpcService.DefaultEffect,
+		Attributes:         convertRPCAttributes(rpcService.Attributes),
+		Revision:           rpcService.Revision,
 	}
 	switch rpcService.Type {
 	case pb.ServiceType_APPLICATION:
@@ -76,6 +80,54 @@ func convertRPCServiceRequest(rpcService *pb.ServiceRequest) *pms.Service {
 	return &ret
 }
 
+func convertRPCAttributes(rpcAttributes []*pb.Attribute) []*pms.Attribute {
+	var ret []*pms.Attribute
+	for _, attribute := range rpcAttributes {
+		ret = append(ret, &pms.Attribute{Name: attribute.Name, Type: attribute.Type, List: attribute.List})
+	}
+	return ret
+}
+
+func convertRPCService(rpcService *pb.Service) *pms.Service {
+	ret := convertRPCServiceRequest(&pb.ServiceRequest{
+		Name:               rpcService.Name,
+		Type:               rpcService.Type,
+		CombiningAlgorithm: rpcService.CombiningAlgorithm,
+		DefaultEffect:      rpcService.DefaultEffect,
+		Attributes:         rpcService.Attributes,
+		Revision:           rpcService.Revision,
+	})
+	for _, policy := range rpcService.Policies {
+		ret.Policies = append(ret.Policies, convertRPCPolicy(policy))
+	}
+	for _, rolePolicy := range rpcService.RolePolicies {
+		ret.RolePolicies = append(ret.RolePolicies, convertRPCRolePolicy(rolePolicy))
+	}
+	return ret
+}
+
+func convertRPCOperation(rpcOperation *pb.Operation) *pms.Operation {
+	ret := pms.Operation{
+		Op:          rpcOperation.Op,
+		Kind:        rpcOperation.Kind,
+		ServiceName: rpcOperation.ServiceName,
+		ID:          rpcOperation.Id,
+	}
+	if rpcOperation.Service != nil {
+		ret.Service = convertRPCService(rpcOperation.Service)
+	}
+	if rpcOperation.Policy != nil {
+		ret.Policy = convertRPCPolicy(rpcOperation.Policy)
+	}
+	if rpcOperation.RolePolicy != nil {
+		ret.RolePolicy = convertRPCRolePolicy(rpcOperation.RolePolicy)
+	}
+	if rpcOperation.Function != nil {
+		ret.Function = convertRPCFunction(rpcOperation.Function)
+	}
+	return &ret
+}
+
 func convertRPCPrincipals(principals []*pb.AndPrincipals) [][]string {
 	ret := [][]string{}
 	for _, andPrincipals := range principals {
@@ -92,7 +144,9 @@ func convertRPCRolePolicy(rpcPolicy *pb.RolePolicy) *pms.RolePolicy {
 		Roles:               rpcPolicy.Roles,
 		Resources:           rpcPolicy.Resources,
 		ResourceExpressions: rpcPolicy.ResourceExpressions,
+		ResourceGlobs:       rpcPolicy.ResourceGlobs,
 		Condition:           rpcPolicy.Condition,
+		Revision:            rpcPolicy.Revision,
 	}
 	switch rpcPolicy.Effect {
 	case pb.Effect_GRANT:
@@ -110,6 +164,8 @@ func convertRPCPolicy(rpcPolicy *pb.Policy) *pms.Policy {
 		ID:        rpcPolicy.Id,
 		Name:      rpcPolicy.Name,
 		Condition: rpcPolicy.Condition,
+		Priority:  int(rpcPolicy.Priority),
+		Revision:  rpcPolicy.Revision,
 	}
 	ret.Principals = convertRPCPrincipals(rpcPolicy.Principals)
 	switch rpcPolicy.Effect {
@@ -135,13 +191,26 @@ func convertRPCPermission(perm *pb.Policy_Permission) *pms.Permission {
 		Actions:            perm.Actions,
 		Resource:           perm.GetResource(),
 		ResourceExpression: perm.GetResourceExpression(),
+		ResourceGlob:       perm.GetResourceGlob(),
 	}
 	return &ret
 }
 
+func convertMetaAttributes(attributes []*pms.Attribute) []*pb.Attribute {
+	var ret []*pb.Attribute
+	for _, attribute := range attributes {
+		ret = append(ret, &pb.Attribute{Name: attribute.Name, Type: attribute.Type, List: attribute.List})
+	}
+	return ret
+}
+
 func convertMetaService(service *pms.Service) *pb.Service {
 	ret := pb.Service{
-		Name: service.Name,
+		Name:               service.Name,
+		CombiningAlgorithm: service.CombiningAlgorithm,
+		DefaultEffect:      service.DefaultEffect,
+		Attributes:         convertMetaAttributes(service.Attributes),
+		Revision:           service.Revision,
 	}
 	switch service.Type {
 	case pms.TypeApplication:
@@ -183,7 +252,9 @@ func convertMetaRolePolicy(policy *pms.RolePolicy) *pb.RolePolicy {
 		Roles:               policy.Roles,
 		Resources:           policy.Resources,
 		ResourceExpressions: policy.ResourceExpressions,
+		ResourceGlobs:       policy.ResourceGlobs,
 		Condition:           policy.Condition,
+		Revision:            policy.Revision,
 	}
 	switch policy.Effect {
 	case pms.Grant:
@@ -201,6 +272,8 @@ func convertMetaPolicy(policy *pms.Policy) *pb.Policy {
 		Id:        policy.ID,
 		Name:      policy.Name,
 		Condition: policy.Condition,
+		Priority:  int32(policy.Priority),
+		Revision:  policy.Revision,
 	}
 	ret.Principals = convertMetaPrincipals(policy.Principals)
 	switch policy.Effect {
@@ -226,11 +299,59 @@ func convertMetaPermission(perm *pms.Permission) *pb.Policy_Permission {
 	ret := pb.Policy_Permission{
 		Resource:           perm.Resource,
 		ResourceExpression: perm.ResourceExpression,
+		ResourceGlob:       perm.ResourceGlob,
 		Actions:            perm.Actions,
 	}
 	return &ret
 }
 
+func convertMetaOperation(op *pms.Operation) *pb.Operation {
+	ret := pb.Operation{
+		Op:          op.Op,
+		Kind:        op.Kind,
+		ServiceName: op.ServiceName,
+		Id:          op.ID,
+	}
+	if op.Service != nil {
+		ret.Service = convertMetaService(op.Service)
+	}
+	if op.Policy != nil {
+		ret.Policy = convertMetaPolicy(op.Policy)
+	}
+	if op.RolePolicy != nil {
+		ret.RolePolicy = convertMetaRolePolicy(op.RolePolicy)
+	}
+	if op.Function != nil {
+		ret.Function = convertMetaFunction(op.Function)
+	}
+	return &ret
+}
+
+func convertMetaHistoryRecord(record *pms.HistoryRecord) *pb.HistoryRecord {
+	ret := pb.HistoryRecord{
+		Kind:        record.Kind,
+		ServiceName: record.ServiceName,
+		Id:          record.ID,
+		Version:     record.Version,
+		Op:          record.Op,
+		ChangedBy:   record.ChangedBy,
+		ChangedAt:   record.ChangedAt,
+	}
+	if record.Service != nil {
+		ret.Service = convertMetaService(record.Service)
+	}
+	if record.Policy != nil {
+		ret.Policy = convertMetaPolicy(record.Policy)
+	}
+	if record.RolePolicy != nil {
+		ret.RolePolicy = convertMetaRolePolicy(record.RolePolicy)
+	}
+	if record.Function != nil {
+		ret.Function = convertMetaFunction(record.Function)
+	}
+	return &ret
+}
+
 func toGRPCStatus(err error) error {
 	if err == nil {
 		return nil
@@ -249,6 +370,12 @@ func toGRPCStatus(err error) error {
 		return status.Error(codes.ResourceExhausted, msg)
 	case errors.InvalidRequest:
 		return status.Error(codes.InvalidArgument, msg)
+	case errors.RevisionConflict:
+		return status.Error(codes.Aborted, msg)
+	case errors.Unauthenticated:
+		return status.Error(codes.Unauthenticated, msg)
+	case errors.PermissionDenied:
+		return status.Error(codes.PermissionDenied, msg)
 	default:
 		return status.Error(codes.Unknown, msg)
 	}
@@ -256,7 +383,7 @@ func toGRPCStatus(err error) error {
 
 func (impl *serviceImpl) CreateFunction(ctx context.Context, in *pb.Function) (*pb.Function, error) {
 	function := convertRPCFunction(in)
-	if function, err := impl.policyStore.CreateFunction(function); err != nil {
+	if function, err := impl.store(ctx).CreateFunction(function); err != nil {
 		// Audit log
 		logging.WriteSimpleFailedAuditLog("[gRPC]CreateFunction", function, err.Error())
 		return nil, toGRPCStatus(err)
@@ -268,6 +395,27 @@ func (impl *serviceImpl) CreateFunction(ctx context.Context, in *pb.Function) (*
 	return convertMetaFunction(function), nil
 }
 
+func (impl *serviceImpl) UpdateFunction(ctx context.Context, in *pb.Function) (*pb.Function, error) {
+	function := convertRPCFunction(in)
+	existing, err := impl.store(ctx).GetFunction(function.Name)
+	if err != nil {
+		// Audit log
+		logging.WriteSimpleFailedAuditLog("[gRPC]UpdateFunction", function, err.Error())
+		return nil, toGRPCStatus(err)
+	}
+	function.Metadata = existing.Metadata
+	if _, err := impl.store(ctx).UpdateFunction(function); err != nil {
+		// Audit log
+		logging.WriteSimpleFailedAuditLog("[gRPC]UpdateFunction", function, err.Error())
+		return nil, toGRPCStatus(err)
+	}
+
+	// Audit log
+	logging.WriteSimpleSucceededAuditLog("[gRPC]UpdateFunction", function, nil)
+
+	return convertMetaFunction(function), nil
+}
+
 func (impl *serviceImpl) QueryFunctions(ctx context.Context, in *pb.FunctionQueryRequest) (*pb.FunctionQueryResponse, error) {
 	var functions = []*pms.Function{}
 	// Audit contextual fields for request
@@ -276,25 +424,15 @@ func (impl *serviceImpl) QueryFunctions(ctx context.Context, in *pb.FunctionQuer
 		"filters": in.Filters,
 	}
 	if len(in.Name) == 0 {
-		if len(in.Filters) != 0 && strings.HasPrefix(in.Filters, "name") { //Query by name
-			functionsMatched, err := impl.policyStore.ListAllFunctions(in.Filters)
-			if err != nil {
-				// Audit log
-				logging.WriteFailedAuditLog("[gRPC]QueryFunctions", ctxFields, err.Error())
-				return nil, toGRPCStatus(err)
-			}
-			functions = functionsMatched
-		} else { // Query all functions
-			functionsMatched, err := impl.policyStore.ListAllFunctions("")
-			if err != nil {
-				// Audit log
-				logging.WriteFailedAuditLog("[gRPC]QueryFunctions", ctxFields, err.Error())
-				return nil, toGRPCStatus(err)
-			}
-			functions = functionsMatched
+		functionsMatched, err := impl.store(ctx).ListAllFunctions(in.Filters)
+		if err != nil {
+			// Audit log
+			logging.WriteFailedAuditLog("[gRPC]QueryFunctions", ctxFields, err.Error())
+			return nil, toGRPCStatus(err)
 		}
+		functions = functionsMatched
 	} else {
-		function, err := impl.policyStore.GetFunction(in.Name)
+		function, err := impl.store(ctx).GetFunction(in.Name)
 		if err != nil {
 			// Audit log
 			logging.WriteFailedAuditLog("[gRPC]QueryFunctions", ctxFields, err.Error())
@@ -333,13 +471,13 @@ func (impl *serviceImpl) DeleteFunctions(ctx context.Context, in *pb.FunctionQue
 
 	//TODO: revisit the query related APIs, currently filter does not work for delete API.
 	if len(in.Name) == 0 {
-		if err := impl.policyStore.DeleteFunctions(); err != nil {
+		if err := impl.store(ctx).DeleteFunctions(); err != nil {
 			// Audit log
 			logging.WriteFailedAuditLog("[gRPC]DeleteFunctions", ctxFields, err.Error())
 			return nil, toGRPCStatus(err)
 		}
 	} else {
-		if err := impl.policyStore.DeleteFunction(in.Name); err != nil {
+		if err := impl.store(ctx).DeleteFunction(in.Name); err != nil {
 			// Audit log
 			logging.WriteFailedAuditLog("[gRPC]DeleteFunctions", ctxFields, err.Error())
 			return nil, toGRPCStatus(err)
@@ -355,14 +493,14 @@ func (impl *serviceImpl) DeleteFunctions(ctx context.Context, in *pb.FunctionQue
 func (impl *serviceImpl) CreateService(ctx context.Context, in *pb.ServiceRequest) (*pb.Service, error) {
 	service := convertRPCServiceRequest(in)
 
-	err := pmsimpl.CheckService(service, impl.policyStore)
+	err := pmsimpl.CheckService(service, impl.store(ctx))
 	if err != nil {
 		// Audit log
 		logging.WriteSimpleFailedAuditLog("[gRPC]CreateService", &service, err.Error())
 		return nil, toGRPCStatus(err)
 	}
 
-	if err := impl.policyStore.CreateService(service); err != nil {
+	if err := impl.store(ctx).CreateService(service); err != nil {
 		// Audit log
 		logging.WriteSimpleFailedAuditLog("[gRPC]CreateService", service, err.Error())
 		return nil, toGRPCStatus(err)
@@ -374,18 +512,56 @@ func (impl *serviceImpl) CreateService(ctx context.Context, in *pb.ServiceReques
 	return convertMetaService(service), nil
 }
 
+func (impl *serviceImpl) UpdateService(ctx context.Context, in *pb.ServiceRequest) (*pb.Service, error) {
+	if len(in.Name) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "service name is not passed")
+	}
+	service := convertRPCServiceRequest(in)
+	if err := pmsimpl.CheckServiceUpdate(service, impl.store(ctx)); err != nil {
+		// Audit log
+		logging.WriteSimpleFailedAuditLog("[gRPC]UpdateService", service, err.Error())
+		return nil, toGRPCStatus(err)
+	}
+
+	existing, err := impl.store(ctx).GetService(service.Name)
+	if err != nil {
+		// Audit log
+		logging.WriteSimpleFailedAuditLog("[gRPC]UpdateService", service, err.Error())
+		return nil, toGRPCStatus(err)
+	}
+	service.Metadata = existing.Metadata
+
+	if err := impl.store(ctx).UpdateService(service); err != nil {
+		// Audit log
+		logging.WriteSimpleFailedAuditLog("[gRPC]UpdateService", service, err.Error())
+		return nil, toGRPCStatus(err)
+	}
+
+	retService, err := impl.store(ctx).GetService(service.Name)
+	if err != nil {
+		// Audit log
+		logging.WriteSimpleFailedAuditLog("[gRPC]UpdateService", service, err.Error())
+		return nil, toGRPCStatus(err)
+	}
+
+	// Audit log
+	logging.WriteSimpleSucceededAuditLog("[gRPC]UpdateService", service, nil)
+
+	return convertMetaService(retService), nil
+}
+
 func (impl *serviceImpl) QueryServices(ctx context.Context, in *pb.ServiceQueryRequest) (*pb.ServiceQueryResponse, error) {
 	var ss []*pms.Service
 	if len(in.Name) == 0 {
 		// Get all services
 		var err error
-		if ss, err = impl.policyStore.ListAllServices(); err != nil {
+		if ss, err = impl.store(ctx).ListAllServices(); err != nil {
 			// Audit log
 			logging.WriteSimpleFailedAuditLog("[gRPC]QueryServices", in.Name, err.Error())
 			return nil, toGRPCStatus(err)
 		}
 	} else {
-		svc, err := impl.policyStore.GetService(in.Name)
+		svc, err := impl.store(ctx).GetService(in.Name)
 		if err != nil {
 			// Audit log
 			logging.WriteSimpleFailedAuditLog("[gRPC]QueryServices", in.Name, err.Error())
@@ -409,7 +585,7 @@ func (impl *serviceImpl) QueryServices(ctx context.Context, in *pb.ServiceQueryR
 
 func (impl *serviceImpl) DeleteServices(ctx context.Context, in *pb.ServiceQueryRequest) (*pb.Empty, error) {
 	if len(in.Name) == 0 {
-		if err := impl.policyStore.DeleteServices(); err != nil {
+		if err := impl.store(ctx).DeleteServices(); err != nil {
 			// Audit log
 			logging.WriteSimpleFailedAuditLog("[gRPC]DeleteServices", in.Name, err.Error())
 			return nil, toGRPCStatus(err)
@@ -420,7 +596,7 @@ func (impl *serviceImpl) DeleteServices(ctx context.Context, in *pb.ServiceQuery
 		return &pb.Empty{}, nil
 	}
 
-	if err := impl.policyStore.DeleteService(in.Name); err != nil {
+	if err := impl.store(ctx).DeleteService(in.Name); err != nil {
 		// Audit log
 		logging.WriteSimpleFailedAuditLog("[gRPC]DeleteServices", in.Name, err.Error())
 		return nil, toGRPCStatus(err)
@@ -447,13 +623,13 @@ func (impl *serviceImpl) CreatePolicy(ctx context.Context, in *pb.PolicyRequest)
 
 	metaPolicy := convertRPCPolicy(in.Policy)
 
-	if err := pmsimpl.CheckPolicy(in.ServiceName, metaPolicy, impl.policyStore); err != nil {
+	if err := pmsimpl.CheckPolicy(in.ServiceName, metaPolicy, impl.store(ctx)); err != nil {
 		// Audit log
 		logging.WriteSimpleFailedAuditLog("[gRPC]CreatePolicy", ctxFields, err.Error())
 		return nil, toGRPCStatus(err)
 	}
 
-	retPolicy, err := impl.policyStore.CreatePolicy(in.ServiceName, metaPolicy)
+	retPolicy, err := impl.store(ctx).CreatePolicy(in.ServiceName, metaPolicy)
 	if err != nil {
 		// Audit log
 		logging.WriteFailedAuditLog("[gRPC]CreatePolicy", ctxFields, err.Error())
@@ -466,6 +642,49 @@ func (impl *serviceImpl) CreatePolicy(ctx context.Context, in *pb.PolicyRequest)
 	return convertMetaPolicy(retPolicy), nil
 }
 
+func (impl *serviceImpl) UpdatePolicy(ctx context.Context, in *pb.PolicyRequest) (*pb.Policy, error) {
+	if len(in.ServiceName) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "service name is not passed")
+	}
+	if in.Policy == nil || len(in.Policy.Id) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "policy or policy ID is not passed")
+	}
+
+	// Audit contextual fields for request
+	ctxFields := map[string]interface{}{
+		"serviceName": in.ServiceName,
+		"policy":      in.Policy,
+	}
+
+	metaPolicy := convertRPCPolicy(in.Policy)
+
+	if err := pmsimpl.CheckPolicyUpdate(in.ServiceName, metaPolicy, impl.store(ctx)); err != nil {
+		// Audit log
+		logging.WriteSimpleFailedAuditLog("[gRPC]UpdatePolicy", ctxFields, err.Error())
+		return nil, toGRPCStatus(err)
+	}
+
+	existing, err := impl.store(ctx).GetPolicy(in.ServiceName, metaPolicy.ID)
+	if err != nil {
+		// Audit log
+		logging.WriteFailedAuditLog("[gRPC]UpdatePolicy", ctxFields, err.Error())
+		return nil, toGRPCStatus(err)
+	}
+	metaPolicy.Metadata = existing.Metadata
+
+	retPolicy, err := impl.store(ctx).UpdatePolicy(in.ServiceName, metaPolicy)
+	if err != nil {
+		// Audit log
+		logging.WriteFailedAuditLog("[gRPC]UpdatePolicy", ctxFields, err.Error())
+		return nil, toGRPCStatus(err)
+	}
+
+	// Audit log
+	logging.WriteSucceededAuditLog("[gRPC]UpdatePolicy", ctxFields, nil)
+
+	return convertMetaPolicy(retPolicy), nil
+}
+
 func (impl *serviceImpl) QueryPolicies(ctx context.Context, in *pb.PolicyQueryRequest) (*pb.PolicyQueryResponse, error) {
 	if len(in.ServiceName) == 0 {
 		return nil, status.Error(codes.InvalidArgument, "service name is not passed")
@@ -478,9 +697,20 @@ func (impl *serviceImpl) QueryPolicies(ctx context.Context, in *pb.PolicyQueryRe
 	}
 
 	var policies = []*pms.Policy{}
+	var continueToken string
 	if len(in.PolicyID) == 0 {
-		if len(in.Filters) != 0 && strings.HasPrefix(in.Filters, "name") { //Query by name
-			policiesMatched, err := impl.policyStore.ListAllPolicies(in.ServiceName, in.Filters)
+		if in.Limit != 0 || len(in.ContinueToken) != 0 { //Query a page
+			policiesMatched, token, err := impl.store(ctx).ListPolicies(in.ServiceName,
+				&pms.ListOptions{Filter: in.Filters, Limit: int(in.Limit), Continue: in.ContinueToken})
+			if err != nil {
+				// Audit log
+				logging.WriteFailedAuditLog("[gRPC]QueryPolicies", ctxFields, err.Error())
+				return nil, toGRPCStatus(err)
+			}
+			policies = policiesMatched
+			continueToken = token
+		} else if len(in.Filters) != 0 { //Query by filter
+			policiesMatched, err := impl.store(ctx).ListAllPolicies(in.ServiceName, in.Filters)
 			if err != nil {
 				// Audit log
 				logging.WriteFailedAuditLog("[gRPC]QueryPolicies", ctxFields, err.Error())
@@ -488,7 +718,7 @@ func (impl *serviceImpl) QueryPolicies(ctx context.Context, in *pb.PolicyQueryRe
 			}
 			policies = policiesMatched
 		} else { // Query all policies
-			service, err := impl.policyStore.GetService(in.ServiceName)
+			service, err := impl.store(ctx).GetService(in.ServiceName)
 			if err != nil {
 				// Audit log
 				logging.WriteFailedAuditLog("[gRPC]QueryPolicies", ctxFields, err.Error())
@@ -497,7 +727,7 @@ func (impl *serviceImpl) QueryPolicies(ctx context.Context, in *pb.PolicyQueryRe
 			policies = service.Policies
 		}
 	} else {
-		policy, err := impl.policyStore.GetPolicy(in.ServiceName, in.PolicyID)
+		policy, err := impl.store(ctx).GetPolicy(in.ServiceName, in.PolicyID)
 		if err != nil {
 			// Audit log
 			logging.WriteFailedAuditLog("[gRPC]QueryPolicies", ctxFields, err.Error())
@@ -507,7 +737,8 @@ func (impl *serviceImpl) QueryPolicies(ctx context.Context, in *pb.PolicyQueryRe
 	}
 
 	retPolicies := pb.PolicyQueryResponse{
-		Policies: make([]*pb.Policy, 0),
+		ContinueToken: continueToken,
+		Policies:      make([]*pb.Policy, 0),
 	}
 	for _, policy := range policies {
 		retPolicies.Policies = append(retPolicies.Policies, convertMetaPolicy(policy))
@@ -535,13 +766,13 @@ func (impl *serviceImpl) DeletePolicies(ctx context.Context, in *pb.PolicyQueryR
 	}
 
 	if len(in.PolicyID) == 0 {
-		if err := impl.policyStore.DeletePolicies(in.ServiceName); err != nil {
+		if err := impl.store(ctx).DeletePolicies(in.ServiceName); err != nil {
 			// Audit log
 			logging.WriteFailedAuditLog("[gRPC]DeletePolicies", ctxFields, err.Error())
 			return nil, toGRPCStatus(err)
 		}
 	} else {
-		if err := impl.policyStore.DeletePolicy(in.ServiceName, in.PolicyID); err != nil {
+		if err := impl.store(ctx).DeletePolicy(in.ServiceName, in.PolicyID); err != nil {
 			// Audit log
 			logging.WriteFailedAuditLog("[gRPC]DeletePolicies", ctxFields, err.Error())
 			return nil, toGRPCStatus(err)
@@ -570,13 +801,13 @@ func (impl *serviceImpl) CreateRolePolicy(ctx context.Context, in *pb.RolePolicy
 
 	metaRolePolicy := convertRPCRolePolicy(in.RolePolicy)
 
-	if err := pmsimpl.CheckRolePolicy(in.ServiceName, metaRolePolicy, impl.policyStore); err != nil {
+	if err := pmsimpl.CheckRolePolicy(in.ServiceName, metaRolePolicy, impl.store(ctx)); err != nil {
 		// Audit log
 		logging.WriteSimpleFailedAuditLog("[gRPC]CreateRolePolicy", ctxFields, err.Error())
 		return nil, toGRPCStatus(err)
 	}
 
-	retPolicy, err := impl.policyStore.CreateRolePolicy(in.ServiceName, metaRolePolicy)
+	retPolicy, err := impl.store(ctx).CreateRolePolicy(in.ServiceName, metaRolePolicy)
 	if err != nil {
 		// Audit log
 		logging.WriteFailedAuditLog("[gRPC]CreateRolePolicy", ctxFields, err.Error())
@@ -589,6 +820,49 @@ func (impl *serviceImpl) CreateRolePolicy(ctx context.Context, in *pb.RolePolicy
 	return convertMetaRolePolicy(retPolicy), nil
 }
 
+func (impl *serviceImpl) UpdateRolePolicy(ctx context.Context, in *pb.RolePolicyRequest) (*pb.RolePolicy, error) {
+	if len(in.ServiceName) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "service name is not passed")
+	}
+	if in.RolePolicy == nil || len(in.RolePolicy.Id) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "role policy or role policy ID is not passed")
+	}
+
+	// Audit contextual fields for request
+	ctxFields := map[string]interface{}{
+		"serviceName": in.ServiceName,
+		"rolePolicy":  in.RolePolicy,
+	}
+
+	metaRolePolicy := convertRPCRolePolicy(in.RolePolicy)
+
+	if err := pmsimpl.CheckRolePolicyUpdate(in.ServiceName, metaRolePolicy, impl.store(ctx)); err != nil {
+		// Audit log
+		logging.WriteSimpleFailedAuditLog("[gRPC]UpdateRolePolicy", ctxFields, err.Error())
+		return nil, toGRPCStatus(err)
+	}
+
+	existing, err := impl.store(ctx).GetRolePolicy(in.ServiceName, metaRolePolicy.ID)
+	if err != nil {
+		// Audit log
+		logging.WriteFailedAuditLog("[gRPC]UpdateRolePolicy", ctxFields, err.Error())
+		return nil, toGRPCStatus(err)
+	}
+	metaRolePolicy.Metadata = existing.Metadata
+
+	retPolicy, err := impl.store(ctx).UpdateRolePolicy(in.ServiceName, metaRolePolicy)
+	if err != nil {
+		// Audit log
+		logging.WriteFailedAuditLog("[gRPC]UpdateRolePolicy", ctxFields, err.Error())
+		return nil, toGRPCStatus(err)
+	}
+
+	// Audit log
+	logging.WriteSucceededAuditLog("[gRPC]UpdateRolePolicy", ctxFields, nil)
+
+	return convertMetaRolePolicy(retPolicy), nil
+}
+
 func (impl *serviceImpl) QueryRolePolicies(ctx context.Context, in *pb.RolePolicyQueryRequest) (*pb.RolePolicyQueryResponse, error) {
 	if len(in.ServiceName) == 0 {
 		return nil, status.Error(codes.InvalidArgument, "service name is not passed.")
@@ -601,9 +875,20 @@ func (impl *serviceImpl) QueryRolePolicies(ctx context.Context, in *pb.RolePolic
 	}
 
 	var policies = []*pms.RolePolicy{}
+	var continueToken string
 	if len(in.RolePolicyID) == 0 {
-		if len(in.Filters) != 0 && strings.HasPrefix(in.Filters, "name") { //Query by name
-			policiesMatched, err := impl.policyStore.ListAllRolePolicies(in.ServiceName, in.Filters)
+		if in.Limit != 0 || len(in.ContinueToken) != 0 { //Query a page
+			policiesMatched, token, err := impl.store(ctx).ListRolePolicies(in.ServiceName,
+				&pms.ListOptions{Filter: in.Filters, Limit: int(in.Limit), Continue: in.ContinueToken})
+			if err != nil {
+				// Audit log
+				logging.WriteFailedAuditLog("[gRPC]QueryRolePolicies", ctxFields, err.Error())
+				return nil, toGRPCStatus(err)
+			}
+			policies = policiesMatched
+			continueToken = token
+		} else if len(in.Filters) != 0 { //Query by filter
+			policiesMatched, err := impl.store(ctx).ListAllRolePolicies(in.ServiceName, in.Filters)
 			if err != nil {
 				// Audit log
 				logging.WriteFailedAuditLog("[gRPC]QueryRolePolicies", ctxFields, err.Error())
@@ -611,7 +896,7 @@ func (impl *serviceImpl) QueryRolePolicies(ctx context.Context, in *pb.RolePolic
 			}
 			policies = policiesMatched
 		} else { // Query all policies
-			service, err := impl.policyStore.GetService(in.ServiceName)
+			service, err := impl.store(ctx).GetService(in.ServiceName)
 			if err != nil {
 				// Audit log
 				logging.WriteFailedAuditLog("[gRPC]QueryRolePolicies", ctxFields, err.Error())
@@ -622,7 +907,7 @@ func (impl *serviceImpl) QueryRolePolicies(ctx context.Context, in *pb.RolePolic
 		// Audit log
 		logging.WriteSucceededAuditLog("[gRPC]QueryRolePolicies", ctxFields, map[string]interface{}{"rolePolicyCount": len(policies)})
 	} else {
-		policy, err := impl.policyStore.GetRolePolicy(in.ServiceName, in.RolePolicyID)
+		policy, err := impl.store(ctx).GetRolePolicy(in.ServiceName, in.RolePolicyID)
 		if err != nil {
 			// Audit log
 			logging.WriteFailedAuditLog("[gRPC]QueryRolePolicies", ctxFields, err.Error())
@@ -635,7 +920,8 @@ func (impl *serviceImpl) QueryRolePolicies(ctx context.Context, in *pb.RolePolic
 	}
 
 	retPolicies := pb.RolePolicyQueryResponse{
-		RolePolicies: make([]*pb.RolePolicy, 0),
+		ContinueToken: continueToken,
+		RolePolicies:  make([]*pb.RolePolicy, 0),
 	}
 	for _, policy := range policies {
 		retPolicies.RolePolicies = append(retPolicies.RolePolicies, convertMetaRolePolicy(policy))
@@ -656,13 +942,13 @@ func (impl *serviceImpl) DeleteRolePolicies(ctx context.Context, in *pb.RolePoli
 	}
 
 	if len(in.RolePolicyID) == 0 {
-		if err := impl.policyStore.DeleteRolePolicies(in.ServiceName); err != nil {
+		if err := impl.store(ctx).DeleteRolePolicies(in.ServiceName); err != nil {
 			// Audit log
 			logging.WriteFailedAuditLog("[gRPC]DeleteRolePolicies", ctxFields, err.Error())
 			return nil, toGRPCStatus(err)
 		}
 	} else {
-		if err := impl.policyStore.DeleteRolePolicy(in.ServiceName, in.RolePolicyID); err != nil {
+		if err := impl.store(ctx).DeleteRolePolicy(in.ServiceName, in.RolePolicyID); err != nil {
 			// Audit log
 			logging.WriteFailedAuditLog("[gRPC]DeleteRolePolicies", ctxFields, err.Error())
 			return nil, toGRPCStatus(err)
@@ -676,7 +962,7 @@ func (impl *serviceImpl) DeleteRolePolicies(ctx context.Context, in *pb.RolePoli
 }
 
 func (impl *serviceImpl) ListPolicyCounts(ctx context.Context, in *pb.Empty) (*pb.PolicyCountsMap, error) {
-	countsMap, err := impl.policyStore.GetPolicyAndRolePolicyCounts()
+	countsMap, err := impl.store(ctx).GetPolicyAndRolePolicyCounts()
 	if err != nil {
 		// Audit log
 		logging.WriteFailedAuditLog("[gRPC]ListPolicyCounts", nil, err.Error())
@@ -697,8 +983,102 @@ func (impl *serviceImpl) ListPolicyCounts(ctx context.Context, in *pb.Empty) (*p
 	return &retCountsMap, nil
 }
 
+func (impl *serviceImpl) ExecuteTransaction(ctx context.Context, in *pb.TransactionRequest) (*pb.TransactionResponse, error) {
+	ops := make([]*pms.Operation, 0, len(in.Operations))
+	for _, rpcOperation := range in.Operations {
+		ops = append(ops, convertRPCOperation(rpcOperation))
+	}
+
+	if err := pmsimpl.CheckOperations(ops, impl.store(ctx)); err != nil {
+		// Audit log
+		logging.WriteSimpleFailedAuditLog("[gRPC]ExecuteTransaction", ops, err.Error())
+		return nil, toGRPCStatus(err)
+	}
+
+	// keep the metadata of the entities to update
+	for _, op := range ops {
+		if op.Op != pms.OpUpdate {
+			continue
+		}
+		switch op.Kind {
+		case pms.KindService:
+			if existing, err := impl.store(ctx).GetService(op.Service.Name); err == nil {
+				op.Service.Metadata = existing.Metadata
+			}
+		case pms.KindPolicy:
+			if existing, err := impl.store(ctx).GetPolicy(op.ServiceName, op.Policy.ID); err == nil {
+				op.Policy.Metadata = existing.Metadata
+			}
+		case pms.KindRolePolicy:
+			if existing, err := impl.store(ctx).GetRolePolicy(op.ServiceName, op.RolePolicy.ID); err == nil {
+				op.RolePolicy.Metadata = existing.Metadata
+			}
+		case pms.KindFunction:
+			if existing, err := impl.store(ctx).GetFunction(op.Function.Name); err == nil {
+				op.Function.Metadata = existing.Metadata
+			}
+		}
+	}
+
+	results, err := impl.store(ctx).ExecuteTransaction(ops)
+	if err != nil {
+		// Audit log
+		logging.WriteSimpleFailedAuditLog("[gRPC]ExecuteTransaction", ops, err.Error())
+		return nil, toGRPCStatus(err)
+	}
+
+	// Audit log
+	logging.WriteSimpleSucceededAuditLog("[gRPC]ExecuteTransaction", ops, len(results))
+
+	ret := pb.TransactionResponse{}
+	for _, result := range results {
+		ret.Operations = append(ret.Operations, convertMetaOperation(result))
+	}
+	return &ret, nil
+}
+
+func (impl *serviceImpl) ListHistory(ctx context.Context, in *pb.HistoryRequest) (*pb.HistoryResponse, error) {
+	historyManager, err := pmsimpl.GetHistoryManager(impl.store(ctx))
+	if err != nil {
+		// Audit log
+		logging.WriteSimpleFailedAuditLog("[gRPC]ListHistory", in, err.Error())
+		return nil, toGRPCStatus(err)
+	}
+	records, err := historyManager.ListHistory(in.Kind, in.ServiceName, in.Id)
+	if err != nil {
+		// Audit log
+		logging.WriteSimpleFailedAuditLog("[gRPC]ListHistory", in, err.Error())
+		return nil, toGRPCStatus(err)
+	}
+
+	// Audit log
+	logging.WriteSimpleSucceededAuditLog("[gRPC]ListHistory", in, nil)
+
+	ret := pb.HistoryResponse{}
+	for _, record := range records {
+		ret.Records = append(ret.Records, convertMetaHistoryRecord(record))
+	}
+	return &ret, nil
+}
+
+func (impl *serviceImpl) Rollback(ctx context.Context, in *pb.RollbackRequest) (*pb.Operation, error) {
+	// keep the metadata of the existing entity, or the one kept in history
+	result, err := pmsimpl.Rollback(impl.store(ctx), in.Kind, in.ServiceName, in.Id, in.Version, func(original map[string]string) map[string]string {
+		return original
+	})
+	if err != nil {
+		// Audit log
+		logging.WriteSimpleFailedAuditLog("[gRPC]Rollback", in, err.Error())
+		return nil, toGRPCStatus(err)
+	}
+
+	// Audit log
+	logging.WriteSimpleSucceededAuditLog("[gRPC]Rollback", in, result)
+	return convertMetaOperation(result), nil
+}
+
 func (impl *serviceImpl) GetDiscoverRequests(ctx context.Context, in *pb.DiscoverRequestsRequest) (*pb.DiscoverRequestsResponse, error) {
-	discoverRequestMgr, _ := impl.policyStore.(store.DiscoverRequestManager)
+	discoverRequestMgr, _ := impl.store(ctx).(store.DiscoverRequestManager)
 	last := in.Last
 	revision := in.Revision
 	serviceName := in.ServiceName
@@ -757,7 +1137,7 @@ func (impl *serviceImpl) GetDiscoverRequests(ctx context.Context, in *pb.Discove
 
 }
 func (impl *serviceImpl) ResetDiscoverRequests(ctx context.Context, in *pb.ResetRequestsRequest) (*pb.ResetRequestsResponse, error) {
-	discoverRequestMgr, _ := impl.policyStore.(store.DiscoverRequestManager)
+	discoverRequestMgr, _ := impl.store(ctx).(store.DiscoverRequestManager)
 	err := discoverRequestMgr.ResetDiscoverRequests(in.ServiceName)
 
 	// Audit log
@@ -771,7 +1151,7 @@ func (impl *serviceImpl) ResetDiscoverRequests(ctx context.Context, in *pb.Reset
 }
 
 func (impl *serviceImpl) GetDiscoverPolicies(ctx context.Context, in *pb.DiscoverPoliciesRequest) (*pb.DiscoverPoliciesResponse, error) {
-	discoverRequestMgr, _ := impl.policyStore.(store.DiscoverRequestManager)
+	discoverRequestMgr, _ := impl.store(ctx).(store.DiscoverRequestManager)
 	serviceMap, revision, err := discoverRequestMgr.GeneratePolicies(in.ServiceName, in.PrincipalType, in.PrincipalName, in.PrincipalIdd)
 
 	// Audit contextual fields for request
@@ -34,8 +34,16 @@ It has these top-level messages:
 	RolePolicyQueryResponse
 	RolePolicy
 	Service
+	Operation
+	TransactionRequest
+	TransactionResponse
+	HistoryRecord
+	HistoryRequest
+	HistoryResponse
+	RollbackRequest
 	PolicyAndRolePolicyCounts
 	PolicyCountsMap
+	Attribute
 */
 package pb
 
@@ -365,6 +373,7 @@ type Function struct {
 	Ca             string `protobuf:"bytes,5,opt,name=ca" json:"ca,omitempty"`
 	ResultCachable bool   `protobuf:"varint,6,opt,name=resultCachable" json:"resultCachable,omitempty"`
 	ResultTTL      int64  `protobuf:"varint,7,opt,name=resultTTL" json:"resultTTL,omitempty"`
+	Revision       int64  `protobuf:"varint,8,opt,name=revision" json:"revision,omitempty"`
 }
 
 func (m *Function) Reset()                    { *m = Function{} }
@@ -421,6 +430,13 @@ func (m *Function) GetResultTTL() int64 {
 	return 0
 }
 
+func (m *Function) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
 type FunctionQueryRequest struct {
 	Name    string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
 	Filters string `protobuf:"bytes,2,opt,name=filters" json:"filters,omitempty"`
@@ -486,8 +502,12 @@ func (*Empty) ProtoMessage()               {}
 func (*Empty) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
 
 type ServiceRequest struct {
-	Name string      `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
-	Type ServiceType `protobuf:"varint,2,opt,name=type,enum=pb.ServiceType" json:"type,omitempty"`
+	Name               string       `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Type               ServiceType  `protobuf:"varint,2,opt,name=type,enum=pb.ServiceType" json:"type,omitempty"`
+	Revision           int64        `protobuf:"varint,3,opt,name=revision" json:"revision,omitempty"`
+	CombiningAlgorithm string       `protobuf:"bytes,4,opt,name=combiningAlgorithm" json:"combiningAlgorithm,omitempty"`
+	DefaultEffect      string       `protobuf:"bytes,5,opt,name=defaultEffect" json:"defaultEffect,omitempty"`
+	Attributes         []*Attribute `protobuf:"bytes,6,rep,name=attributes" json:"attributes,omitempty"`
 }
 
 func (m *ServiceRequest) Reset()                    { *m = ServiceRequest{} }
@@ -509,6 +529,34 @@ func (m *ServiceRequest) GetType() ServiceType {
 	return ServiceType_APPLICATION
 }
 
+func (m *ServiceRequest) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
+func (m *ServiceRequest) GetCombiningAlgorithm() string {
+	if m != nil {
+		return m.CombiningAlgorithm
+	}
+	return ""
+}
+
+func (m *ServiceRequest) GetDefaultEffect() string {
+	if m != nil {
+		return m.DefaultEffect
+	}
+	return ""
+}
+
+func (m *ServiceRequest) GetAttributes() []*Attribute {
+	if m != nil {
+		return m.Attributes
+	}
+	return nil
+}
+
 type PolicyRequest struct {
 	ServiceName string  `protobuf:"bytes,1,opt,name=serviceName" json:"serviceName,omitempty"`
 	Policy      *Policy `protobuf:"bytes,2,opt,name=policy" json:"policy,omitempty"`
@@ -566,9 +614,11 @@ func (m *ServiceQueryRequest) GetName() string {
 }
 
 type PolicyQueryRequest struct {
-	ServiceName string `protobuf:"bytes,1,opt,name=serviceName" json:"serviceName,omitempty"`
-	PolicyID    string `protobuf:"bytes,2,opt,name=policyID" json:"policyID,omitempty"`
-	Filters     string `protobuf:"bytes,3,opt,name=filters" json:"filters,omitempty"`
+	ServiceName   string `protobuf:"bytes,1,opt,name=serviceName" json:"serviceName,omitempty"`
+	PolicyID      string `protobuf:"bytes,2,opt,name=policyID" json:"policyID,omitempty"`
+	Filters       string `protobuf:"bytes,3,opt,name=filters" json:"filters,omitempty"`
+	Limit         int32  `protobuf:"varint,4,opt,name=limit" json:"limit,omitempty"`
+	ContinueToken string `protobuf:"bytes,5,opt,name=continueToken" json:"continueToken,omitempty"`
 }
 
 func (m *PolicyQueryRequest) Reset()                    { *m = PolicyQueryRequest{} }
@@ -597,8 +647,23 @@ func (m *PolicyQueryRequest) GetFilters() string {
 	return ""
 }
 
+func (m *PolicyQueryRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *PolicyQueryRequest) GetContinueToken() string {
+	if m != nil {
+		return m.ContinueToken
+	}
+	return ""
+}
+
 type PolicyQueryResponse struct {
-	Policies []*Policy `protobuf:"bytes,1,rep,name=policies" json:"policies,omitempty"`
+	Policies      []*Policy `protobuf:"bytes,1,rep,name=policies" json:"policies,omitempty"`
+	ContinueToken string    `protobuf:"bytes,2,opt,name=continueToken" json:"continueToken,omitempty"`
 }
 
 func (m *PolicyQueryResponse) Reset()                    { *m = PolicyQueryResponse{} }
@@ -613,6 +678,13 @@ func (m *PolicyQueryResponse) GetPolicies() []*Policy {
 	return nil
 }
 
+func (m *PolicyQueryResponse) GetContinueToken() string {
+	if m != nil {
+		return m.ContinueToken
+	}
+	return ""
+}
+
 type Policy struct {
 	Id          string               `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
 	Name        string               `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
@@ -620,6 +692,8 @@ type Policy struct {
 	Permissions []*Policy_Permission `protobuf:"bytes,4,rep,name=permissions" json:"permissions,omitempty"`
 	Principals  []*AndPrincipals     `protobuf:"bytes,5,rep,name=principals" json:"principals,omitempty"`
 	Condition   string               `protobuf:"bytes,6,opt,name=condition" json:"condition,omitempty"`
+	Revision    int64                `protobuf:"varint,7,opt,name=revision" json:"revision,omitempty"`
+	Priority    int32                `protobuf:"varint,8,opt,name=priority" json:"priority,omitempty"`
 }
 
 func (m *Policy) Reset()                    { *m = Policy{} }
@@ -669,10 +743,25 @@ func (m *Policy) GetCondition() string {
 	return ""
 }
 
+func (m *Policy) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
+func (m *Policy) GetPriority() int32 {
+	if m != nil {
+		return m.Priority
+	}
+	return 0
+}
+
 type Policy_Permission struct {
 	Resource           string   `protobuf:"bytes,1,opt,name=resource" json:"resource,omitempty"`
 	ResourceExpression string   `protobuf:"bytes,2,opt,name=resource_expression,json=resourceExpression" json:"resource_expression,omitempty"`
 	Actions            []string `protobuf:"bytes,3,rep,name=actions" json:"actions,omitempty"`
+	ResourceGlob       string   `protobuf:"bytes,4,opt,name=resource_glob,json=resourceGlob" json:"resource_glob,omitempty"`
 }
 
 func (m *Policy_Permission) Reset()                    { *m = Policy_Permission{} }
@@ -701,6 +790,13 @@ func (m *Policy_Permission) GetActions() []string {
 	return nil
 }
 
+func (m *Policy_Permission) GetResourceGlob() string {
+	if m != nil {
+		return m.ResourceGlob
+	}
+	return ""
+}
+
 type RolePolicyRequest struct {
 	ServiceName string      `protobuf:"bytes,1,opt,name=serviceName" json:"serviceName,omitempty"`
 	RolePolicy  *RolePolicy `protobuf:"bytes,2,opt,name=rolePolicy" json:"rolePolicy,omitempty"`
@@ -726,9 +822,11 @@ func (m *RolePolicyRequest) GetRolePolicy() *RolePolicy {
 }
 
 type RolePolicyQueryRequest struct {
-	ServiceName  string `protobuf:"bytes,1,opt,name=serviceName" json:"serviceName,omitempty"`
-	RolePolicyID string `protobuf:"bytes,2,opt,name=rolePolicyID" json:"rolePolicyID,omitempty"`
-	Filters      string `protobuf:"bytes,3,opt,name=filters" json:"filters,omitempty"`
+	ServiceName   string `protobuf:"bytes,1,opt,name=serviceName" json:"serviceName,omitempty"`
+	RolePolicyID  string `protobuf:"bytes,2,opt,name=rolePolicyID" json:"rolePolicyID,omitempty"`
+	Filters       string `protobuf:"bytes,3,opt,name=filters" json:"filters,omitempty"`
+	Limit         int32  `protobuf:"varint,4,opt,name=limit" json:"limit,omitempty"`
+	ContinueToken string `protobuf:"bytes,5,opt,name=continueToken" json:"continueToken,omitempty"`
 }
 
 func (m *RolePolicyQueryRequest) Reset()                    { *m = RolePolicyQueryRequest{} }
@@ -757,8 +855,23 @@ func (m *RolePolicyQueryRequest) GetFilters() string {
 	return ""
 }
 
+func (m *RolePolicyQueryRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *RolePolicyQueryRequest) GetContinueToken() string {
+	if m != nil {
+		return m.ContinueToken
+	}
+	return ""
+}
+
 type RolePolicyQueryResponse struct {
-	RolePolicies []*RolePolicy `protobuf:"bytes,1,rep,name=rolePolicies" json:"rolePolicies,omitempty"`
+	RolePolicies  []*RolePolicy `protobuf:"bytes,1,rep,name=rolePolicies" json:"rolePolicies,omitempty"`
+	ContinueToken string        `protobuf:"bytes,2,opt,name=continueToken" json:"continueToken,omitempty"`
 }
 
 func (m *RolePolicyQueryResponse) Reset()                    { *m = RolePolicyQueryResponse{} }
@@ -773,6 +886,13 @@ func (m *RolePolicyQueryResponse) GetRolePolicies() []*RolePolicy {
 	return nil
 }
 
+func (m *RolePolicyQueryResponse) GetContinueToken() string {
+	if m != nil {
+		return m.ContinueToken
+	}
+	return ""
+}
+
 type RolePolicy struct {
 	Id                  string   `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
 	Name                string   `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
@@ -782,6 +902,8 @@ type RolePolicy struct {
 	Resources           []string `protobuf:"bytes,6,rep,name=resources" json:"resources,omitempty"`
 	ResourceExpressions []string `protobuf:"bytes,7,rep,name=resource_expressions,json=resourceExpressions" json:"resource_expressions,omitempty"`
 	Condition           string   `protobuf:"bytes,8,opt,name=condition" json:"condition,omitempty"`
+	Revision            int64    `protobuf:"varint,9,opt,name=revision" json:"revision,omitempty"`
+	ResourceGlobs       []string `protobuf:"bytes,10,rep,name=resource_globs,json=resourceGlobs" json:"resource_globs,omitempty"`
 }
 
 func (m *RolePolicy) Reset()                    { *m = RolePolicy{} }
@@ -845,11 +967,29 @@ func (m *RolePolicy) GetCondition() string {
 	return ""
 }
 
+func (m *RolePolicy) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
+func (m *RolePolicy) GetResourceGlobs() []string {
+	if m != nil {
+		return m.ResourceGlobs
+	}
+	return nil
+}
+
 type Service struct {
-	Name         string        `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
-	Type         ServiceType   `protobuf:"varint,2,opt,name=type,enum=pb.ServiceType" json:"type,omitempty"`
-	Policies     []*Policy     `protobuf:"bytes,3,rep,name=policies" json:"policies,omitempty"`
-	RolePolicies []*RolePolicy `protobuf:"bytes,4,rep,name=role_policies,json=rolePolicies" json:"role_policies,omitempty"`
+	Name               string        `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Type               ServiceType   `protobuf:"varint,2,opt,name=type,enum=pb.ServiceType" json:"type,omitempty"`
+	Policies           []*Policy     `protobuf:"bytes,3,rep,name=policies" json:"policies,omitempty"`
+	RolePolicies       []*RolePolicy `protobuf:"bytes,4,rep,name=role_policies,json=rolePolicies" json:"role_policies,omitempty"`
+	Revision           int64         `protobuf:"varint,5,opt,name=revision" json:"revision,omitempty"`
+	CombiningAlgorithm string        `protobuf:"bytes,6,opt,name=combiningAlgorithm" json:"combiningAlgorithm,omitempty"`
+	DefaultEffect      string        `protobuf:"bytes,7,opt,name=defaultEffect" json:"defaultEffect,omitempty"`
+	Attributes         []*Attribute  `protobuf:"bytes,8,rep,name=attributes" json:"attributes,omitempty"`
 }
 
 func (m *Service) Reset()                    { *m = Service{} }
@@ -885,6 +1025,322 @@ func (m *Service) GetRolePolicies() []*RolePolicy {
 	return nil
 }
 
+func (m *Service) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
+func (m *Service) GetCombiningAlgorithm() string {
+	if m != nil {
+		return m.CombiningAlgorithm
+	}
+	return ""
+}
+
+func (m *Service) GetDefaultEffect() string {
+	if m != nil {
+		return m.DefaultEffect
+	}
+	return ""
+}
+
+func (m *Service) GetAttributes() []*Attribute {
+	if m != nil {
+		return m.Attributes
+	}
+	return nil
+}
+
+type Operation struct {
+	Op          string      `protobuf:"bytes,1,opt,name=op" json:"op,omitempty"`
+	Kind        string      `protobuf:"bytes,2,opt,name=kind" json:"kind,omitempty"`
+	ServiceName string      `protobuf:"bytes,3,opt,name=serviceName" json:"serviceName,omitempty"`
+	Id          string      `protobuf:"bytes,4,opt,name=id" json:"id,omitempty"`
+	Service     *Service    `protobuf:"bytes,5,opt,name=service" json:"service,omitempty"`
+	Policy      *Policy     `protobuf:"bytes,6,opt,name=policy" json:"policy,omitempty"`
+	RolePolicy  *RolePolicy `protobuf:"bytes,7,opt,name=rolePolicy" json:"rolePolicy,omitempty"`
+	Function    *Function   `protobuf:"bytes,8,opt,name=function" json:"function,omitempty"`
+}
+
+func (m *Operation) Reset()                    { *m = Operation{} }
+func (m *Operation) String() string            { return proto.CompactTextString(m) }
+func (*Operation) ProtoMessage()               {}
+func (*Operation) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+func (m *Operation) GetOp() string {
+	if m != nil {
+		return m.Op
+	}
+	return ""
+}
+
+func (m *Operation) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *Operation) GetServiceName() string {
+	if m != nil {
+		return m.ServiceName
+	}
+	return ""
+}
+
+func (m *Operation) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Operation) GetService() *Service {
+	if m != nil {
+		return m.Service
+	}
+	return nil
+}
+
+func (m *Operation) GetPolicy() *Policy {
+	if m != nil {
+		return m.Policy
+	}
+	return nil
+}
+
+func (m *Operation) GetRolePolicy() *RolePolicy {
+	if m != nil {
+		return m.RolePolicy
+	}
+	return nil
+}
+
+func (m *Operation) GetFunction() *Function {
+	if m != nil {
+		return m.Function
+	}
+	return nil
+}
+
+type TransactionRequest struct {
+	Operations []*Operation `protobuf:"bytes,1,rep,name=operations" json:"operations,omitempty"`
+}
+
+func (m *TransactionRequest) Reset()                    { *m = TransactionRequest{} }
+func (m *TransactionRequest) String() string            { return proto.CompactTextString(m) }
+func (*TransactionRequest) ProtoMessage()               {}
+func (*TransactionRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+func (m *TransactionRequest) GetOperations() []*Operation {
+	if m != nil {
+		return m.Operations
+	}
+	return nil
+}
+
+type TransactionResponse struct {
+	Operations []*Operation `protobuf:"bytes,1,rep,name=operations" json:"operations,omitempty"`
+}
+
+func (m *TransactionResponse) Reset()                    { *m = TransactionResponse{} }
+func (m *TransactionResponse) String() string            { return proto.CompactTextString(m) }
+func (*TransactionResponse) ProtoMessage()               {}
+func (*TransactionResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+func (m *TransactionResponse) GetOperations() []*Operation {
+	if m != nil {
+		return m.Operations
+	}
+	return nil
+}
+
+type HistoryRecord struct {
+	Kind        string      `protobuf:"bytes,1,opt,name=kind" json:"kind,omitempty"`
+	ServiceName string      `protobuf:"bytes,2,opt,name=serviceName" json:"serviceName,omitempty"`
+	Id          string      `protobuf:"bytes,3,opt,name=id" json:"id,omitempty"`
+	Version     int64       `protobuf:"varint,4,opt,name=version" json:"version,omitempty"`
+	Op          string      `protobuf:"bytes,5,opt,name=op" json:"op,omitempty"`
+	ChangedBy   string      `protobuf:"bytes,6,opt,name=changedBy" json:"changedBy,omitempty"`
+	ChangedAt   string      `protobuf:"bytes,7,opt,name=changedAt" json:"changedAt,omitempty"`
+	Service     *Service    `protobuf:"bytes,8,opt,name=service" json:"service,omitempty"`
+	Policy      *Policy     `protobuf:"bytes,9,opt,name=policy" json:"policy,omitempty"`
+	RolePolicy  *RolePolicy `protobuf:"bytes,10,opt,name=rolePolicy" json:"rolePolicy,omitempty"`
+	Function    *Function   `protobuf:"bytes,11,opt,name=function" json:"function,omitempty"`
+}
+
+func (m *HistoryRecord) Reset()                    { *m = HistoryRecord{} }
+func (m *HistoryRecord) String() string            { return proto.CompactTextString(m) }
+func (*HistoryRecord) ProtoMessage()               {}
+func (*HistoryRecord) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+
+func (m *HistoryRecord) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *HistoryRecord) GetServiceName() string {
+	if m != nil {
+		return m.ServiceName
+	}
+	return ""
+}
+
+func (m *HistoryRecord) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *HistoryRecord) GetVersion() int64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *HistoryRecord) GetOp() string {
+	if m != nil {
+		return m.Op
+	}
+	return ""
+}
+
+func (m *HistoryRecord) GetChangedBy() string {
+	if m != nil {
+		return m.ChangedBy
+	}
+	return ""
+}
+
+func (m *HistoryRecord) GetChangedAt() string {
+	if m != nil {
+		return m.ChangedAt
+	}
+	return ""
+}
+
+func (m *HistoryRecord) GetService() *Service {
+	if m != nil {
+		return m.Service
+	}
+	return nil
+}
+
+func (m *HistoryRecord) GetPolicy() *Policy {
+	if m != nil {
+		return m.Policy
+	}
+	return nil
+}
+
+func (m *HistoryRecord) GetRolePolicy() *RolePolicy {
+	if m != nil {
+		return m.RolePolicy
+	}
+	return nil
+}
+
+func (m *HistoryRecord) GetFunction() *Function {
+	if m != nil {
+		return m.Function
+	}
+	return nil
+}
+
+type HistoryRequest struct {
+	Kind        string `protobuf:"bytes,1,opt,name=kind" json:"kind,omitempty"`
+	ServiceName string `protobuf:"bytes,2,opt,name=serviceName" json:"serviceName,omitempty"`
+	Id          string `protobuf:"bytes,3,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *HistoryRequest) Reset()                    { *m = HistoryRequest{} }
+func (m *HistoryRequest) String() string            { return proto.CompactTextString(m) }
+func (*HistoryRequest) ProtoMessage()               {}
+func (*HistoryRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+
+func (m *HistoryRequest) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *HistoryRequest) GetServiceName() string {
+	if m != nil {
+		return m.ServiceName
+	}
+	return ""
+}
+
+func (m *HistoryRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type HistoryResponse struct {
+	Records []*HistoryRecord `protobuf:"bytes,1,rep,name=records" json:"records,omitempty"`
+}
+
+func (m *HistoryResponse) Reset()                    { *m = HistoryResponse{} }
+func (m *HistoryResponse) String() string            { return proto.CompactTextString(m) }
+func (*HistoryResponse) ProtoMessage()               {}
+func (*HistoryResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
+
+func (m *HistoryResponse) GetRecords() []*HistoryRecord {
+	if m != nil {
+		return m.Records
+	}
+	return nil
+}
+
+type RollbackRequest struct {
+	Kind        string `protobuf:"bytes,1,opt,name=kind" json:"kind,omitempty"`
+	ServiceName string `protobuf:"bytes,2,opt,name=serviceName" json:"serviceName,omitempty"`
+	Id          string `protobuf:"bytes,3,opt,name=id" json:"id,omitempty"`
+	Version     int64  `protobuf:"varint,4,opt,name=version" json:"version,omitempty"`
+}
+
+func (m *RollbackRequest) Reset()                    { *m = RollbackRequest{} }
+func (m *RollbackRequest) String() string            { return proto.CompactTextString(m) }
+func (*RollbackRequest) ProtoMessage()               {}
+func (*RollbackRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{32} }
+
+func (m *RollbackRequest) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *RollbackRequest) GetServiceName() string {
+	if m != nil {
+		return m.ServiceName
+	}
+	return ""
+}
+
+func (m *RollbackRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *RollbackRequest) GetVersion() int64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
 type PolicyAndRolePolicyCounts struct {
 	PolicyCount     int64 `protobuf:"varint,1,opt,name=policyCount" json:"policyCount,omitempty"`
 	RolePolicyCount int64 `protobuf:"varint,2,opt,name=rolePolicyCount" json:"rolePolicyCount,omitempty"`
@@ -893,7 +1349,7 @@ type PolicyAndRolePolicyCounts struct {
 func (m *PolicyAndRolePolicyCounts) Reset()                    { *m = PolicyAndRolePolicyCounts{} }
 func (m *PolicyAndRolePolicyCounts) String() string            { return proto.CompactTextString(m) }
 func (*PolicyAndRolePolicyCounts) ProtoMessage()               {}
-func (*PolicyAndRolePolicyCounts) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+func (*PolicyAndRolePolicyCounts) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
 
 func (m *PolicyAndRolePolicyCounts) GetPolicyCount() int64 {
 	if m != nil {
@@ -916,7 +1372,7 @@ type PolicyCountsMap struct {
 func (m *PolicyCountsMap) Reset()                    { *m = PolicyCountsMap{} }
 func (m *PolicyCountsMap) String() string            { return proto.CompactTextString(m) }
 func (*PolicyCountsMap) ProtoMessage()               {}
-func (*PolicyCountsMap) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+func (*PolicyCountsMap) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
 
 func (m *PolicyCountsMap) GetCountMap() map[string]*PolicyAndRolePolicyCounts {
 	if m != nil {
@@ -925,6 +1381,38 @@ func (m *PolicyCountsMap) GetCountMap() map[string]*PolicyAndRolePolicyCounts {
 	return nil
 }
 
+type Attribute struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Type string `protobuf:"bytes,2,opt,name=type" json:"type,omitempty"`
+	List bool   `protobuf:"varint,3,opt,name=list" json:"list,omitempty"`
+}
+
+func (m *Attribute) Reset()                    { *m = Attribute{} }
+func (m *Attribute) String() string            { return proto.CompactTextString(m) }
+func (*Attribute) ProtoMessage()               {}
+func (*Attribute) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{35} }
+
+func (m *Attribute) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Attribute) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *Attribute) GetList() bool {
+	if m != nil {
+		return m.List
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterType((*DiscoverRequestsRequest)(nil), "pb.DiscoverRequestsRequest")
 	proto.RegisterType((*Principal)(nil), "pb.Principal")
@@ -953,8 +1441,16 @@ func init() {
 	proto.RegisterType((*RolePolicyQueryResponse)(nil), "pb.RolePolicyQueryResponse")
 	proto.RegisterType((*RolePolicy)(nil), "pb.RolePolicy")
 	proto.RegisterType((*Service)(nil), "pb.Service")
+	proto.RegisterType((*Operation)(nil), "pb.Operation")
+	proto.RegisterType((*TransactionRequest)(nil), "pb.TransactionRequest")
+	proto.RegisterType((*TransactionResponse)(nil), "pb.TransactionResponse")
+	proto.RegisterType((*HistoryRecord)(nil), "pb.HistoryRecord")
+	proto.RegisterType((*HistoryRequest)(nil), "pb.HistoryRequest")
+	proto.RegisterType((*HistoryResponse)(nil), "pb.HistoryResponse")
+	proto.RegisterType((*RollbackRequest)(nil), "pb.RollbackRequest")
 	proto.RegisterType((*PolicyAndRolePolicyCounts)(nil), "pb.PolicyAndRolePolicyCounts")
 	proto.RegisterType((*PolicyCountsMap)(nil), "pb.PolicyCountsMap")
+	proto.RegisterType((*Attribute)(nil), "pb.Attribute")
 	proto.RegisterEnum("pb.Effect", Effect_name, Effect_value)
 	proto.RegisterEnum("pb.ServiceType", ServiceType_name, ServiceType_value)
 }
@@ -971,18 +1467,25 @@ const _ = grpc.SupportPackageIsVersion4
 
 type PolicyManagerClient interface {
 	CreateFunction(ctx context.Context, in *Function, opts ...grpc.CallOption) (*Function, error)
+	UpdateFunction(ctx context.Context, in *Function, opts ...grpc.CallOption) (*Function, error)
 	QueryFunctions(ctx context.Context, in *FunctionQueryRequest, opts ...grpc.CallOption) (*FunctionQueryResponse, error)
 	DeleteFunctions(ctx context.Context, in *FunctionQueryRequest, opts ...grpc.CallOption) (*Empty, error)
 	CreateService(ctx context.Context, in *ServiceRequest, opts ...grpc.CallOption) (*Service, error)
+	UpdateService(ctx context.Context, in *ServiceRequest, opts ...grpc.CallOption) (*Service, error)
 	QueryServices(ctx context.Context, in *ServiceQueryRequest, opts ...grpc.CallOption) (*ServiceQueryResponse, error)
 	DeleteServices(ctx context.Context, in *ServiceQueryRequest, opts ...grpc.CallOption) (*Empty, error)
 	CreatePolicy(ctx context.Context, in *PolicyRequest, opts ...grpc.CallOption) (*Policy, error)
+	UpdatePolicy(ctx context.Context, in *PolicyRequest, opts ...grpc.CallOption) (*Policy, error)
 	QueryPolicies(ctx context.Context, in *PolicyQueryRequest, opts ...grpc.CallOption) (*PolicyQueryResponse, error)
 	DeletePolicies(ctx context.Context, in *PolicyQueryRequest, opts ...grpc.CallOption) (*Empty, error)
 	CreateRolePolicy(ctx context.Context, in *RolePolicyRequest, opts ...grpc.CallOption) (*RolePolicy, error)
+	UpdateRolePolicy(ctx context.Context, in *RolePolicyRequest, opts ...grpc.CallOption) (*RolePolicy, error)
 	QueryRolePolicies(ctx context.Context, in *RolePolicyQueryRequest, opts ...grpc.CallOption) (*RolePolicyQueryResponse, error)
 	DeleteRolePolicies(ctx context.Context, in *RolePolicyQueryRequest, opts ...grpc.CallOption) (*Empty, error)
 	ListPolicyCounts(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PolicyCountsMap, error)
+	ExecuteTransaction(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
+	ListHistory(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*HistoryResponse, error)
+	Rollback(ctx context.Context, in *RollbackRequest, opts ...grpc.CallOption) (*Operation, error)
 	GetDiscoverRequests(ctx context.Context, in *DiscoverRequestsRequest, opts ...grpc.CallOption) (*DiscoverRequestsResponse, error)
 	ResetDiscoverRequests(ctx context.Context, in *ResetRequestsRequest, opts ...grpc.CallOption) (*ResetRequestsResponse, error)
 	GetDiscoverPolicies(ctx context.Context, in *DiscoverPoliciesRequest, opts ...grpc.CallOption) (*DiscoverPoliciesResponse, error)
@@ -1005,6 +1508,15 @@ func (c *policyManagerClient) CreateFunction(ctx context.Context, in *Function,
 	return out, nil
 }
 
+func (c *policyManagerClient) UpdateFunction(ctx context.Context, in *Function, opts ...grpc.CallOption) (*Function, error) {
+	out := new(Function)
+	err := grpc.Invoke(ctx, "/pb.PolicyManager/UpdateFunction", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *policyManagerClient) QueryFunctions(ctx context.Context, in *FunctionQueryRequest, opts ...grpc.CallOption) (*FunctionQueryResponse, error) {
 	out := new(FunctionQueryResponse)
 	err := grpc.Invoke(ctx, "/pb.PolicyManager/QueryFunctions", in, out, c.cc, opts...)
@@ -1032,6 +1544,15 @@ func (c *policyManagerClient) CreateService(ctx context.Context, in *ServiceRequ
 	return out, nil
 }
 
+func (c *policyManagerClient) UpdateService(ctx context.Context, in *ServiceRequest, opts ...grpc.CallOption) (*Service, error) {
+	out := new(Service)
+	err := grpc.Invoke(ctx, "/pb.PolicyManager/UpdateService", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *policyManagerClient) QueryServices(ctx context.Context, in *ServiceQueryRequest, opts ...grpc.CallOption) (*ServiceQueryResponse, error) {
 	out := new(ServiceQueryResponse)
 	err := grpc.Invoke(ctx, "/pb.PolicyManager/QueryServices", in, out, c.cc, opts...)
@@ -1059,6 +1580,15 @@ func (c *policyManagerClient) CreatePolicy(ctx context.Context, in *PolicyReques
 	return out, nil
 }
 
+func (c *policyManagerClient) UpdatePolicy(ctx context.Context, in *PolicyRequest, opts ...grpc.CallOption) (*Policy, error) {
+	out := new(Policy)
+	err := grpc.Invoke(ctx, "/pb.PolicyManager/UpdatePolicy", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *policyManagerClient) QueryPolicies(ctx context.Context, in *PolicyQueryRequest, opts ...grpc.CallOption) (*PolicyQueryResponse, error) {
 	out := new(PolicyQueryResponse)
 	err := grpc.Invoke(ctx, "/pb.PolicyManager/QueryPolicies", in, out, c.cc, opts...)
@@ -1086,6 +1616,15 @@ func (c *policyManagerClient) CreateRolePolicy(ctx context.Context, in *RolePoli
 	return out, nil
 }
 
+func (c *policyManagerClient) UpdateRolePolicy(ctx context.Context, in *RolePolicyRequest, opts ...grpc.CallOption) (*RolePolicy, error) {
+	out := new(RolePolicy)
+	err := grpc.Invoke(ctx, "/pb.PolicyManager/UpdateRolePolicy", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *policyManagerClient) QueryRolePolicies(ctx context.Context, in *RolePolicyQueryRequest, opts ...grpc.CallOption) (*RolePolicyQueryResponse, error) {
 	out := new(RolePolicyQueryResponse)
 	err := grpc.Invoke(ctx, "/pb.PolicyManager/QueryRolePolicies", in, out, c.cc, opts...)
@@ -1113,6 +1652,33 @@ func (c *policyManagerClient) ListPolicyCounts(ctx context.Context, in *Empty, o
 	return out, nil
 }
 
+func (c *policyManagerClient) ExecuteTransaction(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*TransactionResponse, error) {
+	out := new(TransactionResponse)
+	err := grpc.Invoke(ctx, "/pb.PolicyManager/ExecuteTransaction", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *policyManagerClient) ListHistory(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*HistoryResponse, error) {
+	out := new(HistoryResponse)
+	err := grpc.Invoke(ctx, "/pb.PolicyManager/ListHistory", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *policyManagerClient) Rollback(ctx context.Context, in *RollbackRequest, opts ...grpc.CallOption) (*Operation, error) {
+	out := new(Operation)
+	err := grpc.Invoke(ctx, "/pb.PolicyManager/Rollback", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *policyManagerClient) GetDiscoverRequests(ctx context.Context, in *DiscoverRequestsRequest, opts ...grpc.CallOption) (*DiscoverRequestsResponse, error) {
 	out := new(DiscoverRequestsResponse)
 	err := grpc.Invoke(ctx, "/pb.PolicyManager/GetDiscoverRequests", in, out, c.cc, opts...)
@@ -1144,18 +1710,25 @@ func (c *policyManagerClient) GetDiscoverPolicies(ctx context.Context, in *Disco
 
 type PolicyManagerServer interface {
 	CreateFunction(context.Context, *Function) (*Function, error)
+	UpdateFunction(context.Context, *Function) (*Function, error)
 	QueryFunctions(context.Context, *FunctionQueryRequest) (*FunctionQueryResponse, error)
 	DeleteFunctions(context.Context, *FunctionQueryRequest) (*Empty, error)
 	CreateService(context.Context, *ServiceRequest) (*Service, error)
+	UpdateService(context.Context, *ServiceRequest) (*Service, error)
 	QueryServices(context.Context, *ServiceQueryRequest) (*ServiceQueryResponse, error)
 	DeleteServices(context.Context, *ServiceQueryRequest) (*Empty, error)
 	CreatePolicy(context.Context, *PolicyRequest) (*Policy, error)
+	UpdatePolicy(context.Context, *PolicyRequest) (*Policy, error)
 	QueryPolicies(context.Context, *PolicyQueryRequest) (*PolicyQueryResponse, error)
 	DeletePolicies(context.Context, *PolicyQueryRequest) (*Empty, error)
 	CreateRolePolicy(context.Context, *RolePolicyRequest) (*RolePolicy, error)
+	UpdateRolePolicy(context.Context, *RolePolicyRequest) (*RolePolicy, error)
 	QueryRolePolicies(context.Context, *RolePolicyQueryRequest) (*RolePolicyQueryResponse, error)
 	DeleteRolePolicies(context.Context, *RolePolicyQueryRequest) (*Empty, error)
 	ListPolicyCounts(context.Context, *Empty) (*PolicyCountsMap, error)
+	ExecuteTransaction(context.Context, *TransactionRequest) (*TransactionResponse, error)
+	ListHistory(context.Context, *HistoryRequest) (*HistoryResponse, error)
+	Rollback(context.Context, *RollbackRequest) (*Operation, error)
 	GetDiscoverRequests(context.Context, *DiscoverRequestsRequest) (*DiscoverRequestsResponse, error)
 	ResetDiscoverRequests(context.Context, *ResetRequestsRequest) (*ResetRequestsResponse, error)
 	GetDiscoverPolicies(context.Context, *DiscoverPoliciesRequest) (*DiscoverPoliciesResponse, error)
@@ -1183,6 +1756,24 @@ func _PolicyManager_CreateFunction_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PolicyManager_UpdateFunction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Function)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PolicyManagerServer).UpdateFunction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.PolicyManager/UpdateFunction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PolicyManagerServer).UpdateFunction(ctx, req.(*Function))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _PolicyManager_QueryFunctions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(FunctionQueryRequest)
 	if err := dec(in); err != nil {
@@ -1237,6 +1828,24 @@ func _PolicyManager_CreateService_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PolicyManager_UpdateService_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ServiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PolicyManagerServer).UpdateService(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.PolicyManager/UpdateService",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PolicyManagerServer).UpdateService(ctx, req.(*ServiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _PolicyManager_QueryServices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ServiceQueryRequest)
 	if err := dec(in); err != nil {
@@ -1291,6 +1900,24 @@ func _PolicyManager_CreatePolicy_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PolicyManager_UpdatePolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PolicyManagerServer).UpdatePolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.PolicyManager/UpdatePolicy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PolicyManagerServer).UpdatePolicy(ctx, req.(*PolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _PolicyManager_QueryPolicies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(PolicyQueryRequest)
 	if err := dec(in); err != nil {
@@ -1345,6 +1972,24 @@ func _PolicyManager_CreateRolePolicy_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PolicyManager_UpdateRolePolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RolePolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PolicyManagerServer).UpdateRolePolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.PolicyManager/UpdateRolePolicy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PolicyManagerServer).UpdateRolePolicy(ctx, req.(*RolePolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _PolicyManager_QueryRolePolicies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(RolePolicyQueryRequest)
 	if err := dec(in); err != nil {
@@ -1399,6 +2044,60 @@ func _PolicyManager_ListPolicyCounts_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PolicyManager_ExecuteTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PolicyManagerServer).ExecuteTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.PolicyManager/ExecuteTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PolicyManagerServer).ExecuteTransaction(ctx, req.(*TransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PolicyManager_ListHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PolicyManagerServer).ListHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.PolicyManager/ListHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PolicyManagerServer).ListHistory(ctx, req.(*HistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PolicyManager_Rollback_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RollbackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PolicyManagerServer).Rollback(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.PolicyManager/Rollback",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PolicyManagerServer).Rollback(ctx, req.(*RollbackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _PolicyManager_GetDiscoverRequests_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DiscoverRequestsRequest)
 	if err := dec(in); err != nil {
@@ -1461,6 +2160,10 @@ var _PolicyManager_serviceDesc = grpc.ServiceDesc{
 			MethodName: "CreateFunction",
 			Handler:    _PolicyManager_CreateFunction_Handler,
 		},
+		{
+			MethodName: "UpdateFunction",
+			Handler:    _PolicyManager_UpdateFunction_Handler,
+		},
 		{
 			MethodName: "QueryFunctions",
 			Handler:    _PolicyManager_QueryFunctions_Handler,
@@ -1473,6 +2176,10 @@ var _PolicyManager_serviceDesc = grpc.ServiceDesc{
 			MethodName: "CreateService",
 			Handler:    _PolicyManager_CreateService_Handler,
 		},
+		{
+			MethodName: "UpdateService",
+			Handler:    _PolicyManager_UpdateService_Handler,
+		},
 		{
 			MethodName: "QueryServices",
 			Handler:    _PolicyManager_QueryServices_Handler,
@@ -1485,6 +2192,10 @@ var _PolicyManager_serviceDesc = grpc.ServiceDesc{
 			MethodName: "CreatePolicy",
 			Handler:    _PolicyManager_CreatePolicy_Handler,
 		},
+		{
+			MethodName: "UpdatePolicy",
+			Handler:    _PolicyManager_UpdatePolicy_Handler,
+		},
 		{
 			MethodName: "QueryPolicies",
 			Handler:    _PolicyManager_QueryPolicies_Handler,
@@ -1497,6 +2208,10 @@ var _PolicyManager_serviceDesc = grpc.ServiceDesc{
 			MethodName: "CreateRolePolicy",
 			Handler:    _PolicyManager_CreateRolePolicy_Handler,
 		},
+		{
+			MethodName: "UpdateRolePolicy",
+			Handler:    _PolicyManager_UpdateRolePolicy_Handler,
+		},
 		{
 			MethodName: "QueryRolePolicies",
 			Handler:    _PolicyManager_QueryRolePolicies_Handler,
@@ -1509,6 +2224,18 @@ var _PolicyManager_serviceDesc = grpc.ServiceDesc{
 			MethodName: "ListPolicyCounts",
 			Handler:    _PolicyManager_ListPolicyCounts_Handler,
 		},
+		{
+			MethodName: "ExecuteTransaction",
+			Handler:    _PolicyManager_ExecuteTransaction_Handler,
+		},
+		{
+			MethodName: "ListHistory",
+			Handler:    _PolicyManager_ListHistory_Handler,
+		},
+		{
+			MethodName: "Rollback",
+			Handler:    _PolicyManager_Rollback_Handler,
+		},
 		{
 			MethodName: "GetDiscoverRequests",
 			Handler:    _PolicyManager_GetDiscoverRequests_Handler,
@@ -1529,93 +2256,124 @@ var _PolicyManager_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("service.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 1400 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x58, 0xdb, 0x6e, 0xdb, 0x46,
-	0x13, 0x16, 0x25, 0xeb, 0x34, 0xb2, 0x64, 0x79, 0x6d, 0xc7, 0x8c, 0xfe, 0xe4, 0x87, 0xbb, 0x6d,
-	0x53, 0x37, 0x40, 0x65, 0x44, 0xe9, 0xc1, 0x68, 0x61, 0x14, 0x8e, 0xac, 0x04, 0x46, 0x6d, 0xd7,
-	0xa5, 0x9d, 0x8b, 0xf6, 0xc6, 0xa0, 0xa8, 0x75, 0xca, 0x86, 0x21, 0x59, 0x2e, 0x65, 0x44, 0x6f,
-	0xd1, 0xeb, 0xde, 0xf4, 0x01, 0xda, 0x77, 0xe9, 0x4d, 0x5f, 0xa6, 0x77, 0xc5, 0x1e, 0xb8, 0xdc,
-	0xa5, 0x14, 0x1f, 0x8a, 0x5e, 0x89, 0x3b, 0x33, 0x3b, 0xf3, 0xcd, 0xec, 0x37, 0xb3, 0xa4, 0xa0,
-	0x4d, 0x49, 0x72, 0xe5, 0x7b, 0xa4, 0x1f, 0x27, 0x51, 0x1a, 0xa1, 0x72, 0x3c, 0xc6, 0xaf, 0x61,
-	0xf3, 0xc0, 0xa7, 0x5e, 0x74, 0x45, 0x12, 0x87, 0xfc, 0x3c, 0x25, 0x34, 0xa5, 0xf2, 0x17, 0x6d,
-	0x41, 0x4b, 0xda, 0x9f, 0xb8, 0x6f, 0x88, 0x6d, 0x6d, 0x59, 0xdb, 0x4d, 0x47, 0x17, 0x21, 0x04,
-	0x4b, 0x81, 0x4b, 0x53, 0xbb, 0xbc, 0x65, 0x6d, 0x37, 0x1c, 0xfe, 0x8c, 0x7a, 0xd0, 0x48, 0xc8,
-	0x95, 0x4f, 0xfd, 0x28, 0xb4, 0x2b, 0x5b, 0xd6, 0x76, 0xc5, 0x51, 0x6b, 0x3c, 0x82, 0xe6, 0x69,
-	0xe2, 0x87, 0x9e, 0x1f, 0xbb, 0x01, 0xdb, 0x9c, 0xce, 0xe2, 0xcc, 0x2f, 0x7f, 0x66, 0xb2, 0x90,
-	0xc5, 0x2a, 0x0b, 0x19, 0x7b, 0x46, 0x5d, 0xa8, 0xf8, 0x93, 0x09, 0xf7, 0xd5, 0x74, 0xd8, 0x23,
-	0x0e, 0xa0, 0x7e, 0x36, 0x1d, 0xff, 0x44, 0xbc, 0x14, 0x7d, 0x02, 0x10, 0x67, 0x1e, 0xa9, 0x6d,
-	0x6d, 0x55, 0xb6, 0x5b, 0x83, 0x76, 0x3f, 0x1e, 0xf7, 0x55, 0x1c, 0x47, 0x33, 0x40, 0x0f, 0xa0,
-	0x99, 0x46, 0xaf, 0x49, 0x78, 0xce, 0x02, 0x8b, 0x20, 0xb9, 0x00, 0xad, 0x43, 0x95, 0x2f, 0x64,
-	0x2c, 0xb1, 0xc0, 0xbf, 0x94, 0xa1, 0x33, 0x8c, 0xc2, 0x94, 0xbc, 0x4d, 0xb3, 0xca, 0x7c, 0x08,
-	0x75, 0x2a, 0x00, 0x70, 0xf4, 0xad, 0x41, 0x8b, 0x85, 0x94, 0x98, 0x9c, 0x4c, 0x57, 0x2c, 0x60,
-	0x79, 0xbe, 0x80, 0xbc, 0x58, 0x34, 0x9a, 0x26, 0x1e, 0x91, 0x41, 0xd5, 0x1a, 0xdd, 0x83, 0x9a,
-	0xeb, 0xa5, 0xac, 0x8c, 0x4b, 0x5c, 0x23, 0x57, 0xe8, 0x19, 0x80, 0x9b, 0xa6, 0x89, 0x3f, 0x9e,
-	0xa6, 0x84, 0xda, 0x55, 0x9e, 0x32, 0x66, 0xf1, 0x4d, 0x90, 0xfd, 0x7d, 0x65, 0x34, 0x0a, 0xd3,
-	0x64, 0xe6, 0x68, 0xbb, 0x7a, 0x7b, 0xb0, 0x52, 0x50, 0xb3, 0x32, 0xbf, 0x26, 0x33, 0x79, 0x1a,
-	0xec, 0x91, 0x95, 0xe3, 0xca, 0x0d, 0xa6, 0x19, 0x70, 0xb1, 0xf8, 0xb2, 0xbc, 0x6b, 0xe1, 0x4b,
-	0xb0, 0xe7, 0x49, 0x43, 0xe3, 0x28, 0xa4, 0x04, 0xf5, 0x59, 0x4a, 0x42, 0x26, 0xcf, 0x03, 0xcd,
-	0x83, 0x73, 0x94, 0x8d, 0xc1, 0x97, 0x72, 0x81, 0x2f, 0xbb, 0xb0, 0xee, 0x10, 0x4a, 0xd2, 0x3b,
-	0x33, 0x13, 0x6f, 0xc2, 0x46, 0x61, 0xa7, 0x80, 0x87, 0x7f, 0xb7, 0x72, 0xc2, 0x9f, 0x46, 0x81,
-	0xef, 0xf9, 0xe4, 0x0e, 0x84, 0xff, 0x00, 0xda, 0x8a, 0x4d, 0x1a, 0x87, 0x4c, 0xa1, 0x61, 0xc5,
-	0x3d, 0x55, 0x0a, 0x56, 0xdc, 0x17, 0x86, 0x65, 0x25, 0x38, 0x9c, 0x4c, 0xe4, 0x29, 0x1b, 0x32,
-	0x7c, 0x91, 0x17, 0x3a, 0x07, 0x2b, 0x0b, 0xfd, 0x11, 0x34, 0x24, 0xb4, 0xac, 0xd0, 0x82, 0x85,
-	0x42, 0xe6, 0x28, 0xe5, 0xb5, 0x15, 0xfe, 0xcb, 0x82, 0xc6, 0xf3, 0x69, 0x28, 0x98, 0x95, 0x75,
-	0x9f, 0xa5, 0x75, 0xdf, 0x16, 0xb4, 0x26, 0x84, 0x7a, 0x89, 0x1f, 0xa7, 0xd9, 0xfe, 0xa6, 0xa3,
-	0x8b, 0x90, 0x0d, 0xf5, 0xcb, 0x69, 0xe8, 0xbd, 0x4c, 0x02, 0x99, 0x67, 0xb6, 0x64, 0x19, 0x06,
-	0x91, 0xe7, 0x06, 0xcf, 0xa5, 0x5a, 0x66, 0xa8, 0xcb, 0x50, 0x07, 0xca, 0x9e, 0x6b, 0x57, 0xb9,
-	0xa6, 0xec, 0xb9, 0xe8, 0x11, 0x74, 0x12, 0x42, 0xa7, 0x41, 0x3a, 0x74, 0xbd, 0x1f, 0xdd, 0x71,
-	0x40, 0xec, 0x1a, 0x1f, 0x2e, 0x05, 0x29, 0xeb, 0x64, 0x21, 0x39, 0x3f, 0x3f, 0xb2, 0xeb, 0x3c,
-	0xab, 0x5c, 0x80, 0x0f, 0x60, 0x3d, 0xcb, 0xea, 0xbb, 0x29, 0x49, 0x66, 0xd9, 0x09, 0x2f, 0xca,
-	0x90, 0xe1, 0xf7, 0x83, 0x94, 0x24, 0x54, 0x66, 0x97, 0x2d, 0xf1, 0x10, 0x36, 0x0a, 0x5e, 0x64,
-	0xe9, 0x1f, 0x43, 0xf3, 0x52, 0x2a, 0xb2, 0xda, 0x2f, 0xb3, 0xda, 0x67, 0xd6, 0x4e, 0xae, 0xc6,
-	0x3b, 0xd0, 0xde, 0x0f, 0x27, 0xa7, 0xf9, 0x0c, 0xfa, 0xff, 0xdc, 0xc8, 0x6a, 0xea, 0x33, 0x0a,
-	0xd7, 0xa1, 0x3a, 0x7a, 0x13, 0xa7, 0x33, 0x7c, 0x08, 0x9d, 0xec, 0x30, 0xaf, 0x81, 0xff, 0xbe,
-	0x1c, 0xa3, 0x0c, 0x7b, 0x67, 0xb0, 0xa2, 0x51, 0x80, 0x71, 0x51, 0xcc, 0x55, 0xfc, 0x12, 0xda,
-	0x9c, 0x3f, 0xb3, 0xdb, 0x53, 0x1d, 0x43, 0x2d, 0xe6, 0x5b, 0xb8, 0xe7, 0xd6, 0x00, 0xf8, 0x54,
-	0x15, 0x4e, 0xa4, 0x06, 0x7f, 0x0d, 0xeb, 0x32, 0x96, 0x59, 0x9f, 0xdb, 0x52, 0x13, 0x7f, 0x0c,
-	0x6b, 0xa6, 0x83, 0x77, 0xe6, 0x89, 0x03, 0x40, 0x22, 0xba, 0x61, 0x79, 0x73, 0x1e, 0x3d, 0x68,
-	0x08, 0xb4, 0x87, 0x07, 0xf2, 0x7c, 0xd5, 0x5a, 0x3f, 0xfa, 0x8a, 0x79, 0xf4, 0x7b, 0xb0, 0x66,
-	0x44, 0x93, 0x89, 0x3d, 0x92, 0xce, 0x7c, 0x95, 0x98, 0x5e, 0x16, 0xa5, 0xc3, 0x7f, 0x96, 0xa1,
-	0x26, 0x84, 0x8c, 0xe0, 0xfe, 0x44, 0x02, 0x2b, 0xfb, 0x93, 0x85, 0x57, 0x1c, 0x86, 0x1a, 0xb9,
-	0xbc, 0x64, 0xd7, 0x49, 0x85, 0x9f, 0x22, 0x77, 0x3a, 0xe2, 0x12, 0x47, 0x6a, 0xd0, 0x17, 0xd0,
-	0x8a, 0x49, 0xf2, 0xc6, 0xa7, 0x94, 0xb3, 0x6e, 0x89, 0x47, 0xdf, 0xc8, 0xa3, 0xf7, 0x4f, 0x95,
-	0xd6, 0xd1, 0x2d, 0xd1, 0x13, 0x83, 0x6f, 0xe2, 0xbe, 0x58, 0x65, 0xfb, 0x0c, 0x5a, 0x16, 0xaf,
-	0x49, 0x2f, 0x0a, 0x27, 0x3e, 0x6f, 0xf9, 0x9a, 0xb8, 0x26, 0x95, 0xa0, 0x47, 0x01, 0xf2, 0x58,
-	0xc6, 0x15, 0x66, 0x15, 0xae, 0xb0, 0x1d, 0x58, 0xcb, 0x9e, 0x2f, 0xc8, 0xdb, 0x38, 0x21, 0x94,
-	0xe6, 0x43, 0x04, 0x65, 0xaa, 0x91, 0xd2, 0xb0, 0x03, 0x71, 0x65, 0x5b, 0x55, 0x78, 0x63, 0x64,
-	0x4b, 0x4c, 0x60, 0xd5, 0x89, 0x02, 0x72, 0x57, 0x16, 0xf7, 0x01, 0x12, 0xb5, 0x4d, 0x32, 0xb9,
-	0xc3, 0x92, 0xd7, 0x9c, 0x69, 0x16, 0xf8, 0x2d, 0xdc, 0xcb, 0x35, 0x77, 0x64, 0x1a, 0x86, 0xe5,
-	0xdc, 0x93, 0x62, 0x9b, 0x21, 0xbb, 0x86, 0x71, 0xc7, 0xb0, 0x39, 0x17, 0x59, 0xb2, 0x6e, 0xa0,
-	0x39, 0xce, 0x99, 0x57, 0x4c, 0xc3, 0xb0, 0xc1, 0x7f, 0x5b, 0x00, 0xb9, 0xf2, 0x3f, 0x63, 0xe1,
-	0x3a, 0x54, 0x59, 0x18, 0xc1, 0xbf, 0xa6, 0x23, 0x16, 0x85, 0x91, 0x56, 0x2d, 0x8e, 0x34, 0x39,
-	0xac, 0xf9, 0x61, 0x53, 0xbb, 0xc6, 0xd5, 0xb9, 0x00, 0x3d, 0x81, 0xf5, 0x05, 0x2c, 0xa1, 0x76,
-	0x9d, 0x1b, 0xae, 0xcd, 0xd3, 0xa4, 0x40, 0xd0, 0x46, 0x81, 0xa0, 0xf8, 0x37, 0x0b, 0xea, 0x72,
-	0xac, 0xfc, 0xeb, 0x91, 0x69, 0xb4, 0x7a, 0xe5, 0xdd, 0xad, 0x8e, 0x9e, 0x42, 0x9b, 0x15, 0xe1,
-	0x42, 0x19, 0x2f, 0xdd, 0xe2, 0x74, 0x5e, 0xc1, 0x7d, 0x21, 0xdf, 0x0f, 0x27, 0xb9, 0xd1, 0x30,
-	0x9a, 0x86, 0x29, 0x65, 0x4c, 0x8b, 0xf3, 0x35, 0x47, 0x5e, 0x71, 0x74, 0x11, 0xda, 0x86, 0x95,
-	0xc4, 0xdc, 0x25, 0x2f, 0xf6, 0xa2, 0x18, 0xff, 0x61, 0xc1, 0x8a, 0xee, 0xfc, 0xd8, 0x8d, 0xd1,
-	0x1e, 0x34, 0x3c, 0xb6, 0x38, 0x76, 0x63, 0x49, 0xa5, 0xf7, 0xf2, 0xcc, 0x94, 0x59, 0x7f, 0x28,
-	0x6d, 0xc4, 0xdb, 0xa3, 0xda, 0xd2, 0xfb, 0x01, 0xda, 0x86, 0x6a, 0xc1, 0x9b, 0xe3, 0x53, 0xfd,
-	0xcd, 0xb1, 0x35, 0x78, 0x98, 0xbb, 0x5f, 0x90, 0xaf, 0xf6, 0x62, 0xf9, 0xf8, 0x21, 0xd4, 0x04,
-	0xe1, 0x50, 0x13, 0xaa, 0x2f, 0x9c, 0xfd, 0x93, 0xf3, 0x6e, 0x09, 0x35, 0x60, 0xe9, 0x60, 0x74,
-	0xf2, 0x7d, 0xd7, 0x7a, 0xbc, 0x03, 0x2d, 0xed, 0xa0, 0xd0, 0x0a, 0xb4, 0xf6, 0x4f, 0x4f, 0x8f,
-	0x0e, 0x87, 0xfb, 0xe7, 0x87, 0xdf, 0x9e, 0x74, 0x4b, 0x4c, 0xf0, 0xcd, 0xee, 0xd9, 0xc5, 0xf0,
-	0xe8, 0xe5, 0xd9, 0xf9, 0xc8, 0xe9, 0x5a, 0x83, 0x5f, 0x1b, 0xd9, 0xc5, 0x77, 0xec, 0x86, 0xee,
-	0x2b, 0x92, 0xa0, 0x3e, 0x74, 0x86, 0x09, 0x71, 0x53, 0xa2, 0xde, 0x7a, 0x8c, 0x9b, 0xbb, 0x67,
-	0xac, 0x70, 0x09, 0xbd, 0x80, 0x0e, 0x6f, 0xc6, 0x4c, 0x44, 0x91, 0xad, 0x5b, 0xe8, 0x23, 0xa2,
-	0x77, 0x7f, 0x81, 0x46, 0xbe, 0x76, 0x96, 0xd0, 0x2e, 0xac, 0x1c, 0x90, 0x80, 0xe4, 0x81, 0xaf,
-	0xf3, 0xd4, 0xe4, 0xad, 0xc7, 0xdf, 0x02, 0x4a, 0x68, 0x00, 0x6d, 0x01, 0x59, 0x71, 0x5a, 0xbf,
-	0x4c, 0xe5, 0x0e, 0xfd, 0x82, 0xc5, 0x25, 0x74, 0x00, 0x6d, 0xee, 0xf0, 0x2c, 0x7b, 0x09, 0xdc,
-	0xd4, 0xf4, 0x46, 0x28, 0x7b, 0x5e, 0xa1, 0x30, 0x7f, 0x0e, 0x1d, 0x81, 0xf9, 0x66, 0x37, 0x06,
-	0xe2, 0x1d, 0x58, 0x16, 0x88, 0xe5, 0xf4, 0x59, 0xd5, 0x3a, 0x47, 0xda, 0x6b, 0xcd, 0x84, 0x4b,
-	0xe8, 0x99, 0x84, 0x9b, 0x35, 0x08, 0xba, 0x97, 0xab, 0x8d, 0x30, 0x9b, 0x73, 0x72, 0x05, 0xf6,
-	0xb3, 0x0c, 0xec, 0x8d, 0x4e, 0x0c, 0xac, 0x5f, 0x41, 0x57, 0x60, 0xd5, 0xa6, 0xe5, 0x46, 0xa1,
-	0x79, 0xe5, 0xbe, 0x42, 0x4f, 0xe3, 0x12, 0x3a, 0x81, 0x55, 0xe1, 0x59, 0x6b, 0x6e, 0xd4, 0x33,
-	0xcd, 0x8c, 0xd0, 0xff, 0x5b, 0xa8, 0x53, 0x39, 0xec, 0x01, 0x12, 0x39, 0xdc, 0xda, 0xa1, 0x91,
-	0xcb, 0xa7, 0xd0, 0x3d, 0xf2, 0x69, 0x6a, 0x4c, 0x93, 0xdc, 0xa0, 0xb7, 0xb6, 0xa0, 0xcd, 0x71,
-	0x09, 0x39, 0xb0, 0xf6, 0x82, 0xa4, 0xc5, 0x0f, 0x3a, 0xc4, 0xa1, 0xbe, 0xe3, 0xbf, 0x81, 0xde,
-	0x83, 0xc5, 0x4a, 0x95, 0xc8, 0x89, 0xfc, 0xfe, 0x9a, 0xf3, 0xca, 0xe9, 0xb6, 0xe8, 0xa3, 0x4e,
-	0x74, 0xcf, 0xe2, 0x8f, 0xb6, 0x22, 0x46, 0x55, 0x19, 0x03, 0x63, 0xe1, 0x73, 0xce, 0xc4, 0x58,
-	0xfc, 0x7c, 0xc2, 0xa5, 0x71, 0x8d, 0xff, 0x0b, 0xf2, 0xf4, 0x9f, 0x00, 0x00, 0x00, 0xff, 0xff,
-	0x77, 0xb6, 0x2a, 0x0a, 0x16, 0x11, 0x00, 0x00,
+	// 1894 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb5, 0x19, 0x4d, 0x53, 0x1c, 0x45,
+	0x94, 0xdd, 0x65, 0xbf, 0xde, 0x66, 0x17, 0x68, 0x20, 0x6c, 0xd6, 0xc4, 0x8a, 0xa3, 0x89, 0x31,
+	0x96, 0x8b, 0x12, 0x3f, 0x28, 0xad, 0x68, 0x11, 0x20, 0x48, 0x49, 0x08, 0x0e, 0x60, 0x95, 0x5e,
+	0xa8, 0xd9, 0xd9, 0x86, 0x8c, 0x0c, 0x33, 0x93, 0x99, 0x59, 0x2a, 0xfc, 0x01, 0xcf, 0x1e, 0xbd,
+	0x7a, 0xb3, 0x4a, 0xbd, 0x79, 0xf4, 0xe0, 0x4f, 0xf1, 0xec, 0xc9, 0x9f, 0x60, 0x7f, 0x4f, 0xf7,
+	0xec, 0x02, 0x4b, 0xd4, 0xd3, 0x76, 0xbf, 0xf7, 0xfa, 0xf5, 0xfb, 0x7e, 0x6f, 0x7a, 0xa1, 0x99,
+	0xe0, 0xf8, 0xd4, 0x73, 0x71, 0x37, 0x8a, 0xc3, 0x34, 0x44, 0xc5, 0xa8, 0x67, 0x1d, 0xc3, 0xc2,
+	0x9a, 0x97, 0xb8, 0xe1, 0x29, 0x8e, 0x6d, 0xfc, 0x7c, 0x80, 0x93, 0x34, 0x11, 0xbf, 0xe8, 0x36,
+	0x34, 0x04, 0xfd, 0xb6, 0x73, 0x82, 0xdb, 0x85, 0xdb, 0x85, 0x7b, 0x75, 0x5b, 0x07, 0x21, 0x04,
+	0x93, 0xbe, 0x93, 0xa4, 0xed, 0x22, 0x41, 0xd5, 0x6c, 0xb6, 0x46, 0x1d, 0xa8, 0xc5, 0xf8, 0xd4,
+	0x4b, 0xbc, 0x30, 0x68, 0x97, 0x08, 0xbc, 0x64, 0xab, 0xbd, 0xb5, 0x0e, 0xf5, 0x9d, 0xd8, 0x0b,
+	0x5c, 0x2f, 0x72, 0x7c, 0x7a, 0x38, 0x3d, 0x8b, 0x24, 0x5f, 0xb6, 0xa6, 0xb0, 0x80, 0xde, 0x55,
+	0xe4, 0x30, 0xba, 0x46, 0xd3, 0x50, 0xf2, 0xfa, 0x7d, 0xc6, 0xab, 0x6e, 0xd3, 0xa5, 0xe5, 0x43,
+	0x75, 0x77, 0xd0, 0xfb, 0x16, 0xbb, 0x29, 0x7a, 0x07, 0x20, 0x92, 0x1c, 0x13, 0xc2, 0xaa, 0x74,
+	0xaf, 0xb1, 0xd4, 0xec, 0x46, 0xbd, 0xae, 0xba, 0xc7, 0xd6, 0x08, 0xd0, 0x4d, 0xa8, 0xa7, 0xe1,
+	0x31, 0x0e, 0xf6, 0xe8, 0xc5, 0xfc, 0x92, 0x0c, 0x80, 0xe6, 0xa0, 0xcc, 0x36, 0xe2, 0x2e, 0xbe,
+	0xb1, 0xbe, 0x2f, 0x42, 0x6b, 0x35, 0x0c, 0x52, 0xfc, 0x22, 0x95, 0x96, 0xb9, 0x03, 0xd5, 0x84,
+	0x0b, 0xc0, 0xa4, 0x6f, 0x2c, 0x35, 0xe8, 0x95, 0x42, 0x26, 0x5b, 0xe2, 0xf2, 0x06, 0x2c, 0x0e,
+	0x1b, 0x90, 0x19, 0x2b, 0x09, 0x07, 0xb1, 0x8b, 0xc5, 0xa5, 0x6a, 0x8f, 0xae, 0x43, 0xc5, 0x71,
+	0x53, 0x6a, 0xc6, 0x49, 0x86, 0x11, 0x3b, 0xf4, 0x08, 0xc0, 0x49, 0xd3, 0xd8, 0xeb, 0x0d, 0x52,
+	0x9c, 0xb4, 0xcb, 0x4c, 0x65, 0x8b, 0xde, 0x6f, 0x0a, 0xd9, 0x5d, 0x51, 0x44, 0xeb, 0x41, 0x1a,
+	0x9f, 0xd9, 0xda, 0xa9, 0xce, 0x43, 0x98, 0xca, 0xa1, 0xa9, 0x99, 0x8f, 0xf1, 0x99, 0xf0, 0x06,
+	0x5d, 0x52, 0x73, 0x9c, 0x3a, 0xfe, 0x40, 0x0a, 0xce, 0x37, 0x1f, 0x17, 0x97, 0x0b, 0xd6, 0x21,
+	0xb4, 0x87, 0x83, 0x26, 0x89, 0xc2, 0x20, 0xc1, 0xa8, 0x4b, 0x55, 0xe2, 0x30, 0xe1, 0x0f, 0x34,
+	0x2c, 0x9c, 0xad, 0x68, 0x8c, 0x78, 0x29, 0xe6, 0xe2, 0x65, 0x19, 0xe6, 0x08, 0x5f, 0x9c, 0x5e,
+	0x39, 0x32, 0xad, 0x05, 0x98, 0xcf, 0x9d, 0xe4, 0xe2, 0x59, 0x3f, 0x17, 0xb2, 0x80, 0xdf, 0x09,
+	0x7d, 0xcf, 0xf5, 0xf0, 0x15, 0x02, 0xfe, 0x0d, 0x68, 0xaa, 0x68, 0xd2, 0x62, 0xc8, 0x04, 0x1a,
+	0x54, 0x8c, 0x53, 0x29, 0x47, 0xc5, 0x78, 0x59, 0x70, 0x4d, 0x01, 0x36, 0x49, 0x80, 0x73, 0x2f,
+	0x1b, 0x30, 0xeb, 0x20, 0x33, 0x74, 0x26, 0xac, 0x30, 0xf4, 0x9b, 0x50, 0x13, 0xa2, 0x49, 0x43,
+	0xf3, 0x28, 0xe4, 0x30, 0x5b, 0x21, 0x2f, 0xb4, 0xf0, 0xdf, 0x05, 0xa8, 0x3d, 0x1e, 0x04, 0x3c,
+	0xb2, 0x64, 0xf6, 0x15, 0xb4, 0xec, 0x23, 0x36, 0xe9, 0xe3, 0xc4, 0x8d, 0xbd, 0x28, 0x95, 0xe7,
+	0x89, 0x4d, 0x34, 0x10, 0x6a, 0x43, 0xf5, 0x90, 0x70, 0xd8, 0x8f, 0x7d, 0xa1, 0xa7, 0xdc, 0x52,
+	0x0d, 0xfd, 0xd0, 0x75, 0xfc, 0xc7, 0x02, 0x2d, 0x34, 0xd4, 0x61, 0xa8, 0x05, 0x45, 0xd7, 0x21,
+	0x51, 0x4c, 0x31, 0x64, 0x85, 0xee, 0x42, 0x8b, 0x64, 0xc0, 0xc0, 0x4f, 0x57, 0x1d, 0xf7, 0x99,
+	0xd3, 0xf3, 0x71, 0xbb, 0xc2, 0x8a, 0x4b, 0x0e, 0x4a, 0x33, 0x99, 0x43, 0xf6, 0xf6, 0xb6, 0xda,
+	0x55, 0xa6, 0x55, 0x06, 0x30, 0x54, 0xae, 0xe5, 0x54, 0x5e, 0x83, 0x39, 0xa9, 0xf1, 0x97, 0x03,
+	0x4c, 0x12, 0x43, 0x78, 0x7f, 0x94, 0xf6, 0x54, 0x37, 0xcf, 0x4f, 0x71, 0x9c, 0x08, 0xcd, 0xe5,
+	0xd6, 0x5a, 0x85, 0xf9, 0x1c, 0x17, 0xe1, 0x96, 0xfb, 0x50, 0x3f, 0x14, 0x08, 0xe9, 0x97, 0x6b,
+	0xd4, 0x2f, 0x92, 0xda, 0xce, 0xd0, 0xd6, 0x22, 0x34, 0x57, 0x82, 0xfe, 0x4e, 0x56, 0x9f, 0x5e,
+	0x1d, 0x2a, 0x67, 0x75, 0xbd, 0x7e, 0x59, 0x55, 0x28, 0xaf, 0x9f, 0x44, 0xe9, 0x99, 0xf5, 0x57,
+	0x01, 0x5a, 0xd2, 0xd3, 0x17, 0xc8, 0xff, 0xba, 0xa8, 0xb1, 0x54, 0xf8, 0xd6, 0xd2, 0x94, 0x16,
+	0x1f, 0x34, 0x50, 0x45, 0xd1, 0xbd, 0xa0, 0x62, 0x93, 0x6c, 0x46, 0x6e, 0x78, 0xd2, 0xf3, 0x02,
+	0x2f, 0x38, 0x5a, 0xf1, 0x8f, 0xc2, 0xd8, 0x4b, 0x9f, 0x9d, 0x08, 0x47, 0x8e, 0xc0, 0xd0, 0xd0,
+	0xef, 0xe3, 0x43, 0x87, 0xb8, 0x61, 0xfd, 0xf0, 0x90, 0xd6, 0x47, 0xee, 0x59, 0x13, 0x48, 0xab,
+	0xb6, 0x56, 0xc2, 0x2a, 0x59, 0xd5, 0x56, 0x45, 0x49, 0xaf, 0x56, 0xd6, 0x3e, 0x34, 0x59, 0xf4,
+	0x9f, 0x8d, 0x9f, 0xa8, 0x16, 0x54, 0x22, 0x76, 0x84, 0xa9, 0xde, 0x58, 0x02, 0xd6, 0x13, 0x38,
+	0x13, 0x81, 0xb1, 0x3e, 0x83, 0x39, 0x61, 0x0c, 0xd3, 0x83, 0xe3, 0x26, 0x96, 0xf5, 0x16, 0xcc,
+	0x9a, 0x0c, 0xce, 0x75, 0x84, 0xf5, 0x53, 0x01, 0x10, 0xbf, 0xde, 0x20, 0xbd, 0x5c, 0x11, 0xe2,
+	0x1c, 0x2e, 0xee, 0xe6, 0x9a, 0x08, 0x41, 0xb5, 0xd7, 0xa3, 0xb3, 0x64, 0x44, 0x27, 0x2d, 0xdd,
+	0xbe, 0x77, 0xe2, 0xa5, 0xcc, 0x53, 0x65, 0x9b, 0x6f, 0xa8, 0x73, 0x5c, 0x52, 0x86, 0xbd, 0x60,
+	0x80, 0xf7, 0x58, 0x9f, 0x13, 0xce, 0x31, 0x80, 0x96, 0x0b, 0xb3, 0x86, 0xa4, 0xc2, 0x2a, 0x77,
+	0x85, 0x20, 0x9e, 0xb2, 0x8a, 0x6e, 0x53, 0x85, 0x1b, 0xbe, 0xa4, 0x38, 0xea, 0x92, 0x5f, 0x4b,
+	0x50, 0xe1, 0x47, 0x69, 0x05, 0xf0, 0xfa, 0x42, 0x75, 0xb2, 0x1a, 0x39, 0x03, 0x10, 0x77, 0x62,
+	0x1e, 0x4f, 0x25, 0x16, 0xc9, 0xec, 0x6a, 0x1e, 0x4c, 0xb6, 0xc0, 0xa0, 0x8f, 0xa0, 0x11, 0xe1,
+	0xf8, 0xc4, 0x4b, 0x12, 0x96, 0x7a, 0x93, 0x4c, 0xc6, 0xf9, 0x4c, 0xc6, 0xee, 0x8e, 0xc2, 0xda,
+	0x3a, 0x25, 0x7a, 0xcf, 0x48, 0x3a, 0xde, 0x50, 0x67, 0x58, 0x34, 0xea, 0xb9, 0x99, 0x9f, 0x23,
+	0x88, 0x3e, 0x7d, 0x8f, 0xd5, 0xc4, 0x0a, 0x9f, 0x23, 0x14, 0xc0, 0x48, 0xa8, 0x6a, 0x2e, 0xa1,
+	0xa8, 0x3f, 0x63, 0x8f, 0x66, 0xcb, 0x19, 0xab, 0x4c, 0x65, 0x5b, 0xed, 0x3b, 0x3f, 0x14, 0x00,
+	0x32, 0x21, 0x8d, 0xe1, 0xa0, 0x90, 0x1b, 0x0e, 0x16, 0x61, 0x56, 0xae, 0x0f, 0xf0, 0x8b, 0x88,
+	0xac, 0x93, 0xac, 0x3c, 0x23, 0x89, 0x5a, 0x57, 0x18, 0x1a, 0x2b, 0x8e, 0x28, 0x4a, 0x25, 0x56,
+	0x56, 0xe4, 0x96, 0xd4, 0x88, 0xa6, 0x62, 0x75, 0xe4, 0x87, 0x3d, 0x59, 0xa6, 0x25, 0x70, 0x83,
+	0xc0, 0x2c, 0x0c, 0x33, 0x76, 0xe8, 0xe3, 0xab, 0xa6, 0x61, 0x17, 0x20, 0x56, 0xc7, 0x44, 0x2a,
+	0xb6, 0xa8, 0x69, 0x35, 0x66, 0x1a, 0x85, 0xf5, 0x5b, 0x01, 0xae, 0x67, 0xa8, 0x2b, 0xa6, 0x0a,
+	0x69, 0x37, 0x19, 0x2b, 0x95, 0x2e, 0x06, 0xec, 0x7f, 0x4a, 0x99, 0x04, 0x16, 0x86, 0xa4, 0x16,
+	0x69, 0xb3, 0xa4, 0x09, 0x95, 0xa5, 0x4e, 0xde, 0x06, 0x06, 0xcd, 0x98, 0x29, 0xf4, 0x7b, 0x11,
+	0x20, 0x63, 0xf1, 0x9f, 0xa5, 0x11, 0xb1, 0x03, 0x15, 0x86, 0x27, 0x10, 0x99, 0xfa, 0xd8, 0x26,
+	0xd7, 0x98, 0xca, 0xf9, 0xc6, 0x24, 0xda, 0x31, 0x8b, 0x17, 0x5e, 0xd0, 0xeb, 0x76, 0x06, 0x20,
+	0x19, 0x36, 0x37, 0x22, 0x5a, 0x13, 0x92, 0x1c, 0x94, 0x70, 0x76, 0x38, 0x5c, 0x73, 0x19, 0x56,
+	0xbb, 0x28, 0xc3, 0xea, 0xb9, 0x0c, 0xbb, 0xc3, 0x26, 0x88, 0x2c, 0x9e, 0x93, 0x36, 0xb0, 0x6b,
+	0x9a, 0x7a, 0x40, 0x27, 0xd6, 0x1f, 0x45, 0xf2, 0x15, 0xc1, 0xa3, 0xe7, 0xe5, 0x5b, 0xa7, 0x5e,
+	0x14, 0x4b, 0x17, 0x14, 0xc5, 0x07, 0x24, 0xc7, 0x88, 0x1d, 0x0f, 0x14, 0xf1, 0xe4, 0x18, 0x61,
+	0xa0, 0x2b, 0x59, 0x1e, 0xab, 0x2f, 0x57, 0xc6, 0xef, 0xcb, 0xd5, 0xcb, 0xfb, 0x72, 0xed, 0xb2,
+	0xbe, 0xfc, 0x5d, 0x11, 0xea, 0x4f, 0x49, 0x25, 0x75, 0x98, 0x4f, 0x48, 0x00, 0x86, 0x91, 0x0c,
+	0xc0, 0x30, 0xa2, 0x46, 0x3d, 0xf6, 0x82, 0xbe, 0x0c, 0x40, 0xba, 0xce, 0x27, 0x71, 0x69, 0x38,
+	0x89, 0x79, 0x18, 0x4f, 0xaa, 0x30, 0xa6, 0x9f, 0x5a, 0x1c, 0xcd, 0x6c, 0x90, 0xeb, 0xc5, 0x12,
+	0xa7, 0xf5, 0xfb, 0xca, 0x79, 0xfd, 0x3e, 0x57, 0x8c, 0xaa, 0x97, 0x15, 0x23, 0x74, 0x0f, 0x6a,
+	0x72, 0x54, 0x63, 0x11, 0x98, 0x1f, 0xe4, 0x14, 0x96, 0x0c, 0x83, 0x68, 0x2f, 0x76, 0x82, 0x84,
+	0x97, 0x54, 0x59, 0xb1, 0x88, 0x35, 0x43, 0x69, 0x1d, 0xe3, 0xdb, 0x54, 0xd9, 0xcc, 0xd6, 0x08,
+	0xc8, 0x5c, 0x3a, 0x6b, 0x30, 0x11, 0x05, 0xe4, 0x8a, 0x5c, 0xfe, 0x2c, 0x42, 0xf3, 0x73, 0x2f,
+	0x49, 0x43, 0x5a, 0x83, 0xdc, 0x30, 0xee, 0x2b, 0x3f, 0x14, 0xce, 0xf7, 0x43, 0xf1, 0x3c, 0x3f,
+	0x94, 0x94, 0x1f, 0x48, 0xe1, 0x24, 0x1f, 0x21, 0x89, 0xfc, 0x1c, 0x2d, 0xd9, 0x72, 0x2b, 0xfc,
+	0x5e, 0x56, 0x7e, 0xa7, 0x99, 0xfb, 0xcc, 0x09, 0x8e, 0x70, 0xff, 0xd1, 0x99, 0xea, 0x8d, 0x12,
+	0xa0, 0x61, 0x57, 0x64, 0x10, 0x66, 0x00, 0xdd, 0xdb, 0xb5, 0xb1, 0xbc, 0x5d, 0x1f, 0xd3, 0xdb,
+	0x70, 0x25, 0x6f, 0x37, 0x2e, 0xf4, 0xf6, 0x57, 0xd0, 0x52, 0x16, 0x56, 0x13, 0xdf, 0xbf, 0x37,
+	0xb1, 0xf5, 0x29, 0x4c, 0x29, 0xbe, 0xc2, 0xf9, 0x6f, 0x43, 0x35, 0x66, 0x5e, 0x94, 0x9e, 0x67,
+	0x73, 0x89, 0xe1, 0x5f, 0x5b, 0x52, 0x58, 0xcf, 0x61, 0x8a, 0xe8, 0xe6, 0xf7, 0x1c, 0xf7, 0xf8,
+	0x3f, 0x15, 0xec, 0x7c, 0xdf, 0x5b, 0x47, 0x70, 0x83, 0x9b, 0x8f, 0x8c, 0x4a, 0x99, 0x5d, 0x57,
+	0xc3, 0x41, 0x40, 0xbe, 0xec, 0xc9, 0x45, 0x51, 0xb6, 0x67, 0x32, 0x94, 0x6c, 0x1d, 0x44, 0x6c,
+	0x3e, 0x15, 0x9b, 0xa7, 0xc4, 0x07, 0x6a, 0x1e, 0x6c, 0xfd, 0x52, 0x80, 0x29, 0x9d, 0xf9, 0x13,
+	0x27, 0x42, 0x0f, 0xa1, 0xe6, 0xd2, 0x0d, 0x59, 0x0b, 0xeb, 0xbc, 0x96, 0xc5, 0x81, 0x22, 0xeb,
+	0xae, 0x0a, 0x1a, 0xfe, 0x0a, 0xa2, 0x8e, 0x74, 0xbe, 0x81, 0xa6, 0x81, 0x1a, 0xf1, 0x02, 0xf2,
+	0x40, 0x7f, 0x01, 0x69, 0x2c, 0xdd, 0xca, 0xd8, 0x8f, 0xd0, 0x57, 0x7f, 0x20, 0xd9, 0x80, 0xba,
+	0x2a, 0x99, 0x23, 0xbb, 0x0b, 0xd2, 0xba, 0x8b, 0xf6, 0xf8, 0xe5, 0x13, 0xcf, 0x32, 0xc3, 0xd3,
+	0xd7, 0x34, 0xb2, 0xbe, 0x7f, 0x0b, 0x2a, 0xa2, 0x36, 0xd7, 0xa1, 0xbc, 0x61, 0xaf, 0x6c, 0xef,
+	0x4d, 0x4f, 0xa0, 0x1a, 0x4c, 0xae, 0xad, 0x6f, 0x7f, 0x3d, 0x5d, 0xb8, 0xbf, 0x08, 0x0d, 0xad,
+	0x29, 0xa1, 0x29, 0x68, 0xac, 0xec, 0xec, 0x6c, 0x6d, 0xae, 0xae, 0xec, 0x6d, 0x3e, 0xdd, 0x26,
+	0x94, 0x04, 0xf0, 0xc5, 0xf2, 0xee, 0xc1, 0xea, 0xd6, 0xfe, 0xee, 0xde, 0xba, 0x3d, 0x5d, 0x58,
+	0xfa, 0xb1, 0x21, 0xbf, 0xa5, 0x9e, 0x38, 0x81, 0x73, 0x84, 0x63, 0x92, 0x27, 0xad, 0xd5, 0x18,
+	0x3b, 0x29, 0x56, 0xcf, 0x00, 0x46, 0xdc, 0x77, 0x8c, 0x9d, 0x35, 0x41, 0xe9, 0xf7, 0xa3, 0xfe,
+	0xf8, 0xf4, 0x1b, 0xd0, 0x62, 0x13, 0x91, 0x04, 0x25, 0xa8, 0xad, 0x53, 0xe8, 0x33, 0x5e, 0xe7,
+	0xc6, 0x08, 0x8c, 0x78, 0xb7, 0x99, 0x40, 0xcb, 0x30, 0xb5, 0x86, 0x7d, 0x9c, 0x5d, 0x7c, 0x11,
+	0xa7, 0x3a, 0x9b, 0x6c, 0xd8, 0xa7, 0xf2, 0x04, 0x99, 0xc1, 0x9a, 0x5c, 0x45, 0xd5, 0xef, 0xf5,
+	0xaa, 0x22, 0x4e, 0xe8, 0x95, 0x86, 0x9f, 0xe1, 0x6a, 0x5e, 0xe1, 0xcc, 0x1a, 0x34, 0x99, 0x10,
+	0xbb, 0xf2, 0xe5, 0x65, 0x41, 0xc3, 0x1b, 0xe2, 0xb5, 0x87, 0x11, 0x4a, 0xcf, 0x0f, 0xa1, 0xc5,
+	0xf5, 0xbc, 0x9c, 0x8d, 0xa1, 0xe5, 0x22, 0x5c, 0xe3, 0x5a, 0x8a, 0x82, 0x36, 0xa3, 0x15, 0x45,
+	0x41, 0xaf, 0xd5, 0x49, 0x7e, 0x80, 0xab, 0x38, 0xee, 0x81, 0x47, 0x42, 0x3f, 0x35, 0xa1, 0x5c,
+	0xcf, 0xd0, 0x86, 0x5c, 0x0b, 0x43, 0x70, 0xa5, 0xdd, 0x07, 0x52, 0xbb, 0x4b, 0x99, 0x18, 0xca,
+	0x7d, 0x02, 0xd3, 0x5c, 0x39, 0x6d, 0xe2, 0x9d, 0xcf, 0x55, 0x73, 0x71, 0x2e, 0x57, 0xe4, 0xf9,
+	0x61, 0xae, 0xe8, 0xcb, 0x1c, 0xde, 0x86, 0x19, 0x2e, 0x96, 0x31, 0x9a, 0x99, 0x64, 0x86, 0xdc,
+	0xaf, 0x8c, 0xc4, 0x29, 0x03, 0x3c, 0x04, 0xc4, 0x0d, 0x30, 0x36, 0x43, 0xc3, 0x10, 0xef, 0xc3,
+	0xf4, 0x16, 0x29, 0x0c, 0x46, 0xa1, 0xcd, 0x08, 0x3a, 0xb3, 0x23, 0x2a, 0x20, 0x4b, 0x42, 0xb4,
+	0xfe, 0x02, 0xbb, 0xa4, 0x1a, 0x69, 0x23, 0x06, 0xb7, 0xfc, 0xf0, 0xe0, 0xc2, 0xdd, 0x37, 0x62,
+	0x16, 0x61, 0x49, 0xd8, 0xa0, 0xd7, 0x8b, 0x0e, 0xc4, 0x93, 0xc2, 0x6c, 0x86, 0x5c, 0x84, 0x5c,
+	0x23, 0x23, 0x27, 0xdf, 0x85, 0x9a, 0xec, 0x4e, 0x68, 0x56, 0x68, 0xab, 0xf7, 0xaa, 0x8e, 0x39,
+	0xd4, 0x90, 0x13, 0x36, 0xcc, 0x6e, 0xe0, 0x34, 0xff, 0xd0, 0x8c, 0x98, 0x7d, 0xcf, 0xf9, 0xcf,
+	0xa2, 0x73, 0x73, 0x34, 0x52, 0x49, 0xb1, 0x2d, 0xde, 0x85, 0x87, 0xb8, 0xb2, 0x8c, 0x1c, 0xf5,
+	0xd8, 0xcc, 0x8b, 0xd2, 0xe8, 0xc7, 0xe4, 0xbc, 0x8c, 0xca, 0x9d, 0x86, 0x8c, 0xb9, 0x67, 0x66,
+	0x53, 0xc6, 0xfc, 0xb3, 0xae, 0x35, 0xd1, 0xab, 0xb0, 0x7f, 0x67, 0x1e, 0xfc, 0x03, 0xd0, 0xc8,
+	0x65, 0xbc, 0xae, 0x19, 0x00, 0x00,
 }
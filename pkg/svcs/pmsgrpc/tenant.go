@@ -0,0 +1,46 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package pmsgrpc
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/logging"
+	"github.com/teramoby/speedle-plus/pkg/svcs"
+	"github.com/teramoby/speedle-plus/pkg/svcs/pmsimpl"
+)
+
+type policyStoreKey struct{}
+
+// UnaryTenantInterceptor chooses the tenant of every call by the speedle-tenant metadata. The call is made on the
+// policy store of the tenant, and authorized against the admin policies of the tenant if enable-authz is set.
+func UnaryTenantInterceptor(tenants map[string]*pmsimpl.Tenant) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		name := svcs.TenantFromContext(ctx)
+		tenant, ok := tenants[name]
+		if !ok {
+			err := errors.Errorf(errors.EntityNotFound, "tenant %q is not found", name)
+			logging.WriteSimpleFailedAuditLog("[gRPC]"+info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:], nil, err.Error())
+			return nil, toGRPCStatus(err)
+		}
+		ctx = context.WithValue(ctx, policyStoreKey{}, tenant.PolicyStore)
+		if tenant.Authorizer != nil {
+			return UnaryAuthzInterceptor(tenant.Authorizer)(ctx, req, info, handler)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// store returns the policy store of the tenant of a call, which is the one of the service if no tenant is chosen
+func (impl *serviceImpl) store(ctx context.Context) pms.PolicyStoreManager {
+	if ps, ok := ctx.Value(policyStoreKey{}).(pms.PolicyStoreManager); ok {
+		return ps
+	}
+	return impl.policyStore
+}
@@ -0,0 +1,188 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package pmsimpl
+
+import (
+	"crypto/x509"
+	"strings"
+
+	adsapi "github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/assertion"
+	"github.com/teramoby/speedle-plus/pkg/cfg"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/eval"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// AdminService is the reserved service holding the policies which protect the policy management service.
+// Its resources are the management resources below, and its actions are the management actions below.
+// Administration of a service could be delegated by a policy with resource expression like "^/service/foo(/.*)?$".
+// The "/" and "%" in the names of services and functions are escaped in the resources, so a service named
+// "foo/policy" has the resource "/service/foo%2Fpolicy" rather than the one of the policies of service foo.
+const AdminService = "speedle-admin"
+
+// nameEscaper escapes the names in the admin resources
+var nameEscaper = strings.NewReplacer("%", "%25", "/", "%2F")
+
+// Actions of the management calls
+const (
+	ActionCreate   = "create"
+	ActionGet      = "get"
+	ActionList     = "list"
+	ActionUpdate   = "update"
+	ActionDelete   = "delete"
+	ActionRollback = "rollback"
+)
+
+// ServiceResource returns the admin resource of a service, or of all services if the name is empty
+func ServiceResource(serviceName string) string {
+	if len(serviceName) == 0 {
+		return "/service"
+	}
+	return "/service/" + nameEscaper.Replace(serviceName)
+}
+
+// PolicyResource returns the admin resource of the policies in a service
+func PolicyResource(serviceName string) string {
+	return ServiceResource(serviceName) + "/policy"
+}
+
+// RolePolicyResource returns the admin resource of the role policies in a service
+func RolePolicyResource(serviceName string) string {
+	return ServiceResource(serviceName) + "/role-policy"
+}
+
+// FunctionResource returns the admin resource of a function, or of all functions if the name is empty
+func FunctionResource(functionName string) string {
+	if len(functionName) == 0 {
+		return "/function"
+	}
+	return "/function/" + nameEscaper.Replace(functionName)
+}
+
+// DiscoverResource returns the admin resource of the discover requests of a service, or of all services if the name is empty
+func DiscoverResource(serviceName string) string {
+	if len(serviceName) == 0 {
+		return "/discover"
+	}
+	return ServiceResource(serviceName) + "/discover"
+}
+
+// EntityResource returns the admin resource of an entity of kind, which is identified as in history and rollback
+func EntityResource(kind, serviceName, id string) string {
+	switch kind {
+	case pms.KindService:
+		return ServiceResource(id)
+	case pms.KindPolicy:
+		return PolicyResource(serviceName)
+	case pms.KindRolePolicy:
+		return RolePolicyResource(serviceName)
+	case pms.KindFunction:
+		return FunctionResource(id)
+	default:
+		return ""
+	}
+}
+
+// Authorizer authenticates the callers of the policy management service, and authorizes the management calls
+// against the policies of AdminService, which are evaluated by an embedded evaluator.
+type Authorizer struct {
+	Evaluator adsapi.PolicyEvaluator
+	Asserter  assertion.TokenAsserter
+}
+
+// NewAuthorizer creates an authorizer evaluating the admin policies in the policy store,
+// asserter is optional if callers are authenticated by client certificates only
+func NewAuthorizer(conf *cfg.Config, ps pms.PolicyStoreManager, asserter assertion.TokenAsserter) (*Authorizer, error) {
+	evaluator, err := eval.NewWithStore(conf, ps)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ConfigError, "failed to create the evaluator for admin policies")
+	}
+	// the role policies of the global service don't apply to the admin service, otherwise anyone delegated the
+	// administration of the global service could grant themselves the roles of the admin policies
+	if impl, ok := evaluator.(*eval.PolicyEvalImpl); ok {
+		impl.IsolatedServices = map[string]bool{AdminService: true}
+	}
+	return &Authorizer{Evaluator: evaluator, Asserter: asserter}, nil
+}
+
+/*
+Authenticate returns the subject of a caller:
+	1. If a token is given, it is asserted by the token asserter;
+	2. Otherwise the common name of the verified client certificate is taken as an entity principal;
+*/
+func (a *Authorizer) Authenticate(token, idpType string, certs []*x509.Certificate) (*adsapi.Subject, error) {
+	if len(token) != 0 {
+		if a.Asserter == nil {
+			return nil, errors.New(errors.Unauthenticated, "token is given but no token asserter is configured")
+		}
+		resp, err := a.Asserter.AssertToken(token, idpType, "", nil)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.Unauthenticated, "failed to assert the token")
+		}
+		if len(resp.Principals) == 0 {
+			return nil, errors.New(errors.Unauthenticated, "no principal is asserted from the token")
+		}
+		return &adsapi.Subject{Principals: resp.Principals}, nil
+	}
+	if len(certs) != 0 && len(certs[0].Subject.CommonName) != 0 {
+		return &adsapi.Subject{Principals: []*adsapi.Principal{
+			{Type: adsapi.PRINCIPAL_TYPE_ENTITY, Name: certs[0].Subject.CommonName},
+		}}, nil
+	}
+	return nil, errors.New(errors.Unauthenticated, "no token or client certificate is given")
+}
+
+// Authorize checks whether the subject is allowed to do action on resource of the admin service
+func (a *Authorizer) Authorize(subject *adsapi.Subject, resource, action string) error {
+	allowed, reason, err := a.Evaluator.IsAllowed(adsapi.RequestContext{
+		Subject:     subject,
+		ServiceName: AdminService,
+		Resource:    resource,
+		Action:      action,
+	})
+	if err != nil {
+		log.Errorf("Failed to authorize %s on %s, err: %v.", action, resource, err)
+		return errors.Wrapf(err, errors.PermissionDenied, "failed to authorize %s on %s", action, resource)
+	}
+	if !allowed {
+		return errors.Errorf(errors.PermissionDenied, "%s is not allowed to %s %s, reason: %s",
+			PrincipalNames(subject), action, resource, reason)
+	}
+	return nil
+}
+
+// AuthorizeOperations checks every operation of a transaction
+func (a *Authorizer) AuthorizeOperations(subject *adsapi.Subject, ops []*pms.Operation) error {
+	for _, op := range ops {
+		if op == nil {
+			continue
+		}
+		id := op.ID
+		switch {
+		case op.Service != nil && len(id) == 0:
+			id = op.Service.Name
+		case op.Function != nil && len(id) == 0:
+			id = op.Function.Name
+		}
+		if err := a.Authorize(subject, EntityResource(op.Kind, op.ServiceName, id), op.Op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PrincipalNames returns the names of the principals of a subject, which are recorded as the creator or updater of entities
+func PrincipalNames(subject *adsapi.Subject) string {
+	if subject == nil {
+		return ""
+	}
+	var names []string
+	for _, principal := range subject.Principals {
+		names = append(names, principal.Name)
+	}
+	return strings.Join(names, ",")
+}
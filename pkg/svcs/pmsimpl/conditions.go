@@ -0,0 +1,93 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package pmsimpl
+
+import (
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/eval/condition"
+)
+
+// checkServiceConditions checks the attribute schema of a service being created or updated, and the conditions of
+// its policies and role policies against it. The conditions of the existing service are checked if the service is
+// updated without policies or role policies.
+func checkServiceConditions(service *pms.Service, policyStore pms.PolicyStoreManager) error {
+	if err := condition.CheckSchema(service.Attributes); err != nil {
+		return errors.Wrap(err, errors.InvalidRequest, "invalid attribute schema")
+	}
+
+	policies, rolePolicies := service.Policies, service.RolePolicies
+	if len(policies) == 0 && len(rolePolicies) == 0 {
+		existing, err := policyStore.GetService(service.Name)
+		if err != nil && errors.Code(err) != errors.EntityNotFound {
+			return err
+		}
+		if existing != nil {
+			policies, rolePolicies = existing.Policies, existing.RolePolicies
+		}
+	}
+	if len(policies) == 0 && len(rolePolicies) == 0 {
+		return nil
+	}
+
+	functions, err := functionNames(policyStore)
+	if err != nil {
+		return err
+	}
+	for _, policy := range policies {
+		if err := condition.Check(policy.Condition, functions, service.Attributes); err != nil {
+			return conditionError("policy", policy.ID, policy.Condition, err)
+		}
+	}
+	for _, rolePolicy := range rolePolicies {
+		if err := condition.Check(rolePolicy.Condition, functions, service.Attributes); err != nil {
+			return conditionError("role policy", rolePolicy.ID, rolePolicy.Condition, err)
+		}
+	}
+	return nil
+}
+
+// checkCondition checks the condition of a policy or role policy being created or updated in a service, against the
+// attribute schema of the service if it exists
+func checkCondition(serviceName string, kind string, id string, cond string, policyStore pms.PolicyStoreManager) error {
+	if len(cond) == 0 {
+		return nil
+	}
+	var schema []*pms.Attribute
+	service, err := policyStore.GetService(serviceName)
+	if err != nil && errors.Code(err) != errors.EntityNotFound {
+		return err
+	}
+	if service != nil {
+		schema = service.Attributes
+	}
+	functions, err := functionNames(policyStore)
+	if err != nil {
+		return err
+	}
+	if err := condition.Check(cond, functions, schema); err != nil {
+		return conditionError(kind, id, cond, err)
+	}
+	return nil
+}
+
+// functionNames returns the names of the custom functions the conditions could call
+func functionNames(policyStore pms.PolicyStoreManager) ([]string, error) {
+	functions, err := policyStore.ListAllFunctions("")
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(functions))
+	for _, function := range functions {
+		names = append(names, function.Name)
+	}
+	return names, nil
+}
+
+func conditionError(kind string, id string, cond string, err error) error {
+	if len(id) == 0 {
+		return errors.Errorf(errors.InvalidRequest, "invalid condition %q of the %s: %v", cond, kind, err)
+	}
+	return errors.Errorf(errors.InvalidRequest, "invalid condition %q of %s %s: %v", cond, kind, id, err)
+}
@@ -0,0 +1,205 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package pmsimpl
+
+import (
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+)
+
+// MetaDataFunc returns the metadata of a restored entity from the original metadata, which is the one of
+// the existing entity, or the one kept in history if the entity does not exist any more
+type MetaDataFunc func(original map[string]string) map[string]string
+
+// GetHistoryManager returns the history of the policy store, if the store keeps history
+func GetHistoryManager(policyStore pms.PolicyStoreManager) (store.HistoryManager, error) {
+	historyManager, ok := policyStore.(store.HistoryManager)
+	if !ok {
+		return nil, errors.Errorf(errors.InvalidRequest, "%s store does not keep history", policyStore.Type())
+	}
+	return historyManager, nil
+}
+
+// exists turns the error of getting an entity into whether the entity exists
+func exists(err error) (bool, error) {
+	if err == nil {
+		return true, nil
+	}
+	if errors.Code(err) == errors.EntityNotFound {
+		return false, nil
+	}
+	return false, err
+}
+
+/*
+Rollback restores a service, policy, role policy or function to a version kept in its history:
+ 1. If the version is a deletion, the entity is deleted;
+ 2. If the entity exists, it is updated to the version;
+ 3. Otherwise it is created again from the version, a policy or role policy may get a new ID;
+
+A service is restored without its policies and role policies, which have their own history.
+*/
+func Rollback(policyStore pms.PolicyStoreManager, kind, serviceName, id string, version int64, metaData MetaDataFunc) (*pms.Operation, error) {
+	historyManager, err := GetHistoryManager(policyStore)
+	if err != nil {
+		return nil, err
+	}
+	record, err := historyManager.GetHistory(kind, serviceName, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	result := pms.Operation{Op: pms.OpDelete, Kind: kind, ServiceName: serviceName, ID: id}
+	switch kind {
+	case pms.KindService:
+		result.ServiceName = ""
+		if record.Op == pms.OpDelete {
+			return &result, policyStore.DeleteService(id)
+		}
+		return &result, rollbackService(policyStore, record, metaData, &result)
+	case pms.KindPolicy:
+		if record.Op == pms.OpDelete {
+			return &result, policyStore.DeletePolicy(serviceName, id)
+		}
+		return &result, rollbackPolicy(policyStore, record, metaData, &result)
+	case pms.KindRolePolicy:
+		if record.Op == pms.OpDelete {
+			return &result, policyStore.DeleteRolePolicy(serviceName, id)
+		}
+		return &result, rollbackRolePolicy(policyStore, record, metaData, &result)
+	case pms.KindFunction:
+		result.ServiceName = ""
+		if record.Op == pms.OpDelete {
+			return &result, policyStore.DeleteFunction(id)
+		}
+		return &result, rollbackFunction(policyStore, record, metaData, &result)
+	default:
+		return nil, errors.Errorf(errors.InvalidRequest, "unknown kind %q", kind)
+	}
+}
+
+func rollbackService(policyStore pms.PolicyStoreManager, record *pms.HistoryRecord, metaData MetaDataFunc, result *pms.Operation) error {
+	if record.Service == nil {
+		return errors.Errorf(errors.StoreError, "version %d of service %q has no content", record.Version, record.ID)
+	}
+	service := *record.Service
+	service.Revision = 0
+	existing, err := policyStore.GetService(record.ID)
+	found, err := exists(err)
+	if err != nil {
+		return err
+	}
+	if found {
+		if err := CheckServiceUpdate(&service, policyStore); err != nil {
+			return err
+		}
+		service.Metadata = metaData(existing.Metadata)
+		result.Op, err = pms.OpUpdate, policyStore.UpdateService(&service)
+	} else {
+		if err := CheckService(&service, policyStore); err != nil {
+			return err
+		}
+		service.Metadata = metaData(record.Service.Metadata)
+		result.Op, err = pms.OpCreate, policyStore.CreateService(&service)
+	}
+	if err != nil {
+		return err
+	}
+	result.Service, err = policyStore.GetService(record.ID)
+	return err
+}
+
+func rollbackPolicy(policyStore pms.PolicyStoreManager, record *pms.HistoryRecord, metaData MetaDataFunc, result *pms.Operation) error {
+	if record.Policy == nil {
+		return errors.Errorf(errors.StoreError, "version %d of policy %q has no content", record.Version, record.ID)
+	}
+	policy := *record.Policy
+	policy.ID, policy.Revision = record.ID, 0
+	existing, err := policyStore.GetPolicy(record.ServiceName, record.ID)
+	found, err := exists(err)
+	if err != nil {
+		return err
+	}
+	if found {
+		if err := CheckPolicyUpdate(record.ServiceName, &policy, policyStore); err != nil {
+			return err
+		}
+		policy.Metadata = metaData(existing.Metadata)
+		result.Op = pms.OpUpdate
+		result.Policy, err = policyStore.UpdatePolicy(record.ServiceName, &policy)
+	} else {
+		if err := CheckPolicy(record.ServiceName, &policy, policyStore); err != nil {
+			return err
+		}
+		policy.Metadata = metaData(record.Policy.Metadata)
+		result.Op = pms.OpCreate
+		result.Policy, err = policyStore.CreatePolicy(record.ServiceName, &policy)
+	}
+	if err != nil {
+		return err
+	}
+	result.ID = result.Policy.ID
+	return nil
+}
+
+func rollbackRolePolicy(policyStore pms.PolicyStoreManager, record *pms.HistoryRecord, metaData MetaDataFunc, result *pms.Operation) error {
+	if record.RolePolicy == nil {
+		return errors.Errorf(errors.StoreError, "version %d of role policy %q has no content", record.Version, record.ID)
+	}
+	rolePolicy := *record.RolePolicy
+	rolePolicy.ID, rolePolicy.Revision = record.ID, 0
+	existing, err := policyStore.GetRolePolicy(record.ServiceName, record.ID)
+	found, err := exists(err)
+	if err != nil {
+		return err
+	}
+	if found {
+		if err := CheckRolePolicyUpdate(record.ServiceName, &rolePolicy, policyStore); err != nil {
+			return err
+		}
+		rolePolicy.Metadata = metaData(existing.Metadata)
+		result.Op = pms.OpUpdate
+		result.RolePolicy, err = policyStore.UpdateRolePolicy(record.ServiceName, &rolePolicy)
+	} else {
+		if err := CheckRolePolicy(record.ServiceName, &rolePolicy, policyStore); err != nil {
+			return err
+		}
+		rolePolicy.Metadata = metaData(record.RolePolicy.Metadata)
+		result.Op = pms.OpCreate
+		result.RolePolicy, err = policyStore.CreateRolePolicy(record.ServiceName, &rolePolicy)
+	}
+	if err != nil {
+		return err
+	}
+	result.ID = result.RolePolicy.ID
+	return nil
+}
+
+func rollbackFunction(policyStore pms.PolicyStoreManager, record *pms.HistoryRecord, metaData MetaDataFunc, result *pms.Operation) error {
+	if record.Function == nil {
+		return errors.Errorf(errors.StoreError, "version %d of function %q has no content", record.Version, record.ID)
+	}
+	function := *record.Function
+	function.Revision = 0
+	existing, err := policyStore.GetFunction(record.ID)
+	found, err := exists(err)
+	if err != nil {
+		return err
+	}
+	if found {
+		// nothing is limited when updating a function, as the update handlers
+		function.Metadata = metaData(existing.Metadata)
+		result.Op = pms.OpUpdate
+		result.Function, err = policyStore.UpdateFunction(&function)
+	} else {
+		if err := CheckFunction(&function, policyStore); err != nil {
+			return err
+		}
+		function.Metadata = metaData(record.Function.Metadata)
+		result.Op = pms.OpCreate
+		result.Function, err = policyStore.CreateFunction(&function)
+	}
+	return err
+}
@@ -0,0 +1,136 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package pmsimpl
+
+import (
+	"strings"
+
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/analyzer"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+)
+
+// LintPolicies enables rejecting the services, policies and role policies being created or updated if the policy
+// analyzer finds errors in them, the warnings and the errors of the other policies aren't checked
+var LintPolicies = false
+
+// newPolicyID stands for the ID of a policy or role policy being created without an ID
+const newPolicyID = "(new)"
+
+// lintService checks the errors of a service being created or updated
+func lintService(service *pms.Service, policyStore pms.PolicyStoreManager) error {
+	if !LintPolicies {
+		return nil
+	}
+	functions, err := policyStore.ListAllFunctions("")
+	if err != nil {
+		return err
+	}
+	var messages []string
+	for _, finding := range analyzer.AnalyzeService(service, functions, nil) {
+		if finding.Severity == analyzer.SeverityError {
+			messages = append(messages, finding.Message)
+		}
+	}
+	return lintError(messages)
+}
+
+// lintPolicy checks the errors of a policy being created or updated in the existing service
+func lintPolicy(serviceName string, policy *pms.Policy, policyStore pms.PolicyStoreManager) error {
+	if !LintPolicies {
+		return nil
+	}
+	service, functions, err := lintContext(serviceName, policyStore)
+	if err != nil || service == nil {
+		return err
+	}
+	linted := *policy
+	if len(linted.ID) == 0 {
+		linted.ID = newPolicyID
+	}
+	policies := []*pms.Policy{}
+	for _, existing := range service.Policies {
+		if existing.ID != linted.ID {
+			policies = append(policies, existing)
+		}
+	}
+	service.Policies = append(policies, &linted)
+
+	var messages []string
+	for _, finding := range analyzer.AnalyzeService(service, functions, nil) {
+		if finding.Severity == analyzer.SeverityError && len(finding.Policies) > 0 && finding.Policies[0] == linted.ID {
+			messages = append(messages, finding.Message)
+		}
+	}
+	return lintError(messages)
+}
+
+// lintRolePolicy checks the errors of a role policy being created or updated in the existing service
+func lintRolePolicy(serviceName string, rolePolicy *pms.RolePolicy, policyStore pms.PolicyStoreManager) error {
+	if !LintPolicies {
+		return nil
+	}
+	service, functions, err := lintContext(serviceName, policyStore)
+	if err != nil || service == nil {
+		return err
+	}
+	linted := *rolePolicy
+	if len(linted.ID) == 0 {
+		linted.ID = newPolicyID
+	}
+	rolePolicies := []*pms.RolePolicy{}
+	for _, existing := range service.RolePolicies {
+		if existing.ID != linted.ID {
+			rolePolicies = append(rolePolicies, existing)
+		}
+	}
+	service.RolePolicies = append(rolePolicies, &linted)
+
+	var messages []string
+	for _, finding := range analyzer.AnalyzeService(service, functions, nil) {
+		if finding.Severity != analyzer.SeverityError {
+			continue
+		}
+		// a role cycle is about every role policy in it
+		if (finding.Kind == analyzer.KindRoleCycle && contains(finding.RolePolicies, linted.ID)) ||
+			(len(finding.RolePolicies) > 0 && finding.RolePolicies[0] == linted.ID) {
+			messages = append(messages, finding.Message)
+		}
+	}
+	return lintError(messages)
+}
+
+// lintContext returns a copy of the existing service and the functions, the service is nil if it doesn't exist,
+// which is reported by the policy store
+func lintContext(serviceName string, policyStore pms.PolicyStoreManager) (*pms.Service, []*pms.Function, error) {
+	existing, err := policyStore.GetService(serviceName)
+	if err != nil {
+		if errors.Code(err) == errors.EntityNotFound {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	functions, err := policyStore.ListAllFunctions("")
+	if err != nil {
+		return nil, nil, err
+	}
+	service := *existing
+	return &service, functions, nil
+}
+
+func lintError(messages []string) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	return errors.Errorf(errors.InvalidRequest, "rejected by the policy analyzer: %s", strings.Join(messages, "; "))
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -14,7 +14,7 @@ var (
 	//The value <= 0 mean don't check the max number/size
 	MaxServiceNum  = int64(-1) // Maximum number of service for a tenant
 	MaxPolicyNum   = int64(-1) // Maximum number of Policy + RolePolicy per tenant
-	MaxFunctionNum = int64(-1) //Maximum number of function defined by customer
+	MaxFunctionNum = int64(-1) //Maximum number of function defined by customer per tenant
 	MaxPolicySize  = int64(-1) // Maximum size in bytes for a Policy or RolePolicy
 )
 
@@ -23,8 +23,13 @@ Check the following items:
 	1. The maximum number of service;
 	2. The maximum number of Policy + RolePolicy;
 	3. The size of each Policy and RolePolicy;
+	4. The combining algorithm, the default effect, the attribute schema and the conditions;
 */
 func CheckService(service *pms.Service, policyStore pms.PolicyStoreManager) error {
+	if err := CheckServiceUpdate(service, policyStore); err != nil {
+		return err
+	}
+
 	// Check the number of the service
 	srvCount, err := policyStore.GetServiceCount()
 	if nil != err {
@@ -72,6 +77,8 @@ Check the following items:
 	1. The maximum number of Policy + RolePolicy;
 	2. The size of the Policy;
     3. If the effect field of policy is empty;
+	4. The condition compiles and matches the attribute schema of the service;
+	5. No error is found by the policy analyzer if LintPolicies is set;
 */
 func CheckPolicy(serviceName string, policy *pms.Policy, policyStore pms.PolicyStoreManager) error {
 	// Check global service
@@ -98,7 +105,10 @@ func CheckPolicy(serviceName string, policy *pms.Policy, policyStore pms.PolicyS
 		return err
 	}
 
-	return nil
+	if err := checkCondition(serviceName, "policy", policy.ID, policy.Condition, policyStore); err != nil {
+		return err
+	}
+	return lintPolicy(serviceName, policy, policyStore)
 }
 
 /*
@@ -106,6 +116,8 @@ Check the following items:
 	1. The maximum number of Policy + RolePolicy;
 	2. The size of the RolePolicy;
     3. If the effect field of RolePolicy is empty;
+	4. The condition compiles and matches the attribute schema of the service;
+	5. No error is found by the policy analyzer if LintPolicies is set;
 */
 func CheckRolePolicy(serviceName string, rolePolicy *pms.RolePolicy, policyStore pms.PolicyStoreManager) error {
 	if len(rolePolicy.Effect) <= 0 {
@@ -127,7 +139,103 @@ func CheckRolePolicy(serviceName string, rolePolicy *pms.RolePolicy, policyStore
 		return err
 	}
 
-	return nil
+	if err := checkCondition(serviceName, "role policy", rolePolicy.ID, rolePolicy.Condition, policyStore); err != nil {
+		return err
+	}
+	return lintRolePolicy(serviceName, rolePolicy, policyStore)
+}
+
+/*
+Check the following items when creating or updating a service:
+	1. The combining algorithm is supported;
+	2. The default effect is grant or deny, and it does not conflict with the combining algorithm;
+	3. The attribute schema is valid, and the conditions compile and match it;
+	4. No error is found by the policy analyzer if LintPolicies is set;
+*/
+func CheckServiceUpdate(service *pms.Service, policyStore pms.PolicyStoreManager) error {
+	if len(service.CombiningAlgorithm) > 0 {
+		supported := false
+		for _, algorithm := range pms.CombiningAlgorithms {
+			if service.CombiningAlgorithm == algorithm {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return errors.Errorf(errors.InvalidRequest, "unknown combining algorithm %q, it should be one of %v", service.CombiningAlgorithm, pms.CombiningAlgorithms)
+		}
+	}
+
+	switch service.DefaultEffect {
+	case "":
+	case pms.Grant:
+		if service.CombiningAlgorithm == pms.DenyUnlessPermit {
+			return errors.Errorf(errors.InvalidRequest, "default effect of combining algorithm %s could only be deny", pms.DenyUnlessPermit)
+		}
+	case pms.Deny:
+		if service.CombiningAlgorithm == pms.PermitUnlessDeny {
+			return errors.Errorf(errors.InvalidRequest, "default effect of combining algorithm %s could only be grant", pms.PermitUnlessDeny)
+		}
+	default:
+		return errors.Errorf(errors.InvalidRequest, "unknown default effect %q, it should be grant or deny", service.DefaultEffect)
+	}
+	if err := checkServiceConditions(service, policyStore); err != nil {
+		return err
+	}
+	return lintService(service, policyStore)
+}
+
+/*
+Check the following items when updating an existing Policy:
+	1. The size of the Policy;
+	2. If the effect field of policy is empty;
+	3. The condition compiles and matches the attribute schema of the service;
+	4. No error is found by the policy analyzer if LintPolicies is set;
+*/
+func CheckPolicyUpdate(serviceName string, policy *pms.Policy, policyStore pms.PolicyStoreManager) error {
+	// Check global service
+	if serviceName == pms.GlobalService {
+		return errors.New(errors.InvalidRequest, "global policy doesn't support authorization policies")
+	}
+
+	if len(policy.Effect) <= 0 {
+		return errors.New(errors.InvalidRequest, "no effect provided in policy.")
+	}
+
+	// Check the size of the Policy
+	sizeValid, err := checkMaxSize(*policy, MaxPolicySize)
+	if !sizeValid {
+		return err
+	}
+
+	if err := checkCondition(serviceName, "policy", policy.ID, policy.Condition, policyStore); err != nil {
+		return err
+	}
+	return lintPolicy(serviceName, policy, policyStore)
+}
+
+/*
+Check the following items when updating an existing RolePolicy:
+	1. The size of the RolePolicy;
+	2. If the effect field of RolePolicy is empty;
+	3. The condition compiles and matches the attribute schema of the service;
+	4. No error is found by the policy analyzer if LintPolicies is set;
+*/
+func CheckRolePolicyUpdate(serviceName string, rolePolicy *pms.RolePolicy, policyStore pms.PolicyStoreManager) error {
+	if len(rolePolicy.Effect) <= 0 {
+		return errors.New(errors.InvalidRequest, "no effect provided in role policy.")
+	}
+
+	// Check the size of the RolePolicy
+	sizeValid, err := checkMaxSize(*rolePolicy, MaxPolicySize)
+	if !sizeValid {
+		return err
+	}
+
+	if err := checkCondition(serviceName, "role policy", rolePolicy.ID, rolePolicy.Condition, policyStore); err != nil {
+		return err
+	}
+	return lintRolePolicy(serviceName, rolePolicy, policyStore)
 }
 
 // get the existing number of policy + rolePolicy
@@ -173,3 +281,97 @@ func CheckFunction(function *pms.Function, policyStore pms.PolicyStoreManager) e
 	}
 	return nil
 }
+
+/*
+Check the following items for each operation of a transaction:
+	1. The operation and the kind of entity are known;
+	2. The entity to create or update is provided;
+	3. The items checked when creating or updating the entity;
+*/
+func CheckOperations(ops []*pms.Operation, policyStore pms.PolicyStoreManager) error {
+	if len(ops) == 0 {
+		return errors.New(errors.InvalidRequest, "no operation provided in transaction.")
+	}
+	for i, op := range ops {
+		if err := checkOperation(op, policyStore); err != nil {
+			return errors.Wrapf(err, errors.Code(err), "invalid operation %d of the transaction", i)
+		}
+	}
+	return nil
+}
+
+func checkOperation(op *pms.Operation, policyStore pms.PolicyStoreManager) error {
+	if op == nil {
+		return errors.New(errors.InvalidRequest, "operation is empty")
+	}
+	switch op.Op {
+	case pms.OpCreate, pms.OpUpdate:
+	case pms.OpDelete:
+		if len(op.ID) == 0 {
+			return errors.Errorf(errors.InvalidRequest, "no id provided to delete %s", op.Kind)
+		}
+	default:
+		return errors.Errorf(errors.InvalidRequest, "unknown operation %q", op.Op)
+	}
+
+	switch op.Kind {
+	case pms.KindService:
+		if op.Op == pms.OpDelete {
+			return nil
+		}
+		if op.Service == nil || len(op.Service.Name) == 0 {
+			return errors.New(errors.InvalidRequest, "no service provided in operation.")
+		}
+		if op.Op == pms.OpCreate {
+			return CheckService(op.Service, policyStore)
+		}
+		return CheckServiceUpdate(op.Service, policyStore)
+	case pms.KindPolicy:
+		if len(op.ServiceName) == 0 {
+			return errors.New(errors.InvalidRequest, "no service name provided in operation.")
+		}
+		if op.Op == pms.OpDelete {
+			return nil
+		}
+		if op.Policy == nil {
+			return errors.New(errors.InvalidRequest, "no policy provided in operation.")
+		}
+		if op.Op == pms.OpCreate {
+			return CheckPolicy(op.ServiceName, op.Policy, policyStore)
+		}
+		if len(op.Policy.ID) == 0 {
+			return errors.New(errors.InvalidRequest, "no id provided to update policy.")
+		}
+		return CheckPolicyUpdate(op.ServiceName, op.Policy, policyStore)
+	case pms.KindRolePolicy:
+		if len(op.ServiceName) == 0 {
+			return errors.New(errors.InvalidRequest, "no service name provided in operation.")
+		}
+		if op.Op == pms.OpDelete {
+			return nil
+		}
+		if op.RolePolicy == nil {
+			return errors.New(errors.InvalidRequest, "no role policy provided in operation.")
+		}
+		if op.Op == pms.OpCreate {
+			return CheckRolePolicy(op.ServiceName, op.RolePolicy, policyStore)
+		}
+		if len(op.RolePolicy.ID) == 0 {
+			return errors.New(errors.InvalidRequest, "no id provided to update role policy.")
+		}
+		return CheckRolePolicyUpdate(op.ServiceName, op.RolePolicy, policyStore)
+	case pms.KindFunction:
+		if op.Op == pms.OpDelete {
+			return nil
+		}
+		if op.Function == nil || len(op.Function.Name) == 0 {
+			return errors.New(errors.InvalidRequest, "no function provided in operation.")
+		}
+		if op.Op == pms.OpCreate {
+			return CheckFunction(op.Function, policyStore)
+		}
+	default:
+		return errors.Errorf(errors.InvalidRequest, "unknown kind %q", op.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package pmsimpl
+
+import (
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/cfg"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/store"
+)
+
+// Tenant is a tenant served by PMS. It has a policy store of its own, so the limits of services, policies and
+// functions are checked against its own entities, and its own admin policies if enable-authz is set.
+type Tenant struct {
+	Name        string
+	PolicyStore pms.PolicyStoreManager
+	// Authorizer protects the management calls of the tenant, it is nil if enable-authz is not set
+	Authorizer *Authorizer
+}
+
+// NewTenants opens the policy stores of the default tenant and the tenants in conf, and returns the tenants by name.
+// newAuthorizer creates the authorizer of a tenant from its policy store, it is nil if enable-authz is not set.
+func NewTenants(conf *cfg.Config, newAuthorizer func(ps pms.PolicyStoreManager) (*Authorizer, error)) (map[string]*Tenant, error) {
+	tenants := make(map[string]*Tenant)
+	// the default tenant is opened first, so the tenants could share its embedded store, e.g. the embedded etcd
+	for _, name := range append([]string{store.DefaultTenant}, conf.Tenants...) {
+		if _, ok := tenants[name]; ok {
+			return nil, errors.Errorf(errors.ConfigError, "duplicate tenant %q", name)
+		}
+		ps, err := store.NewTenantStore(conf.StoreConfig.StoreType, conf.StoreConfig.StoreProps, name)
+		if err != nil {
+			return nil, err
+		}
+		tenant := &Tenant{Name: name, PolicyStore: ps}
+		if newAuthorizer != nil {
+			if tenant.Authorizer, err = newAuthorizer(ps); err != nil {
+				return nil, err
+			}
+		}
+		tenants[name] = tenant
+	}
+	return tenants, nil
+}
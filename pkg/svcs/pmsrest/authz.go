@@ -0,0 +1,157 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package pmsrest
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	adsapi "github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/assertion"
+	"github.com/teramoby/speedle-plus/pkg/httputils"
+	"github.com/teramoby/speedle-plus/pkg/logging"
+	"github.com/teramoby/speedle-plus/pkg/svcs"
+	"github.com/teramoby/speedle-plus/pkg/svcs/pmsimpl"
+)
+
+// permissionFunc returns the admin resource and action of a management call from the path variables
+type permissionFunc func(vars map[string]string) (resource string, action string)
+
+func servicePermission(action string) permissionFunc {
+	return func(vars map[string]string) (string, string) {
+		return pmsimpl.ServiceResource(vars["serviceName"]), action
+	}
+}
+
+func policyPermission(action string) permissionFunc {
+	return func(vars map[string]string) (string, string) {
+		return pmsimpl.PolicyResource(vars["serviceName"]), action
+	}
+}
+
+func rolePolicyPermission(action string) permissionFunc {
+	return func(vars map[string]string) (string, string) {
+		return pmsimpl.RolePolicyResource(vars["serviceName"]), action
+	}
+}
+
+func functionPermission(action string) permissionFunc {
+	return func(vars map[string]string) (string, string) {
+		return pmsimpl.FunctionResource(vars["functionName"]), action
+	}
+}
+
+func discoverPermission(action string) permissionFunc {
+	return func(vars map[string]string) (string, string) {
+		return pmsimpl.DiscoverResource(vars["serviceName"]), action
+	}
+}
+
+// routePermissions maps the routes to their admin resource and action. CreateService, ExecuteTransaction and
+// Simulate are authorized by their handlers, since the resources are in the request body.
+var routePermissions = map[string]permissionFunc{
+	"CreatePolicy":       policyPermission(pmsimpl.ActionCreate),
+	"DeletePolicies":     policyPermission(pmsimpl.ActionDelete),
+	"DeletePolicy":       policyPermission(pmsimpl.ActionDelete),
+	"GetPolicy":          policyPermission(pmsimpl.ActionGet),
+	"UpdatePolicy":       policyPermission(pmsimpl.ActionUpdate),
+	"PatchPolicy":        policyPermission(pmsimpl.ActionUpdate),
+	"ListPolicies":       policyPermission(pmsimpl.ActionList),
+	"CreateRolePolicy":   rolePolicyPermission(pmsimpl.ActionCreate),
+	"DeleteRolePolicies": rolePolicyPermission(pmsimpl.ActionDelete),
+	"DeleteRolePolicy":   rolePolicyPermission(pmsimpl.ActionDelete),
+	"GetRolePolicy":      rolePolicyPermission(pmsimpl.ActionGet),
+	"UpdateRolePolicy":   rolePolicyPermission(pmsimpl.ActionUpdate),
+	"PatchRolePolicy":    rolePolicyPermission(pmsimpl.ActionUpdate),
+	"ListRolePolicies":   rolePolicyPermission(pmsimpl.ActionList),
+
+	"DeleteService":    servicePermission(pmsimpl.ActionDelete),
+	"DeleteServices":   servicePermission(pmsimpl.ActionDelete),
+	"GetService":       servicePermission(pmsimpl.ActionGet),
+	"UpdateService":    servicePermission(pmsimpl.ActionUpdate),
+	"PatchService":     servicePermission(pmsimpl.ActionUpdate),
+	"ListServices":     servicePermission(pmsimpl.ActionList),
+	"ListPolicyCounts": servicePermission(pmsimpl.ActionList),
+
+	"CreateFunction":  functionPermission(pmsimpl.ActionCreate),
+	"DeleteFunction":  functionPermission(pmsimpl.ActionDelete),
+	"DeleteFunctions": functionPermission(pmsimpl.ActionDelete),
+	"GetFunction":     functionPermission(pmsimpl.ActionGet),
+	"UpdateFunction":  functionPermission(pmsimpl.ActionUpdate),
+	"PatchFunction":   functionPermission(pmsimpl.ActionUpdate),
+	"ListFunctions":   functionPermission(pmsimpl.ActionList),
+
+	"ListServiceHistory":    servicePermission(pmsimpl.ActionGet),
+	"RollbackService":       servicePermission(pmsimpl.ActionRollback),
+	"ListPolicyHistory":     policyPermission(pmsimpl.ActionGet),
+	"RollbackPolicy":        policyPermission(pmsimpl.ActionRollback),
+	"ListRolePolicyHistory": rolePolicyPermission(pmsimpl.ActionGet),
+	"RollbackRolePolicy":    rolePolicyPermission(pmsimpl.ActionRollback),
+	"ListFunctionHistory":   functionPermission(pmsimpl.ActionGet),
+	"RollbackFunction":      functionPermission(pmsimpl.ActionRollback),
+
+	"GetAllDiscoverRequests":   discoverPermission(pmsimpl.ActionGet),
+	"GetDiscoverRequests":      discoverPermission(pmsimpl.ActionGet),
+	"ResetDiscoverRequests":    discoverPermission(pmsimpl.ActionDelete),
+	"ResetAllDiscoverRequests": discoverPermission(pmsimpl.ActionDelete),
+	"GetDiscoverPolicies":      discoverPermission(pmsimpl.ActionGet),
+	"GetAllDiscoverPolicies":   discoverPermission(pmsimpl.ActionGet),
+}
+
+type subjectKey struct{}
+
+// authzHandler authenticates the caller and authorizes the route before calling handler.
+// The principals header is replaced by the authenticated principals, which are recorded as creator or updater.
+func (mgr *RESTService) authzHandler(routeName string, handler http.Handler) http.Handler {
+	if mgr.Authorizer == nil {
+		return handler
+	}
+	permission := routePermissions[routeName]
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var certs []*x509.Certificate
+		if r.TLS != nil && len(r.TLS.VerifiedChains) != 0 {
+			certs = r.TLS.VerifiedChains[0]
+		}
+		subject, err := mgr.Authorizer.Authenticate(r.Header.Get(assertion.TokenKey), r.Header.Get(assertion.IdpTypeKey), certs)
+		if err != nil {
+			httputils.HandleError(w, err)
+			logging.WriteSimpleFailedAuditLog(routeName, nil, err.Error())
+			return
+		}
+		r.Header.Set(svcs.PrincipalsHeader, pmsimpl.PrincipalNames(subject))
+		r = r.WithContext(context.WithValue(r.Context(), subjectKey{}, subject))
+
+		if permission != nil {
+			resource, action := permission(mux.Vars(r))
+			if err := mgr.Authorizer.Authorize(subject, resource, action); err != nil {
+				httputils.HandleError(w, err)
+				logging.WriteSimpleFailedAuditLog(routeName, nil, err.Error())
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// authorize checks the management call whose resource is known only by the handler
+func (mgr *RESTService) authorize(r *http.Request, resource, action string) error {
+	if mgr.Authorizer == nil {
+		return nil
+	}
+	subject, _ := r.Context().Value(subjectKey{}).(*adsapi.Subject)
+	return mgr.Authorizer.Authorize(subject, resource, action)
+}
+
+// authorizeOperations checks every operation of a transaction
+func (mgr *RESTService) authorizeOperations(r *http.Request, ops []*pms.Operation) error {
+	if mgr.Authorizer == nil {
+		return nil
+	}
+	subject, _ := r.Context().Value(subjectKey{}).(*adsapi.Subject)
+	return mgr.Authorizer.AuthorizeOperations(subject, ops)
+}
@@ -0,0 +1,163 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package pmsrest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pmsapi "github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/assertion"
+	"github.com/teramoby/speedle-plus/pkg/cfg"
+	"github.com/teramoby/speedle-plus/pkg/store"
+	"github.com/teramoby/speedle-plus/pkg/svcs"
+	"github.com/teramoby/speedle-plus/pkg/svcs/pmsimpl"
+)
+
+// testUser, who is asserted from any token but "test-token", administrates services s1 and global, and the policies
+// of s3 only. testUser
+// has granted the role admin of the admin policies to itself in global, which must not apply to the admin service.
+const authzStore = `{"services": [
+	{"name": "speedle-admin", "policies": [
+		{"id": "p1", "effect": "grant", "principals": [["user:testUser"]],
+		 "permissions": [
+			{"resourceExpression": "/service/s1(/.*)?", "actions": ["create", "get", "list", "update", "delete"]},
+			{"resourceExpression": "/service/global(/.*)?", "actions": ["create", "get", "list", "update", "delete"]},
+			{"resource": "/service/s3/policy", "actions": ["create", "delete"]}
+		 ]},
+		{"id": "p2", "effect": "grant", "principals": [["role:admin"]]}
+	]},
+	{"name": "global", "rolePolicies": [
+		{"id": "rp1", "effect": "grant", "roles": ["admin"], "principals": ["user:testUser"]}
+	]},
+	{"name": "s1"},
+	{"name": "s2"}
+]}`
+
+func newAuthzTestServer(t *testing.T) (*httptest.Server, func()) {
+	dir, err := ioutil.TempDir("", "pmsauthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	storeFile := filepath.Join(dir, "store.json")
+	if err := ioutil.WriteFile(storeFile, []byte(authzStore), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ps, err := store.NewStore(cfg.StorageTypeFile, map[string]interface{}{"FileLocation": storeFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	asserterServer := assertion.NewTestServer(t, nil)
+	asserter, err := assertion.NewAsserter(&assertion.AsserterConfig{Endpoint: asserterServer.URL + "/assert"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorizer, err := pmsimpl.NewAuthorizer(&cfg.Config{}, ps, asserter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	routers, err := NewRouterWithAuthorizer(ps, authorizer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(routers)
+	return server, func() {
+		server.Close()
+		asserterServer.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func sendAuthzTestRequest(t *testing.T, server *httptest.Server, method, path, token string, obj interface{}) (int, string) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(method, server.URL+svcs.PolicyMgmtPath+path, bytes.NewBuffer(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token) != 0 {
+		req.Header.Set(assertion.TokenKey, token)
+	}
+	// the principals header from callers should be ignored
+	req.Header.Set(svcs.PrincipalsHeader, "spoofed")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestAuthz(t *testing.T) {
+	server, cleanup := newAuthzTestServer(t)
+	defer cleanup()
+
+	policy := pmsapi.Policy{
+		Name:        "p1",
+		Effect:      "grant",
+		Permissions: []*pmsapi.Permission{{Resource: "/doc", Actions: []string{"read"}}},
+		Principals:  [][]string{{"user:alice"}},
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   interface{}
+		status int
+		code   string
+	}{
+		{"no token", "GET", "service/s1", "", nil, http.StatusUnauthorized, "SPDL-0005"},
+		{"invalid token", "GET", "service/s1", "test-token", nil, http.StatusUnauthorized, "SPDL-0005"},
+		{"get delegated service", "GET", "service/s1", "token", nil, http.StatusOK, ""},
+		{"get other service", "GET", "service/s2", "token", nil, http.StatusForbidden, "SPDL-0006"},
+		{"list services", "GET", "service", "token", nil, http.StatusForbidden, "SPDL-0006"},
+		{"create policy in delegated service", "POST", "service/s1/policy", "token", &policy, http.StatusCreated, ""},
+		{"create policy in other service", "POST", "service/s2/policy", "token", &policy, http.StatusForbidden, "SPDL-0006"},
+		{"create other service", "POST", "service", "token", &pmsapi.Service{Name: "s3"}, http.StatusForbidden, "SPDL-0006"},
+		{"create role policy in global service", "POST", "service/global/role-policy", "token", &pmsapi.RolePolicy{
+			Name: "rp2", Effect: "grant", Roles: []string{"admin"}, Principals: []string{"user:testUser"},
+		}, http.StatusCreated, ""},
+		{"get other service by global role", "GET", "service/s2", "token", nil, http.StatusForbidden, "SPDL-0006"},
+		{"create service named as policies", "POST", "service", "token", &pmsapi.Service{Name: "s3/policy"}, http.StatusForbidden, "SPDL-0006"},
+		{"rollback delegated service", "POST", "service/s1/rollback", "token", nil, http.StatusForbidden, "SPDL-0006"},
+		{"transaction on other service", "POST", "transaction", "token", []*pmsapi.Operation{
+			{Op: pmsapi.OpCreate, Kind: pmsapi.KindPolicy, ServiceName: "s1", Policy: &policy},
+			{Op: pmsapi.OpCreate, Kind: pmsapi.KindPolicy, ServiceName: "s2", Policy: &policy},
+		}, http.StatusForbidden, "SPDL-0006"},
+	}
+	for _, test := range tests {
+		status, body := sendAuthzTestRequest(t, server, test.method, test.path, test.token, test.body)
+		if status != test.status || !strings.Contains(body, test.code) {
+			t.Errorf("%s: expected status %d with %q, but got %d %s", test.name, test.status, test.code, status, body)
+		}
+	}
+
+	status, body := sendAuthzTestRequest(t, server, "GET", "service/s1/policy", "token", nil)
+	if status != http.StatusOK {
+		t.Fatalf("failed to list policies, status %d %s", status, body)
+	}
+	var policies []*pmsapi.Policy
+	if err := json.Unmarshal([]byte(body), &policies); err != nil {
+		t.Fatal(err)
+	}
+	if len(policies) != 1 || policies[0].Metadata["createby"] != "testUser" {
+		t.Errorf("expected one policy created by testUser, but got %s", body)
+	}
+}
@@ -0,0 +1,109 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package pmsrest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pmsapi "github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/store"
+	"github.com/teramoby/speedle-plus/pkg/svcs"
+)
+
+func TestCheckConditions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pmscondition")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ps, err := store.NewStore("file", map[string]interface{}{"FileLocation": filepath.Join(dir, "policies.json")})
+	if err != nil {
+		t.Fatal("fail to create store:", err)
+	}
+	if _, err := ps.CreateFunction(&pmsapi.Function{Name: "IsMember", FuncURL: "http://localhost/member"}); err != nil {
+		t.Fatal("fail to create function:", err)
+	}
+	router, err := NewRouter(ps)
+	if err != nil {
+		t.Fatal("fail to create router:", err)
+	}
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	post := func(path string, entity interface{}) (int, string) {
+		body, _ := json.Marshal(entity)
+		resp, err := http.Post(server.URL+svcs.PolicyMgmtPath+path, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal("fail to send request:", err)
+		}
+		defer resp.Body.Close()
+		content, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(content)
+	}
+
+	code, body := post("service", &pmsapi.Service{
+		Name:       "service1",
+		Type:       pmsapi.TypeApplication,
+		Attributes: []*pmsapi.Attribute{{Name: "age", Type: "integer"}},
+	})
+	if code != http.StatusBadRequest || !strings.Contains(body, "invalid attribute schema") {
+		t.Errorf("expected the service with invalid schema to be rejected, but got %d %s", code, body)
+	}
+
+	code, body = post("service", &pmsapi.Service{
+		Name:       "service1",
+		Type:       pmsapi.TypeApplication,
+		Attributes: []*pmsapi.Attribute{{Name: "age", Type: pmsapi.AttributeTypeNumeric}, {Name: "tags", Type: pmsapi.AttributeTypeString, List: true}},
+		Policies: []*pmsapi.Policy{{
+			Effect:      pmsapi.Grant,
+			Principals:  [][]string{{"user:alice"}},
+			Permissions: []*pmsapi.Permission{{Resource: "/books/1", Actions: []string{"read"}}},
+			Condition:   "age > 18 && IsMember(request_user, 'staff')",
+		}},
+	})
+	if code != http.StatusCreated {
+		t.Fatalf("expected the service to be created, but got %d %s", code, body)
+	}
+
+	for cond, expected := range map[string]string{
+		"'new' in tags && Sqrt(age) > 4": "",
+		"IsWeekday(request_time)":        "undefined function IsWeekday at column 1",
+		"age > 18 && (":                  "unexpected end of condition at column 14",
+		"age > 18 ||| age < 10":          "at column 10",
+		"age == 'adult'":                 "== can't be applied to age (numeric) and 'adult' (string) at column 5",
+		"level > 3":                      "undefined attribute level at column 1",
+	} {
+		code, body = post("service/service1/policy", &pmsapi.Policy{
+			Effect:      pmsapi.Grant,
+			Principals:  [][]string{{"user:bob"}},
+			Permissions: []*pmsapi.Permission{{Resource: "/books/1", Actions: []string{"read"}}},
+			Condition:   cond,
+		})
+		if len(expected) == 0 {
+			if code != http.StatusCreated {
+				t.Errorf("expected the policy with condition %q to be created, but got %d %s", cond, code, body)
+			}
+		} else if code != http.StatusBadRequest || !strings.Contains(body, expected) {
+			t.Errorf("expected the policy with condition %q to be rejected with %q, but got %d %s", cond, expected, code, body)
+		}
+	}
+
+	code, body = post("service/service1/role-policy", &pmsapi.RolePolicy{
+		Effect:     pmsapi.Grant,
+		Roles:      []string{"adult"},
+		Principals: []string{"user:bob"},
+		Condition:  "age >= '18'",
+	})
+	if code != http.StatusBadRequest || !strings.Contains(body, "can't be applied") {
+		t.Errorf("expected the role policy to be rejected, but got %d %s", code, body)
+	}
+}
@@ -0,0 +1,100 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package pmsrest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/httputils"
+	"github.com/teramoby/speedle-plus/pkg/logging"
+	"github.com/teramoby/speedle-plus/pkg/svcs/pmsimpl"
+)
+
+// parseHistoryTarget returns the kind, service name and ID of the entity in the request path, one of
+//
+//	service/{serviceName}
+//	service/{serviceName}/policy/{policyID}
+//	service/{serviceName}/role-policy/{rolePolicyID}
+//	function/{functionName}
+func parseHistoryTarget(r *http.Request) (kind string, serviceName string, id string) {
+	vars := mux.Vars(r)
+	serviceName = vars["serviceName"]
+	if functionName, ok := vars["functionName"]; ok {
+		return pms.KindFunction, "", functionName
+	}
+	if policyID, ok := vars["policyID"]; ok {
+		return pms.KindPolicy, serviceName, policyID
+	}
+	if rolePolicyID, ok := vars["rolePolicyID"]; ok {
+		return pms.KindRolePolicy, serviceName, rolePolicyID
+	}
+	return pms.KindService, "", serviceName
+}
+
+// ListHistory lists all versions of a service, policy, role policy or function, the oldest first
+func (mgr *RESTService) ListHistory(w http.ResponseWriter, r *http.Request) {
+	kind, serviceName, id := parseHistoryTarget(r)
+
+	// Audit contextual fields for request
+	ctxFields := log.Fields{
+		"kind":        kind,
+		"serviceName": serviceName,
+		"id":          id,
+	}
+
+	historyManager, err := pmsimpl.GetHistoryManager(mgr.PolicyStore)
+	if err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteFailedAuditLog("ListHistory", ctxFields, err.Error())
+		return
+	}
+	records, err := historyManager.ListHistory(kind, serviceName, id)
+	if err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteFailedAuditLog("ListHistory", ctxFields, err.Error())
+		return
+	}
+
+	logging.WriteSucceededAuditLog("ListHistory", ctxFields, nil)
+	httputils.SendOKResponse(w, records)
+}
+
+// Rollback restores a service, policy, role policy or function to the version in query parameter "version"
+func (mgr *RESTService) Rollback(w http.ResponseWriter, r *http.Request) {
+	kind, serviceName, id := parseHistoryTarget(r)
+	versionStr := r.URL.Query().Get("version")
+
+	// Audit contextual fields for request
+	ctxFields := log.Fields{
+		"kind":        kind,
+		"serviceName": serviceName,
+		"id":          id,
+		"version":     versionStr,
+	}
+
+	version, err := strconv.ParseInt(versionStr, 10, 64)
+	if err != nil || version <= 0 {
+		err = errors.Errorf(errors.InvalidRequest, "invalid version %q", versionStr)
+		httputils.HandleError(w, err)
+		logging.WriteFailedAuditLog("Rollback", ctxFields, err.Error())
+		return
+	}
+
+	result, err := pmsimpl.Rollback(mgr.PolicyStore, kind, serviceName, id, version, func(original map[string]string) map[string]string {
+		return getUpdateMetaData(r, original)
+	})
+	if err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteFailedAuditLog("Rollback", ctxFields, err.Error())
+		return
+	}
+
+	logging.WriteSucceededAuditLog("Rollback", ctxFields, map[string]interface{}{"result": result})
+	httputils.SendOKResponse(w, result)
+}
@@ -0,0 +1,97 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package pmsrest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pmsapi "github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/store"
+	"github.com/teramoby/speedle-plus/pkg/svcs"
+	"github.com/teramoby/speedle-plus/pkg/svcs/pmsimpl"
+)
+
+func TestLintPolicies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pmslint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ps, err := store.NewStore("file", map[string]interface{}{"FileLocation": filepath.Join(dir, "policies.json")})
+	if err != nil {
+		t.Fatal("fail to create store:", err)
+	}
+	err = ps.CreateService(&pmsapi.Service{
+		Name: "service1",
+		Type: pmsapi.TypeApplication,
+		Policies: []*pmsapi.Policy{{
+			ID:          "deny1",
+			Effect:      pmsapi.Deny,
+			Principals:  [][]string{{"user:alice"}},
+			Permissions: []*pmsapi.Permission{{ResourceExpression: "/books/.*"}},
+		}},
+	})
+	if err != nil {
+		t.Fatal("fail to create service:", err)
+	}
+	router, err := NewRouter(ps)
+	if err != nil {
+		t.Fatal("fail to create router:", err)
+	}
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	pmsimpl.LintPolicies = true
+	defer func() {
+		pmsimpl.LintPolicies = false
+	}()
+	createPolicy := func(policy *pmsapi.Policy) (int, string) {
+		body, _ := json.Marshal(policy)
+		resp, err := http.Post(server.URL+svcs.PolicyMgmtPath+"service/service1/policy", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal("fail to send request:", err)
+		}
+		defer resp.Body.Close()
+		content, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(content)
+	}
+
+	// the grant is shadowed by the deny
+	code, body := createPolicy(&pmsapi.Policy{
+		Effect:      pmsapi.Grant,
+		Principals:  [][]string{{"user:alice"}},
+		Permissions: []*pmsapi.Permission{{Resource: "/books/1", Actions: []string{"read"}}},
+	})
+	if code != http.StatusBadRequest || !strings.Contains(body, "never takes effect") {
+		t.Errorf("expected the shadowed policy to be rejected, but got %d %s", code, body)
+	}
+
+	// the condition calls an undefined function, which is rejected before the policy is analyzed
+	code, body = createPolicy(&pmsapi.Policy{
+		Effect:      pmsapi.Grant,
+		Principals:  [][]string{{"user:bob"}},
+		Permissions: []*pmsapi.Permission{{Resource: "/books/1", Actions: []string{"read"}}},
+		Condition:   "IsWeekday(request_time)",
+	})
+	if code != http.StatusBadRequest || !strings.Contains(body, "undefined function IsWeekday at column 1") {
+		t.Errorf("expected the policy with undefined function to be rejected, but got %d %s", code, body)
+	}
+
+	code, body = createPolicy(&pmsapi.Policy{
+		Effect:      pmsapi.Grant,
+		Principals:  [][]string{{"user:bob"}},
+		Permissions: []*pmsapi.Permission{{Resource: "/books/1", Actions: []string{"read"}}},
+	})
+	if code != http.StatusCreated {
+		t.Errorf("expected the policy to be created, but got %d %s", code, body)
+	}
+}
@@ -6,6 +6,7 @@ package pmsrest
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/teramoby/speedle-plus/pkg/errors"
@@ -25,6 +26,8 @@ import (
 
 type RESTService struct {
 	PolicyStore pms.PolicyStoreManager
+	// Authorizer protects the management calls, it is nil if enable-authz is not set
+	Authorizer *pmsimpl.Authorizer
 }
 
 type serviceRequestBody struct {
@@ -62,6 +65,20 @@ func ParseForFilters(r *http.Request) string {
 	return filterStr
 }
 
+// ParseForListOptions parses the query parameters filter, limit and continue of a list request
+func ParseForListOptions(r *http.Request) (*pms.ListOptions, error) {
+	query := r.URL.Query()
+	opts := pms.ListOptions{Filter: query.Get("filter"), Continue: query.Get("continue")}
+	if limit := query.Get("limit"); len(limit) != 0 {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return nil, errors.Errorf(errors.InvalidRequest, "invalid limit %q", limit)
+		}
+		opts.Limit = n
+	}
+	return &opts, nil
+}
+
 func decodeServiceRequest(r *http.Request) (*serviceRequestBody, error) {
 	decoder := json.NewDecoder(r.Body)
 	var request serviceRequestBody
@@ -96,7 +113,35 @@ func getCreateMetaData(r *http.Request) map[string]string {
 	return createMetaData
 }
 
+// getUpdateMetaData keeps the creation info of the original entity, and sets updateby and updatetime
+func getUpdateMetaData(r *http.Request, original map[string]string) map[string]string {
+	var updateMetaData = make(map[string]string)
+	for _, key := range []string{"createby", "createtime"} {
+		if value, ok := original[key]; ok {
+			updateMetaData[key] = value
+		}
+	}
+	updater := r.Header.Get(svcs.PrincipalsHeader)
+	if updater != "" { //set updateby meta data only when asserter returned updater info
+		updateMetaData["updateby"] = updater
+	}
+	updateMetaData["updatetime"] = time.Unix(time.Now().Unix(), 0).Format(time.RFC3339)
+	return updateMetaData
+}
+
 // Service management
+// applyIfMatch overrides the expected revision of an update with the one in If-Match header
+func applyIfMatch(r *http.Request, revision *int64) error {
+	ifMatch, ok, err := httputils.ParseIfMatch(r)
+	if err != nil {
+		return err
+	}
+	if ok {
+		*revision = ifMatch
+	}
+	return nil
+}
+
 func (mgr *RESTService) CreateService(w http.ResponseWriter, r *http.Request) {
 	var service pms.Service
 	err := decodeRequestBody(r, &service)
@@ -105,6 +150,12 @@ func (mgr *RESTService) CreateService(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := mgr.authorize(r, pmsimpl.ServiceResource(service.Name), pmsimpl.ActionCreate); err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog("CreateService", &service, err.Error())
+		return
+	}
+
 	err = pmsimpl.CheckService(&service, mgr.PolicyStore)
 	if err != nil {
 		httputils.HandleError(w, err)
@@ -141,6 +192,92 @@ func (mgr *RESTService) CreateService(w http.ResponseWriter, r *http.Request) {
 	httputils.SendCreatedResponse(w, &service)
 }
 
+// UpdateService replaces the type of an existing service
+func (mgr *RESTService) UpdateService(w http.ResponseWriter, r *http.Request) {
+	mgr.updateService(w, r, "UpdateService", false)
+}
+
+// PatchService merges the request body into an existing service
+func (mgr *RESTService) PatchService(w http.ResponseWriter, r *http.Request) {
+	mgr.updateService(w, r, "PatchService", true)
+}
+
+func (mgr *RESTService) updateService(w http.ResponseWriter, r *http.Request, op string, isPatch bool) {
+	serviceName, _ := ParseRequestURI(r)
+	if len(serviceName) == 0 {
+		httputils.SendBadRequestResponse(w, &httputils.ErrorResponse{
+			Error: "Invalid service name.",
+		})
+		return
+	}
+
+	existing, err := mgr.PolicyStore.GetService(serviceName)
+	if err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog(op, serviceName, err.Error())
+		return
+	}
+
+	var service pms.Service
+	if isPatch {
+		service.Name = existing.Name
+		service.Type = existing.Type
+		service.CombiningAlgorithm = existing.CombiningAlgorithm
+		service.DefaultEffect = existing.DefaultEffect
+		service.Attributes = existing.Attributes
+		service.Revision = existing.Revision
+	}
+	if err := decodeRequestBody(r, &service); err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog(op, serviceName, err.Error())
+		return
+	}
+	if err := applyIfMatch(r, &service.Revision); err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog(op, serviceName, err.Error())
+		return
+	}
+	if len(service.Name) == 0 {
+		service.Name = serviceName
+	}
+	if service.Name != serviceName {
+		err := errors.Errorf(errors.InvalidRequest, "service name %q in request body does not match %q", service.Name, serviceName)
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog(op, &service, err.Error())
+		return
+	}
+	if len(service.Policies) > 0 || len(service.RolePolicies) > 0 {
+		err := errors.New(errors.InvalidRequest, "policies and role policies can not be updated together with service")
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog(op, &service, err.Error())
+		return
+	}
+
+	if err := pmsimpl.CheckServiceUpdate(&service, mgr.PolicyStore); err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog(op, &service, err.Error())
+		return
+	}
+
+	service.Metadata = getUpdateMetaData(r, existing.Metadata)
+	if err := mgr.PolicyStore.UpdateService(&service); err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog(op, &service, err.Error())
+		return
+	}
+
+	ret, err := mgr.PolicyStore.GetService(serviceName)
+	if err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog(op, &service, err.Error())
+		return
+	}
+
+	logging.WriteSimpleSucceededAuditLog(op, &service, nil)
+	httputils.SetETag(w, ret.Revision)
+	httputils.SendOKResponse(w, ret)
+}
+
 func (mgr *RESTService) DeleteService(w http.ResponseWriter, r *http.Request) {
 	serviceName, _ := ParseRequestURI(r)
 	if len(serviceName) == 0 {
@@ -150,7 +287,7 @@ func (mgr *RESTService) DeleteService(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := mgr.PolicyStore.DeleteService(serviceName); err != nil {
+	if err := mgr.deleteEntity(r, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindService, ID: serviceName}); err != nil {
 		httputils.HandleError(w, err)
 		logging.WriteSimpleFailedAuditLog("DeleteService", serviceName, err.Error())
 		return
@@ -188,6 +325,7 @@ func (mgr *RESTService) GetService(w http.ResponseWriter, r *http.Request) {
 	}
 
 	logging.WriteSimpleSucceededAuditLog("GetService", serviceName, nil)
+	httputils.SetETag(w, service.Revision)
 	httputils.SendOKResponse(w, &service)
 }
 
@@ -261,6 +399,82 @@ func (mgr *RESTService) CreatePolicy(w http.ResponseWriter, r *http.Request) {
 	httputils.SendCreatedResponse(w, &ret)
 }
 
+// UpdatePolicy replaces an existing policy
+func (mgr *RESTService) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	mgr.updatePolicy(w, r, "UpdatePolicy", false)
+}
+
+// PatchPolicy merges the request body into an existing policy
+func (mgr *RESTService) PatchPolicy(w http.ResponseWriter, r *http.Request) {
+	mgr.updatePolicy(w, r, "PatchPolicy", true)
+}
+
+func (mgr *RESTService) updatePolicy(w http.ResponseWriter, r *http.Request, op string, isPatch bool) {
+	serviceName, policyIDStr := ParseRequestURI(r)
+	if len(serviceName) == 0 || len(policyIDStr) == 0 {
+		httputils.SendBadRequestResponse(w, &httputils.ErrorResponse{
+			Error: "Invalid service name or policy ID.",
+		})
+		return
+	}
+
+	// Audit contextual fields for request
+	ctxFields := log.Fields{
+		"serviceName": serviceName,
+		"policyId":    policyIDStr,
+	}
+
+	existing, err := mgr.PolicyStore.GetPolicy(serviceName, policyIDStr)
+	if err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteFailedAuditLog(op, ctxFields, err.Error())
+		return
+	}
+
+	var policy pms.Policy
+	if isPatch {
+		policy = *existing
+	}
+	if err := decodeRequestBody(r, &policy); err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteFailedAuditLog(op, ctxFields, err.Error())
+		return
+	}
+	if err := applyIfMatch(r, &policy.Revision); err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteFailedAuditLog(op, ctxFields, err.Error())
+		return
+	}
+	ctxFields["policy"] = &policy
+	if len(policy.ID) == 0 {
+		policy.ID = policyIDStr
+	}
+	if policy.ID != policyIDStr {
+		err := errors.Errorf(errors.InvalidRequest, "policy ID %q in request body does not match %q", policy.ID, policyIDStr)
+		httputils.HandleError(w, err)
+		logging.WriteFailedAuditLog(op, ctxFields, err.Error())
+		return
+	}
+
+	if err := pmsimpl.CheckPolicyUpdate(serviceName, &policy, mgr.PolicyStore); err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteFailedAuditLog(op, ctxFields, err.Error())
+		return
+	}
+
+	policy.Metadata = getUpdateMetaData(r, existing.Metadata)
+	ret, err := mgr.PolicyStore.UpdatePolicy(serviceName, &policy)
+	if err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteFailedAuditLog(op, ctxFields, err.Error())
+		return
+	}
+
+	logging.WriteSucceededAuditLog(op, ctxFields, nil)
+	httputils.SetETag(w, ret.Revision)
+	httputils.SendOKResponse(w, ret)
+}
+
 func (mgr *RESTService) DeletePolicies(w http.ResponseWriter, r *http.Request) {
 	serviceName, _ := ParseRequestURI(r)
 	if len(serviceName) == 0 {
@@ -295,7 +509,7 @@ func (mgr *RESTService) DeletePolicy(w http.ResponseWriter, r *http.Request) {
 		"policyId":    policyIDStr,
 	}
 
-	if err := mgr.PolicyStore.DeletePolicy(serviceName, policyIDStr); err != nil {
+	if err := mgr.deleteEntity(r, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindPolicy, ServiceName: serviceName, ID: policyIDStr}); err != nil {
 		httputils.HandleError(w, err)
 		logging.WriteFailedAuditLog("DeletePolicy", ctxFields, err.Error())
 		return
@@ -328,6 +542,7 @@ func (mgr *RESTService) GetPolicy(w http.ResponseWriter, r *http.Request) {
 	}
 
 	logging.WriteSucceededAuditLog("GetPolicy", ctxFields, map[string]interface{}{"policy": policy})
+	httputils.SetETag(w, policy.Revision)
 	httputils.SendOKResponse(w, &policy)
 }
 
@@ -339,8 +554,22 @@ func (mgr *RESTService) ListPolicies(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-	filters := ParseForFilters(r)
-	policies, err := mgr.PolicyStore.ListAllPolicies(serviceName, filters)
+	opts, err := ParseForListOptions(r)
+	if err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog("ListPolicies", serviceName, err.Error())
+		return
+	}
+	var policies []*pms.Policy
+	if opts.Limit == 0 && len(opts.Continue) == 0 {
+		policies, err = mgr.PolicyStore.ListAllPolicies(serviceName, opts.Filter)
+	} else {
+		var token string
+		policies, token, err = mgr.PolicyStore.ListPolicies(serviceName, opts)
+		if len(token) != 0 {
+			w.Header().Set(svcs.ContinueTokenHeader, token)
+		}
+	}
 	if err != nil {
 		httputils.HandleError(w, err)
 		logging.WriteSimpleFailedAuditLog("ListPolicies", serviceName, err.Error())
@@ -395,6 +624,82 @@ func (mgr *RESTService) CreateRolePolicy(w http.ResponseWriter, r *http.Request)
 	httputils.SendCreatedResponse(w, &ret)
 }
 
+// UpdateRolePolicy replaces an existing role policy
+func (mgr *RESTService) UpdateRolePolicy(w http.ResponseWriter, r *http.Request) {
+	mgr.updateRolePolicy(w, r, "UpdateRolePolicy", false)
+}
+
+// PatchRolePolicy merges the request body into an existing role policy
+func (mgr *RESTService) PatchRolePolicy(w http.ResponseWriter, r *http.Request) {
+	mgr.updateRolePolicy(w, r, "PatchRolePolicy", true)
+}
+
+func (mgr *RESTService) updateRolePolicy(w http.ResponseWriter, r *http.Request, op string, isPatch bool) {
+	serviceName, rolePolicyIDStr := ParseRequestURI(r)
+	if len(serviceName) == 0 || len(rolePolicyIDStr) == 0 {
+		httputils.SendBadRequestResponse(w, &httputils.ErrorResponse{
+			Error: "Invalid service name or role policy ID.",
+		})
+		return
+	}
+
+	// Audit log for request
+	ctxFields := log.Fields{
+		"serviceName":  serviceName,
+		"rolePolicyId": rolePolicyIDStr,
+	}
+
+	existing, err := mgr.PolicyStore.GetRolePolicy(serviceName, rolePolicyIDStr)
+	if err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteFailedAuditLog(op, ctxFields, err.Error())
+		return
+	}
+
+	var rolePolicy pms.RolePolicy
+	if isPatch {
+		rolePolicy = *existing
+	}
+	if err := decodeRequestBody(r, &rolePolicy); err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteFailedAuditLog(op, ctxFields, err.Error())
+		return
+	}
+	if err := applyIfMatch(r, &rolePolicy.Revision); err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteFailedAuditLog(op, ctxFields, err.Error())
+		return
+	}
+	ctxFields["rolePolicy"] = &rolePolicy
+	if len(rolePolicy.ID) == 0 {
+		rolePolicy.ID = rolePolicyIDStr
+	}
+	if rolePolicy.ID != rolePolicyIDStr {
+		err := errors.Errorf(errors.InvalidRequest, "role policy ID %q in request body does not match %q", rolePolicy.ID, rolePolicyIDStr)
+		httputils.HandleError(w, err)
+		logging.WriteFailedAuditLog(op, ctxFields, err.Error())
+		return
+	}
+
+	if err := pmsimpl.CheckRolePolicyUpdate(serviceName, &rolePolicy, mgr.PolicyStore); err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteFailedAuditLog(op, ctxFields, err.Error())
+		return
+	}
+
+	rolePolicy.Metadata = getUpdateMetaData(r, existing.Metadata)
+	ret, err := mgr.PolicyStore.UpdateRolePolicy(serviceName, &rolePolicy)
+	if err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteFailedAuditLog(op, ctxFields, err.Error())
+		return
+	}
+
+	logging.WriteSucceededAuditLog(op, ctxFields, nil)
+	httputils.SetETag(w, ret.Revision)
+	httputils.SendOKResponse(w, ret)
+}
+
 func (mgr *RESTService) DeleteRolePolicies(w http.ResponseWriter, r *http.Request) {
 	serviceName, _ := ParseRequestURI(r)
 	if len(serviceName) == 0 {
@@ -429,7 +734,7 @@ func (mgr *RESTService) DeleteRolePolicy(w http.ResponseWriter, r *http.Request)
 		"rolePolicyId": rolePolicyIDStr,
 	}
 
-	if err := mgr.PolicyStore.DeleteRolePolicy(serviceName, rolePolicyIDStr); err != nil {
+	if err := mgr.deleteEntity(r, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindRolePolicy, ServiceName: serviceName, ID: rolePolicyIDStr}); err != nil {
 		httputils.HandleError(w, err)
 		logging.WriteFailedAuditLog("DeleteRolePolicy", ctxFields, err.Error())
 		return
@@ -462,6 +767,7 @@ func (mgr *RESTService) GetRolePolicy(w http.ResponseWriter, r *http.Request) {
 	}
 
 	logging.WriteSucceededAuditLog("GetRolePolicy", ctxFields, map[string]interface{}{"rolePolicy": rolePolicy})
+	httputils.SetETag(w, rolePolicy.Revision)
 	httputils.SendOKResponse(w, &rolePolicy)
 }
 
@@ -473,8 +779,22 @@ func (mgr *RESTService) ListRolePolicies(w http.ResponseWriter, r *http.Request)
 		})
 		return
 	}
-	filters := ParseForFilters(r)
-	rolePolicies, err := mgr.PolicyStore.ListAllRolePolicies(serviceName, filters)
+	opts, err := ParseForListOptions(r)
+	if err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog("ListRolePolicies", serviceName, err.Error())
+		return
+	}
+	var rolePolicies []*pms.RolePolicy
+	if opts.Limit == 0 && len(opts.Continue) == 0 {
+		rolePolicies, err = mgr.PolicyStore.ListAllRolePolicies(serviceName, opts.Filter)
+	} else {
+		var token string
+		rolePolicies, token, err = mgr.PolicyStore.ListRolePolicies(serviceName, opts)
+		if len(token) != 0 {
+			w.Header().Set(svcs.ContinueTokenHeader, token)
+		}
+	}
 	if err != nil {
 		httputils.HandleError(w, err)
 		logging.WriteSimpleFailedAuditLog("ListRolePolicies", serviceName, err.Error())
@@ -518,6 +838,72 @@ func (mgr *RESTService) CreateFunction(w http.ResponseWriter, r *http.Request) {
 	httputils.SendCreatedResponse(w, ret)
 }
 
+// UpdateFunction replaces an existing function
+func (mgr *RESTService) UpdateFunction(w http.ResponseWriter, r *http.Request) {
+	mgr.updateFunction(w, r, "UpdateFunction", false)
+}
+
+// PatchFunction merges the request body into an existing function
+func (mgr *RESTService) PatchFunction(w http.ResponseWriter, r *http.Request) {
+	mgr.updateFunction(w, r, "PatchFunction", true)
+}
+
+func (mgr *RESTService) updateFunction(w http.ResponseWriter, r *http.Request, op string, isPatch bool) {
+	vars := mux.Vars(r)
+	funcName, ok := vars["functionName"]
+	if !ok || funcName == "" {
+		msg := "functionName is not specified"
+		httputils.SendBadRequestResponse(w, &httputils.ErrorResponse{
+			Error: msg,
+		})
+		logging.WriteSimpleFailedAuditLog(op, nil, msg)
+		return
+	}
+
+	existing, err := mgr.PolicyStore.GetFunction(funcName)
+	if err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog(op, funcName, err.Error())
+		return
+	}
+
+	var cf pms.Function
+	if isPatch {
+		cf = *existing
+	}
+	if err := decodeRequestBody(r, &cf); err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog(op, funcName, err.Error())
+		return
+	}
+	if err := applyIfMatch(r, &cf.Revision); err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog(op, funcName, err.Error())
+		return
+	}
+	if len(cf.Name) == 0 {
+		cf.Name = funcName
+	}
+	if cf.Name != funcName {
+		err := errors.Errorf(errors.InvalidRequest, "function name %q in request body does not match %q", cf.Name, funcName)
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog(op, &cf, err.Error())
+		return
+	}
+
+	cf.Metadata = getUpdateMetaData(r, existing.Metadata)
+	ret, err := mgr.PolicyStore.UpdateFunction(&cf)
+	if err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog(op, &cf, err.Error())
+		return
+	}
+
+	logging.WriteSimpleSucceededAuditLog(op, &cf, nil)
+	httputils.SetETag(w, ret.Revision)
+	httputils.SendOKResponse(w, ret)
+}
+
 func (mgr *RESTService) DeleteFunction(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	funcName, ok := vars["functionName"]
@@ -530,7 +916,7 @@ func (mgr *RESTService) DeleteFunction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := mgr.PolicyStore.DeleteFunction(funcName); err != nil {
+	if err := mgr.deleteEntity(r, &pms.Operation{Op: pms.OpDelete, Kind: pms.KindFunction, ID: funcName}); err != nil {
 		httputils.HandleError(w, err)
 		logging.WriteSimpleFailedAuditLog("DeleteFunction", funcName, err.Error())
 		return
@@ -572,11 +958,12 @@ func (mgr *RESTService) GetFunction(w http.ResponseWriter, r *http.Request) {
 	}
 
 	logging.WriteSimpleSucceededAuditLog("GetFunction", funcName, nil)
+	httputils.SetETag(w, cf.Revision)
 	httputils.SendOKResponse(w, cf)
 }
 
 func (mgr *RESTService) ListFunctions(w http.ResponseWriter, r *http.Request) {
-	functions, err := mgr.PolicyStore.ListAllFunctions("")
+	functions, err := mgr.PolicyStore.ListAllFunctions(ParseForFilters(r))
 	if err != nil {
 		httputils.HandleError(w, err)
 		logging.WriteSimpleFailedAuditLog("ListFunctions", nil, err.Error())
@@ -590,3 +977,107 @@ func (mgr *RESTService) ListFunctions(w http.ResponseWriter, r *http.Request) {
 	}
 	httputils.SendOKResponse(w, functions)
 }
+
+// ExecuteTransaction applies an ordered list of operations on services, policies, role policies
+// and functions, either all of them are applied or none
+func (mgr *RESTService) ExecuteTransaction(w http.ResponseWriter, r *http.Request) {
+	var ops []*pms.Operation
+	if err := decodeRequestBody(r, &ops); err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog("ExecuteTransaction", nil, err.Error())
+		return
+	}
+
+	if err := mgr.authorizeOperations(r, ops); err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog("ExecuteTransaction", ops, err.Error())
+		return
+	}
+
+	if err := pmsimpl.CheckOperations(ops, mgr.PolicyStore); err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog("ExecuteTransaction", ops, err.Error())
+		return
+	}
+
+	for _, op := range ops {
+		mgr.setOperationMetaData(r, op)
+	}
+
+	results, err := mgr.PolicyStore.ExecuteTransaction(ops)
+	if err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog("ExecuteTransaction", ops, err.Error())
+		return
+	}
+
+	logging.WriteSimpleSucceededAuditLog("ExecuteTransaction", ops, len(results))
+	httputils.SendOKResponse(w, results)
+}
+
+// setOperationMetaData sets createby and createtime of the entity to create,
+// or keeps the creation info of the existing entity and sets updateby and updatetime of the entity to update,
+// or sets who deletes the entity
+func (mgr *RESTService) setOperationMetaData(r *http.Request, op *pms.Operation) {
+	switch op.Op {
+	case pms.OpCreate:
+		metaData := getCreateMetaData(r)
+		switch op.Kind {
+		case pms.KindService:
+			op.Service.Metadata = metaData
+			for _, policy := range op.Service.Policies {
+				policy.Metadata = metaData
+			}
+			for _, rolePolicy := range op.Service.RolePolicies {
+				rolePolicy.Metadata = metaData
+			}
+		case pms.KindPolicy:
+			op.Policy.Metadata = metaData
+		case pms.KindRolePolicy:
+			op.RolePolicy.Metadata = metaData
+		case pms.KindFunction:
+			op.Function.Metadata = metaData
+		}
+	case pms.OpUpdate:
+		// the entity may be created by an earlier operation of the transaction, which has no creation info yet
+		switch op.Kind {
+		case pms.KindService:
+			var original map[string]string
+			if existing, err := mgr.PolicyStore.GetService(op.Service.Name); err == nil {
+				original = existing.Metadata
+			}
+			op.Service.Metadata = getUpdateMetaData(r, original)
+		case pms.KindPolicy:
+			var original map[string]string
+			if existing, err := mgr.PolicyStore.GetPolicy(op.ServiceName, op.Policy.ID); err == nil {
+				original = existing.Metadata
+			}
+			op.Policy.Metadata = getUpdateMetaData(r, original)
+		case pms.KindRolePolicy:
+			var original map[string]string
+			if existing, err := mgr.PolicyStore.GetRolePolicy(op.ServiceName, op.RolePolicy.ID); err == nil {
+				original = existing.Metadata
+			}
+			op.RolePolicy.Metadata = getUpdateMetaData(r, original)
+		case pms.KindFunction:
+			var original map[string]string
+			if existing, err := mgr.PolicyStore.GetFunction(op.Function.Name); err == nil {
+				original = existing.Metadata
+			}
+			op.Function.Metadata = getUpdateMetaData(r, original)
+		}
+	case pms.OpDelete:
+		op.ChangedBy = r.Header.Get(svcs.PrincipalsHeader)
+	}
+}
+
+// deleteEntity deletes a service, policy, role policy or function in a transaction, so who deletes it is kept in its history.
+// The entity is deleted only if its revision matches the If-Match header when the header is set.
+func (mgr *RESTService) deleteEntity(r *http.Request, op *pms.Operation) error {
+	if err := applyIfMatch(r, &op.Revision); err != nil {
+		return err
+	}
+	mgr.setOperationMetaData(r, op)
+	_, err := mgr.PolicyStore.ExecuteTransaction([]*pms.Operation{op})
+	return err
+}
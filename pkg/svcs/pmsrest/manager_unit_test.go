@@ -4,8 +4,13 @@ package pmsrest
 
 import (
 	"bytes"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
 
 	"os"
 	"testing"
@@ -22,6 +27,7 @@ import (
 	"github.com/teramoby/speedle-plus/pkg/store"
 	_ "github.com/teramoby/speedle-plus/pkg/store/file"
 	"github.com/teramoby/speedle-plus/pkg/svcs"
+	"github.com/teramoby/speedle-plus/pkg/svcs/pmsimpl"
 )
 
 var storeFile = "./fakestore.json"
@@ -81,6 +87,7 @@ func testMain(m *testing.M) int {
 		return 1
 	}
 	defer os.Remove(storeFile)
+	defer os.Remove("./speedle_history.json")
 	testserver, err = NewTestServer()
 	if err != nil {
 		log.Fatal("failed to start test server. error:", err)
@@ -264,3 +271,470 @@ func addPrincipalHeader(req *http.Request) {
 	data, _ := json.Marshal(principals)*/
 	req.Header.Add(svcs.PrincipalsHeader, creator)
 }
+
+func sendTestRequest(t *testing.T, method string, path string, obj interface{}) *http.Response {
+	return sendTestRequestWithHeaders(t, method, path, obj, nil)
+}
+
+func sendTestRequestWithHeaders(t *testing.T, method string, path string, obj interface{}, headers map[string]string) *http.Response {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal("failed to marsh request data")
+	}
+	req, err := http.NewRequest(method, testserver.URL+svcs.PolicyMgmtPath+path, bytes.NewBuffer(data))
+	if err != nil {
+		t.Fatal("failed to make test request")
+	}
+	addPrincipalHeader(req)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal("failed get response")
+	}
+	return resp
+}
+
+func decodeTestResponse(t *testing.T, resp *http.Response, obj interface{}) {
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal("failed to read response.")
+	}
+	if err := json.Unmarshal(body, obj); err != nil {
+		t.Fatal("failed to unmarsh response.")
+	}
+}
+
+func checkUpdateMetaData(metaData map[string]string, t *testing.T) {
+	checkCreateMetaData(metaData, t)
+	if len(metaData["updatetime"]) == 0 {
+		t.Fatal("updatetime is not set")
+	}
+	if metaData["updateby"] != creator {
+		t.Fatal("updateby field is not expected. updateby:", metaData["updateby"])
+	}
+}
+
+func TestUpdatePolicy(t *testing.T) {
+	resp := sendTestRequest(t, "POST", "service/fakeservice/policy", pmsapi.Policy{
+		Name:      "p1",
+		Effect:    "deny",
+		Condition: "a > 1",
+	})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatal("failed to create policy. status:", resp.StatusCode)
+	}
+	var created pmsapi.Policy
+	decodeTestResponse(t, resp, &created)
+
+	// PATCH keeps the fields which are not in request body
+	resp = sendTestRequest(t, "PATCH", "service/fakeservice/policy/"+created.ID, map[string]string{"effect": "grant"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("failed to patch policy. status:", resp.StatusCode)
+	}
+	var patched pmsapi.Policy
+	decodeTestResponse(t, resp, &patched)
+	if patched.ID != created.ID || patched.Name != "p1" || patched.Effect != "grant" || patched.Condition != "a > 1" {
+		t.Fatal("unexpected patched policy:", patched)
+	}
+	checkUpdateMetaData(patched.Metadata, t)
+
+	// PUT replaces the whole policy
+	resp = sendTestRequest(t, "PUT", "service/fakeservice/policy/"+created.ID, pmsapi.Policy{
+		Name:   "p2",
+		Effect: "deny",
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("failed to update policy. status:", resp.StatusCode)
+	}
+	var updated pmsapi.Policy
+	decodeTestResponse(t, resp, &updated)
+	if updated.ID != created.ID || updated.Name != "p2" || updated.Condition != "" {
+		t.Fatal("unexpected updated policy:", updated)
+	}
+	checkUpdateMetaData(updated.Metadata, t)
+
+	// ID in body must match the one in URL
+	resp = sendTestRequest(t, "PUT", "service/fakeservice/policy/"+created.ID, pmsapi.Policy{
+		ID:     "anotherID",
+		Effect: "deny",
+	})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("should fail to update policy with mismatched ID. status:", resp.StatusCode)
+	}
+
+	resp = sendTestRequest(t, "PUT", "service/fakeservice/policy/nonexistID", pmsapi.Policy{Effect: "deny"})
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatal("should fail to update a non-existing policy. status:", resp.StatusCode)
+	}
+}
+
+func TestUpdateRolePolicy(t *testing.T) {
+	resp := sendTestRequest(t, "POST", "service/fakeservice/role-policy", pmsapi.RolePolicy{
+		Name:   "rp1",
+		Effect: "grant",
+		Roles:  []string{"role1"},
+	})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatal("failed to create role policy. status:", resp.StatusCode)
+	}
+	var created pmsapi.RolePolicy
+	decodeTestResponse(t, resp, &created)
+
+	resp = sendTestRequest(t, "PATCH", "service/fakeservice/role-policy/"+created.ID, map[string][]string{"roles": {"role2"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("failed to patch role policy. status:", resp.StatusCode)
+	}
+	var patched pmsapi.RolePolicy
+	decodeTestResponse(t, resp, &patched)
+	if patched.Name != "rp1" || len(patched.Roles) != 1 || patched.Roles[0] != "role2" {
+		t.Fatal("unexpected patched role policy:", patched)
+	}
+	checkUpdateMetaData(patched.Metadata, t)
+}
+
+func TestUpdateService(t *testing.T) {
+	resp := sendTestRequest(t, "POST", "service", pmsapi.Service{Name: "service2", Type: pmsapi.TypeApplication})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatal("failed to create service. status:", resp.StatusCode)
+	}
+	resp.Body.Close()
+
+	resp = sendTestRequest(t, "PUT", "service/service2", pmsapi.Service{Type: pmsapi.TypeK8SCluster})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("failed to update service. status:", resp.StatusCode)
+	}
+	var updated pmsapi.Service
+	decodeTestResponse(t, resp, &updated)
+	if updated.Name != "service2" || updated.Type != pmsapi.TypeK8SCluster {
+		t.Fatal("unexpected updated service:", updated)
+	}
+	checkUpdateMetaData(updated.Metadata, t)
+
+	// policies can not be updated through service
+	resp = sendTestRequest(t, "PATCH", "service/service2", pmsapi.Service{Policies: []*pmsapi.Policy{{Effect: "grant"}}})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("should fail to patch service with policies. status:", resp.StatusCode)
+	}
+}
+
+func TestUpdateFunction(t *testing.T) {
+	resp := sendTestRequest(t, "POST", "function", pmsapi.Function{Name: "f2", FuncURL: "http://fakeurl", ResultTTL: 256})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatal("failed to create function. status:", resp.StatusCode)
+	}
+	resp.Body.Close()
+
+	resp = sendTestRequest(t, "PATCH", "function/f2", map[string]string{"funcURL": "http://fakeurl2"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("failed to patch function. status:", resp.StatusCode)
+	}
+	var patched pmsapi.Function
+	decodeTestResponse(t, resp, &patched)
+	if patched.FuncURL != "http://fakeurl2" || patched.ResultTTL != 256 {
+		t.Fatal("unexpected patched function:", patched)
+	}
+	checkUpdateMetaData(patched.Metadata, t)
+}
+
+func TestUpdatePolicyIfMatch(t *testing.T) {
+	resp := sendTestRequest(t, "POST", "service/fakeservice/policy", pmsapi.Policy{
+		Name:   "p3",
+		Effect: "grant",
+	})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatal("failed to create policy. status:", resp.StatusCode)
+	}
+	var created pmsapi.Policy
+	decodeTestResponse(t, resp, &created)
+
+	resp = sendTestRequest(t, "GET", "service/fakeservice/policy/"+created.ID, nil)
+	resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+	if len(etag) == 0 {
+		t.Fatal("ETag header is not set")
+	}
+
+	resp = sendTestRequestWithHeaders(t, "PATCH", "service/fakeservice/policy/"+created.ID, map[string]string{"effect": "deny"}, map[string]string{"If-Match": etag})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("failed to patch policy with current ETag. status:", resp.StatusCode)
+	}
+	if resp.Header.Get("ETag") == etag {
+		t.Fatal("ETag should change after update")
+	}
+
+	// the ETag got before the last update is stale now
+	resp = sendTestRequestWithHeaders(t, "PATCH", "service/fakeservice/policy/"+created.ID, map[string]string{"effect": "grant"}, map[string]string{"If-Match": etag})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatal("should fail to patch policy with stale ETag. status:", resp.StatusCode)
+	}
+
+	resp = sendTestRequestWithHeaders(t, "PATCH", "service/fakeservice/policy/"+created.ID, map[string]string{"effect": "grant"}, map[string]string{"If-Match": "bad"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("should fail to patch policy with invalid If-Match header. status:", resp.StatusCode)
+	}
+}
+
+func TestDeletePolicyIfMatch(t *testing.T) {
+	resp := sendTestRequest(t, "POST", "service/fakeservice/policy", pmsapi.Policy{Name: "p4", Effect: "grant"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatal("failed to create policy. status:", resp.StatusCode)
+	}
+	var created pmsapi.Policy
+	decodeTestResponse(t, resp, &created)
+	resp = sendTestRequest(t, "GET", "service/fakeservice/policy/"+created.ID, nil)
+	resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+	resp = sendTestRequest(t, "PATCH", "service/fakeservice/policy/"+created.ID, map[string]string{"effect": "deny"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("failed to patch policy. status:", resp.StatusCode)
+	}
+
+	// the policy changed since the ETag was got is not deleted
+	resp = sendTestRequestWithHeaders(t, "DELETE", "service/fakeservice/policy/"+created.ID, nil, map[string]string{"If-Match": etag})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatal("should fail to delete policy with stale ETag. status:", resp.StatusCode)
+	}
+	resp = sendTestRequestWithHeaders(t, "DELETE", "service/fakeservice/policy/"+created.ID, nil, map[string]string{"If-Match": "bad"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("should fail to delete policy with invalid If-Match header. status:", resp.StatusCode)
+	}
+	resp = sendTestRequest(t, "GET", "service/fakeservice/policy/"+created.ID, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("policy should not be deleted. status:", resp.StatusCode)
+	}
+	resp = sendTestRequestWithHeaders(t, "DELETE", "service/fakeservice/policy/"+created.ID, nil, map[string]string{"If-Match": resp.Header.Get("ETag")})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatal("failed to delete policy with current ETag. status:", resp.StatusCode)
+	}
+}
+
+func TestExecuteTransaction(t *testing.T) {
+	resp := sendTestRequest(t, "POST", "transaction", []*pmsapi.Operation{
+		{Op: pmsapi.OpCreate, Kind: pmsapi.KindService, Service: &pmsapi.Service{Name: "txservice", Type: pmsapi.TypeApplication}},
+		{Op: pmsapi.OpCreate, Kind: pmsapi.KindPolicy, ServiceName: "txservice", Policy: &pmsapi.Policy{Name: "p1", Effect: "grant"}},
+		{Op: pmsapi.OpCreate, Kind: pmsapi.KindFunction, Function: &pmsapi.Function{Name: "txfunc", FuncURL: "http://localhost:12345/txfunc"}},
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("failed to execute transaction. status:", resp.StatusCode)
+	}
+	var results []*pmsapi.Operation
+	decodeTestResponse(t, resp, &results)
+	if len(results) != 3 || results[1].Policy == nil {
+		t.Fatal("unexpected results of transaction:", results)
+	}
+	checkCreateMetaData(results[1].Policy.Metadata, t)
+
+	// the policy is not deleted since the transaction fails
+	resp = sendTestRequest(t, "POST", "transaction", []*pmsapi.Operation{
+		{Op: pmsapi.OpDelete, Kind: pmsapi.KindPolicy, ServiceName: "txservice", ID: results[1].ID},
+		{Op: pmsapi.OpDelete, Kind: pmsapi.KindFunction, ID: "nonexistfunc"},
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatal("should fail to delete non-existing function. status:", resp.StatusCode)
+	}
+	resp = sendTestRequest(t, "GET", "service/txservice/policy/"+results[1].ID, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("policy should not be deleted by a failed transaction. status:", resp.StatusCode)
+	}
+
+	resp = sendTestRequest(t, "POST", "transaction", []*pmsapi.Operation{
+		{Op: "rename", Kind: pmsapi.KindService, ID: "txservice"},
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("should fail to execute unknown operation. status:", resp.StatusCode)
+	}
+
+	resp = sendTestRequest(t, "POST", "transaction", []*pmsapi.Operation{
+		{Op: pmsapi.OpDelete, Kind: pmsapi.KindService, ID: "txservice"},
+		{Op: pmsapi.OpDelete, Kind: pmsapi.KindFunction, ID: "txfunc"},
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("failed to execute transaction. status:", resp.StatusCode)
+	}
+}
+
+func TestHistoryAndRollback(t *testing.T) {
+	resp := sendTestRequest(t, "POST", "service/fakeservice/policy", pmsapi.Policy{Name: "historyPolicy", Effect: "grant"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatal("failed to create policy. status:", resp.StatusCode)
+	}
+	var created pmsapi.Policy
+	decodeTestResponse(t, resp, &created)
+	resp = sendTestRequest(t, "PUT", "service/fakeservice/policy/"+created.ID, pmsapi.Policy{Name: "historyPolicy", Effect: "deny"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("failed to update policy. status:", resp.StatusCode)
+	}
+
+	resp = sendTestRequest(t, "GET", "service/fakeservice/policy/"+created.ID+"/history", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("failed to list history of policy. status:", resp.StatusCode)
+	}
+	var records []*pmsapi.HistoryRecord
+	decodeTestResponse(t, resp, &records)
+	if len(records) != 2 || records[0].Op != pmsapi.OpCreate || records[1].Op != pmsapi.OpUpdate {
+		t.Fatal("unexpected history of policy:", records)
+	}
+	if records[0].ChangedBy != creator || records[1].ChangedBy != creator {
+		t.Fatal("who changed the policy is not kept:", records[0].ChangedBy, records[1].ChangedBy)
+	}
+
+	// rollback an existing policy updates it
+	resp = sendTestRequest(t, "POST", fmt.Sprintf("service/fakeservice/policy/%s/rollback?version=%d", created.ID, records[0].Version), nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("failed to rollback policy. status:", resp.StatusCode)
+	}
+	var result pmsapi.Operation
+	decodeTestResponse(t, resp, &result)
+	if result.Op != pmsapi.OpUpdate || result.Policy == nil || result.Policy.Effect != "grant" {
+		t.Fatal("unexpected result of rollback:", result)
+	}
+	checkUpdateMetaData(result.Policy.Metadata, t)
+
+	// rollback a deleted policy creates it again
+	resp = sendTestRequest(t, "DELETE", "service/fakeservice/policy/"+created.ID, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatal("failed to delete policy. status:", resp.StatusCode)
+	}
+	resp = sendTestRequest(t, "GET", "service/fakeservice/policy/"+created.ID+"/history", nil)
+	var deleted []*pmsapi.HistoryRecord
+	decodeTestResponse(t, resp, &deleted)
+	if last := deleted[len(deleted)-1]; last.Op != pmsapi.OpDelete || last.ChangedBy != creator {
+		t.Fatal("who deleted the policy is not kept:", last.Op, last.ChangedBy)
+	}
+	resp = sendTestRequest(t, "POST", fmt.Sprintf("service/fakeservice/policy/%s/rollback?version=%d", created.ID, records[1].Version), nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("failed to rollback deleted policy. status:", resp.StatusCode)
+	}
+	result = pmsapi.Operation{}
+	decodeTestResponse(t, resp, &result)
+	if result.Op != pmsapi.OpCreate || result.Policy == nil || result.Policy.Effect != "deny" {
+		t.Fatal("unexpected result of rollback:", result)
+	}
+	resp = sendTestRequest(t, "GET", "service/fakeservice/policy/"+result.ID, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("policy should be created by rollback. status:", resp.StatusCode)
+	}
+
+	resp = sendTestRequest(t, "POST", "service/fakeservice/policy/"+created.ID+"/rollback?version=abc", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("should fail to rollback to an invalid version. status:", resp.StatusCode)
+	}
+	resp = sendTestRequest(t, "GET", "function/nonexistfunc/history", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatal("should fail to list history of a non-existing function. status:", resp.StatusCode)
+	}
+}
+
+func TestRollbackChecksUpdate(t *testing.T) {
+	resp := sendTestRequest(t, "POST", "service/fakeservice/policy", pmsapi.Policy{Name: "bigPolicy", Effect: "grant", Permissions: []*pmsapi.Permission{{Resource: strings.Repeat("r", 256), Actions: []string{"get"}}}})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatal("failed to create policy. status:", resp.StatusCode)
+	}
+	var created pmsapi.Policy
+	decodeTestResponse(t, resp, &created)
+	resp = sendTestRequest(t, "PUT", "service/fakeservice/policy/"+created.ID, pmsapi.Policy{Name: "bigPolicy", Effect: "grant"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("failed to update policy. status:", resp.StatusCode)
+	}
+	resp = sendTestRequest(t, "GET", "service/fakeservice/policy/"+created.ID+"/history", nil)
+	var records []*pmsapi.HistoryRecord
+	decodeTestResponse(t, resp, &records)
+
+	// the restored version is checked as an update, so it can't exceed the limits set since
+	defer func(max int64) { pmsimpl.MaxPolicySize = max }(pmsimpl.MaxPolicySize)
+	pmsimpl.MaxPolicySize = 200
+	resp = sendTestRequest(t, "POST", fmt.Sprintf("service/fakeservice/policy/%s/rollback?version=%d", created.ID, records[0].Version), nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatal("should fail to rollback to a version exceeding the maximum size. status:", resp.StatusCode)
+	}
+	resp = sendTestRequest(t, "GET", "service/fakeservice/policy/"+created.ID, nil)
+	var policy pmsapi.Policy
+	decodeTestResponse(t, resp, &policy)
+	if len(policy.Permissions) != 0 {
+		t.Fatal("policy should not be rolled back:", policy)
+	}
+}
+
+func TestListPoliciesPages(t *testing.T) {
+	resp := sendTestRequest(t, "POST", "service", pmsapi.Service{Name: "pagedservice", Type: pmsapi.TypeApplication})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatal("failed to create service. status:", resp.StatusCode)
+	}
+	defer func() {
+		sendTestRequest(t, "DELETE", "service/pagedservice", nil).Body.Close()
+	}()
+	for i := 0; i < 5; i++ {
+		effect := pmsapi.Grant
+		if i%2 == 1 {
+			effect = pmsapi.Deny
+		}
+		resp = sendTestRequest(t, "POST", "service/pagedservice/policy", pmsapi.Policy{Name: fmt.Sprintf("p%d", i), Effect: effect})
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatal("failed to create policy. status:", resp.StatusCode)
+		}
+	}
+
+	var names []string
+	query := "limit=2&filter=" + url.QueryEscape("effect eq grant and createtime pr")
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("too many pages:", names)
+		}
+		resp = sendTestRequest(t, "GET", "service/pagedservice/policy?"+query, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatal("failed to list policies. status:", resp.StatusCode)
+		}
+		token := resp.Header.Get(svcs.ContinueTokenHeader)
+		var policies []*pmsapi.Policy
+		decodeTestResponse(t, resp, &policies)
+		if len(policies) > 2 {
+			t.Fatal("expected at most 2 policies in a page, but got", len(policies))
+		}
+		for _, policy := range policies {
+			names = append(names, policy.Name)
+		}
+		if len(token) == 0 {
+			break
+		}
+		query = "limit=2&filter=" + url.QueryEscape("effect eq grant and createtime pr") + "&continue=" + url.QueryEscape(token)
+	}
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"p0", "p2", "p4"}) {
+		t.Fatal("unexpected policies in pages:", names)
+	}
+
+	for _, query := range []string{"limit=-1", "limit=abc", "filter=" + url.QueryEscape("owner eq alice"), "limit=1&continue=" + url.QueryEscape("not a token!")} {
+		resp = sendTestRequest(t, "GET", "service/pagedservice/policy?"+query, nil)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("should fail to list policies with %s. status: %d", query, resp.StatusCode)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/teramoby/speedle-plus/api/pms"
 	"github.com/teramoby/speedle-plus/pkg/svcs"
+	"github.com/teramoby/speedle-plus/pkg/svcs/pmsimpl"
 )
 
 type route struct {
@@ -18,12 +19,7 @@ type route struct {
 	HandlerFunc http.HandlerFunc
 }
 
-func initRouters(ps pms.PolicyStoreManager) (*[]route, error) {
-
-	manager, err := NewRestService(ps)
-	if err != nil {
-		return nil, err
-	}
+func initRouters(manager *RESTService) (*[]route, error) {
 
 	svcRoutes := []route{}
 
@@ -56,6 +52,20 @@ func initRouters(ps pms.PolicyStoreManager) (*[]route, error) {
 			manager.GetPolicy,
 		},
 
+		{
+			"UpdatePolicy",
+			"PUT",
+			svcs.PolicyMgmtPath + "service/{serviceName}/policy/{policyID}",
+			manager.UpdatePolicy,
+		},
+
+		{
+			"PatchPolicy",
+			"PATCH",
+			svcs.PolicyMgmtPath + "service/{serviceName}/policy/{policyID}",
+			manager.PatchPolicy,
+		},
+
 		{
 			"ListPolicies",
 			"GET",
@@ -91,6 +101,20 @@ func initRouters(ps pms.PolicyStoreManager) (*[]route, error) {
 			manager.GetRolePolicy,
 		},
 
+		{
+			"UpdateRolePolicy",
+			"PUT",
+			svcs.PolicyMgmtPath + "service/{serviceName}/role-policy/{rolePolicyID}",
+			manager.UpdateRolePolicy,
+		},
+
+		{
+			"PatchRolePolicy",
+			"PATCH",
+			svcs.PolicyMgmtPath + "service/{serviceName}/role-policy/{rolePolicyID}",
+			manager.PatchRolePolicy,
+		},
+
 		{
 			"ListRolePolicies",
 			"GET",
@@ -129,6 +153,20 @@ func initRouters(ps pms.PolicyStoreManager) (*[]route, error) {
 			manager.GetService,
 		},
 
+		{
+			"UpdateService",
+			"PUT",
+			svcs.PolicyMgmtPath + "service/{serviceName}",
+			manager.UpdateService,
+		},
+
+		{
+			"PatchService",
+			"PATCH",
+			svcs.PolicyMgmtPath + "service/{serviceName}",
+			manager.PatchService,
+		},
+
 		{
 			"ListServices",
 			"GET",
@@ -174,6 +212,20 @@ func initRouters(ps pms.PolicyStoreManager) (*[]route, error) {
 			manager.GetFunction,
 		},
 
+		{
+			"UpdateFunction",
+			"PUT",
+			svcs.PolicyMgmtPath + "function/{functionName}",
+			manager.UpdateFunction,
+		},
+
+		{
+			"PatchFunction",
+			"PATCH",
+			svcs.PolicyMgmtPath + "function/{functionName}",
+			manager.PatchFunction,
+		},
+
 		{
 			"ListFunctions",
 			"GET",
@@ -183,6 +235,85 @@ func initRouters(ps pms.PolicyStoreManager) (*[]route, error) {
 	}
 	svcRoutes = append(svcRoutes, functionManageRoutes...)
 
+	transactionRoutes := []route{
+		{
+			"ExecuteTransaction",
+			"POST",
+			svcs.PolicyMgmtPath + "transaction",
+			manager.ExecuteTransaction,
+		},
+	}
+	svcRoutes = append(svcRoutes, transactionRoutes...)
+
+	simulationRoutes := []route{
+		{
+			"Simulate",
+			"POST",
+			svcs.PolicyMgmtPath + "simulate",
+			manager.Simulate,
+		},
+	}
+	svcRoutes = append(svcRoutes, simulationRoutes...)
+
+	historyRoutes := []route{
+		{
+			"ListServiceHistory",
+			"GET",
+			svcs.PolicyMgmtPath + "service/{serviceName}/history",
+			manager.ListHistory,
+		},
+
+		{
+			"RollbackService",
+			"POST",
+			svcs.PolicyMgmtPath + "service/{serviceName}/rollback",
+			manager.Rollback,
+		},
+
+		{
+			"ListPolicyHistory",
+			"GET",
+			svcs.PolicyMgmtPath + "service/{serviceName}/policy/{policyID}/history",
+			manager.ListHistory,
+		},
+
+		{
+			"RollbackPolicy",
+			"POST",
+			svcs.PolicyMgmtPath + "service/{serviceName}/policy/{policyID}/rollback",
+			manager.Rollback,
+		},
+
+		{
+			"ListRolePolicyHistory",
+			"GET",
+			svcs.PolicyMgmtPath + "service/{serviceName}/role-policy/{rolePolicyID}/history",
+			manager.ListHistory,
+		},
+
+		{
+			"RollbackRolePolicy",
+			"POST",
+			svcs.PolicyMgmtPath + "service/{serviceName}/role-policy/{rolePolicyID}/rollback",
+			manager.Rollback,
+		},
+
+		{
+			"ListFunctionHistory",
+			"GET",
+			svcs.PolicyMgmtPath + "function/{functionName}/history",
+			manager.ListHistory,
+		},
+
+		{
+			"RollbackFunction",
+			"POST",
+			svcs.PolicyMgmtPath + "function/{functionName}/rollback",
+			manager.Rollback,
+		},
+	}
+	svcRoutes = append(svcRoutes, historyRoutes...)
+
 	discoverRequestManageRoutes := []route{
 		{
 			"GetAllDiscoverRequests",
@@ -233,7 +364,18 @@ func initRouters(ps pms.PolicyStoreManager) (*[]route, error) {
 }
 
 func NewRouter(ps pms.PolicyStoreManager) (*mux.Router, error) {
-	routes, err := initRouters(ps)
+	return NewRouterWithAuthorizer(ps, nil)
+}
+
+// NewRouterWithAuthorizer creates the router whose management calls are authenticated and authorized by authorizer,
+// which is the case when enable-authz is set
+func NewRouterWithAuthorizer(ps pms.PolicyStoreManager, authorizer *pmsimpl.Authorizer) (*mux.Router, error) {
+	manager, err := NewRestService(ps)
+	if err != nil {
+		return nil, err
+	}
+	manager.Authorizer = authorizer
+	routes, err := initRouters(manager)
 	if err != nil {
 		return nil, err
 	}
@@ -241,7 +383,7 @@ func NewRouter(ps pms.PolicyStoreManager) (*mux.Router, error) {
 
 	for _, route := range *routes {
 		var handler http.Handler
-		handler = route.HandlerFunc
+		handler = manager.authzHandler(route.Name, route.HandlerFunc)
 		router.
 			Methods(route.Method).
 			Path(route.Pattern).
@@ -251,3 +393,17 @@ func NewRouter(ps pms.PolicyStoreManager) (*mux.Router, error) {
 
 	return router, nil
 }
+
+// NewTenantRouter creates the routers of the tenants, and dispatches every request to the one of its tenant, which is
+// chosen by the Speedle-Tenant header or the path, e.g. /policy-mgmt/v1/tenant/acme/service
+func NewTenantRouter(tenants map[string]*pmsimpl.Tenant) (http.Handler, error) {
+	handlers := make(map[string]http.Handler, len(tenants))
+	for name, tenant := range tenants {
+		router, err := NewRouterWithAuthorizer(tenant.PolicyStore, tenant.Authorizer)
+		if err != nil {
+			return nil, err
+		}
+		handlers[name] = router
+	}
+	return svcs.TenantHandler(svcs.PolicyMgmtPath, handlers), nil
+}
@@ -0,0 +1,88 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package pmsrest
+
+import (
+	"net/http"
+
+	"github.com/teramoby/speedle-plus/api/ads"
+	"github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/eval"
+	"github.com/teramoby/speedle-plus/pkg/httputils"
+	"github.com/teramoby/speedle-plus/pkg/logging"
+	"github.com/teramoby/speedle-plus/pkg/store"
+	"github.com/teramoby/speedle-plus/pkg/svcs/pmsimpl"
+)
+
+type SimulationRequest struct {
+	// Operations are the proposed changes in the format of a transaction
+	Operations []*pms.Operation `json:"operations"`
+	// Requests are replayed through the live and the proposed policies, the recorded discover requests are
+	// replayed if there is no request
+	Requests []*ads.RequestContext `json:"requests,omitempty"`
+}
+
+// Simulate reports the requests whose decisions or granted roles would be changed by the proposed operations,
+// nothing is changed. It is authorized like a transaction of the operations.
+func (mgr *RESTService) Simulate(w http.ResponseWriter, r *http.Request) {
+	var request SimulationRequest
+	if err := decodeRequestBody(r, &request); err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteSimpleFailedAuditLog("Simulate", nil, err.Error())
+		return
+	}
+	ctxFields := map[string]interface{}{"operations": request.Operations, "requestCount": len(request.Requests)}
+
+	if err := mgr.authorizeOperations(r, request.Operations); err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteFailedAuditLog("Simulate", ctxFields, err.Error())
+		return
+	}
+	if len(request.Operations) > 0 {
+		if err := pmsimpl.CheckOperations(request.Operations, mgr.PolicyStore); err != nil {
+			httputils.HandleError(w, err)
+			logging.WriteFailedAuditLog("Simulate", ctxFields, err.Error())
+			return
+		}
+	}
+
+	requests := request.Requests
+	if len(requests) == 0 {
+		discoverRequestMgr, ok := mgr.PolicyStore.(store.DiscoverRequestManager)
+		if !ok {
+			err := errors.Errorf(errors.InvalidRequest, "no request to replay, and %q policy store doesn't record discover requests", mgr.PolicyStore.Type())
+			httputils.HandleError(w, err)
+			logging.WriteFailedAuditLog("Simulate", ctxFields, err.Error())
+			return
+		}
+		if err := mgr.authorize(r, pmsimpl.DiscoverResource(""), pmsimpl.ActionGet); err != nil {
+			httputils.HandleError(w, err)
+			logging.WriteFailedAuditLog("Simulate", ctxFields, err.Error())
+			return
+		}
+		var err error
+		if requests, _, err = discoverRequestMgr.GetDiscoverRequests(""); err != nil {
+			httputils.HandleError(w, err)
+			logging.WriteFailedAuditLog("Simulate", ctxFields, err.Error())
+			return
+		}
+	}
+
+	live, err := mgr.PolicyStore.ReadPolicyStore()
+	if err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteFailedAuditLog("Simulate", ctxFields, err.Error())
+		return
+	}
+	report, err := eval.Simulate(live, request.Operations, requests)
+	if err != nil {
+		httputils.HandleError(w, err)
+		logging.WriteFailedAuditLog("Simulate", ctxFields, err.Error())
+		return
+	}
+
+	logging.WriteSucceededAuditLog("Simulate", ctxFields, map[string]interface{}{"requests": report.Requests, "changed": report.Changed})
+	httputils.SendOKResponse(w, report)
+}
@@ -0,0 +1,103 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package pmsrest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/teramoby/speedle-plus/api/ads"
+	pmsapi "github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/eval"
+	"github.com/teramoby/speedle-plus/pkg/store"
+	"github.com/teramoby/speedle-plus/pkg/svcs"
+)
+
+func TestSimulate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pmssimulate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ps, err := store.NewStore("file", map[string]interface{}{"FileLocation": filepath.Join(dir, "policies.json")})
+	if err != nil {
+		t.Fatal("fail to create store:", err)
+	}
+	err = ps.CreateService(&pmsapi.Service{
+		Name: "service1",
+		Type: pmsapi.TypeApplication,
+		Policies: []*pmsapi.Policy{{
+			ID:          "policy1",
+			Effect:      pmsapi.Grant,
+			Principals:  [][]string{{"user:alice"}},
+			Permissions: []*pmsapi.Permission{{Resource: "/books", Actions: []string{"get"}}},
+		}},
+	})
+	if err != nil {
+		t.Fatal("fail to create service:", err)
+	}
+	service, err := ps.GetService("service1")
+	if err != nil {
+		t.Fatal("fail to get service:", err)
+	}
+	router, err := NewRouter(ps)
+	if err != nil {
+		t.Fatal("fail to create router:", err)
+	}
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	simulate := func(request *SimulationRequest) (int, *eval.SimulationReport) {
+		body, _ := json.Marshal(request)
+		resp, err := http.Post(server.URL+svcs.PolicyMgmtPath+"simulate", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal("fail to send request:", err)
+		}
+		defer resp.Body.Close()
+		var report eval.SimulationReport
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+				t.Fatal("fail to decode response:", err)
+			}
+		}
+		return resp.StatusCode, &report
+	}
+	alice := &ads.RequestContext{
+		Subject:     &ads.Subject{Principals: []*ads.Principal{{Type: "user", Name: "alice"}}},
+		ServiceName: "service1",
+		Resource:    "/books",
+		Action:      "get",
+	}
+	policyID := service.Policies[0].ID
+
+	code, report := simulate(&SimulationRequest{
+		Operations: []*pmsapi.Operation{{Op: pmsapi.OpDelete, Kind: pmsapi.KindPolicy, ServiceName: "service1", ID: policyID}},
+		Requests:   []*ads.RequestContext{alice},
+	})
+	if code != http.StatusOK {
+		t.Fatalf("expected status 200, but got %d", code)
+	}
+	if report.Changed != 1 || !report.Differences[0].Live.Allowed || report.Differences[0].Proposed.Allowed ||
+		len(report.Differences[0].ResponsiblePolicies) != 1 || report.Differences[0].ResponsiblePolicies[0] != policyID {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if service, _ = ps.GetService("service1"); len(service.Policies) != 1 {
+		t.Error("the policy should not be deleted by simulation")
+	}
+
+	// the operations are checked like a transaction
+	code, _ = simulate(&SimulationRequest{
+		Operations: []*pmsapi.Operation{{Op: "drop", Kind: pmsapi.KindPolicy, ServiceName: "service1", ID: policyID}},
+		Requests:   []*ads.RequestContext{alice},
+	})
+	if code != http.StatusBadRequest {
+		t.Errorf("expected status 400, but got %d", code)
+	}
+}
@@ -0,0 +1,103 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package pmsrest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pmsapi "github.com/teramoby/speedle-plus/api/pms"
+	"github.com/teramoby/speedle-plus/pkg/cfg"
+	"github.com/teramoby/speedle-plus/pkg/svcs"
+	"github.com/teramoby/speedle-plus/pkg/svcs/pmsimpl"
+)
+
+func sendTenantRequest(t *testing.T, server *httptest.Server, method, path string, obj interface{}, tenant string) int {
+	var body []byte
+	if obj != nil {
+		var err error
+		if body, err = json.Marshal(obj); err != nil {
+			t.Fatal("failed to marshal request data:", err)
+		}
+	}
+	req, err := http.NewRequest(method, server.URL+svcs.PolicyMgmtPath+path, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal("failed to make test request:", err)
+	}
+	if len(tenant) != 0 {
+		req.Header.Set(svcs.TenantHeader, tenant)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal("failed to get response:", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestTenants(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pmstenants")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	conf := &cfg.Config{
+		StoreConfig: &cfg.StoreConfig{
+			StoreType:  cfg.StorageTypeFile,
+			StoreProps: map[string]interface{}{"FileLocation": filepath.Join(dir, "policies.json")},
+		},
+		Tenants: []string{"acme", "globex"},
+	}
+	tenants, err := pmsimpl.NewTenants(conf, nil)
+	if err != nil {
+		t.Fatal("fail to create tenants:", err)
+	}
+	router, err := NewTenantRouter(tenants)
+	if err != nil {
+		t.Fatal("fail to create router:", err)
+	}
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	service := &pmsapi.Service{Name: "service1", Type: pmsapi.TypeApplication}
+	if code := sendTenantRequest(t, server, "POST", "tenant/acme/service", service, ""); code != http.StatusCreated {
+		t.Fatalf("fail to create service in tenant, status %d", code)
+	}
+
+	tests := []struct {
+		path   string
+		tenant string
+		code   int
+	}{
+		{"tenant/acme/service/service1", "", http.StatusOK},
+		{"service/service1", "acme", http.StatusOK},
+		{"tenant/acme/service/service1", "acme", http.StatusOK},
+		{"service/service1", "", http.StatusNotFound},
+		{"tenant/globex/service/service1", "", http.StatusNotFound},
+		{"tenant/acme/service/service1", "globex", http.StatusBadRequest},
+		{"tenant/initech/service", "", http.StatusNotFound},
+		{"service", "initech", http.StatusNotFound},
+	}
+	for _, test := range tests {
+		if code := sendTenantRequest(t, server, "GET", test.path, nil, test.tenant); code != test.code {
+			t.Errorf("GET %s of tenant %q: expected status %d, but got %d", test.path, test.tenant, test.code, code)
+		}
+	}
+
+	// the limits are checked against the entities of every tenant
+	defer func(max int64) { pmsimpl.MaxServiceNum = max }(pmsimpl.MaxServiceNum)
+	pmsimpl.MaxServiceNum = 1
+	if code := sendTenantRequest(t, server, "POST", "service", &pmsapi.Service{Name: "service2"}, "acme"); code != http.StatusForbidden {
+		t.Errorf("should reach the maximum number of service of tenant, but got status %d", code)
+	}
+	if code := sendTenantRequest(t, server, "POST", "service", &pmsapi.Service{Name: "service2"}, "globex"); code != http.StatusCreated {
+		t.Errorf("fail to create service in another tenant, status %d", code)
+	}
+}
@@ -0,0 +1,56 @@
+//Copyright (c) 2018, Oracle and/or its affiliates. All rights reserved.
+//Licensed under the Universal Permissive License (UPL) Version 1.0 as shown at http://oss.oracle.com/licenses/upl.
+
+package svcs
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/teramoby/speedle-plus/pkg/errors"
+	"github.com/teramoby/speedle-plus/pkg/httputils"
+)
+
+// TenantHandler dispatches a request to the handler of its tenant, which is chosen by the tenant header or the tenant
+// path segment following prefix. The segment is removed from the path before the request is dispatched, and the
+// requests choosing no tenant go to the handler of the default tenant, whose name is empty.
+func TenantHandler(prefix string, handlers map[string]http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant := r.Header.Get(TenantHeader)
+		if rest := strings.TrimPrefix(r.URL.Path, prefix+TenantPathSegment); rest != r.URL.Path {
+			pathTenant, path := rest, ""
+			if i := strings.Index(rest, "/"); i >= 0 {
+				pathTenant, path = rest[:i], rest[i+1:]
+			}
+			if len(tenant) != 0 && tenant != pathTenant {
+				httputils.HandleError(w, errors.Errorf(errors.InvalidRequest, "tenant %q in path does not match tenant %q in header", pathTenant, tenant))
+				return
+			}
+			tenant = pathTenant
+			r = r.Clone(r.Context())
+			r.URL.Path = prefix + path
+			r.URL.RawPath = ""
+		}
+		handler, ok := handlers[tenant]
+		if !ok {
+			httputils.HandleError(w, errors.Errorf(errors.EntityNotFound, "tenant %q is not found", tenant))
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// TenantFromContext returns the tenant chosen by the metadata of a gRPC call, which is empty for the default tenant
+func TenantFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(TenantHeader); len(values) != 0 {
+		return values[0]
+	}
+	return ""
+}
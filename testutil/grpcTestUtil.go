@@ -116,7 +116,7 @@ func (test *GRpcTest) PreExecute(testcase *TestCase, tc *TestContext) error {
 	case METHOD_DELETE_ROLEPOLICY:
 		testcase.SetPreTestFunc(PreGetDeletePolicyTest)
 		break
-	case METHOD_IS_ALLOWED:
+	case METHOD_IS_ALLOWED, METHOD_IS_ALLOWED_BATCH:
 		//use default verification method: VerifyGRpcTestByDefault
 		break
 	case METHOD_GET_GRANTED_ROLES:
@@ -202,6 +202,12 @@ func (test *GRpcTest) Execute(testcase *TestCase, tc *TestContext) error {
 			grpcTD.OutputBody = resp
 		}
 		break
+	case METHOD_IS_ALLOWED_BATCH:
+		resp, err = test.Client.adsClient.IsAllowedBatch(context.Background(), grpcTD.InputBody.(*(adsPB.BatchRequest)))
+		if err == nil {
+			grpcTD.OutputBody = resp
+		}
+		break
 	case METHOD_GET_GRANTED_ROLES:
 		resp, err = test.Client.adsClient.GetAllGrantedRoles(context.Background(), grpcTD.InputBody.(*(adsPB.ContextRequest)))
 		if err == nil {
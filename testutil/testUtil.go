@@ -27,6 +27,7 @@ const (
 	METHOD_QUERY_ROLEPOLICY        = "queryRolePolicy"
 	METHOD_DELETE_ROLEPOLICY       = "deleteRolePolicy"
 	METHOD_IS_ALLOWED              = "isAllowed"
+	METHOD_IS_ALLOWED_BATCH        = "isAllowedBatch"
 	METHOD_GET_GRANTED_PERMISSIONS = "getPerssions"
 	METHOD_GET_GRANTED_ROLES       = "getRoles"
 	METHOD_SLEEP                   = "sleep"